@@ -32,6 +32,8 @@ import (
 	"github.com/DxChainNetwork/godx/eth"
 	"github.com/DxChainNetwork/godx/node"
 	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/storage/storageclient/s3gateway"
+	"github.com/DxChainNetwork/godx/storage/storageclient/webdavgateway"
 	"github.com/DxChainNetwork/godx/storage/storagehost"
 	"github.com/naoina/toml"
 )
@@ -75,9 +77,11 @@ type ethstatsConfig struct {
 }
 
 type gethConfig struct {
-	Eth      eth.Config
-	Node     node.Config
-	Ethstats ethstatsConfig
+	Eth       eth.Config
+	Node      node.Config
+	Ethstats  ethstatsConfig
+	S3Gateway s3gateway.Config
+	WebDAV    webdavgateway.Config
 }
 
 func loadConfig(file string, cfg *gethConfig) error {
@@ -110,8 +114,10 @@ func defaultNodeConfig() node.Config {
 func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 	// Load defaults.
 	cfg := gethConfig{
-		Eth:  eth.DefaultConfig,
-		Node: defaultNodeConfig(),
+		Eth:       eth.DefaultConfig,
+		Node:      defaultNodeConfig(),
+		S3Gateway: s3gateway.DefaultConfig,
+		WebDAV:    webdavgateway.DefaultConfig,
 	}
 
 	// Load config file.
@@ -131,6 +137,8 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 	if ctx.GlobalIsSet(utils.EthStatsURLFlag.Name) {
 		cfg.Ethstats.URL = ctx.GlobalString(utils.EthStatsURLFlag.Name)
 	}
+	utils.SetS3GatewayConfig(ctx, &cfg.S3Gateway)
+	utils.SetWebDAVConfig(ctx, &cfg.WebDAV)
 
 	return stack, cfg
 }
@@ -146,6 +154,16 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 	if cfg.Ethstats.URL != "" {
 		utils.RegisterEthStatsService(stack, cfg.Ethstats.URL)
 	}
+
+	// Add the S3 gateway of the storage client if requested.
+	if cfg.S3Gateway.Enabled {
+		utils.RegisterS3GatewayService(stack, cfg.S3Gateway)
+	}
+
+	// Add the WebDAV gateway of the storage client if requested.
+	if cfg.WebDAV.Enabled {
+		utils.RegisterWebDAVService(stack, cfg.WebDAV)
+	}
 	return stack
 }
 
@@ -107,6 +107,7 @@ var (
 		utils.MinerLegacyExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerfiyFlag,
+		utils.MinerStandbyFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -132,6 +133,15 @@ var (
 		utils.EVMInterpreterFlag,
 		configFileFlag,
 		utils.StorageRoleFlag,
+		utils.StorageOnlyPeersFlag,
+		utils.S3GatewayEnabledFlag,
+		utils.S3GatewayListenAddrFlag,
+		utils.S3GatewayPortFlag,
+		utils.S3GatewayAccessKeysFlag,
+		utils.WebDAVEnabledFlag,
+		utils.WebDAVListenAddrFlag,
+		utils.WebDAVPortFlag,
+		utils.WebDAVUsersFlag,
 	}
 
 	rpcFlags = []cli.Flag{
@@ -2,9 +2,13 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/DxChainNetwork/godx/cmd/utils"
 	"github.com/DxChainNetwork/godx/common"
@@ -78,6 +82,142 @@ var (
 		Name:  "newpath",
 		Usage: "New absolute file path",
 	}
+
+	dirPathFlag = cli.StringFlag{
+		Name:  "dirpath",
+		Usage: "Path of the directory, default to the root directory",
+	}
+
+	listOffsetFlag = cli.IntFlag{
+		Name:  "offset",
+		Usage: "Number of entries to be skipped when listing the directory",
+	}
+
+	listLimitFlag = cli.IntFlag{
+		Name:  "limit",
+		Usage: "Max number of entries to be listed, 0 means no limit",
+	}
+
+	recursiveFlag = cli.BoolFlag{
+		Name:  "recursive, r",
+		Usage: "Remove the directory and all its contents recursively",
+	}
+
+	policyRegionsFlag = cli.StringFlag{
+		Name:  "regions",
+		Usage: "Comma separated regions that the storage hosts are allowed to be located in",
+	}
+
+	policyMaxHostsPerOperatorFlag = cli.StringFlag{
+		Name:  "maxHostsPerOperator",
+		Usage: "Max number of storage hosts run by the same operator",
+	}
+
+	policyMaxHostsPerRegionFlag = cli.StringFlag{
+		Name:  "maxHostsPerRegion",
+		Usage: "Max number of storage hosts located in the same region",
+	}
+
+	policyMinUptimeFlag = cli.StringFlag{
+		Name:  "minUptime",
+		Usage: "Minimum uptime rate of the storage hosts",
+	}
+
+	policyMaxStoragePriceFlag = cli.StringFlag{
+		Name:  "maxStoragePrice",
+		Usage: "CURRENCY - the max storage price per block per byte of the storage hosts",
+	}
+
+	policyMaxUploadPriceFlag = cli.StringFlag{
+		Name:  "maxUploadPrice",
+		Usage: "CURRENCY - the max upload bandwidth price per byte of the storage hosts",
+	}
+
+	policyMaxDownloadPriceFlag = cli.StringFlag{
+		Name:  "maxDownloadPrice",
+		Usage: "CURRENCY - the max download bandwidth price per byte of the storage hosts",
+	}
+
+	policyMaxContractPriceFlag = cli.StringFlag{
+		Name:  "maxContractPrice",
+		Usage: "CURRENCY - the max contract price of the storage hosts",
+	}
+
+	evaluatorFlag = cli.StringFlag{
+		Name:  "evaluator",
+		Usage: "Name of the evaluator used to evaluate the storage hosts",
+	}
+
+	evaluationWeightsFlag = cli.StringFlag{
+		Name:  "weights",
+		Usage: "Comma separated factor:weight pairs used by the weighted evaluator",
+	}
+
+	versioningDisableFlag = cli.BoolFlag{
+		Name:  "disable",
+		Usage: "Disable the versioning of the directory",
+	}
+
+	maxVersionsFlag = cli.Uint64Flag{
+		Name:  "maxVersions",
+		Usage: "Max number of versions retained for each file, 0 means no limit",
+	}
+
+	maxVersionAgeFlag = cli.StringFlag{
+		Name:  "maxAge",
+		Usage: "Max age of the retained versions, such as 720h. Empty means no limit",
+	}
+
+	versionIDFlag = cli.StringFlag{
+		Name:  "version",
+		Usage: "ID of the file version",
+	}
+
+	shareRecipientFlag = cli.StringFlag{
+		Name:  "recipient",
+		Usage: "Enode url of the node that the file is shared with, empty means anyone holding the token",
+	}
+
+	sharePrepayFlag = cli.BoolFlag{
+		Name:  "prepay",
+		Usage: "Prepay the downloads from the storage hosts for the storage clients without contracts",
+	}
+
+	shareTokenFlag = cli.StringFlag{
+		Name:  "token",
+		Usage: "Share token of the shared file",
+	}
+
+	filePriorityFlag = cli.StringFlag{
+		Name:  "priority",
+		Usage: "Scheduling priority of the uploads and downloads: low, normal, or high",
+	}
+
+	uploadCipherFlag = cli.StringFlag{
+		Name:  "cipher",
+		Usage: "Cipher used to encrypt the uploaded file: twofish-gcm or xchacha20",
+	}
+
+	bandwidthScheduleFlag = cli.StringFlag{
+		Name:  "bandwidthSchedule",
+		Usage: "Semicolon separated bandwidth windows, each in format: days start-end uploadspeed downloadspeed",
+	}
+
+	uploadOnlyInWindowFlag = cli.StringFlag{
+		Name:  "uploadOnlyInWindow",
+		Usage: "Whether the repair loops only upload within the bandwidth windows: true or false",
+	}
+
+	syncLocalDirFlag = cli.StringFlag{
+		Name:  "localdir",
+		Usage: "Absolute path of the local directory to be synced",
+	}
+
+	syncDeletePolicyFlag = cli.StringFlag{
+		Name:  "delete",
+		Usage: "Deletion policy of the sync folder: keep, or delete the synced file when the local file is deleted",
+		Value: "keep",
+	}
 )
 
 var storageClientCommand = cli.Command{
@@ -129,6 +269,22 @@ will display detailed host information based on the provided hostID, such as dep
 allowed storage time, and etc.`,
 		},
 
+		{
+			Name:      "migrateHost",
+			Usage:     "Migrate the data stored in the storage host to the replacement storage hosts",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(migrateHost),
+			Flags: []cli.Flag{
+				storageHostIDFlag,
+			},
+			Description: `
+			gdx sclient migrateHost [--hostid arg]
+
+will cancel the contract signed with the storage host specified by the hostID, and repair the
+data stored in the storage host to the replacement storage hosts. The canceled contract will not
+be renewed and will be left to lapse`,
+		},
+
 		{
 			Name:      "hostrank",
 			Usage:     "Retrieve host's ranking status for each storage host learnt by the client",
@@ -217,9 +373,24 @@ flag must be used along with this flag to specify the account address`,
 				contractPeriodFlag,
 				contractHostFlag,
 				contractFundFlag,
+				policyRegionsFlag,
+				policyMaxHostsPerOperatorFlag,
+				policyMaxHostsPerRegionFlag,
+				policyMinUptimeFlag,
+				policyMaxStoragePriceFlag,
+				policyMaxUploadPriceFlag,
+				policyMaxDownloadPriceFlag,
+				policyMaxContractPriceFlag,
+				evaluatorFlag,
+				evaluationWeightsFlag,
+				bandwidthScheduleFlag,
+				uploadOnlyInWindowFlag,
 			},
 			Description: `
-			gdx sclient setConfig [--period arg] [--host arg] [--fund arg]
+			gdx sclient setConfig [--period arg] [--host arg] [--fund arg] [--regions arg] [--maxHostsPerOperator arg]
+				[--maxHostsPerRegion arg] [--minUptime arg] [--maxStoragePrice arg] [--maxUploadPrice arg]
+				[--maxDownloadPrice arg] [--maxContractPrice arg] [--evaluator arg] [--weights arg]
+				[--bandwidthSchedule arg] [--uploadOnlyInWindow arg]
 		
 will configure the client settings used for contract creation, file upload, download, and etc. There are
 multiple flags can be used along with this command to specify the setting:
@@ -227,6 +398,29 @@ multiple flags can be used along with this command to specify the setting:
 2. host: specifies the number of storage hosts that the client want to sign contracts with
 3. fund: specifies the amount of money the client wants to be used for the storage service
 
+The following flags specify the host selection policy, which is enforced during contract formation and
+renewal. Setting a flag to 0 (or empty regions) disables the corresponding constraint:
+1. regions: comma separated regions that the storage hosts are allowed to be located in
+2. maxHostsPerOperator: max number of storage hosts sharing the same payment address
+3. maxHostsPerRegion: max number of storage hosts located in the same region
+4. minUptime: minimum uptime rate of the storage hosts, for example 0.9 or 90%
+5. maxStoragePrice, maxUploadPrice, maxDownloadPrice, maxContractPrice: price ceilings of the storage hosts
+
+The following flags specify how the storage hosts are evaluated:
+1. evaluator: name of the evaluator, the built-in evaluators are default and weighted
+2. weights: factor weights of the weighted evaluator, for example "performance:2,downloadprice:1".
+   Available factors are presence, deposit, interaction, contractprice, storageremaining, uptime,
+   uploadprice, downloadprice and performance. Each weight is ranged from 0 to 10
+
+The following flags specify the bandwidth schedule, which is applied in local time:
+1. bandwidthSchedule: semicolon separated bandwidth windows, for example
+   "mon-fri 09:00-18:00 1Mbps 5Mbps; sat,sun 00:00-24:00 0 0". Each window consists of the days of
+   week (* means every day), the time range, the max upload speed and the max download speed (0 means
+   unlimited). If the end is not after the start, the window ends on the next day. Out of all windows,
+   the max upload speed and max download speed of the client settings are applied. Empty value clears
+   the bandwidth schedule
+2. uploadOnlyInWindow: if true, the file repair only uploads within the bandwidth windows
+
 units:
 currency: [camel, gcamel, dx]
 time: [h, b, d, w, m, y] -> hour, block, day, week, month, year
@@ -242,13 +436,16 @@ Note: without using any of those flags, default settings will be used`,
 			Flags: []cli.Flag{
 				fileSourceFlag,
 				fileDestinationFlag,
+				uploadCipherFlag,
 			},
 			Description: `
-			gdx sclient upload [--src arg] [--dst arg]
+			gdx sclient upload [--src arg] [--dst arg] [--cipher arg]
 		
 will upload the file specified by the client to the storage hosts. This command must be used along
 with two flags to specify the source of the file that is going to be uploaded, and the destination
-that the file is going to be uploaded to. Note: the src must be absolute path: /home/ubuntu/upload.file`,
+that the file is going to be uploaded to. Note: the src must be absolute path: /home/ubuntu/upload.file
+The file is encrypted with a random key using the cipher specified by --cipher, which is twofish-gcm
+by default, or xchacha20`,
 		},
 
 		{
@@ -314,6 +511,315 @@ with this command`,
 will delete the file uploaded by the storage client. This filepath flag must be used along
 with this command to specify which file will be deleted`,
 		},
+		{
+			Name:      "ls",
+			Usage:     "List the directories and files under a directory",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(listDir),
+			Flags: []cli.Flag{
+				dirPathFlag,
+				listOffsetFlag,
+				listLimitFlag,
+			},
+			Description: `
+			gdx sclient ls [--dirpath arg] [--offset arg] [--limit arg]
+
+will display the aggregated information of the directory, including the number of files, total size,
+health and stuck segments, along with the sub directories and files directly under the directory.
+The offset and limit flags can be used to page through large directories`,
+		},
+
+		{
+			Name:      "mv",
+			Usage:     "Rename a directory along with all its contents",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(dirRenaming),
+			Flags: []cli.Flag{
+				prevFilePathFlag,
+				newFilePathFlag,
+			},
+			Description: `
+			gdx sclient mv [--prevpath arg] [--newpath arg]
+
+will rename the directory specified by prevpath to newpath, including all the files and sub
+directories under it`,
+		},
+
+		{
+			Name:      "rm",
+			Usage:     "Delete a file, or a directory along with all its contents",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(removeFileOrDir),
+			Flags: []cli.Flag{
+				filePathFlag,
+				recursiveFlag,
+			},
+			Description: `
+			gdx sclient rm [--filepath arg] [-r]
+
+will delete the file uploaded by the storage client. If the -r flag is used, the directory specified
+by the filepath will be deleted along with all the files and sub directories under it`,
+		},
+
+		{
+			Name:      "versioning",
+			Usage:     "Set the versioning policy of a directory",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(setVersioning),
+			Flags: []cli.Flag{
+				dirPathFlag,
+				versioningDisableFlag,
+				maxVersionsFlag,
+				maxVersionAgeFlag,
+			},
+			Description: `
+			gdx sclient versioning [--dirpath arg] [--maxVersions arg] [--maxAge arg] [--disable]
+
+will enable the versioning of the directory. When a file under the directory is overwritten or
+deleted, the previous version is retained along with its sectors, until it exceeds the maxVersions
+or maxAge limit. The disable flag can be used to disable the versioning of the directory`,
+		},
+
+		{
+			Name:      "versions",
+			Usage:     "List the retained versions of a file",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(listVersions),
+			Flags: []cli.Flag{
+				filePathFlag,
+			},
+			Description: `
+			gdx sclient versions [--filepath arg]
+
+will display the retained versions of the file, with the newest version first`,
+		},
+
+		{
+			Name:      "restore",
+			Usage:     "Restore a file to a retained version",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(restoreVersion),
+			Flags: []cli.Flag{
+				filePathFlag,
+				versionIDFlag,
+			},
+			Description: `
+			gdx sclient restore [--filepath arg] [--version arg]
+
+will restore the file to the version. The current file, if exists, is retained as a new version`,
+		},
+
+		{
+			Name:      "purge",
+			Usage:     "Permanently delete the retained versions of a file",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(purgeVersions),
+			Flags: []cli.Flag{
+				filePathFlag,
+				versionIDFlag,
+			},
+			Description: `
+			gdx sclient purge [--filepath arg] [--version arg]
+
+will permanently delete the version of the file. If the version flag is not used, all versions
+of the file will be deleted`,
+		},
+
+		{
+			Name:      "share",
+			Usage:     "Create the share token of a file, which can be used by others to download the file",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(shareFile),
+			Flags: []cli.Flag{
+				filePathFlag,
+				shareRecipientFlag,
+				sharePrepayFlag,
+				fileDestinationFlag,
+			},
+			Description: `
+			gdx sclient share [--filepath arg] [--recipient arg] [--prepay] [--dst arg]
+
+will create the share token of the file signed by the local node, which contains the sector roots,
+erasure code params and the cipher key of the file. If the recipient flag is used to specify the
+enode url of a node, the cipher key is encrypted with the public key of the node, and only that node
+is able to download the file. Otherwise, anyone holding the token is able to download the file.
+If the prepay flag is used, the downloads from the hosts storing the file are prepaid with the contracts
+of the local node, so that the file could be downloaded from the hosts the downloader has no contract with.
+The prepaid balance is spendable by anyone able to download the file.
+The token will be written to the local file if the dst flag is used, or be displayed otherwise`,
+		},
+
+		{
+			Name:      "downloadShared",
+			Usage:     "Download the file shared by others to the local machine",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(downloadShared),
+			Flags: []cli.Flag{
+				shareTokenFlag,
+				fileSourceFlag,
+				fileDestinationFlag,
+			},
+			Description: `
+			gdx sclient downloadShared [--token arg | --src arg] [--dst arg]
+
+will download the file shared by the share token to the local machine. The token can be specified
+by the token flag, or read from the local file specified by the src flag. The sectors are downloaded
+from the storage hosts that the client has signed contracts with, and from the other storage hosts
+storing the shared file if the downloads are prepaid by the sharer. Thus the client should have signed
+contracts with enough hosts storing the shared file, unless the downloads are prepaid. Note, the download destination must be absolute path.`,
+		},
+
+		{
+			Name:      "priority",
+			Usage:     "Set the scheduling priority of a file or all files under a directory",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(setFilePriority),
+			Flags: []cli.Flag{
+				filePathFlag,
+				dirPathFlag,
+				filePriorityFlag,
+			},
+			Description: `
+			gdx sclient priority [--filepath arg | --dirpath arg] [--priority arg]
+
+will set the scheduling priority of the file, or all files under the directory recursively, to
+low, normal, or high. The segments of the files with higher priority are uploaded, repaired and
+downloaded first. The priority of the newly uploaded files is normal`,
+		},
+
+		{
+			Name:      "pause",
+			Usage:     "Pause the uploads of a file or all files under a directory",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(pauseUploads),
+			Flags: []cli.Flag{
+				filePathFlag,
+				dirPathFlag,
+			},
+			Description: `
+			gdx sclient pause [--filepath arg | --dirpath arg]
+
+will pause the upload and repair of the file, or all files under the directory recursively. The
+segments being uploaded will be finished, and the rest will not be uploaded until resumed`,
+		},
+
+		{
+			Name:      "resume",
+			Usage:     "Resume the uploads of a file or all files under a directory",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(resumeUploads),
+			Flags: []cli.Flag{
+				filePathFlag,
+				dirPathFlag,
+			},
+			Description: `
+			gdx sclient resume [--filepath arg | --dirpath arg]
+
+will resume the upload and repair of the paused file, or all files under the directory recursively`,
+		},
+
+		{
+			Name:      "cancel",
+			Usage:     "Cancel the unfinished uploads of a file or all files under a directory",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(cancelUploads),
+			Flags: []cli.Flag{
+				filePathFlag,
+				dirPathFlag,
+			},
+			Description: `
+			gdx sclient cancel [--filepath arg | --dirpath arg]
+
+will delete the file, or all files under the directory recursively, which have not finished uploading.
+The files fully uploaded are not affected. Note, the canceled files are deleted permanently and are
+not retained as versions`,
+		},
+
+		{
+			Name:      "enableMasterKey",
+			Usage:     "Enable the master key derived from a passphrase to wrap the keys of the files",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(enableMasterKey),
+			Description: `
+			gdx sclient enableMasterKey
+
+will prompt for a passphrase, and enable the master key derived from the passphrase with scrypt. The
+encryption keys of all files, including the files uploaded afterwards, are wrapped by the master key,
+thus the files could not be decrypted with the storage client persist directory alone. The master key
+is never saved, and must be unlocked with the passphrase each time the storage client starts. Note,
+the files could not be downloaded if the passphrase is lost`,
+		},
+
+		{
+			Name:      "unlockMasterKey",
+			Usage:     "Unlock the master key to upload and download the files",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(unlockMasterKey),
+			Description: `
+			gdx sclient unlockMasterKey
+
+will prompt for the passphrase, and unlock the master key. Before unlocked, the files could not be
+uploaded, repaired or downloaded`,
+		},
+
+		{
+			Name:      "rotateMasterKey",
+			Usage:     "Replace the master key with the one derived from a new passphrase",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(rotateMasterKey),
+			Description: `
+			gdx sclient rotateMasterKey
+
+will prompt for the current and the new passphrase, and re-wrap the encryption keys of all files with
+the master key derived from the new passphrase. The files are not uploaded again. If the rotation is
+interrupted, it must be finished with the same new passphrase`,
+		},
+
+		{
+			Name:      "addSyncFolder",
+			Usage:     "Mirror a local directory to a directory of the storage client",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(addSyncFolder),
+			Flags: []cli.Flag{
+				syncLocalDirFlag,
+				dirPathFlag,
+				syncDeletePolicyFlag,
+			},
+			Description: `
+			gdx sclient addSyncFolder [--localdir arg] [--dirpath arg] [--delete arg]
+
+will mirror the local directory to the directory of the storage client. The local directory is scanned
+periodically, the new and changed files are uploaded, and the unchanged files are not uploaded again
+after restart. If the delete policy is delete, the synced file is deleted when the local file is deleted,
+otherwise the synced file is kept. The sync folders must not overlap with each other`,
+		},
+
+		{
+			Name:      "removeSyncFolder",
+			Usage:     "Stop mirroring a local directory",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(removeSyncFolder),
+			Flags: []cli.Flag{
+				syncLocalDirFlag,
+			},
+			Description: `
+			gdx sclient removeSyncFolder [--localdir arg]
+
+will stop mirroring the local directory. The synced files are kept`,
+		},
+
+		{
+			Name:      "syncFolders",
+			Usage:     "Retrieve the status of the sync folders",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(syncFolders),
+			Description: `
+			gdx sclient syncFolders
+
+will display the sync folders, along with the files pending to be synced and the files failed to be
+synced. The pending files include the synced files not yet fully uploaded`,
+		},
+
 		{
 			Name:      "periodCost",
 			Usage:     "Retrieve the client's period cost for all storage contracts",
@@ -352,9 +858,31 @@ func getConfig(ctx *cli.Context) error {
 	Max Upload Speed:               %s
 	Max Download Speed:             %s
 	IP Violation Check Status:      %s
+
+Host Policy:
+	Regions:                        %s
+	Max Hosts Per Operator:         %s
+	Max Hosts Per Region:           %s
+	Min Uptime:                     %s
+	Max Storage Price:              %s
+	Max Upload Price:               %s
+	Max Download Price:             %s
+	Max Contract Price:             %s
+
+Host Evaluation:
+	Evaluator:                      %s
+	Evaluation Weights:             %s
+
+Bandwidth Schedule:
+	Windows:                        %s
+	Upload Only In Window:          %s
 `, config.RentPayment.Fund, config.RentPayment.Period, config.RentPayment.StorageHosts,
 		config.RentPayment.ExpectedRedundancy, config.RentPayment.ExpectedStorage, config.RentPayment.ExpectedUpload,
-		config.RentPayment.ExpectedDownload, config.MaxUploadSpeed, config.MaxDownloadSpeed, config.EnableIPViolation)
+		config.RentPayment.ExpectedDownload, config.MaxUploadSpeed, config.MaxDownloadSpeed, config.EnableIPViolation,
+		config.HostPolicy.Regions, config.HostPolicy.MaxHostsPerOperator, config.HostPolicy.MaxHostsPerRegion,
+		config.HostPolicy.MinUptime, config.HostPolicy.MaxStoragePrice, config.HostPolicy.MaxUploadPrice,
+		config.HostPolicy.MaxDownloadPrice, config.HostPolicy.MaxContractPrice, config.Evaluator,
+		config.EvaluationWeights, config.BandwidthSchedule, config.UploadOnlyInWindow)
 
 	return nil
 }
@@ -423,13 +951,38 @@ func getHostInfo(ctx *cli.Context) error {
 	DownloadBandwidth Price:       %v camel
 	UploadBandwidth Price:         %v camel
 	Sector Access Price:           %v camel
+
+Measured Performance:
+	Upload Transfers:              %v
+	Download Transfers:            %v
+	Latency (p50/p90/p99):         %v / %v / %v
+	Upload Throughput:             %.0f bytes/s
+	Download Throughput:           %.0f bytes/s
+	Transfer Errors:               %s
 	
 `, info.EnodeID.String(), info.IP, info.AcceptingContracts, info.RemainingStorage, info.Deposit, info.ContractPrice,
-		info.StoragePrice, info.DownloadBandwidthPrice, info.UploadBandwidthPrice, info.SectorAccessPrice)
+		info.StoragePrice, info.DownloadBandwidthPrice, info.UploadBandwidthPrice, info.SectorAccessPrice,
+		info.Performance.UploadTransfers, info.Performance.DownloadTransfers,
+		storagehostmanager.LatencyPercentile(info.Performance, 50), storagehostmanager.LatencyPercentile(info.Performance, 90),
+		storagehostmanager.LatencyPercentile(info.Performance, 99), info.Performance.UploadThroughput,
+		info.Performance.DownloadThroughput, formatTransferErrors(info.Performance.TransferErrors))
 
 	return nil
 }
 
+// formatTransferErrors formats the number of failed data transfers grouped by the error type
+func formatTransferErrors(transferErrors map[string]uint64) string {
+	if len(transferErrors) == 0 {
+		return "none"
+	}
+	var formatted []string
+	for errType, count := range transferErrors {
+		formatted = append(formatted, fmt.Sprintf("%s: %d", errType, count))
+	}
+	sort.Strings(formatted)
+	return strings.Join(formatted, ", ")
+}
+
 func getRanking(ctx *cli.Context) error {
 	client, err := gdxAttach(ctx)
 	if err != nil {
@@ -617,6 +1170,28 @@ func setPaymentAddress(ctx *cli.Context) error {
 	return nil
 }
 
+func migrateHost(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var id string
+	if !ctx.IsSet(storageHostIDFlag.Name) {
+		utils.Fatalf("the --hostid flag must be used to specify which storage host the data want to be migrated from")
+	} else {
+		id = ctx.String(storageHostIDFlag.Name)
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_migrateHost", id); err != nil {
+		utils.Fatalf("failed to migrate the storage host: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
 func setClientConfig(ctx *cli.Context) error {
 	client, err := gdxAttach(ctx)
 	if err != nil {
@@ -637,6 +1212,34 @@ func setClientConfig(ctx *cli.Context) error {
 		settings["fund"] = ctx.String(contractFundFlag.Name)
 	}
 
+	// host selection policy and host evaluation related settings
+	policyFlags := map[string]cli.StringFlag{
+		"regions":             policyRegionsFlag,
+		"maxhostsperoperator": policyMaxHostsPerOperatorFlag,
+		"maxhostsperregion":   policyMaxHostsPerRegionFlag,
+		"minuptime":           policyMinUptimeFlag,
+		"maxstorageprice":     policyMaxStoragePriceFlag,
+		"maxuploadprice":      policyMaxUploadPriceFlag,
+		"maxdownloadprice":    policyMaxDownloadPriceFlag,
+		"maxcontractprice":    policyMaxContractPriceFlag,
+		"evaluator":           evaluatorFlag,
+		"weights":             evaluationWeightsFlag,
+	}
+	for key, flag := range policyFlags {
+		if ctx.IsSet(flag.Name) {
+			settings[key] = ctx.String(flag.Name)
+		}
+	}
+
+	// bandwidth schedule related settings
+	if ctx.IsSet(bandwidthScheduleFlag.Name) {
+		settings["bandwidthschedule"] = ctx.String(bandwidthScheduleFlag.Name)
+	}
+
+	if ctx.IsSet(uploadOnlyInWindowFlag.Name) {
+		settings["uploadonlyinwindow"] = ctx.String(uploadOnlyInWindowFlag.Name)
+	}
+
 	var resp string
 	if err = client.Call(&resp, "sclient_setConfig", settings); err != nil {
 		utils.Fatalf("%s", err.Error())
@@ -665,8 +1268,14 @@ func fileUpload(ctx *cli.Context) error {
 		destination = ctx.String(fileDestinationFlag.Name)
 	}
 
+	var cipher *string
+	if ctx.IsSet(uploadCipherFlag.Name) {
+		c := ctx.String(uploadCipherFlag.Name)
+		cipher = &c
+	}
+
 	var resp string
-	if err = client.Call(&resp, "sclient_upload", source, destination); err != nil {
+	if err = client.Call(&resp, "sclient_upload", source, destination, cipher); err != nil {
 		utils.Fatalf("failed to upload the file: %s", err.Error())
 	}
 
@@ -731,8 +1340,10 @@ func getFile(ctx *cli.Context) error {
 	Redundancy:        %v    
 	StorageOnDisk:     %v
 	UploadProgress:    %v
+	Priority:          %s
+	UploadPaused:      %v
 `, fileInfo.DxPath, fileInfo.Status, fileInfo.SourcePath, fileInfo.FileSize, fileInfo.Redundancy,
-		fileInfo.StoredOnDisk, fileInfo.UploadProgress)
+		fileInfo.StoredOnDisk, fileInfo.UploadProgress, fileInfo.Priority, fileInfo.UploadPaused)
 
 	return nil
 }
@@ -787,6 +1398,490 @@ func fileDelete(ctx *cli.Context) error {
 	return nil
 }
 
+func listDir(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	dirPath := ctx.String(dirPathFlag.Name)
+	offset, limit := ctx.Int(listOffsetFlag.Name), ctx.Int(listLimitFlag.Name)
+
+	var listing storage.DirListing
+	if err = client.Call(&listing, "clientfiles_listDir", dirPath, offset, limit); err != nil {
+		utils.Fatalf("failed to list the directory: %s", err.Error())
+	}
+
+	fmt.Printf(`Directory Information:
+	Path:                    /%s
+	NumFiles:                %v
+	TotalSize:               %v bytes
+	Health:                  %v
+	StuckHealth:             %v
+	NumStuckSegments:        %v
+	Versioning:              %v
+
+`, listing.Dir.DxPath.Path, listing.Dir.NumFiles, listing.Dir.TotalSize, listing.Dir.Health,
+		listing.Dir.StuckHealth, listing.Dir.NumStuckSegments, formatVersioning(listing.Dir.Versioning))
+
+	if listing.Total == 0 {
+		fmt.Println("The directory is empty")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Type", "Path", "Files", "Size", "Status", "UploadProgress"})
+
+	for _, dir := range listing.Dirs {
+		dataEntry := []string{"dir", dir.DxPath.Path, fmt.Sprintf("%v", dir.NumFiles),
+			fmt.Sprintf("%v", dir.TotalSize), fmt.Sprintf("health %v", dir.Health), ""}
+		table.Append(dataEntry)
+	}
+	for _, file := range listing.Files {
+		dataEntry := []string{"file", file.Path, "", "", file.Status, floatToString(file.UploadProgress)}
+		table.Append(dataEntry)
+	}
+
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.Render()
+	fmt.Printf("Listed %v of %v entries\n\n", len(listing.Dirs)+len(listing.Files), listing.Total)
+
+	return nil
+}
+
+func dirRenaming(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var prevPath, newPath string
+	if !ctx.IsSet(prevFilePathFlag.Name) {
+		utils.Fatalf("must specify the previous directory path in order to change the name")
+	} else {
+		prevPath = ctx.String(prevFilePathFlag.Name)
+	}
+
+	if !ctx.IsSet(newFilePathFlag.Name) {
+		utils.Fatalf("must specify the new directory path")
+	} else {
+		newPath = ctx.String(newFilePathFlag.Name)
+	}
+
+	var resp string
+	if err = client.Call(&resp, "clientfiles_renameDir", prevPath, newPath); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func removeFileOrDir(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var path string
+	if !ctx.IsSet(filePathFlag.Name) {
+		utils.Fatalf("must specify the path of the file or directory to be deleted")
+	} else {
+		path = ctx.String(filePathFlag.Name)
+	}
+
+	method := "clientfiles_delete"
+	if ctx.Bool("recursive") {
+		method = "clientfiles_deleteDir"
+	}
+
+	var resp string
+	if err = client.Call(&resp, method, path); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func setVersioning(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	dirPath := ctx.String(dirPathFlag.Name)
+	enabled := !ctx.Bool(versioningDisableFlag.Name)
+	maxVersions, maxAge := ctx.Uint64(maxVersionsFlag.Name), ctx.String(maxVersionAgeFlag.Name)
+
+	var resp string
+	if err = client.Call(&resp, "clientfiles_setVersioning", dirPath, enabled, maxVersions, maxAge); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func listVersions(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(filePathFlag.Name) {
+		utils.Fatalf("must specify the file path to list the versions")
+	}
+	filePath := ctx.String(filePathFlag.Name)
+
+	var versions []storage.FileVersionInfo
+	if err = client.Call(&versions, "clientfiles_versions", filePath); err != nil {
+		utils.Fatalf("failed to list the versions: %s", err.Error())
+	}
+
+	if len(versions) == 0 {
+		fmt.Println("No versions retained for the file")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Version", "Size", "TimeModify", "TimeArchived"})
+	for _, version := range versions {
+		dataEntry := []string{version.VersionID, fmt.Sprintf("%v", version.FileSize),
+			version.TimeModify.Format(time.RFC3339), version.TimeArchived.Format(time.RFC3339)}
+		table.Append(dataEntry)
+	}
+
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.Render()
+	return nil
+}
+
+func restoreVersion(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(filePathFlag.Name) || !ctx.IsSet(versionIDFlag.Name) {
+		utils.Fatalf("must specify the file path and the version to be restored")
+	}
+	filePath, versionID := ctx.String(filePathFlag.Name), ctx.String(versionIDFlag.Name)
+
+	var resp string
+	if err = client.Call(&resp, "clientfiles_restoreVersion", filePath, versionID); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func purgeVersions(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(filePathFlag.Name) {
+		utils.Fatalf("must specify the file path to purge the versions")
+	}
+	filePath, versionID := ctx.String(filePathFlag.Name), ctx.String(versionIDFlag.Name)
+
+	var resp string
+	if err = client.Call(&resp, "clientfiles_purgeVersions", filePath, versionID); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func shareFile(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(filePathFlag.Name) {
+		utils.Fatalf("must specify the file path of the file to be shared")
+	}
+	filePath, recipient := ctx.String(filePathFlag.Name), ctx.String(shareRecipientFlag.Name)
+	prepay := ctx.Bool(sharePrepayFlag.Name)
+
+	var token string
+	if err = client.Call(&token, "sclient_share", filePath, recipient, prepay); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	if !ctx.IsSet(fileDestinationFlag.Name) {
+		fmt.Println(token)
+		return nil
+	}
+	destination := ctx.String(fileDestinationFlag.Name)
+	if err = ioutil.WriteFile(destination, []byte(token), 0600); err != nil {
+		utils.Fatalf("failed to write the share token: %s", err.Error())
+	}
+	fmt.Printf("Share token is written to %s\n", destination)
+	return nil
+}
+
+func downloadShared(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var token string
+	switch {
+	case ctx.IsSet(shareTokenFlag.Name):
+		token = ctx.String(shareTokenFlag.Name)
+	case ctx.IsSet(fileSourceFlag.Name):
+		b, err := ioutil.ReadFile(ctx.String(fileSourceFlag.Name))
+		if err != nil {
+			utils.Fatalf("failed to read the share token: %s", err.Error())
+		}
+		token = strings.TrimSpace(string(b))
+	default:
+		utils.Fatalf("must specify the share token, or the file containing the share token")
+	}
+
+	if !ctx.IsSet(fileDestinationFlag.Name) {
+		utils.Fatalf("must specify the destination path used for saving the file")
+	}
+	destination := ctx.String(fileDestinationFlag.Name)
+
+	var result string
+	if err = client.Call(&result, "sclient_downloadShared", token, destination); err != nil {
+		utils.Fatalf("failed to download the shared file: %s", err.Error())
+	}
+
+	fmt.Println(result)
+	return nil
+}
+
+func setFilePriority(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(filePriorityFlag.Name) {
+		utils.Fatalf("must specify the priority to be set")
+	}
+	path, priority := scheduledPath(ctx), ctx.String(filePriorityFlag.Name)
+
+	var resp string
+	if err = client.Call(&resp, "sclient_setPriority", path, priority); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func pauseUploads(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_pauseUploads", scheduledPath(ctx)); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func resumeUploads(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_resumeUploads", scheduledPath(ctx)); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func cancelUploads(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var canceled []string
+	if err = client.Call(&canceled, "sclient_cancelUploads", scheduledPath(ctx)); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	if len(canceled) == 0 {
+		fmt.Println("No unfinished uploads canceled")
+		return nil
+	}
+	fmt.Println("Canceled uploads:")
+	for _, path := range canceled {
+		fmt.Printf("	%s\n", path)
+	}
+	return nil
+}
+func enableMasterKey(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	passphrase := getPassPhrase("Please give a passphrase for the master key. Do not forget this passphrase.", true, 0, nil)
+
+	var resp string
+	if err = client.Call(&resp, "sclient_enableMasterKey", passphrase); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func unlockMasterKey(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	passphrase := getPassPhrase("Please give the passphrase of the master key.", false, 0, nil)
+
+	var resp string
+	if err = client.Call(&resp, "sclient_unlockMasterKey", passphrase); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func rotateMasterKey(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	passphrase := getPassPhrase("Please give the current passphrase of the master key.", false, 0, nil)
+	newPassphrase := getPassPhrase("Please give a new passphrase for the master key. Do not forget this passphrase.", true, 0, nil)
+
+	var resp string
+	if err = client.Call(&resp, "sclient_rotateMasterKey", passphrase, newPassphrase); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func addSyncFolder(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(syncLocalDirFlag.Name) || !ctx.IsSet(dirPathFlag.Name) {
+		utils.Fatalf("must specify the local directory and the directory path to be synced to")
+	}
+	localDir, dirPath := ctx.String(syncLocalDirFlag.Name), ctx.String(dirPathFlag.Name)
+
+	var resp string
+	if err = client.Call(&resp, "sclient_addSyncFolder", localDir, dirPath, ctx.String(syncDeletePolicyFlag.Name)); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func removeSyncFolder(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(syncLocalDirFlag.Name) {
+		utils.Fatalf("must specify the local directory to stop syncing")
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_removeSyncFolder", ctx.String(syncLocalDirFlag.Name)); err != nil {
+		utils.Fatalf("%s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func syncFolders(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var statuses []storage.SyncFolderStatus
+	if err = client.Call(&statuses, "sclient_syncFolders"); err != nil {
+		utils.Fatalf("failed to retrieve the sync folders: %s", err.Error())
+	}
+
+	if len(statuses) == 0 {
+		fmt.Println("No sync folders")
+		return nil
+	}
+	for _, status := range statuses {
+		lastScan := "never"
+		if !status.LastScan.IsZero() {
+			lastScan = status.LastScan.Format(time.RFC3339)
+		}
+		fmt.Printf("%s -> %s\n", status.LocalDir, status.DxPath)
+		fmt.Printf("	Delete Policy:	%s\n", status.DeletePolicy)
+		fmt.Printf("	Last Scan:	%s\n", lastScan)
+		fmt.Printf("	Synced Files:	%d\n", status.NumSynced)
+		fmt.Printf("	Pending Files:	%d\n", len(status.Pending))
+		for _, path := range status.Pending {
+			fmt.Printf("		%s\n", path)
+		}
+		fmt.Printf("	Failed Files:	%d\n", len(status.Failed))
+		for _, failure := range status.Failed {
+			fmt.Printf("		%s: %s\n", failure.Path, failure.Error)
+		}
+	}
+	return nil
+}
+
+// scheduledPath returns the path of the file or directory to be scheduled. The file path is
+// used if specified, otherwise the directory path, which defaults to the root directory
+func scheduledPath(ctx *cli.Context) string {
+	if ctx.IsSet(filePathFlag.Name) {
+		return ctx.String(filePathFlag.Name)
+	}
+	return ctx.String(dirPathFlag.Name)
+}
+
+// formatVersioning returns the human readable versioning policy
+func formatVersioning(policy storage.VersioningPolicy) string {
+	if !policy.Enabled {
+		return "disabled"
+	}
+	maxVersions, maxAge := "unlimited", "unlimited"
+	if policy.MaxVersions != 0 {
+		maxVersions = strconv.FormatUint(policy.MaxVersions, 10)
+	}
+	if policy.MaxAge != 0 {
+		maxAge = (time.Duration(policy.MaxAge) * time.Second).String()
+	}
+	return fmt.Sprintf("enabled (max versions %s, max age %s)", maxVersions, maxAge)
+}
+
 func periodCost(ctx *cli.Context) error {
 	// attaching to the remote gdx
 	client, err := gdxAttach(ctx)
@@ -59,6 +59,16 @@ var (
 		Usage: "DURATION - the max duration for a storage contract",
 	}
 
+	hostRegionFlag = cli.StringFlag{
+		Name:  "region",
+		Usage: "the region or country code of the host announced",
+	}
+
+	announceBondFlag = cli.StringFlag{
+		Name:  "announceBond",
+		Usage: "CURRENCY - the bond declared in the host announcement",
+	}
+
 	hostPaymentAddressFlag = cli.StringFlag{
 		Name:  "address",
 		Usage: "Payment address for the storage service",
@@ -110,11 +120,13 @@ and etc.`,
 				storagePriceFlag,
 				budgetPriceFlag,
 				maxDepositFlag,
+				hostRegionFlag,
+				announceBondFlag,
 			},
 
 			Action: utils.MigrateFlags(setHostConfig),
 			Description: `
-			gdx shost setConfig [--acceptingContracts arg] [--maxDeposit arg] [--depositBudget arg] [--storagePrice arg] [--uploadPrice arg] [--downloadPrice arg] [--contractPrice arg] [--deposit arg] [--maxDuration arg]
+			gdx shost setConfig [--acceptingContracts arg] [--maxDeposit arg] [--depositBudget arg] [--storagePrice arg] [--uploadPrice arg] [--downloadPrice arg] [--contractPrice arg] [--deposit arg] [--maxDuration arg] [--region arg] [--announceBond arg]
 
 change the storage host configuration. The parameters include but not limited to 
 acceptingContracts, storagePrice, uploadPrice, downloadPrice, etc. A complete set of 
@@ -179,6 +191,20 @@ If the host node has higher evaluation, client will automatically create contrac
 		`,
 		},
 
+		{
+			Name:      "retire",
+			Usage:     "Withdraw the announcements of the storage host node",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(makeRetire),
+			Description: `
+			gdx shost retire
+
+will withdraw the announcements of the storage host node, and the node will stop accepting new storage
+contracts. Storage client nodes will remove the host from their host pool once the retire transaction
+is included in the block chain. The existing storage contracts are not affected.
+		`,
+		},
+
 		{
 			Name:      "addFolder",
 			Usage:     "Allocate disk space for saving data uploaded by the storage client",
@@ -271,11 +297,13 @@ func getHostConfig(ctx *cli.Context) error {
 	SectorAccessPrice:             %v
 	StoragePrice:                  %v
 	UploadBandwidthPrice:          %v
+	Region:                        %v
+	AnnounceBond:                  %v
 `, config.AcceptingContracts, config.MaxDownloadBatchSize, config.MaxDuration,
 		config.MaxReviseBatchSize, config.WindowSize, config.PaymentAddress,
 		config.Deposit, config.DepositBudget, config.MaxDeposit, config.BaseRPCPrice,
 		config.ContractPrice, config.DownloadBandwidthPrice, config.SectorAccessPrice,
-		config.StoragePrice, config.UploadBandwidthPrice)
+		config.StoragePrice, config.UploadBandwidthPrice, config.Region, config.AnnounceBond)
 
 	return nil
 }
@@ -347,6 +375,14 @@ func hostConfigFromFlags(ctx *cli.Context) map[string]string {
 		maxDuration := ctx.String(storageDurationFlag.Name)
 		config["maxDuration"] = maxDuration
 	}
+	// set the region announced
+	if ctx.IsSet(hostRegionFlag.Name) {
+		config["region"] = ctx.String(hostRegionFlag.Name)
+	}
+	// set the bond announced
+	if ctx.IsSet(announceBondFlag.Name) {
+		config["announceBond"] = ctx.String(announceBondFlag.Name)
+	}
 
 	return config
 }
@@ -450,6 +486,21 @@ func makeAnnounce(ctx *cli.Context) error {
 	return nil
 }
 
+func makeRetire(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var resp string
+	if err = client.Call(&resp, "shost_retire"); err != nil {
+		utils.Fatalf("failed to retire the storage host: %s", err.Error())
+	}
+
+	fmt.Printf("%s \n\n", resp)
+	return nil
+}
+
 func addFolder(ctx *cli.Context) error {
 	client, err := gdxAttach(ctx)
 	if err != nil {
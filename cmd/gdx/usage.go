@@ -195,6 +195,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
 			utils.MinerNoVerfiyFlag,
+			utils.MinerStandbyFlag,
 		},
 	},
 	{
@@ -235,6 +236,25 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "STORAGE",
 		Flags: []cli.Flag{
 			utils.StorageRoleFlag,
+			utils.StorageOnlyPeersFlag,
+		},
+	},
+	{
+		Name: "S3 GATEWAY",
+		Flags: []cli.Flag{
+			utils.S3GatewayEnabledFlag,
+			utils.S3GatewayListenAddrFlag,
+			utils.S3GatewayPortFlag,
+			utils.S3GatewayAccessKeysFlag,
+		},
+	},
+	{
+		Name: "WEBDAV GATEWAY",
+		Flags: []cli.Flag{
+			utils.WebDAVEnabledFlag,
+			utils.WebDAVListenAddrFlag,
+			utils.WebDAVPortFlag,
+			utils.WebDAVUsersFlag,
 		},
 	},
 	{
@@ -19,6 +19,7 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -55,6 +56,8 @@ import (
 	"github.com/DxChainNetwork/godx/p2p/nat"
 	"github.com/DxChainNetwork/godx/p2p/netutil"
 	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/storage/storageclient/s3gateway"
+	"github.com/DxChainNetwork/godx/storage/storageclient/webdavgateway"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -368,6 +371,10 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerStandbyFlag = cli.BoolFlag{
+		Name:  "miner.standby",
+		Usage: "Run the validator as a standby node, taking over block production only once the signing key is rotated to it",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -599,6 +606,50 @@ var (
 		Name:  "role",
 		Usage: "Chooses which role a node can be. There are four options: all, host, client, and none",
 	}
+	StorageOnlyPeersFlag = cli.BoolFlag{
+		Name:  "storageonlypeers",
+		Usage: "Keep the peers supporting the storage protocol connected when rejected or dropped by the eth protocol",
+	}
+
+	// S3 gateway settings
+	S3GatewayEnabledFlag = cli.BoolFlag{
+		Name:  "s3gateway",
+		Usage: "Enable the S3-compatible HTTP gateway of the storage client",
+	}
+	S3GatewayListenAddrFlag = cli.StringFlag{
+		Name:  "s3gateway.addr",
+		Usage: "S3 gateway listening interface",
+		Value: s3gateway.DefaultHost,
+	}
+	S3GatewayPortFlag = cli.IntFlag{
+		Name:  "s3gateway.port",
+		Usage: "S3 gateway listening port",
+		Value: s3gateway.DefaultPort,
+	}
+	S3GatewayAccessKeysFlag = cli.StringFlag{
+		Name:  "s3gateway.accesskeys",
+		Usage: "JSON file mapping the S3 access key ids to the secret keys (default = inside the s3gateway directory of datadir)",
+	}
+
+	// WebDAV gateway settings
+	WebDAVEnabledFlag = cli.BoolFlag{
+		Name:  "webdav",
+		Usage: "Enable the WebDAV gateway of the storage client",
+	}
+	WebDAVListenAddrFlag = cli.StringFlag{
+		Name:  "webdav.addr",
+		Usage: "WebDAV gateway listening interface",
+		Value: webdavgateway.DefaultHost,
+	}
+	WebDAVPortFlag = cli.IntFlag{
+		Name:  "webdav.port",
+		Usage: "WebDAV gateway listening port",
+		Value: webdavgateway.DefaultPort,
+	}
+	WebDAVUsersFlag = cli.StringFlag{
+		Name:  "webdav.users",
+		Usage: "JSON file mapping the WebDAV user names to the passwords (default = inside the webdav directory of datadir)",
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -1183,6 +1234,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(MinerNoVerfiyFlag.Name) {
 		cfg.MinerNoverify = ctx.Bool(MinerNoVerfiyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerStandbyFlag.Name) {
+		cfg.MinerStandby = ctx.GlobalBool(MinerStandbyFlag.Name)
+	}
 	if ctx.GlobalIsSet(VMEnableDebugFlag.Name) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
@@ -1213,6 +1267,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 			Fatalf("the role %s is not valid, valid roles are [all, storagehost, storageclient, miner]", role)
 		}
 	}
+	if ctx.GlobalIsSet(StorageOnlyPeersFlag.Name) {
+		cfg.StorageOnlyPeers = ctx.GlobalBool(StorageOnlyPeersFlag.Name)
+	}
 
 	// If datadir is set, change ethash directory
 	if ctx.GlobalIsSet(DataDirFlag.Name) {
@@ -1286,6 +1343,74 @@ func RegisterEthService(stack *node.Node, cfg *eth.Config) {
 	}
 }
 
+// SetS3GatewayConfig applies S3 gateway related command line flags to the config.
+func SetS3GatewayConfig(ctx *cli.Context, cfg *s3gateway.Config) {
+	if ctx.GlobalIsSet(S3GatewayEnabledFlag.Name) {
+		cfg.Enabled = ctx.GlobalBool(S3GatewayEnabledFlag.Name)
+	}
+	if ctx.GlobalIsSet(S3GatewayListenAddrFlag.Name) {
+		cfg.Host = ctx.GlobalString(S3GatewayListenAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(S3GatewayPortFlag.Name) {
+		cfg.Port = ctx.GlobalInt(S3GatewayPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(S3GatewayAccessKeysFlag.Name) {
+		cfg.AccessKeysFile = ctx.GlobalString(S3GatewayAccessKeysFlag.Name)
+	}
+}
+
+// RegisterS3GatewayService configures the S3-compatible gateway of the storage
+// client and adds it to the given node.
+func RegisterS3GatewayService(stack *node.Node, cfg s3gateway.Config) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		var ethServ *eth.Ethereum
+		if err := ctx.Service(&ethServ); err != nil {
+			return nil, err
+		}
+		client := ethServ.GetStorageClient()
+		if client == nil {
+			return nil, errors.New("the S3 gateway requires the node to run as the storage client")
+		}
+		return s3gateway.New(cfg, ctx.ResolvePath(s3gateway.PersistDirectory), client)
+	}); err != nil {
+		Fatalf("Failed to register the S3 gateway service: %v", err)
+	}
+}
+
+// SetWebDAVConfig applies WebDAV gateway related command line flags to the config.
+func SetWebDAVConfig(ctx *cli.Context, cfg *webdavgateway.Config) {
+	if ctx.GlobalIsSet(WebDAVEnabledFlag.Name) {
+		cfg.Enabled = ctx.GlobalBool(WebDAVEnabledFlag.Name)
+	}
+	if ctx.GlobalIsSet(WebDAVListenAddrFlag.Name) {
+		cfg.Host = ctx.GlobalString(WebDAVListenAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(WebDAVPortFlag.Name) {
+		cfg.Port = ctx.GlobalInt(WebDAVPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(WebDAVUsersFlag.Name) {
+		cfg.UsersFile = ctx.GlobalString(WebDAVUsersFlag.Name)
+	}
+}
+
+// RegisterWebDAVService configures the WebDAV gateway of the storage client and
+// adds it to the given node.
+func RegisterWebDAVService(stack *node.Node, cfg webdavgateway.Config) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		var ethServ *eth.Ethereum
+		if err := ctx.Service(&ethServ); err != nil {
+			return nil, err
+		}
+		client := ethServ.GetStorageClient()
+		if client == nil {
+			return nil, errors.New("the WebDAV gateway requires the node to run as the storage client")
+		}
+		return webdavgateway.New(cfg, ctx.ResolvePath(webdavgateway.PersistDirectory), client)
+	}); err != nil {
+		Fatalf("Failed to register the WebDAV gateway service: %v", err)
+	}
+}
+
 // RegisterEthStatsService configures the Ethereum Stats daemon and adds it to
 // the given node.
 func RegisterEthStatsService(stack *node.Node, url string) {
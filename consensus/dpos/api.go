@@ -14,7 +14,6 @@ import (
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/ethdb"
 	"github.com/DxChainNetwork/godx/trie"
-	"github.com/syndtr/goleveldb/leveldb"
 )
 
 // API is a user facing RPC API to allow controlling the delegate and voting
@@ -26,25 +25,18 @@ type API struct {
 
 // GetConfirmedBlockNumber retrieves the latest irreversible block
 func (api *API) GetConfirmedBlockNumber() (*big.Int, error) {
-	var err error
-	header := api.dpos.confirmedBlockHeader
-	if header == nil {
-		header, err = api.dpos.loadConfirmedBlockHeader(api.chain)
-		if err != nil {
-
-			// if it's leveldb.ErrNotFound, indicates that only genesis block in local, and return 0
-			if err == leveldb.ErrNotFound {
-				return new(big.Int).SetInt64(0), nil
-			}
-
-			// other errors, return nil
-			return nil, err
-		}
+	header, err := api.dpos.ConfirmedBlockHeader(api.chain)
+	if err != nil {
+		return nil, err
 	}
-
 	return header.Number, nil
 }
 
+// StandbyStatus returns the standby status of the local validator node
+func (api *API) StandbyStatus() StandbyStatus {
+	return api.dpos.StandbyStatus()
+}
+
 // GetValidators will return the validator list based on the block header provided
 func GetValidators(diskdb ethdb.Database, header *types.Header) ([]common.Address, error) {
 	// re-construct trieDB and get the epochTrie
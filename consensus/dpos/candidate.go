@@ -5,9 +5,13 @@
 package dpos
 
 import (
+	"unicode/utf8"
+
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/rlp"
 	"github.com/DxChainNetwork/godx/trie"
 )
 
@@ -47,12 +51,105 @@ func ProcessCancelCandidate(state stateDB, ctx *types.DposContext, addr common.A
 	// set the candidates deposit to 0
 	SetCandidateDeposit(state, addr, common.BigInt0)
 	SetRewardRatioNumerator(state, addr, 0)
+	// The validator still produces the blocks of the current epoch with the signing key,
+	// which is reset at the beginning of the next epoch
+	isValidator, err := isValidatorInEpoch(ctx, addr)
+	if err != nil {
+		return err
+	}
+	if !isValidator {
+		SetSigningKey(state, addr, common.Address{})
+	}
+	return SetCandidateMetadata(state, addr, types.CandidateMetadata{})
+}
+
+// resetCanceledSigningKeys resets the signing keys of the validators that are no longer
+// candidates, which is called at the beginning of a new epoch with the validators of the
+// previous epoch
+func resetCanceledSigningKeys(state stateDB, ctx *types.DposContext, validators []common.Address) {
+	for _, validator := range validators {
+		if !isCandidate(ctx.CandidateTrie(), validator) {
+			SetSigningKey(state, validator, common.Address{})
+		}
+	}
+}
+
+// isValidatorInEpoch returns whether the addr is a validator of the current epoch
+func isValidatorInEpoch(ctx *types.DposContext, addr common.Address) (bool, error) {
+	validators, err := ctx.GetValidators()
+	if err != nil {
+		return false, err
+	}
+	for _, validator := range validators {
+		if validator == addr {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ProcessRotateSigningKey registers the signer as the block signing key of the candidate addr.
+// The deposit and the block rewards stay with the candidate address.
+func ProcessRotateSigningKey(state stateDB, ctx *types.DposContext, addr common.Address, signer common.Address) error {
+	if err := checkValidSigningKey(ctx, addr, signer); err != nil {
+		return err
+	}
+	SetSigningKey(state, addr, signer)
+	// The validator of the current epoch signs the following blocks with the new signing key
+	isValidator, err := isValidatorInEpoch(ctx, addr)
+	if err != nil || !isValidator {
+		return err
+	}
+	if signer == addr {
+		signer = common.Address{}
+	}
+	return ctx.SetSigningKey(addr, signer)
+}
+
+// setEpochSigningKeys sets the signing keys registered by the validators of the new epoch
+// in the epoch trie, against which the headers of the epoch are verified
+func setEpochSigningKeys(state stateDB, ctx *types.DposContext, validators []common.Address) error {
+	for _, validator := range validators {
+		if signer := GetSigningKey(state, validator); signer != validator {
+			if err := ctx.SetSigningKey(validator, signer); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
+// ProcessUpdateCandidateMetadata updates the metadata of the candidate addr. An empty
+// metadata removes the registered metadata.
+func ProcessUpdateCandidateMetadata(state stateDB, ctx *types.DposContext, addr common.Address, metadata types.CandidateMetadata) error {
+	if !isCandidate(ctx.CandidateTrie(), addr) {
+		return errMetadataNotCandidate
+	}
+	if err := checkValidMetadata(metadata); err != nil {
+		return err
+	}
+	return SetCandidateMetadata(state, addr, metadata)
+}
+
+// CandidateMetadataTxDataValidation will validate the candidate metadata update transaction before sending it
+func CandidateMetadataTxDataValidation(ctx *types.DposContext, metadata types.CandidateMetadata, candidateAddress common.Address) error {
+	if !isCandidate(ctx.CandidateTrie(), candidateAddress) {
+		return errMetadataNotCandidate
+	}
+	return checkValidMetadata(metadata)
+}
+
+// SigningKeyTxDataValidation will validate the signing key rotation transaction before sending it
+func SigningKeyTxDataValidation(ctx *types.DposContext, data types.SigningKeyTxData, candidateAddress common.Address) error {
+	return checkValidSigningKey(ctx, candidateAddress, data.Signer)
+}
+
 // CandidateTxDataValidation will validate the candidate apply transaction before sending it
 func CandidateTxDataValidation(state stateDB, data types.AddCandidateTxData, candidateAddress common.Address) error {
-	return checkValidCandidate(state, candidateAddress, data.Deposit, data.RewardRatio)
+	if err := checkValidCandidate(state, candidateAddress, data.Deposit, data.RewardRatio); err != nil {
+		return err
+	}
+	return checkValidMetadata(data.Metadata)
 }
 
 // IsCandidate will check whether or not the given address is a candidate address
@@ -112,6 +209,42 @@ func getAllDelegatorForCandidate(ctx *types.DposContext, candidateAddr common.Ad
 	return addresses
 }
 
+// checkValidSigningKey checks whether the signer could be registered as the block signing
+// key of the candidateAddr
+func checkValidSigningKey(ctx *types.DposContext, candidateAddr common.Address, signer common.Address) error {
+	if signer == (common.Address{}) {
+		return errInvalidSigningKey
+	}
+	if !isCandidate(ctx.CandidateTrie(), candidateAddr) {
+		return errSigningKeyNotCandidate
+	}
+	return nil
+}
+
+// checkValidMetadata checks whether the candidate metadata is within the size limit,
+// and the provided enode url is valid
+func checkValidMetadata(metadata types.CandidateMetadata) error {
+	if metadata.IsEmpty() {
+		return nil
+	}
+	if utf8.RuneCountInString(metadata.Name) > MaxCandidateNameLength {
+		return errMetadataNameTooLong
+	}
+	encoded, err := rlp.EncodeToBytes(&metadata)
+	if err != nil {
+		return err
+	}
+	if len(encoded) > MaxCandidateMetadataSize {
+		return errMetadataTooLarge
+	}
+	if metadata.Enode != "" {
+		if _, err := enode.ParseV4(metadata.Enode); err != nil {
+			return errMetadataInvalidEnode
+		}
+	}
+	return nil
+}
+
 // checkValidCandidate checks whether the candidateAddr in transaction is valid for becoming a candidates.
 // If not valid, an error is returned.
 func checkValidCandidate(state stateDB, candidateAddr common.Address, deposit common.BigInt, rewardRatio uint64) error {
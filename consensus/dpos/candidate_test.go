@@ -7,6 +7,7 @@ package dpos
 import (
 	"bytes"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -121,6 +122,148 @@ func TestProcessCancelCandidate(t *testing.T) {
 	}
 }
 
+// TestProcessRotateSigningKey test the functionality of ProcessRotateSigningKey
+func TestProcessRotateSigningKey(t *testing.T) {
+	addr := common.BytesToAddress([]byte{1})
+	signer := common.BytesToAddress([]byte{2})
+	state, dposCtx, err := newStateAndDposContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only candidates could register a signing key
+	if err = ProcessRotateSigningKey(state, dposCtx, addr, signer); err != errSigningKeyNotCandidate {
+		t.Fatalf("rotate signing key for non-candidate: expect error %v, got %v", errSigningKeyNotCandidate, err)
+	}
+	c := candidatePrototype(addr)
+	addAccountInState(state, c.address, c.balance, c.frozenAssets)
+	if err = ProcessAddCandidate(state, dposCtx, c.address, c.deposit, c.rewardRatio); err != nil {
+		t.Fatal(err)
+	}
+	// Without registering, the candidate address itself is the signing key
+	if key := GetSigningKey(state, addr); key != addr {
+		t.Fatalf("default signing key not expected: expect %x, got %x", addr, key)
+	}
+	if err = ProcessRotateSigningKey(state, dposCtx, addr, common.Address{}); err != errInvalidSigningKey {
+		t.Fatalf("rotate empty signing key: expect error %v, got %v", errInvalidSigningKey, err)
+	}
+	if err = ProcessRotateSigningKey(state, dposCtx, addr, signer); err != nil {
+		t.Fatal(err)
+	}
+	if key := GetSigningKey(state, addr); key != signer {
+		t.Fatalf("signing key not expected: expect %x, got %x", signer, key)
+	}
+	// The signing key of the validator shall be kept after canceling the candidate till
+	// the beginning of the next epoch
+	if err = dposCtx.SetValidators([]common.Address{addr}); err != nil {
+		t.Fatal(err)
+	}
+	// The signing keys of the validators are kept in the epoch trie
+	if err = setEpochSigningKeys(state, dposCtx, []common.Address{addr}); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := dposCtx.GetSigningKey(addr); err != nil || key != signer {
+		t.Fatalf("signing key of the validator in epoch trie not expected: expect %x, got %x, %v", signer, key, err)
+	}
+	if err = ProcessRotateSigningKey(state, dposCtx, addr, addr); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := dposCtx.GetSigningKey(addr); err != nil || key != addr {
+		t.Fatalf("rotated signing key of the validator in epoch trie not expected: expect %x, got %x, %v", addr, key, err)
+	}
+	if err = ProcessRotateSigningKey(state, dposCtx, addr, signer); err != nil {
+		t.Fatal(err)
+	}
+	if err = ProcessCancelCandidate(state, dposCtx, addr, time.Now().Unix()); err != nil {
+		t.Fatal(err)
+	}
+	if key := GetSigningKey(state, addr); key != signer {
+		t.Fatalf("after cancel candidates, signing key of the validator changed: expect %x, got %x", signer, key)
+	}
+	resetCanceledSigningKeys(state, dposCtx, []common.Address{addr})
+	if key := GetSigningKey(state, addr); key != addr {
+		t.Fatalf("at the next epoch, signing key not reset: expect %x, got %x", addr, key)
+	}
+	// The signing key of the candidate not being validator shall be removed after canceling
+	other := candidatePrototype(common.BytesToAddress([]byte{3}))
+	addAccountInState(state, other.address, other.balance, other.frozenAssets)
+	if err = ProcessAddCandidate(state, dposCtx, other.address, other.deposit, other.rewardRatio); err != nil {
+		t.Fatal(err)
+	}
+	if err = ProcessRotateSigningKey(state, dposCtx, other.address, signer); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := dposCtx.GetSigningKey(other.address); err != nil || key != other.address {
+		t.Fatalf("signing key of the candidate not being validator shall not be in epoch trie: got %x, %v", key, err)
+	}
+	if err = ProcessCancelCandidate(state, dposCtx, other.address, time.Now().Unix()); err != nil {
+		t.Fatal(err)
+	}
+	if key := GetSigningKey(state, other.address); key != other.address {
+		t.Fatalf("after cancel candidates, signing key not reset: expect %x, got %x", other.address, key)
+	}
+}
+
+func TestProcessUpdateCandidateMetadata(t *testing.T) {
+	addr := common.BytesToAddress([]byte{1})
+	metadata := types.CandidateMetadata{
+		Name:    "dxchain validator",
+		Website: "https://www.dxchain.com",
+		Contact: "validator@dxchain.com",
+		Enode:   "enode://dd876f54fa5cff74eb4c703110f081e19401b154932150cbdd9f6764a254c34d6a9db31517d9dc1f136655f423191bf825cc64affa999f24540cfb1b1b8f08e6@52.9.177.26:36000",
+	}
+	state, dposCtx, err := newStateAndDposContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only candidates could update the metadata
+	if err = ProcessUpdateCandidateMetadata(state, dposCtx, addr, metadata); err != errMetadataNotCandidate {
+		t.Fatalf("update metadata for non-candidate: expect error %v, got %v", errMetadataNotCandidate, err)
+	}
+	c := candidatePrototype(addr)
+	addAccountInState(state, c.address, c.balance, c.frozenAssets)
+	if err = ProcessAddCandidate(state, dposCtx, c.address, c.deposit, c.rewardRatio); err != nil {
+		t.Fatal(err)
+	}
+	if err = ProcessUpdateCandidateMetadata(state, dposCtx, addr, metadata); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetCandidateMetadata(state, addr); got != metadata {
+		t.Fatalf("metadata not expected: expect %+v, got %+v", metadata, got)
+	}
+	// A shorter metadata shall overwrite the previous one
+	short := types.CandidateMetadata{Name: "dx"}
+	if err = ProcessUpdateCandidateMetadata(state, dposCtx, addr, short); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetCandidateMetadata(state, addr); got != short {
+		t.Fatalf("metadata not expected: expect %+v, got %+v", short, got)
+	}
+	// Invalid metadata shall be rejected
+	invalid := []struct {
+		metadata  types.CandidateMetadata
+		expectErr error
+	}{
+		{types.CandidateMetadata{Name: strings.Repeat("d", MaxCandidateNameLength+1)}, errMetadataNameTooLong},
+		{types.CandidateMetadata{Website: strings.Repeat("d", MaxCandidateMetadataSize)}, errMetadataTooLarge},
+		{types.CandidateMetadata{Enode: "invalid enode"}, errMetadataInvalidEnode},
+	}
+	for _, test := range invalid {
+		if err = ProcessUpdateCandidateMetadata(state, dposCtx, addr, test.metadata); err != test.expectErr {
+			t.Fatalf("update invalid metadata: expect error %v, got %v", test.expectErr, err)
+		}
+	}
+	// The metadata shall be removed after canceling the candidate
+	if err = ProcessCancelCandidate(state, dposCtx, addr, time.Now().Unix()); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetCandidateMetadata(state, addr); !got.IsEmpty() {
+		t.Fatalf("after cancel candidates, metadata not removed: %+v", got)
+	}
+	if state.GetState(addr, makeCandidateMetadataKey(0)) != (common.Hash{}) {
+		t.Fatal("after cancel candidates, metadata chunk not cleared")
+	}
+}
+
 func TestCheckValidCandidate(t *testing.T) {
 	candidateAddr := common.BytesToAddress([]byte{1})
 	tests := []struct {
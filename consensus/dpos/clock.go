@@ -0,0 +1,39 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package dpos
+
+import "time"
+
+// Clock is the time source used by the dpos engine. It could be replaced by a
+// simulated clock to drive the consensus forward in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock reading the system time
+type systemClock struct{}
+
+// Now returns the current system time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SetClock replaces the time source of the dpos engine
+func (d *Dpos) SetClock(clock Clock) {
+	d.mu.Lock()
+	d.clock = clock
+	d.mu.Unlock()
+}
+
+// now returns the current unix time of the engine clock
+func (d *Dpos) now() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.clock == nil {
+		return time.Now().Unix()
+	}
+	return d.clock.Now().Unix()
+}
@@ -44,6 +44,16 @@ const (
 	// MaxVoteCount is the maximum number of candidates that a vote transaction could
 	// include
 	MaxVoteCount = 30
+
+	// defaultStandbyThreshold is the default number of consecutive slots missed by the
+	// primary validator node before the standby node takes over
+	defaultStandbyThreshold = 2
+
+	// MaxCandidateMetadataSize is the maximum rlp encoded size of the candidate metadata
+	MaxCandidateMetadataSize = 1024
+
+	// MaxCandidateNameLength is the maximum length of the candidate name in metadata
+	MaxCandidateNameLength = 64
 )
 
 var (
@@ -10,7 +10,7 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
-	"time"
+	"sync/atomic"
 
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
@@ -20,6 +20,7 @@ import (
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rlp"
@@ -56,17 +57,34 @@ type Dpos struct {
 	config *params.DposConfig // Consensus engine configuration parameters
 	db     ethdb.Database     // Database to store and retrieve snapshot checkpoints
 
+	validator            common.Address
 	signer               common.Address
 	signFn               SignerFn
 	signatures           *lru.ARCCache // Signatures of recent blocks to speed up mining
 	confirmedBlockHeader *types.Header
 
-	mu   sync.RWMutex
-	stop chan bool
+	confirmedCh   chan []*types.Header // Channel to deliver the confirmed headers out of the seal verification
+	confirmedFeed event.Feed
+	scope         event.SubscriptionScope
+	loopOnce      sync.Once  // Starts the confirmedHeaderLoop on the first subscription
+	loopStarted   int32      // Whether the confirmedHeaderLoop is started, accessed atomically
+	notifyLock    sync.Mutex // Serializes the notification of the confirmed headers
+
+	standby standbyMonitor
+
+	clock Clock // Time source of the engine, could be replaced in tests
+
+	mu        sync.RWMutex
+	stop      chan bool
+	closeOnce sync.Once
 
 	Mode Mode
 }
 
+// ConfirmedHeaderEvent is posted when a new block header becomes irreversible,
+// i.e. more than 2/3 of the validators have built blocks on top of it
+type ConfirmedHeaderEvent struct{ Header *types.Header }
+
 // SignerFn is the function for signature
 type SignerFn func(accounts.Account, []byte) ([]byte, error)
 
@@ -107,11 +125,15 @@ func sigHash(header *types.Header) (hash common.Hash) {
 // New creates a dpos consensus engine
 func New(config *params.DposConfig, db ethdb.Database) *Dpos {
 	signatures, _ := lru.NewARC(inmemorySignatures)
-	return &Dpos{
-		config:     config,
-		db:         db,
-		signatures: signatures,
+	d := &Dpos{
+		config:      config,
+		db:          db,
+		signatures:  signatures,
+		clock:       systemClock{},
+		confirmedCh: make(chan []*types.Header, 1),
+		stop:        make(chan bool),
 	}
+	return d
 }
 
 // NewDposFaker create fake dpos for test
@@ -155,7 +177,7 @@ func (d *Dpos) verifyHeader(chain consensus.ChainReader, header *types.Header, p
 	}
 	number := header.Number.Uint64()
 	// Unnecessary to verify the block from feature
-	if header.Time.Cmp(big.NewInt(time.Now().Unix())) > 0 {
+	if header.Time.Cmp(big.NewInt(d.now())) > 0 {
 		return consensus.ErrFutureBlock
 	}
 	// Check that the extra-data contains both the vanity and signature
@@ -259,21 +281,32 @@ func (d *Dpos) verifySeal(chain consensus.ChainReader, header *types.Header, par
 	if err != nil {
 		return err
 	}
-	if err := d.verifyBlockSigner(validator, header); err != nil {
+	// Since the signing key fork, the block shall be signed by the signing key of the validator
+	// kept in the epoch trie of the parent, so that the header is verified without the state.
+	// The blocks before the fork are signed by the validator itself
+	signingKey := validator
+	if chain.Config().IsSigningKey(header.Number) {
+		if signingKey, err = dposContext.GetSigningKey(validator); err != nil {
+			return err
+		}
+	}
+	if err := d.verifyBlockSigner(validator, signingKey, header); err != nil {
 		return err
 	}
 	return d.updateConfirmedBlockHeader(chain)
 }
 
-func (d *Dpos) verifyBlockSigner(validator common.Address, header *types.Header) error {
+// verifyBlockSigner checks the header is produced by the validator and signed by
+// the signing key registered by the validator
+func (d *Dpos) verifyBlockSigner(validator common.Address, signingKey common.Address, header *types.Header) error {
 	signer, err := ecrecover(header, d.signatures)
 	if err != nil {
 		return err
 	}
-	if bytes.Compare(signer.Bytes(), validator.Bytes()) != 0 {
+	if bytes.Compare(validator.Bytes(), header.Validator.Bytes()) != 0 {
 		return ErrInvalidBlockValidator
 	}
-	if bytes.Compare(signer.Bytes(), header.Validator.Bytes()) != 0 {
+	if bytes.Compare(signer.Bytes(), signingKey.Bytes()) != 0 {
 		return ErrMismatchSignerAndValidator
 	}
 	return nil
@@ -308,10 +341,18 @@ func (d *Dpos) updateConfirmedBlockHeader(chain consensus.ChainReader) error {
 
 		validatorMap[curHeader.Validator] = true
 		if len(validatorMap) >= ConsensusSize {
+			prevHeader := d.confirmedBlockHeader
 			d.confirmedBlockHeader = curHeader
 			if err := d.storeConfirmedBlockHeader(d.db); err != nil {
 				return err
 			}
+			if atomic.LoadInt32(&d.loopStarted) == 1 {
+				headers, err := confirmedHeaders(chain, prevHeader, curHeader)
+				if err != nil {
+					return err
+				}
+				d.notifyConfirmedHeaders(headers)
+			}
 			log.Debug("Dpos set confirmed block header success", "currentHeader", curHeader.Number.String())
 			return nil
 		}
@@ -324,8 +365,63 @@ func (d *Dpos) updateConfirmedBlockHeader(chain consensus.ChainReader) error {
 	return nil
 }
 
+// confirmedHeaders returns the headers confirmed along with the header, i.e. the headers after
+// the previous confirmed header up to the header, in ascending order of the block number
+func confirmedHeaders(chain HeaderReader, prev, header *types.Header) ([]*types.Header, error) {
+	headers := []*types.Header{header}
+	for header.Number.Uint64() > prev.Number.Uint64()+1 {
+		if header = chain.GetHeaderByHash(header.ParentHash); header == nil {
+			return nil, ErrNilBlockHeader
+		}
+		headers = append(headers, header)
+	}
+	for i, j := 0, len(headers)-1; i < j; i, j = i+1, j-1 {
+		headers[i], headers[j] = headers[j], headers[i]
+	}
+	return headers, nil
+}
+
+// notifyConfirmedHeaders hands the newly confirmed headers over to the confirmedHeaderLoop
+// without blocking the seal verification. The headers not delivered yet are merged with the
+// new ones, thus every confirmed header is posted to the subscribers in order
+func (d *Dpos) notifyConfirmedHeaders(headers []*types.Header) {
+	d.notifyLock.Lock()
+	defer d.notifyLock.Unlock()
+
+	select {
+	case pending := <-d.confirmedCh:
+		headers = append(pending, headers...)
+	default:
+	}
+	// the channel is drained above and only filled under the lock, thus it never blocks
+	select {
+	case d.confirmedCh <- headers:
+	default:
+	}
+}
+
+// confirmedHeaderLoop posts the confirmed headers to the subscribers until the engine is closed
+func (d *Dpos) confirmedHeaderLoop() {
+	for {
+		select {
+		case headers := <-d.confirmedCh:
+			for _, header := range headers {
+				d.confirmedFeed.Send(ConfirmedHeaderEvent{Header: header})
+			}
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// HeaderReader defines the methods needed to look up the confirmed block header from the chain
+type HeaderReader interface {
+	GetHeaderByNumber(number uint64) *types.Header
+	GetHeaderByHash(hash common.Hash) *types.Header
+}
+
 // load the latest confirmed block from the database
-func (d *Dpos) loadConfirmedBlockHeader(chain consensus.ChainReader) (*types.Header, error) {
+func (d *Dpos) loadConfirmedBlockHeader(chain HeaderReader) (*types.Header, error) {
 	key, err := d.db.Get(confirmedBlockHead)
 	if err != nil {
 		return nil, err
@@ -337,6 +433,41 @@ func (d *Dpos) loadConfirmedBlockHeader(chain consensus.ChainReader) (*types.Hea
 	return header, nil
 }
 
+// ConfirmedBlockHeader returns the latest irreversible block header. If no block
+// has been confirmed yet, the genesis header will be returned
+func (d *Dpos) ConfirmedBlockHeader(chain HeaderReader) (*types.Header, error) {
+	if header := d.confirmedBlockHeader; header != nil {
+		return header, nil
+	}
+	// the not found error differs between the databases, thus check the existence
+	// of the confirmed block first
+	exist, err := d.db.Has(confirmedBlockHead)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		// only genesis block in local
+		header := chain.GetHeaderByNumber(0)
+		if header == nil {
+			return nil, ErrNilBlockHeader
+		}
+		return header, nil
+	}
+	return d.loadConfirmedBlockHeader(chain)
+}
+
+// SubscribeConfirmedHeaderEvent registers a subscription of ConfirmedHeaderEvent. The loop
+// posting the confirmed headers is started by the first subscription, and stopped by Close
+func (d *Dpos) SubscribeConfirmedHeaderEvent(ch chan<- ConfirmedHeaderEvent) event.Subscription {
+	if d.confirmedCh != nil {
+		d.loopOnce.Do(func() {
+			atomic.StoreInt32(&d.loopStarted, 1)
+			go d.confirmedHeaderLoop()
+		})
+	}
+	return d.scope.Track(d.confirmedFeed.Subscribe(ch))
+}
+
 // inserts the confirmed block into the database.
 func (d *Dpos) storeConfirmedBlockHeader(db ethdb.Database) error {
 	return db.Put(confirmedBlockHead, d.confirmedBlockHeader.Hash().Bytes())
@@ -356,7 +487,7 @@ func (d *Dpos) Prepare(chain consensus.ChainReader, header *types.Header) error
 		return consensus.ErrUnknownAncestor
 	}
 	header.Difficulty = d.CalcDifficulty(chain, header.Time.Uint64(), parent)
-	header.Validator = d.signer
+	header.Validator = d.validatorAddress()
 	return nil
 }
 
@@ -475,11 +606,23 @@ func (d *Dpos) CheckValidator(lastBlock *types.Block, now int64) error {
 		return err
 	}
 
-	if (validator == common.Address{}) || bytes.Compare(validator.Bytes(), d.signer.Bytes()) != 0 {
+	if (validator == common.Address{}) || bytes.Compare(validator.Bytes(), d.validatorAddress().Bytes()) != 0 {
 		return ErrInvalidBlockValidator
 	}
 
-	return nil
+	// the local node only produces with the signing key registered for the validator, and
+	// the standby node waits until the key handed over to it is confirmed
+	registered, err := d.isSigningKey(dposContext, validator)
+	if err != nil {
+		return err
+	}
+	confirmed := false
+	if registered {
+		if confirmed, err = d.isConfirmedSigningKey(validator); err != nil {
+			return err
+		}
+	}
+	return d.standby.checkSlot(dposContext, lastBlock, validator, now, registered, confirmed)
 }
 
 // Seal implements consensus.Engine, sign the given block and return it
@@ -512,11 +655,26 @@ func (d *Dpos) Seal(chain consensus.ChainReader, block *types.Block, results cha
 	//}
 	//block.Header().Time.SetInt64(time.Now().Unix())
 
+	// never sign a slot which has already been produced, or has been signed locally
+	// with a different block
+	slot, hash := header.Time.Int64(), sigHash(header)
+	if current := chain.CurrentHeader(); current != nil && current.Time.Int64() >= slot {
+		return ErrSlotAlreadySigned
+	}
+	if signed, err := hasSignedSlot(d.db, header.Validator, slot, hash); err != nil {
+		return err
+	} else if signed {
+		return ErrSlotAlreadySigned
+	}
+
 	// time's up, sign the block
-	sighash, err := d.signFn(accounts.Account{Address: d.signer}, sigHash(header).Bytes())
+	sighash, err := d.signFn(accounts.Account{Address: d.signer}, hash.Bytes())
 	if err != nil {
 		return err
 	}
+	if err := recordSignedSlot(d.db, header.Validator, slot, hash); err != nil {
+		return err
+	}
 	copy(header.Extra[len(header.Extra)-extraSeal:], sighash)
 	results <- block.WithSeal(header)
 	return nil
@@ -535,6 +693,24 @@ func (d *Dpos) Authorize(signer common.Address, signFn SignerFn) {
 	d.mu.Unlock()
 }
 
+// SetValidator register the validator address which holds the candidate deposit. If the
+// validator is not set, the signer is used as the validator
+func (d *Dpos) SetValidator(validator common.Address) {
+	d.mu.Lock()
+	d.validator = validator
+	d.mu.Unlock()
+}
+
+// validatorAddress returns the validator address of the local miner
+func (d *Dpos) validatorAddress() common.Address {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.validator != (common.Address{}) {
+		return d.validator
+	}
+	return d.signer
+}
+
 // APIs implemented Engine interface which includes DPOS API
 func (d *Dpos) APIs(chain consensus.ChainReader) []rpc.API {
 	return []rpc.API{{
@@ -550,8 +726,15 @@ func (d *Dpos) SealHash(header *types.Header) common.Hash {
 	return sigHash(header)
 }
 
-// Close implements consensus.Engine, It's a noop for dpos as there are no background threads.
+// Close implements consensus.Engine, It unsubscribes all the confirmed header
+// subscriptions and stops the loop posting the confirmed headers.
 func (d *Dpos) Close() error {
+	d.scope.Close()
+	d.closeOnce.Do(func() {
+		if d.stop != nil {
+			close(d.stop)
+		}
+	})
 	return nil
 }
 
@@ -340,6 +340,87 @@ func TestUpdateConfirmedBlockHeader(t *testing.T) {
 
 }
 
+// TestNotifyConfirmedHeaders test the confirmed headers are notified without blocking on
+// the subscribers, and every confirmed header is delivered in order
+func TestNotifyConfirmedHeaders(t *testing.T) {
+	dposEng := New(&params.DposConfig{}, ethdb.NewMemDatabase())
+	defer dposEng.Close()
+
+	ch := make(chan ConfirmedHeaderEvent)
+	sub := dposEng.SubscribeConfirmedHeaderEvent(ch)
+	defer sub.Unsubscribe()
+
+	// the subscriber is not receiving, notifying shall not be blocked
+	num := uint64(10)
+	for i := uint64(1); i <= num; i += 2 {
+		dposEng.notifyConfirmedHeaders([]*types.Header{
+			{Number: new(big.Int).SetUint64(i)},
+			{Number: new(big.Int).SetUint64(i + 1)},
+		})
+	}
+
+	timeout := time.After(time.Second)
+	for i := uint64(1); i <= num; i++ {
+		select {
+		case ev := <-ch:
+			if ev.Header.Number.Uint64() != i {
+				t.Fatalf("confirmed header %v expected, got %v", i, ev.Header.Number)
+			}
+		case <-timeout:
+			t.Fatalf("the confirmed header %v is not delivered", i)
+		}
+	}
+}
+
+// TestConfirmedHeaders test the headers between the previous confirmed header and the newly
+// confirmed header are returned in ascending order
+func TestConfirmedHeaders(t *testing.T) {
+	chain := make(testHeaderChain)
+	var parent common.Hash
+	var headers []*types.Header
+	for i := uint64(0); i <= 5; i++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(i), ParentHash: parent}
+		chain[header.Hash()] = header
+		headers = append(headers, header)
+		parent = header.Hash()
+	}
+
+	confirmed, err := confirmedHeaders(chain, headers[1], headers[5])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(confirmed) != 4 {
+		t.Fatalf("4 confirmed headers expected, got %v", len(confirmed))
+	}
+	for i, header := range confirmed {
+		if header.Hash() != headers[i+2].Hash() {
+			t.Errorf("confirmed header %v expected at %v, got %v", i+2, i, header.Number)
+		}
+	}
+
+	// the missing parent header shall be reported
+	delete(chain, headers[3].Hash())
+	if _, err = confirmedHeaders(chain, headers[1], headers[5]); err != ErrNilBlockHeader {
+		t.Errorf("expect error %v, got %v", ErrNilBlockHeader, err)
+	}
+}
+
+// testHeaderChain is the HeaderReader looking up the headers by hash
+type testHeaderChain map[common.Hash]*types.Header
+
+func (chain testHeaderChain) GetHeaderByNumber(number uint64) *types.Header {
+	for _, header := range chain {
+		if header.Number.Uint64() == number {
+			return header
+		}
+	}
+	return nil
+}
+
+func (chain testHeaderChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	return chain[hash]
+}
+
 type testHeader struct {
 	hash        common.Hash
 	number      uint64
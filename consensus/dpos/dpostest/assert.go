@@ -0,0 +1,120 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package dpostest
+
+import (
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
+)
+
+// NodeByAddress returns the node running with the validator address, or nil if
+// not found
+func (nw *Network) NodeByAddress(addr common.Address) *Node {
+	for _, n := range nw.Nodes {
+		if n.Account.Address == addr {
+			return n
+		}
+	}
+	return nil
+}
+
+// IsOnline returns whether the node is online
+func (nw *Network) IsOnline(index int) bool {
+	return nw.online[index]
+}
+
+// CheckConsistency checks all online nodes have the same head block
+func (nw *Network) CheckConsistency() error {
+	base := nw.onlineNode()
+	if base == nil {
+		return nil
+	}
+	for i, n := range nw.Nodes {
+		if !nw.online[i] {
+			continue
+		}
+		if n.Head().Hash() != base.Head().Hash() {
+			return fmt.Errorf("node %d head %v not consistent with node %d head %v", n.Index,
+				n.Head().Number(), base.Index, base.Head().Number())
+		}
+	}
+	return nil
+}
+
+// Validators returns the validators of the current epoch at the node head
+func (nw *Network) Validators(index int) ([]common.Address, error) {
+	_, dposCtx, err := nw.Nodes[index].State()
+	if err != nil {
+		return nil, err
+	}
+	return dposCtx.GetValidators()
+}
+
+// Candidates returns the candidates at the node head
+func (nw *Network) Candidates(index int) ([]common.Address, error) {
+	_, dposCtx, err := nw.Nodes[index].State()
+	if err != nil {
+		return nil, err
+	}
+	return dposCtx.GetCandidates(), nil
+}
+
+// IsCandidate returns whether the address is a candidate at the node head
+func (nw *Network) IsCandidate(index int, addr common.Address) (bool, error) {
+	candidates, err := nw.Candidates(index)
+	if err != nil {
+		return false, err
+	}
+	for _, candidate := range candidates {
+		if candidate == addr {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MinedCount returns the mined block count of the validator in the epoch at
+// the node head
+func (nw *Network) MinedCount(index int, epoch int64, validator common.Address) (int64, error) {
+	_, dposCtx, err := nw.Nodes[index].State()
+	if err != nil {
+		return 0, err
+	}
+	return dposCtx.GetMinedCnt(epoch, validator), nil
+}
+
+// CandidateDeposit returns the candidate deposit of the address at the node head
+func (nw *Network) CandidateDeposit(index int, addr common.Address) (common.BigInt, error) {
+	statedb, _, err := nw.Nodes[index].State()
+	if err != nil {
+		return common.BigInt0, err
+	}
+	return dpos.GetCandidateDeposit(statedb, addr), nil
+}
+
+// FrozenAssets returns the frozen assets of the address at the node head
+func (nw *Network) FrozenAssets(index int, addr common.Address) (common.BigInt, error) {
+	statedb, _, err := nw.Nodes[index].State()
+	if err != nil {
+		return common.BigInt0, err
+	}
+	return dpos.GetFrozenAssets(statedb, addr), nil
+}
+
+// Balance returns the balance of the address at the node head
+func (nw *Network) Balance(index int, addr common.Address) (common.BigInt, error) {
+	statedb, _, err := nw.Nodes[index].State()
+	if err != nil {
+		return common.BigInt0, err
+	}
+	return dpos.GetBalance(statedb, addr), nil
+}
+
+// ConfirmedNumber returns the confirmed block number of the node
+func (nw *Network) ConfirmedNumber(index int) (uint64, error) {
+	return nw.Nodes[index].ConfirmedNumber()
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package dpostest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/consensus/dpos"
+)
+
+// SimClock is a manually driven slot clock shared by all nodes in the simulated
+// network. It implements dpos.Clock.
+type SimClock struct {
+	now  int64
+	lock sync.RWMutex
+}
+
+// NewSimClock creates a SimClock starting at the given unix time
+func NewSimClock(start int64) *SimClock {
+	return &SimClock{now: start}
+}
+
+// Now returns the current simulated time
+func (c *SimClock) Now() time.Time {
+	return time.Unix(c.Unix(), 0)
+}
+
+// Unix returns the current simulated unix time
+func (c *SimClock) Unix() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.now
+}
+
+// Set sets the simulated time to the given unix time
+func (c *SimClock) Set(now int64) {
+	c.lock.Lock()
+	c.now = now
+	c.lock.Unlock()
+}
+
+// Advance moves the simulated time forward by the given seconds
+func (c *SimClock) Advance(seconds int64) {
+	c.lock.Lock()
+	c.now += seconds
+	c.lock.Unlock()
+}
+
+// NextSlot moves the simulated time to the next block slot, and returns the slot time
+func (c *SimClock) NextSlot() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = dpos.NextSlot(c.now + 1)
+	return c.now
+}
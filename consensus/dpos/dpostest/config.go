@@ -0,0 +1,137 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package dpostest
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/params"
+)
+
+const (
+	// blockGasLimit is the gas limit of the simulated blocks, which is large enough
+	// to include the transactions of the whole population in a block
+	blockGasLimit = 100000000
+
+	// genesisTime is the timestamp of the simulated genesis block, which is aligned
+	// to the epoch. The engines of the simulated nodes only read the simulated
+	// clock, thus the time is fixed regardless of the system time.
+	genesisTime = 18000 * dpos.EpochInterval
+)
+
+var (
+	// dx is the unit of one dx token in camel
+	dx = common.NewBigIntUint64(1e18)
+
+	// DefaultConfig is the default population of the simulated network
+	DefaultConfig = Config{
+		Validators:  dpos.MaxValidatorSize,
+		Candidates:  5,
+		Delegators:  10,
+		Balance:     dx.MultInt64(1e6),
+		Deposit:     dx.MultInt64(1e5),
+		VoteDeposit: dx.MultInt64(1e4),
+		RewardRatio: 50,
+	}
+
+	errTooFewValidators = errors.New("number of genesis validators shall not be smaller than the safe size")
+)
+
+// Config is the configuration of the simulated dpos network
+type Config struct {
+	// Validators is the number of genesis validators, each running a node
+	Validators int
+
+	// Candidates is the number of accounts applying for candidate after the network
+	// is started, each running a node
+	Candidates int
+
+	// Delegators is the number of accounts voting for the candidates after the
+	// network is started. Delegators do not run nodes.
+	Delegators int
+
+	// Balance is the genesis balance of each account
+	Balance common.BigInt
+
+	// Deposit is the candidate deposit of validators and candidates
+	Deposit common.BigInt
+
+	// VoteDeposit is the vote deposit of delegators
+	VoteDeposit common.BigInt
+
+	// RewardRatio is the reward ratio of validators and candidates
+	RewardRatio uint64
+}
+
+// Account is an account in the simulated network
+type Account struct {
+	Key     *ecdsa.PrivateKey
+	Address common.Address
+
+	nonce uint64
+}
+
+// newAccount creates a new account with a random key
+func newAccount() (*Account, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		Key:     key,
+		Address: crypto.PubkeyToAddress(key.PublicKey),
+	}, nil
+}
+
+// newAccounts creates num new accounts
+func newAccounts(num int) ([]*Account, error) {
+	accounts := make([]*Account, 0, num)
+	for i := 0; i != num; i++ {
+		account, err := newAccount()
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// makeGenesis makes the genesis of the simulated network with the given
+// validators, and all accounts allocated with balance
+func makeGenesis(config Config, validators []*Account, accounts []*Account, timestamp int64) (*core.Genesis, error) {
+	if len(validators) < dpos.SafeSize {
+		return nil, errTooFewValidators
+	}
+	// the simulated network activates the forks since the genesis
+	chainConfig := *params.DposChainConfig
+	chainConfig.SigningKeyBlock = big.NewInt(0)
+	chainConfig.CandidateMetadataBlock = big.NewInt(0)
+	chainConfig.HostRetireBlock = big.NewInt(0)
+	chainConfig.Dpos = &params.DposConfig{}
+	for _, validator := range validators {
+		chainConfig.Dpos.Validators = append(chainConfig.Dpos.Validators, params.ValidatorConfig{
+			Address:     validator.Address,
+			Deposit:     config.Deposit,
+			RewardRatio: config.RewardRatio,
+		})
+	}
+	alloc := make(core.GenesisAlloc)
+	for _, account := range accounts {
+		alloc[account.Address] = core.GenesisAccount{Balance: config.Balance.BigIntPtr()}
+	}
+	return &core.Genesis{
+		Config:     &chainConfig,
+		Timestamp:  uint64(timestamp),
+		GasLimit:   blockGasLimit,
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
+	}, nil
+}
@@ -0,0 +1,486 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+// Package dpostest implements a simulated dpos network for multi-validator
+// integration tests. The validator nodes run in process on top of the p2p
+// simulation adapter, and the consensus is driven by a simulated slot clock,
+// so that elections, kickouts and thawing across many epochs could be tested
+// without waiting for the real time. Faults like offline validators and network
+// partitions could be injected between the slots.
+package dpostest
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/node"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/p2p/simulations"
+	"github.com/DxChainNetwork/godx/p2p/simulations/adapters"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+const (
+	// txGas is the gas limit of the simulated dpos transactions
+	txGas = 1000000
+
+	// syncTimeout is the maximum time waiting for the simulated nodes to be synced
+	syncTimeout = 10 * time.Second
+
+	// pollInterval is the interval of checking the simulated network status
+	pollInterval = 5 * time.Millisecond
+)
+
+var (
+	errSyncTimeout    = errors.New("timeout waiting for the simulated network")
+	errNoBlockInEpoch = errors.New("no block produced at the beginning of the epoch")
+)
+
+// Network is a simulated dpos network consisting of in-process validator nodes
+// connected by the p2p simulation adapter. The network is driven slot by slot by
+// the simulated clock, and faults could be injected between the slots.
+type Network struct {
+	Config  Config
+	Clock   *SimClock
+	Genesis *core.Genesis
+
+	// Nodes are the simulated nodes, the genesis validators come first followed
+	// by the candidates
+	Nodes      []*Node
+	Delegators []*Account
+
+	sim     *simulations.Network
+	nodeMap map[enode.ID]*Node
+	online  []bool
+	group   []int
+	signer  types.Signer
+	pending []*types.Transaction
+}
+
+// NewNetwork creates a simulated network with the population defined in config.
+// The network is not started until Start is called.
+func NewNetwork(config Config) (*Network, error) {
+	validators, err := newAccounts(config.Validators)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := newAccounts(config.Candidates)
+	if err != nil {
+		return nil, err
+	}
+	delegators, err := newAccounts(config.Delegators)
+	if err != nil {
+		return nil, err
+	}
+	all := append(append(append([]*Account{}, validators...), candidates...), delegators...)
+	genesis, err := makeGenesis(config, validators, all, genesisTime)
+	if err != nil {
+		return nil, err
+	}
+
+	nw := &Network{
+		Config:     config,
+		Clock:      NewSimClock(genesisTime),
+		Genesis:    genesis,
+		Delegators: delegators,
+		nodeMap:    make(map[enode.ID]*Node),
+		signer:     types.NewEIP155Signer(genesis.Config.ChainID),
+	}
+	for i, account := range append(validators, candidates...) {
+		n, err := newNode(i, account, genesis, nw.Clock)
+		if err != nil {
+			nw.stopNodes()
+			return nil, err
+		}
+		nw.Nodes = append(nw.Nodes, n)
+		nw.nodeMap[n.ID] = n
+	}
+	nw.online = make([]bool, len(nw.Nodes))
+	nw.group = make([]int, len(nw.Nodes))
+
+	adapter := adapters.NewSimAdapter(map[string]adapters.ServiceFunc{
+		protocolName: func(ctx *adapters.ServiceContext) (node.Service, error) {
+			n, exist := nw.nodeMap[ctx.Config.ID]
+			if !exist {
+				return nil, fmt.Errorf("unknown simulated node %v", ctx.Config.ID)
+			}
+			return n.service(), nil
+		},
+	})
+	nw.sim = simulations.NewNetwork(adapter, &simulations.NetworkConfig{DefaultService: protocolName})
+	for _, n := range nw.Nodes {
+		if _, err := nw.sim.NewNodeWithConfig(&adapters.NodeConfig{
+			ID:         n.ID,
+			PrivateKey: n.nodeKey,
+			Name:       fmt.Sprintf("node%02d", n.Index),
+			Services:   []string{protocolName},
+		}); err != nil {
+			nw.stopNodes()
+			return nil, err
+		}
+	}
+	return nw, nil
+}
+
+// Start starts all nodes and connects them with each other. The candidates and
+// delegators send their candidate and vote transactions, which are included in
+// the following blocks.
+func (nw *Network) Start() error {
+	for i := range nw.Nodes {
+		if err := nw.sim.Start(nw.Nodes[i].ID); err != nil {
+			return err
+		}
+		nw.online[i] = true
+	}
+	if err := nw.connectAll(); err != nil {
+		return err
+	}
+	// apply candidates and vote for them
+	candidates := nw.Nodes[nw.Config.Validators:]
+	for _, n := range candidates {
+		if err := nw.ApplyCandidate(n.Account, nw.Config.Deposit, nw.Config.RewardRatio); err != nil {
+			return err
+		}
+	}
+	for i, delegator := range nw.Delegators {
+		candidate := nw.Nodes[i%len(nw.Nodes)].Account.Address
+		if err := nw.Vote(delegator, nw.Config.VoteDeposit, []common.Address{candidate}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop shuts down the simulated network and all nodes
+func (nw *Network) Stop() {
+	nw.sim.Shutdown()
+	nw.stopNodes()
+}
+
+// stopNodes stops the chains of all created nodes
+func (nw *Network) stopNodes() {
+	for _, n := range nw.Nodes {
+		n.stop()
+	}
+}
+
+// AdvanceSlot moves the clock to the next slot and lets the validator of the slot
+// produce the block. The produced blocks are returned after they are propagated
+// in the network. No block is returned if the validator is offline.
+func (nw *Network) AdvanceSlot() ([]*types.Block, error) {
+	slot := nw.Clock.NextSlot()
+	var blocks []*types.Block
+	for i, n := range nw.Nodes {
+		if !nw.online[i] {
+			continue
+		}
+		block, err := n.produce(slot, nw.pending)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			continue
+		}
+		log.Debug("Simulated block produced", "node", n.Index, "number", block.NumberU64(), "slot", slot)
+		n.broadcast(block)
+		blocks = append(blocks, block)
+	}
+	// all pending transactions have been tried by the producers, the invalid
+	// ones are discarded
+	if len(blocks) != 0 {
+		nw.pending = nil
+	}
+	return blocks, nw.Sync()
+}
+
+// AdvanceSlots advances the network by num slots
+func (nw *Network) AdvanceSlots(num int) error {
+	for i := 0; i != num; i++ {
+		if _, err := nw.AdvanceSlot(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdvanceEpochs advances the network by num epochs. Instead of producing every
+// slot, the clock jumps directly to the end of each epoch and only the first block
+// of the next epoch is produced, so that the thawing and the election are applied
+// epoch by epoch. The skipped slots are missed by the validators, thus the
+// validators are judged by the kickout rule as if they were offline.
+func (nw *Network) AdvanceEpochs(num int64) error {
+	for i := int64(0); i != num; i++ {
+		if err := nw.advanceEpoch(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// advanceEpoch skips the remaining slots of the current epoch, and advances the
+// network slot by slot until a block of the next epoch is produced
+func (nw *Network) advanceEpoch() error {
+	next := (dpos.CalculateEpochID(nw.Clock.Unix()) + 1) * dpos.EpochInterval
+	nw.Clock.Set(next - dpos.BlockInterval)
+	for i := 0; i != dpos.MaxValidatorSize; i++ {
+		blocks, err := nw.AdvanceSlot()
+		if err != nil {
+			return err
+		}
+		if len(blocks) != 0 {
+			return nil
+		}
+	}
+	return errNoBlockInEpoch
+}
+
+// SkipSlots moves the clock forward by num slots without producing any block,
+// as if all validators were offline
+func (nw *Network) SkipSlots(num int64) {
+	nw.Clock.Advance(num * dpos.BlockInterval)
+}
+
+// SetOffline stops the node. The chain data of the node is retained, and the
+// node could be brought back by SetOnline.
+func (nw *Network) SetOffline(index int) error {
+	if !nw.online[index] {
+		return nil
+	}
+	if err := nw.sim.Stop(nw.Nodes[index].ID); err != nil {
+		return err
+	}
+	nw.online[index] = false
+	return nw.waitFor(func() bool {
+		for i, n := range nw.Nodes {
+			if i != index && n.hasPeer(nw.Nodes[index].ID) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// SetOnline restarts the node, connects it with the reachable nodes and waits
+// for the node to be synced
+func (nw *Network) SetOnline(index int) error {
+	if nw.online[index] {
+		return nil
+	}
+	if err := nw.sim.Start(nw.Nodes[index].ID); err != nil {
+		return err
+	}
+	nw.online[index] = true
+	if err := nw.forget(index); err != nil {
+		return err
+	}
+	if err := nw.connectAll(); err != nil {
+		return err
+	}
+	return nw.Sync()
+}
+
+// Partition splits the network into the given groups of node indexes. The nodes
+// not listed are put in the first group. Nodes in different groups are
+// disconnected until Heal is called.
+func (nw *Network) Partition(groups ...[]int) error {
+	for i := range nw.group {
+		nw.group[i] = 0
+	}
+	for g, group := range groups {
+		for _, index := range group {
+			nw.group[index] = g
+		}
+	}
+	for i := range nw.Nodes {
+		for j := i + 1; j < len(nw.Nodes); j++ {
+			if nw.group[i] == nw.group[j] || !nw.online[i] || !nw.online[j] {
+				continue
+			}
+			if err := nw.disconnect(i, j); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Heal removes the partition, reconnects all online nodes and waits for the
+// network to be synced
+func (nw *Network) Heal() error {
+	for i := range nw.group {
+		nw.group[i] = 0
+	}
+	if err := nw.connectAll(); err != nil {
+		return err
+	}
+	return nw.Sync()
+}
+
+// Sync waits until the online nodes reachable from each other have the same head
+func (nw *Network) Sync() error {
+	return nw.waitFor(func() bool {
+		heads := make(map[int]common.Hash)
+		for i, n := range nw.Nodes {
+			if !nw.online[i] {
+				continue
+			}
+			head, exist := heads[nw.group[i]]
+			if !exist {
+				heads[nw.group[i]] = n.Head().Hash()
+				continue
+			}
+			if head != n.Head().Hash() {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// ApplyCandidate sends a transaction applying for candidate
+func (nw *Network) ApplyCandidate(account *Account, deposit common.BigInt, rewardRatio uint64) error {
+	data, err := rlp.EncodeToBytes(&types.AddCandidateTxData{Deposit: deposit, RewardRatio: rewardRatio})
+	if err != nil {
+		return err
+	}
+	return nw.SendTx(account, vm.ApplyCandidateContractAddress, data)
+}
+
+// CancelCandidate sends a transaction canceling the candidate
+func (nw *Network) CancelCandidate(account *Account) error {
+	return nw.SendTx(account, vm.CancelCandidateContractAddress, nil)
+}
+
+// Vote sends a transaction voting for the candidates with the deposit
+func (nw *Network) Vote(account *Account, deposit common.BigInt, candidates []common.Address) error {
+	data, err := rlp.EncodeToBytes(&types.VoteTxData{Deposit: deposit, Candidates: candidates})
+	if err != nil {
+		return err
+	}
+	return nw.SendTx(account, vm.VoteContractAddress, data)
+}
+
+// CancelVote sends a transaction canceling the vote
+func (nw *Network) CancelVote(account *Account) error {
+	return nw.SendTx(account, vm.CancelVoteContractAddress, nil)
+}
+
+// SendTx signs a transaction from the account and adds it to the pending list,
+// which is included in the next produced block
+func (nw *Network) SendTx(account *Account, to common.Address, data []byte) error {
+	n := nw.onlineNode()
+	if n == nil {
+		return errors.New("no online node in the simulated network")
+	}
+	statedb, _, err := n.State()
+	if err != nil {
+		return err
+	}
+	nonce := statedb.GetNonce(account.Address)
+	for _, tx := range nw.pending {
+		if from, _ := types.Sender(nw.signer, tx); from == account.Address {
+			nonce++
+		}
+	}
+	tx, err := types.SignTx(types.NewTransaction(nonce, to, new(big.Int), txGas, new(big.Int), data), nw.signer, account.Key)
+	if err != nil {
+		return err
+	}
+	nw.pending = append(nw.pending, tx)
+	return nil
+}
+
+// onlineNode returns the first online node
+func (nw *Network) onlineNode() *Node {
+	for i, n := range nw.Nodes {
+		if nw.online[i] {
+			return n
+		}
+	}
+	return nil
+}
+
+// connectAll connects all online nodes in the same partition group which are not
+// connected yet
+func (nw *Network) connectAll() error {
+	for i := range nw.Nodes {
+		for j := i + 1; j < len(nw.Nodes); j++ {
+			if !nw.online[i] || !nw.online[j] || nw.group[i] != nw.group[j] {
+				continue
+			}
+			if nw.Nodes[i].hasPeer(nw.Nodes[j].ID) {
+				continue
+			}
+			if err := nw.connect(i, j); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// forget clears the dial history of the restarted node in the other online nodes,
+// since a node dialed recently would not be redialed until the history expires
+func (nw *Network) forget(index int) error {
+	addr := nw.sim.GetNode(nw.Nodes[index].ID).Addr()
+	for i, n := range nw.Nodes {
+		if i == index || !nw.online[i] {
+			continue
+		}
+		client, err := nw.sim.GetNode(n.ID).Client()
+		if err != nil {
+			return err
+		}
+		if err := client.Call(nil, "admin_removePeer", string(addr)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// connect connects the two nodes and waits for the block relay protocol to run
+func (nw *Network) connect(i, j int) error {
+	one, other := nw.Nodes[i], nw.Nodes[j]
+	if err := nw.sim.Connect(one.ID, other.ID); err != nil {
+		return err
+	}
+	return nw.waitFor(func() bool {
+		return one.hasPeer(other.ID) && other.hasPeer(one.ID)
+	})
+}
+
+// disconnect disconnects the two nodes and waits for the block relay protocol to stop
+func (nw *Network) disconnect(i, j int) error {
+	one, other := nw.Nodes[i], nw.Nodes[j]
+	if !one.hasPeer(other.ID) {
+		return nil
+	}
+	if err := nw.sim.Disconnect(one.ID, other.ID); err != nil {
+		return err
+	}
+	return nw.waitFor(func() bool {
+		return !one.hasPeer(other.ID) && !other.hasPeer(one.ID)
+	})
+}
+
+// waitFor polls the condition until it is satisfied or syncTimeout is reached
+func (nw *Network) waitFor(cond func() bool) error {
+	timeout := time.After(syncTimeout)
+	for !cond() {
+		select {
+		case <-timeout:
+			return errSyncTimeout
+		case <-time.After(pollInterval):
+		}
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package dpostest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
+)
+
+// newTestNetwork creates and starts a simulated network with the default config
+func newTestNetwork(t *testing.T) *Network {
+	nw, err := NewNetwork(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.Start(); err != nil {
+		nw.Stop()
+		t.Fatal(err)
+	}
+	return nw
+}
+
+// TestNetwork_ConfirmedProgression test the blocks are produced by the genesis
+// validators in turn, and the confirmed block progresses
+func TestNetwork_ConfirmedProgression(t *testing.T) {
+	nw := newTestNetwork(t)
+	defer nw.Stop()
+
+	if err := nw.AdvanceSlots(2 * dpos.MaxValidatorSize); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.CheckConsistency(); err != nil {
+		t.Fatal(err)
+	}
+	if head := nw.Nodes[0].Head().NumberU64(); head != 2*dpos.MaxValidatorSize {
+		t.Fatalf("head number not expected: expect %v, got %v", 2*dpos.MaxValidatorSize, head)
+	}
+	confirmed, err := nw.ConfirmedNumber(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if confirmed == 0 {
+		t.Fatal("confirmed block shall progress")
+	}
+	// the population transactions shall be applied
+	for _, n := range nw.Nodes[nw.Config.Validators:] {
+		isCandidate, err := nw.IsCandidate(0, n.Account.Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isCandidate {
+			t.Fatalf("%x shall be a candidate", n.Account.Address)
+		}
+	}
+}
+
+// TestNetwork_OfflineValidator test the slots of an offline validator are missed,
+// and the validator catches up after back online
+func TestNetwork_OfflineValidator(t *testing.T) {
+	nw := newTestNetwork(t)
+	defer nw.Stop()
+
+	if err := nw.SetOffline(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.AdvanceSlots(dpos.MaxValidatorSize); err != nil {
+		t.Fatal(err)
+	}
+	if head := nw.Nodes[0].Head().NumberU64(); head != dpos.MaxValidatorSize-1 {
+		t.Fatalf("head number not expected: expect %v, got %v", dpos.MaxValidatorSize-1, head)
+	}
+	if err := nw.SetOnline(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.CheckConsistency(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestNetwork_Partition test the minority partition could not confirm blocks,
+// and the network converges to the majority chain after healing
+func TestNetwork_Partition(t *testing.T) {
+	nw := newTestNetwork(t)
+	defer nw.Stop()
+
+	if err := nw.AdvanceSlots(dpos.MaxValidatorSize); err != nil {
+		t.Fatal(err)
+	}
+	minority := []int{0, 1, 2, 3, 4}
+	var majority []int
+	for i := 5; i < len(nw.Nodes); i++ {
+		majority = append(majority, i)
+	}
+	if err := nw.Partition(majority, minority); err != nil {
+		t.Fatal(err)
+	}
+	partitionHead := nw.Nodes[0].Head().NumberU64()
+	if err := nw.AdvanceSlots(2 * dpos.MaxValidatorSize); err != nil {
+		t.Fatal(err)
+	}
+	confirmed, err := nw.ConfirmedNumber(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if confirmed > partitionHead {
+		t.Fatalf("minority partition shall not confirm new blocks: head at partition %v, confirmed %v", partitionHead, confirmed)
+	}
+	if err := nw.Heal(); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.CheckConsistency(); err != nil {
+		t.Fatal(err)
+	}
+	if nw.Nodes[0].Head().Hash() != nw.Nodes[len(nw.Nodes)-1].Head().Hash() {
+		t.Fatal("network shall converge to the majority chain")
+	}
+}
+
+// TestNetwork_Election test the candidates applied after genesis are elected as
+// validators. The genesis validators miss the skipped slots and are kicked out
+// down to the safe size, thus all remaining candidates are elected.
+func TestNetwork_Election(t *testing.T) {
+	nw := newTestNetwork(t)
+	defer nw.Stop()
+
+	// include the candidate and vote transactions
+	if err := nw.AdvanceSlots(dpos.MaxValidatorSize); err != nil {
+		t.Fatal(err)
+	}
+	// no election happens at the end of the genesis epoch
+	if err := nw.AdvanceEpochs(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.CheckConsistency(); err != nil {
+		t.Fatal(err)
+	}
+	validators, err := nw.Validators(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(validators) != dpos.SafeSize {
+		t.Fatalf("number of validators not expected: expect %v, got %v", dpos.SafeSize, len(validators))
+	}
+	elected := make(map[common.Address]struct{})
+	for _, validator := range validators {
+		isCandidate, err := nw.IsCandidate(0, validator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isCandidate {
+			t.Fatalf("validator %x shall be a candidate", validator)
+		}
+		elected[validator] = struct{}{}
+	}
+	for _, n := range nw.Nodes[nw.Config.Validators:] {
+		if _, exist := elected[n.Account.Address]; !exist {
+			t.Fatalf("candidate %x shall be elected", n.Account.Address)
+		}
+	}
+}
+
+// TestNetwork_Kickout test the validator producing the least blocks in an epoch
+// is kicked out, and its deposit is thawed after the thawing epochs
+func TestNetwork_Kickout(t *testing.T) {
+	nw := newTestNetwork(t)
+	defer nw.Stop()
+
+	// the mined blocks are not checked in the genesis epoch
+	if err := nw.AdvanceEpochs(1); err != nil {
+		t.Fatal(err)
+	}
+	offline := 1
+	addr := nw.Nodes[offline].Account.Address
+	if err := nw.SetOffline(offline); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.AdvanceSlots(dpos.MaxValidatorSize); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.AdvanceEpochs(1); err != nil {
+		t.Fatal(err)
+	}
+	isCandidate, err := nw.IsCandidate(0, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isCandidate {
+		t.Fatalf("offline validator %x shall be kicked out", addr)
+	}
+	candidates, err := nw.Candidates(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != dpos.SafeSize {
+		t.Fatalf("candidates shall be kicked out down to the safe size: expect %v, got %v", dpos.SafeSize, len(candidates))
+	}
+	deposit, err := nw.CandidateDeposit(0, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deposit.Cmp(common.BigInt0) != 0 {
+		t.Fatalf("deposit of the kicked out validator shall be cleared, got %v", deposit)
+	}
+	// the deposit is frozen until the thawing epoch
+	if err := checkFrozenAssets(nw, addr, nw.Config.Deposit); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.AdvanceEpochs(dpos.ThawingEpochDuration); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkFrozenAssets(nw, addr, common.BigInt0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestNetwork_Thaw test the deposits of the canceled candidate and vote are
+// thawed after the thawing epochs
+func TestNetwork_Thaw(t *testing.T) {
+	nw := newTestNetwork(t)
+	defer nw.Stop()
+
+	if err := nw.AdvanceSlots(dpos.MaxValidatorSize); err != nil {
+		t.Fatal(err)
+	}
+	candidate, delegator := nw.Nodes[nw.Config.Validators].Account, nw.Delegators[0]
+	if err := checkFrozenAssets(nw, candidate.Address, nw.Config.Deposit); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkFrozenAssets(nw, delegator.Address, nw.Config.VoteDeposit); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.CancelCandidate(candidate); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.CancelVote(delegator); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.AdvanceSlots(1); err != nil {
+		t.Fatal(err)
+	}
+	// the deposits are still frozen before the thawing epoch
+	if err := nw.AdvanceEpochs(dpos.ThawingEpochDuration - 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkFrozenAssets(nw, candidate.Address, nw.Config.Deposit); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkFrozenAssets(nw, delegator.Address, nw.Config.VoteDeposit); err != nil {
+		t.Fatal(err)
+	}
+	if err := nw.AdvanceEpochs(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkFrozenAssets(nw, candidate.Address, common.BigInt0); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkFrozenAssets(nw, delegator.Address, common.BigInt0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkFrozenAssets checks the frozen assets of the address at the first online
+// node is as expected
+func checkFrozenAssets(nw *Network, addr common.Address, expect common.BigInt) error {
+	frozen, err := nw.FrozenAssets(nw.onlineNode().Index, addr)
+	if err != nil {
+		return err
+	}
+	if frozen.Cmp(expect) != 0 {
+		return fmt.Errorf("frozen assets of %x not expected: expect %v, got %v", addr, expect, frozen)
+	}
+	return nil
+}
@@ -0,0 +1,413 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package dpostest
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/state"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rpc"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
+)
+
+const (
+	// protocolName is the name of the block relay protocol between simulated nodes
+	protocolName = "dpostest"
+
+	// protocolVersion is the version of the block relay protocol
+	protocolVersion = 1
+
+	// maxBlocksFetch is the maximum number of blocks returned in a blocksMsg
+	maxBlocksFetch = 256
+)
+
+// block relay protocol message codes
+const (
+	statusMsg = iota
+	newBlockMsg
+	getBlocksMsg
+	blocksMsg
+	protocolLength
+)
+
+type (
+	// statusData is the message sent upon a new peer is connected
+	statusData struct {
+		Head uint64
+	}
+
+	// getBlocksData is the request of the canonical blocks starting from the block number
+	getBlocksData struct {
+		From uint64
+	}
+)
+
+// Node is a validator node in the simulated network. The chain data of a node is
+// kept in memory across the node is stopped and started by the network.
+type Node struct {
+	Index   int
+	ID      enode.ID
+	Account *Account
+
+	nodeKey *ecdsa.PrivateKey
+	clock   *SimClock
+	config  *params.ChainConfig
+	db      ethdb.Database
+	engine  *dpos.Dpos
+	chain   *core.BlockChain
+
+	peers map[enode.ID]*peer
+	lock  sync.RWMutex
+}
+
+// peer is a connected peer of the block relay protocol
+type peer struct {
+	id    enode.ID
+	rw    p2p.MsgReadWriter
+	known map[common.Hash]struct{}
+	lock  sync.Mutex
+}
+
+// newNode creates a validator node with the given account and genesis
+func newNode(index int, account *Account, genesis *core.Genesis, clock *SimClock) (*Node, error) {
+	nodeKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	db := ethdb.NewMemDatabase()
+	genesis.MustCommit(db)
+
+	engine := dpos.New(genesis.Config.Dpos, db)
+	engine.SetClock(clock)
+	engine.Authorize(account.Address, func(_ accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, account.Key)
+	})
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{
+		Index:   index,
+		ID:      enode.PubkeyToIDV4(&nodeKey.PublicKey),
+		Account: account,
+		nodeKey: nodeKey,
+		clock:   clock,
+		config:  genesis.Config,
+		db:      db,
+		engine:  engine,
+		chain:   chain,
+		peers:   make(map[enode.ID]*peer),
+	}, nil
+}
+
+// Chain returns the blockchain of the node
+func (n *Node) Chain() *core.BlockChain {
+	return n.chain
+}
+
+// Engine returns the dpos engine of the node
+func (n *Node) Engine() *dpos.Dpos {
+	return n.engine
+}
+
+// Head returns the current head block of the node
+func (n *Node) Head() *types.Block {
+	return n.chain.CurrentBlock()
+}
+
+// State returns the state and dpos context at the head block of the node
+func (n *Node) State() (*state.StateDB, *types.DposContext, error) {
+	head := n.Head()
+	statedb, err := n.chain.StateAt(head.Root())
+	if err != nil {
+		return nil, nil, err
+	}
+	dposCtx, err := types.NewDposContextFromProto(n.db, head.Header().DposContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return statedb, dposCtx, nil
+}
+
+// ConfirmedNumber returns the number of the confirmed block of the node
+func (n *Node) ConfirmedNumber() (uint64, error) {
+	header, err := n.engine.ConfirmedBlockHeader(n.chain)
+	if err != nil {
+		return 0, err
+	}
+	return header.Number.Uint64(), nil
+}
+
+// stop stops the blockchain of the node
+func (n *Node) stop() {
+	n.chain.Stop()
+	n.engine.Close()
+}
+
+// produce produces, seals and imports a block at the slot if the node is the
+// validator of the slot. If the node is not expected to produce the block, nil
+// is returned.
+func (n *Node) produce(slot int64, pending []*types.Transaction) (*types.Block, error) {
+	parent := n.chain.CurrentBlock()
+	if err := n.engine.CheckValidator(parent, slot); err != nil {
+		return nil, nil
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   core.CalcGasLimit(parent, blockGasLimit, blockGasLimit),
+		Time:       big.NewInt(slot),
+		Coinbase:   n.Account.Address,
+	}
+	if err := n.engine.Prepare(n.chain, header); err != nil {
+		return nil, err
+	}
+	statedb, err := n.chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	dposCtx, err := types.NewDposContextFromProto(n.db, parent.Header().DposContext)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the pending transactions, the invalid ones are skipped
+	var (
+		txs      []*types.Transaction
+		receipts []*types.Receipt
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+	)
+	for _, tx := range pending {
+		snap, dposSnap := statedb.Snapshot(), dposCtx.Snapshot()
+		statedb.Prepare(tx.Hash(), common.Hash{}, len(txs))
+		receipt, _, err := core.ApplyTransaction(n.config, n.chain, &header.Coinbase, gp, statedb, header, tx, &header.GasUsed, vm.Config{}, dposCtx)
+		if err != nil {
+			log.Debug("Skipped simulated transaction", "hash", tx.Hash(), "err", err)
+			statedb.RevertToSnapshot(snap)
+			dposCtx.RevertToSnapShot(dposSnap)
+			continue
+		}
+		txs = append(txs, tx)
+		receipts = append(receipts, receipt)
+	}
+	coinchargemaintenance.MaintenanceMissedProof(header.Number.Uint64(), statedb)
+
+	block, err := n.engine.Finalize(n.chain, header, statedb, txs, nil, receipts, dposCtx)
+	if err != nil {
+		return nil, err
+	}
+	block.SetDposCtx(dposCtx)
+
+	results := make(chan *types.Block, 1)
+	if err := n.engine.Seal(n.chain, block, results, nil); err != nil {
+		return nil, err
+	}
+	sealed := <-results
+	if _, err := n.chain.InsertChain(types.Blocks{sealed}); err != nil {
+		return nil, fmt.Errorf("node %d failed to import the produced block: %v", n.Index, err)
+	}
+	return sealed, nil
+}
+
+// broadcast sends the block to all connected peers not knowing the block
+func (n *Node) broadcast(block *types.Block) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	for _, p := range n.peers {
+		if p.markKnown(block.Hash()) {
+			go p2p.Send(p.rw, newBlockMsg, block)
+		}
+	}
+}
+
+// hasPeer returns whether the node is connected with the given node
+func (n *Node) hasPeer(id enode.ID) bool {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	_, exist := n.peers[id]
+	return exist
+}
+
+// service returns the node.Service run by the simulation adapter
+func (n *Node) service() *service {
+	return &service{node: n}
+}
+
+// handle runs the block relay protocol with the peer
+func (n *Node) handle(p2pPeer *p2p.Peer, rw p2p.MsgReadWriter) error {
+	p := &peer{
+		id:    p2pPeer.ID(),
+		rw:    rw,
+		known: make(map[common.Hash]struct{}),
+	}
+	// the status is sent asynchronously, since the peer might be sending its status
+	go p2p.Send(rw, statusMsg, &statusData{Head: n.Head().NumberU64()})
+
+	n.lock.Lock()
+	n.peers[p.id] = p
+	n.lock.Unlock()
+
+	defer func() {
+		n.lock.Lock()
+		delete(n.peers, p.id)
+		n.lock.Unlock()
+	}()
+
+	for {
+		if err := n.handleMsg(p); err != nil {
+			return err
+		}
+	}
+}
+
+// handleMsg reads and handles a message from the peer
+func (n *Node) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	defer msg.Discard()
+
+	switch msg.Code {
+	case statusMsg:
+		var status statusData
+		if err := msg.Decode(&status); err != nil {
+			return err
+		}
+		if status.Head > n.Head().NumberU64() {
+			return n.requestBlocks(p)
+		}
+
+	case newBlockMsg:
+		block := new(types.Block)
+		if err := msg.Decode(block); err != nil {
+			return err
+		}
+		p.markKnown(block.Hash())
+		if n.chain.HasBlock(block.Hash(), block.NumberU64()) {
+			return nil
+		}
+		if !n.chain.HasBlock(block.ParentHash(), block.NumberU64()-1) {
+			return n.requestBlocks(p)
+		}
+		n.importBlocks(types.Blocks{block})
+
+	case getBlocksMsg:
+		var req getBlocksData
+		if err := msg.Decode(&req); err != nil {
+			return err
+		}
+		var blocks types.Blocks
+		head := n.Head().NumberU64()
+		for number := req.From; number <= head && len(blocks) < maxBlocksFetch; number++ {
+			blocks = append(blocks, n.chain.GetBlockByNumber(number))
+		}
+		go p2p.Send(p.rw, blocksMsg, blocks)
+
+	case blocksMsg:
+		var blocks types.Blocks
+		if err := msg.Decode(&blocks); err != nil {
+			return err
+		}
+		if len(blocks) == 0 {
+			return nil
+		}
+		for _, block := range blocks {
+			p.markKnown(block.Hash())
+		}
+		n.importBlocks(blocks)
+		// continue to fetch if there are more blocks
+		if len(blocks) == maxBlocksFetch {
+			go p2p.Send(p.rw, getBlocksMsg, &getBlocksData{From: blocks[len(blocks)-1].NumberU64() + 1})
+		}
+
+	default:
+		return fmt.Errorf("unknown message code %d", msg.Code)
+	}
+	return nil
+}
+
+// requestBlocks requests the canonical blocks of the peer after the local confirmed
+// block, which shall be the common ancestor of the two chains
+func (n *Node) requestBlocks(p *peer) error {
+	from, err := n.ConfirmedNumber()
+	if err != nil {
+		return err
+	}
+	go p2p.Send(p.rw, getBlocksMsg, &getBlocksData{From: from + 1})
+	return nil
+}
+
+// importBlocks imports the blocks to the chain, and relays the new head to peers
+func (n *Node) importBlocks(blocks types.Blocks) {
+	prevHead := n.Head().Hash()
+	if _, err := n.chain.InsertChain(blocks); err != nil {
+		log.Debug("Failed to import simulated blocks", "node", n.Index, "err", err)
+	}
+	if head := n.Head(); head.Hash() != prevHead {
+		n.broadcast(head)
+	}
+}
+
+// markKnown marks the block as known by the peer, and returns whether the
+// block was unknown before
+func (p *peer) markKnown(hash common.Hash) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, exist := p.known[hash]; exist {
+		return false
+	}
+	p.known[hash] = struct{}{}
+	return true
+}
+
+// service is the node.Service wrapping a simulated node. A new service is created
+// each time the node is started, sharing the same chain data.
+type service struct {
+	node *Node
+}
+
+// Protocols returns the block relay protocol
+func (s *service) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{{
+		Name:    protocolName,
+		Version: protocolVersion,
+		Length:  protocolLength,
+		Run:     s.node.handle,
+	}}
+}
+
+// APIs returns the dpos APIs of the node
+func (s *service) APIs() []rpc.API {
+	return s.node.engine.APIs(s.node.chain)
+}
+
+// Start starts the service
+func (s *service) Start(server *p2p.Server) error {
+	return nil
+}
+
+// Stop stops the service. The chain data is retained.
+func (s *service) Stop() error {
+	return nil
+}
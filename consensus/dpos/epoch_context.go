@@ -37,9 +37,16 @@ func (ec *EpochContext) tryElect(genesis, parent *types.Header) error {
 	if err := thawAllFrozenAssetsInEpoch(ec.stateDB, currentEpoch); err != nil {
 		return fmt.Errorf("system not consistent: %v", err)
 	}
+	// the validators of previous epoch, whose signing keys are reset if they are no
+	// longer candidates
+	prevValidators, err := ec.DposContext.GetValidators()
+	if err != nil {
+		return err
+	}
 
 	// if previous epoch is genesis epoch, return directly
 	if prevEpoch == genesisEpoch {
+		resetCanceledSigningKeys(ec.stateDB, ec.DposContext, prevValidators)
 		return nil
 	}
 
@@ -76,6 +83,9 @@ func (ec *EpochContext) tryElect(genesis, parent *types.Header) error {
 		if err != nil {
 			return err
 		}
+		if err = setEpochSigningKeys(ec.stateDB, ec.DposContext, validators); err != nil {
+			return err
+		}
 
 		// Set rewardRatioLastEpoch for each validator
 		for _, validator := range validators {
@@ -92,6 +102,9 @@ func (ec *EpochContext) tryElect(genesis, parent *types.Header) error {
 		log.Info("Come to new epoch", "prevEpoch", i, "nextEpoch", i+1)
 	}
 
+	// reset the signing keys of the canceled and kicked out validators
+	resetCanceledSigningKeys(ec.stateDB, ec.DposContext, prevValidators)
+
 	// Finally, set the snapshot delegate trie root for accumulateRewards
 	setPreEpochSnapshotDelegateTrieRoot(ec.stateDB, ec.DposContext.DelegateTrie().Hash())
 	return nil
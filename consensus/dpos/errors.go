@@ -44,9 +44,33 @@ var (
 
 	// ErrNilBlockHeader is returned if returning a nil block header in api functions
 	ErrNilBlockHeader = errors.New("nil block header returned")
+
+	// ErrStandbyValidator is returned if the local node is a standby validator node and
+	// the signing key of the validator has not been handed over to it
+	ErrStandbyValidator = errors.New("standby validator node waiting for the signing key handover")
+
+	// ErrSigningKeyRotated is returned if the signing key registered for the validator is
+	// not the local signer, e.g. it has been handed over to the standby node
+	ErrSigningKeyRotated = errors.New("block signing key of the validator has been rotated")
+
+	// ErrSlotAlreadySigned is returned if a block has already been signed or produced for
+	// the validator at the slot
+	ErrSlotAlreadySigned = errors.New("block slot has already been signed")
 )
 
 var (
+	// errSlotEpochOver is returned if the slot to be recorded in the local slot signing
+	// record is in an epoch already over
+	errSlotEpochOver = errors.New("the epoch of the slot is over")
+
+	// errMalformedSlotRecord is returned if the slot read from the local slot signing record
+	// is malformed
+	errMalformedSlotRecord = errors.New("malformed slot signing record")
+
+	// errStandbySigningKey is returned if the standby node signs with the signing key
+	// registered for the validator, which is the key of the primary node
+	errStandbySigningKey = errors.New("standby node shall not sign with the registered signing key of the validator")
+
 	// errVoteZeroOrNegativeDeposit happens when voting with zero or negative deposit
 	errVoteZeroOrNegativeDeposit = errors.New("cannot vote with zero or negative deposit")
 
@@ -79,6 +103,26 @@ var (
 	// transaction
 	errCandidateInsufficientBalance = errors.New("candidates not qualified - candidates does not have enough balance")
 
+	// errInvalidSigningKey happens when registering an empty address as the block signing key
+	errInvalidSigningKey = errors.New("invalid signing key - signing key shall not be empty")
+
+	// errSigningKeyNotCandidate happens when an address which is not a candidate trying to
+	// rotate the block signing key
+	errSigningKeyNotCandidate = errors.New("only candidates could rotate the signing key")
+
+	// errMetadataNotCandidate happens when an address which is not a candidate trying to
+	// update the candidate metadata
+	errMetadataNotCandidate = errors.New("only candidates could update the candidate metadata")
+
+	// errMetadataTooLarge happens when the encoded candidate metadata exceeds MaxCandidateMetadataSize
+	errMetadataTooLarge = fmt.Errorf("candidate metadata shall not exceed %v bytes", MaxCandidateMetadataSize)
+
+	// errMetadataNameTooLong happens when the candidate name exceeds MaxCandidateNameLength
+	errMetadataNameTooLong = fmt.Errorf("candidate name shall not exceed %v characters", MaxCandidateNameLength)
+
+	// errMetadataInvalidEnode happens when the enode url in the candidate metadata is invalid
+	errMetadataInvalidEnode = errors.New("invalid enode url in candidate metadata")
+
 	// errInsufficientFrozenAssets is the error happens when subtracting frozen assets, the diff value is
 	// larger the stored frozen assets
 	errInsufficientFrozenAssets = errors.New("not enough frozen assets to subtract")
@@ -0,0 +1,315 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package dpos
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/log"
+)
+
+var (
+	// prefixSignedSlot is the db prefix of the local slot signing record
+	prefixSignedSlot = []byte("dpos-signed-slot-")
+
+	// prefixSignedSlotIndex is the db prefix of the slots recorded for the validator
+	// in the local slot signing record, which are pruned once the epoch is over
+	prefixSignedSlotIndex = []byte("dpos-signed-slots-")
+
+	// signedSlotLock protects the updates of the local slot signing record
+	signedSlotLock sync.Mutex
+)
+
+// The sources of the blocks in the local slot signing record
+const (
+	slotSignedLocally byte = iota
+	slotObserved
+)
+
+// StandbyStatus is the status of a validator node running in standby mode
+type StandbyStatus struct {
+	Enabled     bool `json:"enabled"`
+	Active      bool `json:"active"`
+	MissedSlots int  `json:"missed_slots"`
+	Threshold   int  `json:"threshold"`
+}
+
+// standbyMonitor decides whether a standby node shall produce blocks for the validator. The
+// standby node signs with its own key, and only takes over once the validator has handed the
+// signing key over to it on-chain, so that the blocks of the primary node are no longer valid.
+type standbyMonitor struct {
+	enabled   bool
+	threshold int
+
+	// active indicates whether the standby node has taken over the production
+	active bool
+
+	// missed is the number of consecutive missed slots by the primary node
+	missed int
+
+	// observing is the validator slot under observation, observingEpoch is the epoch
+	// of the parent block of the slot, and minedCnt is the mined block count of the
+	// validator in the epoch when the observation started.
+	observing      int64
+	observingEpoch int64
+	minedCnt       int64
+
+	lock sync.Mutex
+}
+
+// SetStandby enables or disables the standby mode of the local validator node. threshold
+// is the number of consecutive slots missed by the primary node before the handover is
+// requested. If threshold is not positive, defaultStandbyThreshold is used. The standby
+// node refuses to sign with the signing key registered for the validator at head, which
+// is the key of the primary node.
+func (d *Dpos) SetStandby(enabled bool, threshold int, head *types.Header) error {
+	if threshold <= 0 {
+		threshold = defaultStandbyThreshold
+	}
+	if enabled {
+		dposContext, err := types.NewDposContextFromProto(d.db, head.DposContext)
+		if err != nil {
+			return err
+		}
+		if registered, err := d.isSigningKey(dposContext, d.validatorAddress()); err != nil {
+			return err
+		} else if registered {
+			return errStandbySigningKey
+		}
+	}
+	d.standby.lock.Lock()
+	defer d.standby.lock.Unlock()
+
+	d.standby.enabled = enabled
+	d.standby.threshold = threshold
+	d.standby.active = false
+	d.standby.missed = 0
+	d.standby.observing = 0
+	return nil
+}
+
+// StandbyStatus returns the standby status of the local validator node
+func (d *Dpos) StandbyStatus() StandbyStatus {
+	d.standby.lock.Lock()
+	defer d.standby.lock.Unlock()
+
+	return StandbyStatus{
+		Enabled:     d.standby.enabled,
+		Active:      d.standby.active,
+		MissedSlots: d.standby.missed,
+		Threshold:   d.standby.threshold,
+	}
+}
+
+// ObserveBlock is called when a block is inserted into the chain, either on the canonical
+// chain or on a fork. The block of the local validator not signed by the local node is
+// recorded, so that the local node never signs the slot with a different block.
+func (d *Dpos) ObserveBlock(header *types.Header) {
+	validator := header.Validator
+	if validator != d.validatorAddress() {
+		return
+	}
+	slot, hash := header.Time.Int64(), sigHash(header)
+	signedLocally, err := isSignedLocally(d.db, validator, slot, hash)
+	if err == nil && !signedLocally {
+		err = recordObservedSlot(d.db, validator, slot, hash)
+	}
+	if err != nil {
+		log.Warn("Failed to record the observed validator block", "slot", slot, "err", err)
+	}
+}
+
+// isSigningKey checks whether the signing key registered for the validator in the dpos
+// context is the local signer
+func (d *Dpos) isSigningKey(dposContext *types.DposContext, validator common.Address) (bool, error) {
+	signingKey, err := dposContext.GetSigningKey(validator)
+	if err != nil {
+		return false, err
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return signingKey == d.signer, nil
+}
+
+// isConfirmedSigningKey checks whether the signing key registered for the validator at the
+// confirmed block is the local signer. Since the confirmed block is irreversible, the blocks
+// signed with the other keys could not be valid on any chain built on top of it.
+func (d *Dpos) isConfirmedSigningKey(validator common.Address) (bool, error) {
+	confirmed := d.confirmedBlockHeader
+	if confirmed == nil {
+		return false, nil
+	}
+	dposContext, err := types.NewDposContextFromProto(d.db, confirmed.DposContext)
+	if err != nil {
+		return false, err
+	}
+	return d.isSigningKey(dposContext, validator)
+}
+
+// checkSlot is called when the validator is expected to produce the block at slot. A node
+// only produces with the signing key registered at the parent block, and the standby node
+// waits until the key is also confirmed, otherwise it observes the primary at the slot.
+func (sm *standbyMonitor) checkSlot(dposContext *types.DposContext, lastBlock *types.Block, validator common.Address, slot int64, registered, confirmed bool) error {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+
+	if !sm.enabled {
+		if !registered {
+			return ErrSigningKeyRotated
+		}
+		return nil
+	}
+	if registered && confirmed {
+		if !sm.active {
+			log.Warn("Standby validator node takes over block production", "validator", validator)
+		}
+		sm.active = true
+		sm.missed = 0
+		sm.observing = 0
+		return nil
+	}
+	if sm.active {
+		log.Warn("Signing key is no longer handed over, standby node steps back", "validator", validator)
+		sm.active = false
+	}
+	// resolve the last observation. The slot is missed only if the chain has moved past
+	// the slot without the block of the validator
+	if sm.observing != 0 && slot > sm.observing {
+		lastTime := lastBlock.Time().Int64()
+		if cnt := dposContext.GetMinedCnt(sm.observingEpoch, validator); cnt > sm.minedCnt {
+			// the primary node produced the block
+			sm.missed = 0
+		} else if lastTime <= sm.observing {
+			log.Warn("Primary validator node missed slot not seen on chain", "slot", sm.observing, "head", lastTime)
+			sm.missed = 0
+		} else if CalculateEpochID(lastTime) == sm.observingEpoch {
+			sm.missed++
+			log.Warn("Primary validator node missed slot", "slot", sm.observing, "missed", sm.missed)
+			if sm.missed >= sm.threshold {
+				log.Error("Primary validator node keeps missing slots, rotate the signing key to hand over to the standby node",
+					"validator", validator, "missed", sm.missed)
+			}
+		}
+		sm.observing = 0
+	}
+	// start observing the slot if not observed yet
+	if sm.observing != slot {
+		sm.observing = slot
+		sm.observingEpoch = CalculateEpochID(lastBlock.Time().Int64())
+		sm.minedCnt = dposContext.GetMinedCnt(sm.observingEpoch, validator)
+	}
+	return ErrStandbyValidator
+}
+
+// hasSignedSlot checks the local slot signing record whether a block other than the
+// given hash has been signed for the validator at the slot, either signed locally or
+// observed from the other node
+func hasSignedSlot(db ethdb.Database, validator common.Address, slot int64, hash common.Hash) (bool, error) {
+	signed, _, exist, err := readSignedSlot(db, validator, slot)
+	return exist && signed != hash, err
+}
+
+// isSignedLocally checks the local slot signing record whether the block is signed
+// by the local node
+func isSignedLocally(db ethdb.Database, validator common.Address, slot int64, hash common.Hash) (bool, error) {
+	signed, source, exist, err := readSignedSlot(db, validator, slot)
+	return exist && source == slotSignedLocally && signed == hash, err
+}
+
+// recordSignedSlot writes the block hash signed locally for the validator at the slot
+// to the local slot signing record
+func recordSignedSlot(db ethdb.Database, validator common.Address, slot int64, hash common.Hash) error {
+	return writeSignedSlot(db, validator, slot, hash, slotSignedLocally)
+}
+
+// recordObservedSlot writes the block hash of the validator at the slot, which is signed
+// by the other node, to the local slot signing record. The slot already in the record is
+// not overwritten
+func recordObservedSlot(db ethdb.Database, validator common.Address, slot int64, hash common.Hash) error {
+	if _, _, exist, err := readSignedSlot(db, validator, slot); err != nil || exist {
+		return err
+	}
+	if err := writeSignedSlot(db, validator, slot, hash, slotObserved); err != errSlotEpochOver {
+		return err
+	}
+	return nil
+}
+
+// readSignedSlot reads the block hash of the validator at the slot and its source from
+// the local slot signing record
+func readSignedSlot(db ethdb.Database, validator common.Address, slot int64) (common.Hash, byte, bool, error) {
+	key := makeSignedSlotKey(validator, slot)
+	if exist, err := db.Has(key); err != nil || !exist {
+		return common.Hash{}, 0, false, err
+	}
+	signed, err := db.Get(key)
+	if err != nil {
+		return common.Hash{}, 0, false, err
+	}
+	if len(signed) != common.HashLength+1 {
+		return common.Hash{}, 0, false, errMalformedSlotRecord
+	}
+	return common.BytesToHash(signed[:common.HashLength]), signed[common.HashLength], true, nil
+}
+
+// writeSignedSlot writes the block hash of the validator at the slot along with its source
+// to the local slot signing record. The slots of the validator in the epochs before the
+// latest recorded epoch are deleted from the record, and the slot in these epochs could
+// not be recorded
+func writeSignedSlot(db ethdb.Database, validator common.Address, slot int64, hash common.Hash, source byte) error {
+	signedSlotLock.Lock()
+	defer signedSlotLock.Unlock()
+
+	indexKey := append(common.CopyBytes(prefixSignedSlotIndex), validator.Bytes()...)
+	index, _ := db.Get(indexKey)
+	slots := make([]int64, 0, len(index)/8+1)
+	epoch := CalculateEpochID(slot)
+	for i := 0; i+8 <= len(index); i += 8 {
+		recorded := int64(binary.BigEndian.Uint64(index[i : i+8]))
+		if recorded == slot {
+			continue
+		}
+		if recordedEpoch := CalculateEpochID(recorded); recordedEpoch > epoch {
+			epoch = recordedEpoch
+		}
+		slots = append(slots, recorded)
+	}
+	if CalculateEpochID(slot) < epoch {
+		return errSlotEpochOver
+	}
+
+	batch := db.NewBatch()
+	var newIndex []byte
+	for _, recorded := range append(slots, slot) {
+		if CalculateEpochID(recorded) < epoch {
+			if err := batch.Delete(makeSignedSlotKey(validator, recorded)); err != nil {
+				return err
+			}
+			continue
+		}
+		slotBytes := make([]byte, 8)
+		binary.BigEndian.PutUint64(slotBytes, uint64(recorded))
+		newIndex = append(newIndex, slotBytes...)
+	}
+	if err := batch.Put(makeSignedSlotKey(validator, slot), append(hash.Bytes(), source)); err != nil {
+		return err
+	}
+	if err := batch.Put(indexKey, newIndex); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// makeSignedSlotKey makes the db key of the slot signing record
+func makeSignedSlotKey(validator common.Address, slot int64) []byte {
+	slotBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(slotBytes, uint64(slot))
+	key := append(common.CopyBytes(prefixSignedSlot), validator.Bytes()...)
+	return append(key, slotBytes...)
+}
@@ -0,0 +1,305 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package dpos
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/ethdb"
+)
+
+// TestStandbyMonitor_CheckSlot test the standby node only takes over once the signing key
+// handed over to it is confirmed, and steps back once the key is rotated away
+func TestStandbyMonitor_CheckSlot(t *testing.T) {
+	validator := common.HexToAddress("0x1")
+	dposCtx, err := types.NewDposContext(ethdb.NewMemDatabase())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := &standbyMonitor{enabled: true, threshold: 2}
+	parentTime := EpochInterval + 100
+
+	// the primary node produces at the first slot
+	slot := parentTime + BlockInterval
+	if err := sm.checkSlot(dposCtx, newStandbyTestBlock(parentTime), validator, slot, false, false); err != ErrStandbyValidator {
+		t.Fatalf("standby node shall wait for primary: expect %v, got %v", ErrStandbyValidator, err)
+	}
+	if err := updateMinedCnt(parentTime, validator, dposCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the primary node misses the following slots, and the chain moves past the slots
+	for i := int64(2); i <= 4; i++ {
+		slot = parentTime + i*BlockInterval*MaxValidatorSize
+		if err := sm.checkSlot(dposCtx, newStandbyTestBlock(slot-BlockInterval), validator, slot, false, false); err != ErrStandbyValidator {
+			t.Fatalf("standby node shall wait for the handover: expect %v, got %v", ErrStandbyValidator, err)
+		}
+		if sm.missed != int(i-2) || sm.active {
+			t.Fatalf("missed slots not expected: expect %v, got %v, active %v", i-2, sm.missed, sm.active)
+		}
+	}
+
+	// the signing key is handed over but not confirmed yet
+	slot += BlockInterval * MaxValidatorSize
+	if err := sm.checkSlot(dposCtx, newStandbyTestBlock(slot-BlockInterval), validator, slot, true, false); err != ErrStandbyValidator {
+		t.Fatalf("standby node shall wait for the confirmed handover: expect %v, got %v", ErrStandbyValidator, err)
+	}
+	slot += BlockInterval * MaxValidatorSize
+	if err := sm.checkSlot(dposCtx, newStandbyTestBlock(slot-BlockInterval), validator, slot, true, true); err != nil {
+		t.Fatalf("standby node shall take over: %v", err)
+	}
+	if !sm.active || sm.missed != 0 {
+		t.Fatalf("standby node shall be active after the handover: active %v, missed %v", sm.active, sm.missed)
+	}
+
+	// the signing key is rotated away from the standby node
+	slot += BlockInterval * MaxValidatorSize
+	if err := sm.checkSlot(dposCtx, newStandbyTestBlock(slot-BlockInterval), validator, slot, false, true); err != ErrStandbyValidator || sm.active {
+		t.Fatalf("standby node shall step back: active %v, err %v", sm.active, err)
+	}
+}
+
+// TestStandbyMonitor_Fenced test the slots are only counted as missed if the chain moves
+// past the slots without the block of the validator
+func TestStandbyMonitor_Fenced(t *testing.T) {
+	validator := common.HexToAddress("0x1")
+	dposCtx, err := types.NewDposContext(ethdb.NewMemDatabase())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := &standbyMonitor{enabled: true, threshold: 3}
+	parentTime := EpochInterval + 100
+	slot := parentTime + BlockInterval
+
+	// the standby node cut off from the network sees no block after the slots
+	for i := 0; i < 4; i++ {
+		if err := sm.checkSlot(dposCtx, newStandbyTestBlock(parentTime), validator, slot, false, false); err != ErrStandbyValidator {
+			t.Fatalf("standby node shall wait for the handover: expect %v, got %v", ErrStandbyValidator, err)
+		}
+		if sm.missed != 0 {
+			t.Fatalf("the slots not seen on chain shall not be missed: missed %v", sm.missed)
+		}
+		slot += BlockInterval * MaxValidatorSize
+	}
+
+	// the chain moves past the missed slots again
+	for i := 1; i <= 2; i++ {
+		if err := sm.checkSlot(dposCtx, newStandbyTestBlock(slot-BlockInterval), validator, slot, false, false); err != ErrStandbyValidator {
+			t.Fatalf("standby node shall wait for the handover: expect %v, got %v", ErrStandbyValidator, err)
+		}
+		if sm.missed != i {
+			t.Fatalf("missed slots not expected: expect %v, got %v", i, sm.missed)
+		}
+		slot += BlockInterval * MaxValidatorSize
+	}
+	// the last missed slot is not seen on chain
+	if err := sm.checkSlot(dposCtx, newStandbyTestBlock(parentTime), validator, slot, false, false); err != ErrStandbyValidator {
+		t.Fatalf("standby node shall wait for the handover: %v", err)
+	}
+	if sm.missed != 0 {
+		t.Fatalf("the slot not seen on chain shall reset the missed slots: missed %v", sm.missed)
+	}
+}
+
+// TestStandbyMonitor_Disabled test a primary node only produces with the registered signing key
+func TestStandbyMonitor_Disabled(t *testing.T) {
+	dposCtx, err := types.NewDposContext(ethdb.NewMemDatabase())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := &standbyMonitor{}
+	lastBlock := types.NewBlockWithHeader(&types.Header{Time: big.NewInt(EpochInterval)})
+	if err := sm.checkSlot(dposCtx, lastBlock, common.HexToAddress("0x1"), EpochInterval+BlockInterval, true, false); err != nil {
+		t.Fatalf("primary node shall not be blocked: %v", err)
+	}
+	if err := sm.checkSlot(dposCtx, lastBlock, common.HexToAddress("0x1"), EpochInterval+BlockInterval, false, false); err != ErrSigningKeyRotated {
+		t.Fatalf("primary node shall stop once the signing key is rotated: expect %v, got %v", ErrSigningKeyRotated, err)
+	}
+}
+
+// TestDpos_SetStandby test the standby mode is refused if the local node signs with the
+// signing key registered for the validator
+func TestDpos_SetStandby(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	validator, standby := common.HexToAddress("0x1"), common.HexToAddress("0x2")
+	dposCtx, err := types.NewDposContext(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := dposCtx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dposEng := New(nil, db)
+	dposEng.SetValidator(validator)
+	dposEng.signer = validator
+	if err := dposEng.SetStandby(true, 0, &types.Header{DposContext: root}); err != errStandbySigningKey {
+		t.Fatalf("standby mode with the registered signing key: expect %v, got %v", errStandbySigningKey, err)
+	}
+
+	dposEng.signer = standby
+	if err := dposEng.SetStandby(true, 0, &types.Header{DposContext: root}); err != nil {
+		t.Fatal(err)
+	}
+	if status := dposEng.StandbyStatus(); !status.Enabled || status.Active || status.Threshold != defaultStandbyThreshold {
+		t.Fatalf("standby status not expected: %+v", status)
+	}
+
+	// the standby node could not be enabled once the signing key is handed over to it
+	if err := dposCtx.SetSigningKey(validator, standby); err != nil {
+		t.Fatal(err)
+	}
+	if root, err = dposCtx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := dposEng.SetStandby(true, 0, &types.Header{DposContext: root}); err != errStandbySigningKey {
+		t.Fatalf("standby mode with the handed over signing key: expect %v, got %v", errStandbySigningKey, err)
+	}
+}
+
+// TestDpos_ObserveBlock test the inserted blocks of the local validator not signed locally
+// are recorded in the local slot signing record
+func TestDpos_ObserveBlock(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	validator := common.HexToAddress("0x1")
+	dposEng := New(nil, db)
+	dposEng.SetValidator(validator)
+
+	newHeader := func(validator common.Address, slot int64) *types.Header {
+		return &types.Header{
+			Validator:   validator,
+			Time:        big.NewInt(slot),
+			Extra:       make([]byte, extraVanity+extraSeal),
+			DposContext: &types.DposContextRoot{},
+		}
+	}
+	slot := EpochInterval + BlockInterval
+
+	// the block of the other validator is not recorded
+	other := newHeader(common.HexToAddress("0x2"), slot)
+	dposEng.ObserveBlock(other)
+	if _, _, exist, err := readSignedSlot(db, other.Validator, slot); err != nil || exist {
+		t.Fatalf("the block of the other validator shall not be recorded: exist %v, err %v", exist, err)
+	}
+
+	// the block signed locally is kept as signed locally
+	signed := newHeader(validator, slot)
+	if err := recordSignedSlot(db, validator, slot, sigHash(signed)); err != nil {
+		t.Fatal(err)
+	}
+	dposEng.ObserveBlock(signed)
+	if signedLocally, err := isSignedLocally(db, validator, slot, sigHash(signed)); err != nil || !signedLocally {
+		t.Fatalf("the block signed locally shall be kept: signed %v, err %v", signedLocally, err)
+	}
+
+	// the block signed by the other node is recorded as observed
+	observed := newHeader(validator, slot+BlockInterval)
+	dposEng.ObserveBlock(observed)
+	_, source, exist, err := readSignedSlot(db, validator, slot+BlockInterval)
+	if err != nil || !exist || source != slotObserved {
+		t.Fatalf("the block signed by the other node shall be observed: exist %v, source %v, err %v", exist, source, err)
+	}
+}
+
+// TestSignedSlotRecord test the functionality of the local slot signing record
+func TestSignedSlotRecord(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	validator := common.HexToAddress("0x1")
+	slot := EpochInterval + BlockInterval
+	hash1, hash2 := common.HexToHash("0x1"), common.HexToHash("0x2")
+
+	hasSigned := func(slot int64, hash common.Hash) bool {
+		signed, err := hasSignedSlot(db, validator, slot, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	signedLocally := func(slot int64, hash common.Hash) bool {
+		signed, err := isSignedLocally(db, validator, slot, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	if hasSigned(slot, hash1) || signedLocally(slot, hash1) {
+		t.Fatal("empty record shall not have any signed slot")
+	}
+	if err := recordSignedSlot(db, validator, slot, hash1); err != nil {
+		t.Fatal(err)
+	}
+	if hasSigned(slot, hash1) {
+		t.Fatal("re-signing the same block shall be allowed")
+	}
+	if !hasSigned(slot, hash2) {
+		t.Fatal("signing a different block at the signed slot shall be forbidden")
+	}
+	if !signedLocally(slot, hash1) || signedLocally(slot, hash2) {
+		t.Fatal("locally signed block not expected")
+	}
+	if hasSigned(slot+BlockInterval, hash2) {
+		t.Fatal("the next slot shall not be signed")
+	}
+
+	// the block signed by the other node forbids signing a different block at the slot
+	observed := slot + BlockInterval
+	if err := recordObservedSlot(db, validator, observed, hash2); err != nil {
+		t.Fatal(err)
+	}
+	if !hasSigned(observed, hash1) || hasSigned(observed, hash2) {
+		t.Fatal("signing a different block at the observed slot shall be forbidden")
+	}
+	if signedLocally(observed, hash2) {
+		t.Fatal("the observed block shall not be regarded as signed locally")
+	}
+	if err := recordObservedSlot(db, validator, slot, hash2); err != nil {
+		t.Fatal(err)
+	}
+	if !signedLocally(slot, hash1) {
+		t.Fatal("the locally signed slot shall not be overwritten by the observed block")
+	}
+
+	// the malformed record is reported instead of being regarded as signed
+	malformed := slot + 2*BlockInterval
+	if err := db.Put(makeSignedSlotKey(validator, malformed), hash1.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hasSignedSlot(db, validator, malformed, hash2); err != errMalformedSlotRecord {
+		t.Fatalf("malformed record: expect %v, got %v", errMalformedSlotRecord, err)
+	}
+	if err := recordObservedSlot(db, validator, malformed, hash2); err != errMalformedSlotRecord {
+		t.Fatalf("malformed record: expect %v, got %v", errMalformedSlotRecord, err)
+	}
+	if err := db.Delete(makeSignedSlotKey(validator, malformed)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the slots of the past epochs are pruned once a slot of the later epoch is recorded
+	next := slot + EpochInterval
+	if err := recordSignedSlot(db, validator, next, hash1); err != nil {
+		t.Fatal(err)
+	}
+	if hasSigned(slot, hash2) || hasSigned(observed, hash1) {
+		t.Fatal("the slots of the past epoch shall be pruned")
+	}
+	if !signedLocally(next, hash1) {
+		t.Fatal("the slot of the current epoch shall be kept")
+	}
+	if err := recordObservedSlot(db, validator, slot, hash2); err != nil {
+		t.Fatal(err)
+	}
+	if hasSigned(slot, hash1) {
+		t.Fatal("the slot of the past epoch shall not be recorded")
+	}
+}
+
+// newStandbyTestBlock creates the head block at the given time
+func newStandbyTestBlock(time int64) *types.Block {
+	return types.NewBlockWithHeader(&types.Header{Time: big.NewInt(time)})
+}
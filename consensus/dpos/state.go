@@ -10,6 +10,7 @@ import (
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/rlp"
 )
 
 type stateDB interface {
@@ -43,6 +44,16 @@ var (
 	// KeyTotalVote is the key of total vote for each candidates
 	KeyTotalVote = common.BytesToHash([]byte("total-vote"))
 
+	// KeySigningKey is the key of the block signing address registered by the candidates
+	KeySigningKey = common.BytesToHash([]byte("signing-key"))
+
+	// KeyCandidateMetadataSize is the key of the size of the encoded candidate metadata
+	KeyCandidateMetadataSize = common.BytesToHash([]byte("metadata-size"))
+
+	// PrefixCandidateMetadata is the prefix of the keys storing the encoded candidate
+	// metadata chunks
+	PrefixCandidateMetadata = []byte("metadata-chunk")
+
 	// KeyFrozenAssets is the key for frozen assets for in an account
 	KeyFrozenAssets = common.BytesToHash([]byte("frozen-assets"))
 
@@ -106,6 +117,84 @@ func SetRewardRatioNumeratorLastEpoch(state stateDB, addr common.Address, value
 	state.SetState(addr, KeyRewardRatioNumeratorLastEpoch, hash)
 }
 
+// GetSigningKey returns the block signing address of the candidates. If no signing key
+// is registered, the candidates address itself is returned
+func GetSigningKey(state stateDB, addr common.Address) common.Address {
+	hash := state.GetState(addr, KeySigningKey)
+	if hash == (common.Hash{}) {
+		return addr
+	}
+	return common.BytesToAddress(hash.Bytes())
+}
+
+// SetSigningKey set the block signing address for the candidates address. An empty
+// signer removes the registered signing key
+func SetSigningKey(state stateDB, addr common.Address, signer common.Address) {
+	var hash common.Hash
+	if signer != (common.Address{}) {
+		hash = common.BytesToHash(signer.Bytes())
+	}
+	state.SetState(addr, KeySigningKey, hash)
+}
+
+// GetCandidateMetadata returns the metadata registered by the candidates. The metadata
+// is stored in chunks of hash length, prefixed with the encoded size
+func GetCandidateMetadata(state stateDB, addr common.Address) types.CandidateMetadata {
+	var metadata types.CandidateMetadata
+	size := hashToUint64(state.GetState(addr, KeyCandidateMetadataSize))
+	if size == 0 || size > MaxCandidateMetadataSize {
+		return metadata
+	}
+	encoded := make([]byte, 0, size)
+	for i := uint64(0); uint64(len(encoded)) < size; i++ {
+		encoded = append(encoded, state.GetState(addr, makeCandidateMetadataKey(i)).Bytes()...)
+	}
+	if err := rlp.DecodeBytes(encoded[:size], &metadata); err != nil {
+		return types.CandidateMetadata{}
+	}
+	return metadata
+}
+
+// SetCandidateMetadata set the metadata for the candidates address. An empty metadata
+// removes the registered metadata
+func SetCandidateMetadata(state stateDB, addr common.Address, metadata types.CandidateMetadata) error {
+	var encoded []byte
+	if !metadata.IsEmpty() {
+		var err error
+		if encoded, err = rlp.EncodeToBytes(&metadata); err != nil {
+			return err
+		}
+	}
+	// clear the previous chunks exceeding the new size
+	prevSize := hashToUint64(state.GetState(addr, KeyCandidateMetadataSize))
+	for i := candidateMetadataChunks(uint64(len(encoded))); i < candidateMetadataChunks(prevSize); i++ {
+		state.SetState(addr, makeCandidateMetadataKey(i), common.Hash{})
+	}
+	for i := uint64(0); i < candidateMetadataChunks(uint64(len(encoded))); i++ {
+		end := (i + 1) * common.HashLength
+		if end > uint64(len(encoded)) {
+			end = uint64(len(encoded))
+		}
+		var chunk common.Hash
+		copy(chunk[:], encoded[i*common.HashLength:end])
+		state.SetState(addr, makeCandidateMetadataKey(i), chunk)
+	}
+	state.SetState(addr, KeyCandidateMetadataSize, uint64ToHash(uint64(len(encoded))))
+	return nil
+}
+
+// candidateMetadataChunks returns the number of chunks storing the encoded metadata of size
+func candidateMetadataChunks(size uint64) uint64 {
+	return (size + common.HashLength - 1) / common.HashLength
+}
+
+// makeCandidateMetadataKey makes the key of the index-th chunk of the candidate metadata
+func makeCandidateMetadataKey(index uint64) common.Hash {
+	indexByte := make([]byte, 8)
+	binary.BigEndian.PutUint64(indexByte, index)
+	return common.BytesToHash(append(common.CopyBytes(PrefixCandidateMetadata), indexByte...))
+}
+
 // GetTotalVote get the total vote for the candidates address
 func GetTotalVote(state stateDB, addr common.Address) common.BigInt {
 	hash := state.GetState(addr, KeyTotalVote)
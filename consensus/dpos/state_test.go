@@ -40,6 +40,10 @@ func newStateAndDposContext() (*state.StateDB, *types.DposContext, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	// start with no validators in the current epoch
+	if err = ctx.SetValidators([]common.Address{}); err != nil {
+		return nil, nil, err
+	}
 	return stateDB, ctx, nil
 }
 
@@ -211,12 +211,13 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		vmerr error
 	)
 
+	active := contractCreation || vm.IsPrecompiledContractActive(evm.ChainConfig(), evm.BlockNumber, st.to())
 	if contractCreation {
 		ret, _, st.gas, vmerr = evm.Create(sender, st.data, st.gas, st.value)
-	} else if p, ok := vm.PrecompiledStorageContracts[st.to()]; ok {
+	} else if p, ok := vm.PrecompiledStorageContracts[st.to()]; ok && active {
 		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
 		ret, st.gas, vmerr = evm.ApplyStorageContractTransaction(sender, p, st.data, st.gas)
-	} else if p, ok := vm.PrecompiledDPoSContracts[st.to()]; ok {
+	} else if p, ok := vm.PrecompiledDPoSContracts[st.to()]; ok && active {
 		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
 		ret, st.gas, vmerr = evm.ApplyDposTransaction(p, st.dposContext, st.msg.From(), st.data, st.gas, st.value)
 	} else {
@@ -37,6 +37,9 @@ var (
 	candidatePrefix = []byte("candidate-")
 	minedCntPrefix  = []byte("minedCnt-")
 	keyValidator    = []byte("validator")
+
+	// signingKeyPrefix is the prefix of the signing keys of the validators in epochTrie
+	signingKeyPrefix = []byte("signingKey-")
 )
 
 func NewEpochTrie(root common.Hash, db *trie.Database) (*trie.Trie, error) {
@@ -507,6 +510,29 @@ func (dc *DposContext) SetValidators(validators []common.Address) error {
 	return nil
 }
 
+// GetSigningKey retrieves the block signing key of the validator in current epoch. The
+// validator itself is returned if no signing key is set
+func (dc *DposContext) GetSigningKey(validator common.Address) (common.Address, error) {
+	signer, err := dc.epochTrie.TryGet(makeSigningKeyKey(validator))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to retrieve signing key: %s", err)
+	}
+	if len(signer) == 0 {
+		return validator, nil
+	}
+	return common.BytesToAddress(signer), nil
+}
+
+// SetSigningKey updates the block signing key of the validator into epochTrie. An empty
+// signer removes the signing key
+func (dc *DposContext) SetSigningKey(validator common.Address, signer common.Address) error {
+	key := makeSigningKeyKey(validator)
+	if signer == (common.Address{}) {
+		return dc.epochTrie.TryDelete(key)
+	}
+	return dc.epochTrie.TryUpdate(key, signer.Bytes())
+}
+
 // GetVotedCandidatesByAddress retrieve all voted candidates of given delegator
 func (dc *DposContext) GetVotedCandidatesByAddress(delegator common.Address) ([]common.Address, error) {
 	key := delegator.Bytes()
@@ -552,19 +578,51 @@ func makeMinedCntKey(epoch int64, validatorAddr common.Address) []byte {
 	return key
 }
 
+// makeSigningKeyKey is the private function to make the key for the signing key of the
+// validator in epochTrie
+func makeSigningKeyKey(validator common.Address) []byte {
+	key := make([]byte, 0, len(signingKeyPrefix)+common.AddressLength)
+	key = append(key, signingKeyPrefix...)
+	return append(key, validator.Bytes()...)
+}
+
 // DPOS related transaction data.
 type (
-	// AddCandidateTxData is the data field for AddCandidateTx
+	// AddCandidateTxData is the data field for AddCandidateTx. Signer is the optional
+	// block signing key of the candidate, the candidate address itself is used if empty.
+	// Metadata is the optional description of the candidate shown to the delegators
 	AddCandidateTxData struct {
 		Deposit     common.BigInt
 		RewardRatio uint64
+		Signer      common.Address
+		Metadata    CandidateMetadata
+
+		// numOptional is the number of the optional elements the data is decoded from,
+		// including the empty ones
+		numOptional int
 	}
 
 	// addCandidateTxRLPData is the rlp data structure used for rlp encoding/decoding for
-	// AddCandidateTx
+	// AddCandidateTx. The signer and metadata are encoded as optional tail fields to stay
+	// compatible with the data without signing key and metadata
 	addCandidateTxRLPData struct {
 		Deposit     *big.Int
 		RewardRatio uint64
+		Optional    []rlp.RawValue `rlp:"tail"`
+	}
+
+	// CandidateMetadata is the self description of a candidate, which is used by the
+	// delegators to choose the candidates to vote for
+	CandidateMetadata struct {
+		Name    string `json:"name"`
+		Website string `json:"website"`
+		Contact string `json:"contact"`
+		Enode   string `json:"enode"`
+	}
+
+	// SigningKeyTxData is the data field for RotateSigningKeyTx
+	SigningKeyTxData struct {
+		Signer common.Address
 	}
 
 	// VoteTxData is the data field for VoteTx
@@ -587,6 +645,26 @@ func (data *AddCandidateTxData) EncodeRLP(w io.Writer) error {
 		Deposit:     data.Deposit.BigIntPtr(),
 		RewardRatio: data.RewardRatio,
 	}
+	// the signer is encoded as empty bytes if only the metadata is provided
+	hasSigner, hasMetadata := data.Signer != (common.Address{}), !data.Metadata.IsEmpty()
+	if hasSigner || hasMetadata {
+		var signer []byte
+		if hasSigner {
+			signer = data.Signer.Bytes()
+		}
+		encoded, err := rlp.EncodeToBytes(signer)
+		if err != nil {
+			return err
+		}
+		rlpData.Optional = append(rlpData.Optional, encoded)
+	}
+	if hasMetadata {
+		encoded, err := rlp.EncodeToBytes(&data.Metadata)
+		if err != nil {
+			return err
+		}
+		rlpData.Optional = append(rlpData.Optional, encoded)
+	}
 	return rlp.Encode(w, rlpData)
 }
 
@@ -597,9 +675,43 @@ func (data *AddCandidateTxData) DecodeRLP(s *rlp.Stream) error {
 		return err
 	}
 	data.RewardRatio, data.Deposit = rlpData.RewardRatio, common.PtrBigInt(rlpData.Deposit)
+	data.Signer, data.Metadata = common.Address{}, CandidateMetadata{}
+	data.numOptional = len(rlpData.Optional)
+	if len(rlpData.Optional) > 2 {
+		return errors.New("too many fields in add candidate tx data")
+	}
+	if len(rlpData.Optional) > 0 {
+		var signer []byte
+		if err := rlp.DecodeBytes(rlpData.Optional[0], &signer); err != nil {
+			return err
+		}
+		switch len(signer) {
+		case 0:
+		case common.AddressLength:
+			data.Signer = common.BytesToAddress(signer)
+		default:
+			return errors.New("invalid signer in add candidate tx data")
+		}
+	}
+	if len(rlpData.Optional) > 1 {
+		if err := rlp.DecodeBytes(rlpData.Optional[1], &data.Metadata); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// NumOptional returns the number of the optional rlp elements the data is decoded from.
+// An element is counted even if it is empty
+func (data *AddCandidateTxData) NumOptional() int {
+	return data.numOptional
+}
+
+// IsEmpty returns whether all fields of the metadata are empty
+func (m CandidateMetadata) IsEmpty() bool {
+	return m == CandidateMetadata{}
+}
+
 // EncodeRLP defines the rlp encoding rule for VoteTxData
 func (data *VoteTxData) EncodeRLP(w io.Writer) error {
 	rlpData := voteTxRLPData{
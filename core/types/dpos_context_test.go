@@ -188,6 +188,39 @@ func TestDposContextValidators(t *testing.T) {
 	}
 }
 
+func TestDposContextSigningKey(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	dposContext, err := NewDposContext(db)
+	assert.Nil(t, err)
+	assert.Nil(t, dposContext.SetValidators(addresses))
+	validatorsRoot := dposContext.epochTrie.Hash()
+
+	// without signing key, the validator itself is returned
+	signer, err := dposContext.GetSigningKey(addresses[0])
+	assert.Nil(t, err)
+	assert.Equal(t, addresses[0], signer)
+
+	assert.Nil(t, dposContext.SetSigningKey(addresses[0], common.HexToAddress("0x666")))
+	signer, err = dposContext.GetSigningKey(addresses[0])
+	assert.Nil(t, err)
+	assert.Equal(t, common.HexToAddress("0x666"), signer)
+	signer, err = dposContext.GetSigningKey(addresses[1])
+	assert.Nil(t, err)
+	assert.Equal(t, addresses[1], signer)
+
+	// the signing keys shall not be taken as the validators
+	validators, err := dposContext.GetValidators()
+	assert.Nil(t, err)
+	assert.Equal(t, addresses, validators)
+
+	// the empty signer removes the signing key
+	assert.Nil(t, dposContext.SetSigningKey(addresses[0], common.Address{}))
+	signer, err = dposContext.GetSigningKey(addresses[0])
+	assert.Nil(t, err)
+	assert.Equal(t, addresses[0], signer)
+	assert.Equal(t, validatorsRoot, dposContext.epochTrie.Hash())
+}
+
 func TestDposContext_GetVotedCandidatesByAddress(t *testing.T) {
 	db := ethdb.NewMemDatabase()
 	dposContext, err := NewDposContext(db)
@@ -204,3 +237,45 @@ func TestDposContext_GetVotedCandidatesByAddress(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, addresses, candidateListFromTrie)
 }
+
+func TestAddCandidateTxData_RLP(t *testing.T) {
+	tests := []AddCandidateTxData{
+		{Deposit: common.NewBigIntUint64(1e18), RewardRatio: 30},
+		{Deposit: common.NewBigIntUint64(1e18), RewardRatio: 30, Signer: common.HexToAddress("0x666")},
+		{Deposit: common.NewBigIntUint64(1e18), RewardRatio: 30, Metadata: CandidateMetadata{Name: "dx", Website: "https://dxchain.com"}},
+		{Deposit: common.NewBigIntUint64(1e18), RewardRatio: 30, Signer: common.HexToAddress("0x666"), Metadata: CandidateMetadata{Contact: "dx@dxchain.com"}},
+	}
+	for _, data := range tests {
+		b, err := rlp.EncodeToBytes(&data)
+		assert.Nil(t, err)
+
+		var decoded AddCandidateTxData
+		assert.Nil(t, rlp.DecodeBytes(b, &decoded))
+		assert.Equal(t, 0, data.Deposit.Cmp(decoded.Deposit))
+		assert.Equal(t, data.RewardRatio, decoded.RewardRatio)
+		assert.Equal(t, data.Signer, decoded.Signer)
+		assert.Equal(t, data.Metadata, decoded.Metadata)
+	}
+
+	// the data without signer shall be compatible with the legacy encoding
+	legacy, err := rlp.EncodeToBytes([]interface{}{common.NewBigIntUint64(1e18).BigIntPtr(), uint64(30)})
+	assert.Nil(t, err)
+	withoutSigner, err := rlp.EncodeToBytes(&tests[0])
+	assert.Nil(t, err)
+	assert.Equal(t, legacy, withoutSigner)
+
+	// the data with only signer shall be compatible with the encoding before metadata
+	legacy, err = rlp.EncodeToBytes([]interface{}{common.NewBigIntUint64(1e18).BigIntPtr(), uint64(30), common.HexToAddress("0x666")})
+	assert.Nil(t, err)
+	withSigner, err := rlp.EncodeToBytes(&tests[1])
+	assert.Nil(t, err)
+	assert.Equal(t, legacy, withSigner)
+
+	// the empty optional elements shall be counted
+	emptySigner, err := rlp.EncodeToBytes([]interface{}{common.NewBigIntUint64(1e18).BigIntPtr(), uint64(30), []byte{}})
+	assert.Nil(t, err)
+	var decoded AddCandidateTxData
+	assert.Nil(t, rlp.DecodeBytes(emptySigner, &decoded))
+	assert.Equal(t, common.Address{}, decoded.Signer)
+	assert.Equal(t, 1, decoded.NumOptional())
+}
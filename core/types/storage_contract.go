@@ -14,10 +14,61 @@ type StorageContractRLPHash interface {
 	RLPHash() common.Hash
 }
 
+// host announcement versions. The legacy announcement carries only the net address,
+// while the structured announcement carries the metadata as well
+const (
+	HostAnnouncementVersionLegacy uint64 = 1
+	HostAnnouncementVersion       uint64 = 2
+)
+
 type HostAnnouncement struct {
 	// host enode url
 	NetAddress string
 	Signature  []byte
+
+	// Metadata is the structured metadata of the announcement. It is decoded as the rlp
+	// tail so that the legacy announcement without metadata is still valid. At most one
+	// metadata is allowed
+	Metadata []HostAnnouncementMetadata `rlp:"tail"`
+}
+
+// HostAnnouncementMetadata is the structured metadata carried by the host announcement
+type HostAnnouncementMetadata struct {
+	Version uint64
+
+	// Sequence increases each time the host renews the announcement, the announcement
+	// or retirement with a lower sequence is stale
+	Sequence uint64
+
+	// Expiration is the block height after which the announcement expires if not renewed
+	Expiration uint64
+
+	// Region is the region or country code where the host is located
+	Region string
+
+	// ProtocolVersion is the version of the storage protocol used by the host
+	ProtocolVersion uint64
+
+	// Features is the list of optional features supported by the host
+	Features []string
+
+	// Bond is the amount of balance the host declares to hold as the bond
+	Bond *big.Int
+
+	// Announcer is the account sending the announcement and locking the bond. It is signed
+	// by the host so that the announcement cannot be sent by another account
+	Announcer common.Address
+}
+
+// HostRetirement withdraws the announcements of the host
+type HostRetirement struct {
+	// host enode url
+	NetAddress string
+
+	// Sequence is the sequence of the retirement, announcements with a lower or equal
+	// sequence are withdrawn
+	Sequence  uint64
+	Signature []byte
 }
 
 type UnlockConditions struct {
@@ -77,10 +128,35 @@ type StorageProof struct {
 	Signature []byte
 }
 
-// RLPHash calculate the hash of HostAnnouncement
+// RLPHash calculate the hash of HostAnnouncement. The hash of legacy announcement
+// covers the net address only
 func (ha HostAnnouncement) RLPHash() common.Hash {
+	if len(ha.Metadata) == 0 {
+		return rlpHash([]interface{}{
+			ha.NetAddress,
+		})
+	}
 	return rlpHash([]interface{}{
 		ha.NetAddress,
+		ha.Metadata,
+	})
+}
+
+// Version returns the version of the host announcement
+func (ha HostAnnouncement) Version() uint64 {
+	if len(ha.Metadata) == 0 {
+		return HostAnnouncementVersionLegacy
+	}
+	return ha.Metadata[0].Version
+}
+
+// RLPHash calculate the hash of HostRetirement. The hash is prefixed to be distinguished
+// from the hash of the host announcement with the same net address
+func (hr HostRetirement) RLPHash() common.Hash {
+	return rlpHash([]interface{}{
+		"retire",
+		hr.NetAddress,
+		hr.Sequence,
 	})
 }
 
@@ -0,0 +1,87 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package types
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// legacyHostAnnouncement is the host announcement format before the metadata is introduced
+type legacyHostAnnouncement struct {
+	NetAddress string
+	Signature  []byte
+}
+
+func TestHostAnnouncement_LegacyCompatible(t *testing.T) {
+	legacy := legacyHostAnnouncement{NetAddress: "enode://legacy", Signature: []byte{1, 2, 3}}
+	data, err := rlp.EncodeToBytes(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ha HostAnnouncement
+	if err := rlp.DecodeBytes(data, &ha); err != nil {
+		t.Fatalf("failed to decode the legacy announcement: %v", err)
+	}
+	if ha.NetAddress != legacy.NetAddress || len(ha.Metadata) != 0 {
+		t.Errorf("legacy announcement not decoded as expected: %+v", ha)
+	}
+	if ha.Version() != HostAnnouncementVersionLegacy {
+		t.Errorf("unexpected version: %v", ha.Version())
+	}
+	if want := rlpHash([]interface{}{legacy.NetAddress}); ha.RLPHash() != want {
+		t.Errorf("legacy announcement hash changed: %x != %x", ha.RLPHash(), want)
+	}
+	encoded, err := rlp.EncodeToBytes(ha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(encoded, data) {
+		t.Errorf("legacy announcement encoding changed: %x != %x", encoded, data)
+	}
+}
+
+func TestHostAnnouncement_Metadata(t *testing.T) {
+	ha := HostAnnouncement{
+		NetAddress: "enode://structured",
+		Signature:  []byte{1, 2, 3},
+		Metadata: []HostAnnouncementMetadata{{
+			Version:         HostAnnouncementVersion,
+			Sequence:        10,
+			Expiration:      100,
+			Region:          "DE",
+			ProtocolVersion: 1,
+			Features:        []string{"download-batch"},
+			Bond:            big.NewInt(1000),
+		}},
+	}
+	data, err := rlp.EncodeToBytes(ha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded HostAnnouncement
+	if err := rlp.DecodeBytes(data, &decoded); err != nil {
+		t.Fatalf("failed to decode the announcement: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, ha) {
+		t.Errorf("announcement not decoded as expected: %+v != %+v", decoded, ha)
+	}
+	if decoded.Version() != HostAnnouncementVersion {
+		t.Errorf("unexpected version: %v", decoded.Version())
+	}
+	// the metadata is covered by the signed hash
+	legacyHash := HostAnnouncement{NetAddress: ha.NetAddress}.RLPHash()
+	if ha.RLPHash() == legacyHash {
+		t.Error("announcement hash shall cover the metadata")
+	}
+	// the retirement hash shall not collide with the announcement hash
+	if (HostRetirement{NetAddress: ha.NetAddress}).RLPHash() == legacyHash {
+		t.Error("retirement hash shall be distinguished from the announcement hash")
+	}
+}
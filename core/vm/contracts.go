@@ -42,6 +42,8 @@ const (
 	CommitRevisionTransaction = "CommitRevision"
 	//StorageProofTransaction host storage proof  transaction tag
 	StorageProofTransaction = "StorageProof"
+	//HostRetireTransaction host retire transaction tag
+	HostRetireTransaction = "HostRetire"
 
 	// DPoS consensus transaction tags
 
@@ -56,6 +58,12 @@ const (
 
 	// CancelVote is the tx type of canceling all vote
 	CancelVote = "CancelVote"
+
+	// RotateSigningKey is the tx type of registering a new block signing key for candidate
+	RotateSigningKey = "RotateSigningKey"
+
+	// UpdateCandidateMetadata is the tx type of updating the metadata of candidate
+	UpdateCandidateMetadata = "UpdateCandidateMetadata"
 )
 
 var (
@@ -70,22 +78,50 @@ var (
 
 	// CancelVoteContractAddress is pre-compiled cancel vote contract address
 	CancelVoteContractAddress = common.BytesToAddress([]byte{16})
+
+	// RotateSigningKeyContractAddress is pre-compiled rotate signing key contract address
+	RotateSigningKeyContractAddress = common.BytesToAddress([]byte{17})
+
+	// UpdateCandidateMetadataContractAddress is pre-compiled update candidate metadata contract address
+	UpdateCandidateMetadataContractAddress = common.BytesToAddress([]byte{18})
+
+	// HostRetireContractAddress is pre-compiled host retire contract address
+	HostRetireContractAddress = common.BytesToAddress([]byte{19})
 )
 
-// PrecompiledStorageContracts currently contains the transaction types required for four storage contracts
+// PrecompiledStorageContracts currently contains the transaction types required for five storage contracts
 var PrecompiledStorageContracts = map[common.Address]string{
 	common.BytesToAddress([]byte{9}):  HostAnnounceTransaction,
 	common.BytesToAddress([]byte{10}): ContractCreateTransaction,
 	common.BytesToAddress([]byte{11}): CommitRevisionTransaction,
 	common.BytesToAddress([]byte{12}): StorageProofTransaction,
+	HostRetireContractAddress:         HostRetireTransaction,
 }
 
 // PrecompiledDPoSContracts contains some tx types required for DPoS consensus
 var PrecompiledDPoSContracts = map[common.Address]string{
-	ApplyCandidateContractAddress:  ApplyCandidate,
-	CancelCandidateContractAddress: CancelCandidate,
-	VoteContractAddress:            Vote,
-	CancelVoteContractAddress:      CancelVote,
+	ApplyCandidateContractAddress:          ApplyCandidate,
+	CancelCandidateContractAddress:         CancelCandidate,
+	VoteContractAddress:                    Vote,
+	CancelVoteContractAddress:              CancelVote,
+	RotateSigningKeyContractAddress:        RotateSigningKey,
+	UpdateCandidateMetadataContractAddress: UpdateCandidateMetadata,
+}
+
+// IsPrecompiledContractActive returns whether the storage or DPoS pre-compiled contract at the
+// address is activated at the block number. The transaction sent to the contract not activated
+// yet is handled as a normal transaction
+func IsPrecompiledContractActive(config *params.ChainConfig, num *big.Int, addr common.Address) bool {
+	switch addr {
+	case RotateSigningKeyContractAddress:
+		return config.IsSigningKey(num)
+	case UpdateCandidateMetadataContractAddress:
+		return config.IsCandidateMetadata(num)
+	case HostRetireContractAddress:
+		return config.IsHostRetire(num)
+	default:
+		return true
+	}
 }
 
 type PrecompiledContract interface {
@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/params"
 )
 
 // precompiledTest defines the input/output pairs for precompiled contract tests.
@@ -481,3 +482,30 @@ func BenchmarkPrecompiledBn256Pairing(bench *testing.B) {
 		benchmarkPrecompiled("08", test, bench)
 	}
 }
+
+// TestIsPrecompiledContractActive test the pre-compiled contracts gated by the fork blocks
+// are only activated since the fork block
+func TestIsPrecompiledContractActive(t *testing.T) {
+	config := &params.ChainConfig{SigningKeyBlock: big.NewInt(10), CandidateMetadataBlock: big.NewInt(20), HostRetireBlock: big.NewInt(30)}
+	tests := []struct {
+		addr   common.Address
+		num    int64
+		active bool
+	}{
+		{ApplyCandidateContractAddress, 0, true},
+		{RotateSigningKeyContractAddress, 9, false},
+		{RotateSigningKeyContractAddress, 10, true},
+		{UpdateCandidateMetadataContractAddress, 10, false},
+		{UpdateCandidateMetadataContractAddress, 20, true},
+		{HostRetireContractAddress, 20, false},
+		{HostRetireContractAddress, 30, true},
+	}
+	for _, test := range tests {
+		if active := IsPrecompiledContractActive(config, big.NewInt(test.num), test.addr); active != test.active {
+			t.Errorf("contract %x at block %v: expect active %v, got %v", test.addr, test.num, test.active, active)
+		}
+	}
+	if IsPrecompiledContractActive(&params.ChainConfig{}, big.NewInt(1e9), RotateSigningKeyContractAddress) {
+		t.Error("contract without the fork block shall never be activated")
+	}
+}
@@ -41,6 +41,10 @@ var (
 
 	errUnknownStorageContractTx = errors.New("unknown storage contract tx")
 	errUnknownDposOperationTx   = errors.New("unknown dpos operation tx")
+	errSigningKeyNotActivated   = errors.New("separated candidate signing key is not activated")
+	errMetadataNotActivated     = errors.New("candidate metadata registry is not activated")
+
+	errAnnouncementMetadataNotActivated = errors.New("host announcement metadata is not activated")
 )
 
 type (
@@ -497,6 +501,8 @@ func (evm *EVM) ApplyStorageContractTransaction(caller ContractRef, txType strin
 		return evm.CommitRevisionTx(caller, data, gas)
 	case StorageProofTransaction:
 		return evm.StorageProofTx(caller, data, gas)
+	case HostRetireTransaction:
+		return evm.HostRetireTx(caller, data, gas)
 	default:
 		return nil, gas, errUnknownStorageContractTx
 	}
@@ -522,6 +528,10 @@ func (evm *EVM) ApplyDposTransaction(txType string, dposContext *types.DposConte
 		return evm.VoteTx(from, dposContext, data, gas)
 	case CancelVote:
 		return evm.CancelVoteTx(from, dposContext, gas)
+	case RotateSigningKey:
+		return evm.RotateSigningKeyTx(from, dposContext, data, gas)
+	case UpdateCandidateMetadata:
+		return evm.UpdateCandidateMetadataTx(from, dposContext, data, gas)
 	default:
 		return nil, gas, errUnknownDposOperationTx
 	}
@@ -538,6 +548,12 @@ func (evm *EVM) HostAnnounceTx(caller ContractRef, data []byte, gas uint64) ([]b
 		return nil, gasDecode, errDec
 	}
 
+	// The nodes before the host retire fork fail to decode the announcement with metadata,
+	// thus the metadata is rejected before any work on it is charged
+	if len(ha.Metadata) != 0 && !evm.chainConfig.IsHostRetire(evm.BlockNumber) {
+		return nil, gasDecode, errAnnouncementMetadataNotActivated
+	}
+
 	gasCheck, resultCheck := RemainGas(gasDecode, CheckMultiSignatures, ha, [][]byte{ha.Signature})
 	errCheck, _ := resultCheck[0].(error)
 	if errCheck != nil {
@@ -545,10 +561,81 @@ func (evm *EVM) HostAnnounceTx(caller ContractRef, data []byte, gas uint64) ([]b
 		return nil, gasCheck, errCheck
 	}
 
-	log.Trace("Host announce tx execution done", "remain_gas", gasCheck, "host_address", ha.NetAddress)
+	// legacy announcement carries no metadata to be checked
+	if len(ha.Metadata) == 0 {
+		log.Trace("Host announce tx execution done", "remain_gas", gasCheck, "host_address", ha.NetAddress)
+		return nil, gasCheck, nil
+	}
+
+	// check the structured metadata of the announcement
+	currentHeight := evm.BlockNumber.Uint64()
+	gasMeta, resultMeta := RemainGas(gasCheck, CheckHostAnnouncement, evm.StateDB, ha, caller.Address(), currentHeight)
+	errMeta, _ := resultMeta[0].(error)
+	if errMeta != nil {
+		log.Error("Failed to check metadata for host announce", "err", errMeta)
+		return nil, gasMeta, errMeta
+	}
+
+	id, err := hostEnodeID(ha.NetAddress)
+	if err != nil {
+		return nil, gasMeta, err
+	}
+
+	// defines that saving the announcer, the bond, the sequence and updating the frozen
+	// assets all cost params.SstoreSetGas
+	ok, gasRemain := DeductGas(gasMeta, params.SstoreSetGas*4)
+	if !ok {
+		return nil, gasMeta, ErrOutOfGas
+	}
+	if err := lockHostAnnouncementBond(evm.StateDB, id, caller.Address(), ha.Metadata[0]); err != nil {
+		log.Error("Failed to lock bond for host announce", "err", err)
+		return nil, gasRemain, err
+	}
+
+	log.Trace("Host announce tx execution done", "remain_gas", gasRemain, "host_address", ha.NetAddress, "version", ha.Version())
+
+	// return remain gas if everything is ok
+	return nil, gasRemain, nil
+}
+
+// HostRetireTx host withdraws its announcements on the chain
+func (evm *EVM) HostRetireTx(caller ContractRef, data []byte, gas uint64) ([]byte, uint64, error) {
+	log.Trace("Enter host retire tx executing ... ")
+
+	hr := types.HostRetirement{}
+	gasDecode, resultDecode := RemainGas(gas, rlp.DecodeBytes, data, &hr)
+	errDec, _ := resultDecode[0].(error)
+	if errDec != nil {
+		return nil, gasDecode, errDec
+	}
+
+	gasCheck, resultCheck := RemainGas(gasDecode, CheckMultiSignatures, hr, [][]byte{hr.Signature})
+	errCheck, _ := resultCheck[0].(error)
+	if errCheck != nil {
+		log.Error("Failed to check signature for host retire", "err", errCheck)
+		return nil, gasCheck, errCheck
+	}
+
+	id, err := hostEnodeID(hr.NetAddress)
+	if err != nil {
+		return nil, gasCheck, err
+	}
+
+	// defines that saving the announcer, the bond, the sequence and updating the frozen
+	// assets all cost params.SstoreSetGas
+	ok, gasRemain := DeductGas(gasCheck, params.SstoreSetGas*4)
+	if !ok {
+		return nil, gasCheck, ErrOutOfGas
+	}
+	if err := releaseHostAnnouncementBond(evm.StateDB, id, caller.Address(), hr); err != nil {
+		log.Error("Failed to release bond for host retire", "err", err)
+		return nil, gasRemain, err
+	}
+
+	log.Trace("Host retire tx execution done", "remain_gas", gasRemain, "host_address", hr.NetAddress)
 
 	// return remain gas if everything is ok
-	return nil, gasCheck, nil
+	return nil, gasRemain, nil
 }
 
 // CreateContractTx executes contract creation tx
@@ -777,12 +864,38 @@ func (evm *EVM) CandidateTx(caller common.Address, data []byte, gas uint64, dpos
 	if errDec != nil {
 		return nil, gasRemainDec, errDec
 	}
+	// The nodes before the signing key fork fail to decode any optional element, thus
+	// the optional elements are rejected before the fork even if they are empty
+	if voteData.NumOptional() > 0 && !evm.chainConfig.IsSigningKey(evm.BlockNumber) {
+		return nil, gasRemainDec, errSigningKeyNotActivated
+	}
+	// Likewise the metadata element is rejected before the candidate metadata fork
+	if voteData.NumOptional() > 1 && !evm.chainConfig.IsCandidateMetadata(evm.BlockNumber) {
+		return nil, gasRemainDec, errMetadataNotActivated
+	}
 	// Add candidate in dpos
 	if err := dpos.ProcessAddCandidate(evm.StateDB, dposContext, caller, voteData.Deposit, voteData.RewardRatio); err != nil {
 		return nil, gasRemainDec, err
 	}
+	// Register the separated block signing key if provided
+	signingKeyGas := uint64(0)
+	if voteData.Signer != (common.Address{}) {
+		if err := dpos.ProcessRotateSigningKey(evm.StateDB, dposContext, caller, voteData.Signer); err != nil {
+			return nil, gasRemainDec, err
+		}
+		// defines that storing the signing key costs params.SstoreSetGas
+		signingKeyGas = params.SstoreSetGas
+	}
+	// Register the candidate metadata if provided
+	metadataGas := uint64(0)
+	if !voteData.Metadata.IsEmpty() {
+		if err := dpos.ProcessUpdateCandidateMetadata(evm.StateDB, dposContext, caller, voteData.Metadata); err != nil {
+			return nil, gasRemainDec, err
+		}
+		metadataGas = candidateMetadataGas(voteData.Metadata)
+	}
 	// defines that dposCtx.BecomeCandidate and SetState all cost params.SstoreSetGas
-	ok, gasRemain := DeductGas(gasRemainDec, params.SstoreSetGas*3)
+	ok, gasRemain := DeductGas(gasRemainDec, params.SstoreSetGas*3+signingKeyGas+metadataGas)
 	if !ok {
 		return nil, gasRemainDec, ErrOutOfGas
 	}
@@ -791,6 +904,60 @@ func (evm *EVM) CandidateTx(caller common.Address, data []byte, gas uint64, dpos
 	return nil, gasRemain, nil
 }
 
+// RotateSigningKeyTx registers a new block signing key for the candidate. The deposit
+// and the rewards stay with the candidate address
+func (evm *EVM) RotateSigningKeyTx(caller common.Address, dposCtx *types.DposContext, data []byte, gas uint64) ([]byte, uint64, error) {
+	log.Trace("Enter rotate signing key tx executing ... ")
+	var keyData *types.SigningKeyTxData
+	gasRemainDec, resultDec := RemainGas(gas, rlp.DecodeBytes, data, &keyData)
+	errDec, _ := resultDec[0].(error)
+	if errDec != nil {
+		return nil, gasRemainDec, errDec
+	}
+	if err := dpos.ProcessRotateSigningKey(evm.StateDB, dposCtx, caller, keyData.Signer); err != nil {
+		return nil, gasRemainDec, err
+	}
+	// defines that SetState cost params.SstoreSetGas
+	ok, gasRemain := DeductGas(gasRemainDec, params.SstoreSetGas)
+	if !ok {
+		return nil, gasRemainDec, ErrOutOfGas
+	}
+	log.Trace("Rotate signing key tx execution done", "signer", keyData.Signer)
+	return nil, gasRemain, nil
+}
+
+// UpdateCandidateMetadataTx updates the metadata of the candidate, which is shown to
+// the delegators. An empty metadata removes the registered metadata
+func (evm *EVM) UpdateCandidateMetadataTx(caller common.Address, dposCtx *types.DposContext, data []byte, gas uint64) ([]byte, uint64, error) {
+	log.Trace("Enter update candidate metadata tx executing ... ")
+	var metadata *types.CandidateMetadata
+	gasRemainDec, resultDec := RemainGas(gas, rlp.DecodeBytes, data, &metadata)
+	errDec, _ := resultDec[0].(error)
+	if errDec != nil {
+		return nil, gasRemainDec, errDec
+	}
+	if err := dpos.ProcessUpdateCandidateMetadata(evm.StateDB, dposCtx, caller, *metadata); err != nil {
+		return nil, gasRemainDec, err
+	}
+	// defines that storing the metadata size costs params.SstoreSetGas
+	ok, gasRemain := DeductGas(gasRemainDec, params.SstoreSetGas+candidateMetadataGas(*metadata))
+	if !ok {
+		return nil, gasRemainDec, ErrOutOfGas
+	}
+	log.Trace("Update candidate metadata tx execution done")
+	return nil, gasRemain, nil
+}
+
+// candidateMetadataGas returns the gas of storing the metadata, which is params.SstoreSetGas
+// for each stored chunk of hash length
+func candidateMetadataGas(metadata types.CandidateMetadata) uint64 {
+	encoded, err := rlp.EncodeToBytes(&metadata)
+	if err != nil {
+		return 0
+	}
+	return params.SstoreSetGas * uint64((len(encoded)+common.HashLength-1)/common.HashLength)
+}
+
 // CandidateCancelTx cancellation of candidate thawing assets requires a defrosting period.
 func (evm *EVM) CandidateCancelTx(caller common.Address, gas uint64, dposContext *types.DposContext) ([]byte, uint64, error) {
 	log.Trace("Enter cancel candidate tx executing ... ")
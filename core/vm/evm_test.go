@@ -14,6 +14,7 @@ import (
 	"testing"
 
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
 	"github.com/DxChainNetwork/godx/core/rawdb"
 	"github.com/DxChainNetwork/godx/core/state"
 	"github.com/DxChainNetwork/godx/core/types"
@@ -321,6 +322,232 @@ func TestEVM_HostAnnounceTx(t *testing.T) {
 	}
 }
 
+func TestEVM_HostAnnounceTxMetadata(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate private key,error: %v", err)
+	}
+	hostNode := enode.NewV4(&privateKey.PublicKey, net.IP{127, 0, 0, 1}, int(8888), int(8888))
+
+	hostAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
+	otherAddress := common.HexToAddress("0xfe")
+	accounts := mockAccountAlloc([]common.Address{hostAddress, otherAddress})
+	stateDB := mockState(ethdb.NewMemDatabase(), accounts)
+	evm := NewEVM(Context{BlockNumber: big.NewInt(100)}, stateDB, params.TestChainConfig, Config{})
+
+	tests := []struct {
+		metadata  types.HostAnnouncementMetadata
+		sender    common.Address
+		announcer common.Address
+		err       error
+	}{
+		{
+			metadata: types.HostAnnouncementMetadata{Version: types.HostAnnouncementVersion, Sequence: 100, Expiration: 200, Region: "US", Bond: balanceOrigin},
+		},
+		{
+			metadata: types.HostAnnouncementMetadata{Version: types.HostAnnouncementVersion + 1, Sequence: 100},
+			err:      errUnknownAnnouncementVersion,
+		},
+		{
+			metadata: types.HostAnnouncementMetadata{Version: types.HostAnnouncementVersion, Sequence: 100, Expiration: 100},
+			err:      errExpiredAnnouncement,
+		},
+		{
+			metadata: types.HostAnnouncementMetadata{Version: types.HostAnnouncementVersion, Sequence: 101, Bond: new(big.Int).Add(balanceOrigin, big.NewInt(1))},
+			err:      errInsufficientAnnouncementBond,
+		},
+		// the announcement is not able to be replayed
+		{
+			metadata: types.HostAnnouncementMetadata{Version: types.HostAnnouncementVersion, Sequence: 100, Bond: balanceOrigin},
+			err:      errStaleHostAnnouncement,
+		},
+		// the bond locked by the previous announcement covers the same bond again
+		{
+			metadata: types.HostAnnouncementMetadata{Version: types.HostAnnouncementVersion, Sequence: 101, Bond: balanceOrigin},
+		},
+		// the announcement signed for another account is not able to be sent by the sender
+		{
+			metadata:  types.HostAnnouncementMetadata{Version: types.HostAnnouncementVersion, Sequence: 102, Bond: balanceOrigin},
+			sender:    otherAddress,
+			announcer: hostAddress,
+			err:       errAnnouncerMismatch,
+		},
+		// another account is not able to announce the host with the bond locked
+		{
+			metadata: types.HostAnnouncementMetadata{Version: types.HostAnnouncementVersion, Sequence: 102, Bond: balanceOrigin},
+			sender:   otherAddress,
+			err:      errNotHostAnnouncer,
+		},
+		// the announcement without bond releases the locked bond
+		{
+			metadata: types.HostAnnouncementMetadata{Version: types.HostAnnouncementVersion, Sequence: 102},
+		},
+	}
+
+	for i, test := range tests {
+		sender := hostAddress
+		if test.sender != (common.Address{}) {
+			sender = test.sender
+		}
+		test.metadata.Announcer = sender
+		if test.announcer != (common.Address{}) {
+			test.metadata.Announcer = test.announcer
+		}
+		ha := types.HostAnnouncement{
+			NetAddress: hostNode.String(),
+			Metadata:   []types.HostAnnouncementMetadata{test.metadata},
+		}
+		if ha.Signature, err = crypto.Sign(ha.RLPHash().Bytes(), privateKey); err != nil {
+			t.Fatalf("failed to sign host announce,error: %v", err)
+		}
+		rlpBytes, err := rlp.EncodeToBytes(ha)
+		if err != nil {
+			t.Fatalf("failed to rlp host announce,error: %v", err)
+		}
+
+		prevBond := GetHostAnnouncementBond(stateDB, hostNode.ID())
+		_, gasLeft, err := evm.HostAnnounceTx(AccountRef(sender), rlpBytes, gasOrigin)
+		if err != test.err {
+			t.Errorf("test %d: unexpected error: got %v, want %v", i, err, test.err)
+		}
+		wantGas := gasOrigin - params.DecodeGas - params.CheckMultiSignaturesGas - params.CheckFileGas
+		if err == nil || err == errStaleHostAnnouncement || err == errNotHostAnnouncer {
+			wantGas -= params.SstoreSetGas * 4
+		}
+		if gasLeft != wantGas {
+			t.Errorf("test %d: gas left is not right, wanted %d, got %d", i, wantGas, gasLeft)
+		}
+
+		// the bond of the valid announcement is escrowed as the frozen assets of the host
+		wantBond := prevBond
+		if err == nil {
+			wantBond = common.BigInt0
+			if test.metadata.Bond != nil {
+				wantBond = common.PtrBigInt(test.metadata.Bond)
+			}
+		}
+		if bond := GetHostAnnouncementBond(stateDB, hostNode.ID()); bond.Cmp(wantBond) != 0 {
+			t.Errorf("test %d: locked bond not expected, wanted %v, got %v", i, wantBond, bond)
+		}
+		if frozen := dpos.GetFrozenAssets(stateDB, hostAddress); frozen.Cmp(wantBond) != 0 {
+			t.Errorf("test %d: frozen assets not expected, wanted %v, got %v", i, wantBond, frozen)
+		}
+		if frozen := dpos.GetFrozenAssets(stateDB, otherAddress); frozen.Sign() != 0 {
+			t.Errorf("test %d: frozen assets of other account not expected: %v", i, frozen)
+		}
+	}
+}
+
+func TestEVM_HostAnnounceTxMetadataBeforeFork(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate private key,error: %v", err)
+	}
+	hostNode := enode.NewV4(&privateKey.PublicKey, net.IP{127, 0, 0, 1}, int(8888), int(8888))
+
+	hostAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
+	stateDB := mockState(ethdb.NewMemDatabase(), mockAccountAlloc([]common.Address{hostAddress}))
+	evm := NewEVM(Context{BlockNumber: big.NewInt(100)}, stateDB, params.MainnetChainConfig, Config{})
+
+	// the metadata is rejected before the fork, and only the decoding is charged
+	ha := types.HostAnnouncement{
+		NetAddress: hostNode.String(),
+		Metadata:   []types.HostAnnouncementMetadata{{Version: types.HostAnnouncementVersion, Sequence: 100}},
+	}
+	if ha.Signature, err = crypto.Sign(ha.RLPHash().Bytes(), privateKey); err != nil {
+		t.Fatalf("failed to sign host announce,error: %v", err)
+	}
+	rlpBytes, err := rlp.EncodeToBytes(ha)
+	if err != nil {
+		t.Fatalf("failed to rlp host announce,error: %v", err)
+	}
+	_, gasLeft, err := evm.HostAnnounceTx(AccountRef(hostAddress), rlpBytes, gasOrigin)
+	if err != errAnnouncementMetadataNotActivated {
+		t.Errorf("unexpected error: got %v, want %v", err, errAnnouncementMetadataNotActivated)
+	}
+	if gasLeft != gasOrigin-params.DecodeGas {
+		t.Errorf("gas left is not right, wanted %d, got %d", gasOrigin-params.DecodeGas, gasLeft)
+	}
+}
+
+func TestEVM_HostRetireTx(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate private key,error: %v", err)
+	}
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate private key,error: %v", err)
+	}
+	hostNode := enode.NewV4(&privateKey.PublicKey, net.IP{127, 0, 0, 1}, int(8888), int(8888))
+
+	hostAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
+	otherAddress := crypto.PubkeyToAddress(otherKey.PublicKey)
+	stateDB := mockState(ethdb.NewMemDatabase(), mockAccountAlloc([]common.Address{hostAddress, otherAddress}))
+	evm := NewEVM(Context{}, stateDB, params.TestChainConfig, Config{})
+	meta := types.HostAnnouncementMetadata{Sequence: 100, Bond: balanceOrigin}
+	if err := lockHostAnnouncementBond(stateDB, hostNode.ID(), hostAddress, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key      *ecdsa.PrivateKey
+		sender   common.Address
+		sequence uint64
+		err      bool
+		wantErr  error
+		released bool
+	}{
+		// only the retirement signed by the host node is valid
+		{key: otherKey, sender: hostAddress, sequence: 100, err: true},
+		// only the account sending the announcement is able to release the bond
+		{key: privateKey, sender: otherAddress, sequence: 100, err: true, wantErr: errNotHostAnnouncer},
+		// the retirement must cover the latest announcement
+		{key: privateKey, sender: hostAddress, sequence: 99, err: true, wantErr: errStaleHostRetirement},
+		{key: privateKey, sender: hostAddress, sequence: 100, released: true},
+		// the retirement is not able to be replayed
+		{key: privateKey, sender: hostAddress, sequence: 100, err: true, wantErr: errStaleHostRetirement, released: true},
+	}
+
+	for i, test := range tests {
+		hr := types.HostRetirement{
+			NetAddress: hostNode.String(),
+			Sequence:   test.sequence,
+		}
+		if hr.Signature, err = crypto.Sign(hr.RLPHash().Bytes(), test.key); err != nil {
+			t.Fatalf("failed to sign host retire,error: %v", err)
+		}
+		rlpBytes, err := rlp.EncodeToBytes(hr)
+		if err != nil {
+			t.Fatalf("failed to rlp host retire,error: %v", err)
+		}
+
+		_, gasLeft, err := evm.HostRetireTx(AccountRef(test.sender), rlpBytes, gasOrigin)
+		if (err != nil) != test.err || (test.wantErr != nil && err != test.wantErr) {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		wantGas := gasOrigin - params.DecodeGas - params.CheckMultiSignaturesGas
+		if err == nil || test.wantErr != nil {
+			wantGas -= params.SstoreSetGas * 4
+		}
+		if gasLeft != wantGas {
+			t.Errorf("test %d: gas left is not right, wanted %d, got %d", i, wantGas, gasLeft)
+		}
+
+		// the bond locked by the host announcement is released after the retirement
+		wantBond := common.PtrBigInt(balanceOrigin)
+		if test.released {
+			wantBond = common.BigInt0
+		}
+		if bond := GetHostAnnouncementBond(stateDB, hostNode.ID()); bond.Cmp(wantBond) != 0 {
+			t.Errorf("test %d: locked bond not expected, wanted %v, got %v", i, wantBond, bond)
+		}
+		if frozen := dpos.GetFrozenAssets(stateDB, hostAddress); frozen.Cmp(wantBond) != 0 {
+			t.Errorf("test %d: frozen assets not expected, wanted %v, got %v", i, wantBond, frozen)
+		}
+	}
+}
+
 func TestEVM_CreateContractTx(t *testing.T) {
 
 	// mock evm, state, client and host address ...
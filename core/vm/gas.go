@@ -180,6 +180,30 @@ func RemainGas(args ...interface{}) (uint64, []interface{}) {
 		result = append(result, nil)
 		return gas, result
 
+		//CheckHostAnnouncement
+	case func(StateDB, types.HostAnnouncement, common.Address, uint64) error:
+		if gas < params.CheckFileGas {
+			result = append(result, errGasCalculationInsufficient)
+			return gas, result
+		}
+
+		if len(args) != 6 {
+			result = append(result, errGasCalculationParamsNumberWrong)
+			return gas, result
+		}
+		state, _ := args[2].(StateDB)
+		ha, _ := args[3].(types.HostAnnouncement)
+		caller, _ := args[4].(common.Address)
+		bl, _ := args[5].(uint64)
+		gas -= params.CheckFileGas
+		err := i(state, ha, caller, bl)
+		if err != nil {
+			result = append(result, err)
+			return gas, result
+		}
+		result = append(result, nil)
+		return gas, result
+
 		//CheckMultiSignatures
 	case func(types.StorageContractRLPHash, [][]byte) error:
 		if gas < params.CheckMultiSignaturesGas {
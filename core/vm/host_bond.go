@@ -0,0 +1,153 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+)
+
+var (
+	errStaleHostAnnouncement = errors.New("host announcement sequence is not larger than the previous one")
+	errStaleHostRetirement   = errors.New("host retirement sequence is smaller than the previous one")
+	errNotHostAnnouncer      = errors.New("the host is announced by another account")
+)
+
+var (
+	// KeyHostAnnouncer is the key of the account sending the latest host announcement, which
+	// locks the bond of the host
+	KeyHostAnnouncer = common.BytesToHash([]byte("host-announcer"))
+
+	// KeyHostAnnouncementBond is the key of the bond locked by the latest host announcement
+	KeyHostAnnouncementBond = common.BytesToHash([]byte("host-announcement-bond"))
+
+	// KeyHostSequence is the key of the sequence of the latest host announcement or retirement
+	KeyHostSequence = common.BytesToHash([]byte("host-sequence"))
+)
+
+// hostStatePrefix is the prefix to derive the address keeping the announcement state of the host
+const hostStatePrefix = "host-announcement-state"
+
+// HostStateAddress returns the address of the account keeping the announcement state of the
+// host, which is derived from the enode ID of the host
+func HostStateAddress(id enode.ID) common.Address {
+	return common.BytesToAddress(crypto.Keccak256([]byte(hostStatePrefix), id[:]))
+}
+
+// GetHostAnnouncer returns the account sending the latest host announcement
+func GetHostAnnouncer(state StateDB, id enode.ID) common.Address {
+	hash := state.GetState(HostStateAddress(id), KeyHostAnnouncer)
+	return common.BytesToAddress(hash.Bytes())
+}
+
+// GetHostAnnouncementBond returns the bond locked by the latest host announcement
+func GetHostAnnouncementBond(state StateDB, id enode.ID) common.BigInt {
+	hash := state.GetState(HostStateAddress(id), KeyHostAnnouncementBond)
+	return common.PtrBigInt(hash.Big())
+}
+
+// GetHostSequence returns the sequence of the latest host announcement or retirement
+func GetHostSequence(state StateDB, id enode.ID) uint64 {
+	hash := state.GetState(HostStateAddress(id), KeyHostSequence)
+	return hash.Big().Uint64()
+}
+
+// getAnnouncerBond returns the bond of the host locked by the announcer. Zero is returned if
+// the bond is locked by another account
+func getAnnouncerBond(state StateDB, id enode.ID, announcer common.Address) common.BigInt {
+	if GetHostAnnouncer(state, id) != announcer {
+		return common.BigInt0
+	}
+	return GetHostAnnouncementBond(state, id)
+}
+
+// hostEnodeID returns the enode ID of the host with the net address
+func hostEnodeID(netAddress string) (enode.ID, error) {
+	node, err := enode.ParseV4(netAddress)
+	if err != nil {
+		return enode.ID{}, err
+	}
+	return node.ID(), nil
+}
+
+// lockHostAnnouncementBond locks the bond of the host announcement sent by the announcer. The
+// bond is escrowed as the frozen assets of the announcer, so only the difference with the bond
+// locked by the previous announcement is frozen or released. The bond locked by another
+// account must be released by the retirement first
+func lockHostAnnouncementBond(state StateDB, id enode.ID, announcer common.Address, meta types.HostAnnouncementMetadata) error {
+	addr := HostStateAddress(id)
+	if state.Exist(addr) && meta.Sequence <= GetHostSequence(state, id) {
+		return errStaleHostAnnouncement
+	}
+	bond := common.BigInt0
+	if meta.Bond != nil {
+		bond = common.PtrBigInt(meta.Bond)
+	}
+	if GetHostAnnouncer(state, id) != announcer && GetHostAnnouncementBond(state, id).Sign() != 0 {
+		return errNotHostAnnouncer
+	}
+	if err := updateFrozenBond(state, announcer, getAnnouncerBond(state, id, announcer), bond); err != nil {
+		return err
+	}
+	setHostState(state, addr, announcer, bond, meta.Sequence)
+	return nil
+}
+
+// releaseHostAnnouncementBond releases the bond locked by the host announcement on retirement.
+// Only the account sending the latest announcement is able to retire the host, and the
+// retirement must cover the latest announcement. The retirement is recorded so that it cannot
+// be replayed
+func releaseHostAnnouncementBond(state StateDB, id enode.ID, sender common.Address, hr types.HostRetirement) error {
+	addr := HostStateAddress(id)
+	announcer := GetHostAnnouncer(state, id)
+	if state.Exist(addr) {
+		sequence := GetHostSequence(state, id)
+		// the host already retired is not able to retire again with the same sequence
+		if hr.Sequence < sequence || (announcer == common.Address{} && hr.Sequence == sequence) {
+			return errStaleHostRetirement
+		}
+	}
+	if announcer != (common.Address{}) {
+		if announcer != sender {
+			return errNotHostAnnouncer
+		}
+		if err := updateFrozenBond(state, announcer, GetHostAnnouncementBond(state, id), common.BigInt0); err != nil {
+			return err
+		}
+	}
+	setHostState(state, addr, common.Address{}, common.BigInt0, hr.Sequence)
+	return nil
+}
+
+// updateFrozenBond freezes or releases the difference between the previous and the new bond
+// in the frozen assets of the account
+func updateFrozenBond(state StateDB, addr common.Address, prev, bond common.BigInt) error {
+	switch bond.Cmp(prev) {
+	case 1:
+		dpos.AddFrozenAssets(state, addr, bond.Sub(prev))
+	case -1:
+		return dpos.SubFrozenAssets(state, addr, prev.Sub(bond))
+	}
+	return nil
+}
+
+// setHostState saves the announcement state of the host
+func setHostState(state StateDB, addr common.Address, announcer common.Address, bond common.BigInt, sequence uint64) {
+	if !state.Exist(addr) {
+		state.CreateAccount(addr)
+
+		// mark the address as not empty account to avoid being deleted by stateDB
+		state.SetNonce(addr, 1)
+	}
+	state.SetState(addr, KeyHostAnnouncer, common.BytesToHash(announcer.Bytes()))
+	state.SetState(addr, KeyHostAnnouncementBond, common.BigToHash(bond.BigIntPtr()))
+	state.SetState(addr, KeyHostSequence, common.BigToHash(new(big.Int).SetUint64(sequence)))
+}
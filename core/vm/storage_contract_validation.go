@@ -16,6 +16,7 @@ import (
 	"strconv"
 
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
 	"github.com/DxChainNetwork/godx/core/rawdb"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/crypto"
@@ -42,8 +43,66 @@ var (
 	errNoStorageContractType                   = errors.New("no this storage contract type")
 	errInvalidStorageProof                     = errors.New("invalid storage proof")
 	errUnfinishedStorageContract               = errors.New("storage contract has not yet opened")
+	errMultipleAnnouncementMetadata            = errors.New("host announcement carries more than one metadata")
+	errUnknownAnnouncementVersion              = errors.New("unknown host announcement version")
+	errExpiredAnnouncement                     = errors.New("host announcement has already expired")
+	errInvalidAnnouncementRegion               = errors.New("host announcement region is too long")
+	errTooManyAnnouncementFeatures             = errors.New("host announcement carries too many features")
+	errInvalidAnnouncementBond                 = errors.New("host announcement bond is negative")
+	errInsufficientAnnouncementBond            = errors.New("host balance is not enough for the announced bond")
+	errAnnouncerMismatch                       = errors.New("host announcement is not sent by the announcer signed by the host")
 )
 
+// host announcement metadata limits
+const (
+	maxAnnouncementRegionLength = 32
+	maxAnnouncementFeatures     = 32
+)
+
+// CheckHostAnnouncement checks whether the metadata of the host announcement is valid. The
+// announcement must be sent by the announcer in the metadata, and the announced bond must be covered by the available balance of the account sending the
+// announcement, together with the bond of the host already locked by the account
+func CheckHostAnnouncement(state StateDB, ha types.HostAnnouncement, caller common.Address, currentHeight uint64) error {
+	// legacy announcement carries no metadata
+	if len(ha.Metadata) == 0 {
+		return nil
+	}
+	if len(ha.Metadata) > 1 {
+		return errMultipleAnnouncementMetadata
+	}
+
+	meta := ha.Metadata[0]
+	if meta.Version != types.HostAnnouncementVersion {
+		return errUnknownAnnouncementVersion
+	}
+	if meta.Announcer != caller {
+		return errAnnouncerMismatch
+	}
+	if meta.Expiration != 0 && meta.Expiration <= currentHeight {
+		return errExpiredAnnouncement
+	}
+	if len(meta.Region) > maxAnnouncementRegionLength {
+		return errInvalidAnnouncementRegion
+	}
+	if len(meta.Features) > maxAnnouncementFeatures {
+		return errTooManyAnnouncementFeatures
+	}
+	if meta.Bond != nil {
+		if meta.Bond.Sign() < 0 {
+			return errInvalidAnnouncementBond
+		}
+		id, err := hostEnodeID(ha.NetAddress)
+		if err != nil {
+			return err
+		}
+		available := dpos.GetAvailableBalance(state, caller).Add(getAnnouncerBond(state, id, caller))
+		if available.Cmp(common.PtrBigInt(meta.Bond)) < 0 {
+			return errInsufficientAnnouncementBond
+		}
+	}
+	return nil
+}
+
 // CheckCreateContract checks whether a new StorageContract is valid
 func CheckCreateContract(state StateDB, sc types.StorageContract, currentHeight uint64) error {
 	if sc.ClientCollateral.Value.Sign() <= 0 {
@@ -240,17 +299,25 @@ func CheckMultiSignatures(originalData types.StorageContractRLPHash, signatures
 			return err
 		}
 
-		// if it's a host announce, we must check the node public key is equal to the recover key
-		if ha, ok := originalData.(types.HostAnnouncement); ok {
-			hostNode, err := enode.ParseV4(ha.NetAddress)
-			if err != nil {
-				return fmt.Errorf("invalid host announce address: %v", err)
-			}
-
-			urlKey := hostNode.Pubkey()
-			if !crypto.IsEqualPublicKey(recoverKey, urlKey) {
-				return fmt.Errorf("announced host net address is not generated by self hostnode")
-			}
+		// if it's a host announce or retirement, we must check the node public key is equal to the recover key
+		var netAddress string
+		switch dataType := originalData.(type) {
+		case types.HostAnnouncement:
+			netAddress = dataType.NetAddress
+		case types.HostRetirement:
+			netAddress = dataType.NetAddress
+		default:
+			return nil
+		}
+
+		hostNode, err := enode.ParseV4(netAddress)
+		if err != nil {
+			return fmt.Errorf("invalid host announce address: %v", err)
+		}
+
+		urlKey := hostNode.Pubkey()
+		if !crypto.IsEqualPublicKey(recoverKey, urlKey) {
+			return fmt.Errorf("announced host net address is not generated by self hostnode")
 		}
 	} else if len(signatures) == 2 {
 		clientSig = signatures[0]
@@ -3,6 +3,7 @@ package crypto
 import (
 	"errors"
 	"github.com/DxChainNetwork/godx/crypto/twofishgcm"
+	"github.com/DxChainNetwork/godx/crypto/xchacha20"
 )
 
 const (
@@ -14,15 +15,19 @@ const (
 
 	// GCMCipherCode is the cipher code for twofish-gcm
 	GCMCipherCode
+
+	// XChaCha20CipherCode is the cipher code for XChaCha20-Poly1305
+	XChaCha20CipherCode
 )
 
 var (
 	// ErrInvalidCipherCode is the error type saying that the provided cipher code is not supported.
-	// Supported cipher code: PlainCipherCode, GCMCipherCode
+	// Supported cipher code: PlainCipherCode, GCMCipherCode, XChaCha20CipherCode
 	ErrInvalidCipherCode = errors.New("provided CipherType not supported")
 )
 
-// CipherKey is the interface for cipher key, which is implemented by plainCipherKey, and gcmCipherKey
+// CipherKey is the interface for cipher key, which is implemented by plainCipherKey, gcmCipherKey,
+// and xchacha20CipherKey
 type CipherKey interface {
 	// CodeName return the code specified of the CipherKey type
 	CodeName() string
@@ -67,6 +72,8 @@ func NewCipherKey(cipherCode uint8, key []byte) (CipherKey, error) {
 		return newPlainCipherKey()
 	case GCMCipherCode:
 		return twofishgcm.NewGCMCipherKey(key)
+	case XChaCha20CipherCode:
+		return xchacha20.NewXChaCha20CipherKey(key)
 	default:
 		return nil, ErrInvalidCipherCode
 	}
@@ -79,6 +86,8 @@ func GenerateCipherKey(cipherCode uint8) (CipherKey, error) {
 		return &plainCipherKey{}, nil
 	case GCMCipherCode:
 		return twofishgcm.GenerateGCMCipherKey()
+	case XChaCha20CipherCode:
+		return xchacha20.GenerateXChaCha20CipherKey()
 	default:
 		return nil, ErrInvalidCipherCode
 	}
@@ -91,6 +100,8 @@ func Overhead(cipherCode uint8) uint8 {
 		return (&plainCipherKey{}).Overhead()
 	case GCMCipherCode:
 		return (&(twofishgcm.GCMCipherKey{})).Overhead()
+	case XChaCha20CipherCode:
+		return (&(xchacha20.XChaCha20CipherKey{})).Overhead()
 	default:
 		return 0
 	}
@@ -103,6 +114,8 @@ func CipherCodeByName(cipherName string) uint8 {
 		return PlainCipherCode
 	case (&(twofishgcm.GCMCipherKey{})).CodeName():
 		return GCMCipherCode
+	case (&(xchacha20.XChaCha20CipherKey{})).CodeName():
+		return XChaCha20CipherCode
 	default:
 		return CipherCodeNotSupport
 	}
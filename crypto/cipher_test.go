@@ -3,6 +3,7 @@ package crypto
 import (
 	"bytes"
 	"github.com/DxChainNetwork/godx/crypto/twofishgcm"
+	"github.com/DxChainNetwork/godx/crypto/xchacha20"
 	"reflect"
 	"testing"
 )
@@ -22,6 +23,10 @@ func TestNewCipherKey(t *testing.T) {
 			inputCode: GCMCipherCode, inputKey: bytes.Repeat([]byte{1}, int(twofishgcm.GCMCipherKeyLength)),
 			expectKey: &twofishgcm.GCMCipherKey{}, expectErr: nil,
 		},
+		{
+			inputCode: XChaCha20CipherCode, inputKey: bytes.Repeat([]byte{1}, int(xchacha20.XChaCha20CipherKeyLength)),
+			expectKey: &xchacha20.XChaCha20CipherKey{}, expectErr: nil,
+		},
 		{
 			inputCode: 255, inputKey: []byte{},
 			expectKey: nil, expectErr: ErrInvalidCipherCode,
@@ -54,6 +59,10 @@ func TestGenerateCipherKey(t *testing.T) {
 			inputCode: GCMCipherCode,
 			expectKey: &twofishgcm.GCMCipherKey{}, expectErr: nil,
 		},
+		{
+			inputCode: XChaCha20CipherCode,
+			expectKey: &xchacha20.XChaCha20CipherKey{}, expectErr: nil,
+		},
 		{
 			inputCode: 255,
 			expectKey: nil, expectErr: ErrInvalidCipherCode,
@@ -89,6 +98,10 @@ func TestCodeByName(t *testing.T) {
 			cipherName: "TwoFish_GCM",
 			cipherCode: GCMCipherCode,
 		},
+		{
+			cipherName: "XChaCha20_Poly1305",
+			cipherCode: XChaCha20CipherCode,
+		},
 	}
 	for i, test := range tests {
 		code := CipherCodeByName(test.cipherName)
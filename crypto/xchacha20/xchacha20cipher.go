@@ -0,0 +1,113 @@
+package xchacha20
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	// XChaCha20CipherKeyLength is the key length for XChaCha20CipherKey
+	XChaCha20CipherKeyLength = chacha20poly1305.KeySize
+)
+
+// XChaCha20CipherKey is the implementation of XChaCha20-Poly1305 algorithm, implementing crypto.CipherKey
+// interface. The extended nonce is large enough to be randomly generated for each encryption
+type XChaCha20CipherKey [XChaCha20CipherKeyLength]byte
+
+// CodeName return the XChaCha20CipherCode specifying the key type
+func (xck *XChaCha20CipherKey) CodeName() string {
+	return "XChaCha20_Poly1305"
+}
+
+// Overhead returns the additional overhead used for nonce and authentication tag
+func (xck *XChaCha20CipherKey) Overhead() uint8 {
+	return chacha20poly1305.NonceSizeX + chacha20poly1305.Overhead
+}
+
+// Key returns the encryption/decryption key for the XChaCha20CipherKey
+func (xck *XChaCha20CipherKey) Key() []byte {
+	key := make([]byte, XChaCha20CipherKeyLength)
+	copy(key, xck[:])
+	return key
+}
+
+// Encrypt encrypt the input plainText using XChaCha20-Poly1305 algorithm
+func (xck *XChaCha20CipherKey) Encrypt(plainText []byte) ([]byte, error) {
+	aead, err := xck.newAEAD()
+	if err != nil {
+		return nil, err
+	}
+	// randomize the nonce
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plainText)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	// seal the plainText using nonce
+	return aead.Seal(nonce, nonce, plainText, nil), nil
+}
+
+// Decrypt decrypt the input cipherText using XChaCha20-Poly1305 algorithm
+func (xck *XChaCha20CipherKey) Decrypt(cipherText []byte) ([]byte, error) {
+	aead, nonce, cipherText, err := xck.split(cipherText)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, nonce, cipherText, nil)
+}
+
+// DecryptInPlace make use of the input string and decrypt the cipherText in place
+func (xck *XChaCha20CipherKey) DecryptInPlace(cipherText []byte) ([]byte, error) {
+	aead, nonce, cipherText, err := xck.split(cipherText)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(cipherText[:0], nonce, cipherText, nil)
+}
+
+// split creates the AEAD and splits the input into the nonce and the sealed text
+func (xck *XChaCha20CipherKey) split(cipherText []byte) (cipher.AEAD, []byte, []byte, error) {
+	aead, err := xck.newAEAD()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// First part of cipherText is nonce, and rest is cipherText
+	nonceSize := aead.NonceSize()
+	if len(cipherText) < nonceSize {
+		return nil, nil, nil, fmt.Errorf("decrypt error: cipherText has length %v smaller than nonce %v",
+			len(cipherText), nonceSize)
+	}
+	return aead, cipherText[:nonceSize], cipherText[nonceSize:], nil
+}
+
+// newAEAD is the internal method to create an AEAD using chacha20poly1305.NewX.
+// The method is used in encryption and decryption
+func (xck *XChaCha20CipherKey) newAEAD() (cipher.AEAD, error) {
+	return chacha20poly1305.NewX(xck[:])
+}
+
+// NewXChaCha20CipherKey returns a new XChaCha20CipherKey using the input seed.
+// The input key must be of exact size of XChaCha20CipherKeyLength, which is 32
+func NewXChaCha20CipherKey(seed []byte) (*XChaCha20CipherKey, error) {
+	if len(seed) != XChaCha20CipherKeyLength {
+		err := fmt.Errorf("XChaCha20CipherKey has unexpected length. Expect %v, Got %v", XChaCha20CipherKeyLength, len(seed))
+		return nil, err
+	}
+
+	xck := &XChaCha20CipherKey{}
+	copy(xck[:], seed)
+	return xck, nil
+}
+
+// GenerateXChaCha20CipherKey will generate a new XChaCha20CipherKey with random seed
+func GenerateXChaCha20CipherKey() (*XChaCha20CipherKey, error) {
+	seed := make([]byte, XChaCha20CipherKeyLength)
+	_, err := rand.Read(seed)
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate random seed")
+	}
+	return NewXChaCha20CipherKey(seed)
+}
@@ -0,0 +1,79 @@
+package xchacha20
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+)
+
+func TestNewXChaCha20CipherKey(t *testing.T) {
+	tests := []struct {
+		seed  []byte
+		valid bool
+	}{
+		{common.FromHex("123456789012456789012345678901234567890123456789012345678901234"), true},
+		{common.FromHex("12345678901245678901234567890123456789012345678901234567890"), false},
+		{common.FromHex("123456789012456789012345678901234567890123456789012345678901234567"), false},
+	}
+	for i, test := range tests {
+		xck, err := NewXChaCha20CipherKey(test.seed)
+		if test.valid != (err == nil) {
+			t.Errorf("Test %d: expect valid %v, got error %v", i, test.valid, err)
+		}
+		if xck != nil && !bytes.Equal(xck[:], test.seed) {
+			t.Errorf("Test %d: expect value %v, got %v", i, test.seed, xck[:])
+		}
+	}
+}
+
+func TestXChaCha20Cipher(t *testing.T) {
+	plainTexts := [][]byte{
+		[]byte("I "),
+		[]byte("I am jacky. I am genius"),
+		bytes.Repeat([]byte{1}, 1<<12),
+	}
+	xck, err := GenerateXChaCha20CipherKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, plainText := range plainTexts {
+		ct, err := xck.Encrypt(plainText)
+		if err != nil {
+			t.Fatalf("Test %d: cannot encrypt: %v", i, err)
+		}
+		if len(ct) != len(plainText)+int(xck.Overhead()) {
+			t.Errorf("Test %d: unexpected cipher text length. Expect %v, Got %v", i, len(plainText)+int(xck.Overhead()), len(ct))
+		}
+		recovered, err := xck.Decrypt(ct)
+		if err != nil {
+			t.Fatalf("Test %d: cannot decrypt: %v", i, err)
+		}
+		if !bytes.Equal(recovered, plainText) {
+			t.Errorf("Test %d: unexpected recovered text. Expect %v, Got %v", i, string(plainText), string(recovered))
+		}
+		recoveredInPlace, err := xck.DecryptInPlace(ct)
+		if err != nil {
+			t.Fatalf("Test %d: cannot decrypt in place: %v", i, err)
+		}
+		if !bytes.Equal(recoveredInPlace, plainText) {
+			t.Errorf("Test %d: unexpected in place recovered text. Expect %v, Got %v", i, string(plainText), string(recoveredInPlace))
+		}
+	}
+
+	// the cipher text cannot be decrypted with another key
+	ct, err := xck.Encrypt(plainTexts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	another, err := GenerateXChaCha20CipherKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = another.Decrypt(ct); err == nil {
+		t.Errorf("decrypting with another key shall return error")
+	}
+	if _, err = xck.Decrypt(ct[:10]); err == nil {
+		t.Errorf("decrypting the cipher text shorter than nonce shall return error")
+	}
+}
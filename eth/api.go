@@ -29,6 +29,7 @@ import (
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
 	"github.com/DxChainNetwork/godx/core"
 	"github.com/DxChainNetwork/godx/core/rawdb"
 	"github.com/DxChainNetwork/godx/core/state"
@@ -149,6 +150,29 @@ func (api *PrivateMinerAPI) SetValidator(validator common.Address) bool {
 	return true
 }
 
+// SetSigningKey sets the block signing key of the miner, which shall be the
+// signing key registered by the validator
+func (api *PrivateMinerAPI) SetSigningKey(signer common.Address) bool {
+	api.e.SetSigningKey(signer)
+	return true
+}
+
+// SetStandby switches the miner between the primary mode and the standby mode. A
+// standby miner signs with its own key, and only produces blocks once the validator
+// has rotated its signing key to the standby miner and the rotation is confirmed.
+func (api *PrivateMinerAPI) SetStandby(enabled bool) (bool, error) {
+	if dposEng, ok := api.e.engine.(*dpos.Dpos); ok {
+		if err := api.e.setStandby(dposEng, enabled); err != nil {
+			return false, err
+		}
+	}
+
+	api.e.lock.Lock()
+	api.e.config.MinerStandby = enabled
+	api.e.lock.Unlock()
+	return true, nil
+}
+
 // SetCoinbase sets the coinbase of the miner
 func (api *PrivateMinerAPI) SetCoinbase(coinbase common.Address) bool {
 	api.e.SetCoinbase(coinbase)
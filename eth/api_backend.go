@@ -18,11 +18,13 @@ package eth
 
 import (
 	"context"
+	"errors"
 	"math/big"
 
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/math"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
 	"github.com/DxChainNetwork/godx/core"
 	"github.com/DxChainNetwork/godx/core/bloombits"
 	"github.com/DxChainNetwork/godx/core/state"
@@ -36,6 +38,8 @@ import (
 	"github.com/DxChainNetwork/godx/rpc"
 )
 
+var errNoConfirmedHeader = errors.New("finalized block is only supported by the dpos engine")
+
 // EthAPIBackend implements ethapi.Backend for full nodes
 type EthAPIBackend struct {
 	eth *Ethereum
@@ -66,6 +70,9 @@ func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	if blockNr == rpc.LatestBlockNumber {
 		return b.eth.blockchain.CurrentBlock().Header(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		return b.confirmedHeader()
+	}
 	return b.eth.blockchain.GetHeaderByNumber(uint64(blockNr)), nil
 }
 
@@ -83,9 +90,25 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumb
 	if blockNr == rpc.LatestBlockNumber {
 		return b.eth.blockchain.CurrentBlock(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		header, err := b.confirmedHeader()
+		if err != nil {
+			return nil, err
+		}
+		return b.eth.blockchain.GetBlock(header.Hash(), header.Number.Uint64()), nil
+	}
 	return b.eth.blockchain.GetBlockByNumber(uint64(blockNr)), nil
 }
 
+// confirmedHeader returns the latest irreversible block header tracked by the dpos engine
+func (b *EthAPIBackend) confirmedHeader() (*types.Header, error) {
+	dposEng, ok := b.eth.engine.(*dpos.Dpos)
+	if !ok {
+		return nil, errNoConfirmedHeader
+	}
+	return dposEng.ConfirmedBlockHeader(b.eth.blockchain)
+}
+
 func (b *EthAPIBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
 	// Pending state is only known by the miner
 	if blockNr == rpc.PendingBlockNumber {
@@ -157,6 +180,17 @@ func (b *EthAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 	return b.eth.BlockChain().SubscribeLogsEvent(ch)
 }
 
+// SubscribeConfirmedHeaderEvent subscribes the irreversible block headers confirmed by the dpos engine
+func (b *EthAPIBackend) SubscribeConfirmedHeaderEvent(ch chan<- dpos.ConfirmedHeaderEvent) event.Subscription {
+	if dposEng, ok := b.eth.engine.(*dpos.Dpos); ok {
+		return dposEng.SubscribeConfirmedHeaderEvent(ch)
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
 	return b.eth.txPool.AddLocal(signedTx)
 }
@@ -40,6 +40,7 @@ import (
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/core/vm"
 	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/crypto/ecies"
 	"github.com/DxChainNetwork/godx/eth/downloader"
 	"github.com/DxChainNetwork/godx/eth/filters"
 	"github.com/DxChainNetwork/godx/eth/gasprice"
@@ -95,10 +96,11 @@ type Ethereum struct {
 
 	APIBackend *EthAPIBackend
 
-	miner     *miner.Miner
-	gasPrice  *big.Int
-	validator common.Address
-	coinbase  common.Address
+	miner      *miner.Miner
+	gasPrice   *big.Int
+	validator  common.Address
+	signingKey common.Address
+	coinbase   common.Address
 
 	apisOnce       sync.Once
 	registeredAPIs []rpc.API
@@ -154,6 +156,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		networkID:      config.NetworkId,
 		gasPrice:       config.MinerGasPrice,
 		validator:      config.Validator,
+		signingKey:     config.SigningKey,
 		coinbase:       config.Coinbase,
 		bloomRequests:  make(chan chan *bloombits.Retrieval),
 		bloomIndexer:   NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms),
@@ -198,6 +201,8 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if eth.protocolManager, err = NewProtocolManager(eth, eth.chainConfig, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb, config.Whitelist); err != nil {
 		return nil, err
 	}
+	// the storage protocol is only served by the storage client or storage host node
+	eth.protocolManager.storageOnlyPeers = config.StorageOnlyPeers && (config.StorageClient || config.StorageHost)
 
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine, config.MinerRecommit, config.MinerGasFloor, config.MinerGasCeil, eth.isLocalBlock)
 	eth.miner.SetExtra(makeExtraData(config.MinerExtraData))
@@ -444,6 +449,34 @@ func (s *Ethereum) SetValidator(validator common.Address) {
 	s.lock.Unlock()
 }
 
+// SigningKey returns the address used to sign the blocks. If not specified, the
+// validator address is used
+func (s *Ethereum) SigningKey() (common.Address, error) {
+	s.lock.RLock()
+	signingKey := s.signingKey
+	s.lock.RUnlock()
+
+	if signingKey != (common.Address{}) {
+		return signingKey, nil
+	}
+	return s.Validator()
+}
+
+// SetSigningKey sets given block signing key into full node
+func (s *Ethereum) SetSigningKey(signer common.Address) {
+	s.lock.Lock()
+	account := accounts.Account{Address: signer}
+	_, err := s.AccountManager().Find(account)
+	if err != nil {
+		s.lock.Unlock()
+		log.Error("Can not find this account in local wallet", "address", signer)
+		return
+	}
+
+	s.signingKey = signer
+	s.lock.Unlock()
+}
+
 // Coinbase return the address that will receive block award
 func (s *Ethereum) Coinbase() (eb common.Address, err error) {
 	s.lock.RLock()
@@ -581,12 +614,24 @@ func (s *Ethereum) StartMining(threads int) error {
 			panic("start mining without dpos engine")
 		}
 
-		wallet, err := s.accountManager.Find(accounts.Account{Address: validator})
+		// the block signing key could be separated from the validator account
+		signingKey, err := s.SigningKey()
+		if err != nil {
+			return fmt.Errorf("signing key missing: %v", err)
+		}
+
+		wallet, err := s.accountManager.Find(accounts.Account{Address: signingKey})
 		if wallet == nil || err != nil {
 			return fmt.Errorf("signer missing: %v", err)
 		}
 
-		dposEng.Authorize(validator, wallet.SignHash)
+		dposEng.SetValidator(validator)
+		dposEng.Authorize(signingKey, wallet.SignHash)
+
+		// the standby node refuses to start with the signing key of the primary node
+		if err := s.setStandby(dposEng, s.config.MinerStandby); err != nil {
+			return err
+		}
 
 		// If mining is started, we can disable the transaction rejection mechanism
 		// introduced to speed sync times.
@@ -597,6 +642,38 @@ func (s *Ethereum) StartMining(threads int) error {
 	return nil
 }
 
+// setStandby enables or disables the standby mode of the dpos engine. The standby node
+// signs with its own key, and only takes over the block production once the validator
+// has rotated its signing key to the standby node and the rotation is confirmed
+func (s *Ethereum) setStandby(dposEng *dpos.Dpos, enabled bool) error {
+	return dposEng.SetStandby(enabled, 0, s.blockchain.CurrentHeader())
+}
+
+// observeValidatorBlocks feeds the blocks inserted into the chain, on the canonical chain
+// or on the forks, to the dpos engine, so that the blocks of the local validator signed
+// by the other node are recorded
+func (s *Ethereum) observeValidatorBlocks(dposEng *dpos.Dpos) {
+	chainCh := make(chan core.ChainEvent, chainEventChanSize)
+	chainSub := s.blockchain.SubscribeChainEvent(chainCh)
+	defer chainSub.Unsubscribe()
+	sideCh := make(chan core.ChainSideEvent, chainEventChanSize)
+	sideSub := s.blockchain.SubscribeChainSideEvent(sideCh)
+	defer sideSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-chainCh:
+			dposEng.ObserveBlock(ev.Block.Header())
+		case ev := <-sideCh:
+			dposEng.ObserveBlock(ev.Block.Header())
+		case <-chainSub.Err():
+			return
+		case <-sideSub.Err():
+			return
+		}
+	}
+}
+
 // StopMining terminates the miner, both at the consensus engine level as well as
 // at the block creation level.
 func (s *Ethereum) StopMining() {
@@ -627,11 +704,21 @@ func (s *Ethereum) Downloader() *downloader.Downloader { return s.protocolManage
 func (s *Ethereum) GetCurrentBlockHeight() uint64      { return s.blockchain.CurrentHeader().Number.Uint64() }
 func (s *Ethereum) GetBlockChain() *core.BlockChain    { return s.blockchain }
 
+// GetStorageClient returns the storage client, which is nil if the node is not
+// running as the storage client
+func (s *Ethereum) GetStorageClient() *storageclient.StorageClient { return s.storageClient }
+
 // Sign data with node private key. Now it is used to imply host identity
 func (s *Ethereum) SignWithNodeSk(hash []byte) ([]byte, error) {
 	return crypto.Sign(hash, s.server.Config.PrivateKey)
 }
 
+// DecryptWithNodeSk decrypts the data encrypted with the node public key by ECIES. Now it is
+// used to unwrap the cipher key of the file shared to the node
+func (s *Ethereum) DecryptWithNodeSk(ct []byte) ([]byte, error) {
+	return ecies.ImportECDSA(s.server.Config.PrivateKey).Decrypt(ct, nil, nil)
+}
+
 // Get host enode url from enode object
 func (s *Ethereum) GetHostEnodeURL() string {
 	return s.server.Self().String()
@@ -640,10 +727,15 @@ func (s *Ethereum) GetHostEnodeURL() string {
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
+	protos := append([]p2p.Protocol{}, s.protocolManager.SubProtocols...)
+	// the storage protocol is only served by the storage client or storage host node
+	if s.config.StorageClient || s.config.StorageHost {
+		protos = append(protos, s.protocolManager.StorageSubProtocols...)
+	}
 	if s.lesServer == nil {
-		return s.protocolManager.SubProtocols
+		return protos
 	}
-	return append(s.protocolManager.SubProtocols, s.lesServer.Protocols()...)
+	return append(protos, s.lesServer.Protocols()...)
 }
 
 // Start implements node.Service, starting all internal goroutines needed by the
@@ -666,6 +758,9 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	}
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(maxPeers)
+	if dposEng, ok := s.engine.(*dpos.Dpos); ok {
+		go s.observeValidatorBlocks(dposEng)
+	}
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
@@ -732,7 +827,7 @@ func (s *Ethereum) Stop() error {
 // revising
 func (s *Ethereum) TryToRenewOrRevise(hostID enode.ID) bool {
 	peerID := fmt.Sprintf("%x", hostID.Bytes()[:8])
-	peer := s.protocolManager.peers.Peer(peerID)
+	peer := s.protocolManager.storagePeers.Peer(peerID)
 	// if the peer does not exist, meaning currently not revising
 	if peer == nil {
 		return false
@@ -745,7 +840,7 @@ func (s *Ethereum) TryToRenewOrRevise(hostID enode.ID) bool {
 // RevisionOrRenewingDone indicates the renew finished
 func (s *Ethereum) RevisionOrRenewingDone(hostID enode.ID) {
 	peerID := fmt.Sprintf("%x", hostID.Bytes()[:8])
-	peer := s.protocolManager.peers.Peer(peerID)
+	peer := s.protocolManager.storagePeers.Peer(peerID)
 	if peer == nil {
 		return
 	}
@@ -768,7 +863,7 @@ func (s *Ethereum) SetupConnection(enodeURL string) (storagePeer storage.Peer, e
 	peerID := fmt.Sprintf("%x", destNode.ID().Bytes()[:8])
 
 	// check if the peer is already existed
-	peer := s.protocolManager.peers.Peer(peerID)
+	peer := s.protocolManager.storagePeers.Peer(peerID)
 	if peer != nil {
 		// if the connection already existed, convert the connection
 		// to the static connection
@@ -782,7 +877,7 @@ func (s *Ethereum) SetupConnection(enodeURL string) (storagePeer storage.Peer, e
 	s.server.AddPeer(destNode)
 	timeout := time.After(1 * time.Minute)
 	for {
-		peer = s.protocolManager.peers.Peer(peerID)
+		peer = s.protocolManager.storagePeers.Peer(peerID)
 		if peer != nil {
 			// check if the connection is static connection
 			// if not, set the connection to static connection
@@ -110,6 +110,7 @@ type Config struct {
 
 	// Mining-related options
 	Validator      common.Address `toml:",omitempty"`
+	SigningKey     common.Address `toml:",omitempty"`
 	Coinbase       common.Address `toml:",omitempty"`
 	MinerNotify    []string       `toml:",omitempty"`
 	MinerExtraData []byte         `toml:",omitempty"`
@@ -118,6 +119,7 @@ type Config struct {
 	MinerGasPrice  *big.Int
 	MinerRecommit  time.Duration
 	MinerNoverify  bool
+	MinerStandby   bool
 	Dpos           bool
 
 	// Ethash options
@@ -150,6 +152,10 @@ type Config struct {
 	// Role, can only be one of the two roles
 	StorageClient bool
 	StorageHost   bool
+
+	// StorageOnlyPeers keeps the peers supporting the storage protocol connected when
+	// they are rejected or dropped by the eth protocol
+	StorageOnlyPeers bool
 }
 
 type configMarshaling struct {
@@ -14,16 +14,25 @@ import (
 )
 
 var hostHandlers = map[uint64]func(h *storagehost.StorageHost, sp storage.Peer, msg p2p.Msg){
-	storage.ContractCreateReqMsg:   storagehost.ContractCreateHandler,
-	storage.ContractUploadReqMsg:   storagehost.UploadHandler,
-	storage.ContractDownloadReqMsg: storagehost.DownloadHandler,
+	storage.ContractCreateReqMsg:         storagehost.ContractCreateHandler,
+	storage.ContractUploadReqMsg:         storagehost.UploadHandler,
+	storage.ContractDownloadReqMsg:       storagehost.DownloadHandler,
+	storage.ContractDownloadBatchReqMsg:  storagehost.DownloadBatchHandler,
+	storage.EphemeralAccountFundReqMsg:   storagehost.EphemeralAccountFundHandler,
+	storage.EphemeralAccountRefundReqMsg: storagehost.EphemeralAccountRefundHandler,
+	storage.EphemeralDownloadReqMsg:      storagehost.EphemeralDownloadHandler,
+	storage.EphemeralHostConfigReqMsg:    storagehost.EphemeralHostConfigHandler,
 }
 
-func (pm *ProtocolManager) msgDispatch(msg p2p.Msg, p *peer) error {
+func (pm *ProtocolManager) msgDispatch(msg p2p.Msg, p *storagePeer) error {
 	switch {
+	case msg.Code == StorageStatusMsg:
+		// status messages should never arrive after the handshake
+		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
+
 	case msg.Code < 0x20:
-		// ethMsgSchedule
-		return pm.ethMsgSchedule(msg, p)
+		// message code reserved by the storage protocol
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 
 	case msg.Code < 0x30:
 		// clientMsgSchedule
@@ -39,22 +48,7 @@ func (pm *ProtocolManager) msgDispatch(msg p2p.Msg, p *peer) error {
 	}
 }
 
-func (pm *ProtocolManager) ethMsgSchedule(msg p2p.Msg, p *peer) error {
-	// insert the message into eth buffer
-	p.InsertEthMsgBuffer(msg)
-	select {
-	// send the start signal, indicating there
-	// is a new message added into the buffer
-	case p.ethStartIndicator <- struct{}{}:
-		return nil
-	default:
-		// if blocked, indicating that the ethMsgHandler is started already
-		// the messages just inserted will be handled eventually
-		return nil
-	}
-}
-
-func (pm *ProtocolManager) clientMsgSchedule(msg p2p.Msg, p *peer) error {
+func (pm *ProtocolManager) clientMsgSchedule(msg p2p.Msg, p *storagePeer) error {
 	// if the message is hostConfigRespMsg, try to push it to the channel
 	// if failed, discard the message right away, meaning the last config
 	// message handling is not finished yet
@@ -67,6 +61,17 @@ func (pm *ProtocolManager) clientMsgSchedule(msg p2p.Msg, p *peer) error {
 		}
 	}
 
+	// batched download data are streamed by the host one after another, apply
+	// back pressure until the client consumed the previous one
+	if msg.Code == storage.ContractDownloadBatchDataMsg {
+		select {
+		case p.clientContractMsg <- msg:
+			return nil
+		case <-p.StopChan():
+			return msg.Discard()
+		}
+	}
+
 	// otherwise, push the message into clientContractMsg channel
 	// similarly, if the channel is full, meaning the previous message
 	// handling was not complete, trigger the error directly because the
@@ -81,7 +86,19 @@ func (pm *ProtocolManager) clientMsgSchedule(msg p2p.Msg, p *peer) error {
 	}
 }
 
-func (pm *ProtocolManager) hostMsgSchedule(msg p2p.Msg, p *peer) error {
+func (pm *ProtocolManager) hostMsgSchedule(msg p2p.Msg, p *storagePeer) error {
+	// the node is not running as a storage host, the request cannot be handled
+	if pm.eth.storageHost == nil {
+		return errResp(ErrInvalidMsgCode, "%v: storage host is not enabled", msg.Code)
+	}
+
+	// throttle the reads from the client according to the host bandwidth limits,
+	// the next message is not read until the bandwidth allows
+	if err := pm.eth.storageHost.WaitReadBandwidth(p, msg.Size); err != nil {
+		_ = msg.Discard()
+		return err
+	}
+
 	// check if the message code is HostConfigReqMsg, which needs to be handled
 	// explicitly
 	if msg.Code == storage.HostConfigReqMsg {
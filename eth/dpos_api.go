@@ -23,10 +23,12 @@ type PublicDposAPI struct {
 
 // CandidateInfo stores detailed candidate information
 type CandidateInfo struct {
-	Candidate   common.Address `json:"candidate"`
-	Deposit     common.BigInt  `json:"deposit"`
-	Votes       common.BigInt  `json:"votes"`
-	RewardRatio uint64         `json:"reward_distribution"`
+	Candidate   common.Address          `json:"candidate"`
+	Deposit     common.BigInt           `json:"deposit"`
+	Votes       common.BigInt           `json:"votes"`
+	RewardRatio uint64                  `json:"reward_distribution"`
+	SigningKey  common.Address          `json:"signing_key"`
+	Metadata    types.CandidateMetadata `json:"metadata"`
 }
 
 // ValidatorInfo stores detailed validator information
@@ -134,6 +136,8 @@ func (d *PublicDposAPI) Candidate(candidateAddress common.Address, blockNr *rpc.
 		Deposit:     candidateDeposit,
 		Votes:       candidateVotes,
 		RewardRatio: rewardRatio,
+		SigningKey:  dpos.GetSigningKey(statedb, candidateAddress),
+		Metadata:    dpos.GetCandidateMetadata(statedb, candidateAddress),
 	}, nil
 
 }
@@ -184,6 +188,12 @@ func getHeaderBasedOnNumber(blockNr *rpc.BlockNumber, e *Ethereum) (*types.Heade
 	var header *types.Header
 	if blockNr == nil {
 		header = e.BlockChain().CurrentHeader()
+	} else if *blockNr == rpc.FinalizedBlockNumber {
+		dposEng, ok := e.engine.(*dpos.Dpos)
+		if !ok {
+			return nil, errNoConfirmedHeader
+		}
+		return dposEng.ConfirmedBlockHeader(e.BlockChain())
 	} else {
 		header = e.BlockChain().GetHeaderByNumber(uint64(blockNr.Int64()))
 	}
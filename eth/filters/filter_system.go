@@ -22,11 +22,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
 	ethereum "github.com/DxChainNetwork/godx"
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
 	"github.com/DxChainNetwork/godx/core"
 	"github.com/DxChainNetwork/godx/core/rawdb"
 	"github.com/DxChainNetwork/godx/core/types"
@@ -53,6 +55,8 @@ const (
 	PendingTransactionsSubscription
 	// BlocksSubscription queries hashes for blocks that are imported
 	BlocksSubscription
+	// FinalizedBlocksSubscription queries headers for blocks that become irreversible
+	FinalizedBlocksSubscription
 	// LastSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -68,6 +72,8 @@ const (
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+	// confirmedEvChanSize is the size of channel listening to ConfirmedHeaderEvent.
+	confirmedEvChanSize = 10
 )
 
 var (
@@ -99,15 +105,17 @@ type EventSystem struct {
 	logsSub       event.Subscription         // Subscription for new log event
 	rmLogsSub     event.Subscription         // Subscription for removed log event
 	chainSub      event.Subscription         // Subscription for new chain event
+	confirmedSub  event.Subscription         // Subscription for confirmed header event
 	pendingLogSub *event.TypeMuxSubscription // Subscription for pending log event
 
 	// Channels
-	install   chan *subscription         // install filter for event notification
-	uninstall chan *subscription         // remove filter for event notification
-	txsCh     chan core.NewTxsEvent      // Channel to receive new transactions event
-	logsCh    chan []*types.Log          // Channel to receive new log event
-	rmLogsCh  chan core.RemovedLogsEvent // Channel to receive removed log event
-	chainCh   chan core.ChainEvent       // Channel to receive new chain event
+	install     chan *subscription             // install filter for event notification
+	uninstall   chan *subscription             // remove filter for event notification
+	txsCh       chan core.NewTxsEvent          // Channel to receive new transactions event
+	logsCh      chan []*types.Log              // Channel to receive new log event
+	rmLogsCh    chan core.RemovedLogsEvent     // Channel to receive removed log event
+	chainCh     chan core.ChainEvent           // Channel to receive new chain event
+	confirmedCh chan dpos.ConfirmedHeaderEvent // Channel to receive confirmed header event
 }
 
 // NewEventSystem creates a new manager that listens for event on the given mux,
@@ -118,15 +126,16 @@ type EventSystem struct {
 // or by stopping the given mux.
 func NewEventSystem(mux *event.TypeMux, backend Backend, lightMode bool) *EventSystem {
 	m := &EventSystem{
-		mux:       mux,
-		backend:   backend,
-		lightMode: lightMode,
-		install:   make(chan *subscription),
-		uninstall: make(chan *subscription),
-		txsCh:     make(chan core.NewTxsEvent, txChanSize),
-		logsCh:    make(chan []*types.Log, logsChanSize),
-		rmLogsCh:  make(chan core.RemovedLogsEvent, rmLogsChanSize),
-		chainCh:   make(chan core.ChainEvent, chainEvChanSize),
+		mux:         mux,
+		backend:     backend,
+		lightMode:   lightMode,
+		install:     make(chan *subscription),
+		uninstall:   make(chan *subscription),
+		txsCh:       make(chan core.NewTxsEvent, txChanSize),
+		logsCh:      make(chan []*types.Log, logsChanSize),
+		rmLogsCh:    make(chan core.RemovedLogsEvent, rmLogsChanSize),
+		chainCh:     make(chan core.ChainEvent, chainEvChanSize),
+		confirmedCh: make(chan dpos.ConfirmedHeaderEvent, confirmedEvChanSize),
 	}
 
 	// Subscribe events
@@ -134,12 +143,13 @@ func NewEventSystem(mux *event.TypeMux, backend Backend, lightMode bool) *EventS
 	m.logsSub = m.backend.SubscribeLogsEvent(m.logsCh)
 	m.rmLogsSub = m.backend.SubscribeRemovedLogsEvent(m.rmLogsCh)
 	m.chainSub = m.backend.SubscribeChainEvent(m.chainCh)
+	m.confirmedSub = m.backend.SubscribeConfirmedHeaderEvent(m.confirmedCh)
 	// TODO(rjl493456442): use feed to subscribe pending log event
 	m.pendingLogSub = m.mux.Subscribe(core.PendingLogsEvent{})
 
 	// Make sure none of the subscriptions are empty
 	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil ||
-		m.pendingLogSub.Closed() {
+		m.confirmedSub == nil || m.pendingLogSub.Closed() {
 		log.Crit("Subscribe for event system failed")
 	}
 
@@ -194,8 +204,14 @@ func (es *EventSystem) subscribe(sub *subscription) *Subscription {
 
 // SubscribeLogs creates a subscription that will write all logs matching the
 // given criteria to the given logs channel. Default value for the from and to
-// block is "latest". If the fromBlock > toBlock an error is returned.
+// block is "latest". The "finalized" block is resolved into the latest irreversible
+// block number. If the fromBlock > toBlock an error is returned.
 func (es *EventSystem) SubscribeLogs(crit ethereum.FilterQuery, logs chan []*types.Log) (*Subscription, error) {
+	crit, err := es.resolveFinalizedBlock(crit)
+	if err != nil {
+		return nil, err
+	}
+
 	var from, to rpc.BlockNumber
 	if crit.FromBlock == nil {
 		from = rpc.LatestBlockNumber
@@ -231,6 +247,31 @@ func (es *EventSystem) SubscribeLogs(crit ethereum.FilterQuery, logs chan []*typ
 	return nil, fmt.Errorf("invalid from and to block combination: from > to")
 }
 
+// resolveFinalizedBlock replaces the finalized block tag in the criteria with the
+// number of the latest irreversible block
+func (es *EventSystem) resolveFinalizedBlock(crit ethereum.FilterQuery) (ethereum.FilterQuery, error) {
+	isFinalized := func(num *big.Int) bool {
+		return num != nil && num.Int64() == rpc.FinalizedBlockNumber.Int64()
+	}
+	if !isFinalized(crit.FromBlock) && !isFinalized(crit.ToBlock) {
+		return crit, nil
+	}
+	header, err := es.backend.HeaderByNumber(context.Background(), rpc.FinalizedBlockNumber)
+	if err != nil {
+		return crit, err
+	}
+	if header == nil {
+		return crit, errors.New("finalized block not found")
+	}
+	if isFinalized(crit.FromBlock) {
+		crit.FromBlock = new(big.Int).Set(header.Number)
+	}
+	if isFinalized(crit.ToBlock) {
+		crit.ToBlock = new(big.Int).Set(header.Number)
+	}
+	return crit, nil
+}
+
 // subscribeMinedPendingLogs creates a subscription that returned mined and
 // pending logs that match the given criteria.
 func (es *EventSystem) subscribeMinedPendingLogs(crit ethereum.FilterQuery, logs chan []*types.Log) *Subscription {
@@ -298,6 +339,22 @@ func (es *EventSystem) SubscribeNewHeads(headers chan *types.Header) *Subscripti
 	return es.subscribe(sub)
 }
 
+// SubscribeFinalizedHeads creates a subscription that writes the header of a block that
+// becomes irreversible
+func (es *EventSystem) SubscribeFinalizedHeads(headers chan *types.Header) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       FinalizedBlocksSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan []common.Hash),
+		headers:   headers,
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
 // SubscribePendingTxs creates a subscription that writes transaction hashes for
 // transactions that enter the transaction pool.
 func (es *EventSystem) SubscribePendingTxs(hashes chan []common.Hash) *Subscription {
@@ -355,6 +412,10 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 		for _, f := range filters[PendingTransactionsSubscription] {
 			f.hashes <- hashes
 		}
+	case dpos.ConfirmedHeaderEvent:
+		for _, f := range filters[FinalizedBlocksSubscription] {
+			f.headers <- e.Header
+		}
 	case core.ChainEvent:
 		for _, f := range filters[BlocksSubscription] {
 			f.headers <- e.Block.Header()
@@ -453,6 +514,7 @@ func (es *EventSystem) eventLoop() {
 		es.logsSub.Unsubscribe()
 		es.rmLogsSub.Unsubscribe()
 		es.chainSub.Unsubscribe()
+		es.confirmedSub.Unsubscribe()
 	}()
 
 	index := make(filterIndex)
@@ -471,6 +533,8 @@ func (es *EventSystem) eventLoop() {
 			es.broadcast(index, ev)
 		case ev := <-es.chainCh:
 			es.broadcast(index, ev)
+		case ev := <-es.confirmedCh:
+			es.broadcast(index, ev)
 		case ev, active := <-es.pendingLogSub.Chan():
 			if !active { // system stopped
 				return
@@ -506,6 +570,8 @@ func (es *EventSystem) eventLoop() {
 			return
 		case <-es.chainSub.Err():
 			return
+		case <-es.confirmedSub.Err():
+			return
 		}
 	}
 }
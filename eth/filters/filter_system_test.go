@@ -11,6 +11,7 @@ import (
 
 	ethereum "github.com/DxChainNetwork/godx"
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
 	"github.com/DxChainNetwork/godx/consensus/ethash"
 	"github.com/DxChainNetwork/godx/core"
 	"github.com/DxChainNetwork/godx/core/bloombits"
@@ -30,6 +31,8 @@ type testBackend struct {
 	rmLogsFeed *event.Feed
 	logsFeed   *event.Feed
 	chainFeed  *event.Feed
+
+	confirmedFeed *event.Feed
 }
 
 func (b *testBackend) ChainDb() ethdb.Database {
@@ -52,6 +55,9 @@ func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumbe
 			return nil, nil
 		}
 		num = *number
+	} else if blockNr == rpc.FinalizedBlockNumber {
+		// only the genesis block is irreversible in the test chain
+		hash = rawdb.ReadCanonicalHash(b.db, 0)
 	} else {
 		num = uint64(blockNr)
 		hash = rawdb.ReadCanonicalHash(b.db, num)
@@ -104,6 +110,10 @@ func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subsc
 	return b.chainFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeConfirmedHeaderEvent(ch chan<- dpos.ConfirmedHeaderEvent) event.Subscription {
+	return b.confirmedFeed.Subscribe(ch)
+}
+
 func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
@@ -150,7 +160,7 @@ func TestBlockSubscription(t *testing.T) {
 		rmLogsFeed  = new(event.Feed)
 		logsFeed    = new(event.Feed)
 		chainFeed   = new(event.Feed)
-		backend     = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend     = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api         = NewPublicFilterAPI(backend, false)
 		genesis     = core.DefaultGenesisBlock().MustCommit(db)
 		chain, _    = core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {})
@@ -196,6 +206,55 @@ func TestBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
+// TestFinalizedBlockSubscription tests if a finalized heads subscription receives
+// all the headers confirmed by the dpos engine
+func TestFinalizedBlockSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux           = new(event.TypeMux)
+		db            = ethdb.NewMemDatabase()
+		txFeed        = new(event.Feed)
+		rmLogsFeed    = new(event.Feed)
+		logsFeed      = new(event.Feed)
+		chainFeed     = new(event.Feed)
+		confirmedFeed = new(event.Feed)
+		backend       = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, confirmedFeed}
+		api           = NewPublicFilterAPI(backend, false)
+		genesis       = core.DefaultGenesisBlock().MustCommit(db)
+		chain, _      = core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {})
+	)
+
+	headers := make(chan *types.Header)
+	sub := api.events.SubscribeFinalizedHeads(headers)
+	newHeads := make(chan *types.Header)
+	newHeadsSub := api.events.SubscribeNewHeads(newHeads)
+
+	go func() { // simulate client
+		for i := 0; i != len(chain); {
+			select {
+			case header := <-headers:
+				if chain[i].Hash() != header.Hash() {
+					t.Errorf("received invalid hash on index %d, want %x, got %x", i, chain[i].Hash(), header.Hash())
+				}
+				i++
+			case header := <-newHeads:
+				t.Errorf("new heads subscription received confirmed header %x", header.Hash())
+			}
+		}
+		sub.Unsubscribe()
+		newHeadsSub.Unsubscribe()
+	}()
+
+	time.Sleep(1 * time.Second)
+	for _, blk := range chain {
+		confirmedFeed.Send(dpos.ConfirmedHeaderEvent{Header: blk.Header()})
+	}
+
+	<-sub.Err()
+	<-newHeadsSub.Err()
+}
+
 // TestPendingTxFilter tests whether pending tx filters retrieve all pending transactions that are posted to the event mux.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
@@ -207,7 +266,7 @@ func TestPendingTxFilter(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		transactions = []*types.Transaction{
@@ -267,7 +326,7 @@ func TestLogFilterCreation(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		testCases = []struct {
@@ -290,9 +349,20 @@ func TestLogFilterCreation(t *testing.T) {
 			{FilterCriteria{FromBlock: big.NewInt(rpc.PendingBlockNumber.Int64()), ToBlock: big.NewInt(100)}, false},
 			// from block "higher" than to block
 			{FilterCriteria{FromBlock: big.NewInt(rpc.PendingBlockNumber.Int64()), ToBlock: big.NewInt(rpc.LatestBlockNumber.Int64())}, false},
+			// finalized block to new mined blocks
+			{FilterCriteria{FromBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64()), ToBlock: big.NewInt(rpc.LatestBlockNumber.Int64())}, true},
+			// finalized block to pending blocks
+			{FilterCriteria{FromBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64()), ToBlock: big.NewInt(rpc.PendingBlockNumber.Int64())}, true},
+			// block range to finalized block
+			{FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64())}, true},
+			// from block "higher" than finalized block
+			{FilterCriteria{FromBlock: big.NewInt(1), ToBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64())}, false},
 		}
 	)
 
+	// commit the genesis block as the finalized block
+	core.DefaultGenesisBlock().MustCommit(db)
+
 	for i, test := range testCases {
 		_, err := api.NewFilter(test.crit)
 		if test.success && err != nil {
@@ -316,7 +386,7 @@ func TestInvalidLogFilterCreation(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 	)
 
@@ -343,7 +413,7 @@ func TestInvalidGetLogsRequest(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 		blockHash  = common.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111")
 	)
@@ -373,7 +443,7 @@ func TestLogFilter(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -492,7 +562,7 @@ func TestPendingLogsSubscription(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -50,6 +50,9 @@ const (
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
 
+	// chainEventChanSize is the size of channel listening to ChainEvent and ChainSideEvent.
+	chainEventChanSize = 10
+
 	// minimim number of peers to broadcast new blocks to
 	minBroadcastPeers = 4
 )
@@ -83,6 +86,10 @@ type ProtocolManager struct {
 	peers        *peerSet
 	SubProtocols []p2p.Protocol
 
+	storagePeers        *storagePeerSet
+	StorageSubProtocols []p2p.Protocol
+	storageOnlyPeers    bool // Flag whether the peers running the storage protocol are kept without the eth protocol
+
 	eventMux      *event.TypeMux
 	txsCh         chan core.NewTxsEvent
 	txsSub        event.Subscription
@@ -106,18 +113,19 @@ type ProtocolManager struct {
 func NewProtocolManager(eth *Ethereum, config *params.ChainConfig, mode downloader.SyncMode, networkID uint64, mux *event.TypeMux, txpool txPool, engine consensus.Engine, blockchain *core.BlockChain, chaindb ethdb.Database, whitelist map[uint64]common.Hash) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
-		eth:         eth,
-		networkID:   networkID,
-		eventMux:    mux,
-		txpool:      txpool,
-		blockchain:  blockchain,
-		chainconfig: config,
-		peers:       newPeerSet(),
-		whitelist:   whitelist,
-		newPeerCh:   make(chan *peer),
-		noMorePeers: make(chan struct{}),
-		txsyncCh:    make(chan *txsync),
-		quitSync:    make(chan struct{}),
+		eth:          eth,
+		networkID:    networkID,
+		eventMux:     mux,
+		txpool:       txpool,
+		blockchain:   blockchain,
+		chainconfig:  config,
+		peers:        newPeerSet(),
+		storagePeers: newStoragePeerSet(),
+		whitelist:    whitelist,
+		newPeerCh:    make(chan *peer),
+		noMorePeers:  make(chan struct{}),
+		txsyncCh:     make(chan *txsync),
+		quitSync:     make(chan struct{}),
 	}
 	// Figure out whether to allow fast sync or not
 	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
@@ -146,7 +154,7 @@ func NewProtocolManager(eth *Ethereum, config *params.ChainConfig, mode download
 				case manager.newPeerCh <- peer:
 					manager.wg.Add(1)
 					defer manager.wg.Done()
-					return manager.handle(peer)
+					return manager.endEthProtocol(peer, manager.handle(peer))
 				case <-manager.quitSync:
 					return p2p.DiscQuitting
 				}
@@ -165,6 +173,26 @@ func NewProtocolManager(eth *Ethereum, config *params.ChainConfig, mode download
 	if len(manager.SubProtocols) == 0 {
 		return nil, errIncompatibleConfig
 	}
+	// Initiate the storage sub-protocol, which runs independently from the eth sub-protocol
+	manager.StorageSubProtocols = make([]p2p.Protocol, 0, len(StorageProtocolVersions))
+	for i, version := range StorageProtocolVersions {
+		version := version // Closure for the run
+		manager.StorageSubProtocols = append(manager.StorageSubProtocols, p2p.Protocol{
+			Name:    p2p.StorageProtocol,
+			Version: version,
+			Length:  StorageProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				select {
+				case <-manager.quitSync:
+					return p2p.DiscQuitting
+				default:
+				}
+				manager.wg.Add(1)
+				defer manager.wg.Done()
+				return manager.handleStorage(newStoragePeer(int(version), p, rw))
+			},
+		})
+	}
 	// Construct the different synchronisation mechanisms
 	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
 
@@ -201,12 +229,51 @@ func (pm *ProtocolManager) removePeer(id string) {
 	if err := pm.peers.Unregister(id); err != nil {
 		log.Error("Peer removal failed", "peer", id, "err", err)
 	}
-	// Hard disconnect at the networking layer
-	if peer != nil {
+	// Hard disconnect at the networking layer, unless the connection is kept for the
+	// storage protocol. The handler then ends the eth protocol of the removed peer
+	if peer != nil && !pm.keepStoragePeer(peer) {
 		peer.Peer.Disconnect(p2p.DiscUselessPeer)
 	}
 }
 
+// keepStoragePeer checks whether the connection of the peer is kept for the storage protocol
+// when the eth protocol fails, which is the case if the storage protocol is running over the
+// connection, or the storage-only peers are enabled and the peer supports the storage protocol
+func (pm *ProtocolManager) keepStoragePeer(p *peer) bool {
+	if pm.storagePeers.Peer(p.id) != nil {
+		return true
+	}
+	if !pm.storageOnlyPeers {
+		return false
+	}
+	for _, c := range p.Caps() {
+		if c.Name == p2p.StorageProtocol {
+			return true
+		}
+	}
+	return false
+}
+
+// endEthProtocol ends the eth protocol of the peer with the error returned by the handler.
+// Returning from the protocol tears down the whole devp2p connection, thus if the connection
+// is kept for the storage protocol, the eth messages are discarded until the connection is
+// torn down by the storage protocol or the remote peer
+func (pm *ProtocolManager) endEthProtocol(p *peer, err error) error {
+	if !pm.keepStoragePeer(p) {
+		return err
+	}
+	p.Log().Debug("Ethereum protocol ended, keeping the peer for storage", "err", err)
+	for {
+		msg, err := p.rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if err = msg.Discard(); err != nil {
+			return err
+		}
+	}
+}
+
 func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.maxPeers = maxPeers
 
@@ -242,6 +309,7 @@ func (pm *ProtocolManager) Stop() {
 	// sessions which are already established but not added to pm.peers yet
 	// will exit when they try to register.
 	pm.peers.Close()
+	pm.storagePeers.Close()
 
 	// Wait for all peer handler goroutines and the loops to come down.
 	pm.wg.Wait()
@@ -317,48 +385,27 @@ func (pm *ProtocolManager) handle(p *peer) error {
 			return err
 		}
 	}
-	// start the eth handler
-	go func() {
-		pm.wg.Add(1)
-		defer pm.wg.Done()
-		pm.ethMsgHandler(p)
-	}()
-
 	// Handle incoming messages until the connection is torn down
 	for {
-		// keep reading the message util an error occur
 		msg, err := p.rw.ReadMsg()
 		if err != nil {
 			return err
 		}
-		if msg.Size > ProtocolMaxMsgSize {
-			return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
-		}
-
-		// check for error
+		// the peer removed without disconnecting is not handled anymore
 		select {
-		case err := <-p.errMsg:
-			return err
+		case <-p.term:
+			msg.Discard()
+			return errNotRegistered
 		default:
-			// if there are no errors, continue with the message
-			// dispatcher
 		}
-
-		// distribute the message to different handler
-		if err := pm.msgDispatch(msg, p); err != nil {
-			return err
+		if msg.Size > ProtocolMaxMsgSize {
+			return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
 		}
-
-		// check for error
-		select {
-		case err := <-p.errMsg:
+		if err := pm.handleEthMsg(p, msg); err != nil {
+			p.Log().Debug("Ethereum message handling failed", "err", err)
 			return err
-		default:
-			// if there are no errors, continue with the message
-			// dispatcher
 		}
 	}
-
 }
 
 // handleMsg is invoked whenever an inbound message is received from a remote
@@ -27,7 +27,6 @@ import (
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/p2p"
 	"github.com/DxChainNetwork/godx/rlp"
-	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
 	mapset "github.com/deckarep/golang-set"
 )
 
@@ -92,76 +91,23 @@ type peer struct {
 	queuedProps chan *propEvent           // Queue of blocks to broadcast to the peer
 	queuedAnns  chan *types.Block         // Queue of blocks to announce to the peer
 	term        chan struct{}             // Termination channel to stop the broadcaster
-
-	// eth and storage message channel
-	clientConfigMsg   chan p2p.Msg
-	clientContractMsg chan p2p.Msg
-	hostContractMsg   chan p2p.Msg
-
-	ethMsgBuffer      []p2p.Msg
-	ethStartIndicator chan struct{}
-	bufferLock        sync.RWMutex
-
-	hostConfigProcessing   chan struct{}
-	hostContractProcessing chan struct{}
-
-	contractRevisingOrRenewing chan struct{}
-	hostConfigRequesting       chan struct{}
-
-	// error channel
-	errMsg chan error
-
-	checkPeerStopHook func(*peer) error
 }
 
 func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 	return &peer{
-		Peer:                       p,
-		rw:                         rw,
-		version:                    version,
-		id:                         fmt.Sprintf("%x", p.ID().Bytes()[:8]),
-		knownTxs:                   mapset.NewSet(),
-		knownBlocks:                mapset.NewSet(),
-		queuedTxs:                  make(chan []*types.Transaction, maxQueuedTxs),
-		queuedProps:                make(chan *propEvent, maxQueuedProps),
-		queuedAnns:                 make(chan *types.Block, maxQueuedAnns),
-		term:                       make(chan struct{}),
-		clientConfigMsg:            make(chan p2p.Msg, 1),
-		clientContractMsg:          make(chan p2p.Msg, 1),
-		hostContractMsg:            make(chan p2p.Msg, 1),
-		ethStartIndicator:          make(chan struct{}, 1),
-		hostConfigProcessing:       make(chan struct{}, 1),
-		hostContractProcessing:     make(chan struct{}, 1),
-		errMsg:                     make(chan error, 1),
-		contractRevisingOrRenewing: make(chan struct{}, 1),
-		hostConfigRequesting:       make(chan struct{}, 1),
-		checkPeerStopHook:          checkPeerStop,
+		Peer:        p,
+		rw:          rw,
+		version:     version,
+		id:          fmt.Sprintf("%x", p.ID().Bytes()[:8]),
+		knownTxs:    mapset.NewSet(),
+		knownBlocks: mapset.NewSet(),
+		queuedTxs:   make(chan []*types.Transaction, maxQueuedTxs),
+		queuedProps: make(chan *propEvent, maxQueuedProps),
+		queuedAnns:  make(chan *types.Block, maxQueuedAnns),
+		term:        make(chan struct{}),
 	}
 }
 
-// InsertEthMsgBuffer will insert the ethereum p2p message into the
-// buffer list
-func (p *peer) InsertEthMsgBuffer(msg p2p.Msg) {
-	p.bufferLock.Lock()
-	defer p.bufferLock.Unlock()
-	p.ethMsgBuffer = append(p.ethMsgBuffer, msg)
-}
-
-// GetEthMsgBuffer will return the entire ethereum message buffer
-func (p *peer) GetEthMsgBuffer() []p2p.Msg {
-	p.bufferLock.RLock()
-	defer p.bufferLock.RUnlock()
-	return p.ethMsgBuffer
-}
-
-// UpdateEthMsgBuffer will update the ethereum message buffer by
-// removing the first item from the buffer list
-func (p *peer) UpdateEthMsgBuffer() {
-	p.bufferLock.Lock()
-	defer p.bufferLock.Unlock()
-	p.ethMsgBuffer = p.ethMsgBuffer[1:]
-}
-
 // broadcast is a write loop that multiplexes block propagations, announcements
 // and transaction broadcasts into the remote peer. The goal is to have an async
 // writer that does not lock up node internals.
@@ -575,12 +521,3 @@ func (ps *peerSet) Close() {
 	}
 	ps.closed = true
 }
-
-func checkPeerStop(p *peer) error {
-	select {
-	case <-p.StopChan():
-		return coinchargemaintenance.ErrProgramExit
-	default:
-		return nil
-	}
-}
@@ -8,7 +8,66 @@ import (
 	"github.com/DxChainNetwork/godx/storage/storagehost"
 )
 
-func (pm *ProtocolManager) hostConfigMsgHandler(p *peer, configMsg p2p.Msg) error {
+// handleStorage is the callback invoked to manage the life cycle of a storage peer.
+// When this function terminates, the storage peer is disconnected
+func (pm *ProtocolManager) handleStorage(p *storagePeer) error {
+	p.Log().Debug("Storage peer connected", "name", p.Name())
+
+	// Execute the storage handshake
+	if err := p.Handshake(pm.networkID, pm.blockchain.Genesis().Hash()); err != nil {
+		p.Log().Debug("Storage handshake failed", "err", err)
+		return err
+	}
+
+	// Register the storage peer locally
+	if err := pm.storagePeers.Register(p); err != nil {
+		p.Log().Error("Storage peer registration failed", "err", err)
+		return err
+	}
+	defer pm.removeStoragePeer(p.id)
+
+	// Handle incoming messages until the connection is torn down
+	for {
+		// keep reading the message util an error occur
+		msg, err := p.rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Size > StorageProtocolMaxMsgSize {
+			return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, StorageProtocolMaxMsgSize)
+		}
+
+		// check for error
+		select {
+		case err := <-p.errMsg:
+			return err
+		default:
+			// if there are no errors, continue with the message
+			// dispatcher
+		}
+
+		// distribute the message to different handler
+		if err := pm.msgDispatch(msg, p); err != nil {
+			return err
+		}
+
+		// check for error
+		select {
+		case err := <-p.errMsg:
+			return err
+		default:
+		}
+	}
+}
+
+func (pm *ProtocolManager) removeStoragePeer(id string) {
+	log.Debug("Removing storage peer", "peer", id)
+	if err := pm.storagePeers.Unregister(id); err != nil {
+		log.Error("Storage peer removal failed", "peer", id, "err", err)
+	}
+}
+
+func (pm *ProtocolManager) hostConfigMsgHandler(p *storagePeer, configMsg p2p.Msg) error {
 	// avoid multiple host config request calls attack
 	// generate too many go routines and used all resources
 	if err := p.HostConfigProcessing(); err != nil {
@@ -17,8 +76,8 @@ func (pm *ProtocolManager) hostConfigMsgHandler(p *peer, configMsg p2p.Msg) erro
 
 	// start the go routine, handle the host config request
 	// once done, release the channel
+	pm.wg.Add(1)
 	go func() {
-		pm.wg.Add(1)
 		defer pm.wg.Done()
 		defer p.HostConfigProcessingDone()
 		config := pm.eth.storageHost.RetrieveExternalConfig()
@@ -30,7 +89,7 @@ func (pm *ProtocolManager) hostConfigMsgHandler(p *peer, configMsg p2p.Msg) erro
 	return nil
 }
 
-func (pm *ProtocolManager) contractMsgHandler(p *peer, msg p2p.Msg) error {
+func (pm *ProtocolManager) contractMsgHandler(p *storagePeer, msg p2p.Msg) error {
 	// send the message to the hostContractMsg channel if the handler
 	// does not exist
 	select {
@@ -43,7 +102,7 @@ func (pm *ProtocolManager) contractMsgHandler(p *peer, msg p2p.Msg) error {
 	return nil
 }
 
-func (pm *ProtocolManager) contractReqHandler(handler func(h *storagehost.StorageHost, sp storage.Peer, msg p2p.Msg), p *peer, msg p2p.Msg) error {
+func (pm *ProtocolManager) contractReqHandler(handler func(h *storagehost.StorageHost, sp storage.Peer, msg p2p.Msg), p *storagePeer, msg p2p.Msg) error {
 	// avoid continuously contract related requests attack
 	// generate too many go routines and used all resources
 	if err := p.HostContractProcessing(); err != nil {
@@ -55,8 +114,8 @@ func (pm *ProtocolManager) contractReqHandler(handler func(h *storagehost.Storag
 
 	// start the go routine, handle the host contract request
 	// once done, release the channel
+	pm.wg.Add(1)
 	go func() {
-		pm.wg.Add(1)
 		defer pm.wg.Done()
 		defer p.HostContractProcessingDone()
 		handler(pm.eth.storageHost, p, msg)
@@ -64,38 +123,3 @@ func (pm *ProtocolManager) contractReqHandler(handler func(h *storagehost.Storag
 
 	return nil
 }
-
-func (pm *ProtocolManager) ethMsgHandler(p *peer) {
-	// get the initial number of eth messages in the ethMsgBuffer
-	messages := p.GetEthMsgBuffer()
-
-	for {
-		// loop through the messages, handle each of them, and then
-		// update the eth message buffer
-		for _, msg := range messages {
-			if err := pm.handleEthMsg(p, msg); err != nil {
-				p.Log().Error("Ethereum handle message failed", "err", err.Error())
-				p.TriggerError(err)
-			}
-
-			// remove the message from the eth message buffer
-			p.UpdateEthMsgBuffer()
-		}
-
-		// waiting fro the start signal was sent, then update the
-		// eth message buffer
-		if err := pm.waitEthStartIndicator(p); err != nil {
-			return
-		}
-		messages = p.GetEthMsgBuffer()
-	}
-}
-
-func (pm *ProtocolManager) waitEthStartIndicator(p *peer) error {
-	select {
-	case <-p.ethStartIndicator:
-		return nil
-	case <-pm.quitSync:
-		return errors.New("protocol manager sync quit")
-	}
-}
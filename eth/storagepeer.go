@@ -6,17 +6,212 @@ package eth
 
 import (
 	"errors"
+	"fmt"
+	"sync"
 	"time"
 
+	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/p2p"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
 )
 
+// storagePeer is the peer participating in the storage sub-protocol. It is
+// independent from the eth peer, so that storage negotiation never shares the
+// message flow with the block synchronization
+type storagePeer struct {
+	id string
+
+	*p2p.Peer
+	rw p2p.MsgReadWriter
+
+	version int // Protocol version negotiated
+
+	// storage message channel
+	clientConfigMsg   chan p2p.Msg
+	clientContractMsg chan p2p.Msg
+	hostContractMsg   chan p2p.Msg
+
+	hostConfigProcessing   chan struct{}
+	hostContractProcessing chan struct{}
+
+	contractRevisingOrRenewing chan struct{}
+	hostConfigRequesting       chan struct{}
+
+	// error channel
+	errMsg chan error
+
+	checkPeerStopHook func(*storagePeer) error
+}
+
+func newStoragePeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *storagePeer {
+	return &storagePeer{
+		Peer:                       p,
+		rw:                         rw,
+		version:                    version,
+		id:                         fmt.Sprintf("%x", p.ID().Bytes()[:8]),
+		clientConfigMsg:            make(chan p2p.Msg, 1),
+		clientContractMsg:          make(chan p2p.Msg, 1),
+		hostContractMsg:            make(chan p2p.Msg, 1),
+		hostConfigProcessing:       make(chan struct{}, 1),
+		hostContractProcessing:     make(chan struct{}, 1),
+		errMsg:                     make(chan error, 1),
+		contractRevisingOrRenewing: make(chan struct{}, 1),
+		hostConfigRequesting:       make(chan struct{}, 1),
+		checkPeerStopHook:          checkStoragePeerStop,
+	}
+}
+
+// Handshake executes the storage protocol handshake, negotiating version number,
+// network IDs and genesis blocks
+func (p *storagePeer) Handshake(network uint64, genesis common.Hash) error {
+	// Send out own handshake in a new thread
+	errc := make(chan error, 2)
+	go func() {
+		errc <- p2p.Send(p.rw, StorageStatusMsg, &storageStatusData{
+			ProtocolVersion: uint32(p.version),
+			NetworkId:       network,
+			GenesisBlock:    genesis,
+		})
+	}()
+	go func() {
+		errc <- p.readStatus(network, genesis)
+	}()
+	timeout := time.NewTimer(handshakeTimeout)
+	defer timeout.Stop()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+		case <-timeout.C:
+			return p2p.DiscReadTimeout
+		}
+	}
+	return nil
+}
+
+func (p *storagePeer) readStatus(network uint64, genesis common.Hash) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	defer msg.Discard()
+	if msg.Code != StorageStatusMsg {
+		return errResp(ErrNoStatusMsg, "first msg has code %x (!= %x)", msg.Code, StorageStatusMsg)
+	}
+	if msg.Size > StorageProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, StorageProtocolMaxMsgSize)
+	}
+	// Decode the handshake and make sure everything matches
+	var status storageStatusData
+	if err := msg.Decode(&status); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	if status.GenesisBlock != genesis {
+		return errResp(ErrGenesisBlockMismatch, "%x (!= %x)", status.GenesisBlock[:8], genesis[:8])
+	}
+	if status.NetworkId != network {
+		return errResp(ErrNetworkIdMismatch, "%d (!= %d)", status.NetworkId, network)
+	}
+	if int(status.ProtocolVersion) != p.version {
+		return errResp(ErrProtocolVersionMismatch, "%d (!= %d)", status.ProtocolVersion, p.version)
+	}
+	return nil
+}
+
+// String implements fmt.Stringer.
+func (p *storagePeer) String() string {
+	return fmt.Sprintf("Peer %s [%s]", p.id,
+		fmt.Sprintf("%s/%d", p2p.StorageProtocol, p.version),
+	)
+}
+
+// storagePeerSet represents the collection of active peers currently participating in
+// the storage sub-protocol
+type storagePeerSet struct {
+	peers  map[string]*storagePeer
+	lock   sync.RWMutex
+	closed bool
+}
+
+// newStoragePeerSet creates a new storage peer set to track the active participants
+func newStoragePeerSet() *storagePeerSet {
+	return &storagePeerSet{
+		peers: make(map[string]*storagePeer),
+	}
+}
+
+// Register injects a new storage peer into the working set, or returns an error if
+// the peer is already known
+func (ps *storagePeerSet) Register(p *storagePeer) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if ps.closed {
+		return errClosed
+	}
+	if _, ok := ps.peers[p.id]; ok {
+		return errAlreadyRegistered
+	}
+	ps.peers[p.id] = p
+	return nil
+}
+
+// Unregister removes a remote storage peer from the active set
+func (ps *storagePeerSet) Unregister(id string) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if _, ok := ps.peers[id]; !ok {
+		return errNotRegistered
+	}
+	delete(ps.peers, id)
+	return nil
+}
+
+// Peer retrieves the registered storage peer with the given id
+func (ps *storagePeerSet) Peer(id string) *storagePeer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	return ps.peers[id]
+}
+
+// Len returns the current number of storage peers in the set
+func (ps *storagePeerSet) Len() int {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	return len(ps.peers)
+}
+
+// Close disconnects all storage peers.
+// No new peers can be registered after Close has returned.
+func (ps *storagePeerSet) Close() {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	for _, p := range ps.peers {
+		p.Disconnect(p2p.DiscQuitting)
+	}
+	ps.closed = true
+}
+
+func checkStoragePeerStop(p *storagePeer) error {
+	select {
+	case <-p.StopChan():
+		return coinchargemaintenance.ErrProgramExit
+	default:
+		return nil
+	}
+}
+
 // TriggerError is used to send the error message to the errMsg channel,
 // where the node will exit the readLoop and disconnect with the peer
-func (p *peer) TriggerError(err error) {
+func (p *storagePeer) TriggerError(err error) {
 	select {
 	case p.errMsg <- err:
 	default:
@@ -25,7 +220,7 @@ func (p *peer) TriggerError(err error) {
 
 // SendStorageHostConfig will send the storage host configuration to the client
 // once the host got the request from the storage client
-func (p *peer) SendStorageHostConfig(config storage.HostExtConfig) error {
+func (p *storagePeer) SendStorageHostConfig(config storage.HostExtConfig) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.HostConfigRespMsg, config)
@@ -35,7 +230,7 @@ func (p *peer) SendStorageHostConfig(config storage.HostExtConfig) error {
 
 // RequestStorageHostConfig is used when the client is trying to request host's
 // configuration. The HostConfigReqMsg will be sent to the storage host
-func (p *peer) RequestStorageHostConfig() error {
+func (p *storagePeer) RequestStorageHostConfig() error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.HostConfigReqMsg, struct{}{})
@@ -46,7 +241,7 @@ func (p *peer) RequestStorageHostConfig() error {
 // RequestContractCreate will be used when the storage client is trying to create
 // the contract with desired storage host. ContractCreateReqMsg will be sent to the
 // storage host
-func (p *peer) RequestContractCreation(req storage.ContractCreateRequest) error {
+func (p *storagePeer) RequestContractCreation(req storage.ContractCreateRequest) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ContractCreateReqMsg, req)
@@ -56,7 +251,7 @@ func (p *peer) RequestContractCreation(req storage.ContractCreateRequest) error
 
 // SendContractCreateClientRevisionSig will be used once the storage client drafted and
 // signed a contract revision and requesting the validation and signature from the storage host
-func (p *peer) SendContractCreateClientRevisionSign(revisionSign []byte) error {
+func (p *storagePeer) SendContractCreateClientRevisionSign(revisionSign []byte) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ContractCreateClientRevisionSign, revisionSign)
@@ -67,7 +262,7 @@ func (p *peer) SendContractCreateClientRevisionSign(revisionSign []byte) error {
 // SendContractCreationHostSign will be used once the host received the ContractCreateReqMsg
 // message from the client. The host will validated the contract, sign it, and sent back to
 // the storage client
-func (p *peer) SendContractCreationHostSign(contractSign []byte) error {
+func (p *storagePeer) SendContractCreationHostSign(contractSign []byte) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ContractCreateHostSign, contractSign)
@@ -77,7 +272,7 @@ func (p *peer) SendContractCreationHostSign(contractSign []byte) error {
 
 // SendContractCreationHostRevisionSign will be used once the host received the revised
 // contract from the storage client. Host will validate it, sign it, and send it back
-func (p *peer) SendContractCreationHostRevisionSign(revisionSign []byte) error {
+func (p *storagePeer) SendContractCreationHostRevisionSign(revisionSign []byte) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ContractCreateRevisionSign, revisionSign)
@@ -88,7 +283,7 @@ func (p *peer) SendContractCreationHostRevisionSign(revisionSign []byte) error {
 // RequestContractUpload is used when the client is trying to upload data
 // to the corresponded storage host. Upload request must be sent to the storage
 // host first
-func (p *peer) RequestContractUpload(req storage.UploadRequest) error {
+func (p *storagePeer) RequestContractUpload(req storage.UploadRequest) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ContractUploadReqMsg, req)
@@ -98,7 +293,7 @@ func (p *peer) RequestContractUpload(req storage.UploadRequest) error {
 
 // SendContractUploadClientRevisionSign will be sent by the storage client
 // once the client received the merkle proof sent by the storage host
-func (p *peer) SendContractUploadClientRevisionSign(revisionSign []byte) error {
+func (p *storagePeer) SendContractUploadClientRevisionSign(revisionSign []byte) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ContractUploadClientRevisionSign, revisionSign)
@@ -108,7 +303,7 @@ func (p *peer) SendContractUploadClientRevisionSign(revisionSign []byte) error {
 
 // SendUploadMerkleProof is sent by the storage host to prove that it has the data
 // that storage client needed
-func (p *peer) SendUploadMerkleProof(merkleProof storage.UploadMerkleProof) error {
+func (p *storagePeer) SendUploadMerkleProof(merkleProof storage.UploadMerkleProof) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ContractUploadMerkleProofMsg, merkleProof)
@@ -119,7 +314,7 @@ func (p *peer) SendUploadMerkleProof(merkleProof storage.UploadMerkleProof) erro
 // SendUploadHostRevisionSign will be used once the storage host received the contract upload client
 // revision sign sent by the storage client. Host will validate the revised contract, sign it, and
 // send it back to the storage client
-func (p *peer) SendUploadHostRevisionSign(revisionSign []byte) error {
+func (p *storagePeer) SendUploadHostRevisionSign(revisionSign []byte) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ContractUploadRevisionSign, revisionSign)
@@ -129,7 +324,7 @@ func (p *peer) SendUploadHostRevisionSign(revisionSign []byte) error {
 
 // RequestContractDownload will be used when the storage client wants to download
 // data pieces from the corresponded storage host
-func (p *peer) RequestContractDownload(req storage.DownloadRequest) error {
+func (p *storagePeer) RequestContractDownload(req storage.DownloadRequest) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ContractDownloadReqMsg, req)
@@ -139,7 +334,7 @@ func (p *peer) RequestContractDownload(req storage.DownloadRequest) error {
 
 // SendContractDownloadData is sent by the client. Data piece requested by the
 // storage client will be included
-func (p *peer) SendContractDownloadData(resp storage.DownloadResponse) error {
+func (p *storagePeer) SendContractDownloadData(resp storage.DownloadResponse) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ContractDownloadDataMsg, resp)
@@ -147,9 +342,89 @@ func (p *peer) SendContractDownloadData(resp storage.DownloadResponse) error {
 	return err
 }
 
+// RequestContractDownloadBatch will be used when the storage client wants to download
+// multiple data pieces from the corresponded storage host with a single revision
+func (p *storagePeer) RequestContractDownloadBatch(req storage.DownloadBatchRequest) error {
+	var err error
+	if err = p.checkPeerStopHook(p); err == nil {
+		return p2p.Send(p.rw, storage.ContractDownloadBatchReqMsg, req)
+	}
+	return err
+}
+
+// SendContractDownloadBatchData is sent by the host. One data piece of the batched download
+// request will be included
+func (p *storagePeer) SendContractDownloadBatchData(resp storage.DownloadBatchResponse) error {
+	var err error
+	if err = p.checkPeerStopHook(p); err == nil {
+		return p2p.Send(p.rw, storage.ContractDownloadBatchDataMsg, resp)
+	}
+	return err
+}
+
+// RequestEphemeralAccountFund will be used when the storage client wants to fund its
+// ephemeral account in the storage host with a contract revision
+func (p *storagePeer) RequestEphemeralAccountFund(req storage.EphemeralAccountFundRequest) error {
+	var err error
+	if err = p.checkPeerStopHook(p); err == nil {
+		return p2p.Send(p.rw, storage.EphemeralAccountFundReqMsg, req)
+	}
+	return err
+}
+
+// RequestEphemeralAccountRefund will be used when the storage client wants to refund the
+// ephemeral account balance back to the storage contract
+func (p *storagePeer) RequestEphemeralAccountRefund(req storage.EphemeralAccountRefundRequest) error {
+	var err error
+	if err = p.checkPeerStopHook(p); err == nil {
+		return p2p.Send(p.rw, storage.EphemeralAccountRefundReqMsg, req)
+	}
+	return err
+}
+
+// SendEphemeralAccountResponse is sent by the host. The host revision signature and the
+// ephemeral account balance will be included
+func (p *storagePeer) SendEphemeralAccountResponse(resp storage.EphemeralAccountResponse) error {
+	var err error
+	if err = p.checkPeerStopHook(p); err == nil {
+		return p2p.Send(p.rw, storage.EphemeralAccountRespMsg, resp)
+	}
+	return err
+}
+
+// RequestEphemeralDownload will be used when the storage client wants to download data
+// pieces paid by the ephemeral account
+func (p *storagePeer) RequestEphemeralDownload(req storage.EphemeralDownloadRequest) error {
+	var err error
+	if err = p.checkPeerStopHook(p); err == nil {
+		return p2p.Send(p.rw, storage.EphemeralDownloadReqMsg, req)
+	}
+	return err
+}
+
+// RequestEphemeralHostConfig will be used when the storage client wants to request the
+// host config paid by the ephemeral account
+func (p *storagePeer) RequestEphemeralHostConfig(req storage.EphemeralHostConfigRequest) error {
+	var err error
+	if err = p.checkPeerStopHook(p); err == nil {
+		return p2p.Send(p.rw, storage.EphemeralHostConfigReqMsg, req)
+	}
+	return err
+}
+
+// SendEphemeralHostConfig is sent by the host. The host config and the ephemeral account
+// balance will be included
+func (p *storagePeer) SendEphemeralHostConfig(resp storage.EphemeralHostConfigResponse) error {
+	var err error
+	if err = p.checkPeerStopHook(p); err == nil {
+		return p2p.Send(p.rw, storage.EphemeralHostConfigRespMsg, resp)
+	}
+	return err
+}
+
 // SendHostBusyHandleRequestErr will send a error message to client, stating that
 // the host is currently busy handling the previous error message
-func (p *peer) SendHostBusyHandleRequestErr() error {
+func (p *storagePeer) SendHostBusyHandleRequestErr() error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.HostBusyHandleReqMsg, "error handling")
@@ -158,7 +433,7 @@ func (p *peer) SendHostBusyHandleRequestErr() error {
 }
 
 // SendClientNegotiateErrorMsg will send client negotiate error msg
-func (p *peer) SendClientNegotiateErrorMsg() error {
+func (p *storagePeer) SendClientNegotiateErrorMsg() error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ClientNegotiateErrorMsg, storage.ErrClientNegotiate.Error())
@@ -168,7 +443,7 @@ func (p *peer) SendClientNegotiateErrorMsg() error {
 
 // SendClientCommitFailedMsg will send a error msg to Host, indicating that client occurs exception
 // when executing 'Commit Action'
-func (p *peer) SendClientCommitFailedMsg() error {
+func (p *storagePeer) SendClientCommitFailedMsg() error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ClientCommitFailedMsg, storage.ErrClientCommit.Error())
@@ -177,7 +452,7 @@ func (p *peer) SendClientCommitFailedMsg() error {
 }
 
 // SendClientCommitSuccessMsg will send a success msg to Host, indicating that client has no error after 'Commit Action'
-func (p *peer) SendClientCommitSuccessMsg() error {
+func (p *storagePeer) SendClientCommitSuccessMsg() error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ClientCommitSuccessMsg, "commit success")
@@ -186,7 +461,7 @@ func (p *peer) SendClientCommitSuccessMsg() error {
 }
 
 // SendClientCommitSuccessMsg will send host commit failed msg to client
-func (p *peer) SendHostCommitFailedMsg() error {
+func (p *storagePeer) SendHostCommitFailedMsg() error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.HostCommitFailedMsg, storage.ErrHostCommit.Error())
@@ -194,7 +469,7 @@ func (p *peer) SendHostCommitFailedMsg() error {
 	return err
 }
 
-func (p *peer) SendClientAckMsg() error {
+func (p *storagePeer) SendClientAckMsg() error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.ClientAckMsg, "client ack")
@@ -203,7 +478,7 @@ func (p *peer) SendClientAckMsg() error {
 }
 
 // SendHostAckMsg will send host ack msg to client as the last negotiate msg no matter what success or failed
-func (p *peer) SendHostAckMsg() error {
+func (p *storagePeer) SendHostAckMsg() error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.HostAckMsg, "host ack")
@@ -212,7 +487,7 @@ func (p *peer) SendHostAckMsg() error {
 }
 
 // SendHostNegotiateErrorMsg will send host negotiate error msg
-func (p *peer) SendHostNegotiateErrorMsg() error {
+func (p *storagePeer) SendHostNegotiateErrorMsg() error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
 		return p2p.Send(p.rw, storage.HostNegotiateErrorMsg, storage.ErrHostNegotiate.Error())
@@ -222,7 +497,7 @@ func (p *peer) SendHostNegotiateErrorMsg() error {
 
 // WaitConfigResp is used by the storage client, waiting from the configuration
 // response from the storage host
-func (p *peer) WaitConfigResp() (msg p2p.Msg, err error) {
+func (p *storagePeer) WaitConfigResp() (msg p2p.Msg, err error) {
 	timeout := time.After(1 * time.Minute)
 	select {
 	case msg = <-p.clientConfigMsg:
@@ -238,7 +513,7 @@ func (p *peer) WaitConfigResp() (msg p2p.Msg, err error) {
 
 // ClientWaitContractResp is used by the storage client. The method will block the current
 // process until the response was sent back from the storage host
-func (p *peer) ClientWaitContractResp() (msg p2p.Msg, err error) {
+func (p *storagePeer) ClientWaitContractResp() (msg p2p.Msg, err error) {
 	timeout := time.After(1 * time.Minute)
 	select {
 	case msg = <-p.clientContractMsg:
@@ -254,7 +529,7 @@ func (p *peer) ClientWaitContractResp() (msg p2p.Msg, err error) {
 
 // HostWaitContractResp is used by the storage host. The method will block the current
 // process until the response was sent back from the storage client
-func (p *peer) HostWaitContractResp() (msg p2p.Msg, err error) {
+func (p *storagePeer) HostWaitContractResp() (msg p2p.Msg, err error) {
 	timeout := time.After(1 * time.Minute)
 	select {
 	case msg = <-p.hostContractMsg:
@@ -271,7 +546,7 @@ func (p *peer) HostWaitContractResp() (msg p2p.Msg, err error) {
 // HostConfigProcessing is used to indicate that the host is currently processing
 // the storage host configuration request sent from the storage client, which will
 // deny another configuration request sent by the storage client
-func (p *peer) HostConfigProcessing() error {
+func (p *storagePeer) HostConfigProcessing() error {
 	select {
 	case p.hostConfigProcessing <- struct{}{}:
 		return nil
@@ -282,7 +557,7 @@ func (p *peer) HostConfigProcessing() error {
 
 // HostConfigProcessingDone is used to indicate that storage host finished processing
 // the storage host configuration
-func (p *peer) HostConfigProcessingDone() {
+func (p *storagePeer) HostConfigProcessingDone() {
 	select {
 	case <-p.hostConfigProcessing:
 		return
@@ -294,7 +569,7 @@ func (p *peer) HostConfigProcessingDone() {
 // HostContractProcessing is used to indicate that the host is currently processing
 // the contract related request sent from the storage client. It will include data upload,
 // data download, contract creation, and contract revision
-func (p *peer) HostContractProcessing() error {
+func (p *storagePeer) HostContractProcessing() error {
 	select {
 	case p.hostContractProcessing <- struct{}{}:
 		return nil
@@ -305,7 +580,7 @@ func (p *peer) HostContractProcessing() error {
 
 // HostContractProcessingDone is used to indicate that storage host finished processing
 // the client's contract request, and is ready for the next request
-func (p *peer) HostContractProcessingDone() {
+func (p *storagePeer) HostContractProcessingDone() {
 	select {
 	case <-p.hostContractProcessing:
 		return
@@ -316,7 +591,7 @@ func (p *peer) HostContractProcessingDone() {
 
 // TryToRenewOrRevise will try to renew or revise the contract, if failed
 // the renew process and revision process will be interrupted immediately
-func (p *peer) TryToRenewOrRevise() bool {
+func (p *storagePeer) TryToRenewOrRevise() bool {
 	select {
 	case p.contractRevisingOrRenewing <- struct{}{}:
 		return true
@@ -326,7 +601,7 @@ func (p *peer) TryToRenewOrRevise() bool {
 }
 
 // RevisionOrRenewingDone indicates the revision or renewing operation has been finished
-func (p *peer) RevisionOrRenewingDone() {
+func (p *storagePeer) RevisionOrRenewingDone() {
 	select {
 	case <-p.contractRevisingOrRenewing:
 	default:
@@ -336,7 +611,7 @@ func (p *peer) RevisionOrRenewingDone() {
 // TryRequestHostConfig is used to check if the client is currently requesting storage
 // client configuration, meaning the client should not send another request message
 // before the previous request has finished
-func (p *peer) TryRequestHostConfig() error {
+func (p *storagePeer) TryRequestHostConfig() error {
 	select {
 	case p.hostConfigRequesting <- struct{}{}:
 		return nil
@@ -347,7 +622,7 @@ func (p *peer) TryRequestHostConfig() error {
 
 // RequestHostConfigDone is used to indicate the storage client
 // that the storage config request is finished
-func (p *peer) RequestHostConfigDone() {
+func (p *storagePeer) RequestHostConfigDone() {
 	select {
 	case <-p.hostConfigRequesting:
 	default:
@@ -355,11 +630,11 @@ func (p *peer) RequestHostConfigDone() {
 }
 
 // IsStaticConn checks if the connection is static connection
-func (p *peer) IsStaticConn() bool {
+func (p *storagePeer) IsStaticConn() bool {
 	return p.Peer.Info().Network.Static
 }
 
 // PeerNode returns the peer's node information
-func (p *peer) PeerNode() *enode.Node {
+func (p *storagePeer) PeerNode() *enode.Node {
 	return p.Peer.Node()
 }
@@ -0,0 +1,38 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package eth
+
+import (
+	"github.com/DxChainNetwork/godx/common"
+)
+
+// Constants to match up storage protocol versions and messages
+const (
+	storage1 = 1
+)
+
+// StorageProtocolVersions are the supported versions of the storage protocol (first is primary).
+var StorageProtocolVersions = []uint{storage1}
+
+// StorageProtocolLengths are the number of implemented message corresponding to different
+// storage protocol versions. Message codes below 0x20 are reserved for the protocol itself,
+// the storage negotiation messages are defined in the storage package
+var StorageProtocolLengths = []uint64{0x40}
+
+// StorageProtocolMaxMsgSize is the maximum cap on the size of a storage protocol message. It
+// is larger than the eth limit because a single message may carry an entire sector
+const StorageProtocolMaxMsgSize = 32 * 1024 * 1024
+
+// storage protocol message codes
+const (
+	StorageStatusMsg = 0x00
+)
+
+// storageStatusData is the network packet for the storage status message
+type storageStatusData struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	GenesisBlock    common.Hash
+}
@@ -0,0 +1,196 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package eth
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/eth/downloader"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+)
+
+// newTestStoragePeer creates a new storage peer handled by the given protocol manager
+func newTestStoragePeer(name string, pm *ProtocolManager) (*p2p.MsgPipeRW, *storagePeer, <-chan error) {
+	app, net := p2p.MsgPipe()
+
+	var id enode.ID
+	rand.Read(id[:])
+	peer := newStoragePeer(storage1, p2p.NewPeer(id, name, nil), net)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- pm.handleStorage(peer)
+	}()
+	return app, peer, errc
+}
+
+// Tests that storage handshake failures are detected and reported correctly.
+func TestStorageStatusMsgErrors(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	genesis := pm.blockchain.Genesis()
+	defer pm.Stop()
+
+	tests := []struct {
+		code      uint64
+		data      interface{}
+		wantError error
+	}{
+		{
+			code: 0x20, data: struct{}{},
+			wantError: errResp(ErrNoStatusMsg, "first msg has code 20 (!= 0)"),
+		},
+		{
+			code: StorageStatusMsg, data: storageStatusData{10, DefaultConfig.NetworkId, genesis.Hash()},
+			wantError: errResp(ErrProtocolVersionMismatch, "10 (!= %d)", storage1),
+		},
+		{
+			code: StorageStatusMsg, data: storageStatusData{storage1, 999, genesis.Hash()},
+			wantError: errResp(ErrNetworkIdMismatch, "999 (!= 1)"),
+		},
+		{
+			code: StorageStatusMsg, data: storageStatusData{storage1, DefaultConfig.NetworkId, common.Hash{3}},
+			wantError: errResp(ErrGenesisBlockMismatch, "0300000000000000 (!= %x)", genesis.Hash().Bytes()[:8]),
+		},
+	}
+
+	for i, test := range tests {
+		app, _, errc := newTestStoragePeer("peer", pm)
+		// The send call might hang until reset because
+		// the protocol might not read the payload.
+		go p2p.Send(app, test.code, test.data)
+
+		select {
+		case err := <-errc:
+			if err == nil {
+				t.Errorf("test %d: protocol returned nil error, want %q", i, test.wantError)
+			} else if err.Error() != test.wantError.Error() {
+				t.Errorf("test %d: wrong error: got %q, want %q", i, err, test.wantError)
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("protocol did not shut down within 2 seconds")
+		}
+		app.Close()
+	}
+}
+
+// Tests that the storage peer is registered independently from the eth peer, and
+// removed once the storage connection is torn down
+func TestStoragePeerRegistration(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	app, peer, errc := newTestStoragePeer("peer", pm)
+	status := &storageStatusData{storage1, DefaultConfig.NetworkId, pm.blockchain.Genesis().Hash()}
+	if err := p2p.ExpectMsg(app, StorageStatusMsg, status); err != nil {
+		t.Fatalf("status recv: %v", err)
+	}
+	if err := p2p.Send(app, StorageStatusMsg, status); err != nil {
+		t.Fatalf("status send: %v", err)
+	}
+
+	// wait until the storage peer is registered
+	for i := 0; pm.storagePeers.Peer(peer.id) == nil; i++ {
+		if i > 100 {
+			t.Fatal("storage peer is not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if pm.peers.Peer(peer.id) != nil {
+		t.Error("storage peer shall not be registered as an eth peer")
+	}
+
+	// an extra status message shall tear down the storage connection
+	go p2p.Send(app, StorageStatusMsg, status)
+	select {
+	case err := <-errc:
+		if want := errResp(ErrExtraStatusMsg, "uncontrolled status message"); err == nil || err.Error() != want.Error() {
+			t.Errorf("wrong error: got %v, want %v", err, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("protocol did not shut down within 2 seconds")
+	}
+	if pm.storagePeers.Peer(peer.id) != nil {
+		t.Error("storage peer shall be removed after disconnection")
+	}
+	app.Close()
+}
+
+// Tests that the failure of the eth protocol keeps the connection for the storage protocol
+// running over it, and the eth messages are discarded until the connection is torn down
+func TestEthFailureKeepsStoragePeer(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	storageApp, storage, _ := newTestStoragePeer("peer", pm)
+	defer storageApp.Close()
+	status := &storageStatusData{storage1, DefaultConfig.NetworkId, pm.blockchain.Genesis().Hash()}
+	if err := p2p.ExpectMsg(storageApp, StorageStatusMsg, status); err != nil {
+		t.Fatalf("status recv: %v", err)
+	}
+	if err := p2p.Send(storageApp, StorageStatusMsg, status); err != nil {
+		t.Fatalf("status send: %v", err)
+	}
+	for i := 0; pm.storagePeers.Peer(storage.id) == nil; i++ {
+		if i > 100 {
+			t.Fatal("storage peer is not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the eth peer without the storage protocol is disconnected with the error
+	app, net := p2p.MsgPipe()
+	var id enode.ID
+	rand.Read(id[:])
+	if err := pm.endEthProtocol(pm.newPeer(eth63, p2p.NewPeer(id, "other", nil), net), p2p.DiscTooManyPeers); err != p2p.DiscTooManyPeers {
+		t.Errorf("wrong error: got %v, want %v", err, p2p.DiscTooManyPeers)
+	}
+	app.Close()
+
+	// the eth peer over the storage connection keeps discarding the eth messages
+	app, net = p2p.MsgPipe()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- pm.endEthProtocol(pm.newPeer(eth63, p2p.NewPeer(storage.ID(), "peer", nil), net), p2p.DiscTooManyPeers)
+	}()
+	if err := p2p.Send(app, TxMsg, []interface{}{}); err != nil {
+		t.Fatalf("eth message send: %v", err)
+	}
+	select {
+	case err := <-errc:
+		t.Fatalf("eth protocol returned with the storage peer running: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	app.Close()
+	select {
+	case err := <-errc:
+		if err != p2p.ErrPipeClosed {
+			t.Errorf("wrong error: got %v, want %v", err, p2p.ErrPipeClosed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("eth protocol did not shut down within 2 seconds")
+	}
+
+	// the storage-only peer is kept before the storage peer is registered
+	pm.storageOnlyPeers = true
+	app, net = p2p.MsgPipe()
+	rand.Read(id[:])
+	caps := []p2p.Cap{{Name: p2p.StorageProtocol, Version: storage1}}
+	go func() {
+		errc <- pm.endEthProtocol(pm.newPeer(eth63, p2p.NewPeer(id, "storage-only", caps), net), p2p.DiscTooManyPeers)
+	}()
+	select {
+	case err := <-errc:
+		t.Fatalf("eth protocol returned for the storage-only peer: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	app.Close()
+	if err := <-errc; err != p2p.ErrPipeClosed {
+		t.Errorf("wrong error: got %v, want %v", err, p2p.ErrPipeClosed)
+	}
+}
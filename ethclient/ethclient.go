@@ -281,6 +281,9 @@ func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
 	}
+	if number.Cmp(big.NewInt(int64(rpc.FinalizedBlockNumber))) == 0 {
+		return "finalized"
+	}
 	return hexutil.EncodeBig(number)
 }
 
@@ -323,6 +326,12 @@ func (ec *Client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header)
 	return ec.c.EthSubscribe(ctx, ch, "newHeads")
 }
 
+// SubscribeNewFinalizedHead subscribes to notifications about the latest irreversible
+// block confirmed by the validators on the given channel.
+func (ec *Client) SubscribeNewFinalizedHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return ec.c.EthSubscribe(ctx, ch, "newFinalizedHeads")
+}
+
 // State Access
 
 // NetworkID returns the network ID (also known as the chain ID) for this chain.
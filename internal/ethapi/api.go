@@ -654,6 +654,8 @@ func blockToStorageContract(block *types.Block) (map[string]interface{}, error)
 			fields[tx.Hash().String()] = vm.StorageProofTransaction
 		case vm.HostAnnounceTransaction:
 			fields[tx.Hash().String()] = vm.HostAnnounceTransaction
+		case vm.HostRetireTransaction:
+			fields[tx.Hash().String()] = vm.HostRetireTransaction
 		default:
 			continue
 		}
@@ -1230,6 +1232,14 @@ func transactionToStorageContract(transaction *types.Transaction) (map[string]in
 			return fields, errors.New("the data field in the transaction is decoded abnormally")
 		}
 		fields["HostAnnouncement"] = ha
+	case vm.HostRetireTransaction:
+		fields[transaction.Hash().String()] = vm.HostRetireTransaction
+		var hr types.HostRetirement
+		err := rlp.DecodeBytes(transaction.Data(), &hr)
+		if err != nil {
+			return fields, errors.New("the data field in the transaction is decoded abnormally")
+		}
+		fields["HostRetirement"] = hr
 	default:
 	}
 	return fields, nil
@@ -55,6 +55,74 @@ func ParseAndValidateCandidateApplyTxArgs(to common.Address, gas uint64, fields
 	return NewPrecompiledContractTxArgs(candidateAddress, to, data, nil, gas), nil
 }
 
+// ParseAndValidateSigningKeyTxArgs will parse and validate the signing key rotation transaction arguments
+func ParseAndValidateSigningKeyTxArgs(to common.Address, gas uint64, fields map[string]string, dposCtx *types.DposContext, account *accounts.Manager) (*PrecompiledContractTxArgs, error) {
+	// parse the candidateAddress field
+	var candidateAddress common.Address
+	if fromStr, ok := fields["from"]; ok {
+		candidateAddress = common.HexToAddress(fromStr)
+	} else {
+		candidateAddress = defaultAccount(account)
+		log.Info("Candidate account is automatically configured", "candidateAccount", candidateAddress)
+	}
+
+	// validate candidateAddress
+	if reflect.DeepEqual(candidateAddress, common.Address{}) {
+		return nil, fmt.Errorf("the address used for rotating signing key cannot be empty")
+	}
+
+	// form signing key tx data
+	signerStr, ok := fields["signer"]
+	if !ok {
+		return nil, fmt.Errorf("failed to form signingKeyTxData, signer is not provided")
+	}
+	signingKeyTxData := types.SigningKeyTxData{Signer: common.HexToAddress(signerStr)}
+
+	// validate signing key tx data
+	if err := dpos.SigningKeyTxDataValidation(dposCtx, signingKeyTxData, candidateAddress); err != nil {
+		return nil, err
+	}
+
+	// signing key transaction data encoding
+	data, err := rlp.EncodeToBytes(&signingKeyTxData)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPrecompiledContractTxArgs(candidateAddress, to, data, nil, gas), nil
+}
+
+// ParseAndValidateCandidateMetadataTxArgs will parse and validate the candidate metadata update transaction arguments
+func ParseAndValidateCandidateMetadataTxArgs(to common.Address, gas uint64, fields map[string]string, dposCtx *types.DposContext, account *accounts.Manager) (*PrecompiledContractTxArgs, error) {
+	// parse the candidateAddress field
+	var candidateAddress common.Address
+	if fromStr, ok := fields["from"]; ok {
+		candidateAddress = common.HexToAddress(fromStr)
+	} else {
+		candidateAddress = defaultAccount(account)
+		log.Info("Candidate account is automatically configured", "candidateAccount", candidateAddress)
+	}
+
+	// validate candidateAddress
+	if reflect.DeepEqual(candidateAddress, common.Address{}) {
+		return nil, fmt.Errorf("the address used for updating candidate metadata cannot be empty")
+	}
+
+	// form and validate the candidate metadata
+	metadata := formCandidateMetadata(fields)
+	if err := dpos.CandidateMetadataTxDataValidation(dposCtx, metadata, candidateAddress); err != nil {
+		return nil, err
+	}
+
+	// candidate metadata encoding
+	data, err := rlp.EncodeToBytes(&metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPrecompiledContractTxArgs(candidateAddress, to, data, nil, gas), nil
+}
+
 // ParseAndValidateVoteTxArgs will parse and validate the vote transaction arguments
 func ParseAndValidateVoteTxArgs(to common.Address, gas uint64, fields map[string]string, stateDB *state.StateDB, account *accounts.Manager) (*PrecompiledContractTxArgs, error) {
 	// parse the delegator account address
@@ -142,9 +210,27 @@ func formAddCandidateTxData(fields map[string]string) (data types.AddCandidateTx
 		return types.AddCandidateTxData{}, err
 	}
 
+	// parse the optional block signing key
+	if signerStr, ok := fields["signer"]; ok {
+		data.Signer = common.HexToAddress(signerStr)
+	}
+
+	// parse the optional candidate metadata
+	data.Metadata = formCandidateMetadata(fields)
+
 	return
 }
 
+// formCandidateMetadata will parse the optional candidate metadata fields
+func formCandidateMetadata(fields map[string]string) types.CandidateMetadata {
+	return types.CandidateMetadata{
+		Name:    fields["name"],
+		Website: fields["website"],
+		Contact: fields["contact"],
+		Enode:   fields["enode"],
+	}
+}
+
 // parseCandidates will convert the string to a list of candidate address
 func parseCandidates(candidates string) ([]common.Address, error) {
 	// strip all white spaces
@@ -31,12 +31,18 @@ func NewPrivateStorageContractTxAPI(b Backend, nonceLock *AddrLocker) *PrivateSt
 	return &PrivateStorageContractTxAPI{b, nonceLock}
 }
 
-// SendHostAnnounceTX submit a host announce tx to txpool, only for outer request, need to open cmd and RPC API
-func (psc *PrivateStorageContractTxAPI) SendHostAnnounceTX(from common.Address) (common.Hash, error) {
+// SendHostAnnounceTX submit a host announce tx to txpool, only for outer request, need to open cmd and RPC API.
+// If the metadata is not provided, or the host retire fork is not reached by the next block, the
+// legacy announcement carrying only the net address is sent
+func (psc *PrivateStorageContractTxAPI) SendHostAnnounceTX(from common.Address, metadata *types.HostAnnouncementMetadata) (common.Hash, error) {
 	hostEnodeURL := psc.b.GetHostEnodeURL()
 	hostAnnouncement := types.HostAnnouncement{
 		NetAddress: hostEnodeURL,
 	}
+	next := new(big.Int).Add(psc.b.CurrentBlock().Number(), big.NewInt(1))
+	if metadata != nil && psc.b.ChainConfig().IsHostRetire(next) {
+		hostAnnouncement.Metadata = []types.HostAnnouncementMetadata{*metadata}
+	}
 
 	hash := hostAnnouncement.RLPHash()
 	sign, err := psc.b.SignByNode(hash.Bytes())
@@ -64,6 +70,39 @@ func (psc *PrivateStorageContractTxAPI) SendHostAnnounceTX(from common.Address)
 	return txHash, nil
 }
 
+// SendHostRetireTX submit a host retire tx to txpool, which withdraws the host announcements
+// with sequence not larger than the given sequence
+func (psc *PrivateStorageContractTxAPI) SendHostRetireTX(from common.Address, sequence uint64) (common.Hash, error) {
+	hostRetirement := types.HostRetirement{
+		NetAddress: psc.b.GetHostEnodeURL(),
+		Sequence:   sequence,
+	}
+
+	hash := hostRetirement.RLPHash()
+	sign, err := psc.b.SignByNode(hash.Bytes())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hostRetirement.Signature = sign
+
+	payload, err := rlp.EncodeToBytes(hostRetirement)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	to := common.Address{}
+	to.SetBytes([]byte{19})
+	ctx := context.Background()
+
+	// construct args
+	args := NewPrecompiledContractTxArgs(from, to, payload, nil, StorageContractTxGas)
+	txHash, err := sendPrecompiledContractTx(ctx, psc.b, psc.nonceLock, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return txHash, nil
+}
+
 // SendContractCreateTX submit a storage contract creation tx, generally triggered in ContractCreate, not for outer request
 func (psc *PrivateStorageContractTxAPI) SendContractCreateTX(from common.Address, input []byte) (common.Hash, error) {
 	to := common.Address{}
@@ -171,6 +210,66 @@ func (pd *PublicDposTxAPI) SendCancelCandidateTx(from common.Address) (common.Ha
 	return txHash, nil
 }
 
+// SendRotateSigningKeyTx submit a tx registering a new block signing key for the candidate.
+// The deposit and the rewards stay with the candidate address.
+func (pd *PublicDposTxAPI) SendRotateSigningKeyTx(fields map[string]string) (common.Hash, error) {
+	to := vm.RotateSigningKeyContractAddress
+	ctx := context.Background()
+
+	// get the latest block header
+	header, err := pd.b.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if header == nil || err != nil {
+		return common.Hash{}, err
+	}
+
+	dposCtx, err := types.NewDposContextFromProto(pd.b.ChainDb(), header.DposContext)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	// parse precompile contract tx args
+	args, err := ParseAndValidateSigningKeyTxArgs(to, DposTxGas, fields, dposCtx, pd.b.AccountManager())
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash, err := sendPrecompiledContractTx(ctx, pd.b, pd.nonceLock, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return txHash, nil
+}
+
+// SendUpdateCandidateMetadataTx submit a tx updating the metadata of the candidate. The
+// fields not provided are cleared from the metadata.
+func (pd *PublicDposTxAPI) SendUpdateCandidateMetadataTx(fields map[string]string) (common.Hash, error) {
+	to := vm.UpdateCandidateMetadataContractAddress
+	ctx := context.Background()
+
+	// get the latest block header
+	header, err := pd.b.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if header == nil || err != nil {
+		return common.Hash{}, err
+	}
+
+	dposCtx, err := types.NewDposContextFromProto(pd.b.ChainDb(), header.DposContext)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	// parse precompile contract tx args
+	args, err := ParseAndValidateCandidateMetadataTxArgs(to, DposTxGas, fields, dposCtx, pd.b.AccountManager())
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash, err := sendPrecompiledContractTx(ctx, pd.b, pd.nonceLock, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return txHash, nil
+}
+
 // SendVoteTx submit a vote tx
 func (pd *PublicDposTxAPI) SendVoteTx(fields map[string]string) (common.Hash, error) {
 	to := vm.VoteContractAddress
@@ -211,6 +211,18 @@ web3._extend({
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
 		}),
 
+		new web3._extend.Method({
+			name: 'rotateSigningKey',
+			call: 'dpos_sendRotateSigningKeyTx',
+			params: 1,
+		}),
+
+		new web3._extend.Method({
+			name: 'updateCandidateMetadata',
+			call: 'dpos_sendUpdateCandidateMetadataTx',
+			params: 1,
+		}),
+
 		new web3._extend.Method({
 			name: 'vote',
 			call: 'dpos_sendVoteTx',
@@ -230,6 +242,12 @@ web3._extend({
 			params: 1,
 		}),
 
+		new web3._extend.Method({
+			name: 'standbyStatus',
+			call: 'dpos_standbyStatus',
+			params: 0,
+		}),
+
 		new web3._extend.Method({
 			name: 'epochID',
 			call: 'dpos_epochID',
@@ -649,6 +667,17 @@ web3._extend({
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'setStandby',
+			call: 'miner_setStandby',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'setSigningKey',
+			call: 'miner_setSigningKey',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'setCoinbase',
 			call: 'miner_setCoinbase',
@@ -24,6 +24,7 @@ import (
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/math"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
 	"github.com/DxChainNetwork/godx/core"
 	"github.com/DxChainNetwork/godx/core/bloombits"
 	"github.com/DxChainNetwork/godx/core/rawdb"
@@ -39,6 +40,8 @@ import (
 	"github.com/DxChainNetwork/godx/rpc"
 )
 
+var errNoConfirmedHeader = errors.New("finalized block is only supported by the dpos engine")
+
 type LesApiBackend struct {
 	eth *LightEthereum
 	gpo *gasprice.Oracle
@@ -61,6 +64,13 @@ func (b *LesApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
 		return b.eth.blockchain.CurrentHeader(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		dposEng, ok := b.eth.engine.(*dpos.Dpos)
+		if !ok {
+			return nil, errNoConfirmedHeader
+		}
+		return dposEng.ConfirmedBlockHeader(b.eth.blockchain)
+	}
 	return b.eth.blockchain.GetHeaderByNumberOdr(ctx, uint64(blockNr))
 }
 
@@ -160,6 +170,18 @@ func (b *LesApiBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 	return b.eth.blockchain.SubscribeLogsEvent(ch)
 }
 
+// SubscribeConfirmedHeaderEvent subscribes the irreversible block headers confirmed by the dpos engine
+// when verifying the headers synced by the light client
+func (b *LesApiBackend) SubscribeConfirmedHeaderEvent(ch chan<- dpos.ConfirmedHeaderEvent) event.Subscription {
+	if dposEng, ok := b.eth.engine.(*dpos.Dpos); ok {
+		return dposEng.SubscribeConfirmedHeaderEvent(ch)
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *LesApiBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return b.eth.blockchain.SubscribeRemovedLogsEvent(ch)
 }
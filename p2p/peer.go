@@ -61,6 +61,9 @@ const (
 
 	// LightNodeProtocol is the protocol supported by light synced nodes
 	LightNodeProtocol = "lightdx"
+
+	// StorageProtocol is the protocol supported by storage client and storage host nodes
+	StorageProtocol = "dxstorage"
 )
 
 // protoHandshake is the RLP structure of the protocol handshake.
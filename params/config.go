@@ -127,16 +127,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(EthashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -176,6 +176,10 @@ type ChainConfig struct {
 	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"` // Constantinople switch block (nil = no fork, 0 = already activated)
 	EWASMBlock          *big.Int `json:"ewasmBlock,omitempty"`          // EWASM switch block (nil = no fork, 0 = already activated)
 
+	SigningKeyBlock        *big.Int `json:"signingKeyBlock,omitempty"`        // Separated candidate signing key switch block (nil = no fork, 0 = already activated)
+	CandidateMetadataBlock *big.Int `json:"candidateMetadataBlock,omitempty"` // Candidate metadata registry switch block (nil = no fork, 0 = already activated)
+	HostRetireBlock        *big.Int `json:"hostRetireBlock,omitempty"`        // Host announcement metadata, retirement and bond escrow switch block (nil = no fork, 0 = already activated)
+
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
@@ -268,6 +272,24 @@ func (c *ChainConfig) IsEWASM(num *big.Int) bool {
 	return isForked(c.EWASMBlock, num)
 }
 
+// IsSigningKey returns whether num represents a block number after the separated candidate
+// signing key fork
+func (c *ChainConfig) IsSigningKey(num *big.Int) bool {
+	return isForked(c.SigningKeyBlock, num)
+}
+
+// IsCandidateMetadata returns whether num represents a block number after the candidate
+// metadata registry fork
+func (c *ChainConfig) IsCandidateMetadata(num *big.Int) bool {
+	return isForked(c.CandidateMetadataBlock, num)
+}
+
+// IsHostRetire returns whether num represents a block number after the host announcement
+// metadata, retirement and announcement bond escrow fork
+func (c *ChainConfig) IsHostRetire(num *big.Int) bool {
+	return isForked(c.HostRetireBlock, num)
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
@@ -336,6 +358,15 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	if isForkIncompatible(c.SigningKeyBlock, newcfg.SigningKeyBlock, head) {
+		return newCompatError("signing key fork block", c.SigningKeyBlock, newcfg.SigningKeyBlock)
+	}
+	if isForkIncompatible(c.CandidateMetadataBlock, newcfg.CandidateMetadataBlock, head) {
+		return newCompatError("candidate metadata fork block", c.CandidateMetadataBlock, newcfg.CandidateMetadataBlock)
+	}
+	if isForkIncompatible(c.HostRetireBlock, newcfg.HostRetireBlock, head) {
+		return newCompatError("host retire fork block", c.HostRetireBlock, newcfg.HostRetireBlock)
+	}
 	return nil
 }
 
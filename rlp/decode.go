@@ -440,11 +440,18 @@ func makeStructDecoder(typ reflect.Type) (decoder, error) {
 		if _, err := s.List(); err != nil {
 			return wrapStreamError(err, typ)
 		}
-		for _, f := range fields {
+		for i, f := range fields {
 			// decode each data based on each structure field
 			// val.Field returns field val based on the field index
 			err := f.info.decoder(s, val.Field(f.index))
-			if err == EOL {
+			if err == EOL && f.optional {
+				// the optional fields missing from the input are set to zero value
+				for _, of := range fields[i:] {
+					zeroField := val.Field(of.index)
+					zeroField.Set(reflect.Zero(zeroField.Type()))
+				}
+				break
+			} else if err == EOL {
 				return &decodeError{msg: "too few elements", typ: typ}
 			} else if err != nil {
 				return addErrorContext(err, "."+typ.Field(f.index).Name)
@@ -953,6 +953,23 @@ type tailUint struct {
 	Tail []uint `rlp:"tail"`
 }
 
+type optionalFields struct {
+	A uint
+	B uint `rlp:"optional"`
+	C uint `rlp:"optional"`
+}
+
+type invalidOptional struct {
+	A uint `rlp:"optional"`
+	B uint
+}
+
+type optionalWithTail struct {
+	A uint
+	B uint   `rlp:"optional"`
+	C []uint `rlp:"tail"`
+}
+
 var (
 	veryBigInt = big.NewInt(0).Add(
 		big.NewInt(0).Lsh(big.NewInt(0xFFFFFFFFFFFFFF), 16),
@@ -1095,6 +1112,48 @@ var decodeTests = []decodeTest{
 		error: "rlp: expected input string or byte for uint, decoding into (rlp.tailUint).Tail[1]",
 	},
 
+	// struct tag "optional"
+	{
+		input: "C101",
+		ptr:   new(optionalFields),
+		value: optionalFields{1, 0, 0},
+	},
+	{
+		input: "C20102",
+		ptr:   new(optionalFields),
+		value: optionalFields{1, 2, 0},
+	},
+	{
+		input: "C3010203",
+		ptr:   new(optionalFields),
+		value: optionalFields{1, 2, 3},
+	},
+	{
+		input: "C401020304",
+		ptr:   new(optionalFields),
+		error: "rlp: input list has too many elements for rlp.optionalFields",
+	},
+	{
+		input: "C0",
+		ptr:   new(optionalFields),
+		error: "rlp: too few elements for rlp.optionalFields",
+	},
+	{
+		input: "C101",
+		ptr:   &optionalFields{A: 9, B: 9, C: 9},
+		value: optionalFields{1, 0, 0},
+	},
+	{
+		input: "C0",
+		ptr:   new(invalidOptional),
+		error: "rlp: struct field rlp.invalidOptional.B needs \"optional\" tag (previous field A is optional)",
+	},
+	{
+		input: "C20102",
+		ptr:   new(optionalWithTail),
+		error: "rlp: invalid struct tag \"tail\" for rlp.optionalWithTail.C (previous field B is optional)",
+	},
+
 	// struct tag "tail"
 	{
 		input: "C3010203",
@@ -53,8 +53,9 @@ type tags struct {
 
 // used to store data after parsing the structure
 type field struct {
-	index int       // index of the field in the structure
-	info  *typeinfo // decoder&writer used for this field
+	index    int       // index of the field in the structure
+	info     *typeinfo // decoder&writer used for this field
+	optional bool      // if the field can be missing from the end of the input list
 }
 
 // Any function that is satisfied this requirement (same structure), will be type decoder or writer
@@ -130,6 +131,8 @@ func parseStructTag(typ reflect.Type, fi int) (tags, error) {
 			ts.ignored = true
 		case "nil":
 			ts.nilOK = true
+		case "optional":
+			// optional only affects the structure decoder, it is checked by structFields
 		case "tail":
 			ts.tail = true
 			// Check if the filed with the tail tag is in the last position
@@ -150,6 +153,7 @@ func parseStructTag(typ reflect.Type, fi int) (tags, error) {
 // parse the structure, get each field's type, and assign writer&decoder to each type
 // all information are stored inside field structure
 func structFields(typ reflect.Type) (fields []field, err error) {
+	var lastOptional string
 	// typ.NumField returns number of fields in the structure
 	for i := 0; i < typ.NumField(); i++ {
 		// PkgPath will be empty for exported field names (empty)
@@ -163,18 +167,42 @@ func structFields(typ reflect.Type) (fields []field, err error) {
 			if tags.ignored {
 				continue
 			}
+			// once a field is optional, all the following fields must be optional as well.
+			// The tail field cannot follow the optional fields, because the missing optional
+			// fields and the empty tail could not be told apart from the input
+			optional := isOptionalField(f)
+			if optional && tags.tail {
+				return nil, fmt.Errorf(`rlp: invalid struct tag "optional" for %v.%s (also has "tail" tag)`, typ, f.Name)
+			} else if optional {
+				lastOptional = f.Name
+			} else if lastOptional != "" && tags.tail {
+				return nil, fmt.Errorf(`rlp: invalid struct tag "tail" for %v.%s (previous field %s is optional)`, typ, f.Name, lastOptional)
+			} else if lastOptional != "" {
+				return nil, fmt.Errorf(`rlp: struct field %v.%s needs "optional" tag (previous field %s is optional)`, typ, f.Name, lastOptional)
+			}
 			// get the writer and decoder based on the field type
 			info, err := cachedTypeInfo1(f.Type, tags)
 			if err != nil {
 				return nil, err
 			}
 			// stores field index and corresponding type encoder and decoder
-			fields = append(fields, field{i, info})
+			fields = append(fields, field{i, info, optional})
 		}
 	}
 	return fields, nil
 }
 
+// isOptionalField checks if the structure field has the optional tag, which means the field
+// can be missing from the end of the input list and will be decoded as zero value
+func isOptionalField(f reflect.StructField) bool {
+	for _, t := range strings.Split(f.Tag.Get("rlp"), ",") {
+		if strings.TrimSpace(t) == "optional" {
+			return true
+		}
+	}
+	return false
+}
+
 // why using this comparison:
 // all Uint type, including Uint, Uint32, Uint8, Uint63, Uintptr, are counted as uint kind
 // NOTE: uintptr is not a pointer, it is an integer type that is large enough to hold the bit pattern of any pointer
@@ -7,7 +7,7 @@ import (
 	"github.com/DxChainNetwork/godx/common/math"
 )
 
-var bn BlockNumber = -3
+var bn BlockNumber = -4
 
 /*
 	Test the function UnmarshalJSON to check if the function can correctly parse the given byte slice
@@ -20,6 +20,7 @@ var bn BlockNumber = -3
 		earliest
 		latest
 		pending
+		finalized
 		"earliest"
 		regular numbers
 */
@@ -32,6 +33,7 @@ func TestUnmarshalJson(t *testing.T) {
 		{[]byte("earliest"), EarliestBlockNumber, false},
 		{[]byte("latest"), LatestBlockNumber, false},
 		{[]byte("pending"), PendingBlockNumber, false},
+		{[]byte("finalized"), FinalizedBlockNumber, false},
 		{[]byte(`"earliest"`), EarliestBlockNumber, false},
 		{[]byte("1"), BlockNumber(1), true},
 		{[]byte("0x1"), BlockNumber(1), false},
@@ -0,0 +1,97 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// minutesPerDay is the number of minutes of a day, which is the upper bound of the
+// start and end of a bandwidth window
+const minutesPerDay = 24 * 60
+
+type (
+	// BandwidthSchedule is the time-of-day and day-of-week schedule of the storage client
+	// bandwidth limits. Within a window, the speed limits of the first window containing the
+	// current local time are applied. Out of all windows, the MaxUploadSpeed and
+	// MaxDownloadSpeed of the ClientSetting are applied
+	BandwidthSchedule struct {
+		Windows []BandwidthWindow `json:"windows"`
+
+		// UploadOnlyInWindow restricts the repair loops to upload only within the windows
+		UploadOnlyInWindow bool `json:"uploadOnlyInWindow"`
+	}
+
+	// BandwidthWindow is a time window on the days of week. Start and End are the minutes
+	// from the midnight in local time. If End is not after Start, the window crosses the
+	// midnight and ends on the next day. Empty Days means every day. 0 speed means unlimited
+	BandwidthWindow struct {
+		Days             []time.Weekday `json:"days"`
+		Start            uint32         `json:"start"`
+		End              uint32         `json:"end"`
+		MaxUploadSpeed   int64          `json:"maxUploadSpeed"`
+		MaxDownloadSpeed int64          `json:"maxDownloadSpeed"`
+	}
+)
+
+// ActiveWindow returns the first window which contains the time t
+func (s BandwidthSchedule) ActiveWindow(t time.Time) (BandwidthWindow, bool) {
+	for _, w := range s.Windows {
+		if w.Contains(t) {
+			return w, true
+		}
+	}
+	return BandwidthWindow{}, false
+}
+
+// Validate checks whether the windows of the bandwidth schedule are valid
+func (s BandwidthSchedule) Validate() error {
+	for i, w := range s.Windows {
+		if w.Start >= minutesPerDay || w.End > minutesPerDay {
+			return fmt.Errorf("window %d: start %d and end %d must be within a day", i, w.Start, w.End)
+		}
+		if w.MaxUploadSpeed < 0 || w.MaxDownloadSpeed < 0 {
+			return fmt.Errorf("window %d: upload speed %v and download speed %v cannot be smaller than 0", i,
+				w.MaxUploadSpeed, w.MaxDownloadSpeed)
+		}
+		for _, day := range w.Days {
+			if day < time.Sunday || day > time.Saturday {
+				return fmt.Errorf("window %d: invalid day of week %d", i, day)
+			}
+		}
+	}
+	return nil
+}
+
+// Contains checks whether the time t is within the window
+func (w BandwidthWindow) Contains(t time.Time) bool {
+	minute := uint32(t.Hour()*60 + t.Minute())
+	if w.Start < w.End {
+		return minute >= w.Start && minute < w.End && w.onDay(t.Weekday())
+	}
+	// The window crosses the midnight. The time before End belongs to the window
+	// started on the previous day
+	if minute >= w.Start {
+		return w.onDay(t.Weekday())
+	}
+	if minute < w.End {
+		return w.onDay((t.Weekday() + 6) % 7)
+	}
+	return false
+}
+
+// onDay checks whether the window starts on the day of week
+func (w BandwidthWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
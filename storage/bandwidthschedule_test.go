@@ -0,0 +1,85 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBandwidthWindow_Contains(t *testing.T) {
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	office := BandwidthWindow{Days: weekdays, Start: 9 * 60, End: 18 * 60}
+	night := BandwidthWindow{Days: []time.Weekday{time.Friday}, Start: 22 * 60, End: 6 * 60}
+	allDay := BandwidthWindow{Start: 0, End: minutesPerDay}
+
+	// 2019-07-01 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2019, 7, day, hour, minute, 0, 0, time.Local)
+	}
+	tests := []struct {
+		window   BandwidthWindow
+		t        time.Time
+		contains bool
+	}{
+		{office, at(1, 9, 0), true},
+		{office, at(1, 17, 59), true},
+		{office, at(1, 18, 0), false},
+		{office, at(1, 8, 59), false},
+		{office, at(6, 12, 0), false},
+		{night, at(5, 23, 0), true},
+		{night, at(6, 5, 59), true},
+		{night, at(6, 6, 0), false},
+		{night, at(6, 23, 0), false},
+		{night, at(5, 5, 0), false},
+		{allDay, at(7, 0, 0), true},
+		{allDay, at(3, 23, 59), true},
+	}
+	for i, test := range tests {
+		if contains := test.window.Contains(test.t); contains != test.contains {
+			t.Errorf("test %d: window %+v at %v, expect %v, got %v", i, test.window, test.t, test.contains, contains)
+		}
+	}
+}
+
+func TestBandwidthSchedule_ActiveWindow(t *testing.T) {
+	schedule := BandwidthSchedule{
+		Windows: []BandwidthWindow{
+			{Start: 9 * 60, End: 18 * 60, MaxUploadSpeed: 1e6},
+			{Start: 0, End: minutesPerDay, MaxUploadSpeed: 2e6},
+		},
+	}
+	w, active := schedule.ActiveWindow(time.Date(2019, 7, 1, 10, 0, 0, 0, time.Local))
+	if !active || w.MaxUploadSpeed != 1e6 {
+		t.Errorf("the first window containing the time shall be active, got %+v, %v", w, active)
+	}
+	w, active = schedule.ActiveWindow(time.Date(2019, 7, 1, 20, 0, 0, 0, time.Local))
+	if !active || w.MaxUploadSpeed != 2e6 {
+		t.Errorf("the second window shall be active, got %+v, %v", w, active)
+	}
+	if _, active = (BandwidthSchedule{}).ActiveWindow(time.Now()); active {
+		t.Errorf("empty schedule shall have no active window")
+	}
+}
+
+func TestBandwidthSchedule_Validate(t *testing.T) {
+	tests := []struct {
+		window BandwidthWindow
+		valid  bool
+	}{
+		{BandwidthWindow{Start: 0, End: minutesPerDay}, true},
+		{BandwidthWindow{Start: 22 * 60, End: 6 * 60}, true},
+		{BandwidthWindow{Start: minutesPerDay, End: 60}, false},
+		{BandwidthWindow{Start: 0, End: minutesPerDay + 1}, false},
+		{BandwidthWindow{Start: 0, End: 60, MaxUploadSpeed: -1}, false},
+		{BandwidthWindow{Days: []time.Weekday{7}, Start: 0, End: 60}, false},
+	}
+	for i, test := range tests {
+		err := BandwidthSchedule{Windows: []BandwidthWindow{test.window}}.Validate()
+		if (err == nil) != test.valid {
+			t.Errorf("test %d: window %+v, expect valid %v, got error %v", i, test.window, test.valid, err)
+		}
+	}
+}
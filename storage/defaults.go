@@ -45,6 +45,9 @@ const (
 	HostCommitFailedMsg          = 0x27
 	HostAckMsg                   = 0x28
 	HostNegotiateErrorMsg        = 0x29
+	ContractDownloadBatchDataMsg = 0x2a
+	EphemeralAccountRespMsg      = 0x2b
+	EphemeralHostConfigRespMsg   = 0x2c
 
 	// Host Handle Message Set
 	HostConfigReqMsg                 = 0x30
@@ -57,6 +60,11 @@ const (
 	ClientCommitFailedMsg            = 0x37
 	ClientAckMsg                     = 0x38
 	ClientNegotiateErrorMsg          = 0x39
+	ContractDownloadBatchReqMsg      = 0x3a
+	EphemeralAccountFundReqMsg       = 0x3b
+	EphemeralAccountRefundReqMsg     = 0x3c
+	EphemeralDownloadReqMsg          = 0x3d
+	EphemeralHostConfigReqMsg        = 0x3e
 )
 
 const (
@@ -64,6 +72,40 @@ const (
 	RenewWindow = 12 * unit.BlocksPerHour
 )
 
+// Host announcement related constants
+const (
+	// HostProtocolVersion is the version of the storage protocol announced by the host
+	HostProtocolVersion = 1
+
+	// HostAnnouncementValidity is the number of blocks the host announcement stays valid
+	HostAnnouncementValidity = unit.BlocksPerMonth
+
+	// HostAnnouncementRenewWindow is the window before the expiration within which the host
+	// renews its announcement automatically
+	HostAnnouncementRenewWindow = unit.BlocksPerDay
+)
+
+// Host features announced in the host announcement, including the erasure codes and the
+// ciphers of the stored data, and the optional negotiations supported by the host
+const (
+	HostFeatureErasureStandard  = "erasure-standard"
+	HostFeatureErasureShard     = "erasure-shard"
+	HostFeatureCipherPlain      = "cipher-plain"
+	HostFeatureCipherTwofishGCM = "cipher-twofish-gcm"
+	HostFeatureDownloadBatch    = "download-batch"
+	HostFeatureEphemeralAccount = "ephemeral-account"
+)
+
+// DefaultHostFeatures is the features supported by the storage host
+var DefaultHostFeatures = []string{
+	HostFeatureErasureStandard,
+	HostFeatureErasureShard,
+	HostFeatureCipherPlain,
+	HostFeatureCipherTwofishGCM,
+	HostFeatureDownloadBatch,
+	HostFeatureEphemeralAccount,
+}
+
 // The block generation rate for Ethereum is 15s/block. Therefore, 240 blocks
 // can be generated in an hour
 var (
@@ -108,6 +150,22 @@ var (
 	DefaultContractPrice          = common.NewBigInt(1e2)
 )
 
+// Ephemeral account settings
+var (
+	// EphemeralAccountMaxBalance is the max balance an ephemeral account could hold in the host
+	EphemeralAccountMaxBalance = common.PtrBigInt(math.BigPow(10, 18)) // 1 DX
+)
+
+const (
+	// EphemeralAccountExpiry is the number of blocks an ephemeral account could stay inactive
+	// before expired. The balance of an expired account is forfeited to the host
+	EphemeralAccountExpiry = unit.BlocksPerWeek
+
+	// EphemeralWithdrawalWindow is the max number of blocks the expiry of a withdrawal could
+	// be ahead of the current block height
+	EphemeralWithdrawalWindow = unit.BlocksPerHour
+)
+
 const (
 	// ProofWindowSize is the window for storage host to submit a storage proof
 	ProofWindowSize = 12 * unit.BlocksPerHour
@@ -0,0 +1,40 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"errors"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// errWithdrawalSignerMismatch is returned if the withdrawal is not signed by the account owner
+var errWithdrawalSignerMismatch = errors.New("ephemeral withdrawal is not signed by the account owner")
+
+// RLPHash returns the hash of the withdrawal to be signed by the account owner
+func (w EphemeralWithdrawal) RLPHash() common.Hash {
+	data, _ := rlp.EncodeToBytes([]interface{}{
+		w.Account,
+		w.Host,
+		w.Amount,
+		w.Expiry,
+		w.Nonce,
+	})
+	return crypto.Keccak256Hash(data)
+}
+
+// VerifySignature checks whether the withdrawal is signed by the account owner
+func (w EphemeralWithdrawal) VerifySignature() error {
+	pubKey, err := crypto.SigToPub(w.RLPHash().Bytes(), w.Signature)
+	if err != nil {
+		return err
+	}
+	if crypto.PubkeyToAddress(*pubKey) != w.Account {
+		return errWithdrawalSignerMismatch
+	}
+	return nil
+}
@@ -33,6 +33,14 @@ type Peer interface {
 	SendUploadHostRevisionSign(revisionSign []byte) error
 	RequestContractDownload(req DownloadRequest) error
 	SendContractDownloadData(resp DownloadResponse) error
+	RequestContractDownloadBatch(req DownloadBatchRequest) error
+	SendContractDownloadBatchData(resp DownloadBatchResponse) error
+	RequestEphemeralAccountFund(req EphemeralAccountFundRequest) error
+	RequestEphemeralAccountRefund(req EphemeralAccountRefundRequest) error
+	SendEphemeralAccountResponse(resp EphemeralAccountResponse) error
+	RequestEphemeralDownload(req EphemeralDownloadRequest) error
+	RequestEphemeralHostConfig(req EphemeralHostConfigRequest) error
+	SendEphemeralHostConfig(resp EphemeralHostConfigResponse) error
 	SendHostBusyHandleRequestErr() error
 	SendClientNegotiateErrorMsg() error
 	SendClientCommitFailedMsg() error
@@ -0,0 +1,55 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FilePriority is the scheduling priority of the uploads and downloads of a DxFile.
+// The segments of the files with higher priority are uploaded and downloaded first
+type FilePriority uint8
+
+// The scheduling priority levels of a DxFile. The zero value is not a valid priority
+const (
+	PriorityLow FilePriority = iota + 1
+	PriorityNormal
+	PriorityHigh
+)
+
+// ParseFilePriority parse the file priority from the string, which is one of
+// low, normal, and high
+func ParseFilePriority(s string) (FilePriority, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low":
+		return PriorityLow, nil
+	case "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	default:
+		return 0, fmt.Errorf("unknown priority %v, expect low, normal, or high", s)
+	}
+}
+
+// Valid checks whether the file priority is a known priority level
+func (p FilePriority) Valid() bool {
+	return p >= PriorityLow && p <= PriorityHigh
+}
+
+// String returns the string representation of the file priority
+func (p FilePriority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(p))
+	}
+}
@@ -7,6 +7,7 @@ package storage
 import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/p2p/enode"
 	"math/big"
 )
 
@@ -75,4 +76,96 @@ type (
 		Data        []byte
 		MerkleProof []common.Hash
 	}
+
+	// DownloadBatchRequest contains the request parameters for downloading multiple
+	// sector ranges paid by a single contract revision.
+	DownloadBatchRequest struct {
+		StorageContractID common.Hash
+		Sectors           []DownloadRequestSector
+		MerkleProof       bool
+
+		NewRevisionNumber    uint64
+		NewValidProofValues  []*big.Int
+		NewMissedProofValues []*big.Int
+		Signature            []byte
+	}
+
+	// DownloadBatchResponse contains the response data of a single sector range in
+	// DownloadBatchRequest. The host streams one response per requested range in the
+	// request order, and the host signature of the revision is carried by the first one.
+	DownloadBatchResponse struct {
+		Index       uint32
+		Signature   []byte
+		Data        []byte
+		MerkleProof []common.Hash
+	}
+
+	// EphemeralAccountFundRequest contains the request parameters for funding the ephemeral
+	// account in the host with a single contract revision. The ephemeral account is identified
+	// by the Account address, or by the client address of the contract if Account is empty, so
+	// that the client could prepay the downloads of the storage clients without contracts
+	EphemeralAccountFundRequest struct {
+		StorageContractID common.Hash
+		Account           common.Address
+		Amount            *big.Int
+
+		NewRevisionNumber    uint64
+		NewValidProofValues  []*big.Int
+		NewMissedProofValues []*big.Int
+		Signature            []byte
+	}
+
+	// EphemeralAccountRefundRequest contains the request parameters for refunding the balance
+	// withdrawn from the ephemeral account back to the client with a contract revision
+	EphemeralAccountRefundRequest struct {
+		Withdrawal        EphemeralWithdrawal
+		StorageContractID common.Hash
+
+		NewRevisionNumber    uint64
+		NewValidProofValues  []*big.Int
+		NewMissedProofValues []*big.Int
+		Signature            []byte
+	}
+
+	// EphemeralAccountResponse is the host response for the ephemeral account fund and refund
+	// requests. It contains the host signature of the revision and the account balance after
+	// the request is committed
+	EphemeralAccountResponse struct {
+		Signature []byte
+		Balance   *big.Int
+	}
+
+	// EphemeralWithdrawal is the message signed by the ephemeral account owner to draw down the
+	// account balance. Nonce shall be strictly increasing for each account to prevent replay, and
+	// the withdrawal is no longer valid after the Expiry block height. Host is the enode ID of the
+	// host the withdrawal is made to, so that it could not be replayed to other hosts
+	EphemeralWithdrawal struct {
+		Account   common.Address
+		Host      enode.ID
+		Amount    *big.Int
+		Expiry    uint64
+		Nonce     uint64
+		Signature []byte
+	}
+
+	// EphemeralDownloadRequest contains the request parameters for downloading sector ranges
+	// paid by the ephemeral account. Data are streamed back with DownloadBatchResponse
+	EphemeralDownloadRequest struct {
+		Withdrawal  EphemeralWithdrawal
+		Sectors     []DownloadRequestSector
+		MerkleProof bool
+	}
+
+	// EphemeralHostConfigRequest contains the request parameters for requesting the host
+	// config paid by the ephemeral account
+	EphemeralHostConfigRequest struct {
+		Withdrawal EphemeralWithdrawal
+	}
+
+	// EphemeralHostConfigResponse contains the host config and the ephemeral account balance
+	// after the withdrawal
+	EphemeralHostConfigResponse struct {
+		Config  HostExtConfig
+		Balance *big.Int
+	}
 )
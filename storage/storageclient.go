@@ -39,6 +39,8 @@ type EthBackend interface {
 	SetStatic(node *enode.Node)
 	CheckAndUpdateConnection(peerNode *enode.Node)
 	SelfEnodeURL() string
+	SignWithNodeSk(hash []byte) ([]byte, error)
+	DecryptWithNodeSk(ct []byte) ([]byte, error)
 }
 
 // ClientBackend is an interface that used to provide necessary functions
@@ -58,6 +60,7 @@ type ClientBackend interface {
 	GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error)
 	SendStorageContractCreateTx(clientAddr common.Address, input []byte) (common.Hash, error)
 	GetHostAnnouncementWithBlockHash(blockHash common.Hash) (hostAnnouncements []types.HostAnnouncement, number uint64, errGet error)
+	GetHostRetirementWithBlockHash(blockHash common.Hash) (hostRetirements []types.HostRetirement, number uint64, errGet error)
 	GetPaymentAddress() (common.Address, error)
 	TryToRenewOrRevise(hostID enode.ID) bool
 	RevisionOrRenewingDone(hostID enode.ID)
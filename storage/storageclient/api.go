@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/DxChainNetwork/godx/common/unit"
 
@@ -127,8 +128,9 @@ func (api *PublicStorageClientAPI) DownloadSync(remoteFilePath, localPath string
 	return "File downloaded successfully", nil
 }
 
-// Upload their local files to hosts made contract with
-func (api *PublicStorageClientAPI) Upload(source string, dxPath string) (string, error) {
+// Upload their local files to hosts made contract with. The optional cipher specifies the
+// cipher used to encrypt the file, which is twofish-gcm or xchacha20
+func (api *PublicStorageClientAPI) Upload(source string, dxPath string, cipher *string) (string, error) {
 	path, err := storage.NewDxPath(dxPath)
 	if err != nil {
 		return "", err
@@ -138,12 +140,25 @@ func (api *PublicStorageClientAPI) Upload(source string, dxPath string) (string,
 		DxPath: path,
 		Mode:   storage.Override,
 	}
+	if cipher != nil && *cipher != "" {
+		code, exist := uploadCiphers[strings.ToLower(*cipher)]
+		if !exist {
+			return "", fmt.Errorf("cipher %s not supported, expect twofish-gcm or xchacha20", *cipher)
+		}
+		param.CipherCode = code
+	}
 	if err := api.sc.Upload(param); err != nil {
 		return "", err
 	}
 	return "success", nil
 }
 
+// SyncFolders returns the status of the sync folders, including the files pending to be
+// synced and the files failed to be synced
+func (api *PublicStorageClientAPI) SyncFolders() []storage.SyncFolderStatus {
+	return api.sc.SyncFolderStatus()
+}
+
 // GetRenewWindow return the renew window value
 func (api *PublicStorageClientAPI) GetRenewWindow() string {
 	return unit.FormatTime(storage.RenewWindow)
@@ -209,6 +224,171 @@ func (api *PrivateStorageClientAPI) PeriodCost() storage.PeriodCost {
 	return api.sc.contractManager.RetrievePeriodCost()
 }
 
+// MigrateHost will start to migrate the data stored in the storage host to the replacement
+// storage hosts. The contract signed with the storage host will be canceled
+func (api *PrivateStorageClientAPI) MigrateHost(id string) (resp string, err error) {
+	var enodeid enode.ID
+
+	// convert the hex string back to the enode.ID type
+	idSlice, err := hex.DecodeString(id)
+	if err != nil {
+		return "", errors.New("the hostID provided is not valid")
+	}
+	copy(enodeid[:], idSlice)
+
+	if err = api.sc.MigrateHost(enodeid); err != nil {
+		return "", fmt.Errorf("failed to migrate the storage host: %s", err.Error())
+	}
+
+	resp = fmt.Sprintf("Successfully started migrating data away from the storage host %v", id)
+	return
+}
+
+// Share will create the share token of the file, with which the file could be downloaded by
+// other storage clients. If the recipient enode url is provided, only the recipient node is
+// able to decrypt the file. If prepay is true, the downloads from the storage hosts are prepaid
+// so that the file could be downloaded by the storage clients without contracts with the hosts
+func (api *PrivateStorageClientAPI) Share(filePath string, recipient string, prepay bool) (string, error) {
+	path, err := storage.NewDxPath(filePath)
+	if err != nil {
+		return "", err
+	}
+	token, err := api.sc.ShareFile(path, recipient, prepay)
+	if err != nil {
+		return "", fmt.Errorf("failed to share the file: %s", err.Error())
+	}
+	return token, nil
+}
+
+// DownloadShared will download the file shared by the share token to the local path. It blocks
+// until the download is finished
+func (api *PrivateStorageClientAPI) DownloadShared(token string, localPath string) (string, error) {
+	if err := api.sc.DownloadSharedSync(token, localPath); err != nil {
+		return "【ERROR】failed to download", err
+	}
+	return "File downloaded successfully", nil
+}
+
+// SetPriority will set the scheduling priority of the file, or all files under the directory.
+// The priority is one of low, normal, and high
+func (api *PrivateStorageClientAPI) SetPriority(path string, priority string) (string, error) {
+	dxPath, err := scheduledDxPath(path)
+	if err != nil {
+		return "", err
+	}
+	p, err := storage.ParseFilePriority(priority)
+	if err != nil {
+		return "", err
+	}
+	if err = api.sc.SetPriority(dxPath, p); err != nil {
+		return "", fmt.Errorf("failed to set the priority: %s", err.Error())
+	}
+	return fmt.Sprintf("Priority of %s set to %s", path, p), nil
+}
+
+// PauseUploads will pause the upload and repair of the file, or all files under the directory
+func (api *PrivateStorageClientAPI) PauseUploads(path string) (string, error) {
+	dxPath, err := scheduledDxPath(path)
+	if err != nil {
+		return "", err
+	}
+	if err = api.sc.PauseUploads(dxPath); err != nil {
+		return "", fmt.Errorf("failed to pause the uploads: %s", err.Error())
+	}
+	return fmt.Sprintf("Uploads of %s paused", path), nil
+}
+
+// ResumeUploads will resume the upload and repair of the file, or all files under the directory
+func (api *PrivateStorageClientAPI) ResumeUploads(path string) (string, error) {
+	dxPath, err := scheduledDxPath(path)
+	if err != nil {
+		return "", err
+	}
+	if err = api.sc.ResumeUploads(dxPath); err != nil {
+		return "", fmt.Errorf("failed to resume the uploads: %s", err.Error())
+	}
+	return fmt.Sprintf("Uploads of %s resumed", path), nil
+}
+
+// CancelUploads will delete the file, or all files under the directory, which have not
+// finished uploading. Return the paths of the canceled files
+func (api *PrivateStorageClientAPI) CancelUploads(path string) ([]string, error) {
+	dxPath, err := scheduledDxPath(path)
+	if err != nil {
+		return nil, err
+	}
+	canceled, err := api.sc.CancelUploads(dxPath)
+	paths := make([]string, 0, len(canceled))
+	for _, c := range canceled {
+		paths = append(paths, c.Path)
+	}
+	if err != nil {
+		return paths, fmt.Errorf("failed to cancel the uploads: %s", err.Error())
+	}
+	return paths, nil
+}
+
+// EnableMasterKey will enable the master key derived from the passphrase, which wraps the
+// cipher keys of all files
+func (api *PrivateStorageClientAPI) EnableMasterKey(passphrase string) (string, error) {
+	if err := api.sc.EnableMasterKey(passphrase); err != nil {
+		return "", fmt.Errorf("failed to enable the master key: %s", err.Error())
+	}
+	return "Master key enabled", nil
+}
+
+// UnlockMasterKey will unlock the master key with the passphrase, so that the files could
+// be uploaded and downloaded
+func (api *PrivateStorageClientAPI) UnlockMasterKey(passphrase string) (string, error) {
+	if err := api.sc.UnlockMasterKey(passphrase); err != nil {
+		return "", fmt.Errorf("failed to unlock the master key: %s", err.Error())
+	}
+	return "Master key unlocked", nil
+}
+
+// RotateMasterKey will replace the master key with the one derived from the new passphrase,
+// and re-wrap the cipher keys of all files
+func (api *PrivateStorageClientAPI) RotateMasterKey(passphrase, newPassphrase string) (string, error) {
+	if err := api.sc.RotateMasterKey(passphrase, newPassphrase); err != nil {
+		return "", fmt.Errorf("failed to rotate the master key: %s", err.Error())
+	}
+	return "Master key rotated", nil
+}
+
+// AddSyncFolder will start mirroring the local directory to the dxPath. The deletion of the
+// local files is propagated according to the policy, which is one of keep and delete
+func (api *PrivateStorageClientAPI) AddSyncFolder(localDir, dxPath, policy string) (string, error) {
+	path, err := storage.NewDxPath(dxPath)
+	if err != nil {
+		return "", err
+	}
+	p, err := storage.ParseSyncDeletePolicy(policy)
+	if err != nil {
+		return "", err
+	}
+	if err = api.sc.AddSyncFolder(localDir, path, p); err != nil {
+		return "", fmt.Errorf("failed to add the sync folder: %s", err.Error())
+	}
+	return fmt.Sprintf("%s is synced to %s", localDir, dxPath), nil
+}
+
+// RemoveSyncFolder will stop mirroring the local directory. The synced files are kept
+func (api *PrivateStorageClientAPI) RemoveSyncFolder(localDir string) (string, error) {
+	if err := api.sc.RemoveSyncFolder(localDir); err != nil {
+		return "", fmt.Errorf("failed to remove the sync folder: %s", err.Error())
+	}
+	return fmt.Sprintf("%s is no longer synced", localDir), nil
+}
+
+// scheduledDxPath returns the DxPath of the file or directory to be scheduled. Empty path
+// or "/" is regarded as the root directory
+func scheduledDxPath(path string) (storage.DxPath, error) {
+	if strings.Trim(path, "/") == "" {
+		return storage.RootDxPath(), nil
+	}
+	return storage.NewDxPath(path)
+}
+
 // CancelAllContracts will cancel all contracts signed with storage client by
 // marking all active contracts as canceled, not good for uploading, and not good
 // for renewing
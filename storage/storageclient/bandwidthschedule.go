@@ -0,0 +1,85 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"time"
+)
+
+// bandwidthLimits returns the download and upload speed limits at the time t. The limits of
+// the bandwidth schedule window containing t are returned, or the default limits otherwise
+func (client *StorageClient) bandwidthLimits(t time.Time) (downloadSpeedLimit, uploadSpeedLimit int64) {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	if w, active := client.persist.BandwidthSchedule.ActiveWindow(t); active {
+		return w.MaxDownloadSpeed, w.MaxUploadSpeed
+	}
+	return client.persist.MaxDownloadSpeed, client.persist.MaxUploadSpeed
+}
+
+// applyBandwidthSchedule sets the bandwidth limits scheduled at the time t to the rate limit
+// of the contracts, if changed
+func (client *StorageClient) applyBandwidthSchedule(t time.Time) error {
+	downloadSpeedLimit, uploadSpeedLimit := client.bandwidthLimits(t)
+	curDownload, curUpload, _ := client.contractManager.RetrieveRateLimit()
+	if downloadSpeedLimit == curDownload && uploadSpeedLimit == curUpload {
+		return nil
+	}
+	client.log.Info("Applying the scheduled bandwidth limits", "download", downloadSpeedLimit, "upload", uploadSpeedLimit)
+	return client.setBandwidthLimits(downloadSpeedLimit, uploadSpeedLimit)
+}
+
+// uploadAllowed checks whether the repair loops are allowed to upload at the time t. Uploading
+// is not allowed if the master key is not unlocked. If the bandwidth schedule restricts
+// uploading within the windows, only the time within a window is allowed
+func (client *StorageClient) uploadAllowed(t time.Time) bool {
+	if client.masterKeyLocked() {
+		return false
+	}
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	schedule := client.persist.BandwidthSchedule
+	if !schedule.UploadOnlyInWindow {
+		return true
+	}
+	_, active := schedule.ActiveWindow(t)
+	return active
+}
+
+// bandwidthScheduleLoop periodically applies the bandwidth limits of the bandwidth schedule
+func (client *StorageClient) bandwidthScheduleLoop() {
+	err := client.tm.Add()
+	if err != nil {
+		return
+	}
+	defer client.tm.Done()
+
+	for {
+		select {
+		case <-client.tm.StopChan():
+			return
+		case <-time.After(BandwidthScheduleCheckInterval):
+		}
+
+		if err := client.applyBandwidthSchedule(time.Now()); err != nil {
+			client.log.Warn("failed to apply the scheduled bandwidth limits", "err", err)
+		}
+	}
+}
+
+// blockUntilUploadAllowed blocks the repair loops until uploading is allowed by the master key
+// and the bandwidth schedule. Return false if the storage client is stopped
+func (client *StorageClient) blockUntilUploadAllowed() bool {
+	for !client.uploadAllowed(time.Now()) {
+		select {
+		case <-client.tm.StopChan():
+			return false
+		case <-time.After(BandwidthScheduleCheckInterval):
+		}
+	}
+	return true
+}
@@ -6,6 +6,9 @@ package storageclient
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/unit"
@@ -75,6 +78,75 @@ func parseClientSetting(settings map[string]string, prevSetting storage.ClientSe
 			}
 			clientSetting.MaxDownloadSpeed = downloadSpeed
 
+		case key == "regions":
+			clientSetting.HostPolicy.Regions = parseRegions(value)
+
+		case key == "maxhostsperoperator":
+			var maxHosts uint64
+			maxHosts, err = unit.ParseUint64(value, 1, "")
+			if err != nil {
+				err = fmt.Errorf("failed to parse the max hosts per operator: %s", err.Error())
+				break
+			}
+			clientSetting.HostPolicy.MaxHostsPerOperator = maxHosts
+
+		case key == "maxhostsperregion":
+			var maxHosts uint64
+			maxHosts, err = unit.ParseUint64(value, 1, "")
+			if err != nil {
+				err = fmt.Errorf("failed to parse the max hosts per region: %s", err.Error())
+				break
+			}
+			clientSetting.HostPolicy.MaxHostsPerRegion = maxHosts
+
+		case key == "minuptime":
+			var uptime float64
+			uptime, err = parseUptime(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the min uptime: %s", err.Error())
+				break
+			}
+			clientSetting.HostPolicy.MinUptime = uptime
+
+		case key == "maxstorageprice" || key == "maxuploadprice" || key == "maxdownloadprice" || key == "maxcontractprice":
+			var price common.BigInt
+			price, err = unit.ParseCurrency(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the %s value: %s", key, err.Error())
+				break
+			}
+			setPriceCeiling(&clientSetting.HostPolicy, key, price)
+
+		case key == "evaluator":
+			clientSetting.Evaluator = strings.TrimSpace(value)
+
+		case key == "weights":
+			var weights storage.EvaluationWeights
+			weights, err = parseEvaluationWeights(value, clientSetting.EvaluationWeights)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the evaluation weights: %s", err.Error())
+				break
+			}
+			clientSetting.EvaluationWeights = weights
+
+		case key == "bandwidthschedule":
+			var windows []storage.BandwidthWindow
+			windows, err = parseBandwidthWindows(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the bandwidth schedule: %s", err.Error())
+				break
+			}
+			clientSetting.BandwidthSchedule.Windows = windows
+
+		case key == "uploadonlyinwindow":
+			var status bool
+			status, err = unit.ParseBool(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the upload only in window: %s", err.Error())
+				break
+			}
+			clientSetting.BandwidthSchedule.UploadOnlyInWindow = status
+
 		default:
 			err = fmt.Errorf("the key entered: %s is not valid. Here is a list of available keys: %+v",
 				key, keys)
@@ -90,6 +162,213 @@ func parseClientSetting(settings map[string]string, prevSetting storage.ClientSe
 	return
 }
 
+// parseRegions will parse the comma separated regions into the region allow-list. Empty
+// value clears the region allow-list
+func parseRegions(value string) (regions []string) {
+	for _, region := range strings.Split(value, ",") {
+		if region = strings.TrimSpace(region); region != "" {
+			regions = append(regions, region)
+		}
+	}
+	return
+}
+
+// parseUptime will parse the uptime rate, which is either a fraction ranged from 0 to 1,
+// or a percentage ends with %
+func parseUptime(value string) (uptime float64, err error) {
+	value = strings.TrimSpace(value)
+	percentage := strings.HasSuffix(value, "%")
+	if uptime, err = strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64); err != nil {
+		return
+	}
+	if percentage {
+		uptime /= 100
+	}
+	if uptime < 0 || uptime > 1 {
+		err = fmt.Errorf("the uptime %s must be ranged from 0 to 1, or from 0%% to 100%%", value)
+	}
+	return
+}
+
+// setPriceCeiling will set the price ceiling of the host policy specified by the key
+func setPriceCeiling(policy *storage.HostPolicySetting, key string, price common.BigInt) {
+	switch key {
+	case "maxstorageprice":
+		policy.MaxStoragePrice = price
+	case "maxuploadprice":
+		policy.MaxUploadPrice = price
+	case "maxdownloadprice":
+		policy.MaxDownloadPrice = price
+	case "maxcontractprice":
+		policy.MaxContractPrice = price
+	}
+}
+
+// parseEvaluationWeights will parse the comma separated factor:weight pairs, for example
+// "deposit:0.5,downloadprice:2", and apply them to the previous evaluation weights
+func parseEvaluationWeights(value string, prevWeights storage.EvaluationWeights) (weights storage.EvaluationWeights, err error) {
+	weights = prevWeights
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		fields := strings.Split(pair, ":")
+		if len(fields) != 2 {
+			err = fmt.Errorf("invalid factor weight %s, expect the format factor:weight", pair)
+			return
+		}
+		var weight float64
+		if weight, err = strconv.ParseFloat(strings.TrimSpace(fields[1]), 64); err != nil {
+			return
+		}
+		if err = setEvaluationWeight(&weights, strings.ToLower(strings.TrimSpace(fields[0])), weight); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// setEvaluationWeight will set the weight of the evaluation factor
+func setEvaluationWeight(weights *storage.EvaluationWeights, factor string, weight float64) error {
+	switch factor {
+	case "presence":
+		weights.Presence = weight
+	case "deposit":
+		weights.Deposit = weight
+	case "interaction":
+		weights.Interaction = weight
+	case "contractprice":
+		weights.ContractPrice = weight
+	case "storageremaining":
+		weights.StorageRemaining = weight
+	case "uptime":
+		weights.Uptime = weight
+	case "uploadprice":
+		weights.UploadPrice = weight
+	case "downloadprice":
+		weights.DownloadPrice = weight
+	case "performance":
+		weights.Performance = weight
+	default:
+		return fmt.Errorf("the evaluation factor %s is not valid, available factors are: %v", factor, evaluationFactors)
+	}
+	return nil
+}
+
+// parseBandwidthWindows will parse the semicolon separated bandwidth windows, for example
+// "mon-fri 09:00-18:00 1Mbps 5Mbps; sat,sun 00:00-24:00 0 0". Each window consists of the
+// days of week, the time range in local time, the max upload speed and the max download speed,
+// where * means every day and 0 means unlimited. Empty value clears the bandwidth windows
+func parseBandwidthWindows(value string) (windows []storage.BandwidthWindow, err error) {
+	for _, str := range strings.Split(value, ";") {
+		fields := strings.Fields(str)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			err = fmt.Errorf("invalid bandwidth window %s, expect the format: days start-end uploadspeed downloadspeed", strings.TrimSpace(str))
+			return
+		}
+		var w storage.BandwidthWindow
+		if w.Days, err = parseWeekdays(fields[0]); err != nil {
+			return
+		}
+		if w.Start, w.End, err = parseTimeRange(fields[1]); err != nil {
+			return
+		}
+		if w.MaxUploadSpeed, err = parseScheduledSpeed(fields[2]); err != nil {
+			return
+		}
+		if w.MaxDownloadSpeed, err = parseScheduledSpeed(fields[3]); err != nil {
+			return
+		}
+		windows = append(windows, w)
+	}
+	return
+}
+
+// parseWeekdays will parse the comma separated days of week, each is a day or a range of
+// days such as mon-fri. * means every day, which is represented by empty days
+func parseWeekdays(value string) (days []time.Weekday, err error) {
+	if value == "*" {
+		return nil, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		bounds := strings.Split(item, "-")
+		if len(bounds) > 2 {
+			return nil, fmt.Errorf("invalid days of week %s", item)
+		}
+		var first, last time.Weekday
+		if first, err = parseWeekday(bounds[0]); err != nil {
+			return
+		}
+		last = first
+		if len(bounds) == 2 {
+			if last, err = parseWeekday(bounds[1]); err != nil {
+				return
+			}
+		}
+		// the range could wrap around the week, such as fri-mon
+		for day := first; ; day = (day + 1) % 7 {
+			days = append(days, day)
+			if day == last {
+				break
+			}
+		}
+	}
+	return
+}
+
+// parseWeekday will parse the three letter abbreviation of the day of week
+func parseWeekday(value string) (time.Weekday, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if value == strings.ToLower(day.String()[:3]) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid day of week %s, expect one of sun, mon, tue, wed, thu, fri, sat", value)
+}
+
+// parseTimeRange will parse the time range in format HH:MM-HH:MM into the minutes from the
+// midnight. The end of the range could be 24:00
+func parseTimeRange(value string) (start, end uint32, err error) {
+	bounds := strings.Split(value, "-")
+	if len(bounds) != 2 {
+		err = fmt.Errorf("invalid time range %s, expect the format HH:MM-HH:MM", value)
+		return
+	}
+	if start, err = parseClockTime(bounds[0]); err != nil {
+		return
+	}
+	if start == 24*60 {
+		err = fmt.Errorf("the start of time range %s must be earlier than 24:00", value)
+		return
+	}
+	end, err = parseClockTime(bounds[1])
+	return
+}
+
+// parseClockTime will parse the clock time in format HH:MM into the minutes from the midnight
+func parseClockTime(value string) (uint32, error) {
+	var hour, minute uint32
+	if _, err := fmt.Sscanf(value, "%d:%d", &hour, &minute); err != nil {
+		return 0, fmt.Errorf("invalid time %s, expect the format HH:MM", value)
+	}
+	if minute >= 60 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid time %s", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// parseScheduledSpeed will parse the speed limit of a bandwidth window, where 0 means unlimited
+func parseScheduledSpeed(value string) (int64, error) {
+	if value == "0" {
+		return 0, nil
+	}
+	return unit.ParseSpeed(value)
+}
+
 // parseStorageHosts will parse the string version of storage hosts into uint64 type
 func parseStorageHosts(hosts string) (parsed uint64, err error) {
 	return unit.ParseUint64(hosts, 1, "")
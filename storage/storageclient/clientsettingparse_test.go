@@ -7,6 +7,7 @@ package storageclient
 import (
 	"fmt"
 	"math/rand"
+	"reflect"
 	"testing"
 	"time"
 
@@ -73,6 +74,109 @@ func TestParseStorageHosts(t *testing.T) {
 	}
 }
 
+func TestParseUptime(t *testing.T) {
+	var tables = []struct {
+		uptime string
+		parsed float64
+		err    bool
+	}{
+		{"0.95", 0.95, false},
+		{"95%", 0.95, false},
+		{"100%", 1, false},
+		{"1.5", 0, true},
+		{"-10%", 0, true},
+		{"high", 0, true},
+	}
+
+	for _, table := range tables {
+		result, err := parseUptime(table.uptime)
+		if table.err {
+			if err == nil {
+				t.Errorf("parsing %s shall return error", table.uptime)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed to parse the uptime: %s", err.Error())
+		}
+		if result != table.parsed {
+			t.Errorf("by using %s as input, expected parsed value %v, got %v",
+				table.uptime, table.parsed, result)
+		}
+	}
+}
+
+func TestParseRegions(t *testing.T) {
+	if regions := parseRegions(" US,de ,, JP"); !reflect.DeepEqual(regions, []string{"US", "de", "JP"}) {
+		t.Errorf("unexpected regions parsed: %v", regions)
+	}
+	if regions := parseRegions(""); len(regions) != 0 {
+		t.Errorf("empty value shall clear the regions, got %v", regions)
+	}
+}
+
+func TestParseEvaluationWeights(t *testing.T) {
+	prev := storage.EvaluationWeights{Presence: 1, Deposit: 1}
+	weights, err := parseEvaluationWeights("deposit:0.5, DownloadPrice:2,performance:1", prev)
+	if err != nil {
+		t.Fatalf("failed to parse the evaluation weights: %s", err.Error())
+	}
+	expected := storage.EvaluationWeights{Presence: 1, Deposit: 0.5, DownloadPrice: 2, Performance: 1}
+	if weights != expected {
+		t.Errorf("expected weights %+v, got %+v", expected, weights)
+	}
+
+	for _, value := range []string{"deposit", "deposit:high", "latency:1"} {
+		if _, err := parseEvaluationWeights(value, prev); err == nil {
+			t.Errorf("parsing %s shall return error", value)
+		}
+	}
+}
+
+func TestParseBandwidthWindows(t *testing.T) {
+	windows, err := parseBandwidthWindows("mon-fri 09:00-18:00 1Mbps 5Mbps; sat,sun 22:30-06:00 0 100kbps;")
+	if err != nil {
+		t.Fatalf("failed to parse the bandwidth windows: %s", err.Error())
+	}
+	expected := []storage.BandwidthWindow{
+		{
+			Days:             []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			Start:            9 * 60,
+			End:              18 * 60,
+			MaxUploadSpeed:   1e6,
+			MaxDownloadSpeed: 5e6,
+		},
+		{
+			Days:             []time.Weekday{time.Saturday, time.Sunday},
+			Start:            22*60 + 30,
+			End:              6 * 60,
+			MaxDownloadSpeed: 1e5,
+		},
+	}
+	if !reflect.DeepEqual(windows, expected) {
+		t.Errorf("expected windows %+v, got %+v", expected, windows)
+	}
+
+	if windows, err = parseBandwidthWindows("fri-mon 00:00-24:00 0 0"); err != nil {
+		t.Fatalf("failed to parse the bandwidth windows: %s", err.Error())
+	}
+	days := []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday}
+	if !reflect.DeepEqual(windows[0].Days, days) || windows[0].End != 24*60 {
+		t.Errorf("unexpected window parsed: %+v", windows[0])
+	}
+
+	if windows, err = parseBandwidthWindows(""); err != nil || len(windows) != 0 {
+		t.Errorf("empty value shall clear the windows, got %v, %v", windows, err)
+	}
+
+	for _, value := range []string{"mon 09:00-18:00 1Mbps", "someday 09:00-18:00 0 0", "mon 09:00 0 0",
+		"mon 24:00-01:00 0 0", "mon 09:60-18:00 0 0", "mon 09:00-18:00 1 0"} {
+		if _, err := parseBandwidthWindows(value); err == nil {
+			t.Errorf("parsing %s shall return error", value)
+		}
+	}
+}
+
 func randomSettings() (settings map[string]string, err error) {
 	var keys map[string]string
 
@@ -139,6 +243,38 @@ func randomValue(selectedKeys map[string]string) (settings map[string]string, er
 			value = rand.Int63()
 			granularity = unit.SpeedUnit[rand.Intn(len(unit.SpeedUnit))]
 			break
+		case key == "regions":
+			value = "CN, JP"
+			granularity = ""
+			break
+		case key == "maxhostsperoperator" || key == "maxhostsperregion":
+			value = rand.Uint64()
+			granularity = ""
+			break
+		case key == "minuptime":
+			value = rand.Intn(101)
+			granularity = "%"
+			break
+		case key == "maxstorageprice" || key == "maxuploadprice" || key == "maxdownloadprice" || key == "maxcontractprice":
+			value = common.RandomBigInt()
+			granularity = unit.CurrencyUnit[rand.Intn(len(unit.CurrencyUnit))]
+			break
+		case key == "evaluator":
+			value = "weighted"
+			granularity = ""
+			break
+		case key == "weights":
+			value = fmt.Sprintf("deposit:%v", rand.Float64())
+			granularity = ""
+			break
+		case key == "bandwidthschedule":
+			value = fmt.Sprintf("mon-fri %02d:00-18:00 %v0kbps 0", rand.Intn(18), rand.Intn(10))
+			granularity = ""
+			break
+		case key == "uploadonlyinwindow":
+			value = rand.Intn(2) == 0
+			granularity = ""
+			break
 		default:
 			err = fmt.Errorf("the key received is not valid: %s", key)
 			return
@@ -181,6 +317,42 @@ func clientSettingValidation(key string, prevSetting storage.ClientSetting, curr
 	case "downloadspeed":
 		valid = currentSetting.MaxDownloadSpeed == prevSetting.MaxDownloadSpeed
 		return
+	case "regions":
+		valid = reflect.DeepEqual(currentSetting.HostPolicy.Regions, prevSetting.HostPolicy.Regions)
+		return
+	case "maxhostsperoperator":
+		valid = currentSetting.HostPolicy.MaxHostsPerOperator == prevSetting.HostPolicy.MaxHostsPerOperator
+		return
+	case "maxhostsperregion":
+		valid = currentSetting.HostPolicy.MaxHostsPerRegion == prevSetting.HostPolicy.MaxHostsPerRegion
+		return
+	case "minuptime":
+		valid = currentSetting.HostPolicy.MinUptime == prevSetting.HostPolicy.MinUptime
+		return
+	case "maxstorageprice":
+		valid = currentSetting.HostPolicy.MaxStoragePrice.IsEqual(prevSetting.HostPolicy.MaxStoragePrice)
+		return
+	case "maxuploadprice":
+		valid = currentSetting.HostPolicy.MaxUploadPrice.IsEqual(prevSetting.HostPolicy.MaxUploadPrice)
+		return
+	case "maxdownloadprice":
+		valid = currentSetting.HostPolicy.MaxDownloadPrice.IsEqual(prevSetting.HostPolicy.MaxDownloadPrice)
+		return
+	case "maxcontractprice":
+		valid = currentSetting.HostPolicy.MaxContractPrice.IsEqual(prevSetting.HostPolicy.MaxContractPrice)
+		return
+	case "evaluator":
+		valid = currentSetting.Evaluator == prevSetting.Evaluator
+		return
+	case "weights":
+		valid = currentSetting.EvaluationWeights == prevSetting.EvaluationWeights
+		return
+	case "bandwidthschedule":
+		valid = reflect.DeepEqual(currentSetting.BandwidthSchedule.Windows, prevSetting.BandwidthSchedule.Windows)
+		return
+	case "uploadonlyinwindow":
+		valid = currentSetting.BandwidthSchedule.UploadOnlyInWindow == prevSetting.BandwidthSchedule.UploadOnlyInWindow
+		return
 	default:
 		err = fmt.Errorf("the provided key is invalid: %s", key)
 		return
@@ -82,6 +82,11 @@ func (cm *ContractManager) resumeContracts() (err error) {
 
 	// look through all contracts, resume them by updating their status
 	for _, id := range ids {
+		// the contract whose data are being migrated will not be resumed
+		if cm.isMigrating(id) {
+			continue
+		}
+
 		contract, exists := cm.activeContracts.Acquire(id)
 
 		if !exists {
@@ -313,14 +318,69 @@ func (cm *ContractManager) maintainContractStatus(hostsAmount int) (err error) {
 	// it will be marked as not good for upload or download
 	evalBaseline := cm.calculateMinEvaluation(hosts)
 
-	// update the contract status
-	for _, contract := range cm.activeContracts.RetrieveAllContractsMetaData() {
+	// update the contract status. The contracts signed earlier have the priority to be kept
+	// under the host selection policy
+	contracts := cm.activeContracts.RetrieveAllContractsMetaData()
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].StartHeight < contracts[j].StartHeight
+	})
+
+	var selectedHosts []storage.HostInfo
+	var degradedContracts []storage.ContractMetaData
+	for _, contract := range contracts {
 		newStatus := cm.checkContractStatus(contract, evalBaseline)
+		newStatus, selectedHosts = cm.checkContractHostPolicy(contract, newStatus, selectedHosts)
 		if err = cm.updateContractStatus(contract.ID, newStatus); err != nil {
 			return
 		}
+		if cm.checkHostDegraded(contract, evalBaseline) {
+			degradedContracts = append(degradedContracts, contract)
+		}
 	}
 
+	// migrate the data away from the degraded storage hosts
+	cm.autoMigration(degradedContracts)
+
+	return
+}
+
+// checkContractHostPolicy checks the storage host of the contract against the host selection
+// policy, given the storage hosts of the contracts that are kept. If the policy is violated,
+// the contract will be marked as not good for uploading and renewing. Otherwise, the storage
+// host will be added to the selected hosts
+func (cm *ContractManager) checkContractHostPolicy(contract storage.ContractMetaData, stats storage.ContractStatus, selectedHosts []storage.HostInfo) (storage.ContractStatus, []storage.HostInfo) {
+	// the contract will not be kept anyway
+	if !stats.RenewAbility {
+		return stats, selectedHosts
+	}
+
+	host, exists := cm.hostManager.RetrieveHostInfo(contract.EnodeID)
+	if !exists {
+		return stats, selectedHosts
+	}
+
+	if err := cm.hostManager.CheckHostPolicy(host, selectedHosts); err != nil {
+		cm.log.Debug("storage host violates the host selection policy", "hostID", host.EnodeID, "err", err.Error())
+		stats.UploadAbility = false
+		stats.RenewAbility = false
+		return stats, selectedHosts
+	}
+
+	return stats, append(selectedHosts, host)
+}
+
+// policySelectedHosts returns the storage hosts of the active contracts that are good for
+// renewing, which are used to check the host selection policy. The contract with the
+// excluded contract id will not be included
+func (cm *ContractManager) policySelectedHosts(excluded storage.ContractID) (hosts []storage.HostInfo) {
+	for _, contract := range cm.activeContracts.RetrieveAllContractsMetaData() {
+		if contract.ID == excluded || contract.Status.Canceled || !contract.Status.RenewAbility {
+			continue
+		}
+		if host, exists := cm.hostManager.RetrieveHostInfo(contract.EnodeID); exists {
+			hosts = append(hosts, host)
+		}
+	}
 	return
 }
 
@@ -43,6 +43,12 @@ func (cm *ContractManager) prepareCreateContract(neededContracts int, clientRema
 			return
 		}
 
+		// check the host selection policy against the storage hosts already signed contract with
+		if errPolicy := cm.hostManager.CheckHostPolicy(host, cm.policySelectedHosts(storage.ContractID{})); errPolicy != nil {
+			cm.log.Debug("storage host violates the host selection policy", "hostID", host.EnodeID, "err", errPolicy.Error())
+			continue
+		}
+
 		// start to form contract
 		formCost, contract, errFormContract := cm.createContract(host, contractFund, contractEndHeight, rentPayment)
 		// if contract formation failed, the error do not need to be returned, just try to form the
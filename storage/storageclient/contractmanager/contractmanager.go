@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
@@ -49,6 +50,10 @@ type ContractManager struct {
 	renewedTo        map[storage.ContractID]storage.ContractID
 	failedRenewCount map[storage.ContractID]uint64
 
+	// contracts whose data are being migrated to the replacement hosts, mapping to the host id
+	migrating     map[storage.ContractID]enode.ID
+	lastMigration time.Time
+
 	// used to acquire storage contract
 	blockHeight   uint64
 	currentPeriod uint64
@@ -74,6 +79,7 @@ func New(persistDir string, hm *storagehostmanager.StorageHostManager) (cm *Cont
 		renewedFrom:      make(map[storage.ContractID]storage.ContractID),
 		renewedTo:        make(map[storage.ContractID]storage.ContractID),
 		failedRenewCount: make(map[storage.ContractID]uint64),
+		migrating:        make(map[storage.ContractID]enode.ID),
 		hostToContract:   make(map[enode.ID]storage.ContractID),
 		quit:             make(chan struct{}),
 	}
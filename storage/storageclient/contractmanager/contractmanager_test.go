@@ -83,6 +83,7 @@ func newContractManagerTest(hm *storagehostmanager.StorageHostManager) (cm *Cont
 		renewedFrom:      make(map[storage.ContractID]storage.ContractID),
 		renewedTo:        make(map[storage.ContractID]storage.ContractID),
 		failedRenewCount: make(map[storage.ContractID]uint64),
+		migrating:        make(map[storage.ContractID]enode.ID),
 		hostToContract:   make(map[enode.ID]storage.ContractID),
 		quit:             make(chan struct{}),
 		log:              log.New(),
@@ -182,6 +183,10 @@ func (st *storageClientBackendContractManager) GetHostAnnouncementWithBlockHash(
 	return
 }
 
+func (st *storageClientBackendContractManager) GetHostRetirementWithBlockHash(blockHash common.Hash) (hostRetirements []types.HostRetirement, number uint64, errGet error) {
+	return
+}
+
 func (st *storageClientBackendContractManager) GetPaymentAddress() (address common.Address, err error) {
 	return
 }
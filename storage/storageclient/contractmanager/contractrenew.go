@@ -289,6 +289,9 @@ func (cm *ContractManager) renew(renewContract *contractset.Contract, rentPaymen
 	} else if host.MaxDuration < rentPayment.Period {
 		err = fmt.Errorf("the max duration cannot be smaller than the storage contract period")
 		return
+	} else if errPolicy := cm.hostManager.CheckHostPolicy(host, cm.policySelectedHosts(contractMeta.ID)); errPolicy != nil {
+		err = fmt.Errorf("the storage host violates the host selection policy: %s", errPolicy.Error())
+		return
 	}
 
 	// validate the storage host max deposit
@@ -7,6 +7,7 @@ package contractmanager
 import (
 	"errors"
 	"math/big"
+	"time"
 
 	"github.com/DxChainNetwork/godx/common"
 )
@@ -42,6 +43,25 @@ const (
 	consecutiveRenewFailsBeforeReplacement = 12
 )
 
+// contract migration related constants
+const (
+	// migrationInterval is the minimum interval between starting migrating the data away from
+	// the degraded storage hosts automatically
+	migrationInterval = 6 * time.Hour
+
+	// maxConcurrentMigrations is the max number of storage hosts whose data are migrated
+	// automatically at the same time
+	maxConcurrentMigrations = 2
+
+	// migrationMinTransfers is the minimum number of data transfers measured before the
+	// storage host could be considered as degraded because of the measured performance
+	migrationMinTransfers = uint64(10)
+
+	// migrationPerformanceThreshold is the performance score below which the storage host
+	// is considered as degraded
+	migrationPerformanceThreshold = 0.2
+)
+
 // rentPayment related constants
 const (
 	// rent payment size ratios. The contract fund are split according to these ratio
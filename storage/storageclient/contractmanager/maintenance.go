@@ -21,13 +21,16 @@ type contractRenewRecord struct {
 // contractMaintenance will perform the following actions:
 // 		1. maintainExpiration: remove all expired contract from the active contract list and adding
 //		them to expired contract list
-//		2. removeDuplications: contracts belong to the same storage host will be removed from the
+//		2. maintainMigration: remove the migrations whose contracts are no longer active
+//		3. removeDuplications: contracts belong to the same storage host will be removed from the
 //		active contract list
-// 		3. maintainHostToContractIDMapping: update the host to contractID mapping
-// 		4. removeHostWithDuplicateNetworkAddress: for storage host located under same network address, only
+// 		4. maintainHostToContractIDMapping: update the host to contractID mapping
+// 		5. removeHostWithDuplicateNetworkAddress: for storage host located under same network address, only
 // 		one can be saved
-// 		5. filter out contracts need to be renewed, renew contract
-// 		6. check out how many more contracts need to be created, create the contracts
+// 		6. maintainContractStatus: update the contract status, and start migrating data away from
+// 		the degraded storage hosts
+// 		7. filter out contracts need to be renewed, renew contract
+// 		8. check out how many more contracts need to be created, create the contracts
 func (cm *ContractManager) contractMaintenance() {
 	// if the maintenance is running, return directly
 	// otherwise, start the maintaining job
@@ -48,6 +51,7 @@ func (cm *ContractManager) contractMaintenance() {
 
 	// start maintenance
 	cm.maintainExpiration()
+	cm.maintainMigration()
 	cm.removeDuplications()
 	cm.maintainHostToContractIDMapping()
 	cm.removeHostWithDuplicateNetworkAddress()
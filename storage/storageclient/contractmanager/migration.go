@@ -0,0 +1,147 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
+)
+
+// MigrateHost will start to migrate the data away from the storage host explicitly, which is
+// not limited by the migration rate limit. The contract signed with the storage host will be
+// canceled, so that it will no longer be used for uploading, and will not be renewed. The data
+// stored in the storage host will be repaired to the replacement hosts by the storage client
+func (cm *ContractManager) MigrateHost(id enode.ID) (err error) {
+	cm.lock.RLock()
+	contractID, exists := cm.hostToContract[id]
+	cm.lock.RUnlock()
+	if !exists {
+		return fmt.Errorf("no active contract signed with the storage host %v", id)
+	}
+
+	if _, exists := cm.activeContracts.RetrieveContractMetaData(contractID); !exists {
+		return fmt.Errorf("no active contract signed with the storage host %v", id)
+	}
+
+	return cm.startMigration(contractID, id)
+}
+
+// MigratingHosts returns the ids of the storage hosts whose data are being migrated
+func (cm *ContractManager) MigratingHosts() (ids []enode.ID) {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	for _, id := range cm.migrating {
+		ids = append(ids, id)
+	}
+	return
+}
+
+// FinishMigration will be called once all the data have been migrated away from the storage
+// host. The canceled contract will be left to lapse
+func (cm *ContractManager) FinishMigration(id enode.ID) {
+	cm.lock.Lock()
+	for contractID, hostID := range cm.migrating {
+		if hostID == id {
+			delete(cm.migrating, contractID)
+			cm.log.Info("Finished migrating data from the storage host", "hostID", id, "contractID", contractID)
+		}
+	}
+	cm.lock.Unlock()
+
+	if err := cm.saveSettings(); err != nil {
+		cm.log.Error("failed to save the migration updates persistently", "err", err.Error())
+	}
+}
+
+// isMigrating checks if the data stored under the contract is being migrated
+func (cm *ContractManager) isMigrating(id storage.ContractID) (migrating bool) {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	_, migrating = cm.migrating[id]
+	return
+}
+
+// startMigration marks the contract as canceled, and records the migration of the contract
+func (cm *ContractManager) startMigration(contractID storage.ContractID, hostID enode.ID) (err error) {
+	cm.lock.Lock()
+	if _, exists := cm.migrating[contractID]; exists {
+		cm.lock.Unlock()
+		return fmt.Errorf("the data stored in the storage host %v is being migrated already", hostID)
+	}
+	cm.migrating[contractID] = hostID
+	cm.lastMigration = time.Now()
+	cm.lock.Unlock()
+
+	if err = cm.markContractCancel(contractID); err != nil {
+		cm.lock.Lock()
+		delete(cm.migrating, contractID)
+		cm.lock.Unlock()
+		return fmt.Errorf("failed to cancel the contract for migration: %s", err.Error())
+	}
+
+	cm.log.Info("Started migrating data from the storage host", "hostID", hostID, "contractID", contractID)
+	return cm.saveSettings()
+}
+
+// maintainMigration removes the migrations whose contract is no longer active, which happens
+// when the canceled contract expired
+func (cm *ContractManager) maintainMigration() {
+	cm.lock.Lock()
+	for contractID := range cm.migrating {
+		if _, exists := cm.activeContracts.RetrieveContractMetaData(contractID); !exists {
+			delete(cm.migrating, contractID)
+		}
+	}
+	cm.lock.Unlock()
+}
+
+// autoMigration starts to migrate the data away from the degraded storage hosts. The migration
+// is limited to start at most one storage host per migrationInterval, and at most
+// maxConcurrentMigrations storage hosts can be migrated at the same time
+func (cm *ContractManager) autoMigration(candidates []storage.ContractMetaData) {
+	for _, contract := range candidates {
+		cm.lock.RLock()
+		limited := len(cm.migrating) >= maxConcurrentMigrations || time.Since(cm.lastMigration) < migrationInterval
+		cm.lock.RUnlock()
+		if limited {
+			return
+		}
+
+		if err := cm.startMigration(contract.ID, contract.EnodeID); err != nil {
+			cm.log.Warn("failed to start the migration", "hostID", contract.EnodeID, "err", err.Error())
+		}
+	}
+}
+
+// checkHostDegraded checks if the storage host of the contract is degraded, whose evaluation is
+// below the evaluation baseline or whose measured performance is below the threshold. The data
+// stored in the degraded storage host should be migrated to the replacement host
+func (cm *ContractManager) checkHostDegraded(contract storage.ContractMetaData, evalBaseline int64) (degraded bool) {
+	// canceled contract will not be used anymore
+	if contract.Status.Canceled || cm.isMigrating(contract.ID) {
+		return false
+	}
+
+	host, exists := cm.hostManager.RetrieveHostInfo(contract.EnodeID)
+	if !exists || isOffline(host) {
+		return false
+	}
+
+	if evalBaseline > 0 && cm.hostManager.Evaluate(host) < evalBaseline {
+		return true
+	}
+
+	perf := host.Performance
+	if perf.UploadTransfers+perf.DownloadTransfers < migrationMinTransfers {
+		return false
+	}
+	return storagehostmanager.PerformanceScore(host) < migrationPerformanceThreshold
+}
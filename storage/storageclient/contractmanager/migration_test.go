@@ -0,0 +1,119 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package contractmanager
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestContractManager_MigrateHost(t *testing.T) {
+	cm, err := createNewContractManager()
+	if err != nil {
+		t.Fatalf("failed to create contract manager: %s", err.Error())
+	}
+
+	defer os.RemoveAll("test")
+	defer cm.activeContracts.Close()
+	defer cm.activeContracts.EmptyDB()
+
+	contract := randomContractGenerator(100)
+	if _, err := cm.activeContracts.InsertContract(contract, randomRootsGenerator(10)); err != nil {
+		t.Fatalf("failed to insert contract: %s", err.Error())
+	}
+
+	// the storage host without active contract cannot be migrated
+	if err := cm.MigrateHost(contract.EnodeID); err == nil {
+		t.Fatalf("the storage host without contract should not be migrated")
+	}
+
+	cm.hostToContract[contract.EnodeID] = contract.ID
+	if err := cm.MigrateHost(contract.EnodeID); err != nil {
+		t.Fatalf("failed to migrate the storage host: %s", err.Error())
+	}
+	if err := cm.MigrateHost(contract.EnodeID); err == nil {
+		t.Fatalf("the storage host should not be migrated twice")
+	}
+
+	meta, _ := cm.activeContracts.RetrieveContractMetaData(contract.ID)
+	if !meta.Status.Canceled || meta.Status.UploadAbility || meta.Status.RenewAbility {
+		t.Fatalf("the contract should be canceled once the migration started, got %+v", meta.Status)
+	}
+
+	// the migrating contract should not be resumed
+	if err := cm.resumeContracts(); err != nil {
+		t.Fatalf("failed to resume contracts: %s", err.Error())
+	}
+	if meta, _ = cm.activeContracts.RetrieveContractMetaData(contract.ID); !meta.Status.Canceled {
+		t.Fatalf("the migrating contract should not be resumed")
+	}
+
+	if hosts := cm.MigratingHosts(); len(hosts) != 1 || hosts[0] != contract.EnodeID {
+		t.Fatalf("unexpected migrating hosts: %v", hosts)
+	}
+
+	cm.FinishMigration(contract.EnodeID)
+	if hosts := cm.MigratingHosts(); len(hosts) != 0 {
+		t.Fatalf("the migration should be finished, got migrating hosts %v", hosts)
+	}
+}
+
+func TestContractManager_autoMigration(t *testing.T) {
+	cm, err := createNewContractManager()
+	if err != nil {
+		t.Fatalf("failed to create contract manager: %s", err.Error())
+	}
+
+	defer os.RemoveAll("test")
+	defer cm.activeContracts.Close()
+	defer cm.activeContracts.EmptyDB()
+
+	var candidates []storage.ContractMetaData
+	for i := 0; i < maxConcurrentMigrations+1; i++ {
+		contract := randomContractGenerator(100)
+		meta, err := cm.activeContracts.InsertContract(contract, randomRootsGenerator(10))
+		if err != nil {
+			t.Fatalf("failed to insert contract: %s", err.Error())
+		}
+		candidates = append(candidates, meta)
+	}
+
+	// only one storage host can be migrated within the migration interval
+	cm.autoMigration(candidates)
+	if len(cm.MigratingHosts()) != 1 {
+		t.Fatalf("expected 1 migrating host, got %v", len(cm.MigratingHosts()))
+	}
+	cm.autoMigration(candidates)
+	if len(cm.MigratingHosts()) != 1 {
+		t.Fatalf("the migration should be limited by the migration interval, got %v", len(cm.MigratingHosts()))
+	}
+
+	// the amount of concurrent migrations is limited
+	for i := 0; i < maxConcurrentMigrations+1; i++ {
+		cm.lastMigration = time.Now().Add(-migrationInterval)
+		cm.autoMigration(candidates)
+	}
+	if len(cm.MigratingHosts()) != maxConcurrentMigrations {
+		t.Fatalf("expected %v migrating hosts, got %v", maxConcurrentMigrations, len(cm.MigratingHosts()))
+	}
+
+	// the migration is removed once the contract is no longer active
+	for _, contract := range candidates {
+		c, exists := cm.activeContracts.Acquire(contract.ID)
+		if !exists {
+			t.Fatalf("failed to acquire the contract")
+		}
+		if err := cm.activeContracts.Delete(c); err != nil {
+			t.Fatalf("failed to delete the contract: %s", err.Error())
+		}
+	}
+	cm.maintainMigration()
+	if len(cm.MigratingHosts()) != 0 {
+		t.Fatalf("the migration of inactive contract should be removed, got %v", len(cm.MigratingHosts()))
+	}
+}
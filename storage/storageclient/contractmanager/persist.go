@@ -23,6 +23,7 @@ type persistence struct {
 	ExpiredContracts []storage.ContractMetaData    `json:"expiredcontracts"`
 	RenewedFrom      map[string]storage.ContractID `json:"renewedfrom"`
 	RenewedTo        map[string]storage.ContractID `json:"renewedto"`
+	Migrating        []storage.ContractID          `json:"migrating"`
 }
 
 func (cm *ContractManager) persistUpdate() (persist persistence) {
@@ -49,6 +50,11 @@ func (cm *ContractManager) persistUpdate() (persist persistence) {
 		persist.ExpiredContracts = append(persist.ExpiredContracts, ec)
 	}
 
+	// update the migrating contracts
+	for id := range cm.migrating {
+		persist.Migrating = append(persist.Migrating, id)
+	}
+
 	return
 }
 
@@ -113,6 +119,13 @@ func (cm *ContractManager) loadSettings() (err error) {
 		cm.expiredContracts[ec.ID] = ec
 		cm.hostToContract[ec.EnodeID] = ec.ID
 	}
+
+	// update the migrating contracts, which must be active
+	for _, id := range data.Migrating {
+		if contract, exists := cm.activeContracts.RetrieveContractMetaData(id); exists {
+			cm.migrating[id] = contract.EnodeID
+		}
+	}
 	cm.lock.Unlock()
 
 	return
@@ -5,7 +5,11 @@
 package storageclient
 
 import (
+	"os"
 	"time"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage"
 )
 
 // Files and directories related constant
@@ -14,14 +18,39 @@ const (
 	PersistFilename             = "storageclient.json"
 	PersistStorageClientVersion = "1.0"
 	DxPathRoot                  = "dxfiles"
+	SyncFoldersFilename         = "syncfolders.json"
+	SyncFoldersVersion          = "1.0"
+	SyncSnapshotsDirectory      = "syncsnapshots"
+	SharePrepaymentsFilename    = "shareprepayments.json"
+	SharePrepaymentsVersion     = "1.0"
 )
 
+// syncSnapshotExt is the extension of the snapshots of the synced files being uploaded
+const syncSnapshotExt = ".snapshot"
+
+// shareTokenPrefix is the prefix of the encoded share token
+const shareTokenPrefix = "dxshare:"
+
+// SharePrepaymentValidity is the number of blocks the downloads of the shared file are prepaid
+// for. The balance left in the prepaid ephemeral accounts is refunded to the sharer afterwards
+const SharePrepaymentValidity = storage.EphemeralAccountExpiry / 2
+
+// uploadCiphers maps the cipher names to the cipher codes which could be used to encrypt the
+// uploaded files
+var uploadCiphers = map[string]uint8{
+	"twofish-gcm": crypto.GCMCipherCode,
+	"xchacha20":   crypto.XChaCha20CipherCode,
+}
+
 // StorageClient Settings, where 0 means unlimited
 const (
 	DefaultMaxDownloadSpeed = 0
 	DefaultMaxUploadSpeed   = 0
 	DefaultPacketSize       = 4 * 4096
 
+	// the cipher used to encrypt the uploaded files if not specified
+	DefaultUploadCipherCode = crypto.GCMCipherCode
+
 	// frequency to check whether storage client is online
 	OnlineCheckFrequency = time.Second * 10
 
@@ -69,6 +98,39 @@ var (
 	// UploadFailureCoolDown is the initial time of punishment while upload consecutive fails
 	// the punishment time shows exponential growth
 	UploadFailureCoolDown = 3 * time.Second
+
+	// MigrationCheckInterval is the interval for the storage client to check the progress
+	// of migrating data away from the degraded storage hosts
+	MigrationCheckInterval = 10 * time.Minute
+
+	// MaxMigrationFilesPerRound is the maximum number of files pushed to the upload heap
+	// for migration in each round of migration check
+	MaxMigrationFilesPerRound = 50
+
+	// BandwidthScheduleCheckInterval is the interval for the storage client to check and
+	// apply the bandwidth limits of the bandwidth schedule
+	BandwidthScheduleCheckInterval = time.Minute
+
+	// SyncFolderScanInterval is the interval for the storage client to scan the sync folders
+	// for the new, changed and deleted local files
+	SyncFolderScanInterval = 5 * time.Minute
+
+	// SharePrepaymentCheckInterval is the interval for the storage client to refund the balance
+	// left in the ephemeral accounts prepaid for the shared files once the prepayments expire
+	SharePrepaymentCheckInterval = 10 * time.Minute
+
+	// StreamUploadSegmentWindow is the maximum number of segments of a streaming upload
+	// being uploaded at the same time, whose data are held in memory
+	StreamUploadSegmentWindow = 4
+
+	// StreamUploadFileMode is the file mode of the file uploaded from a stream
+	StreamUploadFileMode os.FileMode = 0644
 )
 
-var keys = []string{"fund", "hosts", "period", "violation", "uploadspeed", "downloadspeed"}
+// evaluationFactors is the list of factors whose weights can be set for the weighted evaluator
+var evaluationFactors = []string{"presence", "deposit", "interaction", "contractprice", "storageremaining", "uptime",
+	"uploadprice", "downloadprice", "performance"}
+
+var keys = []string{"fund", "hosts", "period", "violation", "uploadspeed", "downloadspeed", "regions", "maxhostsperoperator",
+	"maxhostsperregion", "minuptime", "maxstorageprice", "maxuploadprice", "maxdownloadprice", "maxcontractprice",
+	"evaluator", "weights", "bandwidthschedule", "uploadonlyinwindow"}
@@ -145,13 +145,22 @@ func (client *StorageClient) distributeDownloadSegmentToWorkers(uds *unfinishedD
 
 	// distribute the segment to workers, marking the number of workers that have received the work.
 	client.lock.Lock()
+	workers := make([]*worker, 0, len(client.workerPool)+len(uds.download.prepaidWorkers))
+	for _, worker := range client.workerPool {
+		workers = append(workers, worker)
+	}
+	client.lock.Unlock()
+	workers = append(workers, uds.download.prepaidWorkers...)
 	uds.mu.Lock()
-	uds.workersRemaining = uint32(len(client.workerPool))
+	uds.workersRemaining = uint32(len(workers))
 	uds.mu.Unlock()
-	for _, worker := range client.workerPool {
+
+	// the fastest workers are given the segment first, so that they are more likely to be
+	// registered for the download
+	sortWorkersByDownloadTime(workers, uds.sectorSize)
+	for _, worker := range workers {
 		worker.queueDownloadSegment(uds)
 	}
-	client.lock.Unlock()
 
 	// if there are no workers, there will be no workers to attempt to clean up
 	// the segment, so we must make sure that cleanUp is called at least once on the segment.
@@ -131,11 +131,18 @@ func (uds *unfinishedDownloadSegment) cleanUp() {
 		return
 	}
 
+	// only the fastest standby workers needed are queued, the rest keep standby
 	var standbyWorkers []*worker
 	for i := 0; i < len(uds.workersStandby); i++ {
 		standbyWorkers = append(standbyWorkers, uds.workersStandby[i])
 	}
-	uds.workersStandby = uds.workersStandby[:0]
+	sortWorkersByDownloadTime(standbyWorkers, uds.sectorSize)
+	if needed := int(desiredSectorsRegistered - uds.sectorsRegistered); needed < len(standbyWorkers) {
+		uds.workersStandby = append(uds.workersStandby[:0], standbyWorkers[needed:]...)
+		standbyWorkers = standbyWorkers[:needed]
+	} else {
+		uds.workersStandby = uds.workersStandby[:0]
+	}
 	uds.mu.Unlock()
 	for i := 0; i < len(standbyWorkers); i++ {
 		standbyWorkers[i].queueDownloadSegment(uds)
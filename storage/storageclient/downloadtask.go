@@ -67,6 +67,9 @@ type (
 		// higher priority will complete first.
 		priority uint64
 
+		// the workers downloading from the hosts without contracts for this download only
+		prepaidWorkers []*worker
+
 		// Utilities.
 		log           log.Logger
 		memoryManager *memorymanager.MemoryManager
@@ -107,6 +110,10 @@ type (
 
 		// higher priority download first
 		priority uint64
+
+		// the workers downloading from the hosts without contracts, paid by the prepaid
+		// ephemeral accounts
+		prepaidWorkers []*worker
 	}
 
 	// a function type that is called when the download completed.
@@ -0,0 +1,493 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/contractset"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
+)
+
+// errEphemeralBalanceUnknown is returned if the ephemeral account balance in the host is not known
+// by the client, and not able to be learned from the host
+var errEphemeralBalanceUnknown = errors.New("ephemeral account balance unknown")
+
+// ephemeralAccountBalance returns the ephemeral account balance in the host known by the client
+func (client *StorageClient) ephemeralAccountBalance(hostInfo *storage.HostInfo) (common.BigInt, bool) {
+	client.ephemeralLock.Lock()
+	defer client.ephemeralLock.Unlock()
+	balance, exist := client.ephemeralBalances[hostInfo.EnodeID]
+	return balance, exist
+}
+
+// setEphemeralAccountBalance records the ephemeral account balance in the host
+func (client *StorageClient) setEphemeralAccountBalance(hostInfo *storage.HostInfo, balance *big.Int) {
+	client.ephemeralLock.Lock()
+	defer client.ephemeralLock.Unlock()
+	client.ephemeralBalances[hostInfo.EnodeID] = common.PtrBigInt(balance)
+}
+
+// nextEphemeralNonce returns a strictly increasing nonce for the withdrawals. The nonce is based on
+// the current time so that it keeps increasing after the client restarts
+func (client *StorageClient) nextEphemeralNonce() uint64 {
+	client.ephemeralLock.Lock()
+	defer client.ephemeralLock.Unlock()
+	nonce := uint64(time.Now().UnixNano())
+	if nonce <= client.ephemeralNonce {
+		nonce = client.ephemeralNonce + 1
+	}
+	client.ephemeralNonce = nonce
+	return nonce
+}
+
+// newEphemeralWithdrawal creates a withdrawal of the amount from the ephemeral account owned by the
+// client address of the contract formed with the host, and signs it
+func (client *StorageClient) newEphemeralWithdrawal(hostInfo *storage.HostInfo, amount common.BigInt) (storage.EphemeralWithdrawal, error) {
+	scs := client.contractManager.GetStorageContractSet()
+	contractID := scs.GetContractIDByHostID(hostInfo.EnodeID)
+	contract, exist := scs.Acquire(contractID)
+	if !exist {
+		return storage.EphemeralWithdrawal{}, fmt.Errorf("not exist this contract: %s", contractID.String())
+	}
+	address := contract.Header().LatestContractRevision.NewValidProofOutputs[0].Address
+	scs.Return(contract)
+
+	w := client.ephemeralWithdrawal(address, hostInfo, amount)
+	am := client.ethBackend.AccountManager()
+	account := accounts.Account{Address: address}
+	wallet, err := am.Find(account)
+	if err != nil {
+		return storage.EphemeralWithdrawal{}, err
+	}
+	if w.Signature, err = wallet.SignHash(account, w.RLPHash().Bytes()); err != nil {
+		return storage.EphemeralWithdrawal{}, err
+	}
+	return w, nil
+}
+
+// newPrepaidWithdrawal creates a withdrawal of the amount from the ephemeral account owned by the
+// payment key, and signs it with the key
+func (client *StorageClient) newPrepaidWithdrawal(hostInfo *storage.HostInfo, key *ecdsa.PrivateKey, amount common.BigInt) (storage.EphemeralWithdrawal, error) {
+	w := client.ephemeralWithdrawal(crypto.PubkeyToAddress(key.PublicKey), hostInfo, amount)
+	sig, err := crypto.Sign(w.RLPHash().Bytes(), key)
+	if err != nil {
+		return storage.EphemeralWithdrawal{}, err
+	}
+	w.Signature = sig
+	return w, nil
+}
+
+// ephemeralWithdrawal creates the unsigned withdrawal of the amount from the ephemeral account
+func (client *StorageClient) ephemeralWithdrawal(account common.Address, hostInfo *storage.HostInfo, amount common.BigInt) storage.EphemeralWithdrawal {
+	return storage.EphemeralWithdrawal{
+		Account: account,
+		Host:    hostInfo.EnodeID,
+		Amount:  amount.BigIntPtr(),
+		Expiry:  client.ethBackend.GetCurrentBlockHeight() + storage.EphemeralWithdrawalWindow/2,
+		Nonce:   client.nextEphemeralNonce(),
+	}
+}
+
+// FundEphemeralAccount funds the ephemeral account in the host with the amount paid by a single
+// contract revision. Returns the balance of the ephemeral account after funding
+func (client *StorageClient) FundEphemeralAccount(sp storage.Peer, hostInfo *storage.HostInfo, amount common.BigInt) (common.BigInt, error) {
+	balance, err := client.fundEphemeralAccount(sp, hostInfo, common.Address{}, amount)
+	if err != nil {
+		return common.BigInt0, err
+	}
+	client.setEphemeralAccountBalance(hostInfo, balance.BigIntPtr())
+	return balance, nil
+}
+
+// fundEphemeralAccount funds the ephemeral account owned by the account address in the host with
+// the amount paid by the contract formed with the host. If the account address is empty, the
+// ephemeral account owned by the client address of the contract is funded
+func (client *StorageClient) fundEphemeralAccount(sp storage.Peer, hostInfo *storage.HostInfo, account common.Address, amount common.BigInt) (common.BigInt, error) {
+	if amount.Sign() <= 0 {
+		return common.BigInt0, errors.New("ephemeral account fund amount shall be positive")
+	}
+
+	// retrieve the contract formed by this host
+	scs := client.contractManager.GetStorageContractSet()
+	contractID := scs.GetContractIDByHostID(hostInfo.EnodeID)
+	contract, exist := scs.Acquire(contractID)
+	if !exist {
+		return common.BigInt0, fmt.Errorf("not exist this contract: %s", contractID.String())
+	}
+	defer scs.Return(contract)
+
+	lastRevision := contract.Header().LatestContractRevision
+	if lastRevision.NewValidProofOutputs[0].Value.Cmp(amount.BigIntPtr()) < 0 {
+		return common.BigInt0, errors.New("client funds not enough to fund the ephemeral account")
+	}
+
+	newRevision := NewRevision(lastRevision, amount.BigIntPtr())
+	clientSig, err := client.signRevision(newRevision)
+	if err != nil {
+		return common.BigInt0, err
+	}
+
+	req := storage.EphemeralAccountFundRequest{
+		StorageContractID: newRevision.ParentID,
+		Account:           account,
+		Amount:            amount.BigIntPtr(),
+		NewRevisionNumber: newRevision.NewRevisionNumber,
+		Signature:         clientSig,
+	}
+	req.NewValidProofValues, req.NewMissedProofValues = revisionProofValues(newRevision)
+
+	if err := sp.RequestEphemeralAccountFund(req); err != nil {
+		return common.BigInt0, err
+	}
+	return client.commitEphemeralAccountRevision(sp, contract, newRevision, clientSig, amount)
+}
+
+// RefundEphemeralAccount refunds the amount from the ephemeral account in the host back to the
+// client with a contract revision. Returns the balance of the ephemeral account after refunding
+func (client *StorageClient) RefundEphemeralAccount(sp storage.Peer, hostInfo *storage.HostInfo, amount common.BigInt) (common.BigInt, error) {
+	if amount.Sign() <= 0 {
+		return common.BigInt0, errors.New("ephemeral account refund amount shall be positive")
+	}
+	withdrawal, err := client.newEphemeralWithdrawal(hostInfo, amount)
+	if err != nil {
+		return common.BigInt0, err
+	}
+	balance, err := client.refundEphemeralAccount(sp, hostInfo, withdrawal)
+	if err != nil {
+		return common.BigInt0, err
+	}
+	client.setEphemeralAccountBalance(hostInfo, balance.BigIntPtr())
+	return balance, nil
+}
+
+// refundEphemeralAccount refunds the amount of the withdrawal from the ephemeral account in the host
+// back to the client address of the contract formed with the host. The withdrawal could be made
+// from the ephemeral account owned by the client address, or by a payment key of the client
+func (client *StorageClient) refundEphemeralAccount(sp storage.Peer, hostInfo *storage.HostInfo, withdrawal storage.EphemeralWithdrawal) (common.BigInt, error) {
+	amount := common.PtrBigInt(withdrawal.Amount)
+
+	// retrieve the contract formed by this host
+	scs := client.contractManager.GetStorageContractSet()
+	contractID := scs.GetContractIDByHostID(hostInfo.EnodeID)
+	contract, exist := scs.Acquire(contractID)
+	if !exist {
+		return common.BigInt0, fmt.Errorf("not exist this contract: %s", contractID.String())
+	}
+	defer scs.Return(contract)
+
+	lastRevision := contract.Header().LatestContractRevision
+	if lastRevision.NewValidProofOutputs[1].Value.Cmp(amount.BigIntPtr()) < 0 {
+		return common.BigInt0, errors.New("host valid proof output not enough for the refund")
+	}
+
+	newRevision := NewRefundRevision(lastRevision, amount.BigIntPtr())
+	clientSig, err := client.signRevision(newRevision)
+	if err != nil {
+		return common.BigInt0, err
+	}
+
+	req := storage.EphemeralAccountRefundRequest{
+		Withdrawal:        withdrawal,
+		StorageContractID: newRevision.ParentID,
+		NewRevisionNumber: newRevision.NewRevisionNumber,
+		Signature:         clientSig,
+	}
+	req.NewValidProofValues, req.NewMissedProofValues = revisionProofValues(newRevision)
+
+	if err := sp.RequestEphemeralAccountRefund(req); err != nil {
+		return common.BigInt0, err
+	}
+
+	// the refund moves money back to the client, thus the download cost is reduced
+	return client.commitEphemeralAccountRevision(sp, contract, newRevision, clientSig, common.BigInt0.Sub(amount))
+}
+
+// EphemeralDownloadBatch requests for multiple whole sectors from the host paid by the ephemeral
+// account. No contract revision is involved. A Merkle proof is always requested.
+func (client *StorageClient) EphemeralDownloadBatch(sp storage.Peer, roots []common.Hash, hostInfo *storage.HostInfo) (data [][]byte, err error) {
+	req := newEphemeralDownloadRequest(roots)
+
+	// the withdrawal shall cover the download cost, and not exceed the known balance
+	cost := estimateDownloadCost(hostInfo, req.Sectors, req.MerkleProof)
+	balance, exist := client.ephemeralAccountBalance(hostInfo)
+	if !exist {
+		return nil, errEphemeralBalanceUnknown
+	}
+	if balance.Cmp(cost) < 0 {
+		return nil, errors.New("ephemeral account balance not enough to support download")
+	}
+	if req.Withdrawal, err = client.newEphemeralWithdrawal(hostInfo, cost); err != nil {
+		return nil, err
+	}
+
+	data, charged, err := client.requestEphemeralDownload(sp, req, hostInfo)
+	if charged {
+		client.setEphemeralAccountBalance(hostInfo, balance.Sub(cost).BigIntPtr())
+	}
+	return data, err
+}
+
+// PrepaidDownloadBatch requests for multiple whole sectors from the host paid by the ephemeral
+// account owned by the payment key, which is prepaid by others. No contract with the host is needed
+func (client *StorageClient) PrepaidDownloadBatch(sp storage.Peer, roots []common.Hash, hostInfo *storage.HostInfo, key *ecdsa.PrivateKey) (data [][]byte, err error) {
+	req := newEphemeralDownloadRequest(roots)
+	cost := estimateDownloadCost(hostInfo, req.Sectors, req.MerkleProof)
+	if req.Withdrawal, err = client.newPrepaidWithdrawal(hostInfo, key, cost); err != nil {
+		return nil, err
+	}
+	data, _, err = client.requestEphemeralDownload(sp, req, hostInfo)
+	return data, err
+}
+
+// newEphemeralDownloadRequest creates the unsigned request of the whole sectors of the roots. A
+// Merkle proof is always requested.
+func newEphemeralDownloadRequest(roots []common.Hash) storage.EphemeralDownloadRequest {
+	req := storage.EphemeralDownloadRequest{
+		Sectors:     make([]storage.DownloadRequestSector, len(roots)),
+		MerkleProof: true,
+	}
+	for i, root := range roots {
+		req.Sectors[i] = storage.DownloadRequestSector{
+			MerkleRoot: root,
+			Offset:     0,
+			Length:     uint32(storage.SectorSize),
+		}
+	}
+	return req
+}
+
+// requestEphemeralDownload sends the ephemeral download request to the host, and receives the
+// sector data. charged reports whether the withdrawal is accepted by the host
+func (client *StorageClient) requestEphemeralDownload(sp storage.Peer, req storage.EphemeralDownloadRequest, hostInfo *storage.HostInfo) (data [][]byte, charged bool, err error) {
+	measure := newTransferMeasure()
+	var hostNegotiateErr error
+	defer func() {
+		if hostNegotiateErr != nil {
+			client.CheckAndUpdateConnection(sp.PeerNode())
+			client.storageHostManager.IncrementFailedInteractions(hostInfo.EnodeID, storagehostmanager.InteractionDownload)
+		}
+		if err == nil {
+			client.storageHostManager.IncrementSuccessfulInteractions(hostInfo.EnodeID, storagehostmanager.InteractionDownload)
+			client.recordTransfer(hostInfo.EnodeID, storagehostmanager.InteractionDownload, measure, uint64(len(req.Sectors))*storage.SectorSize)
+		} else {
+			client.recordTransferError(hostInfo.EnodeID, storagehostmanager.InteractionDownload, err, nil, hostNegotiateErr, nil)
+		}
+	}()
+
+	if err = sp.RequestEphemeralDownload(req); err != nil {
+		return nil, false, err
+	}
+
+	data = make([][]byte, len(req.Sectors))
+	for i, sector := range req.Sectors {
+		msg, err := sp.ClientWaitContractResp()
+		if err != nil {
+			return nil, true, err
+		}
+		measure.firstResponse()
+
+		if msg.Code == storage.HostBusyHandleReqMsg {
+			return nil, true, storage.ErrHostBusyHandleReq
+		}
+		if msg.Code == storage.HostNegotiateErrorMsg {
+			// the withdrawal is either rejected or restored by the host
+			hostNegotiateErr = storage.ErrHostNegotiate
+			return nil, false, hostNegotiateErr
+		}
+
+		var resp storage.DownloadBatchResponse
+		if err = msg.Decode(&resp); err != nil {
+			hostNegotiateErr = err
+			return nil, true, err
+		}
+		if int(resp.Index) != i {
+			hostNegotiateErr = fmt.Errorf("host sent sector data out of order: expect %d, got %d", i, resp.Index)
+			return nil, true, hostNegotiateErr
+		}
+		if len(resp.Data) != int(sector.Length) {
+			hostNegotiateErr = errors.New("host did not send enough sector data")
+			return nil, true, hostNegotiateErr
+		}
+
+		proofStart := int(sector.Offset) / merkle.LeafSize
+		proofEnd := int(sector.Offset+sector.Length) / merkle.LeafSize
+		verified, err := merkle.Sha256VerifyRangeProof(resp.Data, resp.MerkleProof, proofStart, proofEnd, sector.MerkleRoot)
+		if !verified || err != nil {
+			hostNegotiateErr = errors.New("host provided incorrect sector data or Merkle proof")
+			return nil, true, hostNegotiateErr
+		}
+		data[i] = resp.Data
+	}
+	return data, true, nil
+}
+
+// EphemeralHostConfig requests the host config paid by the ephemeral account, and refreshes the
+// ephemeral account balance known by the client
+func (client *StorageClient) EphemeralHostConfig(sp storage.Peer, hostInfo *storage.HostInfo) (storage.HostExtConfig, error) {
+	withdrawal, err := client.newEphemeralWithdrawal(hostInfo, hostInfo.BaseRPCPrice)
+	if err != nil {
+		return storage.HostExtConfig{}, err
+	}
+	resp, err := client.ephemeralHostConfig(sp, withdrawal)
+	if err != nil {
+		return storage.HostExtConfig{}, err
+	}
+	client.setEphemeralAccountBalance(hostInfo, resp.Balance)
+	return resp.Config, nil
+}
+
+// ephemeralHostConfig requests the host config paid by the withdrawal. The response contains the
+// balance of the ephemeral account the withdrawal is made from
+func (client *StorageClient) ephemeralHostConfig(sp storage.Peer, withdrawal storage.EphemeralWithdrawal) (storage.EphemeralHostConfigResponse, error) {
+	if err := sp.RequestEphemeralHostConfig(storage.EphemeralHostConfigRequest{Withdrawal: withdrawal}); err != nil {
+		return storage.EphemeralHostConfigResponse{}, err
+	}
+
+	msg, err := sp.ClientWaitContractResp()
+	if err != nil {
+		return storage.EphemeralHostConfigResponse{}, err
+	}
+	if msg.Code != storage.EphemeralHostConfigRespMsg {
+		return storage.EphemeralHostConfigResponse{}, storage.ErrHostNegotiate
+	}
+
+	var resp storage.EphemeralHostConfigResponse
+	if err := msg.Decode(&resp); err != nil {
+		return storage.EphemeralHostConfigResponse{}, err
+	}
+	if resp.Balance == nil {
+		return storage.EphemeralHostConfigResponse{}, errors.New("host sent incomplete ephemeral host config response")
+	}
+	return resp, nil
+}
+
+// ephemeralDownload downloads the sectors paid by the ephemeral account. If the ephemeral account
+// balance is not enough, the account will be refilled to the max balance with a contract revision
+func (client *StorageClient) ephemeralDownload(sp storage.Peer, roots []common.Hash, hostInfo *storage.HostInfo) ([][]byte, error) {
+	sectors := make([]storage.DownloadRequestSector, len(roots))
+	for i, root := range roots {
+		sectors[i] = storage.DownloadRequestSector{MerkleRoot: root, Length: uint32(storage.SectorSize)}
+	}
+	cost := estimateDownloadCost(hostInfo, sectors, true)
+
+	// learn the balance from the host if not known, which might happen after the client restarted
+	balance, exist := client.ephemeralAccountBalance(hostInfo)
+	if !exist {
+		if _, err := client.EphemeralHostConfig(sp, hostInfo); err != nil {
+			client.setEphemeralAccountBalance(hostInfo, common.BigInt0.BigIntPtr())
+		}
+		balance, _ = client.ephemeralAccountBalance(hostInfo)
+	}
+
+	// refill the ephemeral account with a single contract revision
+	if balance.Cmp(cost) < 0 {
+		if ok := sp.TryToRenewOrRevise(); !ok {
+			return nil, ErrContractRenewing
+		}
+		_, err := client.FundEphemeralAccount(sp, hostInfo, storage.EphemeralAccountMaxBalance.Sub(balance))
+		sp.RevisionOrRenewingDone()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return client.EphemeralDownloadBatch(sp, roots, hostInfo)
+}
+
+// signRevision signs the revision with the client payment address of the revision
+func (client *StorageClient) signRevision(rev types.StorageContractRevision) ([]byte, error) {
+	am := client.ethBackend.AccountManager()
+	account := accounts.Account{Address: rev.NewValidProofOutputs[0].Address}
+	wallet, err := am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.SignHash(account, rev.RLPHash().Bytes())
+}
+
+// revisionProofValues returns the values of the valid and missed proof outputs of the revision
+func revisionProofValues(rev types.StorageContractRevision) (validValues, missedValues []*big.Int) {
+	validValues = make([]*big.Int, len(rev.NewValidProofOutputs))
+	for i, nvpo := range rev.NewValidProofOutputs {
+		validValues[i] = nvpo.Value
+	}
+	missedValues = make([]*big.Int, len(rev.NewMissedProofOutputs))
+	for i, nmpo := range rev.NewMissedProofOutputs {
+		missedValues[i] = nmpo.Value
+	}
+	return
+}
+
+// commitEphemeralAccountRevision waits for the host response of the ephemeral account fund or refund
+// request, and commits the revision signed by both parties
+func (client *StorageClient) commitEphemeralAccountRevision(sp storage.Peer, contract *contractset.Contract, newRevision types.StorageContractRevision, clientSig []byte, cost common.BigInt) (balance common.BigInt, err error) {
+	var hostNegotiateErr, hostCommitErr error
+	defer func() {
+		if hostCommitErr != nil || hostNegotiateErr != nil {
+			client.CheckAndUpdateConnection(sp.PeerNode())
+		}
+	}()
+
+	msg, err := sp.ClientWaitContractResp()
+	if err != nil {
+		return common.BigInt0, err
+	}
+	if msg.Code == storage.HostBusyHandleReqMsg {
+		return common.BigInt0, storage.ErrHostBusyHandleReq
+	}
+	if msg.Code == storage.HostNegotiateErrorMsg {
+		hostNegotiateErr = storage.ErrHostNegotiate
+		return common.BigInt0, hostNegotiateErr
+	}
+
+	var resp storage.EphemeralAccountResponse
+	if err = msg.Decode(&resp); err != nil {
+		hostNegotiateErr = err
+		return common.BigInt0, err
+	}
+	if len(resp.Signature) == 0 || resp.Balance == nil {
+		hostNegotiateErr = errors.New("host sent incomplete ephemeral account response")
+		return common.BigInt0, hostNegotiateErr
+	}
+
+	contractHeader := contract.Header()
+	newRevision.Signatures = [][]byte{clientSig, resp.Signature}
+	if err = contract.CommitRevision(newRevision, cost); err != nil {
+		if err := sp.SendClientCommitFailedMsg(); err != nil {
+			return common.BigInt0, err
+		}
+		_, _ = sp.ClientWaitContractResp()
+		return common.BigInt0, fmt.Errorf("commit ephemeral account revision failed, err: %v", err)
+	}
+
+	_ = sp.SendClientCommitSuccessMsg()
+
+	// wait for HostAckMsg until timeout
+	msg, err = sp.ClientWaitContractResp()
+	if err != nil {
+		_ = contract.RollbackUndoMem(contractHeader)
+		return common.BigInt0, fmt.Errorf("failed to read host ACK message, error: %s", err.Error())
+	}
+	if msg.Code != storage.HostAckMsg {
+		hostCommitErr = storage.ErrHostCommit
+		_ = contract.RollbackUndoMem(contractHeader)
+
+		_ = sp.SendClientAckMsg()
+		_, _ = sp.ClientWaitContractResp()
+		return common.BigInt0, hostCommitErr
+	}
+
+	return common.PtrBigInt(resp.Balance), nil
+}
@@ -6,6 +6,8 @@ package filesystem
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/DxChainNetwork/godx/storage"
 )
@@ -146,3 +148,126 @@ func (api *PublicFileSystemAPI) Delete(path string) string {
 	}
 	return fmt.Sprintf("File %v deleted", path)
 }
+
+// ListDir returns at most limit sub directories and files directly under the directory
+// specified by the path, starting from offset. The aggregated information of the
+// directory is also returned. If limit is not positive, all entries are returned
+func (api *PublicFileSystemAPI) ListDir(path string, offset, limit int) (storage.DirListing, error) {
+	dxPath, err := dirDxPath(path)
+	if err != nil {
+		return storage.DirListing{}, fmt.Errorf("path not valid: %v", path)
+	}
+	return api.fs.ListDir(dxPath, offset, limit)
+}
+
+// RenameDir is the API function that rename a directory and all its contents from
+// prevPath to newPath
+func (api *PublicFileSystemAPI) RenameDir(prevPath, newPath string) string {
+	prevDxPath, err := storage.NewDxPath(prevPath)
+	if err != nil {
+		return fmt.Sprintf("Path not valid: %v", prevPath)
+	}
+	newDxPath, err := storage.NewDxPath(newPath)
+	if err != nil {
+		return fmt.Sprintf("Path not valid: %v", newPath)
+	}
+	if err = api.fs.RenameDir(prevDxPath, newDxPath); err != nil {
+		return fmt.Sprintf("Cannot rename directory from %v to %v: %v", prevPath, newPath, err)
+	}
+	return fmt.Sprintf("Directory %v renamed to %v", prevPath, newPath)
+}
+
+// DeleteDir delete a directory and all its contents specified by the path
+func (api *PublicFileSystemAPI) DeleteDir(path string) string {
+	dxPath, err := storage.NewDxPath(path)
+	if err != nil {
+		return fmt.Sprintf("Path not valid: %v", path)
+	}
+	if err = api.fs.DeleteDir(dxPath); err != nil {
+		return fmt.Sprintf("Cannot delete directory %v: %v", path, err)
+	}
+	return fmt.Sprintf("Directory %v deleted", path)
+}
+
+// SetVersioning is the API function that sets the versioning policy of the directory
+// specified by path. maxVersions limits the number of retained versions of each file, and
+// maxAge is the duration string that limits the age of the retained versions. 0 and empty
+// string means unlimited
+func (api *PublicFileSystemAPI) SetVersioning(path string, enabled bool, maxVersions uint64, maxAge string) string {
+	dxPath, err := dirDxPath(path)
+	if err != nil {
+		return fmt.Sprintf("Path not valid: %v", path)
+	}
+	var age time.Duration
+	if maxAge != "" {
+		if age, err = time.ParseDuration(maxAge); err != nil || age < 0 {
+			return fmt.Sprintf("Max age not valid: %v", maxAge)
+		}
+	}
+	policy := storage.VersioningPolicy{
+		Enabled:     enabled,
+		MaxVersions: maxVersions,
+		MaxAge:      uint64(age / time.Second),
+	}
+	if err = api.fs.SetVersioning(dxPath, policy); err != nil {
+		return fmt.Sprintf("Cannot set versioning of directory %v: %v", path, err)
+	}
+	if !enabled {
+		return fmt.Sprintf("Versioning of directory %v disabled", path)
+	}
+	return fmt.Sprintf("Versioning of directory %v enabled", path)
+}
+
+// Versions returns the retained versions of the file specified by path, with the newest
+// version first
+func (api *PublicFileSystemAPI) Versions(path string) ([]storage.FileVersionInfo, error) {
+	dxPath, err := storage.NewDxPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("path not valid: %v", path)
+	}
+	return api.fs.ListVersions(dxPath)
+}
+
+// RestoreVersion is the API function that restores the file specified by path to the
+// version. The current file is retained as a new version
+func (api *PublicFileSystemAPI) RestoreVersion(path string, versionID string) string {
+	dxPath, err := storage.NewDxPath(path)
+	if err != nil {
+		return fmt.Sprintf("Path not valid: %v", path)
+	}
+	if err = api.fs.RestoreVersion(dxPath, versionID); err != nil {
+		return fmt.Sprintf("Cannot restore file %v to version %v: %v", path, versionID, err)
+	}
+	if parent, err := dxPath.Parent(); err == nil {
+		err = api.fs.InitAndUpdateDirMetadata(parent)
+		if err != nil {
+			api.fs.getLogger().Warn("InitAndUpdateDirMetadata error", "error", err)
+		}
+	}
+	return fmt.Sprintf("File %v restored to version %v", path, versionID)
+}
+
+// PurgeVersions is the API function that permanently deletes the version of the file
+// specified by path. If versionID is empty, all versions of the file are deleted
+func (api *PublicFileSystemAPI) PurgeVersions(path string, versionID string) string {
+	dxPath, err := storage.NewDxPath(path)
+	if err != nil {
+		return fmt.Sprintf("Path not valid: %v", path)
+	}
+	if err = api.fs.PurgeVersions(dxPath, versionID); err != nil {
+		return fmt.Sprintf("Cannot purge versions of file %v: %v", path, err)
+	}
+	if versionID == "" {
+		return fmt.Sprintf("All versions of file %v purged", path)
+	}
+	return fmt.Sprintf("Version %v of file %v purged", versionID, path)
+}
+
+// dirDxPath returns the DxPath of the directory. Empty path or "/" is regarded as the
+// root directory
+func dirDxPath(path string) (storage.DxPath, error) {
+	if strings.Trim(path, "/") == "" {
+		return storage.RootDxPath(), nil
+	}
+	return storage.NewDxPath(path)
+}
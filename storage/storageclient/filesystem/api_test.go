@@ -245,6 +245,7 @@ func TestPublicFileSystemAPI_DetailedFileInfo(t *testing.T) {
 		Redundancy:     300,
 		StoredOnDisk:   false,
 		UploadProgress: 100,
+		Priority:       storage.PriorityNormal.String(),
 	}
 	if err = df.Close(); err != nil {
 		t.Fatal(err)
@@ -18,6 +18,14 @@ const (
 const (
 	dirMetadataUpdateName = "dirMetadataUpdate"
 
+	// dirRenameName and dirDeleteName are the names of the recursive directory
+	// operations recorded in updateWal
+	dirRenameName = "dirRename"
+	dirDeleteName = "dirDelete"
+
+	// fileReplaceName is the name of the dxfile replacement recorded in updateWal
+	fileReplaceName = "fileReplace"
+
 	// numConsecutiveFailRelease defines the time when fail reaches this number,
 	// dirMetadataUpdate is release and deleted from map
 	numConsecutiveFailRelease = 3
@@ -30,6 +38,16 @@ const (
 	// filesDirectory is the directory to put all files
 	filesDirectory = "files"
 
+	// versionsDirectory is the directory to put the retained versions of the dxfiles
+	versionsDirectory = "versions"
+
+	// versionDirExt is the extension of the directory holding the versions of a dxfile
+	versionDirExt = ".versions"
+
+	// uploadingExt is the extension of the temporary dxfile the staged file is uploaded to,
+	// which replaces the existing dxfile once the upload completes
+	uploadingExt = ".uploading"
+
 	// fileWalName is the fileName for the fileWal
 	fileWalName = "file.wal"
 
@@ -61,4 +79,7 @@ const (
 const (
 	// healthCheckInterval is the interval between two health checks
 	healthCheckInterval = 30 * time.Minute
+
+	// versionPruneInterval is the interval between two prunes of the expired versions
+	versionPruneInterval = time.Hour
 )
@@ -484,8 +484,7 @@ func (fs *fileSystem) calculateDxFileMetadata(path storage.DxPath, filename stri
 		StuckHealth: stuckHealth,
 		Redundancy:  redundancy,
 	}
-	// apply cached metadata and return
-	return &metadataForUpdate{
+	update := &metadataForUpdate{
 		numFiles:            1,
 		totalSize:           file.FileSize(),
 		health:              health,
@@ -493,7 +492,14 @@ func (fs *fileSystem) calculateDxFileMetadata(path storage.DxPath, filename stri
 		minRedundancy:       redundancy,
 		numStuckSegments:    numStuckSegments,
 		timeLastHealthCheck: time.Now(),
-	}, file.ApplyCachedHealthMetadata(cachedMetadata)
+	}
+	// The paused file is excluded from the health of the directory, so that it will not
+	// be selected for repair until resumed
+	if file.UploadPaused() {
+		update.health, update.stuckHealth, update.numStuckSegments = dxdir.DefaultHealth, dxdir.DefaultHealth, 0
+	}
+	// apply cached metadata and return
+	return update, file.ApplyCachedHealthMetadata(cachedMetadata)
 }
 
 // calculateDxDirMetadata calculate and return the metadata from the .dxdir file
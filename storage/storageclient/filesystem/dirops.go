@@ -0,0 +1,407 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common/writeaheadlog"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxdir"
+)
+
+var (
+	// errRootDirOperation is the error that the root directory is renamed or deleted
+	errRootDirOperation = errors.New("cannot rename or delete the root directory")
+
+	// ErrDirNotExist is the error that the directory to be operated does not exist
+	ErrDirNotExist = errors.New("directory not exist")
+
+	// errDirExist is the error that the directory to be renamed to already exists
+	errDirExist = errors.New("directory already exist")
+
+	// errRenameToSubDir is the error that a directory is renamed to its sub directory
+	errRenameToSubDir = errors.New("cannot rename a directory to its sub directory")
+)
+
+// dirOperation is the intent of a recursive directory operation or a dxfile replacement
+// recorded in updateWal. For dirDeleteName operation, NewPath is empty
+type dirOperation struct {
+	PrevPath string
+	NewPath  string
+}
+
+// ListDir returns the sub directories and files directly under the directory specified
+// by path. The sub directories are listed prior to files, and both are sorted by path.
+// At most limit entries are returned starting from offset. If limit is not positive,
+// all entries after offset are returned
+func (fs *fileSystem) ListDir(path storage.DxPath, offset, limit int) (storage.DirListing, error) {
+	if err := fs.tm.Add(); err != nil {
+		return storage.DirListing{}, err
+	}
+	defer fs.tm.Done()
+
+	if !fs.isDir(path) {
+		return storage.DirListing{}, ErrDirNotExist
+	}
+	dir, err := fs.dirInfo(path)
+	if err != nil {
+		return storage.DirListing{}, err
+	}
+	dirs, files, err := fs.dirsAndFiles(path)
+	if err != nil {
+		return storage.DirListing{}, err
+	}
+	dirPaths, filePaths := sortedDxPaths(dirs), sortedDxPaths(files)
+
+	listing := storage.DirListing{
+		Dir:   dir,
+		Dirs:  []storage.DirectoryInfo{},
+		Files: []storage.FileBriefInfo{},
+		Total: len(dirPaths) + len(filePaths),
+	}
+	start, end := pageRange(listing.Total, offset, limit)
+	table := fs.contractManager.HostHealthMap()
+	for i := start; i < end; i++ {
+		if i < len(dirPaths) {
+			info, err := fs.dirInfo(dirPaths[i])
+			if err != nil {
+				return storage.DirListing{}, err
+			}
+			listing.Dirs = append(listing.Dirs, info)
+			continue
+		}
+		info, err := fs.fileBriefInfo(filePaths[i-len(dirPaths)], table)
+		if err != nil {
+			return storage.DirListing{}, err
+		}
+		listing.Files = append(listing.Files, info)
+	}
+	return listing, nil
+}
+
+// RenameDir rename the directory from prevPath to newPath recursively, including all
+// the files and sub directories. The intent is recorded in updateWal, so that the
+// operation could be continued after an unexpected shutdown
+func (fs *fileSystem) RenameDir(prevPath, newPath storage.DxPath) error {
+	if prevPath.IsRoot() || newPath.IsRoot() {
+		return errRootDirOperation
+	}
+	if strings.HasPrefix(newPath.Path, prevPath.Path+"/") {
+		return errRenameToSubDir
+	}
+	fs.dirOpLock.Lock()
+	defer fs.dirOpLock.Unlock()
+
+	if !fs.isDir(prevPath) {
+		return ErrDirNotExist
+	}
+	if _, err := os.Stat(string(newPath.SysPath(fs.fileRootDir))); !os.IsNotExist(err) {
+		return errDirExist
+	}
+	txn, err := fs.recordDirOperationIntent(dirRenameName, dirOperation{prevPath.Path, newPath.Path})
+	if err != nil {
+		return fmt.Errorf("cannot record the rename intent: %v", err)
+	}
+	if err = fs.renameDir(prevPath, newPath); err != nil {
+		return err
+	}
+	if err = txn.Release(); err != nil {
+		return err
+	}
+	return fs.updateDirOperationMetadata(prevPath, newPath)
+}
+
+// DeleteDir delete the directory recursively, including all the files and sub directories.
+// The intent is recorded in updateWal, so that the operation could be continued after
+// an unexpected shutdown
+func (fs *fileSystem) DeleteDir(path storage.DxPath) error {
+	if path.IsRoot() {
+		return errRootDirOperation
+	}
+	fs.dirOpLock.Lock()
+	defer fs.dirOpLock.Unlock()
+
+	if !fs.isDir(path) {
+		return ErrDirNotExist
+	}
+	txn, err := fs.recordDirOperationIntent(dirDeleteName, dirOperation{PrevPath: path.Path})
+	if err != nil {
+		return fmt.Errorf("cannot record the delete intent: %v", err)
+	}
+	if err = fs.deleteDir(path); err != nil {
+		return err
+	}
+	if err = txn.Release(); err != nil {
+		return err
+	}
+	return fs.updateDirOperationMetadata(path)
+}
+
+// renameDir moves all the dxfiles and dxdirs under prevPath to newPath, and then removes
+// the directory of prevPath. The dxfiles and dxdirs already moved are not under prevPath
+// anymore, thus renameDir could be safely redone with the same arguments
+func (fs *fileSystem) renameDir(prevPath, newPath storage.DxPath) error {
+	dirs, files, err := fs.subTree(prevPath)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		newFile, err := replaceDxPathPrefix(file, prevPath, newPath)
+		if err != nil {
+			return err
+		}
+		if err = fs.fileSet.Rename(file, newFile); err != nil {
+			return fmt.Errorf("cannot rename file %v: %v", file.Path, err)
+		}
+	}
+	for _, dir := range dirs {
+		newDir, err := replaceDxPathPrefix(dir, prevPath, newPath)
+		if err != nil {
+			return err
+		}
+		err = fs.dirSet.Rename(dir, newDir)
+		if err == os.ErrExist {
+			// The dxdir at new path is already created, and its metadata will be
+			// recalculated. Simply delete the previous one
+			err = fs.dirSet.Delete(dir)
+		}
+		if err != nil && err != os.ErrNotExist {
+			return fmt.Errorf("cannot rename directory %v: %v", dir.Path, err)
+		}
+	}
+	if err = fs.moveVersions(prevPath.SysPath(fs.versionRootDir), newPath.SysPath(fs.versionRootDir)); err != nil {
+		return fmt.Errorf("cannot move the versions: %v", err)
+	}
+	return os.RemoveAll(string(prevPath.SysPath(fs.fileRootDir)))
+}
+
+// deleteDir deletes all the dxfiles and dxdirs under path, and then removes the directory.
+// The dxfiles with versioning enabled are archived as versions. deleteDir could be safely
+// redone with the same argument
+func (fs *fileSystem) deleteDir(path storage.DxPath) error {
+	dirs, files, err := fs.subTree(path)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err = fs.DeleteDxFile(file); err != nil {
+			return fmt.Errorf("cannot delete file %v: %v", file.Path, err)
+		}
+	}
+	for _, dir := range dirs {
+		if err = fs.dirSet.Delete(dir); err != nil && err != os.ErrNotExist {
+			return fmt.Errorf("cannot delete directory %v: %v", dir.Path, err)
+		}
+	}
+	return os.RemoveAll(string(path.SysPath(fs.fileRootDir)))
+}
+
+// updateDirOperationMetadata updates the metadata of the parent directories of the deleted
+// path and the metadata of the newly created paths
+func (fs *fileSystem) updateDirOperationMetadata(prevPath storage.DxPath, newPaths ...storage.DxPath) error {
+	var paths []storage.DxPath
+	if parent, err := prevPath.Parent(); err == nil {
+		paths = append(paths, parent)
+	}
+	paths = append(paths, newPaths...)
+	for _, path := range paths {
+		if err := fs.InitAndUpdateDirMetadata(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subTree returns the dxdirs and dxfiles under the path recursively. The dxdirs are
+// returned with the deeper ones first
+func (fs *fileSystem) subTree(path storage.DxPath) (dirs, files []storage.DxPath, err error) {
+	rootDir := string(fs.fileRootDir)
+	err = filepath.Walk(string(path.SysPath(fs.fileRootDir)), func(sysPath string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if info.Name() == dxdir.DirFileName {
+			rel := strings.TrimPrefix(filepath.Dir(sysPath), rootDir)
+			if strings.Trim(rel, string(filepath.Separator)) == "" {
+				dirs = append(dirs, storage.RootDxPath())
+				return nil
+			}
+			dirPath, err := storage.NewDxPath(rel)
+			if err != nil {
+				return err
+			}
+			dirs = append(dirs, dirPath)
+			return nil
+		}
+		if filepath.Ext(sysPath) != storage.DxFileExt {
+			return nil
+		}
+		filePath, err := storage.NewDxPath(strings.TrimSuffix(strings.TrimPrefix(sysPath, rootDir), storage.DxFileExt))
+		if err != nil {
+			return err
+		}
+		files = append(files, filePath)
+		return nil
+	})
+	// Walk visits the parent directories first. Reverse the dirs
+	for i, j := 0, len(dirs)-1; i < j; i, j = i+1, j-1 {
+		dirs[i], dirs[j] = dirs[j], dirs[i]
+	}
+	return
+}
+
+// isDir checks whether the path is an existing directory in the file system
+func (fs *fileSystem) isDir(path storage.DxPath) bool {
+	info, err := os.Stat(string(path.SysPath(fs.fileRootDir)))
+	return err == nil && info.IsDir()
+}
+
+// dirInfo returns the directory info of the dxdir. If the dxdir is not created yet,
+// return the default directory info
+func (fs *fileSystem) dirInfo(path storage.DxPath) (storage.DirectoryInfo, error) {
+	d, err := fs.dirSet.Open(path)
+	if os.IsNotExist(err) {
+		return storage.DirectoryInfo{
+			Health:        dxdir.DefaultHealth,
+			StuckHealth:   dxdir.DefaultHealth,
+			MinRedundancy: math.MaxUint32,
+			DxPath:        path,
+		}, nil
+	}
+	if err != nil {
+		return storage.DirectoryInfo{}, err
+	}
+	defer d.Close()
+
+	md := d.Metadata()
+	return storage.DirectoryInfo{
+		NumFiles:            md.NumFiles,
+		TotalSize:           md.TotalSize,
+		Health:              md.Health,
+		StuckHealth:         md.StuckHealth,
+		MinRedundancy:       md.MinRedundancy,
+		TimeLastHealthCheck: time.Unix(int64(md.TimeLastHealthCheck), 0),
+		TimeModify:          time.Unix(int64(md.TimeModify), 0),
+		NumStuckSegments:    md.NumStuckSegments,
+		DxPath:              md.DxPath,
+		Versioning:          md.Versioning,
+	}, nil
+}
+
+// recordDirOperationIntent record and commit the directory operation intent to the updateWal
+func (fs *fileSystem) recordDirOperationIntent(name string, op dirOperation) (*writeaheadlog.Transaction, error) {
+	b, err := rlp.EncodeToBytes(op)
+	if err != nil {
+		return nil, err
+	}
+	txn, err := fs.updateWal.NewTransaction([]writeaheadlog.Operation{{Name: name, Data: b}})
+	if err != nil {
+		return nil, err
+	}
+	if <-txn.InitComplete; txn.InitErr != nil {
+		return nil, txn.InitErr
+	}
+	if err = <-txn.Commit(); err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+// redoDirOperation redo the unfinished directory operation or dxfile replacement recorded
+// in updateWal
+func (fs *fileSystem) redoDirOperation(operation writeaheadlog.Operation) error {
+	var op dirOperation
+	if err := rlp.DecodeBytes(operation.Data, &op); err != nil {
+		return err
+	}
+	prevPath, err := storage.NewDxPath(op.PrevPath)
+	if err != nil {
+		return err
+	}
+	switch operation.Name {
+	case dirRenameName:
+		newPath, err := storage.NewDxPath(op.NewPath)
+		if err != nil {
+			return err
+		}
+		if err = fs.renameDir(prevPath, newPath); err != nil {
+			return err
+		}
+		return fs.updateDirOperationMetadata(prevPath, newPath)
+	case dirDeleteName:
+		if err = fs.deleteDir(prevPath); err != nil {
+			return err
+		}
+		return fs.updateDirOperationMetadata(prevPath)
+	case fileReplaceName:
+		newPath, err := storage.NewDxPath(op.NewPath)
+		if err != nil {
+			return err
+		}
+		if _, err = fs.replaceDxFile(prevPath, newPath); err != nil {
+			return err
+		}
+		parent, err := newPath.Parent()
+		if err != nil {
+			return err
+		}
+		return fs.updateDirOperationMetadata(prevPath, parent)
+	default:
+		return fmt.Errorf("unknown directory operation [%s]", operation.Name)
+	}
+}
+
+// isDirOperation checks whether the updateWal operation is a directory operation or a
+// dxfile replacement
+func isDirOperation(operation writeaheadlog.Operation) bool {
+	return operation.Name == dirRenameName || operation.Name == dirDeleteName || operation.Name == fileReplaceName
+}
+
+// replaceDxPathPrefix replace the prefix prevPrefix of path with newPrefix
+func replaceDxPathPrefix(path, prevPrefix, newPrefix storage.DxPath) (storage.DxPath, error) {
+	return storage.NewDxPath(newPrefix.Path + strings.TrimPrefix(path.Path, prevPrefix.Path))
+}
+
+// sortedDxPaths returns the sorted paths in the map
+func sortedDxPaths(paths map[storage.DxPath]struct{}) []storage.DxPath {
+	sorted := make([]storage.DxPath, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+	return sorted
+}
+
+// pageRange returns the range of the page specified by offset and limit within total entries
+func pageRange(total, offset, limit int) (start, end int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	end = total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	return offset, end
+}
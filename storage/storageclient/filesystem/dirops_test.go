@@ -0,0 +1,233 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+)
+
+// TestFileSystem_RenameDir test the functionality of fileSystem.RenameDir. All files and
+// sub directories shall be moved, and the metadata shall be updated
+func TestFileSystem_RenameDir(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	prevDir, newDir := randomDxPath(t, 2), randomDxPath(t, 2)
+	files := createTestFilesUnderDir(t, fs, prevDir)
+
+	if err := fs.RenameDir(prevDir, newDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.waitForUpdatesComplete(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if fs.isDir(prevDir) {
+		t.Errorf("previous directory %v should be removed", prevDir.Path)
+	}
+	for _, file := range files {
+		newFile, err := replaceDxPathPrefix(file, prevDir, newDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fs.fileSet.Exists(file) {
+			t.Errorf("file %v should be moved", file.Path)
+		}
+		if !fs.fileSet.Exists(newFile) {
+			t.Errorf("file %v should exist", newFile.Path)
+		}
+	}
+	for _, path := range []storage.DxPath{newDir, storage.RootDxPath()} {
+		info, err := fs.dirInfo(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.NumFiles != uint64(len(files)) {
+			t.Errorf("directory %v: unexpected number of files. Expect %v, Got %v", path.Path, len(files), info.NumFiles)
+		}
+	}
+
+	// Invalid renames
+	if err := fs.RenameDir(storage.RootDxPath(), randomDxPath(t, 1)); err != errRootDirOperation {
+		t.Errorf("rename root directory: expect error %v, got %v", errRootDirOperation, err)
+	}
+	if err := fs.RenameDir(prevDir, randomDxPath(t, 1)); err != ErrDirNotExist {
+		t.Errorf("rename non-existing directory: expect error %v, got %v", ErrDirNotExist, err)
+	}
+	subDir, err := newDir.Join("sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.RenameDir(newDir, subDir); err != errRenameToSubDir {
+		t.Errorf("rename to sub directory: expect error %v, got %v", errRenameToSubDir, err)
+	}
+	otherDir := randomDxPath(t, 1)
+	createTestFilesUnderDir(t, fs, otherDir)
+	if err := fs.RenameDir(otherDir, newDir); err != errDirExist {
+		t.Errorf("rename to existing directory: expect error %v, got %v", errDirExist, err)
+	}
+}
+
+// TestFileSystem_DeleteDir test the functionality of fileSystem.DeleteDir
+func TestFileSystem_DeleteDir(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	dir := randomDxPath(t, 2)
+	files := createTestFilesUnderDir(t, fs, dir)
+
+	if err := fs.DeleteDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.waitForUpdatesComplete(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if fs.isDir(dir) {
+		t.Errorf("directory %v should be removed", dir.Path)
+	}
+	for _, file := range files {
+		if fs.fileSet.Exists(file) {
+			t.Errorf("file %v should be deleted", file.Path)
+		}
+	}
+	info, err := fs.dirInfo(storage.RootDxPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.NumFiles != 0 {
+		t.Errorf("root directory shall have no files, got %v", info.NumFiles)
+	}
+	if err := fs.DeleteDir(storage.RootDxPath()); err != errRootDirOperation {
+		t.Errorf("delete root directory: expect error %v, got %v", errRootDirOperation, err)
+	}
+}
+
+// TestFileSystem_ListDir test the functionality of fileSystem.ListDir with pagination
+func TestFileSystem_ListDir(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	dir := randomDxPath(t, 1)
+	createTestFilesUnderDir(t, fs, dir)
+	// the directory has two files and one sub directory
+	tests := []struct {
+		offset      int
+		limit       int
+		expectDirs  int
+		expectFiles int
+	}{
+		{0, 0, 1, 2},
+		{0, 1, 1, 0},
+		{1, 1, 0, 1},
+		{1, 5, 0, 2},
+		{3, 5, 0, 0},
+		{-1, 2, 1, 1},
+	}
+	for i, test := range tests {
+		listing, err := fs.ListDir(dir, test.offset, test.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if listing.Total != 3 {
+			t.Errorf("test %d: unexpected total. Expect %v, Got %v", i, 3, listing.Total)
+		}
+		if len(listing.Dirs) != test.expectDirs || len(listing.Files) != test.expectFiles {
+			t.Errorf("test %d: unexpected listing. Expect %v dirs %v files, Got %v dirs %v files", i,
+				test.expectDirs, test.expectFiles, len(listing.Dirs), len(listing.Files))
+		}
+		if listing.Dir.NumFiles != 3 {
+			t.Errorf("test %d: unexpected number of files in directory. Expect %v, Got %v", i, 3, listing.Dir.NumFiles)
+		}
+	}
+	if _, err := fs.ListDir(randomDxPath(t, 1), 0, 0); err != ErrDirNotExist {
+		t.Errorf("list non-existing directory: expect error %v, got %v", ErrDirNotExist, err)
+	}
+}
+
+// TestFileSystem_RedoDirOperation test the unfinished directory operation recorded in
+// updateWal is redone when the file system restarts
+func TestFileSystem_RedoDirOperation(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	prevDir, newDir := randomDxPath(t, 2), randomDxPath(t, 2)
+	files := createTestFilesUnderDir(t, fs, prevDir)
+
+	// record the intent and only rename one file before the unclean shutdown
+	if _, err := fs.recordDirOperationIntent(dirRenameName, dirOperation{prevDir.Path, newDir.Path}); err != nil {
+		t.Fatal(err)
+	}
+	newFile, err := replaceDxPathPrefix(files[0], prevDir, newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.fileSet.Rename(files[0], newFile); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.tm.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	fs.updateWal.CloseIncomplete()
+	if err = fs.fileWal.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// restart the file system, the rename shall be finished
+	newFs := newFileSystem(string(fs.persistDir), &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	if err = newFs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err = newFs.waitForUpdatesComplete(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if newFs.isDir(prevDir) {
+		t.Errorf("previous directory %v should be removed", prevDir.Path)
+	}
+	for _, file := range files {
+		newFile, err := replaceDxPathPrefix(file, prevDir, newDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !newFs.fileSet.Exists(newFile) {
+			t.Errorf("file %v should exist", newFile.Path)
+		}
+	}
+	newFs.postTestCheck(t, true, true, nil)
+}
+
+// createTestFilesUnderDir creates two files directly under the dir and one file under
+// the sub directory of dir. Return the paths of the created files
+func createTestFilesUnderDir(t *testing.T, fs *fileSystem, dir storage.DxPath) []storage.DxPath {
+	ck, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []storage.DxPath
+	for _, name := range []string{"a", "b", "sub/c"} {
+		path, err := dir.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		df, err := fs.fileSet.NewRandomDxFile(path, 10, 30, erasurecode.ECTypeStandard, ck, 1<<22*10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = df.Close(); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+		parent, err := path.Parent()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = fs.InitAndUpdateDirMetadata(parent); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(string(dir.SysPath(fs.fileRootDir))); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.waitForUpdatesComplete(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
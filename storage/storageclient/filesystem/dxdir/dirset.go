@@ -82,11 +82,22 @@ func (ds *DirSet) NewDxDir(path storage.DxPath) (*DirSetEntryWithID, error) {
 	if exist {
 		return nil, os.ErrExist
 	}
+	// the new directory inherits the scheduling settings of the nearest parent directory
+	priority, paused, err := ds.parentScheduling(path)
+	if err != nil {
+		return nil, err
+	}
 	// create the dxdir
 	d, err := New(path, ds.rootDir, ds.wal)
 	if err != nil {
 		return nil, err
 	}
+	if priority != 0 || paused {
+		d.metadata.Priority, d.metadata.UploadPaused = priority, paused
+		if err = d.save(); err != nil {
+			return nil, err
+		}
+	}
 	// create the entry and update dxdir
 	entry := ds.newDirSetEntry(d)
 	tid := randomThreadID()
@@ -98,6 +109,38 @@ func (ds *DirSet) NewDxDir(path storage.DxPath) (*DirSetEntryWithID, error) {
 	}, nil
 }
 
+// ParentScheduling return the scheduling priority and the upload pause status of the nearest
+// existing parent directory of the path. The zero priority means the priority is not set
+func (ds *DirSet) ParentScheduling(path storage.DxPath) (storage.FilePriority, bool, error) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	return ds.parentScheduling(path)
+}
+
+// parentScheduling return the scheduling settings of the nearest existing parent directory
+func (ds *DirSet) parentScheduling(path storage.DxPath) (storage.FilePriority, bool, error) {
+	for !path.IsRoot() {
+		parent, err := path.Parent()
+		if err != nil {
+			return 0, false, err
+		}
+		path = parent
+		if entry, exist := ds.dirMap[path]; exist {
+			return entry.Priority(), entry.UploadPaused(), nil
+		}
+		d, err := load(ds.dirFilePath(path), ds.wal)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		return d.metadata.Priority, d.metadata.UploadPaused, nil
+	}
+	return 0, false, nil
+}
+
 // newDirSetEntry create a New dirSetEntry with the DxDir
 func (ds *DirSet) newDirSetEntry(d *DxDir) *dirSetEntry {
 	threads := make(map[threadID]threadInfo)
@@ -211,6 +254,32 @@ func (ds *DirSet) Delete(path storage.DxPath) error {
 	return nil
 }
 
+// Rename rename the dxdir from path to newPath. If the dxdir not exist, return
+// os.ErrNotExist. If a dxdir already exists at newPath, return os.ErrExist
+func (ds *DirSet) Rename(path, newPath storage.DxPath) error {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	// check whether exists
+	if !ds.exists(path) {
+		return os.ErrNotExist
+	}
+	if ds.exists(newPath) {
+		return os.ErrExist
+	}
+	// open the entry
+	entry, err := ds.open(path)
+	if err != nil {
+		return err
+	}
+	defer ds.closeEntry(entry)
+	if err = entry.Rename(newPath, ds.dirFilePath(newPath)); err != nil {
+		return err
+	}
+	ds.dirMap[newPath] = entry.dirSetEntry
+	delete(ds.dirMap, path)
+	return nil
+}
+
 // UpdateMetadata update the metadata of the dxdir specified by DxPath
 func (ds *DirSet) UpdateMetadata(path storage.DxPath, metadata Metadata) error {
 	ds.lock.Lock()
@@ -148,6 +148,46 @@ func TestDirSet_Delete(t *testing.T) {
 	}
 }
 
+// TestDirSet_Rename test the functionality of DirSet.Rename
+func TestDirSet_Rename(t *testing.T) {
+	ds, entry := newTestDirSet(t)
+	path := entry.DxPath()
+	md := entry.Metadata()
+	newPath := randomDxPath(3)
+	if err := ds.Rename(path, newPath); err != nil {
+		t.Fatal(err)
+	}
+	if ds.Exists(path) {
+		t.Errorf("the previous path should not exist")
+	}
+	if !ds.Exists(newPath) {
+		t.Errorf("the new path should exist")
+	}
+	if entry.DxPath() != newPath {
+		t.Errorf("the entry path not renamed. Expect %v, Got %v", newPath, entry.DxPath())
+	}
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The renamed dxdir could be loaded from disk with the same metadata
+	newEntry, err := ds.Open(newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newEntry.Close()
+	md.DxPath = newPath
+	if !reflect.DeepEqual(newEntry.Metadata(), md) {
+		t.Errorf("metadata not expected. \n\tExpect %+v\n\tGot %+v", md, newEntry.Metadata())
+	}
+	// Rename to an existing path or from a non-existing path shall fail
+	if err := ds.Rename(path, randomDxPath(3)); err != os.ErrNotExist {
+		t.Errorf("rename a non-existing path should return os.ErrNotExist, got %v", err)
+	}
+	if err := ds.Rename(newPath, storage.RootDxPath()); err != os.ErrExist {
+		t.Errorf("rename to an existing path should return os.ErrExist, got %v", err)
+	}
+}
+
 // TestDirSet_UpdateMetadata test the functionality of Update
 func TestDirSet_UpdateMetadata(t *testing.T) {
 	ds, entry := newTestDirSet(t)
@@ -6,6 +6,7 @@ package dxdir
 
 import (
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -66,6 +67,16 @@ type (
 
 		// RootPath is the root path of the file directory
 		RootPath storage.SysPath
+
+		// Versioning is the versioning policy of the DxFiles under the directory
+		Versioning storage.VersioningPolicy `rlp:"optional"`
+
+		// Priority is the scheduling priority of the DxFiles created under the directory.
+		// Zero value means the priority is not set
+		Priority storage.FilePriority `rlp:"optional"`
+
+		// UploadPaused is whether the upload of the DxFiles created under the directory is paused
+		UploadPaused bool `rlp:"optional"`
 	}
 )
 
@@ -112,6 +123,17 @@ func (d *DxDir) Delete() error {
 	return d.delete()
 }
 
+// Rename rename the DxDir to the newDxPath. The dxdir file is moved to newDirFilePath
+func (d *DxDir) Rename(newDxPath storage.DxPath, newDirFilePath storage.SysPath) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(string(newDirFilePath)), 0700); err != nil {
+		return err
+	}
+	return d.rename(newDxPath, newDirFilePath)
+}
+
 // Deleted return the delete status
 func (d *DxDir) Deleted() bool {
 	d.lock.RLock()
@@ -136,6 +158,58 @@ func (d *DxDir) DxPath() storage.DxPath {
 	return d.metadata.DxPath
 }
 
+// Versioning return the versioning policy of the DxDir
+func (d *DxDir) Versioning() storage.VersioningPolicy {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return d.metadata.Versioning
+}
+
+// SetVersioning set the versioning policy of the DxDir and save the DxDir
+func (d *DxDir) SetVersioning(policy storage.VersioningPolicy) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.metadata.Versioning = policy
+	return d.save()
+}
+
+// Priority return the scheduling priority of the DxFiles created under the DxDir
+func (d *DxDir) Priority() storage.FilePriority {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return d.metadata.Priority
+}
+
+// SetPriority set the scheduling priority of the DxFiles created under the DxDir and save the DxDir
+func (d *DxDir) SetPriority(priority storage.FilePriority) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.metadata.Priority = priority
+	return d.save()
+}
+
+// UploadPaused return whether the upload of the DxFiles created under the DxDir is paused
+func (d *DxDir) UploadPaused() bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return d.metadata.UploadPaused
+}
+
+// SetUploadPaused set whether the upload of the DxFiles created under the DxDir is paused and
+// save the DxDir
+func (d *DxDir) SetUploadPaused(paused bool) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.metadata.UploadPaused = paused
+	return d.save()
+}
+
 // filePath return the actual dxdir file path of a dxdir.
 func (d *DxDir) FilePath() string {
 	return string(d.dirFilePath)
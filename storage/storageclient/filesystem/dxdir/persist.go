@@ -92,6 +92,31 @@ func (d *DxDir) delete() error {
 	return storage.ApplyUpdates(d.wal, []storage.FileUpdate{fu})
 }
 
+// rename create and apply the updates to move the dxdir file to newDirFilePath.
+// The deletion of the previous file and the insertion of the new file are applied
+// in one transaction
+func (d *DxDir) rename(newDxPath storage.DxPath, newDirFilePath storage.SysPath) error {
+	if d.deleted {
+		return ErrAlreadyDeleted
+	}
+	du, err := d.createDeleteUpdate()
+	if err != nil {
+		return err
+	}
+	prevDxPath, prevDirFilePath := d.metadata.DxPath, d.dirFilePath
+	d.metadata.DxPath, d.dirFilePath = newDxPath, newDirFilePath
+	iu, err := d.createInsertUpdate()
+	if err == nil {
+		err = storage.ApplyUpdates(d.wal, []storage.FileUpdate{du, iu})
+	}
+	if err != nil {
+		// revert the path in memory
+		d.metadata.DxPath, d.dirFilePath = prevDxPath, prevDirFilePath
+		return err
+	}
+	return nil
+}
+
 // load load the DxDir metadata.
 // input path should be the path of the DxDir file
 func load(dirFilePath storage.SysPath, wal *writeaheadlog.Wal) (*DxDir, error) {
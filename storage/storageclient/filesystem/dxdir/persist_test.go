@@ -67,6 +67,47 @@ func TestDxDir_EncodeDecode(t *testing.T) {
 	}
 }
 
+// TestDxDir_DecodeLegacy test decoding the DxDir saved before the versioning policy is added
+func TestDxDir_DecodeLegacy(t *testing.T) {
+	type legacyMetadata struct {
+		NumFiles            uint64
+		TotalSize           uint64
+		Health              uint32
+		StuckHealth         uint32
+		MinRedundancy       uint32
+		TimeLastHealthCheck uint64
+		TimeModify          uint64
+		NumStuckSegments    uint32
+		DxPath              storage.DxPath
+		RootPath            storage.SysPath
+	}
+	d := randomDxDir(t)
+	m := d.metadata
+	data, err := rlp.EncodeToBytes(legacyMetadata{
+		NumFiles:            m.NumFiles,
+		TotalSize:           m.TotalSize,
+		Health:              m.Health,
+		StuckHealth:         m.StuckHealth,
+		MinRedundancy:       m.MinRedundancy,
+		TimeLastHealthCheck: m.TimeLastHealthCheck,
+		TimeModify:          m.TimeModify,
+		NumStuckSegments:    m.NumStuckSegments,
+		DxPath:              m.DxPath,
+		RootPath:            m.RootPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var newDir *DxDir
+	if err = rlp.DecodeBytes(data, &newDir); err != nil {
+		t.Fatal(err)
+	}
+	m.Versioning = storage.VersioningPolicy{}
+	if !reflect.DeepEqual(m, newDir.metadata) {
+		t.Errorf("metadata not equal\n\t%+v\n\t%+v", m, newDir.metadata)
+	}
+}
+
 // TestDxDir_SaveLoad test save_load process. Test whether the original data could be recovered
 // by save and load
 func TestDxDir_SaveLoad(t *testing.T) {
@@ -87,6 +128,26 @@ func TestDxDir_SaveLoad(t *testing.T) {
 	}
 }
 
+// TestDxDir_SetVersioning test the versioning policy is saved and could be loaded
+func TestDxDir_SetVersioning(t *testing.T) {
+	d := randomDxDir(t)
+	policy := storage.VersioningPolicy{
+		Enabled:     true,
+		MaxVersions: randomUint64(),
+		MaxAge:      randomUint64(),
+	}
+	if err := d.SetVersioning(policy); err != nil {
+		t.Fatal(err)
+	}
+	newDir, err := load(d.dirFilePath, d.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newDir.Versioning() != policy {
+		t.Errorf("versioning policy not expected\n\t%+v\n\t%+v", policy, newDir.Versioning())
+	}
+}
+
 // TestDxDir_SaveDeleteLoad test the process of save_delete_load process
 func TestDxDir_SaveDeleteLoad(t *testing.T) {
 	d := randomDxDir(t)
@@ -56,6 +56,9 @@ type (
 		//cached field
 		erasureCode erasurecode.ErasureCoder
 		cipherKey   crypto.CipherKey
+
+		// wrapKey is the master key which the cipher key is wrapped with
+		wrapKey crypto.CipherKey
 	}
 
 	// hostTable is the map from host address to specific host info
@@ -85,6 +88,12 @@ type (
 // erasureCode is the erasure coder for encoding. cipherKey is the key for encryption.
 // fileSize is the size of the original data file. fileMode is the file privilege mode (e.g. 0777)
 func New(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.SysPath, wal *writeaheadlog.Wal, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode) (*DxFile, error) {
+	return newDxFile(filePath, dxPath, sourcePath, wal, erasureCode, cipherKey, fileSize, fileMode, nil)
+}
+
+// newDxFile creates a new dxfile. If wrapKey is not nil, the cipher key is wrapped by wrapKey
+// before the DxFile is saved for the first time, thus the plain cipher key never reaches the disk
+func newDxFile(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.SysPath, wal *writeaheadlog.Wal, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode, wrapKey crypto.CipherKey) (*DxFile, error) {
 	currentTime := uint64(time.Now().Unix())
 	// create the params for erasureCode and cipherKey
 	minSectors, numSectors, extra, err := erasureCodeToParams(erasureCode)
@@ -117,6 +126,14 @@ func New(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.Sys
 		MinSectors:      minSectors,
 		NumSectors:      numSectors,
 		ECExtra:         extra,
+		Priority:        storage.PriorityNormal,
+	}
+	if wrapKey != nil {
+		wrapped, err := wrapKey.Encrypt(cipherKey.Key())
+		if err != nil {
+			return nil, fmt.Errorf("cannot wrap the cipher key: %v", err)
+		}
+		md.CipherKey, md.KeyWrapped = wrapped, true
 	}
 	if err := md.validate(); err != nil {
 		return nil, err
@@ -131,6 +148,7 @@ func New(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.Sys
 		filePath:    filePath,
 		erasureCode: erasureCode,
 		cipherKey:   cipherKey,
+		wrapKey:     wrapKey,
 	}
 
 	// initialize the segments.
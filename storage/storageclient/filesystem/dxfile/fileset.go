@@ -39,6 +39,14 @@ type (
 		// filesMap is the mapping from dxPath to contents
 		filesMap map[storage.DxPath]*fileSetEntry
 
+		// versionsMap is the mapping from the file path of the opened DxFile versions
+		// to contents
+		versionsMap map[storage.SysPath]*fileSetEntry
+
+		// masterKeys are the master keys used to unwrap the cipher keys of the DxFiles.
+		// The first key is used to wrap the cipher keys of the new DxFiles
+		masterKeys []crypto.CipherKey
+
 		lock sync.Mutex
 		wal  *writeaheadlog.Wal
 	}
@@ -50,6 +58,9 @@ type (
 		*DxFile
 		fileSet *FileSet
 
+		// versionPath is the key in fileSet.versionsMap if the entry is a DxFile version
+		versionPath storage.SysPath
+
 		threadMap     map[uint64]threadInfo
 		threadMapLock sync.Mutex
 	}
@@ -71,9 +82,10 @@ type (
 // NewFileSet create a new DxFileSet with provided rootDir and wal.
 func NewFileSet(rootDir storage.SysPath, wal *writeaheadlog.Wal) *FileSet {
 	return &FileSet{
-		rootDir:  rootDir,
-		filesMap: make(map[storage.DxPath]*fileSetEntry),
-		wal:      wal,
+		rootDir:     rootDir,
+		filesMap:    make(map[storage.DxPath]*fileSetEntry),
+		versionsMap: make(map[storage.SysPath]*fileSetEntry),
+		wal:         wal,
 	}
 }
 
@@ -86,8 +98,12 @@ func (fs *FileSet) NewDxFile(dxPath storage.DxPath, sourcePath storage.SysPath,
 	if exists && !force {
 		return nil, ErrFileExist
 	}
-	// Create a new DxFile
-	df, err := New(fs.filepath(dxPath), dxPath, sourcePath, fs.wal, erasureCode, cipherKey, fileSize, fileMode)
+	// Create a new DxFile with the cipher key wrapped by the master key if unlocked
+	var wrapKey crypto.CipherKey
+	if len(fs.masterKeys) != 0 {
+		wrapKey = fs.masterKeys[0]
+	}
+	df, err := newDxFile(fs.filepath(dxPath), dxPath, sourcePath, fs.wal, erasureCode, cipherKey, fileSize, fileMode, wrapKey)
 	if err != nil {
 		return nil, err
 	}
@@ -129,7 +145,7 @@ func (fs *FileSet) open(dxPath storage.DxPath) (*FileSetEntryWithID, error) {
 	entry, exist := fs.filesMap[dxPath]
 	if !exist {
 		// file not loaded or not exist. Try to read DxFile from disk.
-		df, err := readDxFile(fs.filepath(dxPath), fs.wal)
+		df, err := fs.readDxFile(fs.filepath(dxPath))
 		if os.IsNotExist(err) {
 			return nil, ErrUnknownFile
 		}
@@ -153,6 +169,33 @@ func (fs *FileSet) open(dxPath storage.DxPath) (*FileSetEntryWithID, error) {
 	}, nil
 }
 
+// SetMasterKeys set the master keys used to wrap and unwrap the cipher keys of the DxFiles. The
+// first key is used to wrap the cipher keys of the new DxFiles, and all keys are tried to unwrap
+// the cipher keys. The cipher keys of the opened DxFiles are unwrapped with the keys
+func (fs *FileSet) SetMasterKeys(masterKeys ...crypto.CipherKey) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	fs.masterKeys = masterKeys
+	for _, entry := range fs.filesMap {
+		// the DxFiles which cannot be unwrapped remain locked
+		_ = entry.UnwrapCipherKey(masterKeys...)
+	}
+}
+
+// readDxFile reads the DxFile at filepath, and unwraps the cipher key with the master keys.
+// If the cipher key cannot be unwrapped, the DxFile is still returned with the cipher key locked
+func (fs *FileSet) readDxFile(filepath storage.SysPath) (*DxFile, error) {
+	df, err := readDxFile(filepath, fs.wal)
+	if err != nil {
+		return nil, err
+	}
+	if err = df.UnwrapCipherKey(fs.masterKeys...); err != nil && err != ErrCipherKeyLocked {
+		return nil, err
+	}
+	return df, nil
+}
+
 // Delete delete a file with dxPath from the file set. Also the DxFile specified by dxPath on disk is also deleted
 func (fs *FileSet) Delete(dxPath storage.DxPath) error {
 	fs.lock.Lock()
@@ -239,6 +282,12 @@ func (fs *FileSet) closeEntry(entry *FileSetEntryWithID) {
 	defer entry.threadMapLock.Unlock()
 	delete(entry.threadMap, entry.threadID)
 
+	if entry.versionPath != "" {
+		if fs.versionsMap[entry.versionPath] == entry.fileSetEntry && len(entry.threadMap) == 0 {
+			delete(fs.versionsMap, entry.versionPath)
+		}
+		return
+	}
 	currentEntry := fs.filesMap[entry.metadata.DxPath]
 	if currentEntry != entry.fileSetEntry {
 		return
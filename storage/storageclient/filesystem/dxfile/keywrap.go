@@ -0,0 +1,77 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package dxfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/crypto"
+)
+
+// ErrCipherKeyLocked is the error that the cipher key of the DxFile is wrapped by the master key,
+// which has not been unlocked
+var ErrCipherKeyLocked = errors.New("cipher key is wrapped by the master key which is not unlocked")
+
+// KeyWrapped returns whether the cipher key of the DxFile is wrapped by the master key
+func (df *DxFile) KeyWrapped() bool {
+	df.lock.RLock()
+	defer df.lock.RUnlock()
+	return df.metadata.KeyWrapped
+}
+
+// WrapCipherKey wraps the cipher key of the DxFile with the master key, and saves the wrapped
+// key in the metadata. If the cipher key is already wrapped, it must have been unwrapped, and is
+// re-wrapped with the master key. Nothing is done if already wrapped by the master key
+func (df *DxFile) WrapCipherKey(masterKey crypto.CipherKey) error {
+	df.lock.Lock()
+	defer df.lock.Unlock()
+
+	if df.metadata.KeyWrapped && df.wrapKey != nil && bytes.Equal(df.wrapKey.Key(), masterKey.Key()) {
+		return nil
+	}
+	if df.cipherKey == nil {
+		if df.metadata.KeyWrapped {
+			return ErrCipherKeyLocked
+		}
+		key, err := df.metadata.newCipherKey()
+		if err != nil {
+			return err
+		}
+		df.cipherKey = key
+	}
+	wrapped, err := masterKey.Encrypt(df.cipherKey.Key())
+	if err != nil {
+		return fmt.Errorf("cannot wrap the cipher key: %v", err)
+	}
+	df.metadata.CipherKey, df.metadata.KeyWrapped = wrapped, true
+	df.wrapKey = masterKey
+	return df.saveMetadata()
+}
+
+// UnwrapCipherKey unwraps the cipher key of the DxFile with the master keys. The keys are tried
+// one by one until the cipher key is unwrapped. The unwrapped cipher key is cached and not saved
+func (df *DxFile) UnwrapCipherKey(masterKeys ...crypto.CipherKey) error {
+	df.lock.Lock()
+	defer df.lock.Unlock()
+
+	if !df.metadata.KeyWrapped || df.cipherKey != nil {
+		return nil
+	}
+	for _, masterKey := range masterKeys {
+		plain, err := masterKey.Decrypt(df.metadata.CipherKey)
+		if err != nil {
+			continue
+		}
+		key, err := crypto.NewCipherKey(df.metadata.CipherKeyCode, plain)
+		if err != nil {
+			return err
+		}
+		df.cipherKey, df.wrapKey = key, masterKey
+		return nil
+	}
+	return ErrCipherKeyLocked
+}
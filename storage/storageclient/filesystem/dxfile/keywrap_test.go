@@ -0,0 +1,142 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package dxfile
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+)
+
+// TestFileSet_WrapCipherKey test the wrapped cipher key is locked until the master key is set,
+// and could be re-wrapped with a new master key
+func TestFileSet_WrapCipherKey(t *testing.T) {
+	entry, fs := newTestFileSet(t)
+	dxPath := entry.DxPath()
+	ck, err := entry.CipherKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	masterKey, newMasterKey := newTestMasterKey(t), newTestMasterKey(t)
+	if err = entry.WrapCipherKey(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	if !entry.KeyWrapped() {
+		t.Fatalf("cipher key not wrapped")
+	}
+	if err = entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the master key, the cipher key is locked
+	checkCipherKey(t, NewFileSet(testDir, fs.wal), dxPath, nil)
+	// With the master keys, the cipher key is unwrapped by any of the keys
+	unlocked := NewFileSet(testDir, fs.wal)
+	unlocked.SetMasterKeys(newMasterKey, masterKey)
+	checkCipherKey(t, unlocked, dxPath, ck)
+
+	// Re-wrap the cipher key with the new master key
+	entry, err = unlocked.Open(dxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = entry.WrapCipherKey(newMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	if err = entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	prev := NewFileSet(testDir, fs.wal)
+	prev.SetMasterKeys(masterKey)
+	checkCipherKey(t, prev, dxPath, nil)
+	rotated := NewFileSet(testDir, fs.wal)
+	rotated.SetMasterKeys(newMasterKey)
+	checkCipherKey(t, rotated, dxPath, ck)
+}
+
+// TestFileSet_NewDxFileWrapped test the cipher key of the new DxFile is wrapped by the master key
+func TestFileSet_NewDxFileWrapped(t *testing.T) {
+	wal, _ := newWal(t)
+	fs := NewFileSet(testDir, wal)
+	masterKey := newTestMasterKey(t)
+	fs.SetMasterKeys(masterKey)
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 10, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ck, err := crypto.GenerateCipherKey(crypto.XChaCha20CipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := fs.NewDxFile(randomDxPath(), "", false, ec, ck, 1<<24, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !entry.KeyWrapped() {
+		t.Errorf("cipher key of the new file not wrapped")
+	}
+	if bytes.Equal(entry.metadata.CipherKey, ck.Key()) {
+		t.Errorf("plain cipher key saved in the metadata")
+	}
+	data, err := ioutil.ReadFile(string(entry.filePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, ck.Key()) {
+		t.Errorf("plain cipher key written to the disk")
+	}
+	share, err := entry.Share()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(share.CipherKey, ck.Key()) {
+		t.Errorf("shared cipher key not unwrapped")
+	}
+	if err = entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopened := NewFileSet(testDir, wal)
+	reopened.SetMasterKeys(masterKey)
+	checkCipherKey(t, reopened, entry.DxPath(), ck)
+}
+
+// checkCipherKey opens the DxFile in the file set and checks the cipher key. If expect is nil,
+// the cipher key is expected to be locked
+func checkCipherKey(t *testing.T, fs *FileSet, dxPath storage.DxPath, expect crypto.CipherKey) {
+	entry, err := fs.Open(dxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer entry.Close()
+	ck, err := entry.CipherKey()
+	if expect == nil {
+		if err != ErrCipherKeyLocked {
+			t.Errorf("expect error %v, got %v", ErrCipherKeyLocked, err)
+		}
+		if _, err = entry.Share(); err != ErrCipherKeyLocked {
+			t.Errorf("share locked file: expect error %v, got %v", ErrCipherKeyLocked, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ck.CodeName() != expect.CodeName() || !bytes.Equal(ck.Key(), expect.Key()) {
+		t.Errorf("unexpected cipher key unwrapped")
+	}
+}
+
+// newTestMasterKey creates a random master key
+func newTestMasterKey(t *testing.T) crypto.CipherKey {
+	key, err := crypto.GenerateCipherKey(crypto.XChaCha20CipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
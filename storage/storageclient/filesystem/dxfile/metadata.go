@@ -9,6 +9,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/storage"
@@ -61,6 +62,16 @@ type (
 
 		// Version control for fork
 		Version string
+
+		// Scheduling fields. Optional for the DxFile saved before the fields are introduced
+		Priority     storage.FilePriority `rlp:"optional"` // scheduling priority of the uploads and downloads
+		UploadPaused bool                 `rlp:"optional"` // whether the upload and repair of the file is paused
+
+		// Key wrapping fields
+		KeyWrapped bool `rlp:"optional"` // whether CipherKey is wrapped by the master key of the storage client
+
+		// Application fields
+		AppMetadata []byte `rlp:"optional"` // opaque metadata of the application uploading the file, e.g. the S3 gateway
 	}
 
 	// UpdateMetaData is the Metadata to be updated
@@ -121,6 +132,95 @@ func (df *DxFile) FileSize() uint64 {
 	return df.metadata.FileSize
 }
 
+// SetFileSize increase the file size of the dxfile, and add the empty segments needed for the
+// new size. It is used by the streaming upload where the file size is not known in advance
+func (df *DxFile) SetFileSize(size uint64) error {
+	df.lock.Lock()
+	defer df.lock.Unlock()
+
+	if df.deleted {
+		return fmt.Errorf("file %v is deleted", df.metadata.DxPath)
+	}
+	if size < df.metadata.FileSize {
+		return fmt.Errorf("cannot decrease the file size from %v to %v", df.metadata.FileSize, size)
+	}
+	df.metadata.FileSize = size
+
+	// the segments are persisted contiguously starting from SegmentOffset
+	var updates []storage.FileUpdate
+	segmentPersistSize := PageSize * segmentPersistNumPages(df.metadata.NumSectors)
+	for i := uint64(len(df.segments)); i < df.metadata.numSegments(); i++ {
+		df.segments = append(df.segments, &Segment{Sectors: make([][]*Sector, df.metadata.NumSectors), Index: i})
+		offset := df.metadata.SegmentOffset + i*segmentPersistSize
+		update, err := df.createSegmentUpdate(i, offset)
+		if err != nil {
+			return err
+		}
+		updates = append(updates, update)
+	}
+	up, err := df.createMetadataUpdate()
+	if err != nil {
+		return err
+	}
+	updates = append(updates, up)
+	return storage.ApplyUpdates(df.wal, updates)
+}
+
+// Priority return the scheduling priority of the dxfile
+func (df *DxFile) Priority() storage.FilePriority {
+	df.lock.RLock()
+	defer df.lock.RUnlock()
+	return df.metadata.Priority
+}
+
+// SetPriority set and save the scheduling priority of the dxfile
+func (df *DxFile) SetPriority(priority storage.FilePriority) error {
+	df.lock.Lock()
+	defer df.lock.Unlock()
+
+	if !priority.Valid() {
+		return fmt.Errorf("invalid priority %v", priority)
+	}
+	df.metadata.Priority = priority
+	return df.saveMetadata()
+}
+
+// UploadPaused return whether the upload and repair of the dxfile is paused
+func (df *DxFile) UploadPaused() bool {
+	df.lock.RLock()
+	defer df.lock.RUnlock()
+	return df.metadata.UploadPaused
+}
+
+// SetUploadPaused set and save whether the upload and repair of the dxfile is paused
+func (df *DxFile) SetUploadPaused(paused bool) error {
+	df.lock.Lock()
+	defer df.lock.Unlock()
+
+	df.metadata.UploadPaused = paused
+	return df.saveMetadata()
+}
+
+// AppMetadata return the metadata saved by the application uploading the dxfile
+func (df *DxFile) AppMetadata() []byte {
+	df.lock.RLock()
+	defer df.lock.RUnlock()
+	return common.CopyBytes(df.metadata.AppMetadata)
+}
+
+// SetAppMetadata set and save the metadata of the application uploading the dxfile. The size
+// of the metadata is limited by MaxAppMetadataSize, so that the metadata fits in a page
+func (df *DxFile) SetAppMetadata(meta []byte) error {
+	df.lock.Lock()
+	defer df.lock.Unlock()
+
+	if len(meta) > MaxAppMetadataSize {
+		return fmt.Errorf("app metadata size %v exceeds the limit %v", len(meta), MaxAppMetadataSize)
+	}
+	df.metadata.AppMetadata = common.CopyBytes(meta)
+	return df.saveMetadata()
+}
+
 // TimeModify return the TimeModify of a DxFile
 func (df *DxFile) TimeModify() time.Time {
 	df.lock.RLock()
@@ -219,7 +319,8 @@ func (df *DxFile) SegmentSize() uint64 {
 	return df.metadata.segmentSize()
 }
 
-// CipherKey return the cipher key
+// CipherKey return the cipher key. If the cipher key is wrapped by the master key which has not
+// been unlocked, ErrCipherKeyLocked is returned
 func (df *DxFile) CipherKey() (crypto.CipherKey, error) {
 	df.lock.RLock()
 	defer df.lock.RUnlock()
@@ -227,6 +328,9 @@ func (df *DxFile) CipherKey() (crypto.CipherKey, error) {
 	if df.cipherKey != nil {
 		return df.cipherKey, nil
 	}
+	if df.metadata.KeyWrapped {
+		return nil, ErrCipherKeyLocked
+	}
 	key, err := crypto.NewCipherKey(df.metadata.CipherKeyCode, df.metadata.CipherKey)
 	if err != nil {
 		// this should never happen
@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"testing"
 
+	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
 )
 
@@ -81,3 +82,95 @@ func makeUint32Byte(num uint32) []byte {
 	binary.LittleEndian.PutUint32(uint32Byte, num)
 	return uint32Byte
 }
+
+// TestDxFile_SetFileSize test increasing the file size of a DxFile, and the added segments
+// could be written and loaded
+func TestDxFile_SetFileSize(t *testing.T) {
+	df, err := newTestDxFile(t, 0, 10, 30, erasurecode.ECTypeStandard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if df.NumSegments() != 1 {
+		t.Fatalf("empty file should have 1 segment, got %v", df.NumSegments())
+	}
+	newSize := df.metadata.segmentSize()*3 + 1
+	if err = df.SetFileSize(newSize); err != nil {
+		t.Fatal(err)
+	}
+	if df.NumSegments() != 4 {
+		t.Fatalf("unexpected number of segments. Expect %v, Got %v", 4, df.NumSegments())
+	}
+	newAddr, newHash := randomAddress(), randomHash()
+	if err = df.AddSector(newAddr, newHash, 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = df.SetFileSize(newSize - 1); err == nil {
+		t.Errorf("decreasing file size should return an error")
+	}
+
+	recoveredDF, err := readDxFile(df.filePath, df.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recoveredDF.FileSize() != newSize || recoveredDF.NumSegments() != 4 {
+		t.Fatalf("recovered file not expected: size %v, segments %v", recoveredDF.FileSize(), recoveredDF.NumSegments())
+	}
+	sectors := recoveredDF.segments[3].Sectors[0]
+	if len(sectors) != 1 || sectors[0].MerkleRoot != newHash || sectors[0].HostID != newAddr {
+		t.Errorf("sector added to the new segment not expected")
+	}
+}
+
+// TestDxFile_SetPriority test the priority and the paused status of a DxFile could be set
+// and saved to disk
+func TestDxFile_SetPriority(t *testing.T) {
+	df, err := newTestDxFile(t, sectorSize*10, 10, 30, erasurecode.ECTypeStandard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if df.Priority() != storage.PriorityNormal || df.UploadPaused() {
+		t.Fatalf("new file not expected: priority %v, paused %v", df.Priority(), df.UploadPaused())
+	}
+	if err = df.SetPriority(0); err == nil {
+		t.Errorf("setting an invalid priority should return an error")
+	}
+	if err = df.SetPriority(storage.PriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+	if err = df.SetUploadPaused(true); err != nil {
+		t.Fatal(err)
+	}
+	recoveredDF, err := readDxFile(df.filePath, df.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recoveredDF.Priority() != storage.PriorityHigh || !recoveredDF.UploadPaused() {
+		t.Errorf("recovered file not expected: priority %v, paused %v", recoveredDF.Priority(), recoveredDF.UploadPaused())
+	}
+}
+
+// TestDxFile_SetAppMetadata test the app metadata of a DxFile could be set and saved to disk,
+// and the metadata exceeding the size limit is rejected
+func TestDxFile_SetAppMetadata(t *testing.T) {
+	df, err := newTestDxFile(t, sectorSize*10, 10, 30, erasurecode.ECTypeStandard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(df.AppMetadata()) != 0 {
+		t.Fatalf("new file shall have no app metadata: %x", df.AppMetadata())
+	}
+	if err = df.SetAppMetadata(make([]byte, MaxAppMetadataSize+1)); err == nil {
+		t.Errorf("setting the app metadata exceeding the limit should return an error")
+	}
+	meta := bytes.Repeat([]byte{0xab}, MaxAppMetadataSize)
+	if err = df.SetAppMetadata(meta); err != nil {
+		t.Fatal(err)
+	}
+	recoveredDF, err := readDxFile(df.filePath, df.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(recoveredDF.AppMetadata(), meta) {
+		t.Errorf("recovered app metadata not expected")
+	}
+}
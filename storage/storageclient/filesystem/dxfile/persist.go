@@ -21,6 +21,10 @@ const (
 	// PageSize is the page size of persist Data
 	PageSize = 4096
 
+	// MaxAppMetadataSize is the max size of the app metadata saved in the metadata of a dxfile,
+	// which leaves enough space of the metadata page for the other fields
+	MaxAppMetadataSize = 1024
+
 	// sectorPersistSize is the size of rlp encoded string of a Sector
 	sectorPersistSize = 70
 
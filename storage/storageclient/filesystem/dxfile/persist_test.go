@@ -5,6 +5,7 @@
 package dxfile
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 	"time"
@@ -111,6 +112,9 @@ func TestMetadata_EncodeRLP_DecodeRLP(t *testing.T) {
 		NumSectors:          30,
 		ECExtra:             []byte{},
 		Version:             "1.0.0",
+		Priority:            storage.PriorityHigh,
+		UploadPaused:        true,
+		KeyWrapped:          true,
 	}
 	b, err := rlp.EncodeToBytes(meta)
 	if err != nil {
@@ -125,3 +129,64 @@ func TestMetadata_EncodeRLP_DecodeRLP(t *testing.T) {
 		t.Errorf("not Equal\n\texpect %+v\n\tgot %+v", meta, md)
 	}
 }
+
+// TestDxFile_LoadLegacyMetadata test the metadata saved without the scheduling fields or the
+// key wrapping field could be loaded with the default values
+func TestDxFile_LoadLegacyMetadata(t *testing.T) {
+	path, err := storage.NewDxPath(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := Metadata{
+		HostTableOffset: PageSize,
+		SegmentOffset:   2 * PageSize,
+		FileSize:        randomUint64(),
+		DxPath:          path,
+		CipherKeyCode:   crypto.GCMCipherCode,
+		CipherKey:       randomBytes(twofishgcm.GCMCipherKeyLength),
+		ErasureCodeType: erasurecode.ECTypeShard,
+		MinSectors:      10,
+		NumSectors:      30,
+		ECExtra:         []byte{},
+		Version:         "1.0.0",
+		Priority:        storage.PriorityHigh,
+		UploadPaused:    true,
+		KeyWrapped:      true,
+	}
+	b, err := rlp.EncodeToBytes(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []rlp.RawValue
+	if err = rlp.DecodeBytes(b, &fields); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		removed int
+		expect  func(md Metadata) Metadata
+	}{
+		// Remove the KeyWrapped field
+		{1, func(md Metadata) Metadata {
+			md.KeyWrapped = false
+			return md
+		}},
+		// Remove the Priority, UploadPaused and KeyWrapped fields
+		{3, func(md Metadata) Metadata {
+			md.Priority, md.UploadPaused, md.KeyWrapped = storage.PriorityNormal, false, false
+			return md
+		}},
+	}
+	for _, test := range tests {
+		legacy, err := rlp.EncodeToBytes(fields[:len(fields)-test.removed])
+		if err != nil {
+			t.Fatal(err)
+		}
+		df := &DxFile{}
+		if err = df.loadMetadata(bytes.NewReader(legacy)); err != nil {
+			t.Fatal(err)
+		}
+		if expect := test.expect(meta); !reflect.DeepEqual(*df.metadata, expect) {
+			t.Errorf("%v fields removed: not Equal\n\texpect %+v\n\tgot %+v", test.removed, expect, *df.metadata)
+		}
+	}
+}
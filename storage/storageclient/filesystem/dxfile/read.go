@@ -43,7 +43,10 @@ func readDxFile(filepath storage.SysPath, wal *writeaheadlog.Wal) (*DxFile, erro
 	if df.erasureCode, err = df.metadata.newErasureCode(); err != nil {
 		return nil, fmt.Errorf("cannot new erasureCode: %v", err)
 	}
-	// New cipher key
+	// New cipher key. The wrapped cipher key is created when unwrapped by the master key
+	if df.metadata.KeyWrapped {
+		return df, nil
+	}
 	if df.cipherKey, err = df.metadata.newCipherKey(); err != nil {
 		return nil, fmt.Errorf("cannot new cipherKey: %v", err)
 	}
@@ -56,6 +59,10 @@ func (df *DxFile) loadMetadata(f io.Reader) error {
 	if err != nil {
 		return err
 	}
+	// The DxFile saved before the scheduling fields are introduced does not have the priority
+	if df.metadata.Priority == 0 {
+		df.metadata.Priority = storage.PriorityNormal
+	}
 	// sanity check
 	if err = df.metadata.validate(); err != nil {
 		return err
@@ -0,0 +1,145 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package dxfile
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// Share is the shareable content of a DxFile. With the sector roots, erasure code params and
+// the cipher key, the file content could be downloaded from the storage hosts by other storage
+// clients without the DxFile and the contracts of the sharer
+type Share struct {
+	DxPath     string
+	FileSize   uint64
+	SectorSize uint64
+	FileMode   uint32
+
+	ErasureCodeType uint8
+	MinSectors      uint32
+	NumSectors      uint32
+	ECExtra         []byte
+
+	CipherKeyCode uint8
+	CipherKey     []byte
+
+	Segments []*Segment
+}
+
+// Share creates the Share of the DxFile
+func (df *DxFile) Share() (Share, error) {
+	df.lock.RLock()
+	defer df.lock.RUnlock()
+
+	if df.deleted {
+		return Share{}, fmt.Errorf("file has been deleted")
+	}
+	cipherKey := df.metadata.CipherKey
+	if df.metadata.KeyWrapped {
+		if df.cipherKey == nil {
+			return Share{}, ErrCipherKeyLocked
+		}
+		cipherKey = df.cipherKey.Key()
+	}
+	segments := make([]*Segment, 0, len(df.segments))
+	for _, segment := range df.segments {
+		seg := copySegment(segment)
+		segments = append(segments, &seg)
+	}
+	return Share{
+		DxPath:          df.metadata.DxPath.Path,
+		FileSize:        df.metadata.FileSize,
+		SectorSize:      df.metadata.SectorSize,
+		FileMode:        uint32(df.metadata.FileMode),
+		ErasureCodeType: df.metadata.ErasureCodeType,
+		MinSectors:      df.metadata.MinSectors,
+		NumSectors:      df.metadata.NumSectors,
+		ECExtra:         append([]byte{}, df.metadata.ECExtra...),
+		CipherKeyCode:   df.metadata.CipherKeyCode,
+		CipherKey:       append([]byte{}, cipherKey...),
+		Segments:        segments,
+	}, nil
+}
+
+// Snapshot creates the Snapshot of the shared file, which could be used to download the
+// shared file
+func (s Share) Snapshot() (*Snapshot, error) {
+	md := Metadata{
+		FileSize:        s.FileSize,
+		SectorSize:      s.SectorSize,
+		ErasureCodeType: s.ErasureCodeType,
+		MinSectors:      s.MinSectors,
+		NumSectors:      s.NumSectors,
+		ECExtra:         s.ECExtra,
+		CipherKeyCode:   s.CipherKeyCode,
+		CipherKey:       s.CipherKey,
+	}
+	ec, err := md.newErasureCode()
+	if err != nil {
+		return nil, err
+	}
+	ck, err := md.newCipherKey()
+	if err != nil {
+		return nil, err
+	}
+	dxPath, err := storage.NewDxPath(s.DxPath)
+	if err != nil {
+		return nil, err
+	}
+	if s.SectorSize == 0 {
+		return nil, fmt.Errorf("invalid sector size 0")
+	}
+	if expect := md.numSegments(); uint64(len(s.Segments)) != expect {
+		return nil, fmt.Errorf("unexpected number of segments: expect %v, got %v", expect, len(s.Segments))
+	}
+
+	hostTable := make(map[enode.ID]bool)
+	segments := make([]Segment, 0, len(s.Segments))
+	for i, segment := range s.Segments {
+		if uint32(len(segment.Sectors)) != s.NumSectors {
+			return nil, fmt.Errorf("unexpected number of sectors in segment %v: expect %v, got %v", i, s.NumSectors, len(segment.Sectors))
+		}
+		seg := copySegment(segment)
+		seg.Index = uint64(i)
+		for _, sectors := range seg.Sectors {
+			for _, sector := range sectors {
+				hostTable[sector.HostID] = true
+			}
+		}
+		segments = append(segments, seg)
+	}
+
+	return &Snapshot{
+		fileSize:    s.FileSize,
+		sectorSize:  s.SectorSize,
+		erasureCode: ec,
+		cipherKey:   ck,
+		fileMode:    os.FileMode(s.FileMode),
+		segments:    segments,
+		hostTable:   hostTable,
+		dxPath:      dxPath,
+	}, nil
+}
+
+// HostIDs returns the IDs of the hosts storing the sectors of the shared file
+func (s Share) HostIDs() []enode.ID {
+	var ids []enode.ID
+	added := make(map[enode.ID]bool)
+	for _, segment := range s.Segments {
+		for _, sectors := range segment.Sectors {
+			for _, sector := range sectors {
+				if !added[sector.HostID] {
+					added[sector.HostID] = true
+					ids = append(ids, sector.HostID)
+				}
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package dxfile
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+)
+
+// TestDxFile_Share test the Share of a DxFile could be rlp encoded, decoded, and then
+// converted to the Snapshot same as the one of the DxFile
+func TestDxFile_Share(t *testing.T) {
+	numSector := uint32(30)
+	minSector := uint32(10)
+	df, err := newTestDxFileWithSegments(t, sectorSize*uint64(minSector)*10, minSector, numSector, erasurecode.ECTypeShard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share, err := df.Share()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := rlp.EncodeToBytes(share)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Share
+	if err = rlp.DecodeBytes(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	got, err := decoded.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect, err := df.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.fileSize != expect.fileSize || got.sectorSize != expect.sectorSize || got.fileMode != expect.fileMode {
+		t.Errorf("file params not expected: %v/%v/%v != %v/%v/%v", got.fileSize, got.sectorSize, got.fileMode,
+			expect.fileSize, expect.sectorSize, expect.fileMode)
+	}
+	if !got.dxPath.Equals(expect.dxPath) {
+		t.Errorf("dxPath not expected: %v != %v", got.dxPath.Path, expect.dxPath.Path)
+	}
+	if got.erasureCode.Type() != expect.erasureCode.Type() || got.erasureCode.MinSectors() != minSector ||
+		got.erasureCode.NumSectors() != numSector {
+		t.Errorf("erasure code not expected")
+	}
+	if got.cipherKey.CodeName() != expect.cipherKey.CodeName() || !bytes.Equal(got.cipherKey.Key(), expect.cipherKey.Key()) {
+		t.Errorf("cipher key not expected")
+	}
+	// offset is the persist position in the DxFile, which is not shared
+	for i := range expect.segments {
+		expect.segments[i].offset = 0
+	}
+	if !reflect.DeepEqual(got.segments, expect.segments) {
+		t.Errorf("segments not expected")
+	}
+	if !reflect.DeepEqual(got.hostTable, expect.hostTable) {
+		t.Errorf("host table not expected")
+	}
+	if len(decoded.HostIDs()) != len(expect.hostTable) {
+		t.Errorf("number of hosts not expected. Expect %v, Got %v", len(expect.hostTable), len(decoded.HostIDs()))
+	}
+
+	// A share with missing segments cannot be converted to snapshot
+	decoded.Segments = decoded.Segments[1:]
+	if _, err = decoded.Snapshot(); err == nil {
+		t.Errorf("snapshot of share with missing segments should return an error")
+	}
+}
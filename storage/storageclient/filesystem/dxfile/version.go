@@ -0,0 +1,181 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package dxfile
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// Archive moves the DxFile specified by dxPath to versionPath as a retained version. The DxPath
+// recorded in the version is kept, and the DxFile is removed from the file set
+func (fs *FileSet) Archive(dxPath storage.DxPath, versionPath storage.SysPath) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	entry, err := fs.open(dxPath)
+	if err != nil {
+		return err
+	}
+	defer fs.closeEntry(entry)
+	if err = entry.archive(versionPath); err != nil {
+		return err
+	}
+
+	delete(fs.filesMap, dxPath)
+	return nil
+}
+
+// Restore moves the DxFile version at versionPath back to dxPath. If a DxFile already exists
+// at dxPath, ErrFileExist is returned. If the version is opened, the entry is restored along
+// with the threads using it
+func (fs *FileSet) Restore(dxPath storage.DxPath, versionPath storage.SysPath) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if fs.exists(dxPath) {
+		return ErrFileExist
+	}
+	entry, opened := fs.versionsMap[versionPath]
+	if !opened {
+		df, err := readDxFile(versionPath, fs.wal)
+		if os.IsNotExist(err) {
+			return ErrUnknownFile
+		}
+		if err != nil {
+			return err
+		}
+		entry = fs.newFileSetEntry(df)
+	}
+	// remove the entry of the deleted DxFile which is still opened by other threads
+	delete(fs.filesMap, dxPath)
+
+	if err := entry.Rename(dxPath, fs.filepath(dxPath)); err != nil {
+		return err
+	}
+	if opened {
+		delete(fs.versionsMap, versionPath)
+		entry.versionPath = ""
+		fs.filesMap[dxPath] = entry
+	}
+	return nil
+}
+
+// OpenVersion opens the DxFile version at versionPath, so that the sectors of the version
+// could be repaired. The threads opening the same version share the entry, and the entry
+// is released when all threads are closed
+func (fs *FileSet) OpenVersion(versionPath storage.SysPath) (*FileSetEntryWithID, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	entry, exist := fs.versionsMap[versionPath]
+	if !exist {
+		df, err := fs.readDxFile(versionPath)
+		if os.IsNotExist(err) {
+			return nil, ErrUnknownFile
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry = fs.newFileSetEntry(df)
+		entry.versionPath = versionPath
+		fs.versionsMap[versionPath] = entry
+	}
+	if entry.Deleted() {
+		return nil, ErrUnknownFile
+	}
+	// Register the threadID
+	threadID := randomThreadID()
+	entry.threadMapLock.Lock()
+	defer entry.threadMapLock.Unlock()
+	entry.threadMap[threadID] = newThreadInfo()
+	return &FileSetEntryWithID{
+		fileSetEntry: entry,
+		threadID:     threadID,
+	}, nil
+}
+
+// ReadVersion reads the DxFile version at versionPath. The returned DxFile is not registered
+// in the file set, and should only be used for reading
+func (fs *FileSet) ReadVersion(versionPath storage.SysPath) (*DxFile, error) {
+	fs.lock.Lock()
+	df, err := fs.version(versionPath)
+	fs.lock.Unlock()
+	if os.IsNotExist(err) {
+		return nil, ErrUnknownFile
+	}
+	return df, err
+}
+
+// MoveVersion moves the DxFile version at prevPath to newPath. The opened entry of the
+// version, if exists, is moved as well
+func (fs *FileSet) MoveVersion(prevPath, newPath storage.SysPath) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(string(newPath)), 0700); err != nil {
+		return err
+	}
+	entry, opened := fs.versionsMap[prevPath]
+	if opened {
+		entry.lock.Lock()
+		defer entry.lock.Unlock()
+	}
+	if err := os.Rename(string(prevPath), string(newPath)); err != nil {
+		return err
+	}
+	if opened {
+		entry.filePath = newPath
+		entry.versionPath = newPath
+		delete(fs.versionsMap, prevPath)
+		fs.versionsMap[newPath] = entry
+	}
+	return nil
+}
+
+// DeleteVersion deletes the DxFile version at versionPath
+func (fs *FileSet) DeleteVersion(versionPath storage.SysPath) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	df, err := fs.version(versionPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	delete(fs.versionsMap, versionPath)
+	return df.Delete()
+}
+
+// version returns the DxFile version at versionPath, which is the opened one if exists.
+// fs.lock should be held
+func (fs *FileSet) version(versionPath storage.SysPath) (*DxFile, error) {
+	if entry, exist := fs.versionsMap[versionPath]; exist {
+		return entry.DxFile, nil
+	}
+	return fs.readDxFile(versionPath)
+}
+
+// archive moves the DxFile to versionPath without changing the DxPath. The local path is
+// cleared, since the local file belongs to the live DxFile and the version shall only be
+// repaired from the hosts. After archived, the DxFile is regarded as deleted
+func (df *DxFile) archive(versionPath storage.SysPath) error {
+	df.lock.Lock()
+	defer df.lock.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(string(versionPath)), 0700); err != nil {
+		return err
+	}
+	df.metadata.LocalPath = ""
+	if err := df.rename(df.metadata.DxPath, versionPath); err != nil {
+		return err
+	}
+	df.deleted = true
+	return nil
+}
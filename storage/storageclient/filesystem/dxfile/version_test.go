@@ -0,0 +1,117 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package dxfile
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestFileSet_ArchiveRestore test the process of archive a DxFile as a version, and then
+// restore the version
+func TestFileSet_ArchiveRestore(t *testing.T) {
+	entry, fs := newTestFileSet(t)
+	dxPath := entry.metadata.DxPath
+	fileSize := entry.FileSize()
+	versionPath := testDir.Join(randomDxPath(), "version"+storage.DxFileExt)
+
+	if err := fs.Archive(dxPath, versionPath); err != nil {
+		t.Fatal(err)
+	}
+	if fs.Exists(dxPath) {
+		t.Errorf("After archive, the dxPath should not exist")
+	}
+	if !entry.Deleted() {
+		t.Errorf("After archive, the opened entry should be deleted")
+	}
+	version, err := fs.ReadVersion(versionPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !version.DxPath().Equals(dxPath) || version.FileSize() != fileSize {
+		t.Errorf("version not expected. DxPath %v, FileSize %v", version.DxPath().Path, version.FileSize())
+	}
+	if err = entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = fs.Restore(dxPath, versionPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(string(versionPath)); !os.IsNotExist(err) {
+		t.Errorf("After restore, the version file should not exist: %v", err)
+	}
+	restored, err := fs.Open(dxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+	if restored.FileSize() != fileSize {
+		t.Errorf("restored file size not expected. Expect %v, Got %v", fileSize, restored.FileSize())
+	}
+	if err = fs.Restore(dxPath, versionPath); err != ErrFileExist {
+		t.Errorf("restore to an existing file: expect error %v, got %v", ErrFileExist, err)
+	}
+}
+
+// TestFileSet_OpenVersion test opening a version for repair, moving the opened version, and
+// closing the version
+func TestFileSet_OpenVersion(t *testing.T) {
+	entry, fs := newTestFileSet(t)
+	dxPath := entry.metadata.DxPath
+	versionPath := testDir.Join(randomDxPath(), "version"+storage.DxFileExt)
+	if err := fs.Archive(dxPath, versionPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := fs.OpenVersion(versionPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !version.DxPath().Equals(dxPath) {
+		t.Errorf("version DxPath not expected. Expect %v, Got %v", dxPath.Path, version.DxPath().Path)
+	}
+	if version.metadata.LocalPath != "" {
+		t.Errorf("version should not keep the local path, got %v", version.metadata.LocalPath)
+	}
+	if _, exist := fs.filesMap[dxPath]; exist {
+		t.Errorf("opened version should not be registered as a dxfile")
+	}
+
+	newPath := testDir.Join(randomDxPath(), "version"+storage.DxFileExt)
+	if err = fs.MoveVersion(versionPath, newPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, exist := fs.versionsMap[versionPath]; exist {
+		t.Errorf("after move, the previous version path should not be opened")
+	}
+	if _, exist := fs.versionsMap[newPath]; !exist {
+		t.Errorf("after move, the new version path should be opened")
+	}
+	if version.filePath != newPath {
+		t.Errorf("after move, the opened version should be saved to %v, got %v", newPath, version.filePath)
+	}
+	if err = version.SetStuckByIndex(0, true); err != nil {
+		t.Fatal(err)
+	}
+	if err = version.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(fs.versionsMap) != 0 {
+		t.Errorf("after close, the versions map should be empty, got %v", len(fs.versionsMap))
+	}
+	saved, err := fs.ReadVersion(newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved.GetStuckByIndex(0) {
+		t.Errorf("update of the opened version should be saved to the new path")
+	}
+}
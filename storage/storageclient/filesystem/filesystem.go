@@ -7,8 +7,10 @@ package filesystem
 import (
 	"crypto/rand"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -39,6 +41,9 @@ type fileSystem struct {
 	// persistDir is the directory of containing the persist files
 	persistDir storage.SysPath
 
+	// versionRootDir is the root directory where the retained versions of dxfiles locate
+	versionRootDir storage.SysPath
+
 	// fileSet is the fileSet from module dxfile
 	fileSet *dxfile.FileSet
 
@@ -65,6 +70,12 @@ type fileSystem struct {
 	// lock is meant to protect the map unfinishedUpdates
 	lock sync.Mutex
 
+	// dirOpLock makes the recursive directory operations executed exclusively
+	dirOpLock sync.Mutex
+
+	// versionLock protects the retained versions from concurrent archive, restore and purge
+	versionLock sync.Mutex
+
 	// log is the logger used for file system
 	logger log.Logger
 
@@ -85,6 +96,7 @@ func newFileSystem(persistDir string, contractor contractManager, disrupter disr
 	return &fileSystem{
 		fileRootDir:       storage.SysPath(filepath.Join(persistDir, filesDirectory)),
 		persistDir:        storage.SysPath(persistDir),
+		versionRootDir:    storage.SysPath(filepath.Join(persistDir, versionsDirectory)),
 		contractManager:   contractor,
 		tm:                &threadmanager.ThreadManager{},
 		logger:            log.New("module", "filesystem"),
@@ -112,8 +124,9 @@ func (fs *fileSystem) Start() error {
 	if err := fs.loadUpdateWal(); err != nil {
 		return fmt.Errorf("cannot start the file system: %v", err)
 	}
-	// Start the repair loop
+	// Start the repair loop and the version prune loop
 	go fs.loopRepairUnfinishedDirMetadataUpdate()
+	go fs.loopPruneVersions()
 	return nil
 }
 
@@ -148,8 +161,42 @@ func (fs *fileSystem) PersistDir() storage.SysPath {
 }
 
 // NewDxFile creates a new dxfile in the file system
+// If force and versioning is enabled, the existing dxfile is archived as a version.
+// The new dxfile inherits the scheduling priority and upload pause of its directory
 func (fs *fileSystem) NewDxFile(dxPath storage.DxPath, sourcePath storage.SysPath, force bool, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode) (*dxfile.FileSetEntryWithID, error) {
-	return fs.fileSet.NewDxFile(dxPath, sourcePath, force, erasureCode, cipherKey, fileSize, fileMode)
+	if force {
+		if _, err := fs.archiveVersion(dxPath); err != nil && err != dxfile.ErrUnknownFile {
+			return nil, err
+		}
+	}
+	entry, err := fs.fileSet.NewDxFile(dxPath, sourcePath, force, erasureCode, cipherKey, fileSize, fileMode)
+	if err != nil {
+		return nil, err
+	}
+	// the new dxfile has the scheduling settings of the nearest parent directory
+	if err = fs.inheritScheduling(entry, dxPath); err != nil {
+		entry.Close()
+		return nil, err
+	}
+	return entry, nil
+}
+
+// inheritScheduling set the scheduling priority and upload pause status of the dxfile to the
+// settings of the nearest parent directory
+func (fs *fileSystem) inheritScheduling(entry *dxfile.FileSetEntryWithID, dxPath storage.DxPath) error {
+	priority, paused, err := fs.dirSet.ParentScheduling(dxPath)
+	if err != nil {
+		return err
+	}
+	if priority != 0 && priority != entry.Priority() {
+		if err = entry.SetPriority(priority); err != nil {
+			return err
+		}
+	}
+	if paused {
+		return entry.SetUploadPaused(true)
+	}
+	return nil
 }
 
 // OpenDxFile opens the DxFile specified by the path
@@ -157,14 +204,159 @@ func (fs *fileSystem) OpenDxFile(path storage.DxPath) (*dxfile.FileSetEntryWithI
 	return fs.fileSet.Open(path)
 }
 
-// Delete delete the dxfile from the file system
+// Delete delete the dxfile from the file system. If versioning is enabled, the dxfile
+// is archived as a version instead
 func (fs *fileSystem) DeleteDxFile(dxPath storage.DxPath) error {
+	archived, err := fs.archiveVersion(dxPath)
+	if err == dxfile.ErrUnknownFile {
+		return nil
+	}
+	if err != nil || archived {
+		return err
+	}
 	return fs.fileSet.Delete(dxPath)
 }
 
-// RenameDxFile rename the dxfile from prevPath to newPath
+// RenameDxFile rename the dxfile from prevPath to newPath, along with its versions
 func (fs *fileSystem) RenameDxFile(prevPath, newPath storage.DxPath) error {
-	return fs.fileSet.Rename(prevPath, newPath)
+	if err := fs.fileSet.Rename(prevPath, newPath); err != nil {
+		return err
+	}
+	return fs.moveVersions(fs.versionDir(prevPath), fs.versionDir(newPath))
+}
+
+// ReplaceDxFile replaces the dxfile at dxPath with the dxfile at srcPath. If versioning is
+// enabled, the existing dxfile is archived as a version, otherwise it is deleted. Return
+// whether the existing dxfile is archived. The intent is recorded in updateWal, so that
+// the replacement could be continued after an unexpected shutdown
+func (fs *fileSystem) ReplaceDxFile(srcPath, dxPath storage.DxPath) (bool, error) {
+	if !fs.fileSet.Exists(srcPath) {
+		return false, dxfile.ErrUnknownFile
+	}
+	txn, err := fs.recordDirOperationIntent(fileReplaceName, dirOperation{srcPath.Path, dxPath.Path})
+	if err != nil {
+		return false, fmt.Errorf("cannot record the replace intent: %v", err)
+	}
+	archived, err := fs.replaceDxFile(srcPath, dxPath)
+	if err != nil {
+		return false, err
+	}
+	return archived, txn.Release()
+}
+
+// UploadAndReplaceDxFile uploads the dxfile at uploadPath with the upload function, which then
+// replaces the dxfile at dxPath. Thus the existing dxfile is kept if the upload fails, in which
+// case the uploading dxfile is deleted. Return whether the replaced dxfile is archived
+func (fs *fileSystem) UploadAndReplaceDxFile(uploadPath, dxPath storage.DxPath, upload func() error) (bool, error) {
+	if err := upload(); err != nil {
+		fs.deleteUploadingDxFile(uploadPath)
+		return false, fmt.Errorf("failed to upload the file: %v", err)
+	}
+	archived, err := fs.ReplaceDxFile(uploadPath, dxPath)
+	if err != nil {
+		fs.deleteUploadingDxFile(uploadPath)
+		return false, fmt.Errorf("failed to replace the file: %v", err)
+	}
+	parent, err := dxPath.Parent()
+	if err != nil {
+		return archived, nil
+	}
+	if err = fs.InitAndUpdateDirMetadata(parent); err != nil {
+		fs.logger.Warn("InitAndUpdateDirMetadata error", "dxPath", parent.Path, "err", err)
+	}
+	return archived, nil
+}
+
+// UploadStagedFile uploads the data staged in the local file as the dxfile at dxPath, which
+// replaces the existing dxfile once the upload completes. The data is streamed by the upload
+// function to a temporary dxfile next to dxPath, and the upload function shall return once the
+// data is recoverable from the storage hosts. The uploaded dxfile has no local path and is
+// repaired from the storage hosts, thus the staged file is removed whether the upload succeeds
+// or not. The app metadata, if not empty, is saved in the uploaded dxfile before it replaces
+// the existing one. Return whether the replaced dxfile is archived
+func (fs *fileSystem) UploadStagedFile(stagedPath string, dxPath storage.DxPath, appMeta []byte, upload func(uploadPath storage.DxPath, r io.Reader) error) (bool, error) {
+	defer func() {
+		if err := os.Remove(stagedPath); err != nil && !os.IsNotExist(err) {
+			fs.logger.Warn("failed to remove the staged file", "path", stagedPath, "err", err)
+		}
+	}()
+	uploadPath, err := uploadingDxPath(dxPath)
+	if err != nil {
+		return false, err
+	}
+	staged, err := os.Open(stagedPath)
+	if err != nil {
+		return false, err
+	}
+	defer staged.Close()
+
+	return fs.UploadAndReplaceDxFile(uploadPath, dxPath, func() error {
+		if err := upload(uploadPath, staged); err != nil {
+			return err
+		}
+		if len(appMeta) == 0 {
+			return nil
+		}
+		entry, err := fs.fileSet.Open(uploadPath)
+		if err != nil {
+			return err
+		}
+		defer entry.Close()
+		return entry.SetAppMetadata(appMeta)
+	})
+}
+
+// RemoveDxFile deletes the dxfile, or archives it as a version if versioning is enabled, and
+// updates the metadata of the parent directory
+func (fs *fileSystem) RemoveDxFile(dxPath storage.DxPath) error {
+	if err := fs.DeleteDxFile(dxPath); err != nil {
+		return err
+	}
+	parent, err := dxPath.Parent()
+	if err != nil {
+		return nil
+	}
+	if err = fs.InitAndUpdateDirMetadata(parent); err != nil {
+		fs.logger.Warn("InitAndUpdateDirMetadata error", "dxPath", parent.Path, "err", err)
+	}
+	return nil
+}
+
+// uploadingDxPath returns a unique temporary DxPath next to dxPath for the staged file to be
+// uploaded to
+func uploadingDxPath(dxPath storage.DxPath) (storage.DxPath, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return storage.DxPath{}, err
+	}
+	return storage.NewDxPath(dxPath.Path + "." + hex.EncodeToString(id) + uploadingExt)
+}
+
+// deleteUploadingDxFile deletes the dxfile of the failed upload if created
+func (fs *fileSystem) deleteUploadingDxFile(uploadPath storage.DxPath) {
+	if err := fs.fileSet.Delete(uploadPath); err != nil && err != dxfile.ErrUnknownFile {
+		fs.logger.Warn("failed to delete the uploading file", "dxPath", uploadPath.Path, "err", err)
+	}
+}
+
+// replaceDxFile archives or deletes the dxfile at dxPath, and then renames the dxfile at
+// srcPath to dxPath. The dxfile at srcPath no longer exists once it is renamed, in which
+// case the replacement is finished, thus replaceDxFile could be safely redone with the
+// same arguments
+func (fs *fileSystem) replaceDxFile(srcPath, dxPath storage.DxPath) (bool, error) {
+	if !fs.fileSet.Exists(srcPath) {
+		return false, fs.moveVersions(fs.versionDir(srcPath), fs.versionDir(dxPath))
+	}
+	archived, err := fs.archiveVersion(dxPath)
+	if err != nil && err != dxfile.ErrUnknownFile {
+		return false, err
+	}
+	if !archived {
+		if err = fs.fileSet.Delete(dxPath); err != nil && err != dxfile.ErrUnknownFile {
+			return false, err
+		}
+	}
+	return archived, fs.RenameDxFile(srcPath, dxPath)
 }
 
 // NewDxDir creates a new dxdir specified by path
@@ -219,7 +411,7 @@ LOOP:
 				continue
 			}
 			fHealth := df.GetHealth()
-			if dxfile.CmpRepairPriority(fHealth, health) >= 0 {
+			if !df.UploadPaused() && dxfile.CmpRepairPriority(fHealth, health) >= 0 {
 				// This is the file we want to repair
 				return df, nil
 			}
@@ -431,7 +623,13 @@ func (fs *fileSystem) loadUpdateWal() error {
 	if err != nil {
 		return fmt.Errorf("cannot start file system updateWal: %v", err)
 	}
+	var dirOpTxns []*writeaheadlog.Transaction
 	for i, txn := range unappliedTxns {
+		// directory operations are redone after the updateWal is loaded
+		if len(txn.Operations) != 0 && isDirOperation(txn.Operations[0]) {
+			dirOpTxns = append(dirOpTxns, txn)
+			continue
+		}
 		for j, op := range txn.Operations {
 			path, err := decodeWalOp(op)
 			if err != nil {
@@ -447,6 +645,15 @@ func (fs *fileSystem) loadUpdateWal() error {
 		}
 	}
 	fs.updateWal = updateWal
+	// redo the unfinished directory operations
+	for i, txn := range dirOpTxns {
+		if err = fs.redoDirOperation(txn.Operations[0]); err != nil {
+			fs.logger.Warn(fmt.Sprintf("cannot redo directory operation txn[%d]", i), "error", err)
+		}
+		if err = txn.Release(); err != nil {
+			fs.logger.Warn(fmt.Sprintf("cannot release directory operation txn[%d]", i), "error", err)
+		}
+	}
 	return nil
 }
 
@@ -574,6 +781,8 @@ func (fs *fileSystem) fileDetailedInfo(path storage.DxPath, table storage.HostHe
 		Redundancy:     redundancy,
 		StoredOnDisk:   onDisk,
 		UploadProgress: file.UploadProgress(),
+		Priority:       file.Priority().String(),
+		UploadPaused:   file.UploadPaused(),
 	}
 	return info, nil
 }
@@ -5,6 +5,7 @@
 package filesystem
 
 import (
+	"io"
 	"math/rand"
 	"os"
 	"sync"
@@ -29,15 +30,42 @@ type FileSystem interface {
 	RootDir() storage.SysPath
 	PersistDir() storage.SysPath
 
-	// DxFile related methods, including New, Open, Rename and Delete
+	// DxFile related methods, including New, Open, Rename, Delete, Replace and the upload of
+	// the staged files
 	NewDxFile(dxPath storage.DxPath, sourcePath storage.SysPath, force bool, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode) (*dxfile.FileSetEntryWithID, error)
 	OpenDxFile(path storage.DxPath) (*dxfile.FileSetEntryWithID, error)
 	RenameDxFile(prevDxPath, curDxPath storage.DxPath) error
 	DeleteDxFile(dxPath storage.DxPath) error
+	ReplaceDxFile(srcPath, dxPath storage.DxPath) (bool, error)
+	UploadAndReplaceDxFile(uploadPath, dxPath storage.DxPath, upload func() error) (bool, error)
+	UploadStagedFile(stagedPath string, dxPath storage.DxPath, appMeta []byte, upload func(uploadPath storage.DxPath, r io.Reader) error) (bool, error)
+	RemoveDxFile(dxPath storage.DxPath) error
 
-	// DxDir related methods, including New and open
+	// DxDir related methods, including New, Open, List, and recursive Rename and Delete
 	NewDxDir(path storage.DxPath) (*dxdir.DirSetEntryWithID, error)
 	OpenDxDir(path storage.DxPath) (*dxdir.DirSetEntryWithID, error)
+	ListDir(path storage.DxPath, offset, limit int) (storage.DirListing, error)
+	RenameDir(prevPath, newPath storage.DxPath) error
+	DeleteDir(path storage.DxPath) error
+
+	// DxFile versioning related methods, including the policy setting, List, Restore, Purge and
+	// the access to the versions for repair
+	SetVersioning(path storage.DxPath, policy storage.VersioningPolicy) error
+	VersioningPolicy(dxPath storage.DxPath) (storage.VersioningPolicy, error)
+	ListVersions(dxPath storage.DxPath) ([]storage.FileVersionInfo, error)
+	RestoreVersion(dxPath storage.DxPath, versionID string) error
+	PurgeVersions(dxPath storage.DxPath, versionID string) error
+	VersionPaths() ([]storage.SysPath, error)
+	OpenVersion(path storage.SysPath) (*dxfile.FileSetEntryWithID, error)
+
+	// Upload/Download scheduling related methods, including priority, pause/resume and cancel
+	SetPriority(path storage.DxPath, priority storage.FilePriority) error
+	SetUploadPaused(path storage.DxPath, paused bool) error
+	CancelUploads(path storage.DxPath) ([]storage.DxPath, error)
+
+	// Cipher key wrapping related methods, used by the master key of the storage client
+	SetMasterKeys(masterKeys ...crypto.CipherKey)
+	WrapCipherKeys(masterKey crypto.CipherKey) error
 
 	// Upload/Download logic related functions
 	InitAndUpdateDirMetadata(path storage.DxPath) error
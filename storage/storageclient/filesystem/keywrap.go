@@ -0,0 +1,73 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// SetMasterKeys set the master keys used to unwrap the cipher keys of the dxfiles. The first
+// key is used to wrap the cipher keys of the new dxfiles. Calling with no keys locks the
+// cipher keys of the dxfiles not yet opened
+func (fs *fileSystem) SetMasterKeys(masterKeys ...crypto.CipherKey) {
+	fs.fileSet.SetMasterKeys(masterKeys...)
+}
+
+// WrapCipherKeys wraps the cipher keys of all dxfiles and their retained versions with the
+// master key. The cipher keys already wrapped are re-wrapped, thus they must be unwrapped by
+// the master keys set in advance
+func (fs *fileSystem) WrapCipherKeys(masterKey crypto.CipherKey) error {
+	if err := fs.tm.Add(); err != nil {
+		return err
+	}
+	defer fs.tm.Done()
+
+	fs.dirOpLock.Lock()
+	defer fs.dirOpLock.Unlock()
+
+	_, files, err := fs.subTree(storage.RootDxPath())
+	if err != nil {
+		return err
+	}
+	err = fs.applyToFiles(files, func(df *dxfile.FileSetEntryWithID) error {
+		return df.WrapCipherKey(masterKey)
+	})
+	if err != nil {
+		return err
+	}
+	return fs.wrapVersionCipherKeys(masterKey)
+}
+
+// wrapVersionCipherKeys wraps the cipher keys of all retained versions with the master key
+func (fs *fileSystem) wrapVersionCipherKeys(masterKey crypto.CipherKey) error {
+	fs.versionLock.Lock()
+	defer fs.versionLock.Unlock()
+
+	paths, err := fs.versionedPaths()
+	if err != nil {
+		return err
+	}
+	for _, dxPath := range paths {
+		ids, err := fs.versionIDs(dxPath)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			versionPath, _ := fs.versionPath(dxPath, id)
+			df, err := fs.fileSet.ReadVersion(versionPath)
+			if err != nil {
+				return fmt.Errorf("cannot read version %v of %v: %v", id, dxPath.Path, err)
+			}
+			if err = df.WrapCipherKey(masterKey); err != nil {
+				return fmt.Errorf("cannot wrap the cipher key of version %v of %v: %v", id, dxPath.Path, err)
+			}
+		}
+	}
+	return nil
+}
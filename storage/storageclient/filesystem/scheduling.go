@@ -0,0 +1,201 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxdir"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// errPathNotExist is the error that the path is neither a dxfile nor a directory
+var errPathNotExist = errors.New("no such file or directory")
+
+// SetPriority set the scheduling priority of the dxfile specified by path. If the path is
+// a directory, the priority of all dxfiles and directories under the directory recursively
+// is set, and the dxfiles created under the directory later have the priority
+func (fs *fileSystem) SetPriority(path storage.DxPath, priority storage.FilePriority) error {
+	if err := fs.tm.Add(); err != nil {
+		return err
+	}
+	defer fs.tm.Done()
+
+	if !priority.Valid() {
+		return fmt.Errorf("invalid priority %v", priority)
+	}
+	fs.dirOpLock.Lock()
+	defer fs.dirOpLock.Unlock()
+
+	files, dirs, err := fs.scheduledFiles(path)
+	if err != nil {
+		return err
+	}
+	err = fs.applyToDirs(path, dirs, func(d *dxdir.DirSetEntryWithID) error {
+		return d.SetPriority(priority)
+	})
+	if err != nil {
+		return err
+	}
+	return fs.applyToFiles(files, func(df *dxfile.FileSetEntryWithID) error {
+		return df.SetPriority(priority)
+	})
+}
+
+// SetUploadPaused pause or resume the upload and repair of the dxfile specified by path.
+// If the path is a directory, all dxfiles and directories under the directory recursively
+// are paused or resumed, and the dxfiles created under the directory later are paused or
+// resumed as well. The paused dxfiles are excluded from the health of the directories, thus they
+// will not be selected by the repair loop and the stuck loop
+func (fs *fileSystem) SetUploadPaused(path storage.DxPath, paused bool) error {
+	if err := fs.tm.Add(); err != nil {
+		return err
+	}
+	defer fs.tm.Done()
+
+	fs.dirOpLock.Lock()
+	defer fs.dirOpLock.Unlock()
+
+	files, dirs, err := fs.scheduledFiles(path)
+	if err != nil {
+		return err
+	}
+	err = fs.applyToDirs(path, dirs, func(d *dxdir.DirSetEntryWithID) error {
+		return d.SetUploadPaused(paused)
+	})
+	if err != nil {
+		return err
+	}
+	err = fs.applyToFiles(files, func(df *dxfile.FileSetEntryWithID) error {
+		return df.SetUploadPaused(paused)
+	})
+	if err != nil {
+		return err
+	}
+	return fs.updateScheduledDirsMetadata(dirs)
+}
+
+// CancelUploads deletes the dxfiles specified by path which have not finished uploading.
+// If the path is a directory, all unfinished dxfiles under the directory recursively are
+// deleted. The canceled dxfiles are not archived as versions. Return the paths of the
+// canceled dxfiles
+func (fs *fileSystem) CancelUploads(path storage.DxPath) ([]storage.DxPath, error) {
+	if err := fs.tm.Add(); err != nil {
+		return nil, err
+	}
+	defer fs.tm.Done()
+
+	fs.dirOpLock.Lock()
+	defer fs.dirOpLock.Unlock()
+
+	files, dirs, err := fs.scheduledFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	var canceled []storage.DxPath
+	for _, file := range files {
+		df, err := fs.fileSet.Open(file)
+		if err != nil {
+			return canceled, fmt.Errorf("cannot open file %v: %v", file.Path, err)
+		}
+		finished := df.UploadProgress() >= 100
+		if err = df.Close(); err != nil {
+			return canceled, err
+		}
+		if finished {
+			continue
+		}
+		if err = fs.fileSet.Delete(file); err != nil && err != dxfile.ErrUnknownFile {
+			return canceled, fmt.Errorf("cannot delete file %v: %v", file.Path, err)
+		}
+		canceled = append(canceled, file)
+	}
+	if len(canceled) == 0 {
+		return canceled, nil
+	}
+	return canceled, fs.updateScheduledDirsMetadata(dirs)
+}
+
+// scheduledFiles returns the dxfiles specified by path, along with the directories whose
+// metadata is affected by the dxfiles. If path is a dxfile, only the dxfile is returned.
+// If path is a directory, the dxfiles and directories under it recursively are returned
+func (fs *fileSystem) scheduledFiles(path storage.DxPath) (files, dirs []storage.DxPath, err error) {
+	if fs.isDxFile(path) {
+		parent, err := path.Parent()
+		if err != nil {
+			return nil, nil, err
+		}
+		return []storage.DxPath{path}, []storage.DxPath{parent}, nil
+	}
+	if !fs.isDir(path) {
+		return nil, nil, errPathNotExist
+	}
+	dirs, files, err = fs.subTree(path)
+	return files, dirs, err
+}
+
+// applyToFiles opens the dxfiles one by one and applies the function
+func (fs *fileSystem) applyToFiles(files []storage.DxPath, fn func(df *dxfile.FileSetEntryWithID) error) error {
+	for _, file := range files {
+		df, err := fs.fileSet.Open(file)
+		if err != nil {
+			return fmt.Errorf("cannot open file %v: %v", file.Path, err)
+		}
+		err = fn(df)
+		if closeErr := df.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("cannot update file %v: %v", file.Path, err)
+		}
+	}
+	return nil
+}
+
+// applyToDirs opens the directories one by one and applies the function. Nothing is done if
+// path is a dxfile. The directories without the DxDir file are skipped, since they inherit
+// the scheduling settings of the parent directory once created
+func (fs *fileSystem) applyToDirs(path storage.DxPath, dirs []storage.DxPath, fn func(d *dxdir.DirSetEntryWithID) error) error {
+	if fs.isDxFile(path) {
+		return nil
+	}
+	for _, dir := range dirs {
+		d, err := fs.dirSet.Open(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("cannot open directory %v: %v", dir.Path, err)
+		}
+		err = fn(d)
+		if closeErr := d.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("cannot update directory %v: %v", dir.Path, err)
+		}
+	}
+	return nil
+}
+
+// isDxFile checks whether the path is a dxfile
+func (fs *fileSystem) isDxFile(path storage.DxPath) bool {
+	_, err := os.Stat(string(path.SysPath(fs.fileRootDir)) + storage.DxFileExt)
+	return err == nil
+}
+
+// updateScheduledDirsMetadata updates the metadata of the directories, which are sorted
+// with the deeper ones first. The update of a directory is propagated to its parents
+func (fs *fileSystem) updateScheduledDirsMetadata(dirs []storage.DxPath) error {
+	for _, dir := range dirs {
+		if err := fs.InitAndUpdateDirMetadata(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+)
+
+// TestFileSystem_SetPriority test the priority could be set for a single dxfile and for all
+// dxfiles under a directory recursively
+func TestFileSystem_SetPriority(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	dir := randomDxPath(t, 2)
+	files := createTestFilesUnderDir(t, fs, dir)
+
+	if err := fs.SetPriority(dir, storage.PriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.SetPriority(files[0], storage.PriorityLow); err != nil {
+		t.Fatal(err)
+	}
+	for i, file := range files {
+		expect := storage.PriorityHigh
+		if i == 0 {
+			expect = storage.PriorityLow
+		}
+		if got := fs.filePriority(t, file); got != expect {
+			t.Errorf("file %v: unexpected priority. Expect %v, Got %v", file.Path, expect, got)
+		}
+	}
+
+	if err := fs.SetPriority(dir, 0); err == nil {
+		t.Errorf("setting an invalid priority should return an error")
+	}
+	if err := fs.SetPriority(randomDxPath(t, 1), storage.PriorityHigh); err != errPathNotExist {
+		t.Errorf("set priority of non-existing path: expect error %v, got %v", errPathNotExist, err)
+	}
+}
+
+// TestFileSystem_SetUploadPaused test the paused dxfiles are excluded from the directory
+// health and not selected for repair until resumed
+func TestFileSystem_SetUploadPaused(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	dir := randomDxPath(t, 2)
+	file := createTestUnfinishedFile(t, fs, dir)
+
+	if err := fs.SetUploadPaused(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.waitForUpdatesComplete(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.SelectDxFileToFix(); err != ErrNoRepairNeeded {
+		t.Errorf("select file to fix with all files paused: expect error %v, got %v", ErrNoRepairNeeded, err)
+	}
+
+	if err := fs.SetUploadPaused(file, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.waitForUpdatesComplete(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	df, err := fs.SelectDxFileToFix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer df.Close()
+	if !df.DxPath().Equals(file) {
+		t.Errorf("unexpected file selected to fix. Expect %v, Got %v", file.Path, df.DxPath().Path)
+	}
+}
+
+// TestFileSystem_SchedulingInherited test the dxfiles and directories created under a
+// directory after its priority and upload pause are set inherit the settings
+func TestFileSystem_SchedulingInherited(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	dir := randomDxPath(t, 2)
+	createTestFilesUnderDir(t, fs, dir)
+
+	if err := fs.SetPriority(dir, storage.PriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.SetUploadPaused(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	subDir, err := dir.Join("subdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := fs.NewDxDir(subDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Priority() != storage.PriorityHigh || !d.UploadPaused() {
+		t.Errorf("directory %v: unexpected scheduling. Got priority %v, paused %v", subDir.Path, d.Priority(), d.UploadPaused())
+	}
+	if err = d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// the dxfiles created directly under the directory and under the new sub directory
+	for _, parent := range []storage.DxPath{dir, subDir} {
+		path, err := parent.Join("newfile")
+		if err != nil {
+			t.Fatal(err)
+		}
+		overwriteTestFile(t, fs, path)
+		if got := fs.filePriority(t, path); got != storage.PriorityHigh {
+			t.Errorf("file %v: unexpected priority. Expect %v, Got %v", path.Path, storage.PriorityHigh, got)
+		}
+		df, err := fs.fileSet.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !df.UploadPaused() {
+			t.Errorf("file %v should be paused", path.Path)
+		}
+		df.Close()
+	}
+
+	// setting the priority of a dxfile does not change its directory
+	file, err := dir.Join("newfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.SetPriority(file, storage.PriorityLow); err != nil {
+		t.Fatal(err)
+	}
+	d, err = fs.dirSet.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if d.Priority() != storage.PriorityHigh {
+		t.Errorf("directory %v: unexpected priority. Expect %v, Got %v", dir.Path, storage.PriorityHigh, d.Priority())
+	}
+}
+
+// TestFileSystem_CancelUploads test only the dxfiles not finished uploading are deleted
+func TestFileSystem_CancelUploads(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	dir := randomDxPath(t, 2)
+	files := createTestFilesUnderDir(t, fs, dir)
+	unfinished := createTestUnfinishedFile(t, fs, dir)
+
+	canceled, err := fs.CancelUploads(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(canceled) != 1 || !canceled[0].Equals(unfinished) {
+		t.Errorf("unexpected canceled files: %v", canceled)
+	}
+	if fs.fileSet.Exists(unfinished) {
+		t.Errorf("file %v should be deleted", unfinished.Path)
+	}
+	for _, file := range files {
+		if !fs.fileSet.Exists(file) {
+			t.Errorf("file %v should exist", file.Path)
+		}
+	}
+	if err = fs.waitForUpdatesComplete(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	info, err := fs.dirInfo(storage.RootDxPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.NumFiles != uint64(len(files)) {
+		t.Errorf("unexpected number of files. Expect %v, Got %v", len(files), info.NumFiles)
+	}
+}
+
+// createTestUnfinishedFile creates a partially uploaded dxfile which needs repair under
+// the directory
+func createTestUnfinishedFile(t *testing.T, fs *fileSystem, dir storage.DxPath) storage.DxPath {
+	ck, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := dir.Join("unfinished")
+	if err != nil {
+		t.Fatal(err)
+	}
+	df, err := fs.fileSet.NewRandomDxFile(path, 10, 30, erasurecode.ECTypeStandard, ck, 1<<22*10, 0.3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = df.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.InitAndUpdateDirMetadata(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.waitForUpdatesComplete(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// filePriority returns the priority of the dxfile
+func (fs *fileSystem) filePriority(t *testing.T, path storage.DxPath) storage.FilePriority {
+	df, err := fs.fileSet.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer df.Close()
+	return df.Priority()
+}
@@ -0,0 +1,436 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// versionIDLen is the length of the version id, which is the zero padded unix nano time
+// when the version is archived
+const versionIDLen = 20
+
+// errVersionNotExist is the error that the requested version of the dxfile does not exist
+var errVersionNotExist = errors.New("version not exist")
+
+// SetVersioning set the versioning policy of the directory specified by path. The dxfiles
+// under the directory recursively are versioned with the policy of the nearest directory
+// with versioning enabled
+func (fs *fileSystem) SetVersioning(path storage.DxPath, policy storage.VersioningPolicy) error {
+	if err := fs.tm.Add(); err != nil {
+		return err
+	}
+	defer fs.tm.Done()
+
+	if !fs.isDir(path) {
+		return ErrDirNotExist
+	}
+	dir, err := fs.dirSet.Open(path)
+	if os.IsNotExist(err) {
+		dir, err = fs.dirSet.NewDxDir(path)
+	}
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.SetVersioning(policy)
+}
+
+// VersioningPolicy returns the versioning policy applied to the dxfile specified by dxPath.
+// If the policy is enabled, the dxfile is archived as a version when deleted or replaced
+func (fs *fileSystem) VersioningPolicy(dxPath storage.DxPath) (storage.VersioningPolicy, error) {
+	if err := fs.tm.Add(); err != nil {
+		return storage.VersioningPolicy{}, err
+	}
+	defer fs.tm.Done()
+
+	return fs.versioningPolicy(dxPath)
+}
+
+// ListVersions returns the retained versions of the dxfile, with the newest version first
+func (fs *fileSystem) ListVersions(dxPath storage.DxPath) ([]storage.FileVersionInfo, error) {
+	if err := fs.tm.Add(); err != nil {
+		return nil, err
+	}
+	defer fs.tm.Done()
+
+	fs.versionLock.Lock()
+	defer fs.versionLock.Unlock()
+
+	ids, err := fs.versionIDs(dxPath)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]storage.FileVersionInfo, 0, len(ids))
+	for _, id := range ids {
+		versionPath, _ := fs.versionPath(dxPath, id)
+		df, err := fs.fileSet.ReadVersion(versionPath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read version %v: %v", id, err)
+		}
+		timeArchived, _ := versionTime(id)
+		versions = append(versions, storage.FileVersionInfo{
+			VersionID:    id,
+			DxPath:       dxPath.Path,
+			FileSize:     df.FileSize(),
+			TimeModify:   df.TimeModify(),
+			TimeArchived: timeArchived,
+		})
+	}
+	return versions, nil
+}
+
+// RestoreVersion restores the version of the dxfile. The current dxfile, if exists, is
+// archived as a new version, so that the restore could be reverted
+func (fs *fileSystem) RestoreVersion(dxPath storage.DxPath, versionID string) error {
+	if err := fs.tm.Add(); err != nil {
+		return err
+	}
+	defer fs.tm.Done()
+
+	fs.versionLock.Lock()
+	defer fs.versionLock.Unlock()
+
+	versionPath, err := fs.versionPath(dxPath, versionID)
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stat(string(versionPath)); os.IsNotExist(err) {
+		return errVersionNotExist
+	}
+	if err = fs.archive(dxPath); err != nil && err != dxfile.ErrUnknownFile {
+		return fmt.Errorf("cannot archive the current file: %v", err)
+	}
+	return fs.fileSet.Restore(dxPath, versionPath)
+}
+
+// PurgeVersions permanently deletes the version of the dxfile. If versionID is empty,
+// all versions of the dxfile are deleted
+func (fs *fileSystem) PurgeVersions(dxPath storage.DxPath, versionID string) error {
+	if err := fs.tm.Add(); err != nil {
+		return err
+	}
+	defer fs.tm.Done()
+
+	fs.versionLock.Lock()
+	defer fs.versionLock.Unlock()
+
+	if versionID != "" {
+		versionPath, err := fs.versionPath(dxPath, versionID)
+		if err != nil {
+			return err
+		}
+		if _, err = os.Stat(string(versionPath)); os.IsNotExist(err) {
+			return errVersionNotExist
+		}
+		return fs.fileSet.DeleteVersion(versionPath)
+	}
+	ids, err := fs.versionIDs(dxPath)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		versionPath, _ := fs.versionPath(dxPath, id)
+		if err = fs.fileSet.DeleteVersion(versionPath); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(string(fs.versionDir(dxPath)))
+}
+
+// VersionPaths returns the file paths of all retained versions of the dxfiles, so that the
+// versions could be checked and repaired along with the live dxfiles
+func (fs *fileSystem) VersionPaths() ([]storage.SysPath, error) {
+	if err := fs.tm.Add(); err != nil {
+		return nil, err
+	}
+	defer fs.tm.Done()
+
+	fs.versionLock.Lock()
+	defer fs.versionLock.Unlock()
+
+	var paths []storage.SysPath
+	err := filepath.Walk(string(fs.versionRootDir), func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != storage.DxFileExt {
+			return nil
+		}
+		if !strings.HasSuffix(filepath.Dir(path), versionDirExt) {
+			return nil
+		}
+		paths = append(paths, storage.SysPath(path))
+		return nil
+	})
+	return paths, err
+}
+
+// OpenVersion opens the retained version at the file path returned by VersionPaths. The
+// returned entry should be closed after use
+func (fs *fileSystem) OpenVersion(path storage.SysPath) (*dxfile.FileSetEntryWithID, error) {
+	if err := fs.tm.Add(); err != nil {
+		return nil, err
+	}
+	defer fs.tm.Done()
+
+	return fs.fileSet.OpenVersion(path)
+}
+
+// archiveVersion archives the dxfile as a version if versioning is enabled for the dxfile,
+// and prunes the versions exceeding the versioning policy. Return whether the dxfile is
+// archived
+func (fs *fileSystem) archiveVersion(dxPath storage.DxPath) (bool, error) {
+	policy, err := fs.versioningPolicy(dxPath)
+	if err != nil || !policy.Enabled {
+		return false, err
+	}
+	fs.versionLock.Lock()
+	defer fs.versionLock.Unlock()
+
+	if err = fs.archive(dxPath); err != nil {
+		return false, err
+	}
+	if err = fs.pruneVersions(dxPath, policy); err != nil {
+		fs.logger.Warn("cannot prune versions", "path", dxPath.Path, "err", err)
+	}
+	return true, nil
+}
+
+// archive moves the dxfile to a new version. fs.versionLock should be held
+func (fs *fileSystem) archive(dxPath storage.DxPath) error {
+	versionPath, err := fs.versionPath(dxPath, newVersionID(time.Now()))
+	if err != nil {
+		return err
+	}
+	return fs.fileSet.Archive(dxPath, versionPath)
+}
+
+// versioningPolicy returns the versioning policy applied to the dxfile, which is the policy
+// of the nearest parent directory with versioning enabled
+func (fs *fileSystem) versioningPolicy(dxPath storage.DxPath) (storage.VersioningPolicy, error) {
+	for path := dxPath; !path.IsRoot(); {
+		parent, err := path.Parent()
+		if err != nil {
+			return storage.VersioningPolicy{}, err
+		}
+		path = parent
+		dir, err := fs.dirSet.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return storage.VersioningPolicy{}, err
+		}
+		policy := dir.Versioning()
+		dir.Close()
+		if policy.Enabled {
+			return policy, nil
+		}
+	}
+	return storage.VersioningPolicy{}, nil
+}
+
+// pruneVersions deletes the versions of the dxfile exceeding the max number of versions or
+// older than the max age of the policy. fs.versionLock should be held
+func (fs *fileSystem) pruneVersions(dxPath storage.DxPath, policy storage.VersioningPolicy) error {
+	ids, err := fs.versionIDs(dxPath)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i, id := range ids {
+		timeArchived, _ := versionTime(id)
+		exceeded := policy.MaxVersions != 0 && uint64(i) >= policy.MaxVersions
+		expired := policy.MaxAge != 0 && uint64(now.Sub(timeArchived)/time.Second) > policy.MaxAge
+		if !exceeded && !expired {
+			continue
+		}
+		versionPath, _ := fs.versionPath(dxPath, id)
+		if err = fs.fileSet.DeleteVersion(versionPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loopPruneVersions is the permanent loop for pruning the versions expired by the versioning
+// policy of their directories
+func (fs *fileSystem) loopPruneVersions() {
+	err := fs.tm.Add()
+	if err != nil {
+		return
+	}
+	defer fs.tm.Done()
+
+	for {
+		select {
+		case <-fs.tm.StopChan():
+			return
+		case <-time.After(versionPruneInterval):
+		}
+		if err := fs.pruneAllVersions(); err != nil && err != errStopped {
+			fs.logger.Warn("loop prune versions error", "err", err)
+		}
+	}
+}
+
+// pruneAllVersions prunes the versions of all dxfiles with versioning enabled. The versions
+// of the dxfiles with versioning disabled are kept until purged
+func (fs *fileSystem) pruneAllVersions() error {
+	paths, err := fs.versionedPaths()
+	if err != nil {
+		return err
+	}
+	var fullErr error
+	for _, path := range paths {
+		select {
+		case <-fs.tm.StopChan():
+			return errStopped
+		default:
+		}
+		policy, err := fs.versioningPolicy(path)
+		if err != nil {
+			fullErr = common.ErrCompose(fullErr, err)
+			continue
+		}
+		if !policy.Enabled {
+			continue
+		}
+		fs.versionLock.Lock()
+		err = fs.pruneVersions(path, policy)
+		fs.versionLock.Unlock()
+		fullErr = common.ErrCompose(fullErr, err)
+	}
+	return fullErr
+}
+
+// versionedPaths returns the DxPaths of all dxfiles having versions
+func (fs *fileSystem) versionedPaths() (paths []storage.DxPath, err error) {
+	rootDir := string(fs.versionRootDir)
+	err = filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || !strings.HasSuffix(path, versionDirExt) {
+			return nil
+		}
+		rel, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			return err
+		}
+		dxPath, err := storage.NewDxPath(strings.TrimSuffix(filepath.ToSlash(rel), versionDirExt))
+		if err != nil {
+			return err
+		}
+		paths = append(paths, dxPath)
+		return nil
+	})
+	return
+}
+
+// moveVersions moves all the versions under prevDir to newDir, keeping the relative paths.
+// The versions already moved are not under prevDir anymore, thus moveVersions could be
+// safely redone
+func (fs *fileSystem) moveVersions(prevDir, newDir storage.SysPath) error {
+	fs.versionLock.Lock()
+	defer fs.versionLock.Unlock()
+
+	err := filepath.Walk(string(prevDir), func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != storage.DxFileExt {
+			return nil
+		}
+		rel, err := filepath.Rel(string(prevDir), path)
+		if err != nil {
+			return err
+		}
+		return fs.fileSet.MoveVersion(storage.SysPath(path), storage.SysPath(filepath.Join(string(newDir), rel)))
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(string(prevDir))
+}
+
+// versionIDs returns the ids of the versions of the dxfile, with the newest version first
+func (fs *fileSystem) versionIDs(dxPath storage.DxPath) ([]string, error) {
+	fileInfos, err := ioutil.ReadDir(string(fs.versionDir(dxPath)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	// ReadDir returns the files sorted by name, that is, the oldest version first
+	for i := len(fileInfos) - 1; i >= 0; i-- {
+		id := strings.TrimSuffix(fileInfos[i].Name(), storage.DxFileExt)
+		if fileInfos[i].IsDir() || filepath.Ext(fileInfos[i].Name()) != storage.DxFileExt {
+			continue
+		}
+		if _, err := versionTime(id); err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// versionDir returns the directory holding the versions of the dxfile
+func (fs *fileSystem) versionDir(dxPath storage.DxPath) storage.SysPath {
+	return storage.SysPath(string(dxPath.SysPath(fs.versionRootDir)) + versionDirExt)
+}
+
+// versionPath returns the file path of the version of the dxfile
+func (fs *fileSystem) versionPath(dxPath storage.DxPath, versionID string) (storage.SysPath, error) {
+	if dxPath.IsRoot() {
+		return "", errVersionNotExist
+	}
+	if _, err := versionTime(versionID); err != nil {
+		return "", err
+	}
+	return fs.versionDir(dxPath).Join(storage.RootDxPath(), versionID+storage.DxFileExt), nil
+}
+
+// newVersionID returns the version id of the version archived at time t
+func newVersionID(t time.Time) string {
+	return fmt.Sprintf("%0*d", versionIDLen, t.UnixNano())
+}
+
+// versionTime returns the time when the version is archived
+func versionTime(versionID string) (time.Time, error) {
+	if len(versionID) != versionIDLen {
+		return time.Time{}, errVersionNotExist
+	}
+	nano, err := strconv.ParseInt(versionID, 10, 64)
+	if err != nil || nano < 0 {
+		return time.Time{}, errVersionNotExist
+	}
+	return time.Unix(0, nano), nil
+}
@@ -0,0 +1,333 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+)
+
+// TestFileSystem_Versioning test the dxfiles are archived when deleted or overwritten with
+// versioning enabled, and the versions could be listed, pruned, restored and purged
+func TestFileSystem_Versioning(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	dir := randomDxPath(t, 2)
+	files := createTestFilesUnderDir(t, fs, dir)
+	file := files[2]
+
+	// Without versioning, the deleted file is not retained
+	if err := fs.DeleteDxFile(files[0]); err != nil {
+		t.Fatal(err)
+	}
+	checkVersionNum(t, fs, files[0], 0)
+
+	// Versioning set on the parent directory applies to the file under the sub directory
+	policy := storage.VersioningPolicy{Enabled: true, MaxVersions: 2}
+	if err := fs.SetVersioning(dir, policy); err != nil {
+		t.Fatal(err)
+	}
+	info, err := fs.dirInfo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Versioning != policy {
+		t.Errorf("unexpected versioning policy. Expect %+v, Got %+v", policy, info.Versioning)
+	}
+	if err = fs.DeleteDxFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if fs.fileSet.Exists(file) {
+		t.Errorf("file %v should be deleted", file.Path)
+	}
+	versions := checkVersionNum(t, fs, file, 1)
+
+	// Restore the deleted file
+	if err = fs.RestoreVersion(file, versions[0].VersionID); err != nil {
+		t.Fatal(err)
+	}
+	if !fs.fileSet.Exists(file) {
+		t.Errorf("file %v should be restored", file.Path)
+	}
+	checkVersionNum(t, fs, file, 0)
+
+	// Overwrite the file for three times. Only two versions are retained
+	for i := 0; i != 3; i++ {
+		overwriteTestFile(t, fs, file)
+	}
+	versions = checkVersionNum(t, fs, file, 2)
+	if !versions[0].TimeArchived.After(versions[1].TimeArchived) {
+		t.Errorf("versions shall be listed with the newest first")
+	}
+
+	// Restore the older version. The current file is archived
+	if err = fs.RestoreVersion(file, versions[1].VersionID); err != nil {
+		t.Fatal(err)
+	}
+	checkVersionNum(t, fs, file, 2)
+	if err = fs.RestoreVersion(file, versions[1].VersionID); err != errVersionNotExist {
+		t.Errorf("restore non-existing version: expect error %v, got %v", errVersionNotExist, err)
+	}
+
+	// Purge the versions
+	versions = checkVersionNum(t, fs, file, 2)
+	if err = fs.PurgeVersions(file, versions[0].VersionID); err != nil {
+		t.Fatal(err)
+	}
+	checkVersionNum(t, fs, file, 1)
+	if err = fs.PurgeVersions(file, ""); err != nil {
+		t.Fatal(err)
+	}
+	checkVersionNum(t, fs, file, 0)
+}
+
+// TestFileSystem_VersioningDirOperation test the versions follow the renamed directory, and
+// the files under the deleted directory are archived with versioning enabled
+func TestFileSystem_VersioningDirOperation(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	prevDir, newDir := randomDxPath(t, 2), randomDxPath(t, 2)
+	files := createTestFilesUnderDir(t, fs, prevDir)
+	if err := fs.SetVersioning(prevDir, storage.VersioningPolicy{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		overwriteTestFile(t, fs, file)
+	}
+
+	if err := fs.RenameDir(prevDir, newDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.waitForUpdatesComplete(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	var newFiles []storage.DxPath
+	for _, file := range files {
+		newFile, err := replaceDxPathPrefix(file, prevDir, newDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkVersionNum(t, fs, file, 0)
+		checkVersionNum(t, fs, newFile, 1)
+		newFiles = append(newFiles, newFile)
+	}
+
+	if err := fs.DeleteDir(newDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.waitForUpdatesComplete(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range newFiles {
+		if fs.fileSet.Exists(file) {
+			t.Errorf("file %v should be deleted", file.Path)
+		}
+		checkVersionNum(t, fs, file, 2)
+	}
+}
+
+// TestFileSystem_ReplaceDxFile test the dxfile is replaced by another dxfile, and the
+// replaced dxfile is archived only if versioning is enabled
+func TestFileSystem_ReplaceDxFile(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	dir := randomDxPath(t, 2)
+	files := createTestFilesUnderDir(t, fs, dir)
+	src, file := files[0], files[1]
+
+	archived, err := fs.ReplaceDxFile(src, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived {
+		t.Errorf("file %v should not be archived without versioning", file.Path)
+	}
+	if fs.fileSet.Exists(src) || !fs.fileSet.Exists(file) {
+		t.Errorf("file %v should be moved to %v", src.Path, file.Path)
+	}
+	checkVersionNum(t, fs, file, 0)
+
+	if err = fs.SetVersioning(dir, storage.VersioningPolicy{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	overwriteTestFile(t, fs, src)
+	if archived, err = fs.ReplaceDxFile(src, file); err != nil {
+		t.Fatal(err)
+	}
+	if !archived {
+		t.Errorf("file %v should be archived with versioning", file.Path)
+	}
+	checkVersionNum(t, fs, file, 1)
+
+	// replacing with a non-existing dxfile keeps the existing dxfile
+	if _, err = fs.ReplaceDxFile(src, file); err == nil {
+		t.Errorf("replacing with a non-existing file should return an error")
+	}
+	if !fs.fileSet.Exists(file) {
+		t.Errorf("file %v should exist", file.Path)
+	}
+	checkVersionNum(t, fs, file, 1)
+}
+
+// TestFileSystem_RedoReplaceDxFile test the unfinished dxfile replacement recorded in
+// updateWal is redone when the file system restarts
+func TestFileSystem_RedoReplaceDxFile(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	dir := randomDxPath(t, 2)
+	files := createTestFilesUnderDir(t, fs, dir)
+	src, file := files[0], files[1]
+
+	// record the intent and only delete the existing file before the unclean shutdown
+	if _, err := fs.recordDirOperationIntent(fileReplaceName, dirOperation{src.Path, file.Path}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.fileSet.Delete(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.tm.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	fs.updateWal.CloseIncomplete()
+	if err := fs.fileWal.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// restart the file system, the replacement shall be finished
+	newFs := newFileSystem(string(fs.persistDir), &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	if err := newFs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := newFs.waitForUpdatesComplete(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if newFs.fileSet.Exists(src) || !newFs.fileSet.Exists(file) {
+		t.Errorf("file %v should be moved to %v", src.Path, file.Path)
+	}
+	newFs.postTestCheck(t, true, true, nil)
+}
+
+// TestFileSystem_UploadStagedFile test the staged file is uploaded as the dxfile along with the
+// app metadata, and the staged file is removed whether the upload succeeds or not
+func TestFileSystem_UploadStagedFile(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	dir := randomDxPath(t, 2)
+	files := createTestFilesUnderDir(t, fs, dir)
+	file := files[0]
+	if err := fs.SetVersioning(dir, storage.VersioningPolicy{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	var uploadErr error
+	upload := func(uploadPath storage.DxPath, r io.Reader) error {
+		if uploadErr != nil {
+			return uploadErr
+		}
+		return newTestUploadedFile(t, fs, uploadPath, r)
+	}
+
+	staged := newTestStagedFile(t, "staged data")
+	archived, err := fs.UploadStagedFile(staged, file, []byte("app metadata"), upload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !archived {
+		t.Errorf("file %v should be archived with versioning", file.Path)
+	}
+	if _, err = os.Stat(staged); !os.IsNotExist(err) {
+		t.Errorf("staged file shall be removed after the upload: %v", err)
+	}
+	entry, err := fs.OpenDxFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.FileSize() != uint64(len("staged data")) || entry.LocalPath() != "" || string(entry.AppMetadata()) != "app metadata" {
+		t.Errorf("uploaded file not expected: size %v, local path %v, app metadata %s", entry.FileSize(), entry.LocalPath(), entry.AppMetadata())
+	}
+	if err = entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	checkVersionNum(t, fs, file, 1)
+
+	// the failed upload keeps the existing dxfile, and removes the staged file
+	uploadErr = errors.New("upload failed")
+	staged = newTestStagedFile(t, "failed data")
+	if _, err = fs.UploadStagedFile(staged, file, nil, upload); err == nil {
+		t.Errorf("failed upload should return an error")
+	}
+	if _, err = os.Stat(staged); !os.IsNotExist(err) {
+		t.Errorf("staged file shall be removed after the failed upload: %v", err)
+	}
+	checkVersionNum(t, fs, file, 1)
+}
+
+// newTestStagedFile creates the temporary staged file with the data
+func newTestStagedFile(t *testing.T, data string) string {
+	f, err := ioutil.TempFile("", "staged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+// newTestUploadedFile creates the dxfile without local path as the upload of the data read
+// from r. The data itself is dropped
+func newTestUploadedFile(t *testing.T, fs *fileSystem, path storage.DxPath, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	ck, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	df, err := fs.NewDxFile(path, "", false, ec, ck, uint64(len(data)), 0644)
+	if err != nil {
+		return err
+	}
+	return df.Close()
+}
+
+// overwriteTestFile overwrites the dxfile with a new dxfile
+func overwriteTestFile(t *testing.T, fs *fileSystem, path storage.DxPath) {
+	ck, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 10, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	df, err := fs.NewDxFile(path, "", true, ec, ck, 1<<22*10, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = df.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkVersionNum checks the number of versions of the dxfile, and returns the versions
+func checkVersionNum(t *testing.T, fs *fileSystem, path storage.DxPath, expect int) []storage.FileVersionInfo {
+	versions, err := fs.ListVersions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != expect {
+		t.Fatalf("file %v: unexpected number of versions. Expect %v, Got %v", path.Path, expect, len(versions))
+	}
+	return versions
+}
@@ -6,7 +6,9 @@ package storageclient
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/unit"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/p2p/enode"
@@ -86,9 +88,77 @@ func formatClientSetting(setting storage.ClientSetting) (formatted storage.Clien
 	formatted.MaxUploadSpeed = unit.FormatSpeed(setting.MaxUploadSpeed)
 	formatted.MaxDownloadSpeed = unit.FormatSpeed(setting.MaxDownloadSpeed)
 	formatted.RentPayment = formatRentPayment(setting.RentPayment)
+	formatted.HostPolicy = formatHostPolicy(setting.HostPolicy)
+	formatted.Evaluator = setting.Evaluator
+	formatted.EvaluationWeights = formatEvaluationWeights(setting.EvaluationWeights)
+	formatted.BandwidthSchedule = formatBandwidthSchedule(setting.BandwidthSchedule)
+	formatted.UploadOnlyInWindow = unit.FormatBool(setting.BandwidthSchedule.UploadOnlyInWindow)
 	return
 }
 
+// formatBandwidthSchedule is used to format the bandwidth schedule windows for displaying
+// purpose
+func formatBandwidthSchedule(schedule storage.BandwidthSchedule) (formatted string) {
+	if len(schedule.Windows) == 0 {
+		return "None"
+	}
+	windows := make([]string, 0, len(schedule.Windows))
+	for _, w := range schedule.Windows {
+		days := "*"
+		if len(w.Days) != 0 {
+			names := make([]string, 0, len(w.Days))
+			for _, day := range w.Days {
+				names = append(names, strings.ToLower(day.String()[:3]))
+			}
+			days = strings.Join(names, ",")
+		}
+		windows = append(windows, fmt.Sprintf("%s %02d:%02d-%02d:%02d upload %s download %s", days, w.Start/60, w.Start%60,
+			w.End/60, w.End%60, unit.FormatSpeed(w.MaxUploadSpeed), unit.FormatSpeed(w.MaxDownloadSpeed)))
+	}
+	return strings.Join(windows, "; ")
+}
+
+// formatEvaluationWeights is used to format the factor weights of the weighted evaluator
+func formatEvaluationWeights(weights storage.EvaluationWeights) (formatted string) {
+	return fmt.Sprintf("presence:%v, deposit:%v, interaction:%v, contractprice:%v, storageremaining:%v, "+
+		"uptime:%v, uploadprice:%v, downloadprice:%v, performance:%v", weights.Presence, weights.Deposit,
+		weights.Interaction, weights.ContractPrice, weights.StorageRemaining, weights.Uptime, weights.UploadPrice,
+		weights.DownloadPrice, weights.Performance)
+}
+
+// formatHostPolicy is used to format the host policy setting for displaying purpose.
+// Disabled constraints are displayed as unlimited
+func formatHostPolicy(policy storage.HostPolicySetting) (formatted storage.HostPolicyAPIDisplay) {
+	formatted.Regions = "Unlimited"
+	if len(policy.Regions) != 0 {
+		formatted.Regions = strings.Join(policy.Regions, ",")
+	}
+	formatted.MaxHostsPerOperator = formatHostsLimit(policy.MaxHostsPerOperator)
+	formatted.MaxHostsPerRegion = formatHostsLimit(policy.MaxHostsPerRegion)
+	formatted.MinUptime = fmt.Sprintf("%v%%", policy.MinUptime*100)
+	formatted.MaxStoragePrice = formatPriceCeiling(policy.MaxStoragePrice)
+	formatted.MaxUploadPrice = formatPriceCeiling(policy.MaxUploadPrice)
+	formatted.MaxDownloadPrice = formatPriceCeiling(policy.MaxDownloadPrice)
+	formatted.MaxContractPrice = formatPriceCeiling(policy.MaxContractPrice)
+	return
+}
+
+// formatHostsLimit is used to format the limit on the number of storage hosts
+func formatHostsLimit(limit uint64) (formatted string) {
+	if limit == 0 {
+		return "Unlimited"
+	}
+	return formatHosts(limit)
+}
+
+// formatPriceCeiling is used to format the price ceiling of the host policy
+func formatPriceCeiling(ceiling common.BigInt) (formatted string) {
+	if ceiling.Sign() == 0 {
+		return "Unlimited"
+	}
+	return unit.FormatCurrency(ceiling)
+}
+
 // formatIPViolation is used to format storage.ClientSetting.IPViolation field
 func formatIPViolation(enabled bool) (formatted string) {
 	if enabled {
@@ -0,0 +1,104 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// Package gatewaytest implements the in-memory storage client backend used by the test
+// cases of the gateways
+package gatewaytest
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// writeSize is the size of the pieces written to the download destination
+const writeSize = 4
+
+// Backend is the storage client backend used by the test cases of the gateways. The streamed
+// data is kept in memory by the unique id of the dxfile instead of being uploaded to the storage
+// hosts, so that the data follows the dxfile when it is renamed, replaced or archived
+type Backend struct {
+	FS        filesystem.FileSystem
+	UploadErr error
+
+	lock sync.Mutex
+	data map[dxfile.FileID][]byte
+}
+
+// UploadStream creates the dxfile without local path, and keeps the data read from r. If
+// UploadErr is set, it is returned without creating the dxfile
+func (b *Backend) UploadStream(up storage.FileUploadParams, r io.Reader) error {
+	if b.UploadErr != nil {
+		return b.UploadErr
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return errors.New("no data to upload")
+	}
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 1, 2)
+	if err != nil {
+		return err
+	}
+	ck, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		return err
+	}
+	file, err := b.FS.NewDxFile(up.DxPath, "", false, ec, ck, uint64(len(data)), 0644)
+	if err != nil {
+		return err
+	}
+	b.lock.Lock()
+	if b.data == nil {
+		b.data = make(map[dxfile.FileID][]byte)
+	}
+	b.data[file.UID()] = data
+	b.lock.Unlock()
+	return file.Close()
+}
+
+// DownloadRangeSync writes the data in range of the dxfile kept in memory to the destination.
+// As the storage client does, the data is written piece by piece, each at its position in the
+// range, thus the destination receives the byte at offset at position 0
+func (b *Backend) DownloadRangeSync(dxPath storage.DxPath, destination io.WriterAt, offset, length uint64) error {
+	file, err := b.FS.OpenDxFile(dxPath)
+	if err != nil {
+		return err
+	}
+	id := file.UID()
+	if err = file.Close(); err != nil {
+		return err
+	}
+	b.lock.Lock()
+	data, exist := b.data[id]
+	b.lock.Unlock()
+	if !exist || offset+length > uint64(len(data)) {
+		return errors.New("data of the file not uploaded")
+	}
+	data = data[offset : offset+length]
+	for pos := 0; pos < len(data); pos += writeSize {
+		end := pos + writeSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if _, err = destination.WriteAt(data[pos:end], int64(pos)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetFileSystem returns the file system
+func (b *Backend) GetFileSystem() filesystem.FileSystem {
+	return b.FS
+}
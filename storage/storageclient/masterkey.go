@@ -0,0 +1,231 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+// masterKeyCheckText is the text encrypted by the master key, which is used to verify
+// the passphrase
+var masterKeyCheckText = []byte("dxchain storage client master key")
+
+// The scrypt parameters used to derive the master key from the passphrase
+var (
+	masterKeyScryptN = 1 << 18
+	masterKeyScryptR = 8
+	masterKeyScryptP = 1
+)
+
+var (
+	errMasterKeyEnabled  = errors.New("master key is already enabled")
+	errMasterKeyDisabled = errors.New("master key is not enabled")
+	errEmptyPassphrase   = errors.New("passphrase cannot be empty")
+	errInvalidPassphrase = errors.New("invalid passphrase")
+)
+
+// masterKeyPersist is the persisted parameters of the master key which wraps the cipher keys
+// of the files. The master key itself is never persisted, and is derived from the passphrase
+type masterKeyPersist struct {
+	Salt  []byte
+	Check []byte
+
+	// PendingSalt and PendingCheck are the parameters of the new master key, if the
+	// rotation of the master key is not finished
+	PendingSalt  []byte
+	PendingCheck []byte
+}
+
+// enabled returns whether the master key is enabled
+func (mkp masterKeyPersist) enabled() bool {
+	return len(mkp.Salt) != 0
+}
+
+// rotating returns whether the rotation of the master key is not finished
+func (mkp masterKeyPersist) rotating() bool {
+	return len(mkp.PendingSalt) != 0
+}
+
+// EnableMasterKey enables the master key derived from the passphrase. The cipher keys of all
+// files are wrapped by the master key, thus the files could only be uploaded and downloaded
+// after the master key is unlocked with the passphrase
+func (client *StorageClient) EnableMasterKey(passphrase string) error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	client.masterKeyLock.Lock()
+	defer client.masterKeyLock.Unlock()
+
+	if client.masterKeyPersist().enabled() {
+		return errMasterKeyEnabled
+	}
+	key, salt, check, err := newMasterKey(passphrase)
+	if err != nil {
+		return err
+	}
+	// The parameters are saved before wrapping, so that the wrapped cipher keys could
+	// always be unwrapped
+	if err = client.saveMasterKeyPersist(masterKeyPersist{Salt: salt, Check: check}); err != nil {
+		return err
+	}
+	client.setMasterKeys(key)
+	return client.fileSystem.WrapCipherKeys(key)
+}
+
+// UnlockMasterKey unlocks the master key with the passphrase, so that the cipher keys of the
+// files could be unwrapped. The master key is kept in memory until the storage client stops
+func (client *StorageClient) UnlockMasterKey(passphrase string) error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	client.masterKeyLock.Lock()
+	defer client.masterKeyLock.Unlock()
+
+	mkp := client.masterKeyPersist()
+	if !mkp.enabled() {
+		return errMasterKeyDisabled
+	}
+	key, err := deriveMasterKey(passphrase, mkp.Salt, mkp.Check)
+	if err != nil {
+		return err
+	}
+	client.setMasterKeys(key)
+	if mkp.rotating() {
+		client.log.Warn("The rotation of the master key is not finished, the files wrapped by the new master key are locked. Rotate the master key again to finish the rotation")
+		return nil
+	}
+	// wrap the cipher keys of the files created before the master key is enabled, if enabling
+	// the master key is interrupted
+	return client.fileSystem.WrapCipherKeys(key)
+}
+
+// RotateMasterKey replaces the master key with the one derived from the new passphrase. The
+// cipher keys of all files are re-wrapped by the new master key without uploading the files
+// again. If the previous rotation is not finished, it must be finished with the same new
+// passphrase
+func (client *StorageClient) RotateMasterKey(passphrase, newPassphrase string) error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	client.masterKeyLock.Lock()
+	defer client.masterKeyLock.Unlock()
+
+	mkp := client.masterKeyPersist()
+	if !mkp.enabled() {
+		return errMasterKeyDisabled
+	}
+	key, err := deriveMasterKey(passphrase, mkp.Salt, mkp.Check)
+	if err != nil {
+		return err
+	}
+	var newKey crypto.CipherKey
+	if mkp.rotating() {
+		if newKey, err = deriveMasterKey(newPassphrase, mkp.PendingSalt, mkp.PendingCheck); err != nil {
+			return fmt.Errorf("the previous rotation is not finished, and must be finished with the same new passphrase: %v", err)
+		}
+	} else {
+		if newKey, mkp.PendingSalt, mkp.PendingCheck, err = newMasterKey(newPassphrase); err != nil {
+			return err
+		}
+		// The parameters of the new master key are saved before re-wrapping, so that the
+		// rotation could be finished if interrupted
+		if err = client.saveMasterKeyPersist(mkp); err != nil {
+			return err
+		}
+	}
+	client.setMasterKeys(newKey, key)
+	if err = client.fileSystem.WrapCipherKeys(newKey); err != nil {
+		return err
+	}
+	err = client.saveMasterKeyPersist(masterKeyPersist{Salt: mkp.PendingSalt, Check: mkp.PendingCheck})
+	if err != nil {
+		return err
+	}
+	client.setMasterKeys(newKey)
+	return nil
+}
+
+// masterKeyLocked returns whether the master key is enabled but not unlocked
+func (client *StorageClient) masterKeyLocked() bool {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	return client.persist.MasterKey.enabled() && !client.masterKeyUnlocked
+}
+
+// masterKeyPersist returns the persisted parameters of the master key
+func (client *StorageClient) masterKeyPersist() masterKeyPersist {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	return client.persist.MasterKey
+}
+
+// saveMasterKeyPersist updates and saves the persisted parameters of the master key
+func (client *StorageClient) saveMasterKeyPersist(mkp masterKeyPersist) error {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	client.persist.MasterKey = mkp
+	return client.saveSettings()
+}
+
+// setMasterKeys sets the master keys to the file system, and marks the master key unlocked
+func (client *StorageClient) setMasterKeys(masterKeys ...crypto.CipherKey) {
+	client.fileSystem.SetMasterKeys(masterKeys...)
+	client.lock.Lock()
+	client.masterKeyUnlocked = true
+	client.lock.Unlock()
+}
+
+// newMasterKey derives a new master key from the passphrase with a random salt. The salt and
+// the check text encrypted by the master key are returned
+func newMasterKey(passphrase string) (key crypto.CipherKey, salt, check []byte, err error) {
+	salt = make([]byte, 32)
+	if _, err = rand.Read(salt); err != nil {
+		return
+	}
+	if key, err = newMasterKeyFromPassphrase(passphrase, salt); err != nil {
+		return
+	}
+	check, err = key.Encrypt(masterKeyCheckText)
+	return
+}
+
+// deriveMasterKey derives the master key from the passphrase and the salt, and verifies the
+// master key with the check text
+func deriveMasterKey(passphrase string, salt, check []byte) (crypto.CipherKey, error) {
+	key, err := newMasterKeyFromPassphrase(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	text, err := key.Decrypt(check)
+	if err != nil || !bytes.Equal(text, masterKeyCheckText) {
+		return nil, errInvalidPassphrase
+	}
+	return key, nil
+}
+
+// newMasterKeyFromPassphrase derives the XChaCha20-Poly1305 master key from the passphrase
+// and salt with scrypt
+func newMasterKeyFromPassphrase(passphrase string, salt []byte) (crypto.CipherKey, error) {
+	if len(passphrase) == 0 {
+		return nil, errEmptyPassphrase
+	}
+	seed, err := scrypt.Key([]byte(passphrase), salt, masterKeyScryptN, masterKeyScryptR, masterKeyScryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.NewCipherKey(crypto.XChaCha20CipherCode, seed)
+}
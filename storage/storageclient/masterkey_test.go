@@ -0,0 +1,122 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestStorageClient_MasterKey test the master key could be enabled, unlocked and rotated, and the
+// cipher keys of the files are wrapped by the master key
+func TestStorageClient_MasterKey(t *testing.T) {
+	defer func(n int) { masterKeyScryptN = n }(masterKeyScryptN)
+	masterKeyScryptN = 1 << 12
+
+	dir, err := ioutil.TempDir("", "storageclient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	client, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.fileSystem.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	entry := newFileEntry(t, client)
+	defer entry.Close()
+	ck, err := entry.CipherKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = client.UnlockMasterKey("passphrase"); err != errMasterKeyDisabled {
+		t.Errorf("unlock before enabled: expect error %v, got %v", errMasterKeyDisabled, err)
+	}
+	if err = client.EnableMasterKey(""); err != errEmptyPassphrase {
+		t.Errorf("enable with empty passphrase: expect error %v, got %v", errEmptyPassphrase, err)
+	}
+	if err = client.EnableMasterKey("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if !entry.KeyWrapped() {
+		t.Errorf("cipher key not wrapped after the master key enabled")
+	}
+	if err = client.EnableMasterKey("passphrase"); err != errMasterKeyEnabled {
+		t.Errorf("enable twice: expect error %v, got %v", errMasterKeyEnabled, err)
+	}
+
+	// Simulate the restart of the storage client, where the master key is locked
+	client.masterKeyUnlocked = false
+	if client.uploadAllowed(time.Now()) {
+		t.Errorf("uploading shall not be allowed when the master key is locked")
+	}
+	if err = client.UnlockMasterKey("wrong passphrase"); err != errInvalidPassphrase {
+		t.Errorf("unlock with wrong passphrase: expect error %v, got %v", errInvalidPassphrase, err)
+	}
+	if err = client.UnlockMasterKey("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if !client.uploadAllowed(time.Now()) {
+		t.Errorf("uploading shall be allowed when the master key is unlocked")
+	}
+
+	prevSalt := client.masterKeyPersist().Salt
+	if err = client.RotateMasterKey("wrong passphrase", "new passphrase"); err != errInvalidPassphrase {
+		t.Errorf("rotate with wrong passphrase: expect error %v, got %v", errInvalidPassphrase, err)
+	}
+	if err = client.RotateMasterKey("passphrase", "new passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	mkp := client.masterKeyPersist()
+	if bytes.Equal(mkp.Salt, prevSalt) || mkp.rotating() {
+		t.Errorf("unexpected master key persist after rotation: %+v", mkp)
+	}
+	if _, err = deriveMasterKey("new passphrase", mkp.Salt, mkp.Check); err != nil {
+		t.Errorf("the new passphrase cannot derive the master key: %v", err)
+	}
+	unwrapped, err := entry.CipherKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unwrapped.Key(), ck.Key()) {
+		t.Errorf("cipher key changed after the master key rotated")
+	}
+}
+
+// TestDeriveMasterKey test the master key derived from the same passphrase and salt is the
+// same, and the passphrase is verified by the check text
+func TestDeriveMasterKey(t *testing.T) {
+	defer func(n int) { masterKeyScryptN = n }(masterKeyScryptN)
+	masterKeyScryptN = 1 << 12
+
+	key, salt, check, err := newMasterKey("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	derived, err := deriveMasterKey("passphrase", salt, check)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(derived.Key(), key.Key()) {
+		t.Errorf("derived master key not expected")
+	}
+	if _, err = deriveMasterKey("Passphrase", salt, check); err != errInvalidPassphrase {
+		t.Errorf("expect error %v, got %v", errInvalidPassphrase, err)
+	}
+	another, anotherSalt, _, err := newMasterKey("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(anotherSalt, salt) || bytes.Equal(another.Key(), key.Key()) {
+		t.Errorf("master keys with random salts shall be different")
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// MigrateHost starts to migrate the data stored in the storage host to the replacement
+// storage hosts explicitly. The contract signed with the storage host will be canceled
+// and left to lapse once all the data are migrated
+func (client *StorageClient) MigrateHost(id enode.ID) error {
+	if err := client.contractManager.MigrateHost(id); err != nil {
+		return err
+	}
+
+	select {
+	case client.migrationNeeded <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// migrationLoop periodically pushes the files which have data stored in the migrating
+// storage hosts to the upload heap, so that the data will be repaired to the replacement
+// storage hosts. Once no data is left to be migrated, the migration will be finished
+func (client *StorageClient) migrationLoop() {
+	err := client.tm.Add()
+	if err != nil {
+		return
+	}
+	defer client.tm.Done()
+
+	for {
+		select {
+		case <-client.tm.StopChan():
+			return
+		case <-client.migrationNeeded:
+		case <-time.After(MigrationCheckInterval):
+		}
+
+		// Wait until the storage client is online and uploading is allowed to proceed.
+		if !client.blockUntilOnline() || !client.blockUntilUploadAllowed() {
+			return
+		}
+
+		client.migrateData()
+	}
+}
+
+// migrateData pushes the files which have data to be migrated to the upload heap, and
+// finishes the migration of storage hosts which have no data left to be migrated
+func (client *StorageClient) migrateData() {
+	migratingHosts := client.contractManager.MigratingHosts()
+	if len(migratingHosts) == 0 {
+		return
+	}
+
+	pending := make(map[enode.ID]struct{})
+	for _, id := range migratingHosts {
+		pending[id] = struct{}{}
+	}
+
+	dxPaths, versionPaths, hostsWithData, err := client.filesToMigrate(pending)
+	if err != nil {
+		client.log.Error("failed to find the files to be migrated", "err", err)
+		return
+	}
+
+	// finish the migration of storage hosts which have no data left to be migrated
+	for _, id := range migratingHosts {
+		if _, exists := hostsWithData[id]; !exists {
+			client.contractManager.FinishMigration(id)
+		}
+	}
+
+	if len(dxPaths) == 0 && len(versionPaths) == 0 {
+		return
+	}
+
+	// push the files and the versions to the upload heap, and notify the upload loop
+	hosts := client.refreshHostsAndWorkers()
+	for _, dxPath := range dxPaths {
+		client.pushDirOrFileToSegmentHeap(dxPath, false, hosts, targetUnstuckSegments)
+	}
+	var versions []*dxfile.FileSetEntryWithID
+	for _, path := range versionPaths {
+		if file, err := client.fileSystem.OpenVersion(path); err == nil {
+			versions = append(versions, file)
+		}
+	}
+	client.pushVersionsToSegmentHeap(versions, hosts, client.contractManager.HostHealthMap())
+
+	select {
+	case client.uploadHeap.segmentComing <- struct{}{}:
+	default:
+	}
+}
+
+// filesToMigrate walks through the files and the retained versions in the file system, and
+// returns at most MaxMigrationFilesPerRound files and versions which have data to be migrated
+// away from the migrating storage hosts, along with the migrating storage hosts which still
+// have data to be migrated
+func (client *StorageClient) filesToMigrate(migratingHosts map[enode.ID]struct{}) (dxPaths []storage.DxPath, versionPaths []storage.SysPath, hostsWithData map[enode.ID]struct{}, err error) {
+	hostsWithData = make(map[enode.ID]struct{})
+	table := client.contractManager.HostHealthMap()
+	rootDir := string(client.fileSystem.RootDir())
+
+	err = filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != storage.DxFileExt {
+			return nil
+		}
+		str := strings.TrimSuffix(strings.TrimPrefix(path, rootDir), storage.DxFileExt)
+		dxPath, err := storage.NewDxPath(str)
+		if err != nil {
+			return err
+		}
+
+		file, err := client.fileSystem.OpenDxFile(dxPath)
+		if err != nil {
+			client.log.Warn("failed to open the file for migration", "dxPath", dxPath, "err", err)
+			return nil
+		}
+		hosts := segmentsToMigrate(file, migratingHosts, table)
+		if err := file.Close(); err != nil {
+			client.log.Warn("failed to close the file", "dxPath", dxPath, "err", err)
+		}
+
+		if len(hosts) == 0 {
+			return nil
+		}
+		for id := range hosts {
+			hostsWithData[id] = struct{}{}
+		}
+		if len(dxPaths) < MaxMigrationFilesPerRound {
+			dxPaths = append(dxPaths, dxPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	// the sectors of the retained versions are migrated as well, otherwise they are lost
+	// once the contracts of the migrating storage hosts are canceled
+	paths, err := client.fileSystem.VersionPaths()
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		file, err := client.fileSystem.OpenVersion(path)
+		if err != nil {
+			client.log.Warn("failed to open the version for migration", "path", path, "err", err)
+			continue
+		}
+		hosts := segmentsToMigrate(file, migratingHosts, table)
+		if err := file.Close(); err != nil {
+			client.log.Warn("failed to close the version", "path", path, "err", err)
+		}
+
+		if len(hosts) == 0 {
+			continue
+		}
+		for id := range hosts {
+			hostsWithData[id] = struct{}{}
+		}
+		if len(dxPaths)+len(versionPaths) < MaxMigrationFilesPerRound {
+			versionPaths = append(versionPaths, path)
+		}
+	}
+	return
+}
+
+// segmentsToMigrate returns the migrating storage hosts which store the sectors of the
+// segments not yet completely repaired to the other storage hosts
+func segmentsToMigrate(file *dxfile.FileSetEntryWithID, migratingHosts map[enode.ID]struct{}, table storage.HostHealthInfoTable) (hosts map[enode.ID]struct{}) {
+	hosts = make(map[enode.ID]struct{})
+	for i := 0; i < file.NumSegments(); i++ {
+		if file.SegmentHealth(i, table) >= dxfile.CompleteHealthThreshold {
+			continue
+		}
+		sectors, err := file.Sectors(i)
+		if err != nil {
+			continue
+		}
+		for _, sectorSlot := range sectors {
+			for _, sector := range sectorSlot {
+				if _, exists := migratingHosts[sector.HostID]; exists {
+					hosts[sector.HostID] = struct{}{}
+				}
+			}
+		}
+	}
+	return
+}
@@ -43,3 +43,17 @@ func NewRevision(current types.StorageContractRevision, cost *big.Int) types.Sto
 
 	return rev
 }
+
+// NewRefundRevision update current storage contract revision with its revision number incremented, and amount refunded from the host to the client.
+func NewRefundRevision(current types.StorageContractRevision, amount *big.Int) types.StorageContractRevision {
+	rev := NewRevision(current, big.NewInt(0))
+
+	// move valid payout from host back to client
+	rev.NewValidProofOutputs[0].Value.Add(current.NewValidProofOutputs[0].Value, amount)
+	rev.NewValidProofOutputs[1].Value.Sub(current.NewValidProofOutputs[1].Value, amount)
+
+	// recover the missed payout of client burnt when paying the host
+	rev.NewMissedProofOutputs[0].Value.Add(current.NewMissedProofOutputs[0].Value, amount)
+
+	return rev
+}
@@ -7,9 +7,11 @@ package storageclient
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/storage"
 )
 
 var settingsMetadata = common.Metadata{
@@ -18,8 +20,10 @@ var settingsMetadata = common.Metadata{
 }
 
 type persistence struct {
-	MaxDownloadSpeed int64
-	MaxUploadSpeed   int64
+	MaxDownloadSpeed  int64
+	MaxUploadSpeed    int64
+	BandwidthSchedule storage.BandwidthSchedule
+	MasterKey         masterKeyPersist
 }
 
 func (client *StorageClient) loadPersist() error {
@@ -32,7 +36,13 @@ func (client *StorageClient) loadPersist() error {
 	// initialize logger
 	client.log = log.New()
 
-	return client.loadSettings()
+	if err = client.loadSettings(); err != nil {
+		return err
+	}
+	if err = client.loadSyncFolders(); err != nil {
+		return err
+	}
+	return client.loadSharePrepayments()
 }
 
 // save StorageClient settings into storageclient.json file
@@ -54,5 +64,5 @@ func (client *StorageClient) loadSettings() error {
 	} else if err != nil {
 		return err
 	}
-	return client.setBandwidthLimits(client.persist.MaxUploadSpeed, client.persist.MaxUploadSpeed)
+	return client.applyBandwidthSchedule(time.Now())
 }
@@ -91,6 +91,11 @@ func (client *StorageClient) stuckLoop() {
 			return
 		}
 
+		// Wait until uploading is allowed by the bandwidth schedule
+		if !client.blockUntilUploadAllowed() {
+			return
+		}
+
 		// Randomly get directory with stuck files
 		dir, err := client.fileSystem.RandomStuckDirectory()
 		if err != nil && err != filesystem.ErrNoRepairNeeded {
@@ -0,0 +1,443 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package s3gateway
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	signV4Algorithm  = "AWS4-HMAC-SHA256"
+	signV4Terminator = "aws4_request"
+	signV4Service    = "s3"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	streamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	chunkAlgorithm   = "AWS4-HMAC-SHA256-PAYLOAD"
+	emptySHA256      = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	iso8601Format = "20060102T150405Z"
+	yyyymmdd      = "20060102"
+
+	// maxRequestTimeSkew is the max difference between the request time and the local time
+	maxRequestTimeSkew = 15 * time.Minute
+
+	// maxPresignExpires is the max expiration time of the presigned request
+	maxPresignExpires = 7 * 24 * time.Hour
+)
+
+// signatureV4 is the parsed AWS signature version 4 of the request
+type signatureV4 struct {
+	accessKey     string
+	date          time.Time
+	region        string
+	signedHeaders []string
+	signature     string
+	payloadHash   string
+	presigned     bool
+
+	// signingKey is the key derived from the secret key, which signs the chunks of the
+	// streaming payload
+	signingKey []byte
+}
+
+// scope returns the credential scope of the signature
+func (sig signatureV4) scope() string {
+	return strings.Join([]string{sig.date.Format(yyyymmdd), sig.region, signV4Service, signV4Terminator}, "/")
+}
+
+// loadAccessKeys loads the access keys from the JSON file, which is an object
+// mapping from the access key id to the secret access key
+func loadAccessKeys(path string) (keys map[string]string, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the access keys file: %v", err)
+	}
+	if err = json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse the access keys file: %v", err)
+	}
+	for id, secret := range keys {
+		if id == "" || secret == "" {
+			return nil, fmt.Errorf("empty access key id or secret key in %v", path)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no access key configured in %v", path)
+	}
+	return keys, nil
+}
+
+// verifyRequest verifies the AWS signature version 4 of the request, which is either
+// provided in the Authorization header, or in the query of the presigned url
+func verifyRequest(r *http.Request, keys map[string]string, now time.Time) (sig signatureV4, err error) {
+	switch {
+	case r.Header.Get("Authorization") != "":
+		sig, err = parseAuthorizationHeader(r)
+	case r.URL.Query().Get("X-Amz-Algorithm") != "":
+		sig, err = parsePresignedQuery(r, now)
+	default:
+		err = errAccessDenied
+	}
+	if err != nil {
+		return signatureV4{}, err
+	}
+
+	secret, exists := keys[sig.accessKey]
+	if !exists {
+		return signatureV4{}, errInvalidAccessKeyID
+	}
+	if !sig.presigned && (now.Sub(sig.date) > maxRequestTimeSkew || sig.date.Sub(now) > maxRequestTimeSkew) {
+		return signatureV4{}, errRequestTimeTooSkewed
+	}
+
+	expect := computeSignature(secret, sig, canonicalRequest(r, sig))
+	if !hmac.Equal([]byte(expect), []byte(sig.signature)) {
+		return signatureV4{}, errSignatureDoesNotMatch
+	}
+	sig.signingKey = deriveSigningKey(secret, sig)
+	return sig, nil
+}
+
+// parseAuthorizationHeader parses the signature in the Authorization header, which has
+// the format of: AWS4-HMAC-SHA256 Credential=<key>/<date>/<region>/s3/aws4_request,
+// SignedHeaders=<headers>, Signature=<signature>
+func parseAuthorizationHeader(r *http.Request) (sig signatureV4, err error) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, signV4Algorithm+" ") {
+		return signatureV4{}, errUnsupportedSignature
+	}
+	fields := make(map[string]string)
+	for _, field := range strings.Split(strings.TrimPrefix(auth, signV4Algorithm+" "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 {
+			return signatureV4{}, errAuthorizationMalformed
+		}
+		fields[kv[0]] = kv[1]
+	}
+
+	dateStr := r.Header.Get("X-Amz-Date")
+	if dateStr == "" {
+		dateStr = r.Header.Get("Date")
+	}
+	date, err := parseRequestDate(dateStr)
+	if err != nil {
+		return signatureV4{}, errAccessDenied
+	}
+
+	sig, err = parseCredential(fields["Credential"], date)
+	if err != nil {
+		return signatureV4{}, err
+	}
+	if fields["SignedHeaders"] == "" || fields["Signature"] == "" {
+		return signatureV4{}, errAuthorizationMalformed
+	}
+	sig.signedHeaders = strings.Split(fields["SignedHeaders"], ";")
+	sig.signature = fields["Signature"]
+	sig.payloadHash = r.Header.Get("X-Amz-Content-Sha256")
+	if sig.payloadHash == "" {
+		sig.payloadHash = unsignedPayload
+	}
+	return sig, nil
+}
+
+// parsePresignedQuery parses the signature in the query of the presigned url
+func parsePresignedQuery(r *http.Request, now time.Time) (sig signatureV4, err error) {
+	query := r.URL.Query()
+	if query.Get("X-Amz-Algorithm") != signV4Algorithm {
+		return signatureV4{}, errUnsupportedSignature
+	}
+	date, err := time.Parse(iso8601Format, query.Get("X-Amz-Date"))
+	if err != nil {
+		return signatureV4{}, errAccessDenied
+	}
+	expires, err := strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+	if err != nil || expires < 0 || time.Duration(expires)*time.Second > maxPresignExpires {
+		return signatureV4{}, errAuthorizationMalformed
+	}
+	if now.After(date.Add(time.Duration(expires)*time.Second)) || date.Sub(now) > maxRequestTimeSkew {
+		return signatureV4{}, errExpiredPresignRequest
+	}
+
+	sig, err = parseCredential(query.Get("X-Amz-Credential"), date)
+	if err != nil {
+		return signatureV4{}, err
+	}
+	if query.Get("X-Amz-SignedHeaders") == "" || query.Get("X-Amz-Signature") == "" {
+		return signatureV4{}, errAuthorizationMalformed
+	}
+	sig.signedHeaders = strings.Split(query.Get("X-Amz-SignedHeaders"), ";")
+	sig.signature = query.Get("X-Amz-Signature")
+	sig.payloadHash = unsignedPayload
+	sig.presigned = true
+	return sig, nil
+}
+
+// parseCredential parses the credential of the signature, and checks the credential
+// scope matches the request date
+func parseCredential(credential string, date time.Time) (sig signatureV4, err error) {
+	elems := strings.Split(credential, "/")
+	if len(elems) != 5 || elems[0] == "" || elems[3] != signV4Service || elems[4] != signV4Terminator {
+		return signatureV4{}, errAuthorizationMalformed
+	}
+	if elems[1] != date.Format(yyyymmdd) {
+		return signatureV4{}, errAuthorizationMalformed
+	}
+	return signatureV4{
+		accessKey: elems[0],
+		date:      date,
+		region:    elems[2],
+	}, nil
+}
+
+// parseRequestDate parses the date of the request, which is either in the ISO8601
+// format of X-Amz-Date, or in the http format of Date
+func parseRequestDate(s string) (time.Time, error) {
+	if t, err := time.Parse(iso8601Format, s); err == nil {
+		return t, nil
+	}
+	return http.ParseTime(s)
+}
+
+// canonicalRequest returns the canonical request of the request to be signed
+func canonicalRequest(r *http.Request, sig signatureV4) string {
+	var headers []string
+	for _, name := range sig.signedHeaders {
+		headers = append(headers, name+":"+canonicalHeaderValue(r, name))
+	}
+
+	return strings.Join([]string{
+		r.Method,
+		uriEncode(r.URL.Path, false),
+		canonicalQuery(r, sig.presigned),
+		strings.Join(headers, "\n") + "\n",
+		strings.Join(sig.signedHeaders, ";"),
+		sig.payloadHash,
+	}, "\n")
+}
+
+// canonicalHeaderValue returns the trimmed value of the header. The host header and the
+// content-length header are not in the header map of the incoming request
+func canonicalHeaderValue(r *http.Request, name string) string {
+	switch name {
+	case "host":
+		return r.Host
+	case "content-length":
+		if r.Header.Get("Content-Length") == "" {
+			return strconv.FormatInt(r.ContentLength, 10)
+		}
+	}
+	var values []string
+	for _, value := range r.Header[http.CanonicalHeaderKey(name)] {
+		values = append(values, strings.Join(strings.Fields(value), " "))
+	}
+	return strings.Join(values, ",")
+}
+
+// canonicalQuery returns the encoded query sorted by key. The signature itself is
+// excluded from the presigned query
+func canonicalQuery(r *http.Request, presigned bool) string {
+	var params []string
+	for key, values := range r.URL.Query() {
+		if presigned && key == "X-Amz-Signature" {
+			continue
+		}
+		for _, value := range values {
+			params = append(params, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// computeSignature computes the signature of the canonical request with the secret key
+func computeSignature(secret string, sig signatureV4, canonicalReq string) string {
+	reqHash := sha256.Sum256([]byte(canonicalReq))
+	stringToSign := strings.Join([]string{
+		signV4Algorithm,
+		sig.date.Format(iso8601Format),
+		sig.scope(),
+		hex.EncodeToString(reqHash[:]),
+	}, "\n")
+
+	return hex.EncodeToString(hmacSHA256(deriveSigningKey(secret, sig), stringToSign))
+}
+
+// deriveSigningKey derives the signing key of the credential scope from the secret key
+func deriveSigningKey(secret string, sig signatureV4) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), sig.date.Format(yyyymmdd))
+	key = hmacSHA256(key, sig.region)
+	key = hmacSHA256(key, signV4Service)
+	return hmacSHA256(key, signV4Terminator)
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the data with the key
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// uriEncode encodes the string as specified by AWS signature version 4, which encodes
+// every byte except the unreserved characters. The slash is not encoded in the path
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// payloadReader returns the reader of the request payload. The payload is verified
+// against the signed hash while reading. For the streaming payload the aws-chunked
+// encoding is decoded, and each chunk is verified against its chunk signature, which
+// is chained from the seed signature of the request
+func payloadReader(r *http.Request, sig signatureV4) io.Reader {
+	switch sig.payloadHash {
+	case unsignedPayload:
+		return r.Body
+	case streamingPayload:
+		return &chunkedReader{r: bufio.NewReader(r.Body), sig: sig, prevSignature: sig.signature, h: sha256.New()}
+	default:
+		return &sha256Reader{r: r.Body, h: sha256.New(), expect: sig.payloadHash}
+	}
+}
+
+// sha256Reader checks the sha256 hash of the data read from the reader
+type sha256Reader struct {
+	r      io.Reader
+	h      hash.Hash
+	expect string
+}
+
+// Read reads from the underlying reader, and returns errContentSHA256Mismatch at EOF
+// if the hash is not expected
+func (sr *sha256Reader) Read(p []byte) (int, error) {
+	n, err := sr.r.Read(p)
+	sr.h.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(sr.h.Sum(nil)) != sr.expect {
+		return n, errContentSHA256Mismatch
+	}
+	return n, err
+}
+
+// chunkedReader decodes the aws-chunked encoded payload, in which each chunk has the
+// format of: <hex size>;chunk-signature=<signature>\r\n<data>\r\n
+type chunkedReader struct {
+	r         *bufio.Reader
+	remaining uint64
+	needCRLF  bool
+	done      bool
+
+	// the signature of the current chunk is verified with the hash of the chunk data
+	// and the signature of the previous chunk
+	sig            signatureV4
+	prevSignature  string
+	chunkSignature string
+	h              hash.Hash
+}
+
+// Read reads the decoded data
+func (cr *chunkedReader) Read(p []byte) (n int, err error) {
+	for cr.remaining == 0 {
+		if cr.done {
+			return 0, io.EOF
+		}
+		if err = cr.readChunkHeader(); err != nil {
+			return 0, err
+		}
+	}
+	if uint64(len(p)) > cr.remaining {
+		p = p[:cr.remaining]
+	}
+	n, err = cr.r.Read(p)
+	cr.h.Write(p[:n])
+	cr.remaining -= uint64(n)
+	if err == io.EOF {
+		return n, errIncompleteBody
+	}
+	if err == nil && cr.remaining == 0 {
+		err = cr.verifyChunk()
+	}
+	return n, err
+}
+
+// readChunkHeader reads the header of the next chunk. The zero sized chunk is the
+// last chunk of the payload
+func (cr *chunkedReader) readChunkHeader() error {
+	if cr.needCRLF {
+		if err := cr.readCRLF(); err != nil {
+			return err
+		}
+	}
+	line, err := cr.r.ReadString('\n')
+	if err != nil || !strings.HasSuffix(line, "\r\n") {
+		return errIncompleteBody
+	}
+	elems := strings.SplitN(strings.TrimSuffix(line, "\r\n"), ";", 2)
+	size, err := strconv.ParseUint(elems[0], 16, 64)
+	if err != nil {
+		return errIncompleteBody
+	}
+	if len(elems) != 2 || !strings.HasPrefix(elems[1], "chunk-signature=") {
+		return errSignatureDoesNotMatch
+	}
+	cr.chunkSignature = strings.TrimPrefix(elems[1], "chunk-signature=")
+	cr.h.Reset()
+	if size == 0 {
+		cr.done = true
+		return cr.verifyChunk()
+	}
+	cr.remaining = size
+	cr.needCRLF = true
+	return nil
+}
+
+// verifyChunk verifies the signature of the chunk after the chunk data is read
+func (cr *chunkedReader) verifyChunk() error {
+	stringToSign := strings.Join([]string{
+		chunkAlgorithm,
+		cr.sig.date.Format(iso8601Format),
+		cr.sig.scope(),
+		cr.prevSignature,
+		emptySHA256,
+		hex.EncodeToString(cr.h.Sum(nil)),
+	}, "\n")
+	expect := hex.EncodeToString(hmacSHA256(cr.sig.signingKey, stringToSign))
+	if !hmac.Equal([]byte(expect), []byte(cr.chunkSignature)) {
+		return errSignatureDoesNotMatch
+	}
+	cr.prevSignature = cr.chunkSignature
+	return nil
+}
+
+// readCRLF reads the CRLF following the chunk data
+func (cr *chunkedReader) readCRLF() error {
+	var crlf [2]byte
+	if _, err := io.ReadFull(cr.r, crlf[:]); err != nil || string(crlf[:]) != "\r\n" {
+		return errIncompleteBody
+	}
+	return nil
+}
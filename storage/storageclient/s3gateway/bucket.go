@@ -0,0 +1,354 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package s3gateway
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+const s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// bucketNameRegexp is the pattern of the valid bucket name
+var bucketNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+type (
+	// listAllMyBucketsResult is the response of ListBuckets
+	listAllMyBucketsResult struct {
+		XMLName xml.Name     `xml:"ListAllMyBucketsResult"`
+		Xmlns   string       `xml:"xmlns,attr"`
+		Owner   owner        `xml:"Owner"`
+		Buckets []bucketInfo `xml:"Buckets>Bucket"`
+	}
+
+	owner struct {
+		ID          string `xml:"ID"`
+		DisplayName string `xml:"DisplayName"`
+	}
+
+	bucketInfo struct {
+		Name         string `xml:"Name"`
+		CreationDate string `xml:"CreationDate"`
+	}
+
+	// locationConstraint is the response of GetBucketLocation
+	locationConstraint struct {
+		XMLName xml.Name `xml:"LocationConstraint"`
+		Xmlns   string   `xml:"xmlns,attr"`
+	}
+
+	// listBucketResult is the response of ListObjectsV2
+	listBucketResult struct {
+		XMLName               xml.Name       `xml:"ListBucketResult"`
+		Xmlns                 string         `xml:"xmlns,attr"`
+		Name                  string         `xml:"Name"`
+		Prefix                string         `xml:"Prefix"`
+		Delimiter             string         `xml:"Delimiter,omitempty"`
+		MaxKeys               int            `xml:"MaxKeys"`
+		KeyCount              int            `xml:"KeyCount"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		EncodingType          string         `xml:"EncodingType,omitempty"`
+		ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+		StartAfter            string         `xml:"StartAfter,omitempty"`
+		Contents              []objectInfo   `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	}
+
+	objectInfo struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         uint64 `xml:"Size"`
+		StorageClass string `xml:"StorageClass"`
+	}
+
+	commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+)
+
+// listBuckets lists all the top-level DxDirs as buckets
+func (g *Gateway) listBuckets(w http.ResponseWriter, r *http.Request) error {
+	listing, err := g.fs.ListDir(storage.RootDxPath(), 0, 0)
+	if err != nil {
+		return err
+	}
+	result := listAllMyBucketsResult{Xmlns: s3Namespace}
+	for _, dir := range listing.Dirs {
+		if !bucketNameRegexp.MatchString(dir.DxPath.Path) {
+			continue
+		}
+		result.Buckets = append(result.Buckets, bucketInfo{
+			Name:         dir.DxPath.Path,
+			CreationDate: formatTime(dir.TimeModify),
+		})
+	}
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+// createBucket creates the top-level DxDir for the bucket
+func (g *Gateway) createBucket(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	dxPath, err := bucketDxPath(req.bucket)
+	if err != nil {
+		return err
+	}
+	if g.bucketExists(dxPath) {
+		return errBucketAlreadyOwnedByYou
+	}
+	dir, err := g.fs.NewDxDir(dxPath)
+	if err != nil {
+		return err
+	}
+	if err = dir.Close(); err != nil {
+		return err
+	}
+	w.Header().Set("Location", "/"+req.bucket)
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// headBucket checks whether the bucket exists
+func (g *Gateway) headBucket(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	if _, err := g.existingBucket(req.bucket); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// getBucketLocation returns the empty location constraint of the bucket, since the
+// DxChain storage is not divided into regions
+func (g *Gateway) getBucketLocation(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	if _, err := g.existingBucket(req.bucket); err != nil {
+		return err
+	}
+	writeXML(w, http.StatusOK, locationConstraint{Xmlns: s3Namespace})
+	return nil
+}
+
+// deleteBucket deletes the DxDir of the bucket, which is required to be empty
+func (g *Gateway) deleteBucket(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	dxPath, err := g.existingBucket(req.bucket)
+	if err != nil {
+		return err
+	}
+	keys, err := g.listKeys(dxPath, "")
+	if err != nil {
+		return err
+	}
+	if len(keys) != 0 {
+		return errBucketNotEmpty
+	}
+	if err = g.fs.DeleteDir(dxPath); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// listObjectsV2 lists the objects in the bucket in the lexicographical order. If the
+// delimiter is specified, the keys sharing the same prefix up to the delimiter after
+// the requested prefix are rolled up into the common prefix
+func (g *Gateway) listObjectsV2(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	dxPath, err := g.existingBucket(req.bucket)
+	if err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	result := listBucketResult{
+		Xmlns:             s3Namespace,
+		Name:              req.bucket,
+		Prefix:            query.Get("prefix"),
+		Delimiter:         query.Get("delimiter"),
+		MaxKeys:           maxListKeys,
+		EncodingType:      query.Get("encoding-type"),
+		ContinuationToken: query.Get("continuation-token"),
+		StartAfter:        query.Get("start-after"),
+	}
+	if result.EncodingType != "" && result.EncodingType != "url" {
+		return errInvalidArgument
+	}
+	if s := query.Get("max-keys"); s != "" {
+		maxKeys, err := strconv.Atoi(s)
+		if err != nil || maxKeys < 0 {
+			return errInvalidArgument
+		}
+		if maxKeys < maxListKeys {
+			result.MaxKeys = maxKeys
+		}
+	}
+
+	// entries after the marker are listed
+	marker := result.StartAfter
+	if result.ContinuationToken != "" {
+		token, err := base64.StdEncoding.DecodeString(result.ContinuationToken)
+		if err != nil {
+			return errInvalidArgument
+		}
+		marker = string(token)
+	}
+
+	keys, err := g.listKeys(dxPath, result.Prefix)
+	if err != nil {
+		return err
+	}
+	var lastEntry string
+	for _, key := range keys {
+		entry, isPrefix := listEntry(key, result.Prefix, result.Delimiter)
+		if entry <= marker || entry == lastEntry {
+			continue
+		}
+		if result.KeyCount == result.MaxKeys {
+			result.IsTruncated = true
+			break
+		}
+		lastEntry = entry
+		result.KeyCount++
+		if isPrefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: encodeKey(entry, result.EncodingType)})
+			continue
+		}
+		info, err := g.objectInfo(req.bucket, key)
+		if err != nil {
+			return err
+		}
+		info.Key = encodeKey(key, result.EncodingType)
+		result.Contents = append(result.Contents, info)
+	}
+	if result.IsTruncated {
+		result.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(lastEntry))
+	}
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+// objectInfo returns the listing information of the object
+func (g *Gateway) objectInfo(bucket, key string) (objectInfo, error) {
+	dxPath, err := objectDxPath(bucket, key)
+	if err != nil {
+		return objectInfo{}, err
+	}
+	obj, err := g.openObject(dxPath)
+	if err != nil {
+		return objectInfo{}, err
+	}
+	return objectInfo{
+		Key:          key,
+		LastModified: formatTime(obj.modTime),
+		ETag:         obj.meta.ETag,
+		Size:         obj.size,
+		StorageClass: "STANDARD",
+	}, nil
+}
+
+// listKeys returns the sorted keys of all the objects with the prefix in the bucket.
+// Only the directory containing the prefix is walked through. No object could be listed
+// if the directory is not a valid DxPath in the bucket
+func (g *Gateway) listKeys(bucket storage.DxPath, prefix string) (keys []string, err error) {
+	bucketDir := string(bucket.SysPath(g.fs.RootDir()))
+	walkDir := bucketDir
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dirPath, err := objectDxPath(bucket.Path, prefix[:i])
+		if err != nil {
+			return nil, nil
+		}
+		walkDir = string(dirPath.SysPath(g.fs.RootDir()))
+	}
+	if rel, err := filepath.Rel(bucketDir, walkDir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, nil
+	}
+
+	err = filepath.Walk(walkDir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != storage.DxFileExt {
+			return nil
+		}
+		rel, err := filepath.Rel(bucketDir, path)
+		if err != nil {
+			return err
+		}
+		key := strings.TrimSuffix(filepath.ToSlash(rel), storage.DxFileExt)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	sort.Strings(keys)
+	return
+}
+
+// listEntry returns the listed entry of the key. If the delimiter occurs in the key after
+// the prefix, the key is rolled up to the common prefix ending with the delimiter
+func listEntry(key, prefix, delimiter string) (entry string, isPrefix bool) {
+	if delimiter == "" {
+		return key, false
+	}
+	if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+		return key[:len(prefix)+i+len(delimiter)], true
+	}
+	return key, false
+}
+
+// encodeKey url encodes the key if requested by the encoding type
+func encodeKey(key, encodingType string) string {
+	if encodingType != "url" {
+		return key
+	}
+	return strings.Replace(url.QueryEscape(key), "+", "%20", -1)
+}
+
+// existingBucket returns the DxPath of the bucket, and returns errNoSuchBucket if
+// the bucket does not exist
+func (g *Gateway) existingBucket(bucket string) (storage.DxPath, error) {
+	dxPath, err := bucketDxPath(bucket)
+	if err != nil {
+		return storage.DxPath{}, err
+	}
+	if !g.bucketExists(dxPath) {
+		return storage.DxPath{}, errNoSuchBucket
+	}
+	return dxPath, nil
+}
+
+// bucketExists checks whether the DxDir of the bucket exists
+func (g *Gateway) bucketExists(dxPath storage.DxPath) bool {
+	info, err := os.Stat(string(dxPath.SysPath(g.fs.RootDir())))
+	return err == nil && info.IsDir()
+}
+
+// bucketDxPath returns the DxPath of the bucket
+func bucketDxPath(bucket string) (storage.DxPath, error) {
+	if !bucketNameRegexp.MatchString(bucket) {
+		return storage.DxPath{}, errInvalidBucketName
+	}
+	dxPath, err := storage.NewDxPath(bucket)
+	if err != nil {
+		return storage.DxPath{}, errInvalidBucketName
+	}
+	return dxPath, nil
+}
+
+// formatTime formats the time in the ISO8601 format used in the S3 responses
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
@@ -0,0 +1,39 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package s3gateway
+
+// Default settings of the S3 gateway
+const (
+	DefaultHost = "127.0.0.1"
+	DefaultPort = 9000
+)
+
+// Files and directories related constant
+const (
+	PersistDirectory   = "s3gateway"
+	AccessKeysFilename = "accesskeys.json"
+
+	// multipartDirectory stores the parts of the unfinished multipart uploads
+	multipartDirectory = "multipart"
+
+	// tempDirectory stores the temporary files of the staging and downloading data
+	tempDirectory = "tmp"
+
+	uploadInfoFile = "upload.json"
+)
+
+const (
+	// maxListKeys is the max number of keys returned by ListObjectsV2
+	maxListKeys = 1000
+
+	// maxPartNumber is the max part number of the multipart upload
+	maxPartNumber = 10000
+)
+
+// DefaultConfig is the default configuration of the S3 gateway
+var DefaultConfig = Config{
+	Host: DefaultHost,
+	Port: DefaultPort,
+}
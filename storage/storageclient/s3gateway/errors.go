@@ -0,0 +1,91 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package s3gateway
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// apiError is the error returned to the S3 client, which is encoded as the
+// S3 error response
+type apiError struct {
+	code    string
+	message string
+	status  int
+}
+
+// Error implements the error interface
+func (e *apiError) Error() string {
+	return e.code + ": " + e.message
+}
+
+var (
+	errAccessDenied            = &apiError{"AccessDenied", "Access Denied.", http.StatusForbidden}
+	errInvalidAccessKeyID      = &apiError{"InvalidAccessKeyId", "The access key ID you provided does not exist in our records.", http.StatusForbidden}
+	errSignatureDoesNotMatch   = &apiError{"SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", http.StatusForbidden}
+	errAuthorizationMalformed  = &apiError{"AuthorizationHeaderMalformed", "The authorization header is malformed.", http.StatusBadRequest}
+	errUnsupportedSignature    = &apiError{"InvalidRequest", "Only the AWS Signature Version 4 is supported.", http.StatusBadRequest}
+	errRequestTimeTooSkewed    = &apiError{"RequestTimeTooSkewed", "The difference between the request time and the server's time is too large.", http.StatusForbidden}
+	errExpiredPresignRequest   = &apiError{"AccessDenied", "Request has expired.", http.StatusForbidden}
+	errContentSHA256Mismatch   = &apiError{"XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed.", http.StatusBadRequest}
+	errIncompleteBody          = &apiError{"IncompleteBody", "The request body is not complete.", http.StatusBadRequest}
+	errInvalidBucketName       = &apiError{"InvalidBucketName", "The specified bucket is not valid.", http.StatusBadRequest}
+	errNoSuchBucket            = &apiError{"NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound}
+	errBucketAlreadyOwnedByYou = &apiError{"BucketAlreadyOwnedByYou", "The bucket you tried to create already exists, and you own it.", http.StatusConflict}
+	errBucketNotEmpty          = &apiError{"BucketNotEmpty", "The bucket you tried to delete is not empty.", http.StatusConflict}
+	errNoSuchKey               = &apiError{"NoSuchKey", "The specified key does not exist.", http.StatusNotFound}
+	errInvalidKey              = &apiError{"InvalidArgument", "The specified key is not supported by the DxChain storage.", http.StatusBadRequest}
+	errEmptyObject             = &apiError{"NotImplemented", "Empty objects are not supported by the DxChain storage.", http.StatusNotImplemented}
+	errInvalidRange            = &apiError{"InvalidRange", "The requested range is not satisfiable.", http.StatusRequestedRangeNotSatisfiable}
+	errInvalidArgument         = &apiError{"InvalidArgument", "Invalid argument.", http.StatusBadRequest}
+	errNoSuchUpload            = &apiError{"NoSuchUpload", "The specified multipart upload does not exist.", http.StatusNotFound}
+	errInvalidPart             = &apiError{"InvalidPart", "One or more of the specified parts could not be found.", http.StatusBadRequest}
+	errInvalidPartOrder        = &apiError{"InvalidPartOrder", "The list of parts was not in ascending order.", http.StatusBadRequest}
+	errMalformedXML            = &apiError{"MalformedXML", "The XML you provided was not well-formed.", http.StatusBadRequest}
+	errMethodNotAllowed        = &apiError{"MethodNotAllowed", "The specified method is not allowed against this resource.", http.StatusMethodNotAllowed}
+	errNotImplemented          = &apiError{"NotImplemented", "The requested functionality is not implemented.", http.StatusNotImplemented}
+	errInternalError           = &apiError{"InternalError", "We encountered an internal error. Please try again.", http.StatusInternalServerError}
+)
+
+// errorResponse is the XML body of the S3 error response
+type errorResponse struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource"`
+}
+
+// writeError writes the S3 error response. Errors which are not apiError are
+// reported as internal error
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	e, ok := err.(*apiError)
+	if !ok {
+		e = errInternalError
+	}
+	// HEAD responses shall not have body
+	if r.Method == http.MethodHead {
+		w.WriteHeader(e.status)
+		return
+	}
+	writeXML(w, e.status, errorResponse{
+		Code:     e.code,
+		Message:  e.message,
+		Resource: r.URL.Path,
+	})
+}
+
+// writeXML writes the value as the XML response body with the status code
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
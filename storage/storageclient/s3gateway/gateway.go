@@ -0,0 +1,236 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// Package s3gateway implements the S3-compatible HTTP gateway in front of the storage
+// client. The buckets are mapped to the top-level DxDirs, and the objects are mapped
+// to the DxFiles under the buckets. Only the path-style requests signed with the AWS
+// signature version 4 are supported.
+package s3gateway
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/rpc"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
+)
+
+// Config is the configuration of the S3 gateway
+type Config struct {
+	// Enabled defines whether the S3 gateway is started with the node
+	Enabled bool
+
+	// Host and Port is the listening address of the S3 gateway
+	Host string
+	Port int
+
+	// AccessKeysFile is the JSON file mapping from the access key id to the secret
+	// key. If empty, the accesskeys.json under the persist directory is used
+	AccessKeysFile string `toml:",omitempty"`
+}
+
+// Backend is the storage client used by the S3 gateway
+type Backend interface {
+	// UploadStream uploads the data read from r to the storage hosts, and blocks until
+	// the data is recoverable from the storage hosts
+	UploadStream(up storage.FileUploadParams, r io.Reader) error
+
+	// DownloadRangeSync downloads the data of the file in range, and blocks until
+	// the download is finished
+	DownloadRangeSync(dxPath storage.DxPath, destination io.WriterAt, offset, length uint64) error
+
+	// GetFileSystem returns the file system of the storage client
+	GetFileSystem() filesystem.FileSystem
+}
+
+// Gateway is the S3-compatible HTTP gateway, which implements node.Service
+type Gateway struct {
+	config     Config
+	persistDir string
+	keys       map[string]string
+
+	backend Backend
+	fs      filesystem.FileSystem
+
+	server   *http.Server
+	listener net.Listener
+
+	log log.Logger
+}
+
+// New creates the S3 gateway with the config. The access keys are loaded from the
+// access keys file
+func New(config Config, persistDir string, backend Backend) (*Gateway, error) {
+	keysFile := config.AccessKeysFile
+	if keysFile == "" {
+		keysFile = filepath.Join(persistDir, AccessKeysFilename)
+	}
+	keys, err := loadAccessKeys(keysFile)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Gateway{
+		config:     config,
+		persistDir: persistDir,
+		keys:       keys,
+		backend:    backend,
+		fs:         backend.GetFileSystem(),
+		log:        log.New("module", "s3gateway"),
+	}
+
+	// clear the temporary files left by the last run
+	if err = os.RemoveAll(g.tempDir()); err != nil {
+		return nil, err
+	}
+	for _, dir := range []string{g.multipartDir(), g.tempDir()} {
+		if err = os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create the directory %v: %v", dir, err)
+		}
+	}
+	return g, nil
+}
+
+// Protocols implements node.Service. The S3 gateway has no p2p protocol
+func (g *Gateway) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service. The S3 gateway has no RPC API
+func (g *Gateway) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, starting to serve the S3 requests
+func (g *Gateway) Start(server *p2p.Server) (err error) {
+	endpoint := fmt.Sprintf("%s:%d", g.config.Host, g.config.Port)
+	if g.listener, err = net.Listen("tcp", endpoint); err != nil {
+		return fmt.Errorf("failed to listen on %v: %v", endpoint, err)
+	}
+	g.server = &http.Server{Handler: g}
+	go g.server.Serve(g.listener)
+
+	g.log.Info("S3 gateway started", "url", fmt.Sprintf("http://%s", endpoint))
+	return nil
+}
+
+// Stop implements node.Service, closing the http server
+func (g *Gateway) Stop() error {
+	if g.server == nil {
+		return nil
+	}
+	err := g.server.Close()
+	g.log.Info("S3 gateway stopped")
+	return err
+}
+
+// s3Request is the authenticated S3 request
+type s3Request struct {
+	bucket string
+	key    string
+	sig    signatureV4
+}
+
+// ServeHTTP authenticates the request and dispatches it to the S3 operation
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sig, err := verifyRequest(r, g.keys, time.Now())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	bucket, key := splitPath(r.URL.Path)
+	req := s3Request{bucket: bucket, key: key, sig: sig}
+
+	if err = g.dispatch(w, r, req); err != nil {
+		if _, ok := err.(*apiError); !ok {
+			g.log.Warn("failed to serve the S3 request", "method", r.Method, "path", r.URL.Path, "err", err)
+		}
+		writeError(w, r, err)
+	}
+}
+
+// dispatch calls the handler of the S3 operation specified by the request
+func (g *Gateway) dispatch(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	query := r.URL.Query()
+	switch {
+	case req.bucket == "":
+		if r.Method == http.MethodGet {
+			return g.listBuckets(w, r)
+		}
+
+	case req.key == "":
+		switch r.Method {
+		case http.MethodPut:
+			return g.createBucket(w, r, req)
+		case http.MethodHead:
+			return g.headBucket(w, r, req)
+		case http.MethodDelete:
+			return g.deleteBucket(w, r, req)
+		case http.MethodGet:
+			if _, exists := query["location"]; exists {
+				return g.getBucketLocation(w, r, req)
+			}
+			if query.Get("list-type") != "2" {
+				return errNotImplemented
+			}
+			return g.listObjectsV2(w, r, req)
+		}
+
+	default:
+		_, uploads := query["uploads"]
+		_, uploadID := query["uploadId"]
+		switch r.Method {
+		case http.MethodPut:
+			if r.Header.Get("X-Amz-Copy-Source") != "" {
+				return errNotImplemented
+			}
+			if uploadID {
+				return g.uploadPart(w, r, req)
+			}
+			return g.putObject(w, r, req)
+		case http.MethodGet:
+			return g.getObject(w, r, req)
+		case http.MethodHead:
+			return g.headObject(w, r, req)
+		case http.MethodDelete:
+			if uploadID {
+				return g.abortMultipartUpload(w, r, req)
+			}
+			return g.deleteObject(w, r, req)
+		case http.MethodPost:
+			if uploads {
+				return g.createMultipartUpload(w, r, req)
+			}
+			if uploadID {
+				return g.completeMultipartUpload(w, r, req)
+			}
+		}
+	}
+	return errMethodNotAllowed
+}
+
+// splitPath splits the path-style request path into the bucket and the key
+func splitPath(path string) (bucket, key string) {
+	elems := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	bucket = elems[0]
+	if len(elems) == 2 {
+		key = elems[1]
+	}
+	return
+}
+
+// multipartDir returns the directory of the multipart uploads
+func (g *Gateway) multipartDir() string {
+	return filepath.Join(g.persistDir, multipartDirectory)
+}
+
+// tempDir returns the directory of the temporary files
+func (g *Gateway) tempDir() string {
+	return filepath.Join(g.persistDir, tempDirectory)
+}
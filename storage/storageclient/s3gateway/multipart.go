@@ -0,0 +1,283 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package s3gateway
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCompleteBodySize is the max size of the CompleteMultipartUpload request body
+const maxCompleteBodySize = 1 << 20
+
+type (
+	// multipartUpload is the information of the multipart upload saved in the
+	// directory of the upload, along with the uploaded parts
+	multipartUpload struct {
+		Bucket    string     `json:"bucket"`
+		Key       string     `json:"key"`
+		Initiated time.Time  `json:"initiated"`
+		Meta      objectMeta `json:"meta"`
+	}
+
+	// initiateMultipartUploadResult is the response of CreateMultipartUpload
+	initiateMultipartUploadResult struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		UploadID string   `xml:"UploadId"`
+	}
+
+	// completeMultipartUpload is the request body of CompleteMultipartUpload
+	completeMultipartUpload struct {
+		XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+		Parts   []completePart `xml:"Part"`
+	}
+
+	completePart struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+
+	// completeMultipartUploadResult is the response of CompleteMultipartUpload
+	completeMultipartUploadResult struct {
+		XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Location string   `xml:"Location"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		ETag     string   `xml:"ETag"`
+	}
+)
+
+// createMultipartUpload initiates the multipart upload of the object. The parts are
+// staged in the directory of the upload until the upload is completed or aborted
+func (g *Gateway) createMultipartUpload(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	if _, err := g.objectPath(req); err != nil {
+		return err
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return err
+	}
+	uploadID := hex.EncodeToString(b[:])
+
+	upload := multipartUpload{
+		Bucket:    req.bucket,
+		Key:       req.key,
+		Initiated: time.Now(),
+		Meta: objectMeta{
+			ContentType: r.Header.Get("Content-Type"),
+			UserMeta:    userMetadata(r.Header),
+		},
+	}
+	// check the metadata along with the longest ETag of the completed upload fits in the DxFile
+	completed := upload.Meta
+	completed.ETag = fmt.Sprintf(`"%s-%d"`, md5Hex(nil), maxPartNumber)
+	if _, err := completed.encode(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(g.multipartDir(), uploadID)
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, uploadInfoFile), data, 0600); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+
+	writeXML(w, http.StatusOK, initiateMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Bucket:   req.bucket,
+		Key:      req.key,
+		UploadID: uploadID,
+	})
+	return nil
+}
+
+// uploadPart stages the payload as the part of the multipart upload. The part uploaded
+// with the same part number is replaced
+func (g *Gateway) uploadPart(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	dir, _, err := g.loadUpload(r, req)
+	if err != nil {
+		return err
+	}
+	partNumber, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > maxPartNumber {
+		return errInvalidArgument
+	}
+
+	tmpPath, sum, err := g.stagePayload(r, req.sig)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	if err = checkContentMD5(r, sum); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, filepath.Join(dir, strconv.Itoa(partNumber))); err != nil {
+		if os.IsNotExist(err) {
+			return errNoSuchUpload
+		}
+		return err
+	}
+	w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sum))
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// completeMultipartUpload concatenates the listed parts in order, and uploads the
+// concatenated data as the object
+func (g *Gateway) completeMultipartUpload(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	dir, upload, err := g.loadUpload(r, req)
+	if err != nil {
+		return err
+	}
+	dxPath, err := g.objectPath(req)
+	if err != nil {
+		return err
+	}
+
+	var complete completeMultipartUpload
+	body, err := ioutil.ReadAll(io.LimitReader(payloadReader(r, req.sig), maxCompleteBodySize))
+	if err != nil {
+		return err
+	}
+	if err = xml.Unmarshal(body, &complete); err != nil || len(complete.Parts) == 0 {
+		return errMalformedXML
+	}
+	for i := 1; i < len(complete.Parts); i++ {
+		if complete.Parts[i].PartNumber <= complete.Parts[i-1].PartNumber {
+			return errInvalidPartOrder
+		}
+	}
+
+	tmpPath, sums, err := g.concatParts(dir, complete.Parts)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	meta := upload.Meta
+	meta.ETag = fmt.Sprintf(`"%s-%d"`, md5Hex(sums), len(complete.Parts))
+	if err = g.commitObject(dxPath, tmpPath, meta); err != nil {
+		return err
+	}
+	if err = os.RemoveAll(dir); err != nil {
+		g.log.Warn("failed to remove the multipart upload", "dir", dir, "err", err)
+	}
+
+	writeXML(w, http.StatusOK, completeMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Location: "/" + req.bucket + "/" + req.key,
+		Bucket:   req.bucket,
+		Key:      req.key,
+		ETag:     meta.ETag,
+	})
+	return nil
+}
+
+// abortMultipartUpload removes the multipart upload along with the uploaded parts
+func (g *Gateway) abortMultipartUpload(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	dir, _, err := g.loadUpload(r, req)
+	if err != nil {
+		return err
+	}
+	if err = os.RemoveAll(dir); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// concatParts concatenates the parts into a temporary file. The ETag of each part
+// is checked against the uploaded part, and the md5 checksums of the parts are
+// concatenated and returned
+func (g *Gateway) concatParts(dir string, parts []completePart) (path string, sums []byte, err error) {
+	f, err := ioutil.TempFile(g.tempDir(), "complete")
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	for _, part := range parts {
+		sum, err := appendPart(f, filepath.Join(dir, strconv.Itoa(part.PartNumber)))
+		if err != nil {
+			return "", nil, err
+		}
+		if hex.EncodeToString(sum) != strings.Trim(part.ETag, `"`) {
+			return "", nil, errInvalidPart
+		}
+		sums = append(sums, sum...)
+	}
+	return f.Name(), sums, nil
+}
+
+// appendPart appends the data of the part to the writer, and returns the md5
+// checksum of the part
+func appendPart(w io.Writer, partPath string) ([]byte, error) {
+	part, err := os.Open(partPath)
+	if os.IsNotExist(err) {
+		return nil, errInvalidPart
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer part.Close()
+
+	h := md5.New()
+	if _, err = io.Copy(io.MultiWriter(w, h), part); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// loadUpload loads the multipart upload specified by the uploadId in the query, and
+// checks the upload is initiated for the requested object
+func (g *Gateway) loadUpload(r *http.Request, req s3Request) (dir string, upload multipartUpload, err error) {
+	uploadID := r.URL.Query().Get("uploadId")
+	if _, err = hex.DecodeString(uploadID); err != nil || uploadID == "" {
+		return "", multipartUpload{}, errNoSuchUpload
+	}
+	dir = filepath.Join(g.multipartDir(), uploadID)
+	data, err := ioutil.ReadFile(filepath.Join(dir, uploadInfoFile))
+	if os.IsNotExist(err) {
+		return "", multipartUpload{}, errNoSuchUpload
+	}
+	if err != nil {
+		return "", multipartUpload{}, err
+	}
+	if err = json.Unmarshal(data, &upload); err != nil {
+		return "", multipartUpload{}, err
+	}
+	if upload.Bucket != req.bucket || upload.Key != req.key {
+		return "", multipartUpload{}, errNoSuchUpload
+	}
+	return dir, upload, nil
+}
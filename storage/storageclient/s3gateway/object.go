@@ -0,0 +1,371 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package s3gateway
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+const (
+	defaultContentType = "application/octet-stream"
+	userMetaPrefix     = "x-amz-meta-"
+)
+
+var (
+	errBadDigest        = &apiError{"BadDigest", "The Content-MD5 you specified did not match what we received.", http.StatusBadRequest}
+	errMetadataTooLarge = &apiError{"MetadataTooLarge", "Your metadata headers exceed the maximum allowed metadata size.", http.StatusBadRequest}
+)
+
+// objectMeta is the metadata of the object which is not recorded in the DxFile fields. It
+// is saved as the app metadata of the DxFile
+type objectMeta struct {
+	ETag        string            `json:"etag"`
+	ContentType string            `json:"contentType"`
+	UserMeta    map[string]string `json:"userMeta,omitempty"`
+}
+
+// object is the information of the object retrieved from the DxFile
+type object struct {
+	size    uint64
+	modTime time.Time
+	meta    objectMeta
+}
+
+// putObject stages the payload to the local file, and uploads the staged file as the
+// DxFile of the object. The existing object with the same key is replaced
+func (g *Gateway) putObject(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	dxPath, err := g.objectPath(req)
+	if err != nil {
+		return err
+	}
+	tmpPath, sum, err := g.stagePayload(r, req.sig)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	if err = checkContentMD5(r, sum); err != nil {
+		return err
+	}
+	meta := objectMeta{
+		ETag:        fmt.Sprintf(`"%x"`, sum),
+		ContentType: r.Header.Get("Content-Type"),
+		UserMeta:    userMetadata(r.Header),
+	}
+	if err = g.commitObject(dxPath, tmpPath, meta); err != nil {
+		return err
+	}
+	w.Header().Set("ETag", meta.ETag)
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// getObject downloads the requested range of the object, and writes the data to the
+// response. Only the single range is supported
+func (g *Gateway) getObject(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	dxPath, err := g.objectPath(req)
+	if err != nil {
+		return err
+	}
+	obj, err := g.openObject(dxPath)
+	if err != nil {
+		return err
+	}
+	offset, length, partial, err := parseRange(r.Header.Get("Range"), obj.size)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(g.tempDir(), "download")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+	if err = g.backend.DownloadRangeSync(dxPath, f, offset, length); err != nil {
+		return err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	setObjectHeaders(w, obj)
+	w.Header().Set("Content-Length", strconv.FormatUint(length, 10))
+	status := http.StatusOK
+	if partial {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, obj.size))
+		status = http.StatusPartialContent
+	}
+	w.WriteHeader(status)
+
+	// the error cannot be reported to the client once the header is written
+	if _, err = io.CopyN(w, f, int64(length)); err != nil {
+		g.log.Warn("failed to write the object data", "dxPath", dxPath, "err", err)
+	}
+	return nil
+}
+
+// headObject returns the metadata of the object
+func (g *Gateway) headObject(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	dxPath, err := g.objectPath(req)
+	if err != nil {
+		return err
+	}
+	obj, err := g.openObject(dxPath)
+	if err != nil {
+		return err
+	}
+	setObjectHeaders(w, obj)
+	w.Header().Set("Content-Length", strconv.FormatUint(obj.size, 10))
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// deleteObject deletes the DxFile of the object. If versioning is enabled, the DxFile is
+// archived as a version. Deleting the object not exist is regarded as success
+func (g *Gateway) deleteObject(w http.ResponseWriter, r *http.Request, req s3Request) error {
+	dxPath, err := g.objectPath(req)
+	if err != nil {
+		return err
+	}
+	if _, err = g.openObject(dxPath); err == nil {
+		err = g.fs.RemoveDxFile(dxPath)
+	}
+	if err != nil && err != errNoSuchKey {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// stagePayload writes the request payload to a temporary file, and returns the path
+// and the md5 checksum of the staged file
+func (g *Gateway) stagePayload(r *http.Request, sig signatureV4) (path string, sum []byte, err error) {
+	expectSize := r.ContentLength
+	if sig.payloadHash == streamingPayload {
+		if expectSize, err = strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64); err != nil {
+			return "", nil, errInvalidArgument
+		}
+	}
+
+	f, err := ioutil.TempFile(g.tempDir(), "stage")
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	h := md5.New()
+	size, err := io.Copy(io.MultiWriter(f, h), payloadReader(r, sig))
+	if err != nil {
+		return "", nil, err
+	}
+	if expectSize >= 0 && size != expectSize {
+		return "", nil, errIncompleteBody
+	}
+	if size == 0 {
+		return "", nil, errEmptyObject
+	}
+	return f.Name(), h.Sum(nil), nil
+}
+
+// commitObject uploads the staged file as the DxFile of the object, along with the object
+// metadata. The existing object is replaced once the upload completes, and is kept if the
+// upload fails. The staged file is removed afterwards, and the DxFile is repaired from the
+// storage hosts
+func (g *Gateway) commitObject(dxPath storage.DxPath, stagedPath string, meta objectMeta) error {
+	appMeta, err := meta.encode()
+	if err != nil {
+		return err
+	}
+	_, err = g.fs.UploadStagedFile(stagedPath, dxPath, appMeta, func(uploadPath storage.DxPath, r io.Reader) error {
+		return g.backend.UploadStream(storage.FileUploadParams{
+			DxPath: uploadPath,
+			Mode:   storage.Override,
+		}, r)
+	})
+	return err
+}
+
+// openObject retrieves the information of the object from the DxFile
+func (g *Gateway) openObject(dxPath storage.DxPath) (object, error) {
+	file, err := g.fs.OpenDxFile(dxPath)
+	if err == dxfile.ErrUnknownFile {
+		return object{}, errNoSuchKey
+	}
+	if err != nil {
+		return object{}, err
+	}
+	obj := object{
+		size:    file.FileSize(),
+		modTime: file.TimeModify(),
+	}
+	appMeta := file.AppMetadata()
+	if err = file.Close(); err != nil {
+		g.log.Warn("failed to close the file", "dxPath", dxPath, "err", err)
+	}
+	obj.meta = loadObjectMeta(obj, appMeta)
+	return obj, nil
+}
+
+// loadObjectMeta decodes the metadata saved as the app metadata of the DxFile. The file not
+// uploaded through the S3 gateway has the default metadata
+func loadObjectMeta(obj object, appMeta []byte) (meta objectMeta) {
+	if len(appMeta) != 0 && json.Unmarshal(appMeta, &meta) == nil && meta.ETag != "" {
+		return meta
+	}
+	return objectMeta{
+		ETag: fmt.Sprintf(`"%x-%x"`, obj.size, obj.modTime.Unix()),
+	}
+}
+
+// encode encodes the metadata as the app metadata of the DxFile, which is limited in size
+func (meta objectMeta) encode() ([]byte, error) {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > dxfile.MaxAppMetadataSize {
+		return nil, errMetadataTooLarge
+	}
+	return data, nil
+}
+
+// objectPath returns the DxPath of the object in the existing bucket
+func (g *Gateway) objectPath(req s3Request) (storage.DxPath, error) {
+	if _, err := g.existingBucket(req.bucket); err != nil {
+		return storage.DxPath{}, err
+	}
+	return objectDxPath(req.bucket, req.key)
+}
+
+// objectDxPath returns the DxPath of the object. The key shall be a valid DxPath
+// without leading or trailing slash
+func objectDxPath(bucket, key string) (storage.DxPath, error) {
+	path := bucket + "/" + key
+	dxPath, err := storage.NewDxPath(path)
+	if err != nil || dxPath.Path != path {
+		return storage.DxPath{}, errInvalidKey
+	}
+	return dxPath, nil
+}
+
+// setObjectHeaders sets the response headers of the object metadata
+func setObjectHeaders(w http.ResponseWriter, obj object) {
+	contentType := obj.meta.ContentType
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", obj.meta.ETag)
+	w.Header().Set("Last-Modified", obj.modTime.UTC().Format(http.TimeFormat))
+	w.Header().Set("Accept-Ranges", "bytes")
+	for key, value := range obj.meta.UserMeta {
+		w.Header().Set(key, value)
+	}
+}
+
+// userMetadata returns the user defined metadata in the request header
+func userMetadata(header http.Header) map[string]string {
+	meta := make(map[string]string)
+	for key, values := range header {
+		if key = strings.ToLower(key); strings.HasPrefix(key, userMetaPrefix) && len(values) != 0 {
+			meta[key] = values[0]
+		}
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+// checkContentMD5 checks the md5 checksum of the payload matches the Content-MD5 header
+func checkContentMD5(r *http.Request, sum []byte) error {
+	contentMD5 := r.Header.Get("Content-MD5")
+	if contentMD5 == "" {
+		return nil
+	}
+	expect, err := base64.StdEncoding.DecodeString(contentMD5)
+	if err != nil || !bytes.Equal(expect, sum) {
+		return errBadDigest
+	}
+	return nil
+}
+
+// parseRange parses the single byte range in the Range header. The invalid header
+// is ignored and the whole object is returned, as specified by RFC 7233
+func parseRange(header string, size uint64) (offset, length uint64, partial bool, err error) {
+	spec := strings.TrimPrefix(header, "bytes=")
+	if header == "" || spec == header || strings.Contains(spec, ",") {
+		return 0, size, false, nil
+	}
+	dash := strings.Index(spec, "-")
+	if dash < 0 {
+		return 0, size, false, nil
+	}
+	startStr, endStr := strings.TrimSpace(spec[:dash]), strings.TrimSpace(spec[dash+1:])
+
+	// suffix range of the last bytes
+	if startStr == "" {
+		suffix, err := strconv.ParseUint(endStr, 10, 64)
+		if err != nil {
+			return 0, size, false, nil
+		}
+		if suffix == 0 {
+			return 0, 0, false, errInvalidRange
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return size - suffix, suffix, true, nil
+	}
+
+	start, err := strconv.ParseUint(startStr, 10, 64)
+	if err != nil {
+		return 0, size, false, nil
+	}
+	end := size - 1
+	if endStr != "" {
+		if end, err = strconv.ParseUint(endStr, 10, 64); err != nil || end < start {
+			return 0, size, false, nil
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	if start >= size {
+		return 0, 0, false, errInvalidRange
+	}
+	return start, end - start + 1, true, nil
+}
+
+// md5Hex returns the hex encoded md5 checksum of the data
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// downloadPriorities maps the file priority to the priority of the downloads of the file.
+// The repair downloads have priority 0, thus are always scheduled after user downloads
+var downloadPriorities = map[storage.FilePriority]uint64{
+	storage.PriorityLow:    1,
+	storage.PriorityNormal: 5,
+	storage.PriorityHigh:   10,
+}
+
+// downloadPriority returns the download priority of the file with the file priority
+func downloadPriority(priority storage.FilePriority) uint64 {
+	if p, exist := downloadPriorities[priority]; exist {
+		return p
+	}
+	return downloadPriorities[storage.PriorityNormal]
+}
+
+// SetPriority set the scheduling priority of the file, or all files under the directory
+// specified by path. The segments already in the upload heap are reordered
+func (client *StorageClient) SetPriority(path storage.DxPath, priority storage.FilePriority) error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	if err := client.fileSystem.SetPriority(path, priority); err != nil {
+		return err
+	}
+	client.uploadHeap.reprioritize()
+	return nil
+}
+
+// PauseUploads pause the upload and repair of the file, or all files under the directory
+// specified by path. The segments of the paused files are removed from the upload heap,
+// and the segments being uploaded are not interrupted
+func (client *StorageClient) PauseUploads(path storage.DxPath) error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	if err := client.fileSystem.SetUploadPaused(path, true); err != nil {
+		return err
+	}
+	client.purgeUploadHeap()
+	return nil
+}
+
+// ResumeUploads resume the upload and repair of the file, or all files under the directory
+// specified by path. The resumed files are picked up by the upload loop after the directory
+// metadata is updated
+func (client *StorageClient) ResumeUploads(path storage.DxPath) error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	return client.fileSystem.SetUploadPaused(path, false)
+}
+
+// CancelUploads deletes the file, or all files under the directory specified by path, which
+// have not finished uploading, and removes their segments from the upload heap. Return the
+// paths of the canceled files
+func (client *StorageClient) CancelUploads(path storage.DxPath) ([]storage.DxPath, error) {
+	if err := client.tm.Add(); err != nil {
+		return nil, err
+	}
+	defer client.tm.Done()
+
+	canceled, err := client.fileSystem.CancelUploads(path)
+	if len(canceled) != 0 {
+		client.purgeUploadHeap()
+	}
+	return canceled, err
+}
+
+// purgeUploadHeap removes the segments of the paused or deleted files from the upload heap,
+// and closes the file entries held by the segments
+func (client *StorageClient) purgeUploadHeap() {
+	for _, uc := range client.uploadHeap.purge() {
+		if err := uc.fileEntry.Close(); err != nil {
+			client.log.Warn("failed to close the file", "dxPath", uc.fileEntry.DxPath(), "err", err)
+		}
+	}
+}
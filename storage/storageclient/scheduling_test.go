@@ -0,0 +1,57 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestUploadHeap_Priority test the segments are popped from the upload heap with the higher
+// file priority first, then the stuck segments, then the lower completion percentage
+func TestUploadHeap_Priority(t *testing.T) {
+	uh := uploadHeap{
+		pendingSegments: make(map[uploadSegmentID]struct{}),
+	}
+	segments := []*unfinishedUploadSegment{
+		{id: uploadSegmentID{index: 0}, priority: storage.PriorityLow, stuck: true},
+		{id: uploadSegmentID{index: 1}, priority: storage.PriorityNormal, sectorsCompletedNum: 10},
+		{id: uploadSegmentID{index: 2}, priority: storage.PriorityHigh, sectorsCompletedNum: 20},
+		{id: uploadSegmentID{index: 3}, priority: storage.PriorityNormal, stuck: true},
+		{id: uploadSegmentID{index: 4}, priority: storage.PriorityNormal, sectorsCompletedNum: 5},
+		{id: uploadSegmentID{index: 5}, priority: storage.PriorityHigh, sectorsCompletedNum: 10},
+	}
+	for _, uc := range segments {
+		uc.sectorsAllNeedNum = 30
+		if !uh.push(uc) {
+			t.Fatalf("segment %v not pushed", uc.id.index)
+		}
+	}
+	expect := []uint64{5, 2, 3, 4, 1, 0}
+	for i, index := range expect {
+		uc := uh.pop()
+		if uc == nil {
+			t.Fatalf("pop %v: heap is empty", i)
+		}
+		if uc.id.index != index {
+			t.Errorf("pop %v: unexpected segment. Expect %v, Got %v", i, index, uc.id.index)
+		}
+	}
+}
+
+// TestDownloadPriority test the download priority of the file priorities
+func TestDownloadPriority(t *testing.T) {
+	low, normal, high := downloadPriority(storage.PriorityLow), downloadPriority(storage.PriorityNormal), downloadPriority(storage.PriorityHigh)
+	if !(low < normal && normal < high) {
+		t.Errorf("download priorities not in order: low %v, normal %v, high %v", low, normal, high)
+	}
+	if low == 0 {
+		t.Errorf("download priority shall be higher than the repair downloads")
+	}
+	if p := downloadPriority(0); p != normal {
+		t.Errorf("unknown file priority shall be downloaded with the normal priority. Expect %v, Got %v", normal, p)
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/crypto/ecies"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+var (
+	// errShareSignerMismatch is returned if the share token is not signed by the sharer
+	errShareSignerMismatch = errors.New("share token is not signed by the sharer")
+
+	// errNotShareRecipient is returned if the share token is shared with another node
+	errNotShareRecipient = errors.New("share token is not shared with the local node")
+
+	// errInvalidShareToken is returned if the share token string is not well formatted
+	errInvalidShareToken = errors.New("invalid share token")
+)
+
+// ShareToken is the signed capability with which the shared file could be downloaded from the
+// storage hosts by other storage clients. If the Recipient is not empty, the cipher key in the
+// shared file is wrapped with the public key of the recipient node by ECIES, and only the
+// recipient node is able to decrypt the downloaded data. If the PaymentKey is not empty, the
+// sharer has prepaid the downloads in the ephemeral accounts owned by the key, with which the
+// file could be downloaded from the hosts the recipient has no contract with. The PaymentKey is
+// wrapped for the recipient the same way as the cipher key
+type ShareToken struct {
+	File       dxfile.Share
+	Sharer     enode.ID
+	Recipient  enode.ID
+	PaymentKey []byte
+	Signature  []byte
+}
+
+// RLPHash returns the hash of the share token to be signed by the sharer
+func (st ShareToken) RLPHash() common.Hash {
+	data, _ := rlp.EncodeToBytes([]interface{}{
+		st.File,
+		st.Sharer,
+		st.Recipient,
+		st.PaymentKey,
+	})
+	return crypto.Keccak256Hash(data)
+}
+
+// VerifySignature checks whether the share token is signed by the sharer node
+func (st ShareToken) VerifySignature() error {
+	pubKey, err := crypto.SigToPub(st.RLPHash().Bytes(), st.Signature)
+	if err != nil {
+		return err
+	}
+	if enode.PubkeyToIDV4(pubKey) != st.Sharer {
+		return errShareSignerMismatch
+	}
+	return nil
+}
+
+// EncodeShareToken encodes the share token to a string which could be sent to others
+func EncodeShareToken(st ShareToken) (string, error) {
+	b, err := rlp.EncodeToBytes(st)
+	if err != nil {
+		return "", err
+	}
+	return shareTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeShareToken decodes the share token from the string
+func DecodeShareToken(s string) (ShareToken, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, shareTokenPrefix) {
+		return ShareToken{}, errInvalidShareToken
+	}
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, shareTokenPrefix))
+	if err != nil {
+		return ShareToken{}, fmt.Errorf("%v: %v", errInvalidShareToken, err)
+	}
+	var st ShareToken
+	if err = rlp.DecodeBytes(b, &st); err != nil {
+		return ShareToken{}, fmt.Errorf("%v: %v", errInvalidShareToken, err)
+	}
+	return st, nil
+}
+
+// ShareFile creates the encoded share token of the file, signed by the local node. If the
+// recipient enode url is given, the cipher key is wrapped with the public key of the recipient.
+// Otherwise, anyone holding the token is able to download the file. If prepay is true, the
+// downloads from the hosts storing the file are prepaid with the contracts of the local node
+// for SharePrepaymentValidity blocks, after which the balance left is refunded to the contracts
+func (client *StorageClient) ShareFile(dxPath storage.DxPath, recipient string, prepay bool) (token string, err error) {
+	entry, err := client.fileSystem.OpenDxFile(dxPath)
+	if err != nil {
+		return "", err
+	}
+	share, err := entry.Share()
+	if closeErr := entry.Close(); closeErr != nil {
+		client.log.Warn("failed to close the file", "dxPath", dxPath, "err", closeErr)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	self, err := enode.ParseV4(client.SelfEnodeURL())
+	if err != nil {
+		return "", fmt.Errorf("cannot parse the local enode url: %v", err)
+	}
+	var node *enode.Node
+	if recipient != "" {
+		if node, err = enode.ParseV4(recipient); err != nil {
+			return "", fmt.Errorf("invalid recipient enode url: %v", err)
+		}
+	}
+	st := ShareToken{
+		File:   share,
+		Sharer: self.ID(),
+	}
+	if prepay {
+		var key *ecdsa.PrivateKey
+		if key, err = crypto.GenerateKey(); err != nil {
+			return "", err
+		}
+		if err = client.prepayShareDownload(share, key); err != nil {
+			return "", err
+		}
+		// the prepaid balance is refunded right away if the share token is not created
+		defer func() {
+			if err != nil {
+				client.expireSharePrepayment(key)
+			}
+		}()
+		st.PaymentKey = crypto.FromECDSA(key)
+	}
+	if node != nil {
+		pubKey := ecies.ImportECDSAPublic(node.Pubkey())
+		if st.File.CipherKey, err = ecies.Encrypt(rand.Reader, pubKey, share.CipherKey, nil, nil); err != nil {
+			return "", fmt.Errorf("failed to wrap the cipher key: %v", err)
+		}
+		if len(st.PaymentKey) != 0 {
+			if st.PaymentKey, err = ecies.Encrypt(rand.Reader, pubKey, st.PaymentKey, nil, nil); err != nil {
+				return "", fmt.Errorf("failed to wrap the payment key: %v", err)
+			}
+		}
+		st.Recipient = node.ID()
+	}
+	if st.Signature, err = client.ethBackend.SignWithNodeSk(st.RLPHash().Bytes()); err != nil {
+		return "", fmt.Errorf("failed to sign the share token: %v", err)
+	}
+	return EncodeShareToken(st)
+}
+
+// prepayShareDownload funds the ephemeral accounts owned by the payment key in the hosts storing
+// the shared file with the contracts formed with the hosts. Each host is prepaid for downloading
+// all the sectors it stores one by one, which is the most a download of the file could cost.
+// Nothing is funded unless enough hosts could be prepaid, and the prepayment is recorded before
+// funding so that the balance could be refunded. If not enough hosts are prepaid in the end, the
+// prepayment expires immediately and the balance is refunded in the next check
+func (client *StorageClient) prepayShareDownload(share dxfile.Share, key *ecdsa.PrivateKey) error {
+	sectorsByHost := make(map[enode.ID][]storage.DownloadRequestSector)
+	for _, segment := range share.Segments {
+		for _, sectors := range segment.Sectors {
+			for _, sector := range sectors {
+				sectorsByHost[sector.HostID] = append(sectorsByHost[sector.HostID], storage.DownloadRequestSector{
+					MerkleRoot: sector.MerkleRoot,
+					Length:     uint32(storage.SectorSize),
+				})
+			}
+		}
+	}
+
+	// only the hosts known by the client and having contracts with the client could be prepaid
+	scs := client.contractManager.GetStorageContractSet()
+	var hosts []enode.ID
+	for hostID := range sectorsByHost {
+		if _, exist := client.storageHostManager.RetrieveHostInfo(hostID); !exist {
+			continue
+		}
+		if scs.GetContractIDByHostID(hostID) == (storage.ContractID{}) {
+			continue
+		}
+		hosts = append(hosts, hostID)
+	}
+	if uint32(len(hosts)) < share.MinSectors {
+		return fmt.Errorf("downloads could be prepaid in %v hosts storing the shared file, at least %v needed", len(hosts), share.MinSectors)
+	}
+	if err := client.addSharePrepayment(key, hosts); err != nil {
+		return fmt.Errorf("failed to save the share prepayment: %v", err)
+	}
+
+	var numPrepaid uint32
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	for _, hostID := range hosts {
+		hostInfo, exist := client.storageHostManager.RetrieveHostInfo(hostID)
+		if !exist {
+			continue
+		}
+		amount := common.BigInt0
+		for _, sector := range sectorsByHost[hostID] {
+			amount = amount.Add(estimateDownloadCost(&hostInfo, []storage.DownloadRequestSector{sector}, true))
+		}
+		if amount.Cmp(storage.EphemeralAccountMaxBalance) > 0 {
+			amount = storage.EphemeralAccountMaxBalance
+		}
+		if err := client.prepayHost(&hostInfo, account, amount); err != nil {
+			client.log.Warn("failed to prepay the shared file download", "host", hostID, "err", err)
+			continue
+		}
+		numPrepaid++
+	}
+	if numPrepaid < share.MinSectors {
+		client.expireSharePrepayment(key)
+		return fmt.Errorf("downloads prepaid in %v hosts storing the shared file, at least %v needed", numPrepaid, share.MinSectors)
+	}
+	return nil
+}
+
+// prepayHost funds the ephemeral account of the account address in the host with the amount
+func (client *StorageClient) prepayHost(hostInfo *storage.HostInfo, account common.Address, amount common.BigInt) error {
+	if client.contractManager.GetStorageContractSet().GetContractIDByHostID(hostInfo.EnodeID) == (storage.ContractID{}) {
+		return ErrNoContractsWithHost
+	}
+	sp, err := client.SetupConnection(hostInfo.EnodeURL)
+	if err != nil {
+		return err
+	}
+	if ok := sp.TryToRenewOrRevise(); !ok {
+		return ErrContractRenewing
+	}
+	defer sp.RevisionOrRenewingDone()
+	_, err = client.fundEphemeralAccount(sp, hostInfo, account, amount)
+	return err
+}
+
+// DownloadSharedSync downloads the file shared by the encoded share token to the local path,
+// and blocks until the download is finished. The sectors are downloaded from the hosts with
+// which the client has signed contracts, paid by the ephemeral accounts or the contracts. If
+// the downloads are prepaid by the sharer, the sectors are also downloaded from the other hosts
+// storing the file, paid by the prepaid ephemeral accounts
+func (client *StorageClient) DownloadSharedSync(token string, localPath string) error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	st, err := DecodeShareToken(token)
+	if err != nil {
+		return err
+	}
+	if err = st.VerifySignature(); err != nil {
+		return err
+	}
+	share := st.File
+	if st.Recipient != (enode.ID{}) {
+		self, err := enode.ParseV4(client.SelfEnodeURL())
+		if err != nil {
+			return fmt.Errorf("cannot parse the local enode url: %v", err)
+		}
+		if self.ID() != st.Recipient {
+			return errNotShareRecipient
+		}
+		if share.CipherKey, err = client.ethBackend.DecryptWithNodeSk(share.CipherKey); err != nil {
+			return fmt.Errorf("failed to unwrap the cipher key: %v", err)
+		}
+		if len(st.PaymentKey) != 0 {
+			if st.PaymentKey, err = client.ethBackend.DecryptWithNodeSk(st.PaymentKey); err != nil {
+				return fmt.Errorf("failed to unwrap the payment key: %v", err)
+			}
+		}
+	}
+	var payKey *ecdsa.PrivateKey
+	if len(st.PaymentKey) != 0 {
+		if payKey, err = crypto.ToECDSA(st.PaymentKey); err != nil {
+			return fmt.Errorf("invalid payment key: %v", err)
+		}
+	}
+	snap, err := share.Snapshot()
+	if err != nil {
+		return fmt.Errorf("cannot create snapshot: %v", err)
+	}
+
+	// the segments are recovered from the sectors downloaded by the workers of the contracts
+	// signed with the hosts storing the shared file, and by the prepaid workers of the other
+	// hosts if the downloads are prepaid
+	var numContracted uint32
+	var prepaidWorkers []*worker
+	for _, hostID := range share.HostIDs() {
+		if client.contractManager.GetStorageContractSet().GetContractIDByHostID(hostID) != (storage.ContractID{}) {
+			numContracted++
+			continue
+		}
+		if _, exist := client.storageHostManager.RetrieveHostInfo(hostID); exist && payKey != nil {
+			prepaidWorkers = append(prepaidWorkers, &worker{
+				hostID:       hostID,
+				client:       client,
+				payKey:       payKey,
+				downloadChan: make(chan struct{}, 1),
+				uploadChan:   make(chan struct{}, 1),
+				killChan:     make(chan struct{}),
+			})
+		}
+	}
+	if numHosts := numContracted + uint32(len(prepaidWorkers)); numHosts < share.MinSectors {
+		return fmt.Errorf("contracts signed with or downloads prepaid in %v hosts storing the shared file, at least %v needed", numHosts, share.MinSectors)
+	}
+
+	if localPath, err = localDownloadPath(localPath); err != nil {
+		return err
+	}
+	osFile, err := os.OpenFile(localPath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer osFile.Close()
+
+	// the prepaid workers work for this download only
+	defer func() {
+		for _, w := range prepaidWorkers {
+			close(w.killChan)
+		}
+	}()
+	for _, w := range prepaidWorkers {
+		if err := client.tm.Add(); err != nil {
+			return err
+		}
+		go func(w *worker) {
+			defer client.tm.Done()
+			w.workLoop()
+		}(w)
+	}
+
+	d, err := client.newDownload(downloadParams{
+		destination:       osFile,
+		destinationType:   "file",
+		destinationString: localPath,
+		file:              snap,
+		latencyTarget:     25e3 * time.Millisecond,
+		length:            snap.FileSize(),
+		needsMemory:       true,
+		offset:            0,
+		overdrive:         3,
+		priority:          5,
+		prepaidWorkers:    prepaidWorkers,
+	})
+	if err != nil {
+		return err
+	}
+
+	// block until the download has completed
+	select {
+	case <-d.completeChan:
+		return d.Err()
+	case <-client.tm.StopChan():
+		return errors.New("download is shutdown")
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/crypto/ecies"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// TestShareToken test the share token with the wrapped cipher key and payment key could be
+// encoded, decoded, verified and unwrapped by the recipient
+func TestShareToken(t *testing.T) {
+	sharerKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipientKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipherKey := []byte("the cipher key of the shared file")
+	wrappedKey, err := ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(&recipientKey.PublicKey), cipherKey, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrappedPayKey, err := ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(&recipientKey.PublicKey), crypto.FromECDSA(payKey), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := ShareToken{
+		File: dxfile.Share{
+			DxPath:    "shared/file",
+			FileSize:  1 << 22,
+			CipherKey: wrappedKey,
+		},
+		Sharer:     enode.PubkeyToIDV4(&sharerKey.PublicKey),
+		Recipient:  enode.PubkeyToIDV4(&recipientKey.PublicKey),
+		PaymentKey: wrappedPayKey,
+	}
+	if st.Signature, err = crypto.Sign(st.RLPHash().Bytes(), sharerKey); err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := EncodeShareToken(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeShareToken(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = decoded.VerifySignature(); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.File.DxPath != st.File.DxPath || decoded.File.FileSize != st.File.FileSize || decoded.Recipient != st.Recipient {
+		t.Errorf("decoded share token not expected")
+	}
+	unwrapped, err := ecies.ImportECDSA(recipientKey).Decrypt(decoded.File.CipherKey, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unwrapped, cipherKey) {
+		t.Errorf("unwrapped cipher key not expected")
+	}
+	unwrapped, err = ecies.ImportECDSA(recipientKey).Decrypt(decoded.PaymentKey, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unwrapped, crypto.FromECDSA(payKey)) {
+		t.Errorf("unwrapped payment key not expected")
+	}
+
+	// The share token modified by others shall not pass the verification
+	decoded.Recipient = enode.PubkeyToIDV4(&sharerKey.PublicKey)
+	if err = decoded.VerifySignature(); err != errShareSignerMismatch {
+		t.Errorf("verify modified share token: expect error %v, got %v", errShareSignerMismatch, err)
+	}
+	if _, err = DecodeShareToken(encoded[len(shareTokenPrefix):]); err != errInvalidShareToken {
+		t.Errorf("decode share token without prefix: expect error %v, got %v", errInvalidShareToken, err)
+	}
+}
+
+// TestNewPrepaidWithdrawal test the withdrawal from the prepaid ephemeral account is signed by the
+// payment key, and is made to the host
+func TestNewPrepaidWithdrawal(t *testing.T) {
+	payKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &StorageClient{ethBackend: &BackendTest{}}
+	hostInfo := &storage.HostInfo{EnodeID: enode.RandomID(enode.ID{}, 1)}
+	amount := common.NewBigIntUint64(100)
+
+	w, err := client.newPrepaidWithdrawal(hostInfo, payKey, amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.VerifySignature(); err != nil {
+		t.Fatal(err)
+	}
+	if w.Account != crypto.PubkeyToAddress(payKey.PublicKey) || w.Host != hostInfo.EnodeID {
+		t.Errorf("withdrawal not made from the prepaid account to the host")
+	}
+	if common.PtrBigInt(w.Amount).Cmp(amount) != 0 {
+		t.Errorf("withdrawal amount not expected. Expect %v, Got %v", amount, w.Amount)
+	}
+	next, err := client.newPrepaidWithdrawal(hostInfo, payKey, amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Nonce <= w.Nonce {
+		t.Errorf("withdrawal nonce shall be strictly increasing: %v -> %v", w.Nonce, next.Nonce)
+	}
+}
+
+// TestSharePrepaymentPersist test the share prepayment is saved with the payment key, expired
+// immediately if the share token is not created, and loaded from the persist file
+func TestSharePrepaymentPersist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storageclient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	client := &StorageClient{
+		persistDir:       dir,
+		ethBackend:       &BackendTest{},
+		log:              log.New(),
+		sharePrepayments: make(map[common.Address]*sharePrepayment),
+	}
+	payKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := []enode.ID{enode.RandomID(enode.ID{}, 1), enode.RandomID(enode.ID{}, 2)}
+	if err = client.addSharePrepayment(payKey, hosts); err != nil {
+		t.Fatal(err)
+	}
+	client.expireSharePrepayment(payKey)
+
+	if err = client.loadSharePrepayments(); err != nil {
+		t.Fatal(err)
+	}
+	prepayment, exist := client.sharePrepayments[crypto.PubkeyToAddress(payKey.PublicKey)]
+	if !exist {
+		t.Fatal("share prepayment not loaded")
+	}
+	key, err := prepayment.key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.PubkeyToAddress(key.PublicKey) != crypto.PubkeyToAddress(payKey.PublicKey) {
+		t.Errorf("loaded payment key not expected")
+	}
+	if len(prepayment.Hosts) != len(hosts) || prepayment.Hosts[0] != hosts[0] || prepayment.Hosts[1] != hosts[1] {
+		t.Errorf("loaded prepaid hosts not expected. Expect %v, Got %v", hosts, prepayment.Hosts)
+	}
+	if prepayment.Expiry != client.ethBackend.GetCurrentBlockHeight() {
+		t.Errorf("share prepayment not expired. Expiry %v", prepayment.Expiry)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"crypto/ecdsa"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+var sharePrepaymentsMetadata = common.Metadata{
+	Header:  "storage client share prepayments",
+	Version: SharePrepaymentsVersion,
+}
+
+// sharePrepayment is the record of the downloads of a shared file prepaid in the ephemeral
+// accounts owned by the payment key. The record is saved before any host is funded, so that
+// the balance left in the hosts could be refunded to the sharer once the prepayment expires
+type sharePrepayment struct {
+	PaymentKey hexutil.Bytes
+	Hosts      []enode.ID // hosts whose ephemeral accounts are not refunded yet
+	Expiry     uint64     // block height the balance left could be refunded since
+}
+
+// key returns the payment key of the prepayment
+func (sp *sharePrepayment) key() (*ecdsa.PrivateKey, error) {
+	return crypto.ToECDSA(sp.PaymentKey)
+}
+
+// addSharePrepayment records the prepayment with the payment key in the hosts, and saves it
+// to the persist file
+func (client *StorageClient) addSharePrepayment(key *ecdsa.PrivateKey, hosts []enode.ID) error {
+	client.shareLock.Lock()
+	defer client.shareLock.Unlock()
+
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	client.sharePrepayments[account] = &sharePrepayment{
+		PaymentKey: crypto.FromECDSA(key),
+		Hosts:      hosts,
+		Expiry:     client.ethBackend.GetCurrentBlockHeight() + SharePrepaymentValidity,
+	}
+	return client.saveSharePrepayments()
+}
+
+// expireSharePrepayment expires the prepayment with the payment key immediately, which happens
+// when the share token carrying the payment key is not created. The balance left in the hosts
+// is refunded in the next check
+func (client *StorageClient) expireSharePrepayment(key *ecdsa.PrivateKey) {
+	client.shareLock.Lock()
+	defer client.shareLock.Unlock()
+
+	prepayment, exist := client.sharePrepayments[crypto.PubkeyToAddress(key.PublicKey)]
+	if !exist {
+		return
+	}
+	prepayment.Expiry = client.ethBackend.GetCurrentBlockHeight()
+	if err := client.saveSharePrepayments(); err != nil {
+		client.log.Warn("failed to save the share prepayments", "err", err)
+	}
+}
+
+// sharePrepaymentLoop periodically refunds the balance left in the ephemeral accounts of the
+// expired share prepayments
+func (client *StorageClient) sharePrepaymentLoop() {
+	err := client.tm.Add()
+	if err != nil {
+		return
+	}
+	defer client.tm.Done()
+
+	for {
+		select {
+		case <-client.tm.StopChan():
+			return
+		case <-time.After(SharePrepaymentCheckInterval):
+		}
+
+		client.refundSharePrepayments()
+	}
+}
+
+// refundSharePrepayments refunds the balance left in the ephemeral accounts of the expired share
+// prepayments back to the contracts formed with the hosts. The host failed to be refunded is
+// retried in the next check, until the ephemeral account is forfeited to the host
+func (client *StorageClient) refundSharePrepayments() {
+	height := client.ethBackend.GetCurrentBlockHeight()
+
+	client.shareLock.Lock()
+	expired := make(map[common.Address]sharePrepayment)
+	for account, prepayment := range client.sharePrepayments {
+		if prepayment.Expiry <= height {
+			expired[account] = *prepayment
+		}
+	}
+	client.shareLock.Unlock()
+
+	for account, prepayment := range expired {
+		key, err := prepayment.key()
+		if err != nil {
+			client.log.Warn("invalid share payment key", "account", account, "err", err)
+			continue
+		}
+		var remaining []enode.ID
+		for _, hostID := range prepayment.Hosts {
+			if err := client.refundPrepaidHost(hostID, key); err != nil {
+				client.log.Warn("failed to refund the share prepayment", "host", hostID, "err", err)
+				remaining = append(remaining, hostID)
+			}
+		}
+
+		client.shareLock.Lock()
+		if len(remaining) == 0 || prepayment.Expiry+storage.EphemeralAccountExpiry < height {
+			delete(client.sharePrepayments, account)
+		} else if record, exist := client.sharePrepayments[account]; exist {
+			record.Hosts = remaining
+		}
+		if err := client.saveSharePrepayments(); err != nil {
+			client.log.Warn("failed to save the share prepayments", "err", err)
+		}
+		client.shareLock.Unlock()
+	}
+}
+
+// refundPrepaidHost refunds all the balance left in the ephemeral account owned by the payment key
+// in the host back to the contract formed with the host. The balance is learned by requesting the
+// host config paid by the account. The host rejecting the request has no balance left to refund
+func (client *StorageClient) refundPrepaidHost(hostID enode.ID, key *ecdsa.PrivateKey) error {
+	hostInfo, exist := client.storageHostManager.RetrieveHostInfo(hostID)
+	if !exist {
+		return ErrUnableRetrieveHostInfo
+	}
+	if client.contractManager.GetStorageContractSet().GetContractIDByHostID(hostID) == (storage.ContractID{}) {
+		return ErrNoContractsWithHost
+	}
+	sp, err := client.SetupConnection(hostInfo.EnodeURL)
+	if err != nil {
+		return err
+	}
+
+	withdrawal, err := client.newPrepaidWithdrawal(&hostInfo, key, hostInfo.BaseRPCPrice)
+	if err != nil {
+		return err
+	}
+	resp, err := client.ephemeralHostConfig(sp, withdrawal)
+	if err == storage.ErrHostNegotiate {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	balance := common.PtrBigInt(resp.Balance)
+	if balance.Sign() <= 0 {
+		return nil
+	}
+
+	if withdrawal, err = client.newPrepaidWithdrawal(&hostInfo, key, balance); err != nil {
+		return err
+	}
+	if ok := sp.TryToRenewOrRevise(); !ok {
+		return ErrContractRenewing
+	}
+	defer sp.RevisionOrRenewingDone()
+	_, err = client.refundEphemeralAccount(sp, &hostInfo, withdrawal)
+	return err
+}
+
+// saveSharePrepayments saves the share prepayments to the persist file. The caller shall hold
+// the shareLock
+func (client *StorageClient) saveSharePrepayments() error {
+	return common.SaveDxJSON(sharePrepaymentsMetadata, filepath.Join(client.persistDir, SharePrepaymentsFilename), client.sharePrepayments)
+}
+
+// loadSharePrepayments loads the share prepayments from the persist file
+func (client *StorageClient) loadSharePrepayments() error {
+	client.shareLock.Lock()
+	defer client.shareLock.Unlock()
+
+	client.sharePrepayments = make(map[common.Address]*sharePrepayment)
+	err := common.LoadDxJSON(sharePrepaymentsMetadata, filepath.Join(client.persistDir, SharePrepaymentsFilename), &client.sharePrepayments)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
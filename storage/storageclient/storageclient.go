@@ -54,14 +54,37 @@ type StorageClient struct {
 	// Upload management
 	uploadHeap uploadHeap
 
+	// Migration management, signaled when the data need to be migrated away from a storage host
+	migrationNeeded chan struct{}
+
 	// List of workers that can be used for uploading and/or downloading.
 	workerPool map[storage.ContractID]*worker
 
+	// Ephemeral account balances in the hosts, and the nonce of the last withdrawal
+	ephemeralBalances map[enode.ID]common.BigInt
+	ephemeralNonce    uint64
+	ephemeralLock     sync.Mutex
+
 	// Directories and File related
 	persist        persistence
 	persistDir     string
 	staticFilesDir string
 
+	// Master key management. The master key is unlocked once the passphrase is provided
+	masterKeyLock     sync.Mutex
+	masterKeyUnlocked bool
+
+	// Sync folders mirroring the local directories, keyed by the local directory. The scan
+	// is signaled when a sync folder is added
+	syncFolders    map[string]*syncFolder
+	syncLock       sync.Mutex
+	syncScanNeeded chan struct{}
+
+	// Downloads of the shared files prepaid in the ephemeral accounts, keyed by the account
+	// address of the payment key
+	sharePrepayments map[common.Address]*sharePrepayment
+	shareLock        sync.Mutex
+
 	//storage client is used as the address to sign the storage contract and pays for the money
 	PaymentAddress common.Address
 
@@ -81,17 +104,22 @@ func New(persistDir string) (*StorageClient, error) {
 	var err error
 
 	sc := &StorageClient{
-		persistDir:     persistDir,
-		staticFilesDir: filepath.Join(persistDir, DxPathRoot),
-		log:            log.New(),
-		newDownloads:   make(chan struct{}, 1),
-		downloadHeap:   new(downloadSegmentHeap),
+		persistDir:       persistDir,
+		staticFilesDir:   filepath.Join(persistDir, DxPathRoot),
+		log:              log.New(),
+		newDownloads:     make(chan struct{}, 1),
+		migrationNeeded:  make(chan struct{}, 1),
+		syncFolders:      make(map[string]*syncFolder),
+		syncScanNeeded:   make(chan struct{}, 1),
+		sharePrepayments: make(map[common.Address]*sharePrepayment),
+		downloadHeap:     new(downloadSegmentHeap),
 		uploadHeap: uploadHeap{
 			pendingSegments:     make(map[uploadSegmentID]struct{}),
 			segmentComing:       make(chan struct{}, 1),
 			stuckSegmentSuccess: make(chan storage.DxPath, 1),
 		},
-		workerPool: make(map[storage.ContractID]*worker),
+		workerPool:        make(map[storage.ContractID]*worker),
+		ephemeralBalances: make(map[enode.ID]common.BigInt),
 	}
 
 	sc.memoryManager = memorymanager.New(DefaultMaxMemory, sc.tm.StopChan())
@@ -144,12 +172,17 @@ func (client *StorageClient) Start(b storage.EthBackend, apiBackend ethapi.Backe
 	// active the work pool to get a worker for a upload/download task.
 	client.activateWorkerPool()
 
-	// loop to download, upload, stuck and health check
+	// loop to download, upload, stuck, health check, migration, folder sync and share refund
 	go client.downloadLoop()
 	go client.uploadLoop()
 	go client.stuckLoop()
 	go client.uploadOrRepair()
 	go client.healthCheckLoop()
+	go client.migrationLoop()
+	go client.versionRepairLoop()
+	go client.bandwidthScheduleLoop()
+	go client.syncFolderLoop()
+	go client.sharePrepaymentLoop()
 
 	// kill workers on shutdown.
 	client.tm.OnStop(func() error {
@@ -161,6 +194,9 @@ func (client *StorageClient) Start(b storage.EthBackend, apiBackend ethapi.Backe
 		return nil
 	})
 
+	if client.masterKeyLocked() {
+		client.log.Warn("The master key is locked. Unlock the master key to upload and download files")
+	}
 	client.log.Info("Storage Client Started")
 
 	return nil
@@ -224,7 +260,7 @@ func (client *StorageClient) ActiveContracts() (activeContracts []ActiveContract
 }
 
 // SetClientSetting will config the client setting based on the value provided
-// it will set the bandwidth limit, rentPayment, and ipViolation check
+// it will set the bandwidth limit, rentPayment, ipViolation check, host policy and host evaluator
 // By setting the rentPayment, the contract maintenance
 func (client *StorageClient) SetClientSetting(setting storage.ClientSetting) (err error) {
 	// making sure the entire program will only be terminated after finish the SetClientSetting
@@ -241,14 +277,29 @@ func (client *StorageClient) SetClientSetting(setting storage.ClientSetting) (er
 			setting.MaxUploadSpeed, setting.MaxDownloadSpeed)
 		return
 	}
+	if err = setting.BandwidthSchedule.Validate(); err != nil {
+		err = fmt.Errorf("invalid bandwidth schedule: %s", err.Error())
+		return
+	}
 
-	// set the rent payment
-	if err = client.contractManager.SetRentPayment(setting.RentPayment, client.storageHostManager); err != nil {
+	// set the host policy before the rent payment, so that the contract maintenance triggered
+	// follows the new host policy
+	if err = client.storageHostManager.SetHostPolicy(setting.HostPolicy); err != nil {
+		return
+	}
+
+	// set the host evaluator and the factor weights used by the weighted evaluator
+	if err = client.storageHostManager.SetEvaluationWeights(setting.EvaluationWeights); err != nil {
 		return
 	}
+	if setting.Evaluator != "" {
+		if err = client.storageHostManager.SetEvaluator(setting.Evaluator); err != nil {
+			return
+		}
+	}
 
-	// set upload/download (write/read) bandwidth limits
-	if err = client.setBandwidthLimits(setting.MaxDownloadSpeed, setting.MaxUploadSpeed); err != nil {
+	// set the rent payment
+	if err = client.contractManager.SetRentPayment(setting.RentPayment, client.storageHostManager); err != nil {
 		return
 	}
 
@@ -259,6 +310,7 @@ func (client *StorageClient) SetClientSetting(setting storage.ClientSetting) (er
 	client.lock.Lock()
 	client.persist.MaxDownloadSpeed = setting.MaxDownloadSpeed
 	client.persist.MaxUploadSpeed = setting.MaxUploadSpeed
+	client.persist.BandwidthSchedule = setting.BandwidthSchedule
 	if err = client.saveSettings(); err != nil {
 		err = fmt.Errorf("failed to save the storage client settings: %s", err.Error())
 		client.lock.Unlock()
@@ -266,20 +318,34 @@ func (client *StorageClient) SetClientSetting(setting storage.ClientSetting) (er
 	}
 	client.lock.Unlock()
 
+	// set upload/download (write/read) bandwidth limits based on the bandwidth schedule
+	if err = client.applyBandwidthSchedule(time.Now()); err != nil {
+		return
+	}
+
 	// active the worker pool
 	client.activateWorkerPool()
 
 	return
 }
 
-// RetrieveClientSetting will return the current storage client setting
+// RetrieveClientSetting will return the current storage client setting. The speed limits
+// returned are the ones applied out of the bandwidth schedule windows
 func (client *StorageClient) RetrieveClientSetting() (setting storage.ClientSetting) {
-	maxDownloadSpeed, maxUploadSpeed, _ := client.contractManager.RetrieveRateLimit()
+	client.lock.Lock()
+	maxDownloadSpeed, maxUploadSpeed := client.persist.MaxDownloadSpeed, client.persist.MaxUploadSpeed
+	schedule := client.persist.BandwidthSchedule
+	client.lock.Unlock()
+
 	setting = storage.ClientSetting{
 		RentPayment:       client.contractManager.AcquireRentPayment(),
 		EnableIPViolation: client.storageHostManager.RetrieveIPViolationCheckSetting(),
 		MaxUploadSpeed:    maxUploadSpeed,
 		MaxDownloadSpeed:  maxDownloadSpeed,
+		HostPolicy:        client.storageHostManager.RetrieveHostPolicy(),
+		Evaluator:         client.storageHostManager.RetrieveEvaluator(),
+		EvaluationWeights: client.storageHostManager.RetrieveEvaluationWeights(),
+		BandwidthSchedule: schedule,
 	}
 	return
 }
@@ -379,6 +445,7 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 		req.NewMissedProofValues[i] = o.Value
 	}
 
+	measure := newTransferMeasure()
 	var clientNegotiateErr, hostNegotiateErr, hostCommitErr error
 	defer func() {
 		if clientNegotiateErr != nil {
@@ -396,6 +463,9 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 
 		if err == nil {
 			client.storageHostManager.IncrementSuccessfulInteractions(hostInfo.EnodeID, storagehostmanager.InteractionUpload)
+			client.recordTransfer(hostInfo.EnodeID, storagehostmanager.InteractionUpload, measure, uploadSize(actions))
+		} else {
+			client.recordTransferError(hostInfo.EnodeID, storagehostmanager.InteractionUpload, err, clientNegotiateErr, hostNegotiateErr, hostCommitErr)
 		}
 	}()
 
@@ -410,6 +480,7 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 	if err != nil {
 		return fmt.Errorf("read upload merkle proof response msg failed, err: %v", err)
 	}
+	measure.firstResponse()
 
 	// meaning request was sent too frequently, the host's evaluation
 	// will not be degraded
@@ -613,7 +684,8 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 		req.NewMissedProofValues[i] = nmpo.Value
 	}
 
-	// record the successful or failed interactions
+	// record the successful or failed interactions, and the measured performance
+	measure := newTransferMeasure()
 	var clientNegotiateErr, hostNegotiateErr, hostCommitErr error
 	defer func() {
 		if clientNegotiateErr != nil {
@@ -632,6 +704,9 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 
 		if err == nil {
 			client.storageHostManager.IncrementSuccessfulInteractions(hostInfo.EnodeID, storagehostmanager.InteractionDownload)
+			client.recordTransfer(hostInfo.EnodeID, storagehostmanager.InteractionDownload, measure, uint64(sector.Length))
+		} else {
+			client.recordTransferError(hostInfo.EnodeID, storagehostmanager.InteractionDownload, err, clientNegotiateErr, hostNegotiateErr, hostCommitErr)
 		}
 	}()
 
@@ -649,6 +724,7 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 	if err != nil {
 		return err
 	}
+	measure.firstResponse()
 
 	// meaning request was sent too frequently, the host's evaluation
 	// will not be degraded
@@ -765,6 +841,232 @@ func (client *StorageClient) Download(sp storage.Peer, root common.Hash, offset,
 	return buf.Bytes(), err
 }
 
+// ReadBatch calls the batched download RPC, requesting all sector ranges in req with a single
+// contract revision. The data of the ranges are returned in the request order
+func (client *StorageClient) ReadBatch(sp storage.Peer, req storage.DownloadBatchRequest, hostInfo *storage.HostInfo) (data [][]byte, err error) {
+	// sanity check the request.
+	if len(req.Sectors) == 0 {
+		return nil, errors.New("no sector requested")
+	}
+	var totalLength uint64
+	for _, sector := range req.Sectors {
+		if uint64(sector.Offset)+uint64(sector.Length) > storage.SectorSize {
+			return nil, errors.New("download out boundary of sector")
+		}
+		if req.MerkleProof {
+			if sector.Offset%merkle.LeafSize != 0 || sector.Length%merkle.LeafSize != 0 {
+				return nil, errors.New("offset and length must be multiples of SegmentSize when requesting a Merkle proof")
+			}
+		}
+		totalLength += uint64(sector.Length)
+	}
+	if totalLength > hostInfo.MaxDownloadBatchSize {
+		return nil, fmt.Errorf("requested %v bytes exceeds the host max download batch size %v", totalLength, hostInfo.MaxDownloadBatchSize)
+	}
+
+	// retrieve the last contract revision
+	scs := client.contractManager.GetStorageContractSet()
+
+	// find the contractID formed by this host
+	contractID := scs.GetContractIDByHostID(hostInfo.EnodeID)
+	contract, exist := scs.Acquire(contractID)
+	if !exist {
+		return nil, fmt.Errorf("not exist this contract: %s", contractID.String())
+	}
+	defer scs.Return(contract)
+
+	// old contract header and revision
+	contractHeader := contract.Header()
+	lastRevision := contractHeader.LatestContractRevision
+
+	// calculate price
+	price := estimateDownloadCost(hostInfo, req.Sectors, req.MerkleProof)
+	if lastRevision.NewValidProofOutputs[0].Value.Cmp(price.BigIntPtr()) < 0 {
+		return nil, errors.New("client funds not enough to support download")
+	}
+
+	// increase the price fluctuation by 0.2% to mitigate small errors, like different block height
+	price = price.MultFloat64(1 + extraRatio)
+
+	// create the download revision and sign it
+	newRevision := NewRevision(lastRevision, price.BigIntPtr())
+
+	// client sign the revision
+	am := client.ethBackend.AccountManager()
+	account := accounts.Account{Address: newRevision.NewValidProofOutputs[0].Address}
+	wallet, err := am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+
+	clientSig, err := wallet.SignHash(account, newRevision.RLPHash().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	req.Signature = clientSig[:]
+	req.StorageContractID = newRevision.ParentID
+	req.NewRevisionNumber = newRevision.NewRevisionNumber
+
+	req.NewValidProofValues = make([]*big.Int, len(newRevision.NewValidProofOutputs))
+	for i, nvpo := range newRevision.NewValidProofOutputs {
+		req.NewValidProofValues[i] = nvpo.Value
+	}
+
+	req.NewMissedProofValues = make([]*big.Int, len(newRevision.NewMissedProofOutputs))
+	for i, nmpo := range newRevision.NewMissedProofOutputs {
+		req.NewMissedProofValues[i] = nmpo.Value
+	}
+
+	// record the successful or failed interactions, and the measured performance
+	measure := newTransferMeasure()
+	var clientNegotiateErr, hostNegotiateErr, hostCommitErr error
+	defer func() {
+		if clientNegotiateErr != nil {
+			_ = sp.SendClientNegotiateErrorMsg()
+			if msg, err := sp.ClientWaitContractResp(); err != nil || msg.Code != storage.HostAckMsg {
+				client.log.Error("Client receive host ack msg failed or msg.code is not host ack", "err", err)
+			}
+		}
+
+		if hostCommitErr != nil || hostNegotiateErr != nil {
+			client.CheckAndUpdateConnection(sp.PeerNode())
+			client.storageHostManager.IncrementFailedInteractions(hostInfo.EnodeID, storagehostmanager.InteractionDownload)
+		}
+
+		if err == nil {
+			client.storageHostManager.IncrementSuccessfulInteractions(hostInfo.EnodeID, storagehostmanager.InteractionDownload)
+			client.recordTransfer(hostInfo.EnodeID, storagehostmanager.InteractionDownload, measure, totalLength)
+		} else {
+			client.recordTransferError(hostInfo.EnodeID, storagehostmanager.InteractionDownload, err, clientNegotiateErr, hostNegotiateErr, hostCommitErr)
+		}
+	}()
+
+	// send download batch request
+	err = sp.RequestContractDownloadBatch(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// read the streamed host data responses, one for each requested range
+	var hostSig []byte
+	data = make([][]byte, len(req.Sectors))
+	for i, sector := range req.Sectors {
+		msg, err := sp.ClientWaitContractResp()
+		if err != nil {
+			return nil, err
+		}
+		measure.firstResponse()
+
+		// meaning request was sent too frequently, the host's evaluation
+		// will not be degraded
+		if msg.Code == storage.HostBusyHandleReqMsg {
+			return nil, storage.ErrHostBusyHandleReq
+		}
+
+		// if host send some negotiation error, client should handler it
+		if msg.Code == storage.HostNegotiateErrorMsg {
+			hostNegotiateErr = storage.ErrHostNegotiate
+			return nil, hostNegotiateErr
+		}
+
+		var resp storage.DownloadBatchResponse
+		if err = msg.Decode(&resp); err != nil {
+			hostNegotiateErr = err
+			return nil, err
+		}
+
+		if int(resp.Index) != i {
+			hostNegotiateErr = fmt.Errorf("host sent sector data out of order: expect %d, got %d", i, resp.Index)
+			return nil, hostNegotiateErr
+		}
+		if len(resp.Data) != int(sector.Length) {
+			hostNegotiateErr = errors.New("host did not send enough sector data")
+			return nil, hostNegotiateErr
+		}
+
+		if req.MerkleProof {
+			proofStart := int(sector.Offset) / merkle.LeafSize
+			proofEnd := int(sector.Offset+sector.Length) / merkle.LeafSize
+			verified, err := merkle.Sha256VerifyRangeProof(resp.Data, resp.MerkleProof, proofStart, proofEnd, sector.MerkleRoot)
+			if !verified || err != nil {
+				hostNegotiateErr = errors.New("host provided incorrect sector data or Merkle proof")
+				return nil, hostNegotiateErr
+			}
+		}
+
+		if i == 0 {
+			if len(resp.Signature) == 0 {
+				hostNegotiateErr = errors.New("host lost response data signature")
+				return nil, hostNegotiateErr
+			}
+			hostSig = resp.Signature
+		}
+		data[i] = resp.Data
+	}
+
+	newRevision.Signatures = [][]byte{clientSig, hostSig}
+
+	// commit this revision
+	err = contract.CommitRevision(newRevision, price)
+	if err != nil {
+		if err := sp.SendClientCommitFailedMsg(); err != nil {
+			return nil, err
+		}
+
+		// wait for host ack msg
+		msg, err := sp.ClientWaitContractResp()
+		if err == nil && msg.Code == storage.HostAckMsg {
+			return nil, fmt.Errorf("commitDownloadBatch update contract header failed, err: %v", err)
+		}
+		return nil, fmt.Errorf("commitDownloadBatch failed, but don't wait for host ack msg, err: %v", err)
+	}
+
+	_ = sp.SendClientCommitSuccessMsg()
+
+	// wait for HostAckMsg until timeout
+	msg, err := sp.ClientWaitContractResp()
+	if err != nil {
+		log.Error("contract download batch failed when wait for host ACK msg", "err", err.Error())
+
+		_ = contract.RollbackUndoMem(contractHeader)
+		err = fmt.Errorf("failed to read host ACK message, error: %s", err.Error())
+		return nil, err
+	}
+
+	switch msg.Code {
+	case storage.HostAckMsg:
+		return data, nil
+	default:
+		hostCommitErr = storage.ErrHostCommit
+		_ = contract.RollbackUndoMem(contractHeader)
+
+		_ = sp.SendClientAckMsg()
+		_, _ = sp.ClientWaitContractResp()
+		return nil, hostCommitErr
+	}
+}
+
+// DownloadBatch requests for multiple whole sectors from the host with a single contract revision,
+// and returns the sector data in the order of roots. A Merkle proof is always requested.
+func (client *StorageClient) DownloadBatch(sp storage.Peer, roots []common.Hash, hostInfo *storage.HostInfo) ([][]byte, error) {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	req := storage.DownloadBatchRequest{
+		Sectors:     make([]storage.DownloadRequestSector, len(roots)),
+		MerkleProof: true,
+	}
+	for i, root := range roots {
+		req.Sectors[i] = storage.DownloadRequestSector{
+			MerkleRoot: root,
+			Offset:     0,
+			Length:     uint32(storage.SectorSize),
+		}
+	}
+	return client.ReadBatch(sp, req, hostInfo)
+}
+
 // newDownload creates and initializes a download task based on the provided parameters from outer request
 func (client *StorageClient) newDownload(params downloadParams) (*download, error) {
 
@@ -795,6 +1097,7 @@ func (client *StorageClient) newDownload(params downloadParams) (*download, erro
 		overdrive:         params.overdrive,
 		dxFile:            params.file,
 		priority:          params.priority,
+		prepaidWorkers:    params.prepaidWorkers,
 		log:               client.log,
 		memoryManager:     client.memoryManager,
 	}
@@ -915,25 +1218,8 @@ func (client *StorageClient) createDownload(p storage.DownloadParameters) (*down
 	defer entry.SetTimeAccess(time.Now())
 
 	// validate download parameters.
-	if p.WriteToLocalPath == "" {
-		return nil, errors.New("not specified local path")
-	}
-
-	// if the parameter WriteToLocalPath is not a absolute path, set default file name
-	if p.WriteToLocalPath != "" && !filepath.IsAbs(p.WriteToLocalPath) {
-		if strings.Contains(p.WriteToLocalPath, "/") {
-			return nil, errors.New("should specify the file name not include directory，or specify absolute path")
-		}
-
-		if home := os.Getenv("HOME"); home == "" {
-			return nil, errors.New("not home env")
-		}
-
-		usr, err := user.Current()
-		if err != nil {
-			return nil, err
-		}
-		p.WriteToLocalPath = filepath.Join(usr.HomeDir, p.WriteToLocalPath)
+	if p.WriteToLocalPath, err = localDownloadPath(p.WriteToLocalPath); err != nil {
+		return nil, err
 	}
 
 	// instantiate the file to write the downloaded data
@@ -965,7 +1251,7 @@ func (client *StorageClient) createDownload(p storage.DownloadParameters) (*down
 		// always download from 0
 		offset:    0,
 		overdrive: 3,
-		priority:  5,
+		priority:  downloadPriority(entry.Priority()),
 	})
 	if closer, ok := dw.(io.Closer); err != nil && ok {
 		closeErr := closer.Close()
@@ -989,6 +1275,32 @@ func (client *StorageClient) createDownload(p storage.DownloadParameters) (*down
 	return d, nil
 }
 
+// localDownloadPath validates the local path to write the downloaded data. If the path is not
+// an absolute path, the file is written in the home directory
+func localDownloadPath(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("not specified local path")
+	}
+
+	// if the path is not a absolute path, set default file name
+	if !filepath.IsAbs(path) {
+		if strings.Contains(path, "/") {
+			return "", errors.New("should specify the file name not include directory，or specify absolute path")
+		}
+
+		if home := os.Getenv("HOME"); home == "" {
+			return "", errors.New("not home env")
+		}
+
+		usr, err := user.Current()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(usr.HomeDir, path)
+	}
+	return path, nil
+}
+
 // NOTE: DownloadSync can directly be accessed to outer request via RPC or IPC ...
 // but can not async download to http response, so DownloadAsync should not open to out.
 
@@ -1036,6 +1348,53 @@ func (client *StorageClient) DownloadAsync(p storage.DownloadParameters) error {
 	return err
 }
 
+// DownloadRangeSync downloads length bytes of the file starting from offset, and writes the
+// data to the destination starting from 0. It blocks until the download is finished
+func (client *StorageClient) DownloadRangeSync(dxPath storage.DxPath, destination io.WriterAt, offset, length uint64) error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	entry, err := client.fileSystem.OpenDxFile(dxPath)
+	if err != nil {
+		return err
+	}
+	snap, err := entry.Snapshot()
+	priority := downloadPriority(entry.Priority())
+	entry.SetTimeAccess(time.Now())
+	if closeErr := entry.Close(); closeErr != nil {
+		client.log.Warn("failed to close the file", "dxPath", dxPath, "err", closeErr)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot create snapshot: %v", err)
+	}
+
+	d, err := client.newDownload(downloadParams{
+		destination:       destination,
+		destinationType:   "writer",
+		destinationString: dxPath.Path,
+		file:              snap,
+		latencyTarget:     25e3 * time.Millisecond,
+		length:            length,
+		needsMemory:       true,
+		offset:            offset,
+		overdrive:         3,
+		priority:          priority,
+	})
+	if err != nil {
+		return err
+	}
+
+	// block until the download has completed
+	select {
+	case <-d.completeChan:
+		return d.Err()
+	case <-client.tm.StopChan():
+		return errors.New("download is shutdown")
+	}
+}
+
 // GetHostAnnouncementWithBlockHash will get the HostAnnouncements and block height through the hash of the block
 func (client *StorageClient) GetHostAnnouncementWithBlockHash(blockHash common.Hash) (hostAnnouncements []types.HostAnnouncement, number uint64, errGet error) {
 	precompiled := vm.PrecompiledStorageContracts
@@ -1046,8 +1405,9 @@ func (client *StorageClient) GetHostAnnouncementWithBlockHash(blockHash common.H
 	}
 	number = block.NumberU64()
 	txs := block.Transactions()
-	for _, tx := range txs {
-		if tx.To() == nil {
+	receipts := client.getReceipts(blockHash, len(txs))
+	for i, tx := range txs {
+		if tx.To() == nil || txFailed(receipts, i) {
 			continue
 		}
 		p, ok := precompiled[*tx.To()]
@@ -1070,6 +1430,55 @@ func (client *StorageClient) GetHostAnnouncementWithBlockHash(blockHash common.H
 	return
 }
 
+// GetHostRetirementWithBlockHash will get the HostRetirements and block height through the hash of the block
+func (client *StorageClient) GetHostRetirementWithBlockHash(blockHash common.Hash) (hostRetirements []types.HostRetirement, number uint64, errGet error) {
+	precompiled := vm.PrecompiledStorageContracts
+	block, err := client.ethBackend.GetBlockByHash(blockHash)
+	if err != nil {
+		errGet = err
+		return
+	}
+	number = block.NumberU64()
+	txs := block.Transactions()
+	receipts := client.getReceipts(blockHash, len(txs))
+	for i, tx := range txs {
+		if tx.To() == nil || txFailed(receipts, i) {
+			continue
+		}
+		p, ok := precompiled[*tx.To()]
+		if !ok || p != vm.HostRetireTransaction {
+			continue
+		}
+		var hr types.HostRetirement
+		if err := rlp.DecodeBytes(tx.Data(), &hr); err != nil {
+			client.log.Warn("Rlp decoding error as hostRetirements", "err", err)
+			continue
+		}
+		hostRetirements = append(hostRetirements, hr)
+	}
+	return
+}
+
+// getReceipts returns the receipts of the transactions in the block. Nil is returned if the
+// receipts are not available
+func (client *StorageClient) getReceipts(blockHash common.Hash, txCount int) types.Receipts {
+	chain := client.ethBackend.GetBlockChain()
+	if chain == nil {
+		return nil
+	}
+	receipts := chain.GetReceiptsByHash(blockHash)
+	if len(receipts) != txCount {
+		return nil
+	}
+	return receipts
+}
+
+// txFailed checks whether the execution of the ith transaction failed, in which case the
+// announcement or retirement carried by the transaction is not applied on chain
+func txFailed(receipts types.Receipts, i int) bool {
+	return receipts != nil && receipts[i].Status == types.ReceiptStatusFailed
+}
+
 // GetPaymentAddress get the account address used to sign the storage contract.
 // If not configured, the first address in the local wallet will be used as the paymentAddress by default.
 func (client *StorageClient) GetPaymentAddress() (common.Address, error) {
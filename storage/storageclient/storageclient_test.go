@@ -32,6 +32,7 @@ import (
 	"github.com/DxChainNetwork/godx/storage/storageclient/contractmanager"
 	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
 )
 
 var hashes = []string{"0x89c99d90b79719238d2645c7642f2c9295246e80775b38cfd162b696817fbd50", "0x89c99d90b79719238d2645c7642f2c9295246e80775b38cfd162b696817fbd51",
@@ -208,6 +209,10 @@ type BackendTest struct{}
 
 func (b *BackendTest) SelfEnodeURL() string { return "" }
 
+func (b *BackendTest) SignWithNodeSk(hash []byte) ([]byte, error) { return nil, nil }
+
+func (b *BackendTest) DecryptWithNodeSk(ct []byte) ([]byte, error) { return nil, nil }
+
 func (b *BackendTest) SetStatic(node *enode.Node) {}
 
 func (b *BackendTest) CheckAndUpdateConnection(peerNode *enode.Node) {}
@@ -338,7 +343,7 @@ func (b *BackendTest) GetBlockByHash(blockHash common.Hash) (*types.Block, error
 }
 
 func (b *BackendTest) GetBlockChain() *core.BlockChain {
-	return &core.BlockChain{}
+	return nil
 }
 
 func (b *BackendTest) SetupConnection(enodeURL string) (storage.Peer, error) {
@@ -490,6 +495,24 @@ func randomClientSettingsGenerator() (settings storage.ClientSetting) {
 		EnableIPViolation: true,
 		MaxUploadSpeed:    randInt64(),
 		MaxDownloadSpeed:  randInt64(),
+		HostPolicy:        randHostPolicyGenerator(),
+		Evaluator:         storagehostmanager.DefaultEvaluatorName,
+		EvaluationWeights: storagehostmanager.DefaultEvaluationWeights,
+	}
+
+	return
+}
+
+func randHostPolicyGenerator() (policy storage.HostPolicySetting) {
+	policy = storage.HostPolicySetting{
+		Regions:             []string{"US", "DE"},
+		MaxHostsPerOperator: randUint64(),
+		MaxHostsPerRegion:   randUint64(),
+		MinUptime:           rand.Float64(),
+		MaxStoragePrice:     common.RandomBigInt(),
+		MaxUploadPrice:      common.RandomBigInt(),
+		MaxDownloadPrice:    common.RandomBigInt(),
+		MaxContractPrice:    common.RandomBigInt(),
 	}
 
 	return
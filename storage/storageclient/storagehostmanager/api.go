@@ -57,12 +57,23 @@ func (api *PublicStorageHostManagerAPI) StorageHost(id string) storage.HostInfo
 	return info
 }
 
+// StorageHostsByMetadata will return the storage hosts announced to be located in the region
+// and supporting all the features
+func (api *PublicStorageHostManagerAPI) StorageHostsByMetadata(region string, features []string) []storage.HostInfo {
+	return api.shm.StorageHostsByMetadata(region, features)
+}
+
 // StorageHostRanks will return the storage host rankings based on their evaluations. The
 // higher the evaluation is, the higher order it will be placed
 func (api *PublicStorageHostManagerAPI) StorageHostRanks() (rankings []StorageHostRank) {
 	return api.shm.StorageHostRanks()
 }
 
+// Evaluators will return the names of the registered evaluators
+func (api *PublicStorageHostManagerAPI) Evaluators() []string {
+	return api.shm.Evaluators()
+}
+
 // FilterMode will return the current storage host manager filter mode setting
 func (api *PublicStorageHostManagerAPI) FilterMode() (fm string) {
 	return api.shm.RetrieveFilterMode()
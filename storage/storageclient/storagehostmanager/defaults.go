@@ -5,6 +5,7 @@
 package storagehostmanager
 
 import (
+	"math"
 	"time"
 
 	"github.com/DxChainNetwork/godx/common/unit"
@@ -36,6 +37,10 @@ const (
 	// minScore is the minimum score of a host evaluation. Host evaluation score starts at
 	// this value.
 	minScore = 1
+
+	// maxScore is the maximum score of a host evaluation. It is far below math.MaxInt64, so
+	// that the sum of the scores in the storage host tree does not overflow.
+	maxScore = math.MaxInt64 >> 20
 )
 
 // Presence factor related constants
@@ -152,6 +157,30 @@ const (
 	ceilRatio float64 = 0.2
 )
 
+// Weighted evaluation related constants
+const (
+	// maxEvaluationWeight is the max factor weight of the weighted evaluator
+	maxEvaluationWeight = 10
+
+	// performanceSmoothing is the smoothing factor of the exponential moving average of the
+	// measured host performance. The larger the factor, the more the recent transfers weigh
+	performanceSmoothing = 0.2
+
+	// performanceLatencyBase is the latency with which the latency score is 1
+	performanceLatencyBase = 500 * time.Millisecond
+
+	// performanceThroughputBase is the throughput in bytes per second with which the
+	// throughput score is 1
+	performanceThroughputBase float64 = 1 << 20
+
+	// performanceLatencySamples is the size of the rolling window of the latency samples
+	// used to calculate the latency percentiles
+	performanceLatencySamples = 64
+)
+
+// maxPolicyRegionLength is the max length of the region in the host policy region allow-list
+const maxPolicyRegionLength = 32
+
 var defaultMarketPrice = storage.MarketPrice{
 	ContractPrice: storage.DefaultContractPrice,
 	StoragePrice:  storage.DefaultStoragePrice,
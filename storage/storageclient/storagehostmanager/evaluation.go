@@ -11,14 +11,19 @@ import (
 	"github.com/DxChainNetwork/godx/storage"
 )
 
-// HostEvaluator defines an interface that include methods that used to calculate
+// Evaluator defines an interface that include methods that used to calculate
 // the storage host Evaluate and EvaluateDetail
 type (
-	HostEvaluator interface {
+	Evaluator interface {
 		EvaluateDetail(info storage.HostInfo) EvaluationDetail
 		Evaluate(info storage.HostInfo) int64
 	}
 
+	// EvaluatorCreator creates the evaluator with the storage host manager and the client's
+	// rent payment. It is called each time the rent payment or the evaluator is changed, with
+	// the storage host manager locked
+	EvaluatorCreator func(shm *StorageHostManager, rent storage.RentPayment) Evaluator
+
 	// hostMarket provides methods to evaluate the storage price, upload price, download
 	// price, and deposit price. Currently, the storageHostManager implements the hostMarket,
 	// and be used in evaluation.
@@ -39,6 +44,11 @@ type (
 		ContractPriceScore    float64 `json:"contract_priceScore"`
 		StorageRemainingScore float64 `json:"storage_remainingScore"`
 		UptimeScore           float64 `json:"uptimeScore"`
+
+		// scores only used by the weighted evaluator
+		UploadPriceScore   float64 `json:"upload_priceScore,omitempty"`
+		DownloadPriceScore float64 `json:"download_priceScore,omitempty"`
+		PerformanceScore   float64 `json:"performanceScore,omitempty"`
 	}
 
 	// defaultEvaluator is the default host evaluation rules.
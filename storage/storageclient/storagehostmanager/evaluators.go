@@ -0,0 +1,153 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// names of the built-in evaluators
+const (
+	DefaultEvaluatorName  = "default"
+	WeightedEvaluatorName = "weighted"
+)
+
+// DefaultEvaluationWeights is the default factor weights of the weighted evaluator, with
+// which the weighted evaluator evaluates the same as the default evaluator
+var DefaultEvaluationWeights = storage.EvaluationWeights{
+	Presence:         1,
+	Deposit:          1,
+	Interaction:      1,
+	ContractPrice:    1,
+	StorageRemaining: 1,
+	Uptime:           1,
+}
+
+// builtinEvaluators returns the creators of the built-in evaluators
+func builtinEvaluators() map[string]EvaluatorCreator {
+	return map[string]EvaluatorCreator{
+		DefaultEvaluatorName: func(shm *StorageHostManager, rent storage.RentPayment) Evaluator {
+			return newDefaultEvaluator(shm, rent)
+		},
+		WeightedEvaluatorName: func(shm *StorageHostManager, rent storage.RentPayment) Evaluator {
+			return newWeightedEvaluator(shm, rent, shm.evaluationWeights)
+		},
+	}
+}
+
+// RegisterEvaluator registers the customized evaluator with the name, which can be used
+// to evaluate the storage hosts by calling SetEvaluator. The built-in evaluators cannot
+// be overridden
+func (shm *StorageHostManager) RegisterEvaluator(name string, creator EvaluatorCreator) error {
+	if name == "" || creator == nil {
+		return errors.New("the evaluator name and creator must be provided")
+	}
+	if _, exist := builtinEvaluators()[name]; exist {
+		return fmt.Errorf("the built-in evaluator %s cannot be overridden", name)
+	}
+
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+	shm.evaluators[name] = creator
+	return nil
+}
+
+// SetEvaluator sets the evaluator used to evaluate the storage hosts, and update the host
+// evaluations in storage host tree as well as filtered tree
+func (shm *StorageHostManager) SetEvaluator(name string) error {
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+
+	if _, exist := shm.evaluators[name]; !exist {
+		return fmt.Errorf("evaluator %s is not registered, available evaluators are: %v", name, shm.evaluatorNames())
+	}
+	if name == shm.evaluatorName {
+		return nil
+	}
+	shm.evaluatorName = name
+	return shm.updateEvaluator()
+}
+
+// RetrieveEvaluator returns the name of the evaluator used to evaluate the storage hosts
+func (shm *StorageHostManager) RetrieveEvaluator() string {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+	return shm.evaluatorName
+}
+
+// Evaluators returns the names of all the registered evaluators
+func (shm *StorageHostManager) Evaluators() []string {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+	return shm.evaluatorNames()
+}
+
+// SetEvaluationWeights sets the factor weights of the weighted evaluator. If the weighted
+// evaluator is in use, the host evaluations will be updated. Weights with all zero values
+// are considered as the default weights
+func (shm *StorageHostManager) SetEvaluationWeights(weights storage.EvaluationWeights) error {
+	if err := validateEvaluationWeights(weights); err != nil {
+		return err
+	}
+	if reflect.DeepEqual(weights, storage.EvaluationWeights{}) {
+		weights = DefaultEvaluationWeights
+	}
+
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+	if weights == shm.evaluationWeights {
+		return nil
+	}
+	shm.evaluationWeights = weights
+	if shm.evaluatorName != WeightedEvaluatorName {
+		return nil
+	}
+	return shm.updateEvaluator()
+}
+
+// RetrieveEvaluationWeights returns the factor weights of the weighted evaluator
+func (shm *StorageHostManager) RetrieveEvaluationWeights() storage.EvaluationWeights {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+	return shm.evaluationWeights
+}
+
+// createEvaluator creates the evaluator with the evaluator name. If the evaluator is not
+// registered, the default evaluator is used. The caller must hold the lock
+func (shm *StorageHostManager) createEvaluator(rent storage.RentPayment) Evaluator {
+	creator, exist := shm.evaluators[shm.evaluatorName]
+	if !exist {
+		return newDefaultEvaluator(shm, rent)
+	}
+	return creator(shm, rent)
+}
+
+// evaluatorNames returns the sorted names of the registered evaluators. The caller must
+// hold the lock
+func (shm *StorageHostManager) evaluatorNames() (names []string) {
+	for name := range shm.evaluators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// validateEvaluationWeights checks if the evaluation weights are valid
+func validateEvaluationWeights(weights storage.EvaluationWeights) error {
+	values := []float64{weights.Presence, weights.Deposit, weights.Interaction, weights.ContractPrice,
+		weights.StorageRemaining, weights.Uptime, weights.UploadPrice, weights.DownloadPrice, weights.Performance}
+	for _, value := range values {
+		if value < 0 || value > maxEvaluationWeight || math.IsNaN(value) {
+			return fmt.Errorf("the evaluation weight %v must be ranged from 0 to %v", value, maxEvaluationWeight)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// HostPolicy defines the pluggable host selection policy. Check decides if the storage host
+// can be used for contract formation and renewal, given the storage hosts that are already
+// selected by the storage client
+type (
+	HostPolicy interface {
+		Check(host storage.HostInfo, selected []storage.HostInfo) error
+	}
+
+	// HostPolicies combines multiple host policies, the storage host must satisfy all of them
+	HostPolicies []HostPolicy
+
+	// regionPolicy only allows the storage hosts announced in the allowed regions
+	regionPolicy struct {
+		regions map[string]struct{}
+	}
+
+	// operatorPolicy limits the number of storage hosts run by the same operator, which is
+	// identified by the payment address of the storage host
+	operatorPolicy struct {
+		maxHosts uint64
+	}
+
+	// regionSpreadPolicy limits the number of storage hosts located in the same region
+	regionSpreadPolicy struct {
+		maxHosts uint64
+	}
+
+	// uptimePolicy filters out the storage hosts whose uptime rate is too low
+	uptimePolicy struct {
+		minUptime float64
+	}
+
+	// pricePolicy filters out the storage hosts whose prices exceed the ceilings
+	pricePolicy struct {
+		maxStoragePrice  common.BigInt
+		maxUploadPrice   common.BigInt
+		maxDownloadPrice common.BigInt
+		maxContractPrice common.BigInt
+	}
+)
+
+// NewHostPolicy creates the host policy based on the host policy setting, only the
+// constraints enabled in the setting are included
+func NewHostPolicy(setting storage.HostPolicySetting) HostPolicy {
+	var policies HostPolicies
+
+	if len(setting.Regions) != 0 {
+		regions := make(map[string]struct{})
+		for _, region := range setting.Regions {
+			regions[strings.ToUpper(region)] = struct{}{}
+		}
+		policies = append(policies, &regionPolicy{regions: regions})
+	}
+
+	if setting.MaxHostsPerOperator != 0 {
+		policies = append(policies, &operatorPolicy{maxHosts: setting.MaxHostsPerOperator})
+	}
+
+	if setting.MaxHostsPerRegion != 0 {
+		policies = append(policies, &regionSpreadPolicy{maxHosts: setting.MaxHostsPerRegion})
+	}
+
+	if setting.MinUptime != 0 {
+		policies = append(policies, &uptimePolicy{minUptime: setting.MinUptime})
+	}
+
+	if setting.MaxStoragePrice.Sign() > 0 || setting.MaxUploadPrice.Sign() > 0 ||
+		setting.MaxDownloadPrice.Sign() > 0 || setting.MaxContractPrice.Sign() > 0 {
+		policies = append(policies, &pricePolicy{
+			maxStoragePrice:  setting.MaxStoragePrice,
+			maxUploadPrice:   setting.MaxUploadPrice,
+			maxDownloadPrice: setting.MaxDownloadPrice,
+			maxContractPrice: setting.MaxContractPrice,
+		})
+	}
+
+	return policies
+}
+
+// validateHostPolicySetting checks if the host policy setting is valid
+func validateHostPolicySetting(setting storage.HostPolicySetting) error {
+	for _, region := range setting.Regions {
+		if len(region) == 0 || len(region) > maxPolicyRegionLength {
+			return fmt.Errorf("invalid region %q in the region allow-list", region)
+		}
+	}
+	if setting.MinUptime < 0 || setting.MinUptime > 1 {
+		return fmt.Errorf("the min uptime %v must be ranged from 0 to 1", setting.MinUptime)
+	}
+	if setting.MaxStoragePrice.IsNeg() || setting.MaxUploadPrice.IsNeg() ||
+		setting.MaxDownloadPrice.IsNeg() || setting.MaxContractPrice.IsNeg() {
+		return errors.New("the price ceilings cannot be negative")
+	}
+	return nil
+}
+
+// Check checks the storage host against all the host policies
+func (hp HostPolicies) Check(host storage.HostInfo, selected []storage.HostInfo) error {
+	for _, policy := range hp {
+		if err := policy.Check(host, selected); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check checks if the storage host announced in the allowed regions
+func (rp *regionPolicy) Check(host storage.HostInfo, selected []storage.HostInfo) error {
+	if _, allowed := rp.regions[strings.ToUpper(host.Region)]; !allowed {
+		return fmt.Errorf("the region %q of the storage host is not allowed", host.Region)
+	}
+	return nil
+}
+
+// Check checks if the operator of the storage host runs too many selected storage hosts
+func (op *operatorPolicy) Check(host storage.HostInfo, selected []storage.HostInfo) error {
+	var count uint64
+	for _, info := range selected {
+		if info.EnodeID != host.EnodeID && info.PaymentAddress == host.PaymentAddress {
+			count++
+		}
+	}
+	if count >= op.maxHosts {
+		return fmt.Errorf("the operator %v already runs %v selected storage hosts", host.PaymentAddress.Hex(), count)
+	}
+	return nil
+}
+
+// Check checks if too many selected storage hosts are located in the region of the storage host
+func (rsp *regionSpreadPolicy) Check(host storage.HostInfo, selected []storage.HostInfo) error {
+	var count uint64
+	for _, info := range selected {
+		if info.EnodeID != host.EnodeID && strings.EqualFold(info.Region, host.Region) {
+			count++
+		}
+	}
+	if count >= rsp.maxHosts {
+		return fmt.Errorf("already %v selected storage hosts located in the region %q", count, host.Region)
+	}
+	return nil
+}
+
+// Check checks if the uptime rate of the storage host is high enough
+func (up *uptimePolicy) Check(host storage.HostInfo, selected []storage.HostInfo) error {
+	if upRate := getHostUpRate(host); upRate < up.minUptime {
+		return fmt.Errorf("the uptime rate %v of the storage host is lower than %v", upRate, up.minUptime)
+	}
+	return nil
+}
+
+// Check checks if the prices of the storage host exceed the ceilings
+func (pp *pricePolicy) Check(host storage.HostInfo, selected []storage.HostInfo) error {
+	if exceedPriceCeiling(host.StoragePrice, pp.maxStoragePrice) {
+		return fmt.Errorf("the storage price %v exceeds the ceiling %v", host.StoragePrice, pp.maxStoragePrice)
+	}
+	if exceedPriceCeiling(host.UploadBandwidthPrice, pp.maxUploadPrice) {
+		return fmt.Errorf("the upload price %v exceeds the ceiling %v", host.UploadBandwidthPrice, pp.maxUploadPrice)
+	}
+	if exceedPriceCeiling(host.DownloadBandwidthPrice, pp.maxDownloadPrice) {
+		return fmt.Errorf("the download price %v exceeds the ceiling %v", host.DownloadBandwidthPrice, pp.maxDownloadPrice)
+	}
+	if exceedPriceCeiling(host.ContractPrice, pp.maxContractPrice) {
+		return fmt.Errorf("the contract price %v exceeds the ceiling %v", host.ContractPrice, pp.maxContractPrice)
+	}
+	return nil
+}
+
+// exceedPriceCeiling checks if the price exceeds the ceiling. Zero ceiling means no limit
+func exceedPriceCeiling(price, ceiling common.BigInt) bool {
+	return ceiling.Sign() > 0 && price.Cmp(ceiling) > 0
+}
+
+// SetHostPolicy sets the host policy setting, the host selection policy will be rebuilt
+// based on the setting
+func (shm *StorageHostManager) SetHostPolicy(setting storage.HostPolicySetting) error {
+	if err := validateHostPolicySetting(setting); err != nil {
+		return err
+	}
+
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+	shm.hostPolicySetting = setting
+	shm.hostPolicy = NewHostPolicy(setting)
+	return nil
+}
+
+// RetrieveHostPolicy returns the current host policy setting
+func (shm *StorageHostManager) RetrieveHostPolicy() storage.HostPolicySetting {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+	return shm.hostPolicySetting
+}
+
+// RegisterHostPolicy plugs the customized host policy into the storage host manager, which
+// is checked along with the policy built from the host policy setting. The customized host
+// policy is not saved persistently
+func (shm *StorageHostManager) RegisterHostPolicy(policy HostPolicy) {
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+	shm.customHostPolicies = append(shm.customHostPolicies, policy)
+}
+
+// CheckHostPolicy checks if the storage host satisfies the host selection policies, given
+// the storage hosts that are already selected
+func (shm *StorageHostManager) CheckHostPolicy(host storage.HostInfo, selected []storage.HostInfo) error {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+	return shm.checkHostPolicy(host, selected)
+}
+
+// checkHostPolicy checks the storage host against the host selection policies. The caller
+// must hold the lock
+func (shm *StorageHostManager) checkHostPolicy(host storage.HostInfo, selected []storage.HostInfo) error {
+	if shm.hostPolicy != nil {
+		if err := shm.hostPolicy.Check(host, selected); err != nil {
+			return err
+		}
+	}
+	return shm.customHostPolicies.Check(host, selected)
+}
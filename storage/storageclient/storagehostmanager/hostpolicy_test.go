@@ -0,0 +1,116 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// hostPolicyPrototype is the prototype of the storage host used in the host policy tests
+var hostPolicyPrototype = storage.HostInfo{
+	HostExtConfig: storage.HostExtConfig{
+		AcceptingContracts:     true,
+		PaymentAddress:         common.HexToAddress("0x1"),
+		StoragePrice:           common.NewBigInt(100),
+		UploadBandwidthPrice:   common.NewBigInt(100),
+		DownloadBandwidthPrice: common.NewBigInt(100),
+		ContractPrice:          common.NewBigInt(100),
+	},
+	Region:              "DE",
+	AccumulatedUptime:   90,
+	AccumulatedDowntime: 10,
+	ScanRecords: storage.HostPoolScans{storage.HostPoolScan{
+		Timestamp: time.Now(),
+		Success:   true,
+	}},
+}
+
+// TestNewHostPolicy test the constraints of the host policy built from the setting
+func TestNewHostPolicy(t *testing.T) {
+	hosts := hostInfosByPrototype(hostPolicyPrototype, 3)
+	hosts[1].Region = "us"
+	hosts[2].PaymentAddress = common.HexToAddress("0x2")
+	hosts[2].Region = "FR"
+
+	tests := []struct {
+		setting  storage.HostPolicySetting
+		host     storage.HostInfo
+		selected []storage.HostInfo
+		valid    bool
+	}{
+		{storage.HostPolicySetting{}, hosts[0], hosts[1:], true},
+		{storage.HostPolicySetting{Regions: []string{"de", "US"}}, hosts[0], nil, true},
+		{storage.HostPolicySetting{Regions: []string{"de", "US"}}, hosts[1], nil, true},
+		{storage.HostPolicySetting{Regions: []string{"de", "US"}}, hosts[2], nil, false},
+		{storage.HostPolicySetting{MaxHostsPerOperator: 1}, hosts[0], hosts[2:], true},
+		{storage.HostPolicySetting{MaxHostsPerOperator: 1}, hosts[0], hosts[1:], false},
+		{storage.HostPolicySetting{MaxHostsPerOperator: 2}, hosts[0], hosts[1:], true},
+		// the storage host itself is not counted
+		{storage.HostPolicySetting{MaxHostsPerOperator: 1}, hosts[0], hosts[:1], true},
+		{storage.HostPolicySetting{MaxHostsPerRegion: 1}, hosts[0], hosts[1:], true},
+		{storage.HostPolicySetting{MaxHostsPerRegion: 1}, hosts[1], []storage.HostInfo{{Region: "US"}}, false},
+		{storage.HostPolicySetting{MinUptime: 0.8}, hosts[0], nil, true},
+		{storage.HostPolicySetting{MinUptime: 0.95}, hosts[0], nil, false},
+		{storage.HostPolicySetting{MaxStoragePrice: common.NewBigInt(100)}, hosts[0], nil, true},
+		{storage.HostPolicySetting{MaxStoragePrice: common.NewBigInt(99)}, hosts[0], nil, false},
+		{storage.HostPolicySetting{MaxUploadPrice: common.NewBigInt(99)}, hosts[0], nil, false},
+		{storage.HostPolicySetting{MaxDownloadPrice: common.NewBigInt(99)}, hosts[0], nil, false},
+		{storage.HostPolicySetting{MaxContractPrice: common.NewBigInt(99)}, hosts[0], nil, false},
+	}
+
+	for i, test := range tests {
+		err := NewHostPolicy(test.setting).Check(test.host, test.selected)
+		if test.valid && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("test %d: the host policy shall be violated", i)
+		}
+	}
+}
+
+// TestStorageHostManager_HostPolicy test the host policy is applied to the random host selection
+func TestStorageHostManager_HostPolicy(t *testing.T) {
+	shm := newHostManagerTestData()
+	shm.finishInitialScan()
+
+	allowed := hostInfosByPrototype(hostPolicyPrototype, 5)
+	prototype := hostPolicyPrototype
+	prototype.Region = "CN"
+	denied := hostInfosByPrototype(prototype, 5)
+	if err := insertHostInfos(shm, append(allowed, denied...)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shm.SetHostPolicy(storage.HostPolicySetting{MinUptime: 1.1}); err == nil {
+		t.Error("invalid host policy setting shall not be accepted")
+	}
+	if err := shm.SetHostPolicy(storage.HostPolicySetting{Regions: []string{"DE"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := shm.RetrieveRandomHosts(10, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != len(allowed) {
+		t.Errorf("expect %v storage hosts selected, got %v", len(allowed), len(infos))
+	}
+	for _, info := range infos {
+		if info.Region != "DE" {
+			t.Errorf("storage host in region %v shall not be selected", info.Region)
+		}
+	}
+
+	// the customized host policy is checked along with the setting
+	shm.RegisterHostPolicy(&operatorPolicy{maxHosts: 1})
+	if err := shm.CheckHostPolicy(allowed[0], allowed[1:]); err == nil {
+		t.Error("the customized host policy shall be violated")
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// types of the failed data transfers recorded in the host performance
+const (
+	// TransferErrorHostNegotiate is the error that the storage host responded with the
+	// HostNegotiateErrorMsg
+	TransferErrorHostNegotiate = "host negotiate"
+
+	// TransferErrorHostCommit is the error that the storage host failed to commit the revision
+	TransferErrorHostCommit = "host commit"
+
+	// TransferErrorHostBusy is the error that the storage host is busy handling the previous request
+	TransferErrorHostBusy = "host busy"
+
+	// TransferErrorInvalidResponse is the error that the storage host responded with invalid
+	// data, signature or merkle proof
+	TransferErrorInvalidResponse = "invalid response"
+
+	// TransferErrorConnection is the error that failed to communicate with the storage host,
+	// including the response timeout
+	TransferErrorConnection = "connection"
+)
+
+// RecordTransfer records the performance measured from a data transfer with the storage host.
+// The latency is the time used to receive the first response from the storage host, and the
+// duration is the time used to transfer the data of the size
+func (shm *StorageHostManager) RecordTransfer(id enode.ID, interactionType InteractionType, latency, duration time.Duration, size uint64) {
+	if err := shm.updatePerformance(id, interactionType, latency, duration, size); err != nil {
+		shm.log.Warn("Record transfer performance", "err", err)
+	}
+}
+
+// RecordTransferError records the failed data transfer with the storage host by the error type
+func (shm *StorageHostManager) RecordTransferError(id enode.ID, interactionType InteractionType, errType string) {
+	if err := shm.updateTransferErrors(id, interactionType, errType); err != nil {
+		shm.log.Warn("Record transfer error", "err", err)
+	}
+}
+
+// updatePerformance update the performance of the host info with the given id
+func (shm *StorageHostManager) updatePerformance(id enode.ID, interactionType InteractionType, latency, duration time.Duration, size uint64) error {
+	return shm.modifyPerformance(id, interactionType, func(perf storage.HostPerformance) storage.HostPerformance {
+		return calcPerformanceUpdate(perf, interactionType, latency, duration, size)
+	})
+}
+
+// updateTransferErrors increment the number of the transfer errors of the type for the host
+// info with the given id
+func (shm *StorageHostManager) updateTransferErrors(id enode.ID, interactionType InteractionType, errType string) error {
+	return shm.modifyPerformance(id, interactionType, func(perf storage.HostPerformance) storage.HostPerformance {
+		return calcTransferErrorsUpdate(perf, errType)
+	})
+}
+
+// modifyPerformance applies the update function to the performance of the host info with
+// the given id, and update the host info in the tree
+func (shm *StorageHostManager) modifyPerformance(id enode.ID, interactionType InteractionType, update func(storage.HostPerformance) storage.HostPerformance) error {
+	if interactionType != InteractionUpload && interactionType != InteractionDownload {
+		return fmt.Errorf("performance of the %v interaction is not measured", interactionType)
+	}
+
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+
+	// get the storage host
+	info, exist := shm.storageHostTree.RetrieveHostInfo(id)
+	if !exist {
+		return fmt.Errorf("failed to retrive host info [%v]", id)
+	}
+	info.Performance = update(info.Performance)
+	// Evaluate the score and update the host info
+	if err := shm.modify(info); err != nil {
+		return fmt.Errorf("failed to update host info: %v", err)
+	}
+	return nil
+}
+
+// calcPerformanceUpdate applies the measurement of the data transfer to the host performance,
+// which is the exponential moving average of the measurements
+func calcPerformanceUpdate(perf storage.HostPerformance, interactionType InteractionType, latency, duration time.Duration, size uint64) storage.HostPerformance {
+	if perf.UploadTransfers == 0 && perf.DownloadTransfers == 0 {
+		perf.Latency = latency
+	} else {
+		perf.Latency = time.Duration(movingAverage(float64(perf.Latency), float64(latency)))
+	}
+	perf.LatencySamples = appendLatencySample(perf.LatencySamples, latency)
+
+	var throughput float64
+	if duration > 0 {
+		throughput = float64(size) / duration.Seconds()
+	}
+	switch interactionType {
+	case InteractionUpload:
+		if perf.UploadTransfers == 0 {
+			perf.UploadThroughput = throughput
+		} else {
+			perf.UploadThroughput = movingAverage(perf.UploadThroughput, throughput)
+		}
+		perf.UploadTransfers++
+	case InteractionDownload:
+		if perf.DownloadTransfers == 0 {
+			perf.DownloadThroughput = throughput
+		} else {
+			perf.DownloadThroughput = movingAverage(perf.DownloadThroughput, throughput)
+		}
+		perf.DownloadTransfers++
+	}
+	return perf
+}
+
+// calcTransferErrorsUpdate increment the number of the transfer errors of the type. The
+// errors are copied since the host info retrieved from the tree shares the map
+func calcTransferErrorsUpdate(perf storage.HostPerformance, errType string) storage.HostPerformance {
+	transferErrors := make(map[string]uint64, len(perf.TransferErrors)+1)
+	for t, count := range perf.TransferErrors {
+		transferErrors[t] = count
+	}
+	transferErrors[errType]++
+	perf.TransferErrors = transferErrors
+	return perf
+}
+
+// appendLatencySample append the latency to the rolling window of the latency samples, and
+// returns the new samples. The samples are copied since the host info retrieved from the tree
+// shares the slice
+func appendLatencySample(samples []time.Duration, latency time.Duration) []time.Duration {
+	if len(samples) >= performanceLatencySamples {
+		samples = samples[len(samples)-performanceLatencySamples+1:]
+	}
+	newSamples := make([]time.Duration, 0, len(samples)+1)
+	newSamples = append(newSamples, samples...)
+	return append(newSamples, latency)
+}
+
+// LatencyPercentile returns the latency percentile of the latest transfers measured from the
+// storage host. The percentile is ranged from 0 to 100, and 0 is returned if no transfer has
+// been measured
+func LatencyPercentile(perf storage.HostPerformance, percentile float64) time.Duration {
+	if len(perf.LatencySamples) == 0 {
+		return 0
+	}
+	samples := make([]time.Duration, len(perf.LatencySamples))
+	copy(samples, perf.LatencySamples)
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	// nearest-rank method
+	rank := int(math.Ceil(percentile * float64(len(samples)) / 100))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(samples) {
+		rank = len(samples)
+	}
+	return samples[rank-1]
+}
+
+// EstimateDownloadTime estimates the time used to download the data of the size from the
+// storage host based on the median latency and the download throughput. The storage host
+// without measurement is estimated with the base latency and throughput
+func EstimateDownloadTime(perf storage.HostPerformance, size uint64) time.Duration {
+	latency, throughput := performanceLatencyBase, performanceThroughputBase
+	if median := LatencyPercentile(perf, 50); median != 0 {
+		latency = median
+	}
+	if perf.DownloadTransfers != 0 && perf.DownloadThroughput > 0 {
+		throughput = perf.DownloadThroughput
+	}
+	return latency + time.Duration(float64(size)/throughput*float64(time.Second))
+}
+
+// movingAverage returns the exponential moving average with the new sample
+func movingAverage(average, sample float64) float64 {
+	return average*(1-performanceSmoothing) + sample*performanceSmoothing
+}
+
+// PerformanceScore returns the score of the measured latency and throughput of the storage
+// host, which is 1 for the storage host with base performance or without measurement
+func PerformanceScore(info storage.HostInfo) float64 {
+	return performanceScoreCalc(info)
+}
+
+// performanceScoreCalc calculates the score based on the measured latency and throughput of
+// the storage host. The storage host without measurement gets the neutral score 1
+func performanceScoreCalc(info storage.HostInfo) float64 {
+	return latencyScoreCalc(info.Performance) * throughputScoreCalc(info.Performance)
+}
+
+// latencyScoreCalc calculates the latency score, which is 1 for the latency equal to
+// performanceLatencyBase, approaches 2 for low latency and 0 for high latency
+func latencyScoreCalc(perf storage.HostPerformance) float64 {
+	if perf.UploadTransfers == 0 && perf.DownloadTransfers == 0 {
+		return 1
+	}
+	return 2 / (1 + float64(perf.Latency)/float64(performanceLatencyBase))
+}
+
+// throughputScoreCalc calculates the throughput score based on the average of the measured
+// upload and download throughput. The score is 1 for the throughput equal to
+// performanceThroughputBase, approaches 2 for high throughput and 0 for low throughput
+func throughputScoreCalc(perf storage.HostPerformance) float64 {
+	var total float64
+	var measured int
+	if perf.UploadTransfers != 0 {
+		total += perf.UploadThroughput
+		measured++
+	}
+	if perf.DownloadTransfers != 0 {
+		total += perf.DownloadThroughput
+		measured++
+	}
+	if measured == 0 {
+		return 1
+	}
+	ratio := total / float64(measured) / performanceThroughputBase
+	return 2 * ratio / (ratio + 1)
+}
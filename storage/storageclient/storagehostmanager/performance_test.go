@@ -0,0 +1,184 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestCalcPerformanceUpdate test the functionality of calcPerformanceUpdate
+func TestCalcPerformanceUpdate(t *testing.T) {
+	var perf storage.HostPerformance
+	// the first measurement is taken as it is
+	perf = calcPerformanceUpdate(perf, InteractionDownload, time.Second, time.Second, 1000)
+	if perf.Latency != time.Second || perf.DownloadThroughput != 1000 || perf.DownloadTransfers != 1 {
+		t.Fatalf("unexpected performance after the first measurement: %+v", perf)
+	}
+	if perf.UploadTransfers != 0 || perf.UploadThroughput != 0 {
+		t.Fatalf("upload performance shall not be measured: %+v", perf)
+	}
+	// the following measurements are averaged
+	perf = calcPerformanceUpdate(perf, InteractionDownload, 2*time.Second, time.Second, 2000)
+	expectLatency := time.Duration(movingAverage(float64(time.Second), float64(2*time.Second)))
+	if perf.Latency != expectLatency {
+		t.Errorf("unexpected latency. Got %v, Expect %v", perf.Latency, expectLatency)
+	}
+	if expect := movingAverage(1000, 2000); perf.DownloadThroughput != expect {
+		t.Errorf("unexpected download throughput. Got %v, Expect %v", perf.DownloadThroughput, expect)
+	}
+	perf = calcPerformanceUpdate(perf, InteractionUpload, time.Second, 0, 1000)
+	if perf.UploadTransfers != 1 || perf.UploadThroughput != 0 {
+		t.Errorf("unexpected upload performance: %+v", perf)
+	}
+}
+
+// TestPerformanceScoreCalc test the functionality of performanceScoreCalc
+func TestPerformanceScoreCalc(t *testing.T) {
+	tests := []struct {
+		perf   storage.HostPerformance
+		expect float64
+	}{
+		{storage.HostPerformance{}, 1},
+		{storage.HostPerformance{
+			Latency:            performanceLatencyBase,
+			DownloadThroughput: performanceThroughputBase,
+			DownloadTransfers:  1,
+		}, 1},
+		{storage.HostPerformance{
+			Latency:            3 * performanceLatencyBase,
+			DownloadThroughput: performanceThroughputBase,
+			DownloadTransfers:  1,
+		}, 0.5},
+		{storage.HostPerformance{
+			Latency:            performanceLatencyBase,
+			UploadThroughput:   0,
+			DownloadThroughput: 6 * performanceThroughputBase,
+			UploadTransfers:    1,
+			DownloadTransfers:  1,
+		}, 1.5},
+	}
+	for i, test := range tests {
+		if got := performanceScoreCalc(storage.HostInfo{Performance: test.perf}); got != test.expect {
+			t.Errorf("test %d: unexpected score. Got %v, Expect %v", i, got, test.expect)
+		}
+	}
+}
+
+// TestStorageHostManager_RecordTransfer test the measured performance is updated in the
+// storage host tree
+func TestStorageHostManager_RecordTransfer(t *testing.T) {
+	shm := newHostManagerTestData()
+	info := hostInfoGenerator()
+	if err := shm.insert(info); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shm.updatePerformance(info.EnodeID, InteractionCreateContract, time.Second, time.Second, 1); err == nil {
+		t.Error("the performance of contract creation shall not be measured")
+	}
+	if err := shm.updatePerformance(enodeIDGenerator(), InteractionUpload, time.Second, time.Second, 1); err == nil {
+		t.Error("the performance of unknown storage host shall not be measured")
+	}
+	shm.RecordTransfer(info.EnodeID, InteractionUpload, time.Second, time.Second, 1<<20)
+	updated, exist := shm.storageHostTree.RetrieveHostInfo(info.EnodeID)
+	if !exist {
+		t.Fatal("storage host does not exist after recording transfer")
+	}
+	if updated.Performance.UploadTransfers != 1 || updated.Performance.UploadThroughput != 1<<20 {
+		t.Errorf("unexpected performance: %+v", updated.Performance)
+	}
+}
+
+// TestAppendLatencySample test the rolling window of the latency samples
+func TestAppendLatencySample(t *testing.T) {
+	var samples []time.Duration
+	for i := 1; i <= performanceLatencySamples+10; i++ {
+		prev := samples
+		samples = appendLatencySample(samples, time.Duration(i))
+		if len(prev) != 0 && &prev[0] == &samples[0] {
+			t.Fatal("the latency samples shall be copied")
+		}
+	}
+	if len(samples) != performanceLatencySamples {
+		t.Fatalf("unexpected number of samples. Got %v, Expect %v", len(samples), performanceLatencySamples)
+	}
+	if samples[0] != 11 || samples[len(samples)-1] != performanceLatencySamples+10 {
+		t.Errorf("the latest samples shall be kept. Got %v - %v", samples[0], samples[len(samples)-1])
+	}
+}
+
+// TestLatencyPercentile test the functionality of LatencyPercentile
+func TestLatencyPercentile(t *testing.T) {
+	var perf storage.HostPerformance
+	if p := LatencyPercentile(perf, 50); p != 0 {
+		t.Errorf("unexpected percentile without samples: %v", p)
+	}
+	for i := 100; i > 0; i-- {
+		perf.LatencySamples = append(perf.LatencySamples, time.Duration(i)*time.Millisecond)
+	}
+	tests := []struct {
+		percentile float64
+		expect     time.Duration
+	}{
+		{0, time.Millisecond},
+		{50, 50 * time.Millisecond},
+		{90, 90 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+	}
+	for i, test := range tests {
+		if got := LatencyPercentile(perf, test.percentile); got != test.expect {
+			t.Errorf("test %d: unexpected percentile. Got %v, Expect %v", i, got, test.expect)
+		}
+	}
+	if perf.LatencySamples[0] != 100*time.Millisecond {
+		t.Error("the latency samples shall not be modified")
+	}
+}
+
+// TestEstimateDownloadTime test the measured faster storage host is estimated with shorter
+// download time
+func TestEstimateDownloadTime(t *testing.T) {
+	size := uint64(1 << 22)
+	unmeasured := EstimateDownloadTime(storage.HostPerformance{}, size)
+	if expect := performanceLatencyBase + 4*time.Second; unmeasured != expect {
+		t.Errorf("unexpected estimation of unmeasured host. Got %v, Expect %v", unmeasured, expect)
+	}
+	fast := storage.HostPerformance{
+		LatencySamples:     []time.Duration{100 * time.Millisecond},
+		DownloadThroughput: 4 * performanceThroughputBase,
+		DownloadTransfers:  1,
+	}
+	if estimate := EstimateDownloadTime(fast, size); estimate != 1100*time.Millisecond {
+		t.Errorf("unexpected estimation of fast host. Got %v, Expect %v", estimate, 1100*time.Millisecond)
+	}
+}
+
+// TestStorageHostManager_RecordTransferError test the transfer errors are counted by type
+func TestStorageHostManager_RecordTransferError(t *testing.T) {
+	shm := newHostManagerTestData()
+	info := hostInfoGenerator()
+	if err := shm.insert(info); err != nil {
+		t.Fatal(err)
+	}
+
+	shm.RecordTransferError(info.EnodeID, InteractionDownload, TransferErrorHostNegotiate)
+	shm.RecordTransferError(info.EnodeID, InteractionUpload, TransferErrorHostNegotiate)
+	shm.RecordTransferError(info.EnodeID, InteractionDownload, TransferErrorConnection)
+	shm.RecordTransferError(info.EnodeID, InteractionGetConfig, TransferErrorConnection)
+
+	updated, _ := shm.storageHostTree.RetrieveHostInfo(info.EnodeID)
+	expect := map[string]uint64{
+		TransferErrorHostNegotiate: 2,
+		TransferErrorConnection:    1,
+	}
+	if !reflect.DeepEqual(updated.Performance.TransferErrors, expect) {
+		t.Errorf("unexpected transfer errors. Got %v, Expect %v", updated.Performance.TransferErrors, expect)
+	}
+}
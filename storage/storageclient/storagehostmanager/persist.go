@@ -7,6 +7,7 @@ package storagehostmanager
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"time"
 
 	"github.com/DxChainNetwork/godx/common"
@@ -30,6 +31,11 @@ type persistence struct {
 	IPViolationCheck bool
 	FilteredHosts    map[enode.ID]struct{}
 	FilterMode       FilterMode
+	RetiredHosts     map[enode.ID]uint64
+	HostPolicy       storage.HostPolicySetting
+
+	Evaluator         string
+	EvaluationWeights storage.EvaluationWeights
 }
 
 // saveSettings will save the storage host configurations into the JSON file
@@ -47,6 +53,11 @@ func (shm *StorageHostManager) persistUpdate() (persist persistence) {
 		IPViolationCheck: shm.ipViolationCheck,
 		FilteredHosts:    shm.filteredHosts,
 		FilterMode:       shm.filterMode,
+		RetiredHosts:     shm.retiredHosts,
+		HostPolicy:       shm.hostPolicySetting,
+
+		Evaluator:         shm.evaluatorName,
+		EvaluationWeights: shm.evaluationWeights,
 	}
 }
 
@@ -85,6 +96,7 @@ func (shm *StorageHostManager) loadSettings() error {
 
 	var persist persistence
 	persist.FilteredHosts = make(map[enode.ID]struct{})
+	persist.RetiredHosts = make(map[enode.ID]uint64)
 
 	err = common.LoadDxJSON(settingsMetadata, filepath.Join(shm.persistDir, PersistFilename), &persist)
 	if err != nil {
@@ -97,6 +109,20 @@ func (shm *StorageHostManager) loadSettings() error {
 	shm.ipViolationCheck = persist.IPViolationCheck
 	shm.filteredHosts = persist.FilteredHosts
 	shm.filterMode = persist.FilterMode
+	shm.retiredHosts = persist.RetiredHosts
+	shm.hostPolicySetting = persist.HostPolicy
+	shm.hostPolicy = NewHostPolicy(persist.HostPolicy)
+
+	// restore the evaluator, the customized evaluators might not be registered yet
+	if _, exist := shm.evaluators[persist.Evaluator]; exist {
+		shm.evaluatorName = persist.Evaluator
+	} else if persist.Evaluator != "" {
+		shm.log.Warn("the evaluator is not registered, use the default evaluator instead", "evaluator", persist.Evaluator)
+	}
+	if validateEvaluationWeights(persist.EvaluationWeights) == nil && !reflect.DeepEqual(persist.EvaluationWeights, storage.EvaluationWeights{}) {
+		shm.evaluationWeights = persist.EvaluationWeights
+	}
+	shm.hostEvaluator = shm.createEvaluator(shm.rent)
 
 	// update the storage host tree
 	for _, info := range persist.StorageHostsInfo {
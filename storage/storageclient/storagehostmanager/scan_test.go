@@ -172,6 +172,11 @@ func newHostManagerTestData() *StorageHostManager {
 		rent:          storage.DefaultRentPayment,
 		scanLookup:    make(map[enode.ID]struct{}),
 		filteredHosts: make(map[enode.ID]struct{}),
+		retiredHosts:  make(map[enode.ID]uint64),
+
+		evaluators:        builtinEvaluators(),
+		evaluatorName:     DefaultEvaluatorName,
+		evaluationWeights: DefaultEvaluationWeights,
 	}
 
 	shm.hostEvaluator = newDefaultEvaluator(shm, shm.rent)
@@ -301,6 +306,10 @@ func (st *storageClientBackendTestData) GetHostAnnouncementWithBlockHash(blockHa
 	return
 }
 
+func (st *storageClientBackendTestData) GetHostRetirementWithBlockHash(blockHash common.Hash) (hostRetirements []types.HostRetirement, number uint64, errGet error) {
+	return
+}
+
 func (st *storageClientBackendTestData) TryToRenewOrRevise(hostID enode.ID) bool {
 	return false
 }
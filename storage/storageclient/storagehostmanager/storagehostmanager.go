@@ -10,6 +10,7 @@ import (
 	"os"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -29,12 +30,22 @@ type StorageHostManager struct {
 	eth storage.EthBackend
 
 	rent            storage.RentPayment
-	hostEvaluator   HostEvaluator
+	hostEvaluator   Evaluator
 	storageHostTree storagehosttree.StorageHostTree
 
+	// evaluator related, evaluators contains all the registered evaluator creators
+	evaluators        map[string]EvaluatorCreator
+	evaluatorName     string
+	evaluationWeights storage.EvaluationWeights
+
 	// ip violation check
 	ipViolationCheck bool
 
+	// host selection policy related
+	hostPolicySetting  storage.HostPolicySetting
+	hostPolicy         HostPolicy
+	customHostPolicies HostPolicies
+
 	// maintenance related
 	// initialScanFinished is atomic value to denote the status whether the initial scan has been
 	// finished. Initialized to value 0, and changed value to 1 when initial scan is finished.
@@ -57,6 +68,9 @@ type StorageHostManager struct {
 	filteredHosts map[enode.ID]struct{}
 	filteredTree  storagehosttree.StorageHostTree
 
+	// retiredHosts maps the retired storage hosts to the sequence of their retirements
+	retiredHosts map[enode.ID]uint64
+
 	// blockHeight and its lock
 	blockHeight     uint64
 	blockHeightLock sync.RWMutex
@@ -74,6 +88,11 @@ func New(persistDir string) *StorageHostManager {
 		scanLookup:    make(map[enode.ID]struct{}),
 		filterMode:    DisableFilter,
 		filteredHosts: make(map[enode.ID]struct{}),
+		retiredHosts:  make(map[enode.ID]uint64),
+
+		evaluators:        builtinEvaluators(),
+		evaluatorName:     DefaultEvaluatorName,
+		evaluationWeights: DefaultEvaluationWeights,
 	}
 
 	shm.hostEvaluator = newDefaultEvaluator(shm, shm.rent)
@@ -145,6 +164,36 @@ func (shm *StorageHostManager) ActiveStorageHosts() (activeStorageHosts []storag
 	return
 }
 
+// StorageHostsByMetadata will return the storage hosts announced to be located in the region
+// and supporting all the features. The empty region matches the storage hosts in any region
+func (shm *StorageHostManager) StorageHostsByMetadata(region string, features []string) (infos []storage.HostInfo) {
+	allHosts := shm.storageHostTree.All()
+	for _, host := range allHosts {
+		if region != "" && !strings.EqualFold(host.Region, region) {
+			continue
+		}
+		if !hostSupportsFeatures(host, features) {
+			continue
+		}
+		infos = append(infos, host)
+	}
+	return
+}
+
+// hostSupportsFeatures checks whether the storage host announced to support all the features
+func hostSupportsFeatures(host storage.HostInfo, features []string) bool {
+	supported := make(map[string]struct{}, len(host.Features))
+	for _, feature := range host.Features {
+		supported[feature] = struct{}{}
+	}
+	for _, feature := range features {
+		if _, exist := supported[feature]; !exist {
+			return false
+		}
+	}
+	return true
+}
+
 // SetRentPayment will modify the rent payment and update the host evaluations in storage host
 // tree as well as filtered tree
 func (shm *StorageHostManager) SetRentPayment(rent storage.RentPayment) (err error) {
@@ -156,9 +205,15 @@ func (shm *StorageHostManager) SetRentPayment(rent storage.RentPayment) (err err
 	}
 	// update the rent
 	shm.rent = rent
-	// update the host evaluator
-	hostEvaluator := newDefaultEvaluator(shm, rent)
-	shm.hostEvaluator = hostEvaluator
+	// update the host evaluator and the evaluations
+	return shm.updateEvaluator()
+}
+
+// updateEvaluator creates the host evaluator with the current evaluator setting and the rent
+// payment, and update the host evaluations in storage host tree as well as filtered tree. The
+// caller must hold the lock
+func (shm *StorageHostManager) updateEvaluator() (err error) {
+	shm.hostEvaluator = shm.createEvaluator(shm.rent)
 	// Update the storage host tree and filtered tree
 	if err = shm.evaluateHostTree(shm.storageHostTree); err != nil {
 		return fmt.Errorf("cannot update the host tree: %v", err)
@@ -283,16 +338,22 @@ func (shm *StorageHostManager) FilterIPViolationHosts(hostIDs []enode.ID) (badHo
 //  1. blacklist represents the storage host that are prohibited to be selected
 //  2. addrBlacklist represents for any storage host whose network address is caontine
 func (shm *StorageHostManager) RetrieveRandomHosts(num int, blacklist, addrBlacklist []enode.ID) (infos []storage.HostInfo, err error) {
-	shm.lock.RLock()
-	ipCheck := shm.ipViolationCheck
-	shm.lock.RUnlock()
-
 	// if the initialize scan is not complete
 	if !shm.isInitialScanFinished() {
 		err = errors.New("storage host pool initial scan is not finished")
 		return
 	}
 
+	shm.lock.RLock()
+	ipCheck := shm.ipViolationCheck
+	// the storage hosts violating the host selection policy are prohibited to be selected
+	for _, host := range shm.filteredTree.All() {
+		if shm.checkHostPolicy(host, nil) != nil {
+			blacklist = append(blacklist, host.EnodeID)
+		}
+	}
+	shm.lock.RUnlock()
+
 	// select random
 	if ipCheck {
 		infos = shm.filteredTree.SelectRandom(num, blacklist, addrBlacklist)
@@ -5,10 +5,13 @@
 package storagehostmanager
 
 import (
+	"errors"
 	"time"
 
+	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core"
 	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
@@ -67,7 +70,17 @@ func (shm *StorageHostManager) analyzeChainEventChange(change core.ChainChangeEv
 			continue
 		}
 		shm.analyzeHostAnnouncements(hostAnnouncements)
+
+		hostRetirements, _, err := shm.b.GetHostRetirementWithBlockHash(hash)
+		if err != nil {
+			shm.log.Error("error extracting host retirement", "block hash", hash, "err", err.Error())
+			continue
+		}
+		shm.analyzeHostRetirements(hostRetirements)
 	}
+
+	// remove the storage hosts whose announcements expired
+	shm.removeExpiredHosts()
 }
 
 // analyzeHostAnnouncements will parse the storage host announcement and insert it into the storage host
@@ -91,10 +104,68 @@ func (shm *StorageHostManager) analyzeHostAnnouncements(hostAnnouncements []type
 			continue
 		}
 
+		// check if the announcement already expired or withdrawn by the host
+		if info.AnnounceExpiration != 0 && info.AnnounceExpiration <= shm.getBlockHeight() {
+			continue
+		}
+		if shm.isRetired(info.EnodeID, info.AnnounceSequence) {
+			continue
+		}
+
 		shm.insertStorageHostInformation(info)
 	}
 }
 
+// analyzeHostRetirements will parse the storage host retirement and remove the storage host
+// from the storage host manager
+func (shm *StorageHostManager) analyzeHostRetirements(hostRetirements []types.HostRetirement) {
+	for _, retirement := range hostRetirements {
+		id, err := parseHostRetirement(retirement)
+		if err != nil {
+			shm.log.Error("failed to parse the retirement information", "err", err.Error())
+			continue
+		}
+
+		// record the retirement, so that the withdrawn announcements cannot be applied again
+		shm.lock.Lock()
+		if sequence, exist := shm.retiredHosts[id]; !exist || sequence < retirement.Sequence {
+			shm.retiredHosts[id] = retirement.Sequence
+		}
+		shm.lock.Unlock()
+
+		// the host announced again after the retirement
+		info, exists := shm.storageHostTree.RetrieveHostInfo(id)
+		if !exists || info.AnnounceSequence > retirement.Sequence {
+			continue
+		}
+		if err := shm.remove(id); err != nil {
+			shm.log.Error("failed to remove the retired storage host", "err", err.Error())
+		}
+	}
+}
+
+// isRetired checks whether the host announcement with the sequence is withdrawn by the host
+func (shm *StorageHostManager) isRetired(id enode.ID, sequence uint64) bool {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+	retired, exist := shm.retiredHosts[id]
+	return exist && sequence <= retired
+}
+
+// removeExpiredHosts removes the storage hosts whose announcements expired without renewal.
+// The legacy announcements never expire
+func (shm *StorageHostManager) removeExpiredHosts() {
+	height := shm.getBlockHeight()
+	for _, info := range shm.storageHostTree.All() {
+		if info.AnnounceExpiration == 0 || info.AnnounceExpiration > height {
+			continue
+		}
+		if err := shm.remove(info.EnodeID); err != nil {
+			shm.log.Error("failed to remove the expired storage host", "err", err.Error())
+		}
+	}
+}
+
 // insertStorageHostInformation will insert the storage host information into the storage
 // host manager
 func (shm *StorageHostManager) insertStorageHostInformation(info storage.HostInfo) {
@@ -118,9 +189,15 @@ func (shm *StorageHostManager) insertStorageHostInformation(info storage.HostInf
 		return
 	}
 
+	// ignore the stale announcement
+	if info.AnnounceSequence < oldInfo.AnnounceSequence {
+		return
+	}
+
 	// if the storage host information already existed, update the settings
 	oldInfo.EnodeURL = info.EnodeURL
 	oldInfo.IP = info.IP
+	applyAnnouncementMetadata(&oldInfo, info)
 
 	// check if the ip address has been changed, if so, update the IP network field
 	// and update the LastIPNetWorkChange time
@@ -143,7 +220,28 @@ func (shm *StorageHostManager) insertStorageHostInformation(info storage.HostInf
 
 // parseHostAnnouncement will parse the storage host announcement into storage.HostInfo type
 func parseHostAnnouncement(announcement types.HostAnnouncement) (hostInfo storage.HostInfo, err error) {
+	// the transaction failed in the block is still included, thus verify the signature
+	if err = vm.CheckMultiSignatures(announcement, [][]byte{announcement.Signature}); err != nil {
+		return
+	}
+	if len(announcement.Metadata) > 1 {
+		err = errors.New("host announcement carries more than one metadata")
+		return
+	}
+
 	hostInfo.EnodeURL = announcement.NetAddress
+	hostInfo.AnnouncementVersion = announcement.Version()
+	if len(announcement.Metadata) != 0 {
+		meta := announcement.Metadata[0]
+		hostInfo.AnnounceSequence = meta.Sequence
+		hostInfo.AnnounceExpiration = meta.Expiration
+		hostInfo.Region = meta.Region
+		hostInfo.ProtocolVersion = meta.ProtocolVersion
+		hostInfo.Features = meta.Features
+		if meta.Bond != nil {
+			hostInfo.Bond = common.PtrBigInt(meta.Bond)
+		}
+	}
 
 	// parse the enode URL, get enode id and ip address
 	node, err := enode.ParseV4(announcement.NetAddress)
@@ -156,3 +254,27 @@ func parseHostAnnouncement(announcement types.HostAnnouncement) (hostInfo storag
 
 	return
 }
+
+// parseHostRetirement will parse the storage host retirement and return the enode id of the host
+func parseHostRetirement(retirement types.HostRetirement) (id enode.ID, err error) {
+	if err = vm.CheckMultiSignatures(retirement, [][]byte{retirement.Signature}); err != nil {
+		return
+	}
+	node, err := enode.ParseV4(retirement.NetAddress)
+	if err != nil {
+		return
+	}
+	return node.ID(), nil
+}
+
+// applyAnnouncementMetadata applies the metadata of the host announcement to the stored
+// storage host information
+func applyAnnouncementMetadata(stored *storage.HostInfo, announced storage.HostInfo) {
+	stored.AnnouncementVersion = announced.AnnouncementVersion
+	stored.AnnounceSequence = announced.AnnounceSequence
+	stored.AnnounceExpiration = announced.AnnounceExpiration
+	stored.Region = announced.Region
+	stored.ProtocolVersion = announced.ProtocolVersion
+	stored.Features = announced.Features
+	stored.Bond = announced.Bond
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package storagehostmanager
+
+import (
+	"crypto/ecdsa"
+	"net"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+)
+
+// TestAnalyzeHostAnnouncementsAndRetirements test the announcement metadata is applied, and
+// the stale announcements and the withdrawn announcements are ignored
+func TestAnalyzeHostAnnouncementsAndRetirements(t *testing.T) {
+	shm := newHostManagerTestData()
+	// the scan shall not be started in the test
+	shm.scanWait = true
+	shm.setBlockHeight(100)
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := enode.NewV4(&key.PublicKey, net.IP{127, 0, 0, 1}, 30303, 30303)
+
+	shm.analyzeHostAnnouncements([]types.HostAnnouncement{signedAnnouncement(t, key, node, 100, 1000, "US")})
+	info, exist := shm.storageHostTree.RetrieveHostInfo(node.ID())
+	if !exist {
+		t.Fatal("announced host shall be inserted")
+	}
+	if info.AnnouncementVersion != types.HostAnnouncementVersion || info.AnnounceSequence != 100 || info.Region != "US" {
+		t.Errorf("announcement metadata not applied: %+v", info)
+	}
+	if hosts := shm.StorageHostsByMetadata("us", []string{"download-batch"}); len(hosts) != 1 {
+		t.Errorf("host shall be found by region and feature, got %v hosts", len(hosts))
+	}
+	if hosts := shm.StorageHostsByMetadata("DE", nil); len(hosts) != 0 {
+		t.Errorf("host shall not be found by another region, got %v hosts", len(hosts))
+	}
+
+	// the stale announcement shall be ignored
+	shm.analyzeHostAnnouncements([]types.HostAnnouncement{signedAnnouncement(t, key, node, 90, 1000, "DE")})
+	if info, _ = shm.storageHostTree.RetrieveHostInfo(node.ID()); info.Region != "US" {
+		t.Errorf("stale announcement shall be ignored, region %v", info.Region)
+	}
+
+	// the forged retirement shall be ignored
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shm.analyzeHostRetirements([]types.HostRetirement{signedRetirement(t, otherKey, node, 200)})
+	if _, exist = shm.storageHostTree.RetrieveHostInfo(node.ID()); !exist {
+		t.Fatal("forged retirement shall not remove the host")
+	}
+
+	// the retirement removes the host, and the withdrawn announcement cannot be applied again
+	shm.analyzeHostRetirements([]types.HostRetirement{signedRetirement(t, key, node, 200)})
+	if _, exist = shm.storageHostTree.RetrieveHostInfo(node.ID()); exist {
+		t.Fatal("retired host shall be removed")
+	}
+	shm.analyzeHostAnnouncements([]types.HostAnnouncement{signedAnnouncement(t, key, node, 100, 1000, "US")})
+	if _, exist = shm.storageHostTree.RetrieveHostInfo(node.ID()); exist {
+		t.Fatal("withdrawn announcement shall not be applied again")
+	}
+
+	// the host announces again after the retirement, and the announcement expires
+	shm.analyzeHostAnnouncements([]types.HostAnnouncement{signedAnnouncement(t, key, node, 201, 1000, "US")})
+	if _, exist = shm.storageHostTree.RetrieveHostInfo(node.ID()); !exist {
+		t.Fatal("host announced after the retirement shall be inserted")
+	}
+	shm.setBlockHeight(1000)
+	shm.removeExpiredHosts()
+	if _, exist = shm.storageHostTree.RetrieveHostInfo(node.ID()); exist {
+		t.Fatal("expired host shall be removed")
+	}
+}
+
+// signedAnnouncement creates a host announcement with metadata signed by the key
+func signedAnnouncement(t *testing.T, key *ecdsa.PrivateKey, node *enode.Node, sequence, expiration uint64, region string) types.HostAnnouncement {
+	ha := types.HostAnnouncement{
+		NetAddress: node.String(),
+		Metadata: []types.HostAnnouncementMetadata{{
+			Version:    types.HostAnnouncementVersion,
+			Sequence:   sequence,
+			Expiration: expiration,
+			Region:     region,
+			Features:   []string{"download-batch"},
+		}},
+	}
+	sig, err := crypto.Sign(ha.RLPHash().Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ha.Signature = sig
+	return ha
+}
+
+// signedRetirement creates a host retirement signed by the key
+func signedRetirement(t *testing.T, key *ecdsa.PrivateKey, node *enode.Node, sequence uint64) types.HostRetirement {
+	hr := types.HostRetirement{
+		NetAddress: node.String(),
+		Sequence:   sequence,
+	}
+	sig, err := crypto.Sign(hr.RLPHash().Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hr.Signature = sig
+	return hr
+}
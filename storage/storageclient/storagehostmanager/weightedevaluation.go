@@ -0,0 +1,99 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"math"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// weightedEvaluator evaluates the storage host with the user defined factor weights. Besides
+// the scores of the default evaluator, the bandwidth prices and the measured performance of
+// the storage host are also evaluated
+type weightedEvaluator struct {
+	market  hostMarket
+	rent    storage.RentPayment
+	weights storage.EvaluationWeights
+}
+
+// newWeightedEvaluator creates a new weightedEvaluator based on the given storageHostManager,
+// rentPayment and the factor weights
+func newWeightedEvaluator(shm *StorageHostManager, rent storage.RentPayment, weights storage.EvaluationWeights) *weightedEvaluator {
+	// regulate rent payment
+	regulateRentPayment(&rent)
+
+	return &weightedEvaluator{
+		market:  shm,
+		rent:    rent,
+		weights: weights,
+	}
+}
+
+// Evaluate evaluate the host info, and return the final score.
+func (we *weightedEvaluator) Evaluate(info storage.HostInfo) int64 {
+	return we.EvaluateDetail(info).Evaluation
+}
+
+// EvaluateDetail evaluate the host info, and return the final score with the score details
+func (we *weightedEvaluator) EvaluateDetail(info storage.HostInfo) EvaluationDetail {
+	// regulate host info
+	regulateHostInfo(&info)
+
+	m, r := we.market, we.rent
+	marketPrice := m.GetMarketPrice()
+	detail := EvaluationDetail{
+		PresenceScore:         presenceScoreCalc(info, m),
+		DepositScore:          depositScoreCalc(info, r, m),
+		ContractPriceScore:    contractCostScoreCalc(info, r, m),
+		StorageRemainingScore: storageRemainingScoreCalc(info, r),
+		InteractionScore:      interactionScoreCalc(info),
+		UptimeScore:           uptimeScoreCalc(info),
+		UploadPriceScore:      bandwidthPriceScoreCalc(info.UploadBandwidthPrice, marketPrice.UploadPrice),
+		DownloadPriceScore:    bandwidthPriceScoreCalc(info.DownloadBandwidthPrice, marketPrice.DownloadPrice),
+		PerformanceScore:      performanceScoreCalc(info),
+	}
+	detail.Evaluation = we.calcFinalScore(detail)
+	return detail
+}
+
+// calcFinalScore calculate the final score, which is the product of the scores raised to the
+// power of their weights
+func (we *weightedEvaluator) calcFinalScore(detail EvaluationDetail) int64 {
+	w := we.weights
+	total := math.Pow(detail.PresenceScore, w.Presence) *
+		math.Pow(detail.DepositScore, w.Deposit) *
+		math.Pow(detail.ContractPriceScore, w.ContractPrice) *
+		math.Pow(detail.StorageRemainingScore, w.StorageRemaining) *
+		math.Pow(detail.InteractionScore, w.Interaction) *
+		math.Pow(detail.UptimeScore, w.Uptime) *
+		math.Pow(detail.UploadPriceScore, w.UploadPrice) *
+		math.Pow(detail.DownloadPriceScore, w.DownloadPrice) *
+		math.Pow(detail.PerformanceScore, w.Performance)
+	total *= scoreDefaultBase
+	if total < minScore || math.IsNaN(total) {
+		total = minScore
+	}
+	// the score overflowing int64, including +Inf, is clamped to the max score
+	if total > maxScore {
+		total = maxScore
+	}
+	return int64(total)
+}
+
+// bandwidthPriceScoreCalc calculates the score based on the bandwidth price that storage host
+// requested compared with the market price. The lower the price is, the higher the score will be
+func bandwidthPriceScoreCalc(hostPrice, marketPrice common.BigInt) float64 {
+	if marketPrice.Cmp(common.BigInt0) <= 0 {
+		marketPrice = common.BigInt1
+	}
+	ratio := hostPrice.Float64() / marketPrice.Float64()
+	// If ratio is smaller than 0.1, the factor has value 10; Else the factor has value 1/x
+	if ratio <= 0.1 {
+		return 10
+	}
+	return 1 / ratio
+}
@@ -0,0 +1,151 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestWeightedEvaluator_DefaultWeights test the weighted evaluator with the default weights
+// evaluates the same as the default evaluator
+func TestWeightedEvaluator_DefaultWeights(t *testing.T) {
+	shm := newHostManagerTestData()
+	rent := storage.DefaultRentPayment
+	de := newDefaultEvaluator(shm, rent)
+	we := newWeightedEvaluator(shm, rent, DefaultEvaluationWeights)
+
+	for i := 0; i != 10; i++ {
+		info := hostInfoGenerator()
+		info.Performance = storage.HostPerformance{
+			Latency:           time.Second,
+			DownloadTransfers: 1,
+		}
+		if expect, got := de.Evaluate(info), we.Evaluate(info); expect != got {
+			t.Errorf("test %d: evaluation not expected. Got %v, Expect %v", i, got, expect)
+		}
+	}
+}
+
+// TestWeightedEvaluator_calcFinalScore test weightedEvaluator.calcFinalScore
+func TestWeightedEvaluator_calcFinalScore(t *testing.T) {
+	allOne := EvaluationDetail{
+		PresenceScore:         1,
+		DepositScore:          1,
+		InteractionScore:      1,
+		ContractPriceScore:    1,
+		StorageRemainingScore: 1,
+		UptimeScore:           1,
+		UploadPriceScore:      1,
+		DownloadPriceScore:    1,
+		PerformanceScore:      1,
+	}
+	fastHost := allOne
+	fastHost.PerformanceScore = 2
+	infHost := allOne
+	infHost.PerformanceScore = math.Inf(1)
+	tests := []struct {
+		detail  EvaluationDetail
+		weights storage.EvaluationWeights
+		expect  int64
+	}{
+		{allOne, DefaultEvaluationWeights, scoreDefaultBase},
+		{EvaluationDetail{}, DefaultEvaluationWeights, minScore},
+		// score with zero weight is ignored
+		{fastHost, DefaultEvaluationWeights, scoreDefaultBase},
+		{fastHost, storage.EvaluationWeights{Performance: 1}, 2 * scoreDefaultBase},
+		{fastHost, storage.EvaluationWeights{Performance: 2}, 4 * scoreDefaultBase},
+		// score overflowing int64 is clamped
+		{fastHost, storage.EvaluationWeights{Performance: 100}, maxScore},
+		{infHost, storage.EvaluationWeights{Performance: 1}, maxScore},
+	}
+	for i, test := range tests {
+		we := &weightedEvaluator{weights: test.weights}
+		if got := we.calcFinalScore(test.detail); got != test.expect {
+			t.Errorf("test %d: unexpected score. Got %v, Expect %v", i, got, test.expect)
+		}
+	}
+}
+
+// TestBandwidthPriceScoreCalc test the functionality of bandwidthPriceScoreCalc
+func TestBandwidthPriceScoreCalc(t *testing.T) {
+	tests := []struct {
+		hostPrice   common.BigInt
+		marketPrice common.BigInt
+		expect      float64
+	}{
+		{common.NewBigInt(100), common.NewBigInt(100), 1},
+		{common.NewBigInt(200), common.NewBigInt(100), 0.5},
+		{common.NewBigInt(1), common.NewBigInt(100), 10},
+		{common.NewBigInt(0), common.NewBigInt(0), 10},
+		{common.NewBigInt(2), common.NewBigInt(0), 0.5},
+	}
+	for i, test := range tests {
+		if got := bandwidthPriceScoreCalc(test.hostPrice, test.marketPrice); got != test.expect {
+			t.Errorf("test %d: unexpected score. Got %v, Expect %v", i, got, test.expect)
+		}
+	}
+}
+
+// TestStorageHostManager_SetEvaluator test registering and switching the evaluators
+func TestStorageHostManager_SetEvaluator(t *testing.T) {
+	shm := newHostManagerTestData()
+	if err := insertHostInfos(shm, hostInfosByPrototype(hostPolicyPrototype, 5)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shm.SetEvaluator("constant"); err == nil {
+		t.Error("unregistered evaluator shall not be set")
+	}
+	if err := shm.RegisterEvaluator(DefaultEvaluatorName, nil); err == nil {
+		t.Error("built-in evaluator shall not be overridden")
+	}
+	creator := func(shm *StorageHostManager, rent storage.RentPayment) Evaluator {
+		return &constantEvaluator{}
+	}
+	if err := shm.RegisterEvaluator("constant", creator); err != nil {
+		t.Fatal(err)
+	}
+	if err := shm.SetEvaluator("constant"); err != nil {
+		t.Fatal(err)
+	}
+	if name := shm.RetrieveEvaluator(); name != "constant" {
+		t.Errorf("unexpected evaluator. Got %v, Expect %v", name, "constant")
+	}
+	// all the storage hosts shall be re-evaluated with the new evaluator
+	for _, info := range shm.storageHostTree.All() {
+		if eval := shm.hostEvaluator.Evaluate(info); eval != scoreDefaultBase {
+			t.Errorf("unexpected evaluation. Got %v, Expect %v", eval, scoreDefaultBase)
+		}
+	}
+
+	if err := shm.SetEvaluationWeights(storage.EvaluationWeights{Performance: maxEvaluationWeight + 1}); err == nil {
+		t.Error("invalid evaluation weights shall not be accepted")
+	}
+	if err := shm.SetEvaluationWeights(storage.EvaluationWeights{}); err != nil {
+		t.Fatal(err)
+	}
+	if weights := shm.RetrieveEvaluationWeights(); weights != DefaultEvaluationWeights {
+		t.Errorf("empty weights shall be regarded as default. Got %+v", weights)
+	}
+}
+
+// constantEvaluator is the customized evaluator which evaluates all the storage hosts
+// with the same score
+type constantEvaluator struct{}
+
+// Evaluate return the constant score
+func (ce *constantEvaluator) Evaluate(info storage.HostInfo) int64 {
+	return scoreDefaultBase
+}
+
+// EvaluateDetail return the constant score with empty details
+func (ce *constantEvaluator) EvaluateDetail(info storage.HostInfo) EvaluationDetail {
+	return EvaluationDetail{Evaluation: scoreDefaultBase}
+}
@@ -0,0 +1,559 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+var syncFoldersMetadata = common.Metadata{
+	Header:  "storage client sync folders",
+	Version: SyncFoldersVersion,
+}
+
+var (
+	errSyncFolderNotExist = errors.New("the local directory is not a sync folder")
+	errEmptySyncFile      = errors.New("empty files are not supported by the DxChain storage")
+	errSyncFileChanged    = errors.New("the file is changed during the sync")
+	errSyncConflict       = errors.New("the file already exists and is not uploaded by the sync")
+)
+
+type (
+	// syncFolder is a local directory mirrored to a DxPath. The new and changed local files are
+	// uploaded, and the deletion of the local files is propagated according to the DeletePolicy
+	syncFolder struct {
+		LocalDir     string
+		DxPath       storage.DxPath
+		DeletePolicy storage.SyncDeletePolicy
+		LastScan     time.Time
+
+		// Files is the state of the synced files when they are uploaded, keyed by the slash
+		// separated path relative to LocalDir
+		Files map[string]syncedFile
+
+		// Failed is the files failed to be synced, keyed by the relative path
+		Failed map[string]storage.SyncFailure
+
+		// pending is the files detected by the scan and not yet synced
+		pending map[string]struct{}
+	}
+
+	// syncedFile is the state of a synced local file. The file with unchanged size and
+	// modification time is not checked again, and the file with unchanged checksum is not
+	// uploaded again
+	syncedFile struct {
+		Size     int64
+		ModTime  time.Time
+		Checksum string
+	}
+
+	// syncFileMeta is the app metadata of the DxFile uploaded by the sync, which records the
+	// checksum of the uploaded data
+	syncFileMeta struct {
+		Checksum string `json:"checksum"`
+	}
+
+	// syncChange is a new, changed or deleted file detected by the scan of the sync folder
+	syncChange struct {
+		rel  string
+		info os.FileInfo // nil if the file is deleted
+	}
+)
+
+// AddSyncFolder starts mirroring the local directory to the dxPath. The local directory
+// and the dxPath must not overlap with the existing sync folders
+func (client *StorageClient) AddSyncFolder(localDir string, dxPath storage.DxPath, policy storage.SyncDeletePolicy) error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	if !policy.Valid() {
+		return fmt.Errorf("invalid deletion policy %v", policy)
+	}
+	localDir, err := filepath.Abs(localDir)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(localDir)
+	if err != nil {
+		return fmt.Errorf("unable to stat the local directory: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%v is not a directory", localDir)
+	}
+
+	client.syncLock.Lock()
+	defer client.syncLock.Unlock()
+	for _, folder := range client.syncFolders {
+		if pathsOverlap(folder.LocalDir, localDir, string(filepath.Separator)) {
+			return fmt.Errorf("the local directory overlaps with the sync folder %v", folder.LocalDir)
+		}
+		if folder.DxPath.IsRoot() || dxPath.IsRoot() || pathsOverlap(folder.DxPath.Path, dxPath.Path, "/") {
+			return fmt.Errorf("the DxPath overlaps with the sync folder %v", folder.LocalDir)
+		}
+	}
+	client.syncFolders[localDir] = &syncFolder{
+		LocalDir:     localDir,
+		DxPath:       dxPath,
+		DeletePolicy: policy,
+		Files:        make(map[string]syncedFile),
+		Failed:       make(map[string]storage.SyncFailure),
+		pending:      make(map[string]struct{}),
+	}
+	if err = client.saveSyncFolders(); err != nil {
+		delete(client.syncFolders, localDir)
+		return err
+	}
+
+	select {
+	case client.syncScanNeeded <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// RemoveSyncFolder stops mirroring the local directory. The synced DxFiles are kept
+func (client *StorageClient) RemoveSyncFolder(localDir string) error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	localDir, err := filepath.Abs(localDir)
+	if err != nil {
+		return err
+	}
+	client.syncLock.Lock()
+	defer client.syncLock.Unlock()
+	folder, exists := client.syncFolders[localDir]
+	if !exists {
+		return errSyncFolderNotExist
+	}
+	delete(client.syncFolders, localDir)
+	if err = client.saveSyncFolders(); err != nil {
+		client.syncFolders[localDir] = folder
+		return err
+	}
+	return nil
+}
+
+// SyncFolderStatus returns the status of the sync folders sorted by the local directory. The
+// pending files include the files detected but not yet synced, and the synced files still
+// being uploaded
+func (client *StorageClient) SyncFolderStatus() []storage.SyncFolderStatus {
+	client.syncLock.Lock()
+	defer client.syncLock.Unlock()
+
+	statuses := make([]storage.SyncFolderStatus, 0, len(client.syncFolders))
+	for _, folder := range client.syncFolders {
+		status := storage.SyncFolderStatus{
+			LocalDir:     folder.LocalDir,
+			DxPath:       folder.DxPath.Path,
+			DeletePolicy: folder.DeletePolicy.String(),
+			LastScan:     folder.LastScan,
+			NumSynced:    len(folder.Files),
+			Pending:      []string{},
+			Failed:       []storage.SyncFailure{},
+		}
+		for rel := range folder.pending {
+			status.Pending = append(status.Pending, rel)
+		}
+		for rel := range folder.Files {
+			if _, pending := folder.pending[rel]; !pending && client.syncUploading(folder, rel) {
+				status.Pending = append(status.Pending, rel)
+			}
+		}
+		for _, failure := range folder.Failed {
+			status.Failed = append(status.Failed, failure)
+		}
+		sort.Strings(status.Pending)
+		sort.Slice(status.Failed, func(i, j int) bool { return status.Failed[i].Path < status.Failed[j].Path })
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].LocalDir < statuses[j].LocalDir })
+	return statuses
+}
+
+// syncUploading checks whether the DxFile of the synced file has not finished uploading
+func (client *StorageClient) syncUploading(folder *syncFolder, rel string) bool {
+	dxPath, err := folder.DxPath.Join(rel)
+	if err != nil {
+		return false
+	}
+	entry, err := client.fileSystem.OpenDxFile(dxPath)
+	if err != nil {
+		return false
+	}
+	defer entry.Close()
+	return entry.UploadProgress() < 100
+}
+
+// syncFolderLoop scans the sync folders periodically, or when a sync folder is added
+func (client *StorageClient) syncFolderLoop() {
+	err := client.tm.Add()
+	if err != nil {
+		return
+	}
+	defer client.tm.Done()
+
+	// clear the snapshots left by the uploads interrupted in the last run
+	if err = os.RemoveAll(client.syncSnapshotDir()); err != nil {
+		client.log.Warn("failed to clear the sync snapshots", "err", err)
+	}
+
+	for {
+		client.scanSyncFolders()
+
+		select {
+		case <-client.tm.StopChan():
+			return
+		case <-client.syncScanNeeded:
+		case <-time.After(SyncFolderScanInterval):
+		}
+	}
+}
+
+// scanSyncFolders scans all sync folders and syncs the changes
+func (client *StorageClient) scanSyncFolders() {
+	client.syncLock.Lock()
+	folders := make([]*syncFolder, 0, len(client.syncFolders))
+	for _, folder := range client.syncFolders {
+		folders = append(folders, folder)
+	}
+	client.syncLock.Unlock()
+
+	for _, folder := range folders {
+		select {
+		case <-client.tm.StopChan():
+			return
+		default:
+		}
+		if err := client.scanSyncFolder(folder); err != nil {
+			client.log.Warn("failed to scan the sync folder", "localDir", folder.LocalDir, "err", err)
+		}
+	}
+}
+
+// scanSyncFolder detects the changes of the local files in the sync folder, and syncs each
+// change. The files failed to be synced are recorded, and retried in the next scan
+func (client *StorageClient) scanSyncFolder(folder *syncFolder) error {
+	changes, err := client.detectSyncChanges(folder)
+	if err != nil {
+		return err
+	}
+
+	// the changes synced before the client is stopped are saved
+changeLoop:
+	for _, change := range changes {
+		select {
+		case <-client.tm.StopChan():
+			break changeLoop
+		default:
+		}
+		err := client.applySyncChange(folder, change)
+
+		client.syncLock.Lock()
+		delete(folder.pending, change.rel)
+		if err != nil {
+			folder.Failed[change.rel] = storage.SyncFailure{
+				Path:  change.rel,
+				Error: err.Error(),
+				Time:  time.Now(),
+			}
+		} else {
+			delete(folder.Failed, change.rel)
+		}
+		client.syncLock.Unlock()
+	}
+
+	client.syncLock.Lock()
+	defer client.syncLock.Unlock()
+	folder.LastScan = time.Now()
+	if client.syncFolders[folder.LocalDir] != folder {
+		// removed during the scan
+		return nil
+	}
+	return client.saveSyncFolders()
+}
+
+// detectSyncChanges walks the local directory, and returns the files which are new, deleted,
+// or whose size or modification time is changed since synced. If the local directory cannot
+// be fully walked, an error is returned instead of regarding the files as deleted
+func (client *StorageClient) detectSyncChanges(folder *syncFolder) ([]syncChange, error) {
+	client.syncLock.Lock()
+	synced := make(map[string]syncedFile, len(folder.Files))
+	for rel, record := range folder.Files {
+		synced[rel] = record
+	}
+	client.syncLock.Unlock()
+
+	var changes []syncChange
+	walked := make(map[string]struct{})
+	err := filepath.Walk(folder.LocalDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(folder.LocalDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		walked[rel] = struct{}{}
+		if record, exists := synced[rel]; exists && record.Size == info.Size() && record.ModTime.Equal(info.ModTime()) {
+			return nil
+		}
+		changes = append(changes, syncChange{rel: rel, info: info})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for rel := range synced {
+		if _, exists := walked[rel]; !exists {
+			deleted = append(deleted, rel)
+		}
+	}
+	sort.Strings(deleted)
+	for _, rel := range deleted {
+		changes = append(changes, syncChange{rel: rel})
+	}
+
+	client.syncLock.Lock()
+	for _, change := range changes {
+		folder.pending[change.rel] = struct{}{}
+	}
+	client.syncLock.Unlock()
+	return changes, nil
+}
+
+// applySyncChange uploads the new or changed file, or propagates the deletion of the file
+func (client *StorageClient) applySyncChange(folder *syncFolder, change syncChange) error {
+	dxPath, err := folder.DxPath.Join(change.rel)
+	if err != nil {
+		return err
+	}
+	if change.info == nil {
+		return client.syncDeletedFile(folder, change.rel, dxPath)
+	}
+	return client.syncFile(folder, change, dxPath)
+}
+
+// syncFile uploads the local file if its content differs from the synced one, or its DxFile
+// does not exist. The DxFile uploaded from the same local file before the state is saved is
+// adopted without uploading again. The DxFile not uploaded by the sync is only overwritten if
+// versioning is enabled to retain it
+func (client *StorageClient) syncFile(folder *syncFolder, change syncChange, dxPath storage.DxPath) error {
+	localPath := filepath.Join(folder.LocalDir, filepath.FromSlash(change.rel))
+	if change.info.Size() == 0 {
+		return errEmptySyncFile
+	}
+	checksum, err := fileChecksum(localPath)
+	if err != nil {
+		return err
+	}
+	record := syncedFile{
+		Size:     change.info.Size(),
+		ModTime:  change.info.ModTime(),
+		Checksum: checksum,
+	}
+
+	client.syncLock.Lock()
+	prev, synced := folder.Files[change.rel]
+	client.syncLock.Unlock()
+	uploadNeeded := !synced || prev.Size != record.Size || prev.Checksum != record.Checksum
+
+	entry, err := client.fileSystem.OpenDxFile(dxPath)
+	switch {
+	case err == dxfile.ErrUnknownFile:
+		uploadNeeded = true
+	case err != nil:
+		return err
+	default:
+		sameSource := entry.FileSize() == uint64(record.Size) && isSyncSource(string(entry.LocalPath()), entry.AppMetadata(), localPath, checksum)
+		if err = entry.Close(); err != nil {
+			return err
+		}
+		if !synced && sameSource {
+			uploadNeeded = false
+		}
+		if !synced && !sameSource {
+			policy, err := client.fileSystem.VersioningPolicy(dxPath)
+			if err != nil {
+				return err
+			}
+			if !policy.Enabled {
+				return errSyncConflict
+			}
+		}
+	}
+
+	if uploadNeeded {
+		if err = client.uploadSyncFile(localPath, checksum, dxPath); err != nil {
+			return err
+		}
+	}
+
+	client.syncLock.Lock()
+	folder.Files[change.rel] = record
+	client.syncLock.Unlock()
+	return nil
+}
+
+// uploadSyncFile uploads the snapshot of the local file, which replaces the existing DxFile
+// once the upload completes. Thus the existing DxFile is kept if the upload fails. The snapshot,
+// instead of the local file, is uploaded, so that the changes of the local file during the
+// upload are not mixed into the DxFile. The snapshot is removed afterwards, and the DxFile is
+// repaired from the storage hosts. The checksum is saved as the app metadata of the DxFile
+func (client *StorageClient) uploadSyncFile(localPath string, checksum string, dxPath storage.DxPath) error {
+	appMeta, err := json.Marshal(syncFileMeta{Checksum: checksum})
+	if err != nil {
+		return err
+	}
+	snapshot, err := client.snapshotSyncFile(localPath, checksum)
+	if err != nil {
+		return err
+	}
+	_, err = client.fileSystem.UploadStagedFile(snapshot, dxPath, appMeta, func(uploadPath storage.DxPath, r io.Reader) error {
+		return client.UploadStream(storage.FileUploadParams{
+			DxPath: uploadPath,
+			Mode:   storage.Override,
+		}, r)
+	})
+	return err
+}
+
+// snapshotSyncFile copies the local file to a new snapshot. The checksum of the copied data
+// is checked against the checksum of the local file, so that the file modified during the
+// sync is not recorded as synced
+func (client *StorageClient) snapshotSyncFile(localPath string, checksum string) (string, error) {
+	if err := os.MkdirAll(client.syncSnapshotDir(), 0700); err != nil {
+		return "", err
+	}
+	src, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	snapshot, err := ioutil.TempFile(client.syncSnapshotDir(), "*"+syncSnapshotExt)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(snapshot, h), src)
+	if closeErr := snapshot.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && hex.EncodeToString(h.Sum(nil)) != checksum {
+		err = errSyncFileChanged
+	}
+	if err != nil {
+		os.Remove(snapshot.Name())
+		return "", err
+	}
+	return snapshot.Name(), nil
+}
+
+// isSyncSource checks whether the DxFile is uploaded from the local file, or uploaded by the
+// sync with the same checksum
+func isSyncSource(source string, appMeta []byte, localPath, checksum string) bool {
+	if source == localPath {
+		return true
+	}
+	var meta syncFileMeta
+	if err := json.Unmarshal(appMeta, &meta); err != nil {
+		return false
+	}
+	return meta.Checksum == checksum
+}
+
+// syncSnapshotDir returns the directory of the snapshots of the files being uploaded
+func (client *StorageClient) syncSnapshotDir() string {
+	return filepath.Join(client.persistDir, SyncSnapshotsDirectory)
+}
+
+// syncDeletedFile forgets the deleted local file. If the deletion policy is delete, the
+// DxFile is also deleted, or archived as a version if versioning is enabled. The DxFile
+// already deleted is regarded as synced
+func (client *StorageClient) syncDeletedFile(folder *syncFolder, rel string, dxPath storage.DxPath) error {
+	if folder.DeletePolicy == storage.SyncDeleteRemove {
+		err := client.fileSystem.RemoveDxFile(dxPath)
+		if err != nil && err != dxfile.ErrUnknownFile {
+			return fmt.Errorf("failed to delete the file: %v", err)
+		}
+	}
+	client.syncLock.Lock()
+	delete(folder.Files, rel)
+	client.syncLock.Unlock()
+	return nil
+}
+
+// saveSyncFolders saves the sync folders to the persist file. The caller shall hold the syncLock
+func (client *StorageClient) saveSyncFolders() error {
+	return common.SaveDxJSON(syncFoldersMetadata, filepath.Join(client.persistDir, SyncFoldersFilename), client.syncFolders)
+}
+
+// loadSyncFolders loads the sync folders from the persist file
+func (client *StorageClient) loadSyncFolders() error {
+	client.syncLock.Lock()
+	defer client.syncLock.Unlock()
+
+	client.syncFolders = make(map[string]*syncFolder)
+	err := common.LoadDxJSON(syncFoldersMetadata, filepath.Join(client.persistDir, SyncFoldersFilename), &client.syncFolders)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, folder := range client.syncFolders {
+		if folder.Files == nil {
+			folder.Files = make(map[string]syncedFile)
+		}
+		if folder.Failed == nil {
+			folder.Failed = make(map[string]storage.SyncFailure)
+		}
+		folder.pending = make(map[string]struct{})
+	}
+	return nil
+}
+
+// fileChecksum returns the hex encoded sha256 checksum of the file content
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// pathsOverlap checks whether the two paths are the same, or one contains the other
+func pathsOverlap(a, b, sep string) bool {
+	return a == b || strings.HasPrefix(a, b+sep) || strings.HasPrefix(b, a+sep)
+}
@@ -0,0 +1,360 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// TestStorageClient_AddSyncFolder test adding and removing the sync folders, and the sync
+// folders are persisted
+func TestStorageClient_AddSyncFolder(t *testing.T) {
+	client, dir := newSyncTestClient(t)
+	defer os.RemoveAll(dir)
+	defer client.Close()
+
+	localDir := filepath.Join(dir, "local")
+	if err := os.MkdirAll(filepath.Join(localDir, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	dxPath, _ := storage.NewDxPath("sync")
+	if err := client.AddSyncFolder(localDir, dxPath, storage.SyncDeleteRemove); err != nil {
+		t.Fatal(err)
+	}
+
+	otherPath, _ := storage.NewDxPath("other")
+	subPath, _ := storage.NewDxPath("sync/sub")
+	tests := []struct {
+		localDir string
+		dxPath   storage.DxPath
+		policy   storage.SyncDeletePolicy
+	}{
+		{filepath.Join(dir, "not-exist"), otherPath, storage.SyncDeleteKeep},
+		{localDir, otherPath, storage.SyncDeleteKeep},
+		{filepath.Join(localDir, "sub"), otherPath, storage.SyncDeleteKeep},
+		{dir, otherPath, storage.SyncDeleteKeep},
+		{filepath.Join(dir, "persist"), subPath, storage.SyncDeleteKeep},
+		{filepath.Join(dir, "persist"), storage.RootDxPath(), storage.SyncDeleteKeep},
+		{filepath.Join(dir, "persist"), otherPath, 0},
+	}
+	for i, test := range tests {
+		if err := client.AddSyncFolder(test.localDir, test.dxPath, test.policy); err == nil {
+			t.Errorf("test %v: expect error adding the sync folder %v -> %v", i, test.localDir, test.dxPath.Path)
+		}
+	}
+
+	// reload the sync folders from the persist file
+	if err := client.loadSyncFolders(); err != nil {
+		t.Fatal(err)
+	}
+	statuses := client.SyncFolderStatus()
+	if len(statuses) != 1 {
+		t.Fatalf("expect 1 sync folder, got %v", len(statuses))
+	}
+	if statuses[0].LocalDir != localDir || statuses[0].DxPath != "sync" || statuses[0].DeletePolicy != "delete" {
+		t.Errorf("unexpected sync folder status: %+v", statuses[0])
+	}
+
+	if err := client.RemoveSyncFolder(localDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.RemoveSyncFolder(localDir); err != errSyncFolderNotExist {
+		t.Errorf("remove twice: expect error %v, got %v", errSyncFolderNotExist, err)
+	}
+	if err := client.loadSyncFolders(); err != nil {
+		t.Fatal(err)
+	}
+	if statuses = client.SyncFolderStatus(); len(statuses) != 0 {
+		t.Errorf("expect no sync folder after removed, got %v", len(statuses))
+	}
+}
+
+// TestStorageClient_ScanSyncFolder test the scan of the sync folder detects the new, changed
+// and deleted files. Since the client has no contracts, the uploads fail and are recorded
+func TestStorageClient_ScanSyncFolder(t *testing.T) {
+	client, dir := newSyncTestClient(t)
+	defer os.RemoveAll(dir)
+	defer client.Close()
+
+	localDir := filepath.Join(dir, "local")
+	if err := os.MkdirAll(filepath.Join(localDir, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	dxPath, _ := storage.NewDxPath("sync")
+	if err := client.AddSyncFolder(localDir, dxPath, storage.SyncDeleteRemove); err != nil {
+		t.Fatal(err)
+	}
+	folder := client.syncFolders[localDir]
+
+	// The file uploaded before the sync state is saved is adopted, and the empty file fails
+	adopted := filepath.Join(localDir, "sub", "adopted")
+	writeSyncTestFile(t, adopted, "adopted data")
+	writeSyncTestFile(t, filepath.Join(localDir, "empty"), "")
+	adoptedPath, _ := dxPath.Join("sub/adopted")
+	newSyncTestDxFile(t, client, adoptedPath, adopted)
+
+	if err := client.scanSyncFolder(folder); err != nil {
+		t.Fatal(err)
+	}
+	status := client.SyncFolderStatus()[0]
+	if status.NumSynced != 1 || len(status.Failed) != 1 || status.Failed[0].Path != "empty" {
+		t.Fatalf("unexpected status after the first scan: %+v", status)
+	}
+	if len(status.Pending) != 1 || status.Pending[0] != "sub/adopted" {
+		t.Errorf("the adopted file not uploaded shall be pending: %v", status.Pending)
+	}
+	if status.LastScan.IsZero() {
+		t.Errorf("last scan time not updated")
+	}
+
+	// The file touched with the same content is not uploaded again
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(adopted, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.scanSyncFolder(folder); err != nil {
+		t.Fatal(err)
+	}
+	if record := folder.Files["sub/adopted"]; !record.ModTime.Equal(future) {
+		t.Errorf("the modification time of the touched file not updated: %v", record.ModTime)
+	}
+	if _, failed := folder.Failed["sub/adopted"]; failed {
+		t.Errorf("the touched file shall not be uploaded again")
+	}
+
+	// The changed file is uploaded again, which fails without contracts
+	writeSyncTestFile(t, adopted, "changed data")
+	if err := client.scanSyncFolder(folder); err != nil {
+		t.Fatal(err)
+	}
+	if _, failed := folder.Failed["sub/adopted"]; !failed {
+		t.Errorf("the changed file shall be uploaded again")
+	}
+	entry, err := client.fileSystem.OpenDxFile(adoptedPath)
+	if err != nil {
+		t.Fatalf("the previous file shall be kept when the upload fails: %v", err)
+	}
+	if string(entry.LocalPath()) != adopted {
+		t.Errorf("the previous file shall not be replaced: %v", entry.LocalPath())
+	}
+	if err = entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if snapshots, _ := ioutil.ReadDir(client.syncSnapshotDir()); len(snapshots) != 0 {
+		t.Errorf("the snapshot of the failed upload shall be removed, got %v", len(snapshots))
+	}
+
+	// The deletion of the synced file is propagated
+	writeSyncTestFile(t, adopted, "adopted data")
+	delete(folder.Files, "sub/adopted")
+	if err := client.scanSyncFolder(folder); err != nil {
+		t.Fatal(err)
+	}
+	if _, synced := folder.Files["sub/adopted"]; !synced {
+		t.Fatalf("the file is not adopted")
+	}
+	if err := os.Remove(adopted); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.scanSyncFolder(folder); err != nil {
+		t.Fatal(err)
+	}
+	if _, synced := folder.Files["sub/adopted"]; synced {
+		t.Errorf("the deleted file shall not be recorded")
+	}
+	if _, err := client.fileSystem.OpenDxFile(adoptedPath); err != dxfile.ErrUnknownFile {
+		t.Errorf("the synced file shall be deleted with the local file, got error %v", err)
+	}
+
+	// The deletion of the synced file already deleted is regarded as synced
+	conflict := filepath.Join(localDir, "conflict")
+	writeSyncTestFile(t, conflict, "conflict data")
+	conflictPath, _ := dxPath.Join("conflict")
+	newSyncTestDxFile(t, client, conflictPath, conflict)
+	if err := client.scanSyncFolder(folder); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.fileSystem.DeleteDxFile(conflictPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(conflict); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.scanSyncFolder(folder); err != nil {
+		t.Fatal(err)
+	}
+	if _, synced := folder.Files["conflict"]; synced {
+		t.Errorf("the deleted file shall not be recorded")
+	}
+	if failure, failed := folder.Failed["conflict"]; failed {
+		t.Errorf("the deletion of the deleted file shall not fail: %v", failure.Error)
+	}
+
+	// The DxFile not uploaded by the sync is only overwritten with versioning enabled
+	other := filepath.Join(dir, "other")
+	writeSyncTestFile(t, other, "other data")
+	writeSyncTestFile(t, conflict, "conflict data")
+	newSyncTestDxFile(t, client, conflictPath, other)
+	if err := client.scanSyncFolder(folder); err != nil {
+		t.Fatal(err)
+	}
+	if failure := folder.Failed["conflict"]; failure.Error != errSyncConflict.Error() {
+		t.Errorf("expect error %v, got %v", errSyncConflict, failure.Error)
+	}
+	if err := client.fileSystem.SetVersioning(dxPath, storage.VersioningPolicy{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.scanSyncFolder(folder); err != nil {
+		t.Fatal(err)
+	}
+	if failure := folder.Failed["conflict"]; failure.Error == errSyncConflict.Error() {
+		t.Errorf("the file shall be uploaded with versioning enabled")
+	}
+	if err := client.fileSystem.SetVersioning(dxPath, storage.VersioningPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(conflict); err != nil {
+		t.Fatal(err)
+	}
+
+	// The missing local directory is not regarded as all files deleted
+	writeSyncTestFile(t, adopted, "adopted data")
+	newSyncTestDxFile(t, client, adoptedPath, adopted)
+	if err := client.scanSyncFolder(folder); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(localDir, localDir+".moved"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.scanSyncFolder(folder); err == nil {
+		t.Errorf("expect error scanning the missing local directory")
+	}
+	if _, synced := folder.Files["sub/adopted"]; !synced {
+		t.Errorf("the files shall be kept when the local directory is missing")
+	}
+}
+
+// TestStorageClient_SnapshotSyncFile test the snapshot copies the local file, and the file
+// changed after the checksum is computed is detected
+func TestStorageClient_SnapshotSyncFile(t *testing.T) {
+	client, dir := newSyncTestClient(t)
+	defer os.RemoveAll(dir)
+	defer client.Close()
+
+	localPath := filepath.Join(dir, "file")
+	writeSyncTestFile(t, localPath, "snapshot data")
+	checksum, err := fileChecksum(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := client.snapshotSyncFile(localPath, checksum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(snapshot) != client.syncSnapshotDir() {
+		t.Errorf("unexpected snapshot path %v", snapshot)
+	}
+	data, err := ioutil.ReadFile(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "snapshot data" {
+		t.Errorf("unexpected snapshot data: %s", data)
+	}
+	if err = os.Remove(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	writeSyncTestFile(t, localPath, "changed data")
+	if _, err = client.snapshotSyncFile(localPath, checksum); err != errSyncFileChanged {
+		t.Errorf("expect error %v, got %v", errSyncFileChanged, err)
+	}
+	if snapshots, _ := ioutil.ReadDir(client.syncSnapshotDir()); len(snapshots) != 0 {
+		t.Errorf("the snapshot of the changed file shall be removed, got %v", len(snapshots))
+	}
+}
+
+// TestIsSyncSource test the DxFile is regarded as uploaded from the local file if the local
+// path is its source, or the checksum saved by the sync matches
+func TestIsSyncSource(t *testing.T) {
+	appMeta, err := json.Marshal(syncFileMeta{Checksum: "checksum"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		source  string
+		appMeta []byte
+		expect  bool
+	}{
+		{"/local/file", nil, true},
+		{"", appMeta, true},
+		{"/other/file", appMeta, true},
+		{"", nil, false},
+		{"", []byte("not json"), false},
+		{"/other/file", []byte(`{"checksum":"other"}`), false},
+	}
+	for i, test := range tests {
+		if got := isSyncSource(test.source, test.appMeta, "/local/file", "checksum"); got != test.expect {
+			t.Errorf("test %v: expect %v, got %v", i, test.expect, got)
+		}
+	}
+}
+
+// newSyncTestClient creates the storage client with the file system started in a new
+// temporary directory
+func newSyncTestClient(t *testing.T) (*StorageClient, string) {
+	dir, err := ioutil.TempDir("", "storageclient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := New(filepath.Join(dir, "persist"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.fileSystem.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return client, dir
+}
+
+// writeSyncTestFile writes the data to the local file
+func writeSyncTestFile(t *testing.T, path string, data string) {
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newSyncTestDxFile creates the DxFile with the local file as the source
+func newSyncTestDxFile(t *testing.T, client *StorageClient, dxPath storage.DxPath, localPath string) {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ck, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := client.fileSystem.NewDxFile(dxPath, storage.SysPath(localPath), false, ec, ck, uint64(info.Size()), info.Mode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package storageclient
+
+import (
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
+)
+
+// transferMeasure measures the latency and the duration of a data transfer with the storage host
+type transferMeasure struct {
+	start   time.Time
+	latency time.Duration
+}
+
+// newTransferMeasure starts to measure the data transfer
+func newTransferMeasure() *transferMeasure {
+	return &transferMeasure{
+		start: time.Now(),
+	}
+}
+
+// firstResponse records the latency once the first response from the storage host is received
+func (tm *transferMeasure) firstResponse() {
+	if tm.latency == 0 {
+		tm.latency = time.Since(tm.start)
+	}
+}
+
+// recordTransfer records the measured performance of the finished data transfer of the size
+// into the storage host manager
+func (client *StorageClient) recordTransfer(id enode.ID, interactionType storagehostmanager.InteractionType, tm *transferMeasure, size uint64) {
+	client.storageHostManager.RecordTransfer(id, interactionType, tm.latency, time.Since(tm.start), size)
+}
+
+// recordTransferError records the failed data transfer with the storage host into the storage
+// host manager. The failure caused by the client itself is not recorded
+func (client *StorageClient) recordTransferError(id enode.ID, interactionType storagehostmanager.InteractionType, err, clientNegotiateErr, hostNegotiateErr, hostCommitErr error) {
+	if clientNegotiateErr != nil {
+		return
+	}
+	client.storageHostManager.RecordTransferError(id, interactionType, transferErrorType(err, hostNegotiateErr, hostCommitErr))
+}
+
+// transferErrorType returns the type of the failed data transfer based on the errors occurred
+// during the negotiation
+func transferErrorType(err, hostNegotiateErr, hostCommitErr error) string {
+	switch {
+	case hostCommitErr != nil:
+		return storagehostmanager.TransferErrorHostCommit
+	case hostNegotiateErr == storage.ErrHostNegotiate:
+		return storagehostmanager.TransferErrorHostNegotiate
+	case hostNegotiateErr != nil:
+		return storagehostmanager.TransferErrorInvalidResponse
+	case err == storage.ErrHostBusyHandleReq:
+		return storagehostmanager.TransferErrorHostBusy
+	default:
+		return storagehostmanager.TransferErrorConnection
+	}
+}
+
+// uploadSize returns the size of the data uploaded by the upload actions
+func uploadSize(actions []storage.UploadAction) (size uint64) {
+	for _, action := range actions {
+		size += uint64(len(action.Data))
+	}
+	return
+}
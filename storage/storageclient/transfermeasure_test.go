@@ -0,0 +1,34 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package storageclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
+)
+
+// TestTransferErrorType test the failed data transfers are classified by the negotiation errors
+func TestTransferErrorType(t *testing.T) {
+	errInvalid := errors.New("invalid merkle proof")
+	errTimeout := errors.New("timeout")
+	tests := []struct {
+		err, hostNegotiateErr, hostCommitErr error
+		expect                               string
+	}{
+		{storage.ErrHostCommit, nil, storage.ErrHostCommit, storagehostmanager.TransferErrorHostCommit},
+		{storage.ErrHostNegotiate, storage.ErrHostNegotiate, nil, storagehostmanager.TransferErrorHostNegotiate},
+		{errInvalid, errInvalid, nil, storagehostmanager.TransferErrorInvalidResponse},
+		{storage.ErrHostBusyHandleReq, nil, nil, storagehostmanager.TransferErrorHostBusy},
+		{errTimeout, nil, nil, storagehostmanager.TransferErrorConnection},
+	}
+	for i, test := range tests {
+		if got := transferErrorType(test.err, test.hostNegotiateErr, test.hostCommitErr); got != test.expect {
+			t.Errorf("test %d: unexpected error type. Got %v, Expect %v", i, got, test.expect)
+		}
+	}
+}
@@ -5,7 +5,9 @@
 package storageclient
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 
@@ -49,33 +51,12 @@ func (client *StorageClient) Upload(up storage.FileUploadParams) error {
 	//	}
 	//}
 
-	// Setup ECTypeStandard's ErasureCode with default params
-	if up.ErasureCode == nil {
-		up.ErasureCode, _ = erasurecode.New(erasurecode.ECTypeStandard, storage.DefaultMinSectors, storage.DefaultNumSectors)
-	}
-
-	numContracts := uint64(len(client.contractManager.GetStorageContractSet().Contracts()))
-	// requiredContracts = ceil(min + redundant/2)
-	requiredContracts := math.Ceil(float64(up.ErasureCode.NumSectors()+up.ErasureCode.MinSectors()) / 2)
-	if numContracts < uint64(requiredContracts) {
-		return fmt.Errorf("not enough contracts to upload file: got %v, needed %v", numContracts, (up.ErasureCode.NumSectors()+up.ErasureCode.MinSectors())/2)
-	}
-
-	dirDxPath := up.DxPath
-
-	// Try to create the directory. If ErrPathOverload is returned it already exists
-	dxDirEntry, err := client.fileSystem.NewDxDir(dirDxPath)
-
-	if err != os.ErrExist && err != nil {
-		return fmt.Errorf("unable to create dx directory for new file, error: %v", err)
-	} else if err == nil {
-		if err := dxDirEntry.Close(); err != nil {
-			return err
-		}
+	dirDxPath, err := client.prepareUpload(&up)
+	if err != nil {
+		return err
 	}
-	//client.log.Error("test error for NewDxDir in upload", "error", err)
 
-	cipherKey, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	cipherKey, err := crypto.GenerateCipherKey(up.CipherCode)
 	if err != nil {
 		return fmt.Errorf("generate cipher key error: %v", err)
 	}
@@ -108,3 +89,185 @@ func (client *StorageClient) Upload(up storage.FileUploadParams) error {
 	}
 	return nil
 }
+
+// UploadStream uploads the data read from r until EOF as the file at up.DxPath, without a local
+// source file. The segments are erasure coded, encrypted and uploaded as the data arrives, and the
+// file size is finalized at EOF. Since no local copy exists, the file is repaired by downloading
+// the data from the storage hosts. It blocks until all segments are recoverable from the hosts
+func (client *StorageClient) UploadStream(up storage.FileUploadParams, r io.Reader) error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	dirDxPath, err := client.prepareUpload(&up)
+	if err != nil {
+		return err
+	}
+
+	cipherKey, err := crypto.GenerateCipherKey(up.CipherCode)
+	if err != nil {
+		return fmt.Errorf("generate cipher key error: %v", err)
+	}
+
+	// Create the DxFile without source path. The file size is increased as the data arrives
+	entry, err := client.fileSystem.NewDxFile(up.DxPath, "", false, up.ErasureCode, cipherKey, 0, StreamUploadFileMode)
+	if err != nil {
+		return fmt.Errorf("could not create a new dx file, error: %v", err)
+	}
+	defer entry.Close()
+
+	hosts := client.refreshHostsAndWorkers()
+	if err = client.streamSegments(entry, hosts, r); err != nil {
+		// the data of the file is not recoverable, remove the file
+		if deleteErr := entry.Delete(); deleteErr != nil {
+			client.log.Warn("failed to delete the file of failed streaming upload", "dxPath", up.DxPath, "err", deleteErr)
+		}
+		return err
+	}
+
+	// Update the health of the DxFile directory recursively to ensure the health is updated with the new file
+	go client.fileSystem.InitAndUpdateDirMetadata(dirDxPath)
+	return nil
+}
+
+// prepareUpload sets the default erasure code and cipher of the upload, checks whether there are
+// enough contracts for the upload, and creates the parent directory of the new file. Return the
+// path of the parent directory
+func (client *StorageClient) prepareUpload(up *storage.FileUploadParams) (storage.DxPath, error) {
+	// Setup ECTypeStandard's ErasureCode with default params
+	if up.ErasureCode == nil {
+		up.ErasureCode, _ = erasurecode.New(erasurecode.ECTypeStandard, storage.DefaultMinSectors, storage.DefaultNumSectors)
+	}
+	if up.CipherCode == crypto.CipherCodeNotSupport {
+		up.CipherCode = DefaultUploadCipherCode
+	}
+
+	numContracts := uint64(len(client.contractManager.GetStorageContractSet().Contracts()))
+	// requiredContracts = ceil(min + redundant/2)
+	requiredContracts := math.Ceil(float64(up.ErasureCode.NumSectors()+up.ErasureCode.MinSectors()) / 2)
+	if numContracts < uint64(requiredContracts) {
+		return storage.DxPath{}, fmt.Errorf("not enough contracts to upload file: got %v, needed %v", numContracts, (up.ErasureCode.NumSectors()+up.ErasureCode.MinSectors())/2)
+	}
+
+	// Try to create the parent directory. If ErrPathOverload is returned it already exists
+	dirDxPath, err := up.DxPath.Parent()
+	if err != nil {
+		return storage.DxPath{}, err
+	}
+	dxDirEntry, err := client.fileSystem.NewDxDir(dirDxPath)
+
+	if err != os.ErrExist && err != nil {
+		return storage.DxPath{}, fmt.Errorf("unable to create dx directory for new file, error: %v", err)
+	} else if err == nil {
+		if err := dxDirEntry.Close(); err != nil {
+			return storage.DxPath{}, err
+		}
+	}
+	return dirDxPath, nil
+}
+
+// streamSegments reads the data from r segment by segment. For each segment, the file size is
+// increased, and the segment is pushed to the upload heap along with its data. At most
+// StreamUploadSegmentWindow segments are uploading at the same time. The memory of the data
+// buffer is requested from the memory manager, and is counted as part of the memory needed
+// by the segment
+func (client *StorageClient) streamSegments(entry *dxfile.FileSetEntryWithID, hosts map[string]struct{}, r io.Reader) error {
+	ec, err := entry.ErasureCode()
+	if err != nil {
+		return err
+	}
+	key, err := entry.CipherKey()
+	if err != nil {
+		return err
+	}
+	segmentSize := entry.SegmentSize()
+
+	var fileSize uint64
+	var uploading []*unfinishedUploadSegment
+	for index := 0; ; index++ {
+		if !client.memoryManager.Request(segmentSize, false) {
+			return errors.New("can't obtain enough memory")
+		}
+		data := make([]byte, segmentSize)
+		n, err := io.ReadFull(r, data)
+		if err == io.EOF {
+			client.memoryManager.Return(segmentSize)
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			client.memoryManager.Return(segmentSize)
+			return fmt.Errorf("failed to read the upload data: %v", err)
+		}
+		fileSize += uint64(n)
+
+		uc, err := client.pushStreamSegment(entry, index, fileSize, hosts, ec, key, data)
+		if err != nil {
+			client.memoryManager.Return(segmentSize)
+			return err
+		}
+		select {
+		case client.uploadHeap.segmentComing <- struct{}{}:
+		default:
+		}
+
+		uploading = append(uploading, uc)
+		if len(uploading) >= StreamUploadSegmentWindow {
+			if err := client.waitStreamSegment(uploading[0]); err != nil {
+				return err
+			}
+			uploading = uploading[1:]
+		}
+		if uint64(n) < segmentSize {
+			break
+		}
+	}
+	if fileSize == 0 {
+		return errors.New("no data to upload")
+	}
+
+	for _, uc := range uploading {
+		if err := client.waitStreamSegment(uc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pushStreamSegment increases the file size and pushes the new segment with the data to the
+// upload heap. Both are done under the heap lock, so that the repair loop, which might find the
+// new segment once the file size is increased, could not push the segment without the data first
+func (client *StorageClient) pushStreamSegment(entry *dxfile.FileSetEntryWithID, index int, fileSize uint64, hosts map[string]struct{}, ec erasurecode.ErasureCoder, key crypto.CipherKey, data []byte) (*unfinishedUploadSegment, error) {
+	client.uploadHeap.mu.Lock()
+	defer client.uploadHeap.mu.Unlock()
+
+	if err := entry.SetFileSize(fileSize); err != nil {
+		return nil, err
+	}
+	uc := newUnfinishedUploadSegment(entry, index, hosts, ec, key)
+	uc.logicalSegmentData = [][]byte{data}
+	uc.memoryAcquired = uint64(len(data))
+	uc.done = make(chan struct{})
+	if !client.uploadHeap.pushLocked(uc) {
+		return nil, fmt.Errorf("segment %v is already uploading", index)
+	}
+	return uc, nil
+}
+
+// waitStreamSegment blocks until the segment of the streaming upload is done, and checks whether
+// the segment is recoverable from the storage hosts
+func (client *StorageClient) waitStreamSegment(uc *unfinishedUploadSegment) error {
+	select {
+	case <-uc.done:
+	case <-client.tm.StopChan():
+		return errors.New("upload is shutdown")
+	}
+
+	uc.mu.Lock()
+	sectorsCompletedNum := uc.sectorsCompletedNum
+	uc.mu.Unlock()
+	if sectorsCompletedNum < uc.sectorsMinNeedNum {
+		return fmt.Errorf("segment %v is not recoverable: %v sectors uploaded, %v needed", uc.index, sectorsCompletedNum, uc.sectorsMinNeedNum)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@
 package storageclient
 
 import (
+	"bytes"
 	"encoding/binary"
 	"io"
 	"io/ioutil"
@@ -423,3 +424,115 @@ func getDxPathFromPath(root string, path string) storage.DxPath {
 	}
 	return storage.RootDxPath()
 }
+
+// TestStreamSegments test the data read from the stream are pushed to the upload heap segment
+// by segment, and the file size is finalized at EOF
+func TestStreamSegments(t *testing.T) {
+	storage.ENV = storage.EnvTest
+
+	sct := newStorageClientTester(t)
+	defer sct.Client.Close()
+
+	data := generateRandomBytes(9)
+	entry := newStreamFileEntry(t, sct.Client)
+	defer entry.Close()
+	segmentSize := entry.SegmentSize()
+	numSegments := int((uint64(len(data)) + segmentSize - 1) / segmentSize)
+
+	// mock the upload loop, which uploads all sectors of the segments
+	var received []byte
+	uploaded := make(chan struct{})
+	go func() {
+		defer close(uploaded)
+		for i := 0; i != numSegments; i++ {
+			uc := popStreamSegment(t, sct.Client)
+			received = append(received, uc.logicalSegmentData[0]...)
+			if uc.memoryAcquired != segmentSize {
+				t.Errorf("memory of the segment data not acquired: %v", uc.memoryAcquired)
+			}
+			sct.Client.returnAcquiredMemory(uc)
+			uc.sectorsCompletedNum = uc.sectorsAllNeedNum
+			uc.notifyDone()
+		}
+	}()
+	if err := sct.Client.streamSegments(entry, make(map[string]struct{}), bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	<-uploaded
+	if entry.FileSize() != uint64(len(data)) || entry.NumSegments() != numSegments {
+		t.Errorf("unexpected file size %v and number of segments %v", entry.FileSize(), entry.NumSegments())
+	}
+	if !bytes.Equal(received[:len(data)], data) {
+		t.Errorf("data of the segments not expected")
+	}
+
+	// The streaming upload fails if the segment is not recoverable
+	entry2 := newStreamFileEntry(t, sct.Client)
+	defer entry2.Close()
+	go func() {
+		uc := popStreamSegment(t, sct.Client)
+		sct.Client.returnAcquiredMemory(uc)
+		uc.notifyDone()
+	}()
+	if err := sct.Client.streamSegments(entry2, make(map[string]struct{}), bytes.NewReader(data[:1024])); err == nil {
+		t.Errorf("streaming upload with unrecoverable segment should return an error")
+	}
+
+	// The segment without data pushed by the repair loop once the file size is increased is
+	// rejected, and the segment with data is kept
+	entry3 := newStreamFileEntry(t, sct.Client)
+	defer entry3.Close()
+	ec, err := entry3.ErasureCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := entry3.CipherKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc, err := sct.Client.pushStreamSegment(entry3, 0, 1024, make(map[string]struct{}), ec, key, data[:1024])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sct.Client.uploadHeap.push(newUnfinishedUploadSegment(entry3, 0, make(map[string]struct{}), ec, key)) {
+		t.Errorf("segment without data should not be pushed after the streaming segment")
+	}
+	if popped := popStreamSegment(t, sct.Client); popped != uc {
+		t.Errorf("segment with data of the streaming upload should be kept in the heap")
+	}
+	sct.Client.returnAcquiredMemory(uc)
+}
+
+// newStreamFileEntry creates an empty DxFile without local source path
+func newStreamFileEntry(t *testing.T, client *StorageClient) *dxfile.FileSetEntryWithID {
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ck, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := client.fileSystem.NewDxFile(randomDxPath(), "", false, ec, ck, 0, StreamUploadFileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+// popStreamSegment waits for the segment of the streaming upload pushed to the upload heap
+func popStreamSegment(t *testing.T, client *StorageClient) *unfinishedUploadSegment {
+	timeout := time.After(10 * time.Second)
+	for {
+		if uc := client.uploadHeap.pop(); uc != nil {
+			return uc
+		}
+		select {
+		case <-client.uploadHeap.segmentComing:
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Error("timeout waiting for the segment")
+			return &unfinishedUploadSegment{}
+		}
+	}
+}
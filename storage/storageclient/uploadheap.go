@@ -16,7 +16,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
 )
@@ -33,12 +35,17 @@ const (
 
 // uploadSegmentHeap is a min-heap of priority-sorted segments that need to be either uploaded or repaired
 // The rules of priority:
-//   1) stuck first
-//   2) the lower completion percentage, the more forward when they have the same stuck status
+//   1) the higher file priority first
+//   2) stuck first when they have the same file priority
+//   3) the lower completion percentage, the more forward when they have the same stuck status
 type uploadSegmentHeap []*unfinishedUploadSegment
 
 func (uch uploadSegmentHeap) Len() int { return len(uch) }
 func (uch uploadSegmentHeap) Less(i, j int) bool {
+	if uch[i].priority != uch[j].priority {
+		return uch[i].priority > uch[j].priority
+	}
+
 	if uch[i].stuck == uch[j].stuck {
 		return float64(uch[i].sectorsCompletedNum)/float64(uch[i].sectorsAllNeedNum) < float64(uch[j].sectorsCompletedNum)/float64(uch[j].sectorsAllNeedNum)
 	}
@@ -82,18 +89,23 @@ func (uh *uploadHeap) len() int {
 }
 
 func (uh *uploadHeap) push(uuc *unfinishedUploadSegment) bool {
-	var added bool
 	uh.mu.Lock()
-	_, exists := uh.pendingSegments[uuc.id]
-	if !exists {
-		uh.pendingSegments[uuc.id] = struct{}{}
-		heap.Push(&uh.heap, uuc)
-		added = true
-	}
+	added := uh.pushLocked(uuc)
 	uh.mu.Unlock()
 	return added
 }
 
+// pushLocked pushes the segment to the heap if the segment is not pending. The caller shall
+// hold the heap lock
+func (uh *uploadHeap) pushLocked(uuc *unfinishedUploadSegment) bool {
+	if _, exists := uh.pendingSegments[uuc.id]; exists {
+		return false
+	}
+	uh.pendingSegments[uuc.id] = struct{}{}
+	heap.Push(&uh.heap, uuc)
+	return true
+}
+
 func (uh *uploadHeap) pop() (uc *unfinishedUploadSegment) {
 	uh.mu.Lock()
 	if len(uh.heap) > 0 {
@@ -104,6 +116,71 @@ func (uh *uploadHeap) pop() (uc *unfinishedUploadSegment) {
 	return uc
 }
 
+// purge removes the segments of the paused or deleted files from the heap. The segments
+// whose data is held in memory by the streaming upload are kept
+func (uh *uploadHeap) purge() (removed []*unfinishedUploadSegment) {
+	uh.mu.Lock()
+	defer uh.mu.Unlock()
+
+	kept := uh.heap[:0]
+	for _, uc := range uh.heap {
+		if uc.logicalSegmentData == nil && (uc.fileEntry.Deleted() || uc.fileEntry.UploadPaused()) {
+			delete(uh.pendingSegments, uc.id)
+			removed = append(removed, uc)
+			continue
+		}
+		kept = append(kept, uc)
+	}
+	uh.heap = kept
+	heap.Init(&uh.heap)
+	return
+}
+
+// reprioritize reloads the file priority of the segments in the heap and fixes the order
+func (uh *uploadHeap) reprioritize() {
+	uh.mu.Lock()
+	defer uh.mu.Unlock()
+
+	for _, uc := range uh.heap {
+		uc.priority = uc.fileEntry.Priority()
+	}
+	heap.Init(&uh.heap)
+}
+
+// newUnfinishedUploadSegment creates the unfinishedUploadSegment of the segment index within
+// the file, with all the hosts unused
+func newUnfinishedUploadSegment(entry *dxfile.FileSetEntryWithID, index int, hosts map[string]struct{}, ec erasurecode.ErasureCoder, key crypto.CipherKey) *unfinishedUploadSegment {
+	uc := &unfinishedUploadSegment{
+		fileEntry: entry.CopyEntry(),
+
+		id: uploadSegmentID{
+			fid:   entry.UID(),
+			index: uint64(index),
+		},
+
+		index:  uint64(index),
+		length: entry.SegmentSize(),
+		offset: int64(uint64(index) * entry.SegmentSize()),
+
+		memoryNeeded:      entry.SectorSize()*uint64(ec.NumSectors()+ec.MinSectors()) + uint64(ec.NumSectors())*uint64(key.Overhead()),
+		sectorsMinNeedNum: int(ec.MinSectors()),
+		sectorsAllNeedNum: int(ec.NumSectors()),
+		stuck:             entry.GetStuckByIndex(index),
+		priority:          entry.Priority(),
+
+		physicalSegmentData: make([][]byte, ec.NumSectors()),
+
+		sectorSlotsStatus: make([]bool, ec.NumSectors()),
+		unusedHosts:       make(map[string]struct{}),
+	}
+
+	// Every Segment can have a different set of unused hosts.
+	for host := range hosts {
+		uc.unusedHosts[host] = struct{}{}
+	}
+	return uc
+}
+
 func (client *StorageClient) createUnfinishedSegments(entry *dxfile.FileSetEntryWithID, hosts map[string]struct{}, target uploadTarget, hostHealthInfoTable storage.HostHealthInfoTable) ([]*unfinishedUploadSegment, error) {
 	ec, err := entry.ErasureCode()
 	if err != nil {
@@ -154,33 +231,7 @@ func (client *StorageClient) createUnfinishedSegments(entry *dxfile.FileSetEntry
 		if err != nil {
 			return nil, fmt.Errorf("cannot create erasure code: %v", err)
 		}
-		newUnfinishedSegments[i] = &unfinishedUploadSegment{
-			fileEntry: entry.CopyEntry(),
-
-			id: uploadSegmentID{
-				fid:   fid,
-				index: uint64(index),
-			},
-
-			index:  uint64(index),
-			length: entry.SegmentSize(),
-			offset: int64(uint64(index) * entry.SegmentSize()),
-
-			memoryNeeded:      entry.SectorSize()*uint64(ec.NumSectors()+ec.MinSectors()) + uint64(ec.NumSectors())*uint64(key.Overhead()),
-			sectorsMinNeedNum: int(ec.MinSectors()),
-			sectorsAllNeedNum: int(ec.NumSectors()),
-			stuck:             entry.GetStuckByIndex(index),
-
-			physicalSegmentData: make([][]byte, ec.NumSectors()),
-
-			sectorSlotsStatus: make([]bool, ec.NumSectors()),
-			unusedHosts:       make(map[string]struct{}),
-		}
-
-		// Every Segment can have a different set of unused hosts.
-		for host := range hosts {
-			newUnfinishedSegments[i].unusedHosts[host] = struct{}{}
-		}
+		newUnfinishedSegments[i] = newUnfinishedUploadSegment(entry, index, hosts, ec, key)
 	}
 
 	// Iterate through the sectors of all segments of the file and mark which
@@ -398,6 +449,15 @@ func (client *StorageClient) openDxFile(path storage.DxPath, target uploadTarget
 		return nil, err
 	}
 
+	// Ignore the files whose upload is paused
+	if file.UploadPaused() {
+		err := file.Close()
+		if err != nil {
+			client.log.Error("Could not close file", "err", err)
+		}
+		return nil, err
+	}
+
 	// For normal repairs, ignore files that don't have any unstuck segments
 	if target == targetUnstuckSegments && file.NumSegments() == file.NumStuckSegments() {
 		err := file.Close()
@@ -412,8 +472,9 @@ func (client *StorageClient) openDxFile(path storage.DxPath, target uploadTarget
 
 // doProcessNextSegment takes the next segment from the segment heap and prepares it for upload
 func (client *StorageClient) doProcessNextSegment(uuc *unfinishedUploadSegment) error {
-	// Block until there is enough memory, and then upload segment asynchronously
-	if !client.memoryManager.Request(uuc.memoryNeeded, false) {
+	// Block until there is enough memory, and then upload segment asynchronously. The memory
+	// acquired before the segment is queued is part of the memory needed
+	if !client.memoryManager.Request(uuc.memoryNeeded-uuc.memoryAcquired, false) {
 		return errors.New("can't obtain enough memory")
 	}
 
@@ -470,6 +531,7 @@ func (client *StorageClient) uploadOrRepair() {
 		client.lock.Unlock()
 		if availableWorkers < nextSegment.sectorsMinNeedNum {
 			client.log.Info("Setting segment as stuck because there are not enough good workers", "segmentID", nextSegment.id)
+			client.returnAcquiredMemory(nextSegment)
 			err := client.setStuckAndClose(nextSegment, true)
 			if err != nil {
 				client.log.Error("Unable to mark segment as stuck and close", "err", err)
@@ -481,6 +543,7 @@ func (client *StorageClient) uploadOrRepair() {
 		err := client.doProcessNextSegment(nextSegment)
 		if err != nil {
 			client.log.Error("Unable to prepare next segment without issues", "segmentID", nextSegment.id, "err", err)
+			client.returnAcquiredMemory(nextSegment)
 			err = client.setStuckAndClose(nextSegment, true)
 			if err != nil {
 				client.log.Error("Unable to mark segment as stuck and close", "err", err)
@@ -493,7 +556,8 @@ func (client *StorageClient) uploadOrRepair() {
 		if consecutiveSegmentUploads >= MaxConsecutiveSegmentUploads {
 			var stuckSegments []*unfinishedUploadSegment
 			for client.uploadHeap.len() > 0 {
-				if c := client.uploadHeap.pop(); c.stuck {
+				// segments of the streaming upload are kept since their data is only in memory
+				if c := client.uploadHeap.pop(); c.stuck || c.logicalSegmentData != nil {
 					stuckSegments = append(stuckSegments, c)
 				}
 			}
@@ -566,6 +630,11 @@ func (client *StorageClient) uploadLoop() {
 			return
 		}
 
+		// Wait until uploading is allowed by the bandwidth schedule
+		if !client.blockUntilUploadAllowed() {
+			return
+		}
+
 		// Check whether a repair is needed of root dir. If the root dir health is more than
 		// RepairHealthThreshold, it is not necessary to upload any sectors
 		rootMetadata, err := client.dirMetadata(storage.RootDxPath())
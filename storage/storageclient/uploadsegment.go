@@ -45,6 +45,7 @@ type unfinishedUploadSegment struct {
 
 	memoryNeeded   uint64 // memory needed in bytes
 	memoryReleased uint64 // memory that has been returned of memoryNeeded
+	memoryAcquired uint64 // memory of memoryNeeded acquired before the segment is queued
 
 	sectorsMinNeedNum int // number of sectors minimum to recover file
 	sectorsAllNeedNum int // number of sectors of minimum + redundant
@@ -52,6 +53,8 @@ type unfinishedUploadSegment struct {
 	stuck       bool // flag whether the segment was stuck during upload
 	stuckRepair bool // flag if the segment was set 'true' for repair by the stuck loop
 
+	priority storage.FilePriority // scheduling priority of the file the segment belongs to
+
 	// The logical data is the data read from file of user
 	// The physical data is all the sectors encrypted and stored on disk across the network
 	logicalSegmentData  [][]byte
@@ -65,6 +68,21 @@ type unfinishedUploadSegment struct {
 	unusedHosts         map[string]struct{} // hosts that aren't yet storing any sectors or performing any work
 	workersRemain       int                 // number of inactive workers still able to upload a sector
 	workerBackups       []*worker           // workers that can be used if other workers fail
+
+	// done is closed when the segment is released or set stuck, used by the streaming upload
+	// to wait for the segment in memory to be uploaded. nil for other segments
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+// notifyDone closes the done channel of the segment, if any
+func (uc *unfinishedUploadSegment) notifyDone() {
+	if uc.done == nil {
+		return
+	}
+	uc.doneOnce.Do(func() {
+		close(uc.done)
+	})
 }
 
 // notifyBackupWorkers is called when a worker fails to upload a sector, meaning
@@ -291,6 +309,11 @@ func (client *StorageClient) retrieveDataAndDispatchSegment(segment *unfinishedU
 
 // retrieveLogicalSegmentData will get the raw data from disk if possible otherwise queueing a download
 func (client *StorageClient) retrieveLogicalSegmentData(segment *unfinishedUploadSegment) error {
+	// The logical data of the segment from the streaming upload is already in memory
+	if segment.logicalSegmentData != nil {
+		return nil
+	}
+
 	numRedundantSectors := float64(segment.sectorsAllNeedNum - segment.sectorsMinNeedNum)
 	minMissingSectorsToDownload := int(numRedundantSectors * RemoteRepairDownloadThreshold)
 	needDownload := segment.sectorsCompletedNum+minMissingSectorsToDownload < segment.sectorsAllNeedNum
@@ -361,6 +384,7 @@ func (client *StorageClient) cleanupUploadSegment(uc *unfinishedUploadSegment) {
 		client.uploadHeap.mu.Lock()
 		delete(client.uploadHeap.pendingSegments, uc.id)
 		client.uploadHeap.mu.Unlock()
+		uc.notifyDone()
 	}
 
 	uc.memoryReleased += uint64(memoryReleased)
@@ -383,8 +407,20 @@ func (client *StorageClient) cleanupUploadSegment(uc *unfinishedUploadSegment) {
 
 }
 
+// returnAcquiredMemory returns the memory acquired before the segment is queued. It is used when
+// the segment is dropped from the heap before being processed
+func (client *StorageClient) returnAcquiredMemory(uc *unfinishedUploadSegment) {
+	if uc.memoryAcquired == 0 {
+		return
+	}
+	client.memoryManager.Return(uc.memoryAcquired)
+	uc.memoryAcquired = 0
+}
+
 // setStuckAndClose sets the unfinishedUploadSegment's stuck status
 func (client *StorageClient) setStuckAndClose(uc *unfinishedUploadSegment, stuck bool) error {
+	defer uc.notifyDone()
+
 	err := uc.fileEntry.SetStuckByIndex(int(uc.index), stuck)
 	if err != nil {
 		return fmt.Errorf("unable to update Segment stuck status for file %v: %v", uc.fileEntry.DxPath(), err)
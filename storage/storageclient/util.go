@@ -7,6 +7,7 @@ package storageclient
 import (
 	"context"
 	"math/big"
+	"math/bits"
 	"sort"
 	"time"
 
@@ -199,6 +200,29 @@ func (client *StorageClient) SelfEnodeURL() string {
 	return client.ethBackend.SelfEnodeURL()
 }
 
+// estimateDownloadCost calculates the price of downloading the sector ranges from the host.
+// Each distinct sector is charged with one sector access, and each range is charged with its
+// length plus the worst-case merkle proof size if merkle proof is requested
+func estimateDownloadCost(hostInfo *storage.HostInfo, sectors []storage.DownloadRequestSector, merkleProof bool) common.BigInt {
+	var estProofHashes uint64
+	if merkleProof {
+		// use the worst-case proof size of 2*tree depth,
+		// which occurs when proving across the two leaves in the center of the tree
+		estProofHashes = uint64(2 * bits.Len64(storage.SectorSize/storage.SegmentSize))
+	}
+
+	var estBandwidth uint64
+	sectorAccesses := make(map[common.Hash]struct{})
+	for _, sector := range sectors {
+		estBandwidth += uint64(sector.Length) + estProofHashes*uint64(storage.HashSize)
+		sectorAccesses[sector.MerkleRoot] = struct{}{}
+	}
+
+	bandwidthPrice := hostInfo.DownloadBandwidthPrice.MultUint64(estBandwidth)
+	sectorAccessPrice := hostInfo.SectorAccessPrice.MultUint64(uint64(len(sectorAccesses)))
+	return hostInfo.BaseRPCPrice.Add(bandwidthPrice).Add(sectorAccessPrice)
+}
+
 // CalculateProofRanges will calculate the proof ranges which is used to verify a
 // pre-modification Merkle diff proof for the specified actions.
 func CalculateProofRanges(actions []storage.UploadAction, oldNumSectors uint64) []merkle.SubTreeLimit {
@@ -0,0 +1,102 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// versionRepairLoop periodically checks the health of the retained versions of the dxfiles,
+// and pushes the versions in need of repair to the upload heap. The versions are not covered
+// by the directory metadata, thus they are walked separately from the live dxfiles
+func (client *StorageClient) versionRepairLoop() {
+	err := client.tm.Add()
+	if err != nil {
+		return
+	}
+	defer client.tm.Done()
+
+	for {
+		select {
+		case <-client.tm.StopChan():
+			return
+		case <-time.After(HealthCheckInterval):
+		}
+
+		// Wait until the storage client is online and uploading is allowed to proceed.
+		if !client.blockUntilOnline() || !client.blockUntilUploadAllowed() {
+			return
+		}
+
+		client.repairVersions()
+	}
+}
+
+// repairVersions pushes the retained versions whose health is below the repair threshold,
+// or which have stuck segments, to the upload heap
+func (client *StorageClient) repairVersions() {
+	paths, err := client.fileSystem.VersionPaths()
+	if err != nil {
+		client.log.Error("failed to list the versions", "err", err)
+		return
+	}
+	table := client.contractManager.HostHealthMap()
+
+	var versions []*dxfile.FileSetEntryWithID
+	for _, path := range paths {
+		file, err := client.fileSystem.OpenVersion(path)
+		if err != nil {
+			client.log.Warn("failed to open the version for health check", "path", path, "err", err)
+			continue
+		}
+		if health, _, numStuckSegments := file.Health(table); health >= dxfile.RepairHealthThreshold && numStuckSegments == 0 {
+			if err := file.Close(); err != nil {
+				client.log.Warn("failed to close the version", "path", path, "err", err)
+			}
+			continue
+		}
+		versions = append(versions, file)
+	}
+	if len(versions) == 0 {
+		return
+	}
+	client.pushVersionsToSegmentHeap(versions, client.refreshHostsAndWorkers(), table)
+}
+
+// pushVersionsToSegmentHeap pushes the segments of the versions to the upload heap and closes
+// the versions. The stuck loop only walks the directories, thus the stuck segments of the
+// versions are retried each time the versions are pushed
+func (client *StorageClient) pushVersionsToSegmentHeap(versions []*dxfile.FileSetEntryWithID, hosts map[string]struct{}, table storage.HostHealthInfoTable) {
+	defer func() {
+		for _, file := range versions {
+			if err := file.Close(); err != nil {
+				client.log.Warn("failed to close the version", "dxPath", file.DxPath(), "err", err)
+			}
+		}
+	}()
+
+	for _, file := range versions {
+		for i := 0; i < file.NumSegments(); i++ {
+			if !file.GetStuckByIndex(i) {
+				continue
+			}
+			if err := file.SetStuckByIndex(i, false); err != nil {
+				client.log.Warn("failed to unmark the stuck segment of the version", "dxPath", file.DxPath(), "err", err)
+			}
+		}
+	}
+	if err := client.createAndPushSegments(versions, hosts, targetUnstuckSegments, table); err != nil {
+		client.log.Warn("failed to push the versions to the upload heap", "err", err)
+		return
+	}
+
+	select {
+	case client.uploadHeap.segmentComing <- struct{}{}:
+	default:
+	}
+}
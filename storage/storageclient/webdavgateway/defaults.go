@@ -0,0 +1,38 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package webdavgateway
+
+// Default settings of the WebDAV gateway
+const (
+	DefaultHost = "127.0.0.1"
+	DefaultPort = 9100
+)
+
+// Files and directories related constant
+const (
+	PersistDirectory = "webdav"
+	UsersFilename    = "users.json"
+
+	// tempDirectory stores the temporary files of the data being written, which are
+	// removed once uploaded
+	tempDirectory = "tmp"
+)
+
+const (
+	// readBufferSize is the size of the data downloaded at once when reading a file. The
+	// small reads of the http server are served from the buffered data
+	readBufferSize = 1 << 22
+
+	// authRealm is the realm of the basic authentication challenge
+	authRealm = "DxChain WebDAV"
+
+	defaultContentType = "application/octet-stream"
+)
+
+// DefaultConfig is the default configuration of the WebDAV gateway
+var DefaultConfig = Config{
+	Host: DefaultHost,
+	Port: DefaultPort,
+}
@@ -0,0 +1,293 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package webdavgateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+var (
+	errReadOnly  = errors.New("the file is opened for reading")
+	errWriteOnly = errors.New("the file is opened for writing")
+	errNegSeek   = errors.New("seek to a negative position")
+)
+
+// fileInfo is the os.FileInfo of the DxFile or DxDir
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+// Name returns the base name of the file
+func (fi *fileInfo) Name() string { return fi.name }
+
+// Size returns the size of the file
+func (fi *fileInfo) Size() int64 { return fi.size }
+
+// Mode returns the file mode bits
+func (fi *fileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0700
+	}
+	return 0600
+}
+
+// ModTime returns the modification time
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+
+// IsDir returns whether the file is a directory
+func (fi *fileInfo) IsDir() bool { return fi.dir }
+
+// Sys returns nil since there is no underlying data source
+func (fi *fileInfo) Sys() interface{} { return nil }
+
+// ContentType implements webdav.ContentTyper, so that the content type is decided by the
+// file extension instead of downloading the file to sniff the content
+func (fi *fileInfo) ContentType(ctx context.Context) (string, error) {
+	return contentType(fi.name), nil
+}
+
+// dirFile is the webdav.File of the DxDir, which could only be listed
+type dirFile struct {
+	g      *Gateway
+	dxPath storage.DxPath
+	info   *fileInfo
+
+	// entries is the directory entries not yet returned by Readdir
+	entries []os.FileInfo
+	listed  bool
+}
+
+// Close closes the directory
+func (f *dirFile) Close() error { return nil }
+
+// Read is not supported by the directory
+func (f *dirFile) Read(p []byte) (int, error) { return 0, errIsDir }
+
+// Seek is not supported by the directory
+func (f *dirFile) Seek(offset int64, whence int) (int64, error) { return 0, errIsDir }
+
+// Write is not supported by the directory
+func (f *dirFile) Write(p []byte) (int, error) { return 0, errIsDir }
+
+// Stat returns the information of the directory
+func (f *dirFile) Stat() (os.FileInfo, error) { return f.info, nil }
+
+// Readdir returns at most count entries of the directory. If count is not positive,
+// all remaining entries are returned
+func (f *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.listed {
+		entries, err := f.list()
+		if err != nil {
+			return nil, err
+		}
+		f.entries, f.listed = entries, true
+	}
+	if count <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(f.entries) {
+		count = len(f.entries)
+	}
+	entries := f.entries[:count]
+	f.entries = f.entries[count:]
+	return entries, nil
+}
+
+// list returns the information of the sub directories and files of the directory
+func (f *dirFile) list() ([]os.FileInfo, error) {
+	listing, err := f.g.fs.ListDir(f.dxPath, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]os.FileInfo, 0, len(listing.Dirs)+len(listing.Files))
+	for _, dir := range listing.Dirs {
+		entries = append(entries, &fileInfo{
+			name:    davName(dir.DxPath),
+			modTime: dir.TimeModify,
+			dir:     true,
+		})
+	}
+	for _, file := range listing.Files {
+		dxPath, err := storage.NewDxPath(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := f.g.fileInfo(dxPath)
+		if os.IsNotExist(err) {
+			// deleted after listed
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, info)
+	}
+	return entries, nil
+}
+
+// readFile is the webdav.File of the DxFile opened for reading. The data is downloaded
+// from the storage hosts on demand, readBufferSize at a time
+type readFile struct {
+	g      *Gateway
+	dxPath storage.DxPath
+	info   *fileInfo
+	pos    int64
+
+	// buf is the downloaded data starting from bufOffset
+	buf       []byte
+	bufOffset int64
+}
+
+// Close closes the file and releases the buffered data
+func (f *readFile) Close() error {
+	f.buf = nil
+	return nil
+}
+
+// Read reads the data at the current position. If the data is not buffered, the data
+// starting from the current position is downloaded
+func (f *readFile) Read(p []byte) (int, error) {
+	if f.pos >= f.info.size {
+		return 0, io.EOF
+	}
+	if f.pos < f.bufOffset || f.pos >= f.bufOffset+int64(len(f.buf)) {
+		if err := f.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, f.buf[f.pos-f.bufOffset:])
+	f.pos += int64(n)
+	return n, nil
+}
+
+// fill downloads the data starting from the current position to the buffer
+func (f *readFile) fill() error {
+	length := f.info.size - f.pos
+	if length > readBufferSize {
+		length = readBufferSize
+	}
+	buf := make([]byte, length)
+	if err := f.g.backend.DownloadRangeSync(f.dxPath, bufferWriterAt(buf), uint64(f.pos), uint64(length)); err != nil {
+		return err
+	}
+	f.buf, f.bufOffset = buf, f.pos
+	return nil
+}
+
+// Seek sets the position of the next Read
+func (f *readFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += f.info.size
+	default:
+		return 0, os.ErrInvalid
+	}
+	if offset < 0 {
+		return 0, errNegSeek
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+// Write is not supported by the file opened for reading
+func (f *readFile) Write(p []byte) (int, error) { return 0, errReadOnly }
+
+// Readdir is not supported by the file
+func (f *readFile) Readdir(count int) ([]os.FileInfo, error) { return nil, errNotDir }
+
+// Stat returns the information of the file
+func (f *readFile) Stat() (os.FileInfo, error) { return f.info, nil }
+
+// writeFile is the webdav.File of the DxFile opened for writing. The data is staged in
+// the temporary file, and uploaded as the DxFile when closed
+type writeFile struct {
+	g      *Gateway
+	dxPath storage.DxPath
+	file   *os.File
+}
+
+// Close uploads the staged data as the DxFile
+func (f *writeFile) Close() (err error) {
+	tempPath := f.file.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(tempPath)
+		}
+	}()
+	info, err := f.file.Stat()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return errEmptyFile
+	}
+	return f.g.commitFile(f.dxPath, tempPath)
+}
+
+// Write writes the data to the staged file
+func (f *writeFile) Write(p []byte) (int, error) { return f.file.Write(p) }
+
+// Read is not supported by the file opened for writing
+func (f *writeFile) Read(p []byte) (int, error) { return 0, errWriteOnly }
+
+// Seek is not supported by the file opened for writing
+func (f *writeFile) Seek(offset int64, whence int) (int64, error) { return 0, errWriteOnly }
+
+// Readdir is not supported by the file
+func (f *writeFile) Readdir(count int) ([]os.FileInfo, error) { return nil, errNotDir }
+
+// Stat returns the information of the staged data
+func (f *writeFile) Stat() (os.FileInfo, error) {
+	info, err := f.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{
+		name:    davName(f.dxPath),
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}, nil
+}
+
+// bufferWriterAt is the io.WriterAt writing to the fixed size buffer
+type bufferWriterAt []byte
+
+// WriteAt writes the data to the buffer at offset
+func (b bufferWriterAt) WriteAt(p []byte, offset int64) (int, error) {
+	if offset < 0 || offset+int64(len(p)) > int64(len(b)) {
+		return 0, io.ErrShortWrite
+	}
+	return copy(b[offset:], p), nil
+}
+
+// contentType returns the content type decided by the file extension
+func contentType(name string) string {
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		return ctype
+	}
+	return defaultContentType
+}
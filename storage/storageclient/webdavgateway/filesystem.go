@@ -0,0 +1,284 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package webdavgateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+	"golang.org/x/net/webdav"
+)
+
+var (
+	errInvalidPath   = errors.New("the path is not supported by the DxChain storage")
+	errRootOperation = errors.New("cannot rename or delete the root directory")
+	errEmptyFile     = errors.New("empty files are not supported by the DxChain storage")
+	errPartialWrite  = errors.New("the existing file could only be replaced as a whole")
+	errIsDir         = errors.New("is a directory")
+	errNotDir        = errors.New("not a directory")
+)
+
+// davFileSystem is the webdav.FileSystem backed by the file system of the storage client.
+// The DxDirs and DxFiles are served as the directories and files
+type davFileSystem struct {
+	g *Gateway
+}
+
+// Mkdir creates the DxDir. The parent directory must exist
+func (dfs *davFileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	dxPath, err := davDxPath(name)
+	if err != nil {
+		return err
+	}
+	if _, err = dfs.g.stat(dxPath); err == nil {
+		return os.ErrExist
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if err = dfs.g.checkParentDir(dxPath); err != nil {
+		return err
+	}
+	dir, err := dfs.g.fs.NewDxDir(dxPath)
+	if err != nil {
+		return err
+	}
+	return dir.Close()
+}
+
+// OpenFile opens the DxFile or DxDir for reading. If opened for writing, the data is
+// staged locally, and uploaded as the DxFile when the file is closed. The existing
+// DxFile could only be replaced as a whole
+func (dfs *davFileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	dxPath, err := davDxPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		f, err := dfs.g.createFile(dxPath, flag)
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	info, err := dfs.g.stat(dxPath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &dirFile{g: dfs.g, dxPath: dxPath, info: info}, nil
+	}
+	return &readFile{g: dfs.g, dxPath: dxPath, info: info}, nil
+}
+
+// RemoveAll deletes the DxFile, or the DxDir along with all its contents. Deleting the
+// path not exist is regarded as success
+func (dfs *davFileSystem) RemoveAll(ctx context.Context, name string) error {
+	dxPath, err := davDxPath(name)
+	if err != nil {
+		return err
+	}
+	if dxPath.IsRoot() {
+		return errRootOperation
+	}
+	if _, err = dfs.g.fileInfo(dxPath); err == nil {
+		return dfs.g.fs.RemoveDxFile(dxPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	err = dfs.g.fs.DeleteDir(dxPath)
+	if err == filesystem.ErrDirNotExist {
+		return nil
+	}
+	return err
+}
+
+// Rename renames the DxFile, or the DxDir along with all its contents
+func (dfs *davFileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	prevPath, err := davDxPath(oldName)
+	if err != nil {
+		return err
+	}
+	newPath, err := davDxPath(newName)
+	if err != nil {
+		return err
+	}
+	if prevPath.IsRoot() || newPath.IsRoot() {
+		return errRootOperation
+	}
+	if err = dfs.g.checkParentDir(newPath); err != nil {
+		return err
+	}
+	_, err = dfs.g.fileInfo(prevPath)
+	if os.IsNotExist(err) {
+		err = dfs.g.fs.RenameDir(prevPath, newPath)
+		if err == filesystem.ErrDirNotExist {
+			return os.ErrNotExist
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err = dfs.g.fs.RenameDxFile(prevPath, newPath); err != nil {
+		return err
+	}
+	dfs.g.updateParentMetadata(prevPath, newPath)
+	return nil
+}
+
+// Stat returns the information of the DxFile or DxDir
+func (dfs *davFileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	dxPath, err := davDxPath(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := dfs.g.stat(dxPath)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// stat returns the information of the DxFile or DxDir. os.ErrNotExist is returned if
+// neither exists
+func (g *Gateway) stat(dxPath storage.DxPath) (*fileInfo, error) {
+	if !dxPath.IsRoot() {
+		info, err := g.fileInfo(dxPath)
+		if !os.IsNotExist(err) {
+			return info, err
+		}
+	}
+	listing, err := g.fs.ListDir(dxPath, 0, 1)
+	if err == filesystem.ErrDirNotExist {
+		return nil, os.ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{
+		name:    davName(dxPath),
+		modTime: listing.Dir.TimeModify,
+		dir:     true,
+	}, nil
+}
+
+// fileInfo returns the information of the DxFile
+func (g *Gateway) fileInfo(dxPath storage.DxPath) (*fileInfo, error) {
+	file, err := g.fs.OpenDxFile(dxPath)
+	if err == dxfile.ErrUnknownFile {
+		return nil, os.ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	info := &fileInfo{
+		name:    davName(dxPath),
+		size:    int64(file.FileSize()),
+		modTime: file.TimeModify(),
+	}
+	if err = file.Close(); err != nil {
+		g.log.Warn("failed to close the file", "dxPath", dxPath, "err", err)
+	}
+	return info, nil
+}
+
+// checkParentDir checks the parent directory of the path exists
+func (g *Gateway) checkParentDir(dxPath storage.DxPath) error {
+	parent, err := dxPath.Parent()
+	if err != nil {
+		return err
+	}
+	info, err := g.stat(parent)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return errNotDir
+	}
+	return nil
+}
+
+// createFile creates the file staging the data written, which is uploaded as the DxFile
+// at dxPath when closed
+func (g *Gateway) createFile(dxPath storage.DxPath, flag int) (*writeFile, error) {
+	info, err := g.stat(dxPath)
+	switch {
+	case err == nil && info.IsDir():
+		return nil, errIsDir
+	case err == nil && flag&os.O_EXCL != 0:
+		return nil, os.ErrExist
+	case err == nil && flag&os.O_TRUNC == 0:
+		return nil, errPartialWrite
+	case os.IsNotExist(err) && flag&os.O_CREATE == 0:
+		return nil, err
+	case os.IsNotExist(err):
+		if err = g.checkParentDir(dxPath); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	}
+	f, err := ioutil.TempFile(g.tempDir(), "stage")
+	if err != nil {
+		return nil, err
+	}
+	return &writeFile{g: g, dxPath: dxPath, file: f}, nil
+}
+
+// commitFile uploads the staged file as the DxFile, which replaces the existing DxFile once
+// the upload completes. The staged file is removed afterwards, and the DxFile is repaired
+// from the storage hosts
+func (g *Gateway) commitFile(dxPath storage.DxPath, stagedPath string) error {
+	_, err := g.fs.UploadStagedFile(stagedPath, dxPath, nil, func(uploadPath storage.DxPath, r io.Reader) error {
+		return g.backend.UploadStream(storage.FileUploadParams{
+			DxPath: uploadPath,
+			Mode:   storage.Override,
+		}, r)
+	})
+	return err
+}
+
+// updateParentMetadata updates the metadata of the parent directories of the paths
+func (g *Gateway) updateParentMetadata(paths ...storage.DxPath) {
+	for _, dxPath := range paths {
+		parent, err := dxPath.Parent()
+		if err != nil {
+			continue
+		}
+		if err = g.fs.InitAndUpdateDirMetadata(parent); err != nil {
+			g.log.Warn("InitAndUpdateDirMetadata error", "error", err)
+		}
+	}
+}
+
+// davDxPath returns the DxPath of the slash-separated WebDAV path. The root path is
+// mapped to the root DxPath
+func davDxPath(name string) (storage.DxPath, error) {
+	p := strings.Trim(path.Clean("/"+name), "/")
+	if p == "" {
+		return storage.RootDxPath(), nil
+	}
+	dxPath, err := storage.NewDxPath(p)
+	if err != nil || dxPath.Path != p {
+		return storage.DxPath{}, errInvalidPath
+	}
+	return dxPath, nil
+}
+
+// davName returns the base name of the DxPath
+func davName(dxPath storage.DxPath) string {
+	if dxPath.IsRoot() {
+		return "/"
+	}
+	return path.Base(dxPath.Path)
+}
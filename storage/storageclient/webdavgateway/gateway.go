@@ -0,0 +1,199 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// Package webdavgateway implements the WebDAV server in front of the storage client, so
+// that the DxDirs and DxFiles could be mounted by the WebDAV clients of the operating
+// systems. The data written to a file is staged locally and uploaded when the file is
+// closed, and the data read from a file is downloaded from the storage hosts on demand.
+// The requests are authenticated with the HTTP basic authentication.
+package webdavgateway
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/rpc"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
+	"golang.org/x/net/webdav"
+)
+
+// Config is the configuration of the WebDAV gateway
+type Config struct {
+	// Enabled defines whether the WebDAV gateway is started with the node
+	Enabled bool
+
+	// Host and Port is the listening address of the WebDAV gateway
+	Host string
+	Port int
+
+	// UsersFile is the JSON file mapping from the user name to the password. If empty,
+	// the users.json under the persist directory is used
+	UsersFile string `toml:",omitempty"`
+}
+
+// Backend is the storage client used by the WebDAV gateway
+type Backend interface {
+	// UploadStream uploads the data read from r to the storage hosts, and blocks until
+	// the data is recoverable from the storage hosts
+	UploadStream(up storage.FileUploadParams, r io.Reader) error
+
+	// DownloadRangeSync downloads the data of the file in range, and blocks until
+	// the download is finished
+	DownloadRangeSync(dxPath storage.DxPath, destination io.WriterAt, offset, length uint64) error
+
+	// GetFileSystem returns the file system of the storage client
+	GetFileSystem() filesystem.FileSystem
+}
+
+// Gateway is the WebDAV gateway, which implements node.Service
+type Gateway struct {
+	config     Config
+	persistDir string
+	users      map[string]string
+
+	backend Backend
+	fs      filesystem.FileSystem
+	handler *webdav.Handler
+
+	server   *http.Server
+	listener net.Listener
+
+	log log.Logger
+}
+
+// New creates the WebDAV gateway with the config. The users are loaded from the
+// users file
+func New(config Config, persistDir string, backend Backend) (*Gateway, error) {
+	usersFile := config.UsersFile
+	if usersFile == "" {
+		usersFile = filepath.Join(persistDir, UsersFilename)
+	}
+	users, err := loadUsers(usersFile)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Gateway{
+		config:     config,
+		persistDir: persistDir,
+		users:      users,
+		backend:    backend,
+		fs:         backend.GetFileSystem(),
+		log:        log.New("module", "webdavgateway"),
+	}
+	g.handler = &webdav.Handler{
+		FileSystem: &davFileSystem{g},
+		LockSystem: webdav.NewMemLS(),
+		Logger:     g.logRequest,
+	}
+
+	// clear the temporary files left by the last run
+	if err = os.RemoveAll(g.tempDir()); err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(g.tempDir(), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create the directory %v: %v", g.tempDir(), err)
+	}
+	return g, nil
+}
+
+// Protocols implements node.Service. The WebDAV gateway has no p2p protocol
+func (g *Gateway) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service. The WebDAV gateway has no RPC API
+func (g *Gateway) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, starting to serve the WebDAV requests
+func (g *Gateway) Start(server *p2p.Server) (err error) {
+	endpoint := fmt.Sprintf("%s:%d", g.config.Host, g.config.Port)
+	if g.listener, err = net.Listen("tcp", endpoint); err != nil {
+		return fmt.Errorf("failed to listen on %v: %v", endpoint, err)
+	}
+	g.server = &http.Server{Handler: g}
+	go g.server.Serve(g.listener)
+
+	g.log.Info("WebDAV gateway started", "url", fmt.Sprintf("http://%s", endpoint))
+	return nil
+}
+
+// Stop implements node.Service, closing the http server
+func (g *Gateway) Stop() error {
+	if g.server == nil {
+		return nil
+	}
+	err := g.server.Close()
+	g.log.Info("WebDAV gateway stopped")
+	return err
+}
+
+// ServeHTTP authenticates the request and serves it with the WebDAV handler
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !g.authenticate(r) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", authRealm))
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	// Set the content type in advance, otherwise the http server sniffs the content
+	// type by downloading the beginning of the file
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		w.Header().Set("Content-Type", contentType(r.URL.Path))
+	}
+	g.handler.ServeHTTP(w, r)
+}
+
+// authenticate checks the user name and password of the basic authentication
+func (g *Gateway) authenticate(r *http.Request) bool {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	expect, exists := g.users[user]
+	if !exists {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(password), []byte(expect)) == 1
+}
+
+// logRequest logs the error of serving the WebDAV request
+func (g *Gateway) logRequest(r *http.Request, err error) {
+	if err != nil && !os.IsNotExist(err) {
+		g.log.Warn("failed to serve the WebDAV request", "method", r.Method, "path", r.URL.Path, "err", err)
+	}
+}
+
+// tempDir returns the directory of the temporary files
+func (g *Gateway) tempDir() string {
+	return filepath.Join(g.persistDir, tempDirectory)
+}
+
+// loadUsers loads the users from the JSON file, which is an object mapping from the
+// user name to the password
+func loadUsers(path string) (users map[string]string, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the users file: %v", err)
+	}
+	if err = json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse the users file: %v", err)
+	}
+	for user, password := range users {
+		if user == "" || password == "" {
+			return nil, fmt.Errorf("empty user name or password in %v", path)
+		}
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("no user configured in %v", path)
+	}
+	return users, nil
+}
@@ -0,0 +1,300 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package webdavgateway
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
+	"github.com/DxChainNetwork/godx/storage/storageclient/internal/gatewaytest"
+)
+
+const (
+	testUser     = "user"
+	testPassword = "password"
+)
+
+// multistatus is the PROPFIND response
+type multistatus struct {
+	Responses []struct {
+		Href          string `xml:"href"`
+		ContentLength string `xml:"propstat>prop>getcontentlength"`
+		ContentType   string `xml:"propstat>prop>getcontenttype"`
+	} `xml:"response"`
+}
+
+// TestDavDxPath test converting the WebDAV path to DxPath
+func TestDavDxPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect string
+		err    error
+	}{
+		{"/", "", nil},
+		{"", "", nil},
+		{"/a/b/", "a/b", nil},
+		{"/a/../b", "b", nil},
+		{"/a/.dxdir", "", errInvalidPath},
+	}
+	for _, test := range tests {
+		dxPath, err := davDxPath(test.name)
+		if err != test.err {
+			t.Errorf("%v: expect error %v, got %v", test.name, test.err, err)
+			continue
+		}
+		if err == nil && dxPath.Path != test.expect {
+			t.Errorf("%v: expect DxPath %v, got %v", test.name, test.expect, dxPath.Path)
+		}
+	}
+}
+
+// TestGateway_Auth test the requests without valid basic authentication are rejected
+func TestGateway_Auth(t *testing.T) {
+	_, server := newTestGateway(t)
+	defer server.Close()
+
+	req := newRequest(t, server, "PROPFIND", "/", nil)
+	req.Header.Del("Authorization")
+	resp := sendRequest(t, req)
+	expectStatus(t, resp, http.StatusUnauthorized)
+	if !strings.HasPrefix(resp.Header.Get("WWW-Authenticate"), "Basic") {
+		t.Errorf("unexpected authentication challenge: %v", resp.Header.Get("WWW-Authenticate"))
+	}
+	req = newRequest(t, server, "PROPFIND", "/", nil)
+	req.SetBasicAuth(testUser, "wrong password")
+	expectStatus(t, sendRequest(t, req), http.StatusUnauthorized)
+}
+
+// TestGateway_Files test creating directories, putting, listing, getting, moving and
+// deleting files through the WebDAV gateway
+func TestGateway_Files(t *testing.T) {
+	g, server := newTestGateway(t)
+	defer server.Close()
+
+	expectStatus(t, doRequest(t, server, "MKCOL", "/docs", nil), http.StatusCreated)
+	expectStatus(t, doRequest(t, server, "MKCOL", "/docs", nil), http.StatusMethodNotAllowed)
+	expectStatus(t, doRequest(t, server, "MKCOL", "/no/dir", nil), http.StatusConflict)
+	expectStatus(t, doRequest(t, server, http.MethodPut, "/no/file", []byte("data")), http.StatusConflict)
+	expectStatus(t, doRequest(t, server, http.MethodPut, "/docs/empty", nil), http.StatusMethodNotAllowed)
+
+	files := map[string][]byte{
+		"/docs/a.txt":     []byte("0123456789"),
+		"/docs/b":         []byte("hello"),
+		"/docs/sub/c.txt": []byte("nested"),
+	}
+	expectStatus(t, doRequest(t, server, "MKCOL", "/docs/sub", nil), http.StatusCreated)
+	for name, data := range files {
+		expectStatus(t, doRequest(t, server, http.MethodPut, name, data), http.StatusCreated)
+	}
+	// replace the existing file
+	files["/docs/b"] = []byte("hello world")
+	expectStatus(t, doRequest(t, server, http.MethodPut, "/docs/b", files["/docs/b"]), http.StatusCreated)
+	if staged := stagedFiles(t, g); len(staged) != 0 {
+		t.Errorf("staged files shall be removed once uploaded: %v", staged)
+	}
+
+	// list the directory
+	req := newRequest(t, server, "PROPFIND", "/docs", nil)
+	req.Header.Set("Depth", "1")
+	resp := sendRequest(t, req)
+	expectStatus(t, resp, http.StatusMultiStatus)
+	var ms multistatus
+	if err := xml.Unmarshal(readBody(t, resp), &ms); err != nil {
+		t.Fatal(err)
+	}
+	var hrefs []string
+	for _, r := range ms.Responses {
+		hrefs = append(hrefs, r.Href)
+		if r.Href == "/docs/a.txt" && (r.ContentLength != "10" || !strings.HasPrefix(r.ContentType, "text/plain")) {
+			t.Errorf("unexpected properties of %v: %+v", r.Href, r)
+		}
+	}
+	sort.Strings(hrefs)
+	if strings.Join(hrefs, ",") != "/docs/,/docs/a.txt,/docs/b,/docs/sub/" {
+		t.Errorf("unexpected listed paths: %v", hrefs)
+	}
+
+	// get the file with range
+	for name, data := range files {
+		resp = doRequest(t, server, http.MethodGet, name, nil)
+		expectStatus(t, resp, http.StatusOK)
+		if body := readBody(t, resp); !bytes.Equal(body, data) {
+			t.Errorf("unexpected data of %v. Expect %s, Got %s", name, data, body)
+		}
+	}
+	req = newRequest(t, server, http.MethodGet, "/docs/a.txt", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	resp = sendRequest(t, req)
+	expectStatus(t, resp, http.StatusPartialContent)
+	if body := readBody(t, resp); string(body) != "2345" {
+		t.Errorf("unexpected range data. Expect %v, Got %v", "2345", string(body))
+	}
+	expectStatus(t, doRequest(t, server, http.MethodGet, "/docs/not-exist", nil), http.StatusNotFound)
+
+	// move the file and the directory
+	req = newRequest(t, server, "MOVE", "/docs/a.txt", nil)
+	req.Header.Set("Destination", server.URL+"/docs/sub/moved.txt")
+	expectStatus(t, sendRequest(t, req), http.StatusCreated)
+	expectStatus(t, doRequest(t, server, http.MethodGet, "/docs/a.txt", nil), http.StatusNotFound)
+	req = newRequest(t, server, "MOVE", "/docs/sub", nil)
+	req.Header.Set("Destination", server.URL+"/renamed")
+	expectStatus(t, sendRequest(t, req), http.StatusCreated)
+	resp = doRequest(t, server, http.MethodGet, "/renamed/moved.txt", nil)
+	expectStatus(t, resp, http.StatusOK)
+	if body := readBody(t, resp); string(body) != "0123456789" {
+		t.Errorf("unexpected data of the moved file: %s", body)
+	}
+
+	// delete the file and the directory
+	expectStatus(t, doRequest(t, server, http.MethodDelete, "/docs/b", nil), http.StatusNoContent)
+	expectStatus(t, doRequest(t, server, http.MethodDelete, "/renamed", nil), http.StatusNoContent)
+	expectStatus(t, doRequest(t, server, "PROPFIND", "/renamed", nil), http.StatusNotFound)
+	expectStatus(t, doRequest(t, server, http.MethodDelete, "/", nil), http.StatusMethodNotAllowed)
+}
+
+// TestGateway_ReplaceFile test the existing file is kept if the upload of the replacing
+// file fails, and the replaced file is archived if versioning is enabled. The staged files
+// are removed whether the upload succeeds or not
+func TestGateway_ReplaceFile(t *testing.T) {
+	g, server := newTestGateway(t)
+	defer server.Close()
+	backend := g.backend.(*gatewaytest.Backend)
+
+	expectStatus(t, doRequest(t, server, "MKCOL", "/docs", nil), http.StatusCreated)
+	expectStatus(t, doRequest(t, server, http.MethodPut, "/docs/a", []byte("v1")), http.StatusCreated)
+
+	backend.UploadErr = errors.New("upload failed")
+	if resp := doRequest(t, server, http.MethodPut, "/docs/a", []byte("v2")); resp.StatusCode < http.StatusBadRequest {
+		t.Errorf("put with the upload failed: unexpected status code %v", resp.StatusCode)
+	}
+	backend.UploadErr = nil
+	checkFileData(t, server, "/docs/a", "v1")
+	if staged := stagedFiles(t, g); len(staged) != 0 {
+		t.Errorf("staged file of the failed upload shall be removed: %v", staged)
+	}
+
+	docs, err := storage.NewDxPath("docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = g.fs.SetVersioning(docs, storage.VersioningPolicy{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, doRequest(t, server, http.MethodPut, "/docs/a", []byte("v2")), http.StatusCreated)
+	checkFileData(t, server, "/docs/a", "v2")
+	file, err := docs.Join("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions, err := g.fs.ListVersions(file); err != nil || len(versions) != 1 {
+		t.Errorf("expect 1 version, got %v: %v", len(versions), err)
+	}
+	expectStatus(t, doRequest(t, server, http.MethodDelete, "/docs/a", nil), http.StatusNoContent)
+	if versions, err := g.fs.ListVersions(file); err != nil || len(versions) != 2 {
+		t.Errorf("expect 2 versions, got %v: %v", len(versions), err)
+	}
+	if staged := stagedFiles(t, g); len(staged) != 0 {
+		t.Errorf("staged files shall be removed once uploaded: %v", staged)
+	}
+}
+
+// checkFileData checks the data of the file got from the gateway
+func checkFileData(t *testing.T, server *httptest.Server, name string, expect string) {
+	resp := doRequest(t, server, http.MethodGet, name, nil)
+	expectStatus(t, resp, http.StatusOK)
+	if body := readBody(t, resp); string(body) != expect {
+		t.Errorf("unexpected data of %v. Expect %s, Got %s", name, expect, body)
+	}
+}
+
+// newTestGateway creates the WebDAV gateway with a new file system, and the http test
+// server serving the gateway
+func newTestGateway(t *testing.T) (*Gateway, *httptest.Server) {
+	dir, err := ioutil.TempDir("", "webdavgateway")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsDir := filepath.Join(dir, "filesystem")
+	if err = os.MkdirAll(fsDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	fs := filesystem.New(fsDir, &filesystem.AlwaysSuccessContractManager{})
+	if err = fs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	usersFile := filepath.Join(dir, UsersFilename)
+	users := fmt.Sprintf(`{"%s": "%s"}`, testUser, testPassword)
+	if err = ioutil.WriteFile(usersFile, []byte(users), 0600); err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(Config{UsersFile: usersFile}, filepath.Join(dir, PersistDirectory), &gatewaytest.Backend{FS: fs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g, httptest.NewServer(g)
+}
+
+// newRequest creates the request with the basic authentication of the test user
+func newRequest(t *testing.T, server *httptest.Server, method, path string, body []byte) *http.Request {
+	req, err := http.NewRequest(method, server.URL+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth(testUser, testPassword)
+	return req
+}
+
+// doRequest sends the authenticated request to the test server
+func doRequest(t *testing.T, server *httptest.Server, method, path string, body []byte) *http.Response {
+	return sendRequest(t, newRequest(t, server, method, path, body))
+}
+
+// sendRequest sends the request
+func sendRequest(t *testing.T, req *http.Request) *http.Response {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// readBody reads and closes the response body
+func readBody(t *testing.T, resp *http.Response) []byte {
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// expectStatus checks the status code of the response
+func expectStatus(t *testing.T, resp *http.Response, status int) {
+	if resp.StatusCode != status {
+		t.Fatalf("%v %v: unexpected status code. Expect %v, Got %v: %s", resp.Request.Method, resp.Request.URL.Path,
+			status, resp.StatusCode, readBody(t, resp))
+	}
+}
+
+// stagedFiles returns the files staging the written data left in the gateway
+func stagedFiles(t *testing.T, g *Gateway) []string {
+	files, err := filepath.Glob(filepath.Join(g.tempDir(), "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
@@ -5,13 +5,18 @@
 package storageclient
 
 import (
+	"crypto/ecdsa"
 	"errors"
+	"math"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
 )
 
 var (
@@ -36,6 +41,10 @@ type worker struct {
 	hostID   enode.ID
 	client   *StorageClient
 
+	// The key of the prepaid ephemeral account in the host. If not nil, the worker downloads
+	// from the host without a contract, paid by the prepaid ephemeral account
+	payKey *ecdsa.PrivateKey
+
 	// How many failures in a row?
 	ownedDownloadConsecutiveFailures int
 
@@ -118,9 +127,9 @@ func (w *worker) workLoop() {
 	defer w.killDownloading()
 
 	for {
-		downloadSegment := w.nextDownloadSegment()
-		if downloadSegment != nil {
-			err := w.download(downloadSegment)
+		downloadSegments := w.nextDownloadSegments(w.downloadBatchLimit())
+		if len(downloadSegments) != 0 {
+			err := w.download(downloadSegments)
 			if err == ErrNoContractsWithHost || err == ErrUnableRetrieveHostInfo {
 				break
 			}
@@ -203,17 +212,31 @@ func (w *worker) queueDownloadSegment(uds *unfinishedDownloadSegment) {
 	}
 }
 
-// Pull the next potential segment out of the work queue for downloading.
-func (w *worker) nextDownloadSegment() *unfinishedDownloadSegment {
+// Pull at most limit potential segments out of the work queue for downloading. All the
+// segments pulled will be downloaded from the host in a single batch.
+func (w *worker) nextDownloadSegments(limit int) []*unfinishedDownloadSegment {
 	w.downloadMu.Lock()
 	defer w.downloadMu.Unlock()
 
-	if len(w.downloadSegments) == 0 {
-		return nil
+	if limit > len(w.downloadSegments) {
+		limit = len(w.downloadSegments)
+	}
+	nextSegments := make([]*unfinishedDownloadSegment, limit)
+	copy(nextSegments, w.downloadSegments[:limit])
+	w.downloadSegments = w.downloadSegments[limit:]
+	return nextSegments
+}
+
+// downloadBatchLimit returns the number of sectors that could be downloaded from the host in a
+// single batch, which is limited by the MaxDownloadBatchSize advertised by the host
+func (w *worker) downloadBatchLimit() int {
+	limit := 1
+	if hostInfo, ok := w.client.storageHostManager.RetrieveHostInfo(w.hostID); ok {
+		if n := int(hostInfo.MaxDownloadBatchSize / storage.SectorSize); n > limit {
+			limit = n
+		}
 	}
-	nextSegment := w.downloadSegments[0]
-	w.downloadSegments = w.downloadSegments[1:]
-	return nextSegment
+	return limit
 }
 
 func (w *worker) checkConnection() (storage.Peer, *storage.HostInfo, error) {
@@ -238,43 +261,106 @@ func (w *worker) checkConnection() (storage.Peer, *storage.HostInfo, error) {
 	return sp, hostInfo, err
 }
 
-// Actually perform a download task
-func (w *worker) download(uds *unfinishedDownloadSegment) error {
-	sp, hostInfo, err := w.checkConnection()
-	defer sp.RevisionOrRenewingDone()
-
+// Actually perform a download task. The sectors of all the given segments stored in the
+// host are requested in a single batched download, paid by the ephemeral account in the host
+// if possible, otherwise by a new contract revision
+func (w *worker) download(segments []*unfinishedDownloadSegment) error {
+	hostInfo, err := w.downloadHostInfo()
+	if err != nil {
+		w.client.log.Error("failed to check the connection", "err", err)
+		return err
+	}
+	sp, err := w.client.SetupConnection(hostInfo.EnodeURL)
 	if err != nil {
 		w.client.log.Error("failed to check the connection", "err", err)
 		return err
 	}
 
-	// check the uds whether can be the worker performed
-	uds = w.processDownloadSegment(uds)
-	if uds == nil {
+	// check the segments whether can be the worker performed
+	var udsList []*unfinishedDownloadSegment
+	var roots []common.Hash
+	for _, uds := range segments {
+		if uds = w.processDownloadSegment(uds); uds != nil {
+			udsList = append(udsList, uds)
+			roots = append(roots, uds.segmentMap[w.hostID.String()].root)
+		}
+	}
+	if len(udsList) == 0 {
 		return err
 	}
 
 	// whether download success or fail, we should remove the worker at last
-	defer uds.removeWorker()
+	defer func() {
+		for _, uds := range udsList {
+			uds.removeWorker()
+		}
+	}()
 
 	// for not supporting partial encoding, we need to download the whole sector every time.
-	fetchOffset, fetchLength := 0, storage.SectorSize
-	root := uds.segmentMap[w.hostID.String()].root
-
 	// call rpc request the data from host, if get error, unregister the worker.
-	sectorData, err := w.client.Download(sp, root, uint32(fetchOffset), uint32(fetchLength), hostInfo)
+	var sectorsData [][]byte
+	if w.payKey != nil {
+		sectorsData, err = w.client.PrepaidDownloadBatch(sp, roots, hostInfo, w.payKey)
+	} else if sectorsData, err = w.client.ephemeralDownload(sp, roots, hostInfo); err != nil {
+		w.client.log.Debug("worker failed to download sectors with ephemeral account", "error", err)
+		sectorsData, err = w.revisionDownload(sp, roots, hostInfo)
+	}
 	if err != nil {
-		w.client.log.Error("worker failed to download sector", "error", err)
-		uds.unregisterWorker(w)
+		w.client.log.Error("worker failed to download sectors", "error", err)
+		for _, uds := range udsList {
+			uds.unregisterWorker(w)
+		}
 		return err
 	}
 
+	for i, uds := range udsList {
+		if err := w.handleDownloadedSector(uds, sectorsData[i]); err != nil {
+			for _, uds := range udsList[i:] {
+				uds.unregisterWorker(w)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadHostInfo returns the info of the host to download from. The worker paid by the prepaid
+// ephemeral account does not need a contract with the host
+func (w *worker) downloadHostInfo() (*storage.HostInfo, error) {
+	if w.payKey == nil {
+		return w.updateWorkerContractID(w.contract.ID)
+	}
+	hostInfo, ok := w.client.storageHostManager.RetrieveHostInfo(w.hostID)
+	if !ok {
+		return nil, ErrUnableRetrieveHostInfo
+	}
+	return &hostInfo, nil
+}
+
+// revisionDownload downloads the sectors paid by a new contract revision. A single sector is
+// still requested with the plain download request.
+func (w *worker) revisionDownload(sp storage.Peer, roots []common.Hash, hostInfo *storage.HostInfo) ([][]byte, error) {
+	// start contract revision, if failed, meaning the renewing is started
+	if ok := sp.TryToRenewOrRevise(); !ok {
+		return nil, errors.New("the contract is currently renewing or revising")
+	}
+	defer sp.RevisionOrRenewingDone()
+
+	if len(roots) == 1 {
+		sectorData, err := w.client.Download(sp, roots[0], 0, uint32(storage.SectorSize), hostInfo)
+		return [][]byte{sectorData}, err
+	}
+	return w.client.DownloadBatch(sp, roots, hostInfo)
+}
+
+// handleDownloadedSector decrypts the sector downloaded for the segment, and recovers the
+// logical data if enough sectors are completed
+func (w *worker) handleDownloadedSector(uds *unfinishedDownloadSegment, sectorData []byte) error {
 	// decrypt the sector
 	key := uds.clientFile.CipherKey()
 	decryptedSector, err := key.DecryptInPlace(sectorData)
 	if err != nil {
 		w.client.log.Error("worker failed to decrypt sector", "error", err)
-		uds.unregisterWorker(w)
 		return err
 	}
 
@@ -337,6 +423,23 @@ func (w *worker) processDownloadSegment(uds *unfinishedDownloadSegment) *unfinis
 	return nil
 }
 
+// sortWorkersByDownloadTime sorts the workers by the time estimated to download the sector of
+// the size from their storage hosts based on the measured host performance, the fastest first
+func sortWorkersByDownloadTime(workers []*worker, size uint64) {
+	estimates := make(map[*worker]time.Duration, len(workers))
+	for _, w := range workers {
+		hostInfo, exist := w.client.storageHostManager.RetrieveHostInfo(w.hostID)
+		if !exist {
+			estimates[w] = math.MaxInt64
+			continue
+		}
+		estimates[w] = storagehostmanager.EstimateDownloadTime(hostInfo.Performance, size)
+	}
+	sort.SliceStable(workers, func(i, j int) bool {
+		return estimates[workers[i]] < estimates[workers[j]]
+	})
+}
+
 // Return true if the worker is on cooldown for download failure.
 func (w *worker) onDownloadCooldown() bool {
 	requiredCooldown := DownloadFailureCooldown
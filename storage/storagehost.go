@@ -24,6 +24,7 @@ type HostBackend interface {
 	AccountManager() *accounts.Manager
 	SetStatic(node *enode.Node)
 	CheckAndUpdateConnection(peerNode *enode.Node)
+	SelfEnodeURL() string
 }
 
 // AccountManager is the interface for account.Manager to be used in storage host module
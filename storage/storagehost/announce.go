@@ -0,0 +1,107 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// announce sends the structured host announcement, which expires after HostAnnouncementValidity
+// blocks unless it is renewed
+func (h *StorageHost) announce() (common.Hash, error) {
+	address, err := h.getPaymentAddress()
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("cannot get the payment address: %v", err)
+	}
+
+	h.lock.RLock()
+	// the sequence of the new announcement must be larger than the previous one
+	sequence := h.blockHeight
+	if sequence <= h.announceSequence {
+		sequence = h.announceSequence + 1
+	}
+	metadata := types.HostAnnouncementMetadata{
+		Version:         types.HostAnnouncementVersion,
+		Sequence:        sequence,
+		Expiration:      h.blockHeight + storage.HostAnnouncementValidity,
+		Region:          h.config.Region,
+		ProtocolVersion: storage.HostProtocolVersion,
+		Features:        storage.DefaultHostFeatures,
+		Bond:            h.config.AnnounceBond.BigIntPtr(),
+		Announcer:       address,
+	}
+	h.lock.RUnlock()
+
+	hash, err := h.parseAPI.StorageTx.SendHostAnnounceTX(address, &metadata)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.announceSequence = metadata.Sequence
+	h.announceExpiration = metadata.Expiration
+	if err = h.syncConfig(); err != nil {
+		h.log.Warn("cannot save the announcement status", "err", err)
+	}
+	return hash, nil
+}
+
+// retire sends the host retire transaction which withdraws all the previous announcements
+// of the host, and stops accepting new contracts
+func (h *StorageHost) retire() (common.Hash, error) {
+	if err := h.setAcceptContracts(false); err != nil {
+		return common.Hash{}, fmt.Errorf("cannot set AcceptingContracts: %v", err)
+	}
+	address, err := h.getPaymentAddress()
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("cannot get the payment address: %v", err)
+	}
+
+	h.lock.RLock()
+	// the retirement covers all the previous announcements
+	sequence := h.blockHeight
+	if sequence < h.announceSequence {
+		sequence = h.announceSequence
+	}
+	h.lock.RUnlock()
+
+	hash, err := h.parseAPI.StorageTx.SendHostRetireTX(address, sequence)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.announceSequence = sequence
+	h.announceExpiration = 0
+	if err = h.syncConfig(); err != nil {
+		h.log.Warn("cannot save the announcement status", "err", err)
+	}
+	return hash, nil
+}
+
+// renewAnnouncement renews the host announcement when it is about to expire. Only the
+// host accepting contracts renews its announcement
+func (h *StorageHost) renewAnnouncement() {
+	h.lock.RLock()
+	renew := h.config.AcceptingContracts && h.announceExpiration != 0 &&
+		h.blockHeight+storage.HostAnnouncementRenewWindow >= h.announceExpiration
+	h.lock.RUnlock()
+
+	if !renew {
+		return
+	}
+	hash, err := h.announce()
+	if err != nil {
+		h.log.Warn("failed to renew the host announcement", "err", err)
+		return
+	}
+	h.log.Info("Host announcement renewed", "tx", hash.Hex())
+}
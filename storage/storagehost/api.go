@@ -42,22 +42,28 @@ func (h *HostPrivateAPI) PersistDir() string {
 }
 
 // Announce set accepting contracts to true, and then send the announcement
-// transaction
+// transaction. The announcement is renewed automatically before it expires
 func (h *HostPrivateAPI) Announce() string {
 	if err := h.storageHost.setAcceptContracts(true); err != nil {
 		return fmt.Sprintf("cannot set AcceptingContracts: %v", err)
 	}
-	address, err := h.storageHost.getPaymentAddress()
-	if err != nil {
-		return fmt.Sprintf("cannot get the payment address: %v", err)
-	}
-	hash, err := h.storageHost.parseAPI.StorageTx.SendHostAnnounceTX(address)
+	hash, err := h.storageHost.announce()
 	if err != nil {
 		return fmt.Sprintf("cannot send the announce transaction: %v", err)
 	}
 	return fmt.Sprintf("Announcement transaction: %v", hash.Hex())
 }
 
+// Retire set accepting contracts to false, and then send the retire transaction
+// withdrawing the host announcements
+func (h *HostPrivateAPI) Retire() string {
+	hash, err := h.storageHost.retire()
+	if err != nil {
+		return fmt.Sprintf("cannot send the retire transaction: %v", err)
+	}
+	return fmt.Sprintf("Retire transaction: %v", hash.Hex())
+}
+
 // Folders return all the folders
 func (h *HostPrivateAPI) Folders() []storage.HostFolder {
 	return h.storageHost.StorageManager.Folders()
@@ -68,6 +74,11 @@ func (h *HostPrivateAPI) AvailableSpace() storage.HostSpace {
 	return h.storageHost.StorageManager.AvailableSpace()
 }
 
+// SectorCacheStats return the statistics of the sector read cache of the host
+func (h *HostPrivateAPI) SectorCacheStats() storage.HostSectorCacheStats {
+	return h.storageHost.StorageManager.SectorCacheStats()
+}
+
 // GetHostConfig return the internal settings of the storage host
 func (h *HostPrivateAPI) GetHostConfig() storage.HostIntConfigForDisplay {
 	// Get the internal setting
@@ -89,6 +100,12 @@ func (h *HostPrivateAPI) GetHostConfig() storage.HostIntConfigForDisplay {
 		SectorAccessPrice:      unit.FormatCurrency(config.SectorAccessPrice, "/sector"),
 		StoragePrice:           unit.FormatCurrency(config.StoragePrice, "/byte/block"),
 		UploadBandwidthPrice:   unit.FormatCurrency(config.UploadBandwidthPrice, "/byte"),
+		MaxUploadSpeed:         unit.FormatSpeed(config.MaxUploadSpeed),
+		MaxDownloadSpeed:       unit.FormatSpeed(config.MaxDownloadSpeed),
+		MaxClientUploadSpeed:   unit.FormatSpeed(config.MaxClientUploadSpeed),
+		MaxClientDownloadSpeed: unit.FormatSpeed(config.MaxClientDownloadSpeed),
+		Region:                 config.Region,
+		AnnounceBond:           unit.FormatCurrency(config.AnnounceBond),
 	}
 
 	return display
@@ -166,7 +183,7 @@ func (h *HostPrivateAPI) DeleteFolder(folderPath string) (string, error) {
 }
 
 // hostSetterCallbacks is the mapping from the field name to the setter function
-var hostSetterCallbacks = map[string]func(*HostPrivateAPI, string) error{
+var hostSetterCallbacks = map[string]func(*HostPrivateAPI, *storage.HostIntConfig, string) error{
 	"acceptingContracts":     (*HostPrivateAPI).setAcceptingContracts,
 	"maxDownloadBatchSize":   (*HostPrivateAPI).setMaxDownloadBatchSize,
 	"maxDuration":            (*HostPrivateAPI).setMaxDuration,
@@ -181,35 +198,36 @@ var hostSetterCallbacks = map[string]func(*HostPrivateAPI, string) error{
 	"sectorAccessPrice":      (*HostPrivateAPI).setSectorAccessPrice,
 	"storagePrice":           (*HostPrivateAPI).setStoragePrice,
 	"uploadBandwidthPrice":   (*HostPrivateAPI).setUploadBandwidthPrice,
+	"maxUploadSpeed":         (*HostPrivateAPI).setMaxUploadSpeed,
+	"maxDownloadSpeed":       (*HostPrivateAPI).setMaxDownloadSpeed,
+	"maxClientUploadSpeed":   (*HostPrivateAPI).setMaxClientUploadSpeed,
+	"maxClientDownloadSpeed": (*HostPrivateAPI).setMaxClientDownloadSpeed,
+	"region":                 (*HostPrivateAPI).setRegion,
+	"announceBond":           (*HostPrivateAPI).setAnnounceBond,
 }
 
-// SetConfig set the config specified by a mapping of key value pair
+// SetConfig set the config specified by a mapping of key value pair. The setters modify
+// a copy of the host config, which replaces the host config under the host lock
 func (h *HostPrivateAPI) SetConfig(config map[string]string) (string, error) {
 	h.storageHost.lock.Lock()
-	// record the previous config and register the defer function
-	var err error
-	prevConfig := h.storageHost.config
-	defer func() {
-		// If error happened, revert to the previous config
-		if err != nil {
-			h.storageHost.config = prevConfig
-		}
-		h.storageHost.lock.Unlock()
-	}()
+	defer h.storageHost.lock.Unlock()
 
-	// Loops over the user set config and change the host settings
+	// Loops over the user set config and change the copy of the host settings
+	prevConfig := h.storageHost.config
+	newConfig := prevConfig
 	for key, value := range config {
 		callback, exist := hostSetterCallbacks[key]
 		if !exist {
-			err = fmt.Errorf("unknown config variable")
-			return "", err
+			return "", fmt.Errorf("unknown config variable")
 		}
-		if err = callback(h, value); err != nil {
+		if err := callback(h, &newConfig, value); err != nil {
 			return "", err
 		}
 	}
-	// sync the config
-	if err = h.storageHost.syncConfig(); err != nil {
+	// apply and sync the config. If error happened, revert to the previous config
+	h.storageHost.config = newConfig
+	if err := h.storageHost.syncConfig(); err != nil {
+		h.storageHost.config = prevConfig
 		return "", err
 	}
 	return `Successfully set the host config. Next please use 
@@ -221,48 +239,48 @@ to broadcast the config changes.
 }
 
 // setAcceptingContracts set host AcceptingContracts to val specified by valStr
-func (h *HostPrivateAPI) setAcceptingContracts(valStr string) error {
+func (h *HostPrivateAPI) setAcceptingContracts(config *storage.HostIntConfig, valStr string) error {
 	val, err := unit.ParseBool(valStr)
 	if err != nil {
 		return fmt.Errorf("invalid bool string: %v", err)
 	}
-	h.storageHost.config.AcceptingContracts = val
+	config.AcceptingContracts = val
 	return nil
 }
 
 // setMaxDownloadBatchSize set host MaxDownloadBatchSize to value
-func (h *HostPrivateAPI) setMaxDownloadBatchSize(valStr string) error {
+func (h *HostPrivateAPI) setMaxDownloadBatchSize(config *storage.HostIntConfig, valStr string) error {
 	val, err := unit.ParseStorage(valStr)
 	if err != nil {
 		return fmt.Errorf("invalid storage string: %v", err)
 	}
-	h.storageHost.config.MaxDownloadBatchSize = val
+	config.MaxDownloadBatchSize = val
 	return nil
 }
 
 // setMaxDuration set host MaxDuration to value
-func (h *HostPrivateAPI) setMaxDuration(str string) error {
+func (h *HostPrivateAPI) setMaxDuration(config *storage.HostIntConfig, str string) error {
 	val, err := unit.ParseTime(str)
 	if err != nil {
 		return fmt.Errorf("invalid time string: %v", err)
 	}
-	h.storageHost.config.MaxDuration = val
+	config.MaxDuration = val
 	return nil
 }
 
 // setMaxReviseBatchSize set host MaxReviseBatchSize to value
-func (h *HostPrivateAPI) setMaxReviseBatchSize(str string) error {
+func (h *HostPrivateAPI) setMaxReviseBatchSize(config *storage.HostIntConfig, str string) error {
 	val, err := unit.ParseStorage(str)
 	if err != nil {
 		return fmt.Errorf("invalid size string: %v", err)
 	}
-	h.storageHost.config.MaxReviseBatchSize = val
+	config.MaxReviseBatchSize = val
 	return nil
 }
 
 // setPaymentAddress configure the account address used to sign the storage contract,
 // which has and can only be the address of the local wallet.
-func (h *HostPrivateAPI) setPaymentAddress(addrStr string) error {
+func (h *HostPrivateAPI) setPaymentAddress(config *storage.HostIntConfig, addrStr string) error {
 	addr := common.HexToAddress(addrStr)
 	account := accounts.Account{Address: addr}
 	if h.storageHost.am == nil {
@@ -272,96 +290,155 @@ func (h *HostPrivateAPI) setPaymentAddress(addrStr string) error {
 	if err != nil {
 		return errors.New("unknown account")
 	}
-	h.storageHost.config.PaymentAddress = addr
+	config.PaymentAddress = addr
 	return nil
 }
 
 // setDeposit set host Deposit to value.
-func (h *HostPrivateAPI) setDeposit(str string) error {
+func (h *HostPrivateAPI) setDeposit(config *storage.HostIntConfig, str string) error {
 	wei, err := unit.ParseCurrency(str)
 	if err != nil {
 		return fmt.Errorf("invalid currency expression: %v", err)
 	}
-	h.storageHost.config.Deposit = wei
+	config.Deposit = wei
 	return nil
 }
 
 // setDepositBudget set host DepositBudget to value
-func (h *HostPrivateAPI) setDepositBudget(str string) error {
+func (h *HostPrivateAPI) setDepositBudget(config *storage.HostIntConfig, str string) error {
 	wei, err := unit.ParseCurrency(str)
 	if err != nil {
 		return fmt.Errorf("invalid currency expression: %v", err)
 	}
-	h.storageHost.config.DepositBudget = wei
+	config.DepositBudget = wei
 	return nil
 }
 
 // setMaxDeposit set host MaxDeposit to value
-func (h *HostPrivateAPI) setMaxDeposit(str string) error {
+func (h *HostPrivateAPI) setMaxDeposit(config *storage.HostIntConfig, str string) error {
 	wei, err := unit.ParseCurrency(str)
 	if err != nil {
 		return fmt.Errorf("invalid currency expression: %v", err)
 	}
-	h.storageHost.config.MaxDeposit = wei
+	config.MaxDeposit = wei
 	return nil
 }
 
 // setBaseRPCPrice set host BaseRPCPrice to value
-func (h *HostPrivateAPI) setBaseRPCPrice(str string) error {
+func (h *HostPrivateAPI) setBaseRPCPrice(config *storage.HostIntConfig, str string) error {
 	wei, err := unit.ParseCurrency(str)
 	if err != nil {
 		return fmt.Errorf("invalid currency expression: %v", err)
 	}
-	h.storageHost.config.BaseRPCPrice = wei
+	config.BaseRPCPrice = wei
 	return nil
 }
 
 // setContractPrice set host ContractPrice to value
-func (h *HostPrivateAPI) setContractPrice(str string) error {
+func (h *HostPrivateAPI) setContractPrice(config *storage.HostIntConfig, str string) error {
 	wei, err := unit.ParseCurrency(str)
 	if err != nil {
 		return fmt.Errorf("invalid currency expression: %v", err)
 	}
-	h.storageHost.config.ContractPrice = wei
+	config.ContractPrice = wei
 	return nil
 }
 
 // setDownloadBandwidthPrice set host DownloadBandwidthPrice to value
-func (h *HostPrivateAPI) setDownloadBandwidthPrice(str string) error {
+func (h *HostPrivateAPI) setDownloadBandwidthPrice(config *storage.HostIntConfig, str string) error {
 	wei, err := unit.ParseCurrency(str)
 	if err != nil {
 		return fmt.Errorf("invalid currency expression: %v", err)
 	}
-	h.storageHost.config.DownloadBandwidthPrice = wei
+	config.DownloadBandwidthPrice = wei
 	return nil
 }
 
 // setSectorAccessPrice set host SectorAccessPrice to value
-func (h *HostPrivateAPI) setSectorAccessPrice(str string) error {
+func (h *HostPrivateAPI) setSectorAccessPrice(config *storage.HostIntConfig, str string) error {
 	wei, err := unit.ParseCurrency(str)
 	if err != nil {
 		return fmt.Errorf("invalid currency expression: %v", err)
 	}
-	h.storageHost.config.SectorAccessPrice = wei
+	config.SectorAccessPrice = wei
 	return nil
 }
 
 // setStoragePrice set host StoragePrice to value
-func (h *HostPrivateAPI) setStoragePrice(str string) error {
+func (h *HostPrivateAPI) setStoragePrice(config *storage.HostIntConfig, str string) error {
 	wei, err := unit.ParseCurrency(str)
 	if err != nil {
 		return fmt.Errorf("invalid currency expression: %v", err)
 	}
-	h.storageHost.config.StoragePrice = wei
+	config.StoragePrice = wei
 	return nil
 }
 
 // setUploadBandwidthPrice set host UploadBandwidthPrice to value
-func (h *HostPrivateAPI) setUploadBandwidthPrice(str string) error {
+func (h *HostPrivateAPI) setUploadBandwidthPrice(config *storage.HostIntConfig, str string) error {
+	wei, err := unit.ParseCurrency(str)
+	if err != nil {
+		return fmt.Errorf("invalid currency expression: %v", err)
+	}
+	config.UploadBandwidthPrice = wei
+	return nil
+}
+
+// setMaxUploadSpeed set host-wide MaxUploadSpeed to value
+func (h *HostPrivateAPI) setMaxUploadSpeed(config *storage.HostIntConfig, str string) error {
+	val, err := unit.ParseSpeed(str)
+	if err != nil {
+		return fmt.Errorf("invalid speed string: %v", err)
+	}
+	config.MaxUploadSpeed = val
+	return nil
+}
+
+// setMaxDownloadSpeed set host-wide MaxDownloadSpeed to value
+func (h *HostPrivateAPI) setMaxDownloadSpeed(config *storage.HostIntConfig, str string) error {
+	val, err := unit.ParseSpeed(str)
+	if err != nil {
+		return fmt.Errorf("invalid speed string: %v", err)
+	}
+	config.MaxDownloadSpeed = val
+	return nil
+}
+
+// setMaxClientUploadSpeed set the per client MaxClientUploadSpeed to value
+func (h *HostPrivateAPI) setMaxClientUploadSpeed(config *storage.HostIntConfig, str string) error {
+	val, err := unit.ParseSpeed(str)
+	if err != nil {
+		return fmt.Errorf("invalid speed string: %v", err)
+	}
+	config.MaxClientUploadSpeed = val
+	return nil
+}
+
+// setMaxClientDownloadSpeed set the per client MaxClientDownloadSpeed to value
+func (h *HostPrivateAPI) setMaxClientDownloadSpeed(config *storage.HostIntConfig, str string) error {
+	val, err := unit.ParseSpeed(str)
+	if err != nil {
+		return fmt.Errorf("invalid speed string: %v", err)
+	}
+	config.MaxClientDownloadSpeed = val
+	return nil
+}
+
+// setRegion set the host Region announced to value
+func (h *HostPrivateAPI) setRegion(config *storage.HostIntConfig, str string) error {
+	if len(str) > maxRegionLength {
+		return fmt.Errorf("invalid region: longer than %v characters", maxRegionLength)
+	}
+	config.Region = str
+	return nil
+}
+
+// setAnnounceBond set the host AnnounceBond to value
+func (h *HostPrivateAPI) setAnnounceBond(config *storage.HostIntConfig, str string) error {
 	wei, err := unit.ParseCurrency(str)
 	if err != nil {
 		return fmt.Errorf("invalid currency expression: %v", err)
 	}
-	h.storageHost.config.UploadBandwidthPrice = wei
+	config.AnnounceBond = wei
 	return nil
 }
@@ -92,6 +92,21 @@ func TestHostPrivateAPI_SetConfig(t *testing.T) {
 			storage.HostIntConfig{UploadBandwidthPrice: mustParseCurrency("1camel")},
 			nil,
 		},
+		"maxDownloadSpeed": {
+			map[string]string{"maxDownloadSpeed": "10mbps"},
+			storage.HostIntConfig{MaxDownloadSpeed: 10e6},
+			nil,
+		},
+		"maxClientUploadSpeed": {
+			map[string]string{"maxClientUploadSpeed": "1kbps"},
+			storage.HostIntConfig{MaxClientUploadSpeed: 1e3},
+			nil,
+		},
+		"speed parse error": {
+			map[string]string{"maxUploadSpeed": "1234", "acceptingContracts": "true"},
+			storage.HostIntConfig{},
+			errors.New("speed error"),
+		},
 		"currency parse error": {
 			map[string]string{"baseRPCPrice": "1234", "acceptingContracts": "true"},
 			storage.HostIntConfig{},
@@ -0,0 +1,160 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// bandwidthDirection distinguishes the data sent from the client to the host (upload)
+// and the data sent from the host to the client (download)
+type bandwidthDirection int
+
+const (
+	bandwidthUpload bandwidthDirection = iota
+	bandwidthDownload
+)
+
+// bandwidthLimits is the bandwidth limits in bytes per second, 0 for unlimited
+type bandwidthLimits struct {
+	host   [2]int64
+	client [2]int64
+}
+
+// newBandwidthLimits returns the bandwidth limits specified by the host config
+func newBandwidthLimits(config storage.HostIntConfig) bandwidthLimits {
+	return bandwidthLimits{
+		host:   [2]int64{config.MaxUploadSpeed, config.MaxDownloadSpeed},
+		client: [2]int64{config.MaxClientUploadSpeed, config.MaxClientDownloadSpeed},
+	}
+}
+
+// tokenBucket limits the data transferred in terms of bytes per second. The bucket
+// holds at most one second of tokens, and goes into debt for the transfer larger than
+// that, so that the following transfers are delayed accordingly
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes size bytes from the bucket refilled at the rate, and returns the
+// duration to wait before the transfer complies with the rate
+func (tb *tokenBucket) reserve(rate int64, size uint64, now time.Time) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	// refill the bucket, a bucket never used before is full
+	capacity := float64(rate)
+	if tb.last.IsZero() {
+		tb.tokens = capacity
+	} else if elapsed := now.Sub(tb.last); elapsed > 0 {
+		tb.tokens += elapsed.Seconds() * capacity
+	}
+	if tb.tokens > capacity {
+		tb.tokens = capacity
+	}
+	tb.last = now
+
+	tb.tokens -= float64(size)
+	if tb.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-tb.tokens / capacity * float64(time.Second))
+}
+
+// clientBandwidth is the bandwidth usage of a storage client
+type clientBandwidth struct {
+	buckets    [2]tokenBucket
+	lastActive time.Time
+}
+
+// bandwidthScheduler schedules the host bandwidth among the storage clients. Besides the
+// host-wide limit and the per client limit, the host-wide limit is shared fairly among
+// the clients actively transferring data, so that a single client flooding the requests
+// cannot take the bandwidth of the other clients. Internal operations of the host, such as
+// the storage proof generation, never go through the scheduler
+type bandwidthScheduler struct {
+	host    [2]tokenBucket
+	clients map[enode.ID]*clientBandwidth
+	lock    sync.Mutex
+}
+
+// newBandwidthScheduler creates a new bandwidth scheduler
+func newBandwidthScheduler() *bandwidthScheduler {
+	return &bandwidthScheduler{
+		clients: make(map[enode.ID]*clientBandwidth),
+	}
+}
+
+// reserve reserves the bandwidth for transferring size bytes between the host and the
+// client, and returns the duration to wait before the transfer
+func (bs *bandwidthScheduler) reserve(limits bandwidthLimits, client enode.ID, dir bandwidthDirection, size uint64, now time.Time) time.Duration {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+
+	// remove the inactive clients, which no longer take the share of the bandwidth
+	for id, cb := range bs.clients {
+		if now.Sub(cb.lastActive) > bandwidthActiveWindow {
+			delete(bs.clients, id)
+		}
+	}
+	cb, exist := bs.clients[client]
+	if !exist {
+		cb = &clientBandwidth{}
+		bs.clients[client] = cb
+	}
+	cb.lastActive = now
+
+	// the client rate is limited to its fair share of the host-wide rate
+	hostRate, clientRate := limits.host[dir], limits.client[dir]
+	if hostRate > 0 {
+		share := hostRate / int64(len(bs.clients))
+		if share == 0 {
+			share = 1
+		}
+		if clientRate <= 0 || share < clientRate {
+			clientRate = share
+		}
+	}
+
+	wait := cb.buckets[dir].reserve(clientRate, size, now)
+	if hostWait := bs.host[dir].reserve(hostRate, size, now); hostWait > wait {
+		wait = hostWait
+	}
+	return wait
+}
+
+// WaitReadBandwidth blocks until receiving the message of size bytes from the client
+// complies with the upload bandwidth limits of the host. It is called in the read loop
+// of the client connection, so that the following messages are not read from the
+// connection until the bandwidth allows
+func (h *StorageHost) WaitReadBandwidth(sp storage.Peer, size uint32) error {
+	return h.waitBandwidth(sp, bandwidthUpload, uint64(size))
+}
+
+// waitBandwidth blocks until transferring size bytes with the client in the direction
+// complies with the bandwidth limits of the host
+func (h *StorageHost) waitBandwidth(sp storage.Peer, dir bandwidthDirection, size uint64) error {
+	h.lock.RLock()
+	limits := newBandwidthLimits(h.config)
+	h.lock.RUnlock()
+
+	wait := h.bandwidth.reserve(limits, sp.PeerNode().ID(), dir, size, time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-h.tm.StopChan():
+		return errHostStopped
+	}
+}
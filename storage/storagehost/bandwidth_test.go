@@ -0,0 +1,72 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+)
+
+func TestTokenBucket_Reserve(t *testing.T) {
+	var tb tokenBucket
+	now := time.Now()
+
+	tests := []struct {
+		rate    int64
+		size    uint64
+		elapsed time.Duration
+		wait    time.Duration
+	}{
+		{0, 1 << 30, 0, 0},                     // unlimited
+		{1000, 1000, 0, 0},                     // full bucket at the first use
+		{1000, 500, 0, 500 * time.Millisecond}, // bucket in debt
+		{1000, 500, 500 * time.Millisecond, 500 * time.Millisecond},
+		{1000, 100, 10 * time.Second, 0}, // bucket refilled to at most one second
+		{1000, 1000, 0, 100 * time.Millisecond},
+	}
+	for i, test := range tests {
+		now = now.Add(test.elapsed)
+		if wait := tb.reserve(test.rate, test.size, now); wait != test.wait {
+			t.Errorf("test %d: expect wait %v, got %v", i, test.wait, wait)
+		}
+	}
+}
+
+func TestBandwidthScheduler_FairShare(t *testing.T) {
+	bs := newBandwidthScheduler()
+	limits := bandwidthLimits{
+		host:   [2]int64{0, 1000},
+		client: [2]int64{0, 800},
+	}
+	now := time.Now()
+	flooder, other := enode.ID{1}, enode.ID{2}
+
+	// a single active client is limited by the per client limit
+	if wait := bs.reserve(limits, flooder, bandwidthDownload, 1600, now); wait != time.Second {
+		t.Fatalf("expect wait %v, got %v", time.Second, wait)
+	}
+	// upload is not limited
+	if wait := bs.reserve(limits, flooder, bandwidthUpload, 1<<30, now); wait != 0 {
+		t.Fatalf("upload shall not be limited, got wait %v", wait)
+	}
+
+	// once another client is active, each client takes half of the host-wide bandwidth
+	now = now.Add(2 * time.Second)
+	if wait := bs.reserve(limits, other, bandwidthDownload, 500, now); wait != 0 {
+		t.Fatalf("expect no wait for the fair share, got %v", wait)
+	}
+	if wait := bs.reserve(limits, other, bandwidthDownload, 500, now); wait != time.Second {
+		t.Fatalf("expect wait %v, got %v", time.Second, wait)
+	}
+
+	// the inactive client no longer takes the share
+	now = now.Add(bandwidthActiveWindow + time.Second)
+	bs.reserve(limits, other, bandwidthDownload, 0, now)
+	if len(bs.clients) != 1 {
+		t.Fatalf("inactive client shall be removed, got %v clients", len(bs.clients))
+	}
+}
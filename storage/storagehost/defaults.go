@@ -6,6 +6,7 @@ package storagehost
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/unit"
@@ -38,8 +39,18 @@ const (
 	//prefixHeight db prefix for task
 	prefixHeight = "height-"
 
+	//prefixEphemeralAccount db prefix for ephemeral account
+	prefixEphemeralAccount = "EphemeralAccount-"
+
 	//Total time to sign the contract
 	postponedExecutionBuffer = 12 * unit.BlocksPerHour
+
+	// bandwidthActiveWindow is the duration a client is considered active after its
+	// last transfer, during which it takes a share of the host-wide bandwidth
+	bandwidthActiveWindow = 10 * time.Second
+
+	// maxRegionLength is the maximum length of the region announced
+	maxRegionLength = 32
 )
 
 var (
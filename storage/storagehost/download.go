@@ -22,6 +22,20 @@ import (
 
 // DownloadHandler handles the download negotiation
 func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
+	handleDownload(h, sp, downloadReqMsg, false)
+}
+
+// DownloadBatchHandler handles the batched download negotiation. All requested sector ranges
+// are paid by a single contract revision, and the data of each range are streamed back to the
+// client one message after another
+func DownloadBatchHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
+	handleDownload(h, sp, downloadReqMsg, true)
+}
+
+// handleDownload handles the download negotiation shared by the single and batched download
+// requests. The payment revision is verified and signed, then the requested data are sent,
+// and the storage responsibility is updated once the client commits
+func handleDownload(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg, batch bool) {
 	var hostNegotiateErr, clientNegotiateErr, clientCommitErr error
 
 	defer func() {
@@ -34,10 +48,9 @@ func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
 	}()
 
 	// read the download request.
-	var req storage.DownloadRequest
-	err := downloadReqMsg.Decode(&req)
+	req, err := decodeDownloadRequest(downloadReqMsg, batch)
 	if err != nil {
-		clientNegotiateErr = fmt.Errorf("error decoding the download request message: %s", err.Error())
+		clientNegotiateErr = err
 		return
 	}
 
@@ -63,55 +76,22 @@ func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
 	currentRevision := so.StorageContractRevisions[len(so.StorageContractRevisions)-1]
 
 	// Validate the request.
-	sec := req.Sector
-	switch {
-	case uint64(sec.Offset)+uint64(sec.Length) > storage.SectorSize:
-		err = errors.New("download out boundary of sector")
-	case sec.Length == 0:
-		err = errors.New("length cannot be 0")
-	case req.MerkleProof && (sec.Offset%storage.SegmentSize != 0 || sec.Length%storage.SegmentSize != 0):
-		err = errors.New("offset and length must be multiples of SegmentSize when requesting a Merkle proof")
-	case len(req.NewValidProofValues) != len(currentRevision.NewValidProofOutputs):
-		err = errors.New("the number of valid proof values not match the old")
-	case len(req.NewMissedProofValues) != len(currentRevision.NewMissedProofOutputs):
-		err = errors.New("the number of missed proof values not match the old")
+	if batch {
+		err = validateDownloadBatch(req.Sectors, req.MerkleProof, settings.MaxDownloadBatchSize)
+	} else {
+		err = validateDownloadSector(req.Sectors[0], req.MerkleProof)
+	}
+	if err == nil {
+		err = validateDownloadProofValues(currentRevision, req.NewValidProofValues, req.NewMissedProofValues)
 	}
 	if err != nil {
 		hostNegotiateErr = fmt.Errorf("download request validation failed: %s", err.Error())
 		return
 	}
 
-	// construct the new revision
-	newRevision := currentRevision
-	newRevision.NewRevisionNumber = req.NewRevisionNumber
-	newRevision.NewValidProofOutputs = make([]types.DxcoinCharge, len(currentRevision.NewValidProofOutputs))
-	for i := range newRevision.NewValidProofOutputs {
-		newRevision.NewValidProofOutputs[i] = types.DxcoinCharge{
-			Value:   req.NewValidProofValues[i],
-			Address: currentRevision.NewValidProofOutputs[i].Address,
-		}
-	}
-	newRevision.NewMissedProofOutputs = make([]types.DxcoinCharge, len(currentRevision.NewMissedProofOutputs))
-	for i := range newRevision.NewMissedProofOutputs {
-		newRevision.NewMissedProofOutputs[i] = types.DxcoinCharge{
-			Value:   req.NewMissedProofValues[i],
-			Address: currentRevision.NewMissedProofOutputs[i].Address,
-		}
-	}
-
-	// calculate expected cost and verify against client's revision
-	var estBandwidth uint64
-	sectorAccesses := make(map[common.Hash]struct{})
-	// use the worst-case proof size of 2*tree depth (this occurs when
-	// proving across the two leaves in the center of the tree)
-	estHashesPerProof := 2 * bits.Len64(storage.SectorSize/merkle.LeafSize)
-	estBandwidth += uint64(sec.Length) + uint64(estHashesPerProof*storage.HashSize)
-	sectorAccesses[sec.MerkleRoot] = struct{}{}
-
-	// calculate total cost
-	bandwidthCost := settings.DownloadBandwidthPrice.MultUint64(estBandwidth)
-	sectorAccessCost := settings.SectorAccessPrice.MultUint64(uint64(len(sectorAccesses)))
-	totalCost := settings.BaseRPCPrice.Add(bandwidthCost).Add(sectorAccessCost)
+	// construct the new revision, and verify the payment covers all requested ranges
+	newRevision := newDownloadRevision(currentRevision, req.NewRevisionNumber, req.NewValidProofValues, req.NewMissedProofValues)
+	totalCost := downloadCost(settings, req.Sectors)
 	err = verifyPaymentRevision(currentRevision, newRevision, h.blockHeight, totalCost.BigIntPtr())
 	if err != nil {
 		hostNegotiateErr = fmt.Errorf("failed to verify the payment revision: %s", err.Error())
@@ -139,37 +119,23 @@ func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
 	so.PotentialDownloadRevenue = so.PotentialDownloadRevenue.Add(paymentTransfer)
 	so.StorageContractRevisions = append(so.StorageContractRevisions, newRevision)
 
-	// fetch the requested data from host local storage
-	sectorData, err := h.ReadSector(sec.MerkleRoot)
-	if err != nil {
-		hostNegotiateErr = fmt.Errorf("host failed read sector: %s", err.Error())
-		return
-	}
-	data := sectorData[sec.Offset : sec.Offset+sec.Length]
-
-	// construct the Merkle proof, if requested.
-	var proof []common.Hash
-	if req.MerkleProof {
-		proofStart := int(sec.Offset) / merkle.LeafSize
-		proofEnd := int(sec.Offset+sec.Length) / merkle.LeafSize
-		proof, err = merkle.Sha256RangeProof(sectorData, proofStart, proofEnd)
+	// fetch each requested range from host local storage, construct the Merkle proof if
+	// requested, and send it. Sectors requested more than once are served from the sector
+	// cache of the local storage
+	for i, sec := range req.Sectors {
+		data, proof, err := h.readDownloadSector(sec, req.MerkleProof)
 		if err != nil {
-			hostNegotiateErr = fmt.Errorf("host failed to generate the merkle proof: %s", err.Error())
+			hostNegotiateErr = err
+			return
+		}
+		if err := h.waitBandwidth(sp, bandwidthDownload, uint64(len(data))); err != nil {
+			hostNegotiateErr = err
+			return
+		}
+		if err := sendDownloadData(sp, batch, i, hostSig, data, proof); err != nil {
+			log.Error("failed to send the contract download data message", "err", err)
 			return
 		}
-	}
-
-	// send the response
-	resp := storage.DownloadResponse{
-		Signature:   nil,
-		Data:        data,
-		MerkleProof: proof,
-	}
-
-	resp.Signature = hostSig
-	if err := sp.SendContractDownloadData(resp); err != nil {
-		log.Error("failed to send the contract download data message", "err", err)
-		return
 	}
 
 	// wait for client commit success msg
@@ -221,6 +187,152 @@ func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
 	}
 }
 
+// decodeDownloadRequest decodes the download request message. The single download request is
+// converted to the batched request with one sector range
+func decodeDownloadRequest(downloadReqMsg p2p.Msg, batch bool) (storage.DownloadBatchRequest, error) {
+	var req storage.DownloadBatchRequest
+	if batch {
+		if err := downloadReqMsg.Decode(&req); err != nil {
+			return req, fmt.Errorf("error decoding the download batch request message: %s", err.Error())
+		}
+		return req, nil
+	}
+	var single storage.DownloadRequest
+	if err := downloadReqMsg.Decode(&single); err != nil {
+		return req, fmt.Errorf("error decoding the download request message: %s", err.Error())
+	}
+	return storage.DownloadBatchRequest{
+		StorageContractID:    single.StorageContractID,
+		Sectors:              []storage.DownloadRequestSector{single.Sector},
+		MerkleProof:          single.MerkleProof,
+		NewRevisionNumber:    single.NewRevisionNumber,
+		NewValidProofValues:  single.NewValidProofValues,
+		NewMissedProofValues: single.NewMissedProofValues,
+		Signature:            single.Signature,
+	}, nil
+}
+
+// sendDownloadData sends the data of the requested range at index. The host signature of the
+// revision is carried by the first message
+func sendDownloadData(sp storage.Peer, batch bool, index int, hostSig []byte, data []byte, proof []common.Hash) error {
+	if index > 0 {
+		hostSig = nil
+	}
+	if !batch {
+		return sp.SendContractDownloadData(storage.DownloadResponse{
+			Signature:   hostSig,
+			Data:        data,
+			MerkleProof: proof,
+		})
+	}
+	return sp.SendContractDownloadBatchData(storage.DownloadBatchResponse{
+		Index:       uint32(index),
+		Signature:   hostSig,
+		Data:        data,
+		MerkleProof: proof,
+	})
+}
+
+// validateDownloadSector checks whether the requested sector range is valid
+func validateDownloadSector(sec storage.DownloadRequestSector, merkleProof bool) error {
+	switch {
+	case uint64(sec.Offset)+uint64(sec.Length) > storage.SectorSize:
+		return errors.New("download out boundary of sector")
+	case sec.Length == 0:
+		return errors.New("length cannot be 0")
+	case merkleProof && (sec.Offset%storage.SegmentSize != 0 || sec.Length%storage.SegmentSize != 0):
+		return errors.New("offset and length must be multiples of SegmentSize when requesting a Merkle proof")
+	}
+	return nil
+}
+
+// validateDownloadBatch checks all the requested sector ranges, and makes sure the total
+// requested length does not exceed the max download batch size of the host
+func validateDownloadBatch(sectors []storage.DownloadRequestSector, merkleProof bool, maxBatchSize uint64) error {
+	if len(sectors) == 0 {
+		return errors.New("no sector requested")
+	}
+	var totalLength uint64
+	for i, sec := range sectors {
+		if err := validateDownloadSector(sec, merkleProof); err != nil {
+			return fmt.Errorf("invalid sector %d: %s", i, err.Error())
+		}
+		totalLength += uint64(sec.Length)
+	}
+	if totalLength > maxBatchSize {
+		return fmt.Errorf("requested %v bytes exceeds the max download batch size %v", totalLength, maxBatchSize)
+	}
+	return nil
+}
+
+// validateDownloadProofValues checks the number of the new proof values matches the current revision
+func validateDownloadProofValues(currentRevision types.StorageContractRevision, validValues, missedValues []*big.Int) error {
+	if len(validValues) != len(currentRevision.NewValidProofOutputs) {
+		return errors.New("the number of valid proof values not match the old")
+	}
+	if len(missedValues) != len(currentRevision.NewMissedProofOutputs) {
+		return errors.New("the number of missed proof values not match the old")
+	}
+	return nil
+}
+
+// newDownloadRevision constructs the download payment revision based on the current revision
+// and the values provided by the client
+func newDownloadRevision(currentRevision types.StorageContractRevision, revisionNumber uint64, validValues, missedValues []*big.Int) types.StorageContractRevision {
+	newRevision := currentRevision
+	newRevision.NewRevisionNumber = revisionNumber
+	newRevision.NewValidProofOutputs = make([]types.DxcoinCharge, len(currentRevision.NewValidProofOutputs))
+	for i := range newRevision.NewValidProofOutputs {
+		newRevision.NewValidProofOutputs[i] = types.DxcoinCharge{
+			Value:   validValues[i],
+			Address: currentRevision.NewValidProofOutputs[i].Address,
+		}
+	}
+	newRevision.NewMissedProofOutputs = make([]types.DxcoinCharge, len(currentRevision.NewMissedProofOutputs))
+	for i := range newRevision.NewMissedProofOutputs {
+		newRevision.NewMissedProofOutputs[i] = types.DxcoinCharge{
+			Value:   missedValues[i],
+			Address: currentRevision.NewMissedProofOutputs[i].Address,
+		}
+	}
+	return newRevision
+}
+
+// downloadCost calculates the expected cost of downloading the sector ranges. Each distinct
+// sector is charged with one sector access, and each range is charged with its length plus
+// the worst-case merkle proof size
+func downloadCost(settings storage.HostExtConfig, sectors []storage.DownloadRequestSector) common.BigInt {
+	var estBandwidth uint64
+	sectorAccesses := make(map[common.Hash]struct{})
+	// use the worst-case proof size of 2*tree depth (this occurs when
+	// proving across the two leaves in the center of the tree)
+	estHashesPerProof := 2 * bits.Len64(storage.SectorSize/merkle.LeafSize)
+	for _, sec := range sectors {
+		estBandwidth += uint64(sec.Length) + uint64(estHashesPerProof*storage.HashSize)
+		sectorAccesses[sec.MerkleRoot] = struct{}{}
+	}
+
+	// calculate total cost
+	bandwidthCost := settings.DownloadBandwidthPrice.MultUint64(estBandwidth)
+	sectorAccessCost := settings.SectorAccessPrice.MultUint64(uint64(len(sectorAccesses)))
+	return settings.BaseRPCPrice.Add(bandwidthCost).Add(sectorAccessCost)
+}
+
+// readDownloadSector reads the requested range of the sector, and constructs the merkle proof
+// if requested. Only the range and the segments needed by the proof are read from the disk
+func (h *StorageHost) readDownloadSector(sec storage.DownloadRequestSector, merkleProof bool) (data []byte, proof []common.Hash, err error) {
+	if !merkleProof {
+		if data, err = h.ReadSectorRange(sec.MerkleRoot, uint64(sec.Offset), uint64(sec.Length)); err != nil {
+			return nil, nil, fmt.Errorf("host failed read sector: %s", err.Error())
+		}
+		return data, nil, nil
+	}
+	if data, proof, err = h.ReadSectorRangeProof(sec.MerkleRoot, uint64(sec.Offset), uint64(sec.Length)); err != nil {
+		return nil, nil, fmt.Errorf("host failed read sector: %s", err.Error())
+	}
+	return data, proof, nil
+}
+
 // verifyPaymentRevision verifies that the revision being provided to pay for
 // the data has transferred the expected amount of money from the client to the
 // host.
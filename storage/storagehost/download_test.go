@@ -0,0 +1,70 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestValidateDownloadBatch(t *testing.T) {
+	fullSector := storage.DownloadRequestSector{MerkleRoot: common.HexToHash("0x01"), Length: uint32(storage.SectorSize)}
+	defaultBatchSize := uint64(storage.DefaultMaxDownloadBatchSize)
+	tests := []struct {
+		name         string
+		sectors      []storage.DownloadRequestSector
+		merkleProof  bool
+		maxBatchSize uint64
+		expectErr    bool
+	}{
+		{"empty batch", nil, true, defaultBatchSize, true},
+		{"single sector", []storage.DownloadRequestSector{fullSector}, true, defaultBatchSize, false},
+		{"multiple sectors", []storage.DownloadRequestSector{fullSector, fullSector, fullSector}, true, 3 * storage.SectorSize, false},
+		{"exceed batch size", []storage.DownloadRequestSector{fullSector, fullSector, fullSector}, true, 3*storage.SectorSize - 1, true},
+		{"zero length", []storage.DownloadRequestSector{fullSector, {Offset: 0, Length: 0}}, true, defaultBatchSize, true},
+		{"out of boundary", []storage.DownloadRequestSector{{Offset: uint32(storage.SectorSize), Length: storage.SegmentSize}}, true, defaultBatchSize, true},
+		{"unaligned with proof", []storage.DownloadRequestSector{{Offset: 1, Length: storage.SegmentSize}}, true, defaultBatchSize, true},
+		{"unaligned without proof", []storage.DownloadRequestSector{{Offset: 1, Length: storage.SegmentSize}}, false, defaultBatchSize, false},
+	}
+	for _, test := range tests {
+		err := validateDownloadBatch(test.sectors, test.merkleProof, test.maxBatchSize)
+		if (err != nil) != test.expectErr {
+			t.Errorf("test %v: expect error %v, got %v", test.name, test.expectErr, err)
+		}
+	}
+}
+
+func TestDownloadCost(t *testing.T) {
+	settings := storage.HostExtConfig{
+		BaseRPCPrice:           common.NewBigInt(100),
+		DownloadBandwidthPrice: common.NewBigInt(2),
+		SectorAccessPrice:      common.NewBigInt(1000),
+	}
+	root1, root2 := common.HexToHash("0x01"), common.HexToHash("0x02")
+	sec1 := storage.DownloadRequestSector{MerkleRoot: root1, Length: uint32(storage.SectorSize)}
+	sec2 := storage.DownloadRequestSector{MerkleRoot: root2, Length: uint32(storage.SectorSize)}
+
+	single := downloadCost(settings, []storage.DownloadRequestSector{sec1})
+	batch := downloadCost(settings, []storage.DownloadRequestSector{sec1, sec2})
+
+	// a batch of two sectors shall be charged the base rpc price only once
+	expect := single.MultInt64(2).Sub(settings.BaseRPCPrice)
+	if batch.Cmp(expect) != 0 {
+		t.Errorf("batch download cost not expected: expect %v, got %v", expect, batch)
+	}
+
+	// ranges in the same sector shall be charged a single sector access
+	halfLength := uint32(storage.SectorSize / 2)
+	sameSector := downloadCost(settings, []storage.DownloadRequestSector{
+		{MerkleRoot: root1, Offset: 0, Length: halfLength},
+		{MerkleRoot: root1, Offset: halfLength, Length: halfLength},
+	})
+	expect = batch.Sub(settings.SectorAccessPrice).Sub(settings.DownloadBandwidthPrice.MultUint64(storage.SectorSize))
+	if sameSector.Cmp(expect) != 0 {
+		t.Errorf("same sector download cost not expected: expect %v, got %v", expect, sameSector)
+	}
+}
@@ -0,0 +1,681 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// ephemeralAccount is the prepaid balance of a storage client kept by the host. The account
+// is funded by a contract revision, and drawn down by the withdrawals signed by the owner
+type ephemeralAccount struct {
+	Address    common.Address
+	Balance    common.BigInt
+	Nonce      uint64 // nonce of the last accepted withdrawal
+	LastActive uint64 // block height the account is last funded or withdrawn
+}
+
+// expired returns whether the account has been inactive for longer than EphemeralAccountExpiry
+func (ea ephemeralAccount) expired(blockHeight uint64) bool {
+	return ea.LastActive+storage.EphemeralAccountExpiry < blockHeight
+}
+
+// EphemeralAccountBalance returns the ephemeral account balance of the address
+func (h *StorageHost) EphemeralAccountBalance(addr common.Address) (common.BigInt, error) {
+	h.eaLock.Lock()
+	defer h.eaLock.Unlock()
+
+	ea, err := getEphemeralAccount(h.db, addr)
+	if err != nil {
+		return common.BigInt0, err
+	}
+	return ea.Balance, nil
+}
+
+// fundEphemeralAccount adds the amount to the ephemeral account balance. The account will be
+// created if not exist. Returns the balance after funding
+func (h *StorageHost) fundEphemeralAccount(addr common.Address, amount common.BigInt) (common.BigInt, error) {
+	h.eaLock.Lock()
+	defer h.eaLock.Unlock()
+
+	ea, err := getEphemeralAccount(h.db, addr)
+	if err == errEphemeralAccountNotFound {
+		ea = ephemeralAccount{Address: addr}
+	} else if err != nil {
+		return common.BigInt0, err
+	}
+	if err := checkEphemeralAccountFund(ea, amount); err != nil {
+		return common.BigInt0, err
+	}
+	ea.Balance = ea.Balance.Add(amount)
+	ea.LastActive = h.GetCurrentBlockHeight()
+	if err := putEphemeralAccount(h.db, ea); err != nil {
+		return common.BigInt0, err
+	}
+	return ea.Balance, nil
+}
+
+// checkEphemeralAccountFund checks whether the account could be funded with the amount
+func checkEphemeralAccountFund(ea ephemeralAccount, amount common.BigInt) error {
+	if amount.Sign() <= 0 {
+		return errors.New("ephemeral account fund amount shall be positive")
+	}
+	if ea.Balance.Add(amount).Cmp(storage.EphemeralAccountMaxBalance) > 0 {
+		return errEphemeralAccountMaxBalance
+	}
+	return nil
+}
+
+// withdrawEphemeralAccount validates the withdrawal and draws down the account balance by the
+// withdrawal amount, which shall cover the expected cost. Returns the balance after withdrawal
+func (h *StorageHost) withdrawEphemeralAccount(w storage.EphemeralWithdrawal, cost common.BigInt) (common.BigInt, error) {
+	hostID, err := h.selfEnodeID()
+	if err != nil {
+		return common.BigInt0, err
+	}
+	if err := validateEphemeralWithdrawal(w, hostID, h.GetCurrentBlockHeight(), cost); err != nil {
+		return common.BigInt0, err
+	}
+
+	h.eaLock.Lock()
+	defer h.eaLock.Unlock()
+
+	ea, err := getEphemeralAccount(h.db, w.Account)
+	if err != nil {
+		return common.BigInt0, err
+	}
+	if w.Nonce <= ea.Nonce {
+		return common.BigInt0, errEphemeralWithdrawalReplay
+	}
+	amount := common.PtrBigInt(w.Amount)
+	if ea.Balance.Cmp(amount) < 0 {
+		return common.BigInt0, errEphemeralAccountInsufficientBalance
+	}
+	ea.Balance = ea.Balance.Sub(amount)
+	ea.Nonce = w.Nonce
+	ea.LastActive = h.GetCurrentBlockHeight()
+	if err := putEphemeralAccount(h.db, ea); err != nil {
+		return common.BigInt0, err
+	}
+	return ea.Balance, nil
+}
+
+// restoreEphemeralAccount adds back the amount withdrawn from the account, which is used when
+// the host failed to serve a request after the withdrawal is accepted
+func (h *StorageHost) restoreEphemeralAccount(addr common.Address, amount common.BigInt) {
+	h.eaLock.Lock()
+	defer h.eaLock.Unlock()
+
+	ea, err := getEphemeralAccount(h.db, addr)
+	if err != nil {
+		h.log.Error("failed to restore ephemeral account", "account", addr, "err", err)
+		return
+	}
+	ea.Balance = ea.Balance.Add(amount)
+	if err := putEphemeralAccount(h.db, ea); err != nil {
+		h.log.Error("failed to restore ephemeral account", "account", addr, "err", err)
+	}
+}
+
+// unfundEphemeralAccount draws back the amount funded to the account, which is used when the
+// funding revision is rolled back after the account is funded. The balance already spent is
+// not able to be drawn back
+func (h *StorageHost) unfundEphemeralAccount(addr common.Address, amount common.BigInt) {
+	h.eaLock.Lock()
+	defer h.eaLock.Unlock()
+
+	ea, err := getEphemeralAccount(h.db, addr)
+	if err != nil {
+		h.log.Error("failed to unfund ephemeral account", "account", addr, "err", err)
+		return
+	}
+	if ea.Balance.Cmp(amount) < 0 {
+		h.log.Warn("ephemeral account balance spent before the funding is rolled back", "account", addr, "balance", ea.Balance, "amount", amount)
+		amount = ea.Balance
+	}
+	ea.Balance = ea.Balance.Sub(amount)
+	if err := putEphemeralAccount(h.db, ea); err != nil {
+		h.log.Error("failed to unfund ephemeral account", "account", addr, "err", err)
+	}
+}
+
+// validateEphemeralWithdrawal checks the withdrawal signature, host, expiry and amount
+func validateEphemeralWithdrawal(w storage.EphemeralWithdrawal, hostID enode.ID, blockHeight uint64, cost common.BigInt) error {
+	if w.Host != hostID {
+		return errEphemeralWithdrawalWrongHost
+	}
+	if w.Amount == nil || w.Amount.Sign() < 0 {
+		return errors.New("invalid ephemeral withdrawal amount")
+	}
+	if w.Expiry < blockHeight {
+		return errEphemeralWithdrawalExpired
+	}
+	if w.Expiry > blockHeight+storage.EphemeralWithdrawalWindow {
+		return errEphemeralWithdrawalExtremeFuture
+	}
+	if common.PtrBigInt(w.Amount).Cmp(cost) < 0 {
+		return fmt.Errorf("ephemeral withdrawal amount %v does not cover the cost %v", w.Amount, cost)
+	}
+	return w.VerifySignature()
+}
+
+// pruneEphemeralAccounts deletes all ephemeral accounts expired at the current block height.
+// The balance of the expired accounts is forfeited to the host
+func (h *StorageHost) pruneEphemeralAccounts() {
+	blockHeight := h.GetCurrentBlockHeight()
+
+	h.eaLock.Lock()
+	defer h.eaLock.Unlock()
+
+	var expired []common.Address
+	iter := h.db.NewIteratorWithPrefix([]byte(prefixEphemeralAccount))
+	for iter.Next() {
+		var ea ephemeralAccount
+		if err := rlp.DecodeBytes(iter.Value(), &ea); err != nil {
+			continue
+		}
+		if ea.expired(blockHeight) {
+			expired = append(expired, ea.Address)
+		}
+	}
+	iter.Release()
+
+	for _, addr := range expired {
+		if err := deleteEphemeralAccount(h.db, addr); err != nil {
+			h.log.Warn("failed to delete expired ephemeral account", "account", addr, "err", err)
+		}
+	}
+}
+
+// getEphemeralAccount get the ephemeral account from DB
+func getEphemeralAccount(db ethdb.Database, addr common.Address) (ephemeralAccount, error) {
+	key, err := ethdb.MakeKey(prefixEphemeralAccount, addr)
+	if err != nil {
+		return ephemeralAccount{}, err
+	}
+	if exist, err := db.Has(key); err != nil {
+		return ephemeralAccount{}, err
+	} else if !exist {
+		return ephemeralAccount{}, errEphemeralAccountNotFound
+	}
+	valueBytes, err := db.Get(key)
+	if err != nil {
+		return ephemeralAccount{}, err
+	}
+	var ea ephemeralAccount
+	if err = rlp.DecodeBytes(valueBytes, &ea); err != nil {
+		return ephemeralAccount{}, err
+	}
+	return ea, nil
+}
+
+// putEphemeralAccount store the ephemeral account into DB
+func putEphemeralAccount(db ethdb.Database, ea ephemeralAccount) error {
+	scdb := ethdb.StorageContractDB{db}
+	data, err := rlp.EncodeToBytes(ea)
+	if err != nil {
+		return err
+	}
+	return scdb.StoreWithPrefix(ea.Address, data, prefixEphemeralAccount)
+}
+
+// deleteEphemeralAccount delete the ephemeral account from DB
+func deleteEphemeralAccount(db ethdb.Database, addr common.Address) error {
+	scdb := ethdb.StorageContractDB{db}
+	return scdb.DeleteWithPrefix(addr, prefixEphemeralAccount)
+}
+
+// EphemeralAccountFundHandler handles the ephemeral account fund negotiation. The client pays
+// the fund amount with a contract revision, and the amount is added to the ephemeral account
+// of the client once the revision is committed
+func EphemeralAccountFundHandler(h *StorageHost, sp storage.Peer, fundReqMsg p2p.Msg) {
+	var hostNegotiateErr, clientNegotiateErr, clientCommitErr error
+
+	defer func() {
+		if clientNegotiateErr != nil || clientCommitErr != nil {
+			_ = sp.SendHostAckMsg()
+			h.ethBackend.CheckAndUpdateConnection(sp.PeerNode())
+		} else if hostNegotiateErr != nil {
+			_ = sp.SendHostNegotiateErrorMsg()
+		}
+	}()
+
+	// read the fund request.
+	var req storage.EphemeralAccountFundRequest
+	if err := fundReqMsg.Decode(&req); err != nil {
+		clientNegotiateErr = fmt.Errorf("error decoding the ephemeral account fund request message: %s", err.Error())
+		return
+	}
+	if req.Amount == nil {
+		hostNegotiateErr = errors.New("ephemeral account fund amount not provided")
+		return
+	}
+	amount := common.PtrBigInt(req.Amount)
+
+	so, currentRevision, err := h.lockedContractRevision(req.StorageContractID)
+	if err != nil {
+		hostNegotiateErr = err
+		return
+	}
+	snapshotSo := so
+
+	// the ephemeral account is owned by the client of the contract if not specified
+	account := req.Account
+	if account == (common.Address{}) {
+		account = currentRevision.NewValidProofOutputs[0].Address
+	}
+	if err := h.checkEphemeralAccountFundable(account, amount); err != nil {
+		hostNegotiateErr = err
+		return
+	}
+
+	// construct the new revision, and verify the payment covers the fund amount
+	if err := validateDownloadProofValues(currentRevision, req.NewValidProofValues, req.NewMissedProofValues); err != nil {
+		hostNegotiateErr = fmt.Errorf("ephemeral account fund request validation failed: %s", err.Error())
+		return
+	}
+	newRevision := newDownloadRevision(currentRevision, req.NewRevisionNumber, req.NewValidProofValues, req.NewMissedProofValues)
+	if err := verifyPaymentRevision(currentRevision, newRevision, h.GetCurrentBlockHeight(), amount.BigIntPtr()); err != nil {
+		hostNegotiateErr = fmt.Errorf("failed to verify the payment revision: %s", err.Error())
+		return
+	}
+
+	hostSig, err := h.signRevision(&newRevision, req.Signature)
+	if err != nil {
+		hostNegotiateErr = err
+		return
+	}
+
+	// update the storage responsibility. The ephemeral account is used to pay for downloads
+	paymentTransfer := common.PtrBigInt(currentRevision.NewValidProofOutputs[0].Value).Sub(common.PtrBigInt(newRevision.NewValidProofOutputs[0].Value))
+	so.PotentialDownloadRevenue = so.PotentialDownloadRevenue.Add(paymentTransfer)
+	so.StorageContractRevisions = append(so.StorageContractRevisions, newRevision)
+
+	balance, err := h.EphemeralAccountBalance(account)
+	if err != nil && err != errEphemeralAccountNotFound {
+		hostNegotiateErr = err
+		return
+	}
+	resp := storage.EphemeralAccountResponse{
+		Signature: hostSig,
+		Balance:   balance.Add(amount).BigIntPtr(),
+	}
+	if err := sp.SendEphemeralAccountResponse(resp); err != nil {
+		log.Error("failed to send the ephemeral account response message", "err", err)
+		return
+	}
+
+	// the account funded is drawn back if the revision is rolled back afterwards
+	var funded, committed bool
+	committed, clientNegotiateErr, clientCommitErr = h.waitEphemeralAccountCommit(sp, so, snapshotSo, func() error {
+		if _, err := h.fundEphemeralAccount(account, amount); err != nil {
+			return err
+		}
+		funded = true
+		return nil
+	})
+	if funded && !committed {
+		h.unfundEphemeralAccount(account, amount)
+	}
+}
+
+// EphemeralAccountRefundHandler handles the ephemeral account refund negotiation. The amount of
+// the withdrawal is drawn from the ephemeral account, and transferred back from the host to the
+// client with a contract revision. The withdrawal is signed by the owner of the ephemeral account,
+// which is either the client address of the contract, or the payment key the client prepaid the
+// downloads of others with
+func EphemeralAccountRefundHandler(h *StorageHost, sp storage.Peer, refundReqMsg p2p.Msg) {
+	var hostNegotiateErr, clientNegotiateErr, clientCommitErr error
+
+	defer func() {
+		if clientNegotiateErr != nil || clientCommitErr != nil {
+			_ = sp.SendHostAckMsg()
+			h.ethBackend.CheckAndUpdateConnection(sp.PeerNode())
+		} else if hostNegotiateErr != nil {
+			_ = sp.SendHostNegotiateErrorMsg()
+		}
+	}()
+
+	// read the refund request.
+	var req storage.EphemeralAccountRefundRequest
+	if err := refundReqMsg.Decode(&req); err != nil {
+		clientNegotiateErr = fmt.Errorf("error decoding the ephemeral account refund request message: %s", err.Error())
+		return
+	}
+
+	so, currentRevision, err := h.lockedContractRevision(req.StorageContractID)
+	if err != nil {
+		hostNegotiateErr = err
+		return
+	}
+	snapshotSo := so
+
+	// construct the new revision, and verify the refund amount
+	if err := validateDownloadProofValues(currentRevision, req.NewValidProofValues, req.NewMissedProofValues); err != nil {
+		hostNegotiateErr = fmt.Errorf("ephemeral account refund request validation failed: %s", err.Error())
+		return
+	}
+	if req.Withdrawal.Amount == nil {
+		hostNegotiateErr = errors.New("ephemeral account refund amount not provided")
+		return
+	}
+	amount := common.PtrBigInt(req.Withdrawal.Amount)
+	// the refund is paid back from the download revenue funded to the ephemeral accounts
+	if so.PotentialDownloadRevenue.Cmp(amount) < 0 {
+		hostNegotiateErr = fmt.Errorf("ephemeral account refund amount %v exceeds the potential download revenue %v", amount, so.PotentialDownloadRevenue)
+		return
+	}
+	newRevision := newDownloadRevision(currentRevision, req.NewRevisionNumber, req.NewValidProofValues, req.NewMissedProofValues)
+	if err := verifyRefundRevision(currentRevision, newRevision, h.GetCurrentBlockHeight(), req.Withdrawal.Amount); err != nil {
+		hostNegotiateErr = fmt.Errorf("failed to verify the refund revision: %s", err.Error())
+		return
+	}
+
+	// draw down the ephemeral account before signing the revision. The amount will be restored
+	// if the refund revision is not committed or rolled back
+	balance, err := h.withdrawEphemeralAccount(req.Withdrawal, amount)
+	if err != nil {
+		hostNegotiateErr = err
+		return
+	}
+	var committed bool
+	defer func() {
+		if !committed {
+			h.restoreEphemeralAccount(req.Withdrawal.Account, amount)
+		}
+	}()
+
+	hostSig, err := h.signRevision(&newRevision, req.Signature)
+	if err != nil {
+		hostNegotiateErr = err
+		return
+	}
+
+	so.PotentialDownloadRevenue = so.PotentialDownloadRevenue.Sub(amount)
+	so.StorageContractRevisions = append(so.StorageContractRevisions, newRevision)
+
+	resp := storage.EphemeralAccountResponse{
+		Signature: hostSig,
+		Balance:   balance.BigIntPtr(),
+	}
+	if err := sp.SendEphemeralAccountResponse(resp); err != nil {
+		log.Error("failed to send the ephemeral account response message", "err", err)
+		return
+	}
+
+	committed, clientNegotiateErr, clientCommitErr = h.waitEphemeralAccountCommit(sp, so, snapshotSo, nil)
+}
+
+// EphemeralDownloadHandler handles the download request paid by the ephemeral account. No contract
+// revision is involved, the requested sector ranges are streamed back once the withdrawal is accepted
+func EphemeralDownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
+	var hostNegotiateErr error
+	defer func() {
+		if hostNegotiateErr != nil {
+			_ = sp.SendHostNegotiateErrorMsg()
+		}
+	}()
+
+	var req storage.EphemeralDownloadRequest
+	if err := downloadReqMsg.Decode(&req); err != nil {
+		hostNegotiateErr = fmt.Errorf("error decoding the ephemeral download request message: %s", err.Error())
+		return
+	}
+
+	settings := h.externalConfig()
+	if err := validateDownloadBatch(req.Sectors, req.MerkleProof, settings.MaxDownloadBatchSize); err != nil {
+		hostNegotiateErr = fmt.Errorf("ephemeral download request validation failed: %s", err.Error())
+		return
+	}
+
+	cost := downloadCost(settings, req.Sectors)
+	if _, err := h.withdrawEphemeralAccount(req.Withdrawal, cost); err != nil {
+		hostNegotiateErr = fmt.Errorf("failed to withdraw from ephemeral account: %s", err.Error())
+		return
+	}
+
+	// read all the requested ranges before streaming, and restore the withdrawal if the host
+	// failed to serve the request
+	resps := make([]storage.DownloadBatchResponse, len(req.Sectors))
+	for i, sec := range req.Sectors {
+		data, proof, err := h.readDownloadSector(sec, req.MerkleProof)
+		if err != nil {
+			hostNegotiateErr = err
+			break
+		}
+		resps[i] = storage.DownloadBatchResponse{
+			Index:       uint32(i),
+			Data:        data,
+			MerkleProof: proof,
+		}
+	}
+	if hostNegotiateErr != nil {
+		h.restoreEphemeralAccount(req.Withdrawal.Account, common.PtrBigInt(req.Withdrawal.Amount))
+		return
+	}
+
+	for _, resp := range resps {
+		if err := h.waitBandwidth(sp, bandwidthDownload, uint64(len(resp.Data))); err != nil {
+			log.Error("failed to wait for the download bandwidth", "err", err)
+			return
+		}
+		if err := sp.SendContractDownloadBatchData(resp); err != nil {
+			log.Error("failed to send the ephemeral download data message", "err", err)
+			return
+		}
+	}
+}
+
+// EphemeralHostConfigHandler handles the host config request paid by the ephemeral account. The
+// host charges BaseRPCPrice for the request
+func EphemeralHostConfigHandler(h *StorageHost, sp storage.Peer, configReqMsg p2p.Msg) {
+	var req storage.EphemeralHostConfigRequest
+	if err := configReqMsg.Decode(&req); err != nil {
+		_ = sp.SendHostNegotiateErrorMsg()
+		return
+	}
+
+	settings := h.externalConfig()
+	balance, err := h.withdrawEphemeralAccount(req.Withdrawal, settings.BaseRPCPrice)
+	if err != nil {
+		h.log.Debug("failed to withdraw from ephemeral account for host config", "err", err)
+		_ = sp.SendHostNegotiateErrorMsg()
+		return
+	}
+
+	resp := storage.EphemeralHostConfigResponse{
+		Config:  settings,
+		Balance: balance.BigIntPtr(),
+	}
+	if err := sp.SendEphemeralHostConfig(resp); err != nil {
+		log.Error("failed to send the ephemeral host config message", "err", err)
+	}
+}
+
+// lockedContractRevision returns the storage responsibility and the latest revision of the contract
+func (h *StorageHost) lockedContractRevision(contractID common.Hash) (StorageResponsibility, types.StorageContractRevision, error) {
+	h.lock.RLock()
+	so, err := getStorageResponsibility(h.db, contractID)
+	h.lock.RUnlock()
+	if err != nil {
+		return StorageResponsibility{}, types.StorageContractRevision{}, err
+	}
+
+	// check whether the contract is empty
+	if reflect.DeepEqual(so.OriginStorageContract, types.StorageContract{}) {
+		return StorageResponsibility{}, types.StorageContractRevision{}, errors.New("no contract locked")
+	}
+	return so, so.StorageContractRevisions[len(so.StorageContractRevisions)-1], nil
+}
+
+// checkEphemeralAccountFundable checks whether the ephemeral account could be funded with the amount
+func (h *StorageHost) checkEphemeralAccountFundable(addr common.Address, amount common.BigInt) error {
+	h.eaLock.Lock()
+	defer h.eaLock.Unlock()
+
+	ea, err := getEphemeralAccount(h.db, addr)
+	if err == errEphemeralAccountNotFound {
+		ea = ephemeralAccount{Address: addr}
+	} else if err != nil {
+		return err
+	}
+	return checkEphemeralAccountFund(ea, amount)
+}
+
+// signRevision signs the revision with the host payout address, and fills the signatures of the
+// revision with the client signature and the host signature
+func (h *StorageHost) signRevision(rev *types.StorageContractRevision, clientSig []byte) ([]byte, error) {
+	account := accounts.Account{Address: rev.NewValidProofOutputs[1].Address}
+	wallet, err := h.am.Find(account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the account address: %s", err.Error())
+	}
+
+	hostSig, err := wallet.SignHash(account, rev.RLPHash().Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("host failed to sign the revision: %s", err.Error())
+	}
+	rev.Signatures = [][]byte{clientSig, hostSig}
+	return hostSig, nil
+}
+
+// waitEphemeralAccountCommit waits for the client commit message. Once the client committed the
+// revision, the storage responsibility is updated and the onCommit is called if not nil before
+// sending the host ack message. The storage responsibility is rolled back if onCommit failed or
+// the ack message is not sent, in which case the caller shall undo the work done by onCommit.
+// Return whether the storage responsibility is committed without being rolled back
+func (h *StorageHost) waitEphemeralAccountCommit(sp storage.Peer, so, snapshotSo StorageResponsibility, onCommit func() error) (committed bool, clientNegotiateErr, clientCommitErr error) {
+	msg, err := sp.HostWaitContractResp()
+	if err != nil {
+		log.Error("storage host failed to get client commit success msg", "err", err)
+		return
+	}
+
+	switch msg.Code {
+	case storage.ClientCommitSuccessMsg:
+	case storage.ClientCommitFailedMsg:
+		return false, nil, storage.ErrClientCommit
+	case storage.ClientNegotiateErrorMsg:
+		return false, storage.ErrClientNegotiate, nil
+	default:
+		return false, nil, nil
+	}
+
+	err = h.modifyStorageResponsibility(so, nil, nil, nil)
+	if err == nil && onCommit != nil {
+		if err = onCommit(); err != nil {
+			log.Error("storage host failed to update the ephemeral account", "err", err)
+			_ = h.rollbackStorageResponsibility(snapshotSo, nil, nil, nil)
+		}
+	}
+	if err != nil {
+		_ = sp.SendHostCommitFailedMsg()
+
+		// wait for client ack msg
+		if _, err = sp.HostWaitContractResp(); err != nil {
+			log.Error("storage host failed to get client ack msg", "err", err)
+			return
+		}
+
+		// host send the last ack msg and return
+		_ = sp.SendHostAckMsg()
+		return
+	}
+
+	// send host 'ACK' msg to client
+	if err := sp.SendHostAckMsg(); err != nil {
+		log.Error("storage host failed to send host ack msg", "err", err)
+		_ = h.rollbackStorageResponsibility(snapshotSo, nil, nil, nil)
+		h.ethBackend.CheckAndUpdateConnection(sp.PeerNode())
+		return
+	}
+	return true, nil, nil
+}
+
+// verifyRefundRevision verifies that the revision transfers exactly the refund amount from the
+// host back to the client, with all the non-volatile fields unchanged
+func verifyRefundRevision(existingRevision, refundRevision types.StorageContractRevision, blockHeight uint64, amount *big.Int) error {
+	// Check that the revision is well-formed.
+	if len(refundRevision.NewValidProofOutputs) != 2 || len(refundRevision.NewMissedProofOutputs) != 2 {
+		return errBadContractOutputCounts
+	}
+	if existingRevision.NewWindowStart-postponedExecutionBuffer <= blockHeight {
+		return errLateRevision
+	}
+
+	// payout addresses shouldn't change
+	for i := range existingRevision.NewValidProofOutputs {
+		if refundRevision.NewValidProofOutputs[i].Address != existingRevision.NewValidProofOutputs[i].Address ||
+			refundRevision.NewMissedProofOutputs[i].Address != existingRevision.NewMissedProofOutputs[i].Address {
+			return errors.New("payout address changed during refunding")
+		}
+	}
+
+	// host shall not refund more than its valid proof output
+	if existingRevision.NewValidProofOutputs[1].Value.Cmp(amount) < 0 {
+		return errLowHostValidOutput
+	}
+
+	// the exact refund amount shall be transferred from the host to the client, and the
+	// client missed output burnt during downloading is recovered
+	toClient := new(big.Int).Sub(refundRevision.NewValidProofOutputs[0].Value, existingRevision.NewValidProofOutputs[0].Value)
+	fromHost := new(big.Int).Sub(existingRevision.NewValidProofOutputs[1].Value, refundRevision.NewValidProofOutputs[1].Value)
+	if toClient.Cmp(amount) != 0 || fromHost.Cmp(amount) != 0 {
+		s := fmt.Sprintf("expected exactly %v to be refunded to the client during refunding: ", amount)
+		return ExtendErr(s, errHighClientValidOutput)
+	}
+	missedToClient := new(big.Int).Sub(refundRevision.NewMissedProofOutputs[0].Value, existingRevision.NewMissedProofOutputs[0].Value)
+	if missedToClient.Cmp(amount) > 0 {
+		return errHighClientMissedOutput
+	}
+	if refundRevision.NewMissedProofOutputs[1].Value.Cmp(existingRevision.NewMissedProofOutputs[1].Value) != 0 {
+		return errors.New("host missed proof payout changed during refunding")
+	}
+	if refundRevision.NewValidProofOutputs[0].Value.Cmp(refundRevision.NewMissedProofOutputs[0].Value) > 0 {
+		return errHighClientMissedOutput
+	}
+
+	// Check that the revision count has increased.
+	if refundRevision.NewRevisionNumber <= existingRevision.NewRevisionNumber {
+		return errBadRevisionNumber
+	}
+
+	// Check that all of the non-volatile fields are the same.
+	if refundRevision.ParentID != existingRevision.ParentID {
+		return errBadParentID
+	}
+	if refundRevision.UnlockConditions.UnlockHash() != existingRevision.UnlockConditions.UnlockHash() {
+		return errBadUnlockConditions
+	}
+	if refundRevision.NewFileSize != existingRevision.NewFileSize {
+		return errBadFileSize
+	}
+	if refundRevision.NewFileMerkleRoot != existingRevision.NewFileMerkleRoot {
+		return errBadFileMerkleRoot
+	}
+	if refundRevision.NewWindowStart != existingRevision.NewWindowStart {
+		return errBadWindowStart
+	}
+	if refundRevision.NewWindowEnd != existingRevision.NewWindowEnd {
+		return errBadWindowEnd
+	}
+	if refundRevision.NewUnlockHash != existingRevision.NewUnlockHash {
+		return errBadUnlockHash
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestStorageHost_EphemeralAccount(t *testing.T) {
+	h := newTestStorageHost(t)
+	h.blockHeight = 100
+	hostKey, _ := crypto.GenerateKey()
+	hostNode := enode.NewV4(&hostKey.PublicKey, net.ParseIP("127.0.0.1"), 30303, 30303)
+	h.ethBackend = &mockHostBackend{enodeURL: hostNode.String()}
+	hostID := hostNode.ID()
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	if _, err := h.EphemeralAccountBalance(addr); err != errEphemeralAccountNotFound {
+		t.Fatalf("expect error %v, got %v", errEphemeralAccountNotFound, err)
+	}
+	balance, err := h.fundEphemeralAccount(addr, common.NewBigInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Cmp(common.NewBigInt(1000)) != 0 {
+		t.Fatalf("balance after funding not expected: %v", balance)
+	}
+	if _, err := h.fundEphemeralAccount(addr, storage.EphemeralAccountMaxBalance); err != errEphemeralAccountMaxBalance {
+		t.Fatalf("expect error %v, got %v", errEphemeralAccountMaxBalance, err)
+	}
+
+	// withdraw 300 with nonce 1
+	w := newTestWithdrawal(t, key, hostID, 300, h.blockHeight+1, 1)
+	if balance, err = h.withdrawEphemeralAccount(w, common.NewBigInt(200)); err != nil {
+		t.Fatal(err)
+	}
+	if balance.Cmp(common.NewBigInt(700)) != 0 {
+		t.Fatalf("balance after withdrawal not expected: %v", balance)
+	}
+
+	tests := []struct {
+		name       string
+		withdrawal storage.EphemeralWithdrawal
+		cost       int64
+		err        error
+	}{
+		{"replay", w, 200, errEphemeralWithdrawalReplay},
+		{"expired", newTestWithdrawal(t, key, hostID, 300, h.blockHeight-1, 2), 200, errEphemeralWithdrawalExpired},
+		{"extreme future", newTestWithdrawal(t, key, hostID, 300, h.blockHeight+storage.EphemeralWithdrawalWindow+1, 2), 200, errEphemeralWithdrawalExtremeFuture},
+		{"insufficient balance", newTestWithdrawal(t, key, hostID, 701, h.blockHeight, 2), 200, errEphemeralAccountInsufficientBalance},
+		{"another host", newTestWithdrawal(t, key, enode.ID{1}, 300, h.blockHeight, 2), 200, errEphemeralWithdrawalWrongHost},
+	}
+	for _, test := range tests {
+		if _, err := h.withdrawEphemeralAccount(test.withdrawal, common.NewBigInt(test.cost)); err != test.err {
+			t.Errorf("test %v: expect error %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	// amount not covering the cost, and tampered withdrawals shall be rejected
+	if _, err := h.withdrawEphemeralAccount(newTestWithdrawal(t, key, hostID, 100, h.blockHeight, 2), common.NewBigInt(200)); err == nil {
+		t.Error("withdrawal not covering the cost shall be rejected")
+	}
+	tampered := newTestWithdrawal(t, key, hostID, 100, h.blockHeight, 2)
+	tampered.Amount = big.NewInt(1)
+	if _, err := h.withdrawEphemeralAccount(tampered, common.NewBigInt(0)); err == nil {
+		t.Error("tampered withdrawal shall be rejected")
+	}
+	redirected := newTestWithdrawal(t, key, enode.ID{1}, 100, h.blockHeight, 2)
+	redirected.Host = hostID
+	if _, err := h.withdrawEphemeralAccount(redirected, common.NewBigInt(0)); err == nil {
+		t.Error("withdrawal signed for another host shall be rejected")
+	}
+
+	// the funding rolled back is drawn back
+	if _, err = h.fundEphemeralAccount(addr, common.NewBigInt(500)); err != nil {
+		t.Fatal(err)
+	}
+	h.unfundEphemeralAccount(addr, common.NewBigInt(500))
+	if balance, err = h.EphemeralAccountBalance(addr); err != nil || balance.Cmp(common.NewBigInt(700)) != 0 {
+		t.Fatalf("balance after unfunding not expected: %v, %v", balance, err)
+	}
+
+	// the account shall survive the host restart
+	h.db.Close()
+	if h.db, err = openDB(filepath.Join(h.persistDir, databaseFile)); err != nil {
+		t.Fatal(err)
+	}
+	if balance, err = h.EphemeralAccountBalance(addr); err != nil {
+		t.Fatal(err)
+	}
+	if balance.Cmp(common.NewBigInt(700)) != 0 {
+		t.Fatalf("balance after restart not expected: %v", balance)
+	}
+
+	// the account expires after being inactive for EphemeralAccountExpiry blocks
+	h.blockHeight += storage.EphemeralAccountExpiry
+	h.pruneEphemeralAccounts()
+	if _, err := h.EphemeralAccountBalance(addr); err != nil {
+		t.Fatalf("account shall not be expired: %v", err)
+	}
+	h.blockHeight++
+	h.pruneEphemeralAccounts()
+	if _, err := h.EphemeralAccountBalance(addr); err != errEphemeralAccountNotFound {
+		t.Fatalf("expect error %v, got %v", errEphemeralAccountNotFound, err)
+	}
+}
+
+func TestVerifyRefundRevision(t *testing.T) {
+	existing := types.StorageContractRevision{
+		NewRevisionNumber: 1,
+		NewWindowStart:    postponedExecutionBuffer + 1000,
+		NewValidProofOutputs: []types.DxcoinCharge{
+			{Address: common.HexToAddress("0x01"), Value: big.NewInt(500)},
+			{Address: common.HexToAddress("0x02"), Value: big.NewInt(1500)},
+		},
+		NewMissedProofOutputs: []types.DxcoinCharge{
+			{Address: common.HexToAddress("0x01"), Value: big.NewInt(500)},
+			{Address: common.HexToAddress("0x02"), Value: big.NewInt(1000)},
+		},
+	}
+	refund := func(valid0, valid1, missed0 int64) types.StorageContractRevision {
+		rev := existing
+		rev.NewRevisionNumber++
+		rev.NewValidProofOutputs = []types.DxcoinCharge{
+			{Address: existing.NewValidProofOutputs[0].Address, Value: big.NewInt(valid0)},
+			{Address: existing.NewValidProofOutputs[1].Address, Value: big.NewInt(valid1)},
+		}
+		rev.NewMissedProofOutputs = []types.DxcoinCharge{
+			{Address: existing.NewMissedProofOutputs[0].Address, Value: big.NewInt(missed0)},
+			existing.NewMissedProofOutputs[1],
+		}
+		return rev
+	}
+
+	tests := []struct {
+		name      string
+		revision  types.StorageContractRevision
+		amount    int64
+		expectErr bool
+	}{
+		{"valid refund", refund(600, 1400, 600), 100, false},
+		{"refund more than amount", refund(700, 1300, 700), 100, true},
+		{"host not paying", refund(600, 1500, 600), 100, true},
+		{"high client missed output", refund(600, 1400, 800), 100, true},
+		{"low client missed output", refund(600, 1400, 500), 100, true},
+		{"refund all host output", refund(2000, 0, 2000), 1500, false},
+		{"more than host output", refund(2100, -100, 2100), 1600, true},
+	}
+	for _, test := range tests {
+		err := verifyRefundRevision(existing, test.revision, 0, big.NewInt(test.amount))
+		if (err != nil) != test.expectErr {
+			t.Errorf("test %v: expect error %v, got %v", test.name, test.expectErr, err)
+		}
+	}
+}
+
+// newTestWithdrawal creates a withdrawal to the host signed by the key
+func newTestWithdrawal(t *testing.T, key *ecdsa.PrivateKey, hostID enode.ID, amount int64, expiry uint64, nonce uint64) storage.EphemeralWithdrawal {
+	w := storage.EphemeralWithdrawal{
+		Account: crypto.PubkeyToAddress(key.PublicKey),
+		Host:    hostID,
+		Amount:  big.NewInt(amount),
+		Expiry:  expiry,
+		Nonce:   nonce,
+	}
+	sig, err := crypto.Sign(w.RLPHash().Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Signature = sig
+	return w
+}
@@ -48,6 +48,12 @@ func (h *StorageHost) hostBlockHeightChange(cce core.ChainChangeEvent) {
 	// update the contractToClientID
 	h.UpdateContractToClientNodeMappingAndConnection()
 
+	// remove the expired ephemeral accounts
+	h.pruneEphemeralAccounts()
+
+	// renew the host announcement if it is about to expire
+	h.renewAnnouncement()
+
 	// sync the configuration
 	err := h.syncConfig()
 	if err != nil {
@@ -79,7 +79,9 @@ var spf = types.StorageProof{
 	Signature: []byte("0x14564645456"),
 }
 
-type mockHostBackend struct{}
+type mockHostBackend struct {
+	enodeURL string
+}
 
 func mockBlockHeader(number uint64) *types.Header {
 	return &types.Header{
@@ -183,6 +185,7 @@ func (m *mockHostBackend) AccountManager() *accounts.Manager             { retur
 func (m *mockHostBackend) SetStatic(node *enode.Node)                    {}
 func (m *mockHostBackend) CheckAndUpdateConnection(peerNode *enode.Node) {}
 func (m *mockHostBackend) APIs() []rpc.API                               { return nil }
+func (m *mockHostBackend) SelfEnodeURL() string                          { return m.enodeURL }
 
 func TestGetAllStorageContractIDsWithBlockHash(t *testing.T) {
 	host := &StorageHost{}
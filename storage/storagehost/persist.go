@@ -17,6 +17,9 @@ type persistence struct {
 	FinancialMetrics HostFinancialMetrics   `json:"financialmetrics"`
 	Config           storage.HostIntConfig  `json:"config"`
 	Contracts        map[string]common.Hash `json:"contracts"`
+
+	AnnounceSequence   uint64 `json:"announceSequence"`
+	AnnounceExpiration uint64 `json:"announceExpiration"`
 }
 
 // save the host config: the filed as persistence shown, to the json file
@@ -50,6 +53,9 @@ func (h *StorageHost) extractPersistence() *persistence {
 		FinancialMetrics: h.financialMetrics,
 		Config:           h.config,
 		Contracts:        h.clientToContract,
+
+		AnnounceSequence:   h.announceSequence,
+		AnnounceExpiration: h.announceExpiration,
 	}
 }
 
@@ -60,4 +66,6 @@ func (h *StorageHost) loadPersistence(persist *persistence) {
 	h.financialMetrics = persist.FinancialMetrics
 	h.config = persist.Config
 	h.clientToContract = persist.Contracts
+	h.announceSequence = persist.AnnounceSequence
+	h.announceExpiration = persist.AnnounceExpiration
 }
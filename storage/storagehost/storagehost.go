@@ -41,14 +41,22 @@ type StorageHost struct {
 	lockedStorageResponsibility map[common.Hash]*TryMutex
 	clientToContract            map[string]common.Hash
 
+	// bandwidth scheduler throttling the data transfer with the clients
+	bandwidth *bandwidthScheduler
+
+	// status of the latest host announcement
+	announceSequence   uint64
+	announceExpiration uint64
+
 	// things for log and persistence
 	db         *ethdb.LDBDatabase
 	persistDir string
 	log        log.Logger
 
 	// things for thread safety
-	lock sync.RWMutex
-	tm   tm.ThreadManager
+	lock   sync.RWMutex
+	eaLock sync.Mutex // protects the ephemeral accounts stored in db
+	tm     tm.ThreadManager
 }
 
 // IsContractSignedWithClient check whether this host signed a contract with the given client
@@ -109,6 +117,7 @@ func New(persistDir string) (*StorageHost, error) {
 		persistDir:                  persistDir,
 		lockedStorageResponsibility: make(map[common.Hash]*TryMutex),
 		clientToContract:            make(map[string]common.Hash),
+		bandwidth:                   newBandwidthScheduler(),
 	}
 
 	var err error
@@ -215,6 +224,15 @@ func (h *StorageHost) load() error {
 	return nil
 }
 
+// selfEnodeID returns the enode ID of the host node
+func (h *StorageHost) selfEnodeID() (enode.ID, error) {
+	node, err := enode.ParseV4(h.ethBackend.SelfEnodeURL())
+	if err != nil {
+		return enode.ID{}, fmt.Errorf("failed to parse the host enode url: %s", err.Error())
+	}
+	return node.ID(), nil
+}
+
 // getPaymentAddress get the current payment address. If no address is set, assign the first
 // account address as the payment address
 func (h *StorageHost) getPaymentAddress() (common.Address, error) {
@@ -85,6 +85,10 @@ func (sm *storageManager) AddSector(root common.Hash, data []byte) (err error) {
 		}
 		return
 	}
+	// cache the proof segment roots of the new sector
+	if uint64(len(data)) == storage.SectorSize && update.folder != nil {
+		sm.segmentRoots.add(update.id, update.folder.id, sectorSegmentRoots(data))
+	}
 	return
 }
 
@@ -4,6 +4,12 @@
 
 package storagemanager
 
+import (
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
 const (
 	// database related keys and prefixes
 	prefixFolder         = "storageFolder"
@@ -32,6 +38,25 @@ const (
 	opNameRelocateSector = "relocate sector"
 )
 
+const (
+	// sectorCacheSize is the maximum size of the sector data cached in memory
+	sectorCacheSize = 64 * storage.SectorSize
+
+	// proofSegmentSize is the size of the sector segment whose merkle root is cached, so
+	// that the merkle proof of a sector range only needs to read the segments at the range
+	// boundaries from the disk
+	proofSegmentSize = uint64(1 << 16)
+
+	// proofSegmentLeaves is the number of merkle leaves in a proof segment, which is
+	// 1 << proofSegmentHeight
+	proofSegmentLeaves = proofSegmentSize / merkle.LeafSize
+	proofSegmentHeight = 10
+
+	// segmentRootsCacheSize is the maximum size of the segment roots cached in memory, which
+	// covers 1 << 14 sectors
+	segmentRootsCacheSize = (1 << 14) * storage.SectorSize / proofSegmentSize * common.HashLength
+)
+
 const (
 	databaseFileName = "storagemanager.db"
 	walFileName      = "storagemanager.wal"
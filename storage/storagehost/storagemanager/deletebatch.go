@@ -71,6 +71,9 @@ func (sm *storageManager) DeleteSectorBatch(roots []common.Hash) (err error) {
 	defer sm.lock.Unlock()
 	// create the update and record the intent
 	update := sm.createDeleteSectorBatchUpdate(roots)
+	// the cached sectors are invalidated no matter the update succeed or not
+	defer sm.sectorCache.remove(update.ids...)
+	defer sm.segmentRoots.remove(update.ids...)
 	if err = update.recordIntent(sm); err != nil {
 		return err
 	}
@@ -8,49 +8,167 @@ import (
 	"fmt"
 
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/syndtr/goleveldb/leveldb"
 )
 
-//ReadSector read the sector data
+// ReadSector read the sector data
 func (sm *storageManager) ReadSector(root common.Hash) (data []byte, err error) {
 	sm.lock.RLock()
 	defer sm.lock.RUnlock()
 
-	// calculate the sector id
+	// calculate the sector id and locate the sector before checking the cache, so that
+	// the sector in the unavailable folder is never served
 	id := sm.calculateSectorID(root)
+	folder, index, err := sm.locateSector(id)
+	if err != nil {
+		return nil, err
+	}
+	if cached, exist := sm.sectorCache.get(id); exist {
+		return common.CopyBytes(cached), nil
+	}
+	if data, err = sm.readSectorData(id, folder, index); err != nil {
+		return nil, err
+	}
+	return common.CopyBytes(data), nil
+}
+
+// ReadSectorRange read length bytes of the sector data starting from offset. If the sector
+// is not cached, only the requested range is read from the disk
+func (sm *storageManager) ReadSectorRange(root common.Hash, offset, length uint64) (data []byte, err error) {
+	if err = validateSectorRange(offset, length); err != nil {
+		return nil, err
+	}
+	sm.lock.RLock()
+	defer sm.lock.RUnlock()
+
+	// calculate the sector id, locate the sector and check the cache
+	id := sm.calculateSectorID(root)
+	folder, index, err := sm.locateSector(id)
+	if err != nil {
+		return nil, err
+	}
+	if cached, exist := sm.sectorCache.get(id); exist {
+		return common.CopyBytes(cached[offset : offset+length]), nil
+	}
+	return readSectorRange(folder, index, offset, length)
+}
+
+// ReadSectorRangeProof read length bytes of the sector data starting from offset, and
+// constructs the merkle range proof of the data. With the proof segment roots cached, only
+// the requested range and the proof segments at the range boundaries are read from the disk.
+// Otherwise the whole sector is read once to calculate the proof segment roots
+func (sm *storageManager) ReadSectorRangeProof(root common.Hash, offset, length uint64) (data []byte, proof []common.Hash, err error) {
+	if err = validateSectorRange(offset, length); err != nil {
+		return nil, nil, err
+	}
+	if offset%merkle.LeafSize != 0 || length%merkle.LeafSize != 0 {
+		return nil, nil, fmt.Errorf("sector range not aligned with merkle leaves: offset %v, length %v", offset, length)
+	}
+	proofStart, proofEnd := int(offset/merkle.LeafSize), int((offset+length)/merkle.LeafSize)
+
+	sm.lock.RLock()
+	defer sm.lock.RUnlock()
+
+	// calculate the sector id, locate the sector and check the cache
+	id := sm.calculateSectorID(root)
+	folder, index, err := sm.locateSector(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	sectorData, exist := sm.sectorCache.get(id)
+	roots, rootsExist := sm.segmentRoots.get(id)
+	if !exist && !rootsExist {
+		if sectorData, err = sm.readSectorData(id, folder, index); err != nil {
+			return nil, nil, err
+		}
+		exist = true
+	}
+	if exist {
+		if proof, err = merkle.Sha256RangeProof(sectorData, proofStart, proofEnd); err != nil {
+			return nil, nil, err
+		}
+		return common.CopyBytes(sectorData[offset : offset+length]), proof, nil
+	}
+
+	// read the range and the proof segments needed by the proof from the disk
+	if data, err = readSectorRange(folder, index, offset, length); err != nil {
+		return nil, nil, err
+	}
+	sr := newSegmentSubtreeRoot(roots, func(segment uint64) ([]byte, error) {
+		return readSectorRange(folder, index, segment*proofSegmentSize, proofSegmentSize)
+	})
+	proofSet, err := merkle.GetLimitStorageProof(proofStart, proofEnd, sr)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, p := range proofSet {
+		proof = append(proof, common.BytesToHash(p))
+	}
+	return data, proof, nil
+}
+
+// readSectorData reads the whole sector from the folder, and caches the sector data
+// and the proof segment roots. The returned data shall not be modified
+func (sm *storageManager) readSectorData(id sectorID, folder *storageFolder, index uint64) (data []byte, err error) {
+	if data, err = readSectorRange(folder, index, 0, storage.SectorSize); err != nil {
+		return nil, err
+	}
+	sm.sectorCache.add(id, folder.id, data)
+	sm.segmentRoots.add(id, folder.id, sectorSegmentRoots(data))
+	return data, nil
+}
+
+// readSectorRange reads length bytes of the sector at the index of the folder starting
+// from offset
+func readSectorRange(folder *storageFolder, index, offset, length uint64) (data []byte, err error) {
+	data = make([]byte, length)
+	n, err := folder.dataFile.ReadAt(data, int64(index*storage.SectorSize+offset))
+	if uint64(n) != length {
+		return nil, fmt.Errorf("cannot read the sector: read %v bytes, expect %v bytes", n, length)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the sector: %v", err)
+	}
+	return data, nil
+}
+
+// validateSectorRange checks the sector range is within the sector
+func validateSectorRange(offset, length uint64) error {
+	if offset+length < offset || offset+length > storage.SectorSize {
+		return fmt.Errorf("sector range out of bounds: offset %v, length %v", offset, length)
+	}
+	return nil
+}
+
+// SectorCacheStats returns the statistics of the sector read cache
+func (sm *storageManager) SectorCacheStats() storage.HostSectorCacheStats {
+	return sm.sectorCache.stats()
+}
+
+// locateSector returns the storage folder and the index in folder where the sector is stored
+func (sm *storageManager) locateSector(id sectorID) (folder *storageFolder, index uint64, err error) {
 	// get the sector from database
-	var s *sector
-	s, err = sm.db.getSector(id)
+	s, err := sm.db.getSector(id)
 	if err != nil {
 		if err == leveldb.ErrNotFound {
 			err = ErrNotFound
 		}
 		return
 	}
-	folderID, index := s.folderID, s.index
 	// get the folder path
-	folderPath, err := sm.db.getFolderPath(folderID)
+	folderPath, err := sm.db.getFolderPath(s.folderID)
 	if err != nil {
-		return nil, fmt.Errorf("db data might be corrupted: %v", err)
+		return nil, 0, fmt.Errorf("db data might be corrupted: %v", err)
 	}
 	// Get the folder from memory
-	folder, err := sm.folders.get(folderPath)
+	folder, err = sm.folders.get(folderPath)
 	if err != nil {
-		return nil, fmt.Errorf("check folder in memory: %v", err)
+		return nil, 0, fmt.Errorf("check folder in memory: %v", err)
 	}
 	if folder.status == folderUnavailable {
-		return nil, fmt.Errorf("folder status unavailable")
-	}
-
-	// Read the data from folder
-	data = make([]byte, storage.SectorSize)
-	n, err := folder.dataFile.ReadAt(data, int64(index*storage.SectorSize))
-	if uint64(n) != storage.SectorSize {
-		return nil, fmt.Errorf("cannot read the sector: read %v bytes, expect %v bytes", n, storage.SectorSize)
-	}
-	if err != nil {
-		return nil, fmt.Errorf("cannot read the sector: %v", err)
+		return nil, 0, fmt.Errorf("folder status unavailable")
 	}
-	return
+	return folder, s.index, nil
 }
@@ -0,0 +1,87 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestReadSectorRangeProof test the range proof read from the sector is the same no matter
+// the sector data, the proof segment roots, or neither of them are cached
+func TestReadSectorRangeProof(t *testing.T) {
+	sm := newTestStorageManager(t, "", newDisruptor())
+	defer sm.shutdown(t, time.Second)
+	path := randomFolderPath(t, "")
+	if err := sm.AddStorageFolder(path, 1<<25); err != nil {
+		t.Fatal(err)
+	}
+	data := randomBytes(storage.SectorSize)
+	root := merkle.Sha256MerkleTreeRoot(data)
+	if err := sm.AddSector(root, data); err != nil {
+		t.Fatal(err)
+	}
+	id := sm.calculateSectorID(root)
+
+	tests := []struct {
+		offset, length uint64
+	}{
+		{0, merkle.LeafSize},
+		{5 * merkle.LeafSize, 3 * merkle.LeafSize},
+		{proofSegmentSize - merkle.LeafSize, 2 * merkle.LeafSize},
+		{proofSegmentSize, 2 * proofSegmentSize},
+		{100 * merkle.LeafSize, 4096},
+		{storage.SectorSize - merkle.LeafSize, merkle.LeafSize},
+		{0, storage.SectorSize},
+	}
+	caches := []struct {
+		name  string
+		reset func()
+	}{
+		{"segment roots", func() { sm.sectorCache.remove(id) }},
+		{"nothing", func() { sm.sectorCache.remove(id); sm.segmentRoots.remove(id) }},
+		{"sector data", func() { sm.segmentRoots.remove(id) }},
+	}
+	for _, cache := range caches {
+		for i, test := range tests {
+			cache.reset()
+			got, proof, err := sm.ReadSectorRangeProof(root, test.offset, test.length)
+			if err != nil {
+				t.Fatalf("%v cached, test %v: %v", cache.name, i, err)
+			}
+			if !bytes.Equal(got, data[test.offset:test.offset+test.length]) {
+				t.Errorf("%v cached, test %v: data not expected", cache.name, i)
+			}
+			start, end := int(test.offset/merkle.LeafSize), int((test.offset+test.length)/merkle.LeafSize)
+			if verified, err := merkle.Sha256VerifyRangeProof(got, proof, start, end, root); err != nil || !verified {
+				t.Errorf("%v cached, test %v: proof not verified: %v", cache.name, i, err)
+			}
+		}
+	}
+	if _, _, err := sm.ReadSectorRangeProof(root, 1, merkle.LeafSize); err == nil {
+		t.Error("expect error reading the range not aligned with merkle leaves")
+	}
+
+	// the cached sector in the unavailable folder shall not be served
+	if _, err := sm.ReadSector(root); err != nil {
+		t.Fatal(err)
+	}
+	sf, err := sm.folders.get(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf.status = folderUnavailable
+	if _, err := sm.ReadSector(root); err == nil {
+		t.Error("expect error reading the cached sector in the unavailable folder")
+	}
+	if _, _, err := sm.ReadSectorRangeProof(root, 0, merkle.LeafSize); err == nil {
+		t.Error("expect error reading the cached sector range in the unavailable folder")
+	}
+	sf.status = folderAvailable
+}
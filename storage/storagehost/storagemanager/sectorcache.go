@@ -0,0 +1,129 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+type (
+	// sectorCache is the size aware LRU cache of the sector data read from the disk.
+	// The total size of the cached data never exceeds the capacity
+	sectorCache struct {
+		capacity uint64
+		size     uint64
+
+		entries map[sectorID]*list.Element
+		lru     *list.List
+
+		hits      uint64
+		misses    uint64
+		evictions uint64
+
+		lock sync.Mutex
+	}
+
+	// sectorCacheEntry is the entry in the sector cache. The folder is where the sector
+	// is stored, so that the sectors are invalidated with the folder
+	sectorCacheEntry struct {
+		id     sectorID
+		folder folderID
+		data   []byte
+	}
+)
+
+// newSectorCache creates a new sector cache with the capacity in bytes
+func newSectorCache(capacity uint64) *sectorCache {
+	return &sectorCache{
+		capacity: capacity,
+		entries:  make(map[sectorID]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// get returns the cached sector data. The returned data shall not be modified
+func (sc *sectorCache) get(id sectorID) (data []byte, exist bool) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	elem, exist := sc.entries[id]
+	if !exist {
+		sc.misses++
+		return nil, false
+	}
+	sc.hits++
+	sc.lru.MoveToFront(elem)
+	return elem.Value.(*sectorCacheEntry).data, true
+}
+
+// add adds the data of the sector stored in the folder to the cache, evicting the least
+// recently used sectors until the data fits in the capacity
+func (sc *sectorCache) add(id sectorID, folder folderID, data []byte) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	size := uint64(len(data))
+	if size > sc.capacity {
+		return
+	}
+	if elem, exist := sc.entries[id]; exist {
+		sc.removeElement(elem)
+	}
+	for sc.size+size > sc.capacity {
+		sc.removeElement(sc.lru.Back())
+		sc.evictions++
+	}
+	sc.entries[id] = sc.lru.PushFront(&sectorCacheEntry{id: id, folder: folder, data: data})
+	sc.size += size
+}
+
+// remove invalidates the cached sectors
+func (sc *sectorCache) remove(ids ...sectorID) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	for _, id := range ids {
+		if elem, exist := sc.entries[id]; exist {
+			sc.removeElement(elem)
+		}
+	}
+}
+
+// removeFolder invalidates all cached sectors stored in the folder
+func (sc *sectorCache) removeFolder(folder folderID) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	for _, elem := range sc.entries {
+		if elem.Value.(*sectorCacheEntry).folder == folder {
+			sc.removeElement(elem)
+		}
+	}
+}
+
+// removeElement removes the element from the cache
+func (sc *sectorCache) removeElement(elem *list.Element) {
+	entry := sc.lru.Remove(elem).(*sectorCacheEntry)
+	delete(sc.entries, entry.id)
+	sc.size -= uint64(len(entry.data))
+}
+
+// stats returns the statistics of the sector cache
+func (sc *sectorCache) stats() storage.HostSectorCacheStats {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	return storage.HostSectorCacheStats{
+		Hits:      sc.hits,
+		Misses:    sc.misses,
+		Evictions: sc.evictions,
+		Sectors:   uint64(len(sc.entries)),
+		Size:      sc.size,
+		Capacity:  sc.capacity,
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestSectorCache_Eviction test the least recently used sectors are evicted
+// once the cached size exceeds the capacity
+func TestSectorCache_Eviction(t *testing.T) {
+	sc := newSectorCache(30)
+	ids := []sectorID{{1}, {2}, {3}, {4}}
+	for _, id := range ids[:3] {
+		sc.add(id, 0, bytes.Repeat([]byte{id[0]}, 10))
+	}
+	// access the first sector so that the second one is the least recently used
+	if data, exist := sc.get(ids[0]); !exist || !bytes.Equal(data, bytes.Repeat([]byte{1}, 10)) {
+		t.Fatalf("sector %v shall be cached", ids[0])
+	}
+	sc.add(ids[3], 0, bytes.Repeat([]byte{4}, 10))
+	if _, exist := sc.get(ids[1]); exist {
+		t.Errorf("sector %v shall be evicted", ids[1])
+	}
+	for _, id := range []sectorID{ids[0], ids[2], ids[3]} {
+		if _, exist := sc.get(id); !exist {
+			t.Errorf("sector %v shall be cached", id)
+		}
+	}
+	// data larger than the capacity is never cached
+	sc.add(sectorID{5}, 0, make([]byte, 31))
+	if _, exist := sc.get(sectorID{5}); exist {
+		t.Error("sector larger than the capacity shall not be cached")
+	}
+
+	stats := sc.stats()
+	if stats.Hits != 4 || stats.Misses != 2 || stats.Evictions != 1 {
+		t.Errorf("unexpected hits / misses / evictions: %v / %v / %v", stats.Hits, stats.Misses, stats.Evictions)
+	}
+	if stats.Sectors != 3 || stats.Size != 30 || stats.Capacity != 30 {
+		t.Errorf("unexpected sectors / size / capacity: %v / %v / %v", stats.Sectors, stats.Size, stats.Capacity)
+	}
+}
+
+// TestSectorCache_Remove test the invalidated sectors are removed from the cache
+func TestSectorCache_Remove(t *testing.T) {
+	sc := newSectorCache(100)
+	sc.add(sectorID{1}, 0, make([]byte, 10))
+	sc.add(sectorID{2}, 0, make([]byte, 20))
+	// replacing the data of a sector shall not double count the size
+	sc.add(sectorID{2}, 0, make([]byte, 30))
+	if size := sc.stats().Size; size != 40 {
+		t.Errorf("cache size not expected: %v != %v", size, 40)
+	}
+	sc.remove(sectorID{1}, sectorID{3})
+	if _, exist := sc.get(sectorID{1}); exist {
+		t.Error("removed sector shall not be cached")
+	}
+	stats := sc.stats()
+	if stats.Sectors != 1 || stats.Size != 30 {
+		t.Errorf("unexpected sectors / size after remove: %v / %v", stats.Sectors, stats.Size)
+	}
+	// removing the folder shall only remove the sectors stored in the folder
+	sc.add(sectorID{4}, 1, make([]byte, 10))
+	sc.removeFolder(1)
+	if _, exist := sc.get(sectorID{4}); exist {
+		t.Error("sector in the removed folder shall not be cached")
+	}
+	if _, exist := sc.get(sectorID{2}); !exist {
+		t.Error("sector in the other folder shall be cached")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// sectorSegmentRoots calculates the merkle roots of the proof segments of the sector data.
+// The roots are concatenated so that they can be cached in the sector cache
+func sectorSegmentRoots(data []byte) []byte {
+	roots := make([]byte, 0, storage.SectorSize/proofSegmentSize*common.HashLength)
+	for offset := uint64(0); offset < uint64(len(data)); offset += proofSegmentSize {
+		root := merkle.Sha256MerkleTreeRoot(data[offset : offset+proofSegmentSize])
+		roots = append(roots, root.Bytes()...)
+	}
+	return roots
+}
+
+// segmentSubtreeRoot implements merkle.SubtreeRoot for the sector on the disk. The subtrees
+// made of whole proof segments are calculated from the segment roots, and only the proof
+// segments containing the smaller subtrees are read from the disk
+type segmentSubtreeRoot struct {
+	roots       []byte
+	readSegment func(index uint64) ([]byte, error)
+
+	leafIndex    uint64
+	segmentIndex uint64
+	segmentData  []byte
+}
+
+// newSegmentSubtreeRoot creates the segmentSubtreeRoot with the segment roots of the sector
+// and the function to read the proof segment from the disk
+func newSegmentSubtreeRoot(roots []byte, readSegment func(index uint64) ([]byte, error)) *segmentSubtreeRoot {
+	return &segmentSubtreeRoot{
+		roots:       roots,
+		readSegment: readSegment,
+	}
+}
+
+// GetSubtreeRoot returns the root of the subtree of the next n leaves
+func (sr *segmentSubtreeRoot) GetSubtreeRoot(n int) ([]byte, error) {
+	numLeaves := uint64(len(sr.roots)/common.HashLength) * proofSegmentLeaves
+	if sr.leafIndex >= numLeaves {
+		return nil, io.EOF
+	}
+	size := uint64(n)
+	if size > numLeaves-sr.leafIndex {
+		size = numLeaves - sr.leafIndex
+	}
+	tree := merkle.NewTree(sha256.New())
+
+	// the subtree is made of whole proof segments
+	if sr.leafIndex%proofSegmentLeaves == 0 && size%proofSegmentLeaves == 0 {
+		start, end := sr.leafIndex/proofSegmentLeaves, (sr.leafIndex+size)/proofSegmentLeaves
+		for i := start; i < end; i++ {
+			root := sr.roots[i*common.HashLength : (i+1)*common.HashLength]
+			if err := tree.PushSubTree(proofSegmentHeight, root); err != nil {
+				return nil, err
+			}
+		}
+		sr.leafIndex += size
+		return tree.Root(), nil
+	}
+
+	// the subtree is within a proof segment, read the segment data
+	index := sr.leafIndex / proofSegmentLeaves
+	if sr.segmentData == nil || sr.segmentIndex != index {
+		data, err := sr.readSegment(index)
+		if err != nil {
+			return nil, err
+		}
+		sr.segmentIndex, sr.segmentData = index, data
+	}
+	start := sr.leafIndex % proofSegmentLeaves * merkle.LeafSize
+	end := start + size*merkle.LeafSize
+	if end > uint64(len(sr.segmentData)) {
+		return nil, fmt.Errorf("subtree of %v leaves at %v crosses the proof segment", size, sr.leafIndex)
+	}
+	for offset := start; offset < end; offset += merkle.LeafSize {
+		tree.PushLeaf(sr.segmentData[offset : offset+merkle.LeafSize])
+	}
+	sr.leafIndex += size
+	return tree.Root(), nil
+}
+
+// Skip skips the next n leaves
+func (sr *segmentSubtreeRoot) Skip(n int) error {
+	sr.leafIndex += uint64(n)
+	return nil
+}
@@ -263,6 +263,8 @@ func (update *shrinkFolderUpdate) relocateSector(manager *storageManager, s *sec
 		_ = update.targetFolder.setFreeSectorSlot(s.index)
 		return sectorRelocation{}, err
 	}
+	// invalidate the cached sector since the sector is moved
+	manager.sectorCache.remove(s.id)
 	relocate = sectorRelocation{
 		ID: s.id,
 		PrevLocation: sectorLocation{
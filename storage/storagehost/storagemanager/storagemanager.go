@@ -31,6 +31,8 @@ type (
 		DeleteSector(sectorRoot common.Hash) error
 		DeleteSectorBatch(sectorRoots []common.Hash) error
 		ReadSector(sectorRoot common.Hash) ([]byte, error)
+		ReadSectorRange(sectorRoot common.Hash, offset, length uint64) ([]byte, error)
+		ReadSectorRangeProof(sectorRoot common.Hash, offset, length uint64) ([]byte, []common.Hash, error)
 		// Functions from user calls
 		AddStorageFolder(path string, size uint64) error
 		DeleteFolder(folderPath string) error
@@ -38,6 +40,7 @@ type (
 		// Status check
 		Folders() []storage.HostFolder
 		AvailableSpace() storage.HostSpace
+		SectorCacheStats() storage.HostSectorCacheStats
 	}
 
 	storageManager struct {
@@ -51,6 +54,12 @@ type (
 		// folders is a in-memory map of the folder
 		folders *folderManager
 
+		// sectorCache is the LRU cache of the sector data read from the disk
+		sectorCache *sectorCache
+
+		// segmentRoots is the LRU cache of the proof segment roots of the sectors
+		segmentRoots *sectorCache
+
 		// utility field
 		log        log.Logger
 		persistDir string
@@ -81,6 +90,8 @@ func newStorageManager(persistDir string, d *disruptor) (sm *storageManager, err
 	}
 	sm.log = log.New("module", "storage manager")
 	sm.persistDir = persistDir
+	sm.sectorCache = newSectorCache(sectorCacheSize)
+	sm.segmentRoots = newSectorCache(segmentRootsCacheSize)
 	// Only initialize the WAL in start
 	sm.tm = &threadmanager.ThreadManager{}
 	sm.disruptor = d
@@ -226,6 +237,7 @@ func (sm *storageManager) DeleteFolder(folderPath string) (err error) {
 		return err
 	}
 	sm.folders.delete(folderPath)
+	sm.sectorCache.removeFolder(sf.id)
 	if err = sf.dataFile.Close(); err != nil {
 		return err
 	}
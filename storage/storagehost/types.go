@@ -117,6 +117,34 @@ var (
 	errInsaneRevision             = errors.New("revision is not necessary")
 	errNotAllowed                 = errors.New("time is not allowed")
 	errTransactionNotConfirmed    = errors.New("transaction not confirmed")
+
+	// errEphemeralAccountNotFound is returned if the ephemeral account does not exist in the host
+	errEphemeralAccountNotFound = errors.New("ephemeral account not found")
+
+	// errEphemeralAccountMaxBalance is returned if funding the ephemeral account will exceed
+	// the max balance allowed
+	errEphemeralAccountMaxBalance = errors.New("ephemeral account balance will exceed the max balance")
+
+	// errEphemeralAccountInsufficientBalance is returned if the ephemeral account does not have
+	// enough balance for the withdrawal
+	errEphemeralAccountInsufficientBalance = errors.New("ephemeral account does not have enough balance")
+
+	// errEphemeralWithdrawalExpired is returned if the withdrawal has expired
+	errEphemeralWithdrawalExpired = errors.New("ephemeral withdrawal has expired")
+
+	// errEphemeralWithdrawalExtremeFuture is returned if the expiry of the withdrawal is too far
+	// ahead of the current block height
+	errEphemeralWithdrawalExtremeFuture = errors.New("ephemeral withdrawal expiry is too far in the future")
+
+	// errEphemeralWithdrawalReplay is returned if the withdrawal nonce is not larger than the
+	// nonce of the last accepted withdrawal
+	errEphemeralWithdrawalReplay = errors.New("ephemeral withdrawal nonce has been used")
+
+	// errEphemeralWithdrawalWrongHost is returned if the withdrawal is not made to this host
+	errEphemeralWithdrawalWrongHost = errors.New("ephemeral withdrawal is made to another host")
+
+	// errHostStopped is returned if the storage host is stopped while waiting for the bandwidth
+	errHostStopped = errors.New("storage host is stopped")
 )
 
 // ExtendErr wraps a error with a string
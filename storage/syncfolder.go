@@ -0,0 +1,76 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SyncDeletePolicy decides how the deletion of a local file in a sync folder is propagated
+// to the mirrored DxFile
+type SyncDeletePolicy uint8
+
+// The deletion policies of a sync folder. The zero value is not a valid policy
+const (
+	// SyncDeleteKeep keeps the DxFile when the local file is deleted
+	SyncDeleteKeep SyncDeletePolicy = iota + 1
+
+	// SyncDeleteRemove deletes the DxFile when the local file is deleted. If versioning is
+	// enabled, the DxFile is archived as a version
+	SyncDeleteRemove
+)
+
+type (
+	// SyncFolderStatus is the status of a local directory mirrored to a DxPath
+	SyncFolderStatus struct {
+		LocalDir     string        `json:"localDir"`
+		DxPath       string        `json:"dxpath"`
+		DeletePolicy string        `json:"deletePolicy"`
+		LastScan     time.Time     `json:"lastScan"`
+		NumSynced    int           `json:"numSynced"`
+		Pending      []string      `json:"pending"`
+		Failed       []SyncFailure `json:"failed"`
+	}
+
+	// SyncFailure is a file in the sync folder failed to be synced. The path is relative to
+	// the local directory of the sync folder
+	SyncFailure struct {
+		Path  string    `json:"path"`
+		Error string    `json:"error"`
+		Time  time.Time `json:"time"`
+	}
+)
+
+// ParseSyncDeletePolicy parse the deletion policy from the string, which is one of
+// keep and delete
+func ParseSyncDeletePolicy(s string) (SyncDeletePolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "keep":
+		return SyncDeleteKeep, nil
+	case "delete":
+		return SyncDeleteRemove, nil
+	default:
+		return 0, fmt.Errorf("unknown deletion policy %v, expect keep or delete", s)
+	}
+}
+
+// Valid checks whether the deletion policy is a known policy
+func (p SyncDeletePolicy) Valid() bool {
+	return p >= SyncDeleteKeep && p <= SyncDeleteRemove
+}
+
+// String returns the string representation of the deletion policy
+func (p SyncDeletePolicy) String() string {
+	switch p {
+	case SyncDeleteKeep:
+		return "keep"
+	case SyncDeleteRemove:
+		return "delete"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(p))
+	}
+}
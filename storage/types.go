@@ -71,6 +71,18 @@ type (
 		SectorAccessPrice      common.BigInt `json:"sectorAccessPrice"`
 		StoragePrice           common.BigInt `json:"storagePrice"`
 		UploadBandwidthPrice   common.BigInt `json:"uploadBandwidthPrice"`
+
+		// bandwidth limits in bytes per second, 0 for unlimited. Upload is the data sent
+		// from the client to the host, and download is the data sent from the host to the client
+		MaxUploadSpeed         int64 `json:"maxUploadSpeed"`
+		MaxDownloadSpeed       int64 `json:"maxDownloadSpeed"`
+		MaxClientUploadSpeed   int64 `json:"maxClientUploadSpeed"`
+		MaxClientDownloadSpeed int64 `json:"maxClientDownloadSpeed"`
+
+		// announcement metadata. Region is the region or country code of the host, and
+		// AnnounceBond is the amount of balance the host declares as the bond
+		Region       string        `json:"region"`
+		AnnounceBond common.BigInt `json:"announceBond"`
 	}
 
 	// HostIntConfigForDisplay is the host internal config for displayed
@@ -92,6 +104,14 @@ type (
 		SectorAccessPrice      string `json:"sectorAccessPrice"`
 		StoragePrice           string `json:"storagePrice"`
 		UploadBandwidthPrice   string `json:"uploadBandwidthPrice"`
+
+		MaxUploadSpeed         string `json:"maxUploadSpeed"`
+		MaxDownloadSpeed       string `json:"maxDownloadSpeed"`
+		MaxClientUploadSpeed   string `json:"maxClientUploadSpeed"`
+		MaxClientDownloadSpeed string `json:"maxClientDownloadSpeed"`
+
+		Region       string `json:"region"`
+		AnnounceBond string `json:"announceBond"`
 	}
 
 	// HostExtConfig make group of host setting to broadcast as object
@@ -148,9 +168,44 @@ type (
 		EnodeURL   string   `json:"enodeurl"`
 		NodePubKey []byte   `json:"nodepubkey"`
 
+		// metadata decoded from the latest host announcement
+		AnnouncementVersion uint64        `json:"announcementVersion"`
+		AnnounceSequence    uint64        `json:"announceSequence"`
+		AnnounceExpiration  uint64        `json:"announceExpiration"`
+		Region              string        `json:"region"`
+		ProtocolVersion     uint64        `json:"protocolVersion"`
+		Features            []string      `json:"features"`
+		Bond                common.BigInt `json:"bond"`
+
+		// performance measured from the data transfers with the storage host
+		Performance HostPerformance `json:"performance"`
+
 		Filtered bool `json:"filtered"`
 	}
 
+	// HostPerformance is the performance of the storage host measured from the actual data
+	// transfers. The latency and throughput are the exponential moving average of the
+	// measurements, along with the rolling statistics of the latest transfers
+	HostPerformance struct {
+		// Latency is the time used to receive the first response from the storage host
+		Latency time.Duration `json:"latency"`
+
+		// throughput of the data transfers in bytes per second
+		UploadThroughput   float64 `json:"uploadThroughput"`
+		DownloadThroughput float64 `json:"downloadThroughput"`
+
+		// number of the data transfers measured
+		UploadTransfers   uint64 `json:"uploadTransfers"`
+		DownloadTransfers uint64 `json:"downloadTransfers"`
+
+		// LatencySamples is the rolling window of the latest measured latencies, which is
+		// used to calculate the latency percentiles
+		LatencySamples []time.Duration `json:"latencySamples"`
+
+		// TransferErrors is the number of failed data transfers grouped by the error type
+		TransferErrors map[string]uint64 `json:"transferErrors"`
+	}
+
 	// HostPoolScans stores a list of host pool scan records
 	HostPoolScans []HostPoolScan
 
@@ -211,10 +266,51 @@ type RentPayment struct {
 // where EnableIPViolation specifies if the host with same network IP addresses will be filtered
 // out or not
 type ClientSetting struct {
-	RentPayment       RentPayment `json:"rentPayment"`
-	EnableIPViolation bool        `json:"enableIPViolation"`
-	MaxUploadSpeed    int64       `json:"maxUploadSpeed"`
-	MaxDownloadSpeed  int64       `json:"maxDownloadSpeed"`
+	RentPayment       RentPayment       `json:"rentPayment"`
+	EnableIPViolation bool              `json:"enableIPViolation"`
+	MaxUploadSpeed    int64             `json:"maxUploadSpeed"`
+	MaxDownloadSpeed  int64             `json:"maxDownloadSpeed"`
+	HostPolicy        HostPolicySetting `json:"hostPolicy"`
+	Evaluator         string            `json:"evaluator"`
+	EvaluationWeights EvaluationWeights `json:"evaluationWeights"`
+	BandwidthSchedule BandwidthSchedule `json:"bandwidthSchedule"`
+}
+
+// EvaluationWeights defines the weights of the factors used by the weighted evaluator to
+// evaluate the storage hosts. The final evaluation is the product of the factor scores, each
+// raised to the power of its weight. Thus the factor with weight 0 is ignored
+type EvaluationWeights struct {
+	Presence         float64 `json:"presence"`
+	Deposit          float64 `json:"deposit"`
+	Interaction      float64 `json:"interaction"`
+	ContractPrice    float64 `json:"contractPrice"`
+	StorageRemaining float64 `json:"storageRemaining"`
+	Uptime           float64 `json:"uptime"`
+	UploadPrice      float64 `json:"uploadPrice"`
+	DownloadPrice    float64 `json:"downloadPrice"`
+	Performance      float64 `json:"performance"`
+}
+
+// HostPolicySetting defines the constraints on the storage hosts that the client forms and renews
+// contracts with. The zero value of each field disables the corresponding constraint
+type HostPolicySetting struct {
+	// Regions is the allow-list of the regions announced by the storage hosts
+	Regions []string `json:"regions"`
+
+	// MaxHostsPerOperator is the max number of storage hosts sharing the same payment address
+	MaxHostsPerOperator uint64 `json:"maxHostsPerOperator"`
+
+	// MaxHostsPerRegion is the max number of storage hosts located in the same region
+	MaxHostsPerRegion uint64 `json:"maxHostsPerRegion"`
+
+	// MinUptime is the minimum uptime rate of the storage host, ranged from 0 to 1
+	MinUptime float64 `json:"minUptime"`
+
+	// price ceilings of the storage host
+	MaxStoragePrice  common.BigInt `json:"maxStoragePrice"`
+	MaxUploadPrice   common.BigInt `json:"maxUploadPrice"`
+	MaxDownloadPrice common.BigInt `json:"maxDownloadPrice"`
+	MaxContractPrice common.BigInt `json:"maxContractPrice"`
 }
 
 type (
@@ -236,10 +332,27 @@ type (
 
 	// ClientSettingAPIDisplay is used for API Configurations Display
 	ClientSettingAPIDisplay struct {
-		RentPayment       RentPaymentAPIDisplay `json:"RentPayment Setting"`
-		EnableIPViolation string                `json:"IP Violation Check Status"`
-		MaxUploadSpeed    string                `json:"Max Upload Speed"`
-		MaxDownloadSpeed  string                `json:"Max Download Speed"`
+		RentPayment        RentPaymentAPIDisplay `json:"RentPayment Setting"`
+		EnableIPViolation  string                `json:"IP Violation Check Status"`
+		MaxUploadSpeed     string                `json:"Max Upload Speed"`
+		MaxDownloadSpeed   string                `json:"Max Download Speed"`
+		HostPolicy         HostPolicyAPIDisplay  `json:"Host Policy Setting"`
+		Evaluator          string                `json:"Host Evaluator"`
+		EvaluationWeights  string                `json:"Evaluation Weights"`
+		BandwidthSchedule  string                `json:"Bandwidth Schedule"`
+		UploadOnlyInWindow string                `json:"Upload Only In Window"`
+	}
+
+	// HostPolicyAPIDisplay is used for API Configurations Display
+	HostPolicyAPIDisplay struct {
+		Regions             string `json:"Regions"`
+		MaxHostsPerOperator string `json:"Max Hosts Per Operator"`
+		MaxHostsPerRegion   string `json:"Max Hosts Per Region"`
+		MinUptime           string `json:"Min Uptime"`
+		MaxStoragePrice     string `json:"Max Storage Price"`
+		MaxUploadPrice      string `json:"Max Upload Price"`
+		MaxDownloadPrice    string `json:"Max Download Price"`
+		MaxContractPrice    string `json:"Max Contract Price"`
 	}
 )
 
@@ -322,6 +435,9 @@ type (
 		DxPath      DxPath
 		ErasureCode erasurecode.ErasureCoder
 		Mode        int
+
+		// CipherCode is the cipher used to encrypt the file, twofish-gcm by default
+		CipherCode uint8
 	}
 
 	// UploadFileInfo provides information about a file
@@ -369,6 +485,19 @@ type (
 		NumStuckSegments uint32 `json:"numStuckSegments"`
 
 		DxPath DxPath `json:"dxPath"`
+
+		Versioning VersioningPolicy `json:"versioning"`
+	}
+
+	// DirListing is a page of the sub directories and files directly under a dxdir,
+	// along with the aggregated information of the dxdir itself
+	DirListing struct {
+		Dir   DirectoryInfo   `json:"dir"`
+		Dirs  []DirectoryInfo `json:"dirs"`
+		Files []FileBriefInfo `json:"files"`
+
+		// Total is the total number of sub directories and files under the dxdir
+		Total int `json:"total"`
 	}
 
 	// HostHealthInfo is the file structure used for DxFile health update.
@@ -392,6 +521,8 @@ type (
 		Redundancy     uint32  `json:"redundancy"`
 		StoredOnDisk   bool    `json:"storedOnDisk"`
 		UploadProgress float64 `json:"uploadProgress"`
+		Priority       string  `json:"priority"`
+		UploadPaused   bool    `json:"uploadPaused"`
 	}
 
 	// FileBriefInfo is the brief info about a DxFile
@@ -400,6 +531,24 @@ type (
 		Status         string  `json:"status"`
 		UploadProgress float64 `json:"uploadProgress"`
 	}
+
+	// VersioningPolicy is the versioning policy of a dxdir. If enabled, the previous version
+	// of a DxFile under the dxdir is retained when the DxFile is overwritten or deleted.
+	// MaxVersions and MaxAge (in seconds) limit the retained versions, and 0 means unlimited
+	VersioningPolicy struct {
+		Enabled     bool   `json:"enabled"`
+		MaxVersions uint64 `json:"maxVersions"`
+		MaxAge      uint64 `json:"maxAge"`
+	}
+
+	// FileVersionInfo is the information of a retained version of a DxFile
+	FileVersionInfo struct {
+		VersionID    string    `json:"versionID"`
+		DxPath       string    `json:"dxpath"`
+		FileSize     uint64    `json:"fileSize"`
+		TimeModify   time.Time `json:"timeModify"`
+		TimeArchived time.Time `json:"timeArchived"`
+	}
 )
 
 type (
@@ -416,6 +565,16 @@ type (
 		UsedSectors  uint64 `json:"usedSectors"`
 		FreeSectors  uint64 `json:"freeSectors"`
 	}
+
+	// HostSectorCacheStats is the statistics of the host sector read cache
+	HostSectorCacheStats struct {
+		Hits      uint64 `json:"hits"`
+		Misses    uint64 `json:"misses"`
+		Evictions uint64 `json:"evictions"`
+		Sectors   uint64 `json:"sectors"`
+		Size      uint64 `json:"size"`
+		Capacity  uint64 `json:"capacity"`
+	}
 )
 
 const (